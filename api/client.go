@@ -1,8 +1,14 @@
 package api
 
 import (
+	"bytes"
+	"context"
 	"crypto/tls"
 	"fmt"
+	"io/ioutil"
+	"math"
+	"math/rand"
+	"net"
 	"net/http"
 	"net/url"
 	"os"
@@ -13,7 +19,7 @@ import (
 
 	"github.com/hashicorp/go-cleanhttp"
 	"github.com/hashicorp/go-rootcerts"
-	"github.com/sethgrid/pester"
+	"golang.org/x/time/rate"
 )
 
 const EnvVaultAddress = "VAULT_ADDR"
@@ -25,6 +31,11 @@ const EnvVaultInsecure = "VAULT_SKIP_VERIFY"
 const EnvVaultTLSServerName = "VAULT_TLS_SERVER_NAME"
 const EnvVaultWrapTTL = "VAULT_WRAP_TTL"
 const EnvVaultMaxRetries = "VAULT_MAX_RETRIES"
+const EnvVaultRateLimit = "VAULT_RATE_LIMIT"
+const EnvVaultSRVLookup = "VAULT_SRV_LOOKUP"
+const EnvVaultClientTimeout = "VAULT_CLIENT_TIMEOUT"
+const EnvVaultHTTPProxy = "VAULT_HTTP_PROXY"
+const EnvVaultNamespace = "VAULT_NAMESPACE"
 
 // WrappingLookupFunc is a function that, given an HTTP verb and a path,
 // returns an optional string duration to be used for response wrapping (e.g.
@@ -38,20 +49,58 @@ type Config struct {
 	// Address is the address of the Vault server. This should be a complete
 	// URL such as "http://vault.example.com". If you need a custom SSL
 	// cert or want to enable insecure mode, you need to specify a custom
-	// HttpClient.
+	// HttpClient. It can also be a comma-separated list of the addresses of
+	// the servers of a cluster, which the client fails over between when
+	// the server it uses can't be reached.
 	Address string
 
+	// SRVLookup, if set, resolves the addresses without a port with the
+	// _vault._tcp SRV records of their host, and the client fails over
+	// between the targets of the records
+	SRVLookup bool
+
 	// HttpClient is the HTTP client to use, which will currently always have the
 	// same values as http.DefaultClient. This is used to control redirect behavior.
 	HttpClient *http.Client
 
 	redirectSetup sync.Once
 
-	// MaxRetries controls the maximum number of times to retry when a 5xx error
-	// occurs. Set to 0 or less to disable retrying.
+	// MaxRetries controls the maximum number of times to retry when a 5xx
+	// or 429 error, or a connection error, occurs. Set to 0 or less to
+	// disable retrying. It can be overridden for a request with its
+	// MaxRetries.
 	MaxRetries int
+
+	// Backoff returns the time waited before each retry, DefaultBackoff if
+	// it is nil
+	Backoff BackoffFunc
+
+	// Limiter, if set, limits the rate of the requests of the client, which
+	// wait for it before being sent
+	Limiter *rate.Limiter
+
+	// WrapTransport, if set, wraps the transport of HttpClient for the
+	// requests of the client, such as to instrument them
+	WrapTransport func(http.RoundTripper) http.RoundTripper
+
+	// Timeout, if set, is the timeout of each try of the requests,
+	// overriding the timeout of HttpClient
+	Timeout time.Duration
+
+	// Proxy, if set, returns the proxy the requests are sent through, nil
+	// for none. DefaultConfig sets it to http.ProxyFromEnvironment, which
+	// honors HTTPS_PROXY, HTTP_PROXY and NO_PROXY.
+	Proxy func(*http.Request) (*url.URL, error)
+
+	// DialContext, if set, opens the connections of the requests to the
+	// servers, or to the proxy, instead of the dialer of HttpClient
+	DialContext func(ctx context.Context, network, addr string) (net.Conn, error)
 }
 
+// BackoffFunc returns the time to wait before the retry of a request, the
+// first retry being 1
+type BackoffFunc func(retry int) time.Duration
+
 // TLSConfig contains the parameters needed to configure TLS on the HTTP client
 // used to communicate with Vault.
 type TLSConfig struct {
@@ -87,8 +136,10 @@ func DefaultConfig() *Config {
 		Address: "https://127.0.0.1:8200",
 
 		HttpClient: cleanhttp.DefaultClient(),
+		Timeout:    time.Second * 60,
+		Proxy:      http.ProxyFromEnvironment,
 	}
-	config.HttpClient.Timeout = time.Second * 60
+	config.HttpClient.Timeout = config.Timeout
 	transport := config.HttpClient.Transport.(*http.Transport)
 	transport.TLSHandshakeTimeout = 10 * time.Second
 	transport.TLSClientConfig = &tls.Config{
@@ -99,7 +150,8 @@ func DefaultConfig() *Config {
 		config.Address = v
 	}
 
-	config.MaxRetries = pester.DefaultClient.MaxRetries
+	config.MaxRetries = 2
+	config.Backoff = DefaultBackoff
 
 	return config
 }
@@ -111,6 +163,12 @@ func (c *Config) ConfigureTLS(t *TLSConfig) error {
 		return fmt.Errorf("config HTTP Client must be set")
 	}
 
+	return configureTLS(c.HttpClient.Transport.(*http.Transport).TLSClientConfig, t)
+}
+
+// configureTLS applies the TLS configuration to the TLS configuration of a
+// transport
+func configureTLS(clientTLSConfig *tls.Config, t *TLSConfig) error {
 	var clientCert tls.Certificate
 	foundClientCert := false
 	if t.CACert != "" || t.CAPath != "" || t.ClientCert != "" || t.ClientKey != "" || t.Insecure {
@@ -126,7 +184,6 @@ func (c *Config) ConfigureTLS(t *TLSConfig) error {
 		}
 	}
 
-	clientTLSConfig := c.HttpClient.Transport.(*http.Transport).TLSClientConfig
 	rootConfig := &rootcerts.Config{
 		CAFile: t.CACert,
 		CAPath: t.CAPath,
@@ -159,6 +216,10 @@ func (c *Config) ReadEnvironment() error {
 	var envInsecure bool
 	var envTLSServerName string
 	var envMaxRetries *uint64
+	var envLimiter *rate.Limiter
+	var envSRVLookup bool
+	var envTimeout time.Duration
+	var envProxy *url.URL
 
 	// Parse the environment variables
 	if v := os.Getenv(EnvVaultAddress); v != "" {
@@ -171,6 +232,27 @@ func (c *Config) ReadEnvironment() error {
 		}
 		envMaxRetries = &maxRetries
 	}
+	if v := os.Getenv(EnvVaultRateLimit); v != "" {
+		limiter, err := parseRateLimit(v)
+		if err != nil {
+			return err
+		}
+		envLimiter = limiter
+	}
+	if v := os.Getenv(EnvVaultClientTimeout); v != "" {
+		timeout, err := parseTimeout(v)
+		if err != nil {
+			return err
+		}
+		envTimeout = timeout
+	}
+	if v := os.Getenv(EnvVaultHTTPProxy); v != "" {
+		proxy, err := url.Parse(v)
+		if err != nil || proxy.Host == "" {
+			return fmt.Errorf("Could not parse %s", EnvVaultHTTPProxy)
+		}
+		envProxy = proxy
+	}
 	if v := os.Getenv(EnvVaultCACert); v != "" {
 		envCACert = v
 	}
@@ -193,6 +275,13 @@ func (c *Config) ReadEnvironment() error {
 	if v := os.Getenv(EnvVaultTLSServerName); v != "" {
 		envTLSServerName = v
 	}
+	if v := os.Getenv(EnvVaultSRVLookup); v != "" {
+		var err error
+		envSRVLookup, err = strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("Could not parse %s", EnvVaultSRVLookup)
+		}
+	}
 
 	// Configure the HTTP clients TLS configuration.
 	t := &TLSConfig{
@@ -212,18 +301,82 @@ func (c *Config) ReadEnvironment() error {
 	}
 
 	if envMaxRetries != nil {
-		c.MaxRetries = int(*envMaxRetries) + 1
+		c.MaxRetries = int(*envMaxRetries)
+	}
+
+	if envLimiter != nil {
+		c.Limiter = envLimiter
+	}
+
+	if envSRVLookup {
+		c.SRVLookup = true
+	}
+
+	if envTimeout != 0 {
+		c.Timeout = envTimeout
+	}
+
+	if envProxy != nil {
+		c.Proxy = http.ProxyURL(envProxy)
 	}
 
 	return nil
 }
 
+// parseTimeout parses the timeout of VAULT_CLIENT_TIMEOUT, a duration such
+// as "30s" or a number of seconds
+func parseTimeout(v string) (time.Duration, error) {
+	if seconds, err := strconv.ParseUint(v, 10, 32); err == nil {
+		return time.Duration(seconds) * time.Second, nil
+	}
+	timeout, err := time.ParseDuration(v)
+	if err != nil || timeout < 0 {
+		return 0, fmt.Errorf("Could not parse %s: invalid timeout %q", EnvVaultClientTimeout, v)
+	}
+	return timeout, nil
+}
+
+// parseRateLimit parses the rate limit of VAULT_RATE_LIMIT, a number of
+// requests per second optionally followed by the burst, such as "10:20". The
+// burst is the rate by default.
+func parseRateLimit(v string) (*rate.Limiter, error) {
+	rateStr, burstStr := v, ""
+	i := strings.Index(v, ":")
+	if i >= 0 {
+		rateStr, burstStr = v[:i], v[i+1:]
+	}
+
+	limit, err := strconv.ParseFloat(rateStr, 64)
+	if err != nil || limit <= 0 {
+		return nil, fmt.Errorf("Could not parse %s: invalid rate %q", EnvVaultRateLimit, rateStr)
+	}
+	burst := int(math.Ceil(limit))
+	if i >= 0 {
+		burst, err = strconv.Atoi(burstStr)
+		if err != nil || burst <= 0 {
+			return nil, fmt.Errorf("Could not parse %s: invalid burst %q", EnvVaultRateLimit, burstStr)
+		}
+	}
+	return rate.NewLimiter(rate.Limit(limit), burst), nil
+}
+
 // Client is the client to the Vault API. Create a client with
 // NewClient.
 type Client struct {
-	addr               *url.URL
+	// modifyLock protects the fields which can be modified while the client
+	// is in use. addr is the address the requests are sent to, one of addrs,
+	// and httpClient sends them with transport, wrapped by WrapTransport.
+	modifyLock sync.RWMutex
+	addr       *url.URL
+	addrs      []*url.URL
+	httpClient *http.Client
+	transport  *http.Transport
+	limiter    *rate.Limiter
+	timeout    time.Duration
+
 	config             *Config
 	token              string
+	namespace          string
 	wrappingLookupFunc WrappingLookupFunc
 }
 
@@ -231,7 +384,8 @@ type Client struct {
 //
 // If the environment variable `VAULT_TOKEN` is present, the token will be
 // automatically added to the client. Otherwise, you must manually call
-// `SetToken()`.
+// `SetToken()`. Likewise, the namespace of the requests is read from the
+// environment variable `VAULT_NAMESPACE`.
 func NewClient(c *Config) (*Client, error) {
 	if c == nil {
 		c = DefaultConfig()
@@ -240,7 +394,7 @@ func NewClient(c *Config) (*Client, error) {
 		}
 	}
 
-	u, err := url.Parse(c.Address)
+	addrs, err := parseAddresses(c.Address, c.SRVLookup)
 	if err != nil {
 		return nil, err
 	}
@@ -249,6 +403,25 @@ func NewClient(c *Config) (*Client, error) {
 		c.HttpClient = DefaultConfig().HttpClient
 	}
 
+	// The transport is replaced when the TLS configuration is, and it can
+	// only be configured if it is an http.Transport
+	transport, _ := c.HttpClient.Transport.(*http.Transport)
+	if c.Proxy != nil || c.DialContext != nil {
+		if transport == nil {
+			return nil, fmt.Errorf("the HTTP client transport doesn't support proxy or dialer configuration")
+		}
+		if c.Proxy != nil {
+			transport.Proxy = c.Proxy
+		}
+		if c.DialContext != nil {
+			transport.Dial = nil
+			transport.DialContext = c.DialContext
+		}
+	}
+	if err := configureUnixSocket(transport, addrs[0]); err != nil {
+		return nil, err
+	}
+
 	redirFunc := func() {
 		// Ensure redirects are not automatically followed
 		// Note that this is sane for the API client as it has its own
@@ -256,8 +429,8 @@ func NewClient(c *Config) (*Client, error) {
 		// but in e.g. http_test actual redirect handling is necessary
 		c.HttpClient.CheckRedirect = func(req *http.Request, via []*http.Request) error {
 			// Returning this value causes the Go net library to not close the
-			// response body and nil out the error. Otherwise pester tries
-			// three times on every redirect because it sees an error from this
+			// response body and nil out the error. Otherwise the request is
+			// retried on every redirect because it sees an error from this
 			// function being passed through.
 			return http.ErrUseLastResponse
 		}
@@ -266,26 +439,158 @@ func NewClient(c *Config) (*Client, error) {
 	c.redirectSetup.Do(redirFunc)
 
 	client := &Client{
-		addr:   u,
-		config: c,
+		addr:      addrs[0],
+		addrs:     addrs,
+		transport: transport,
+		limiter:   c.Limiter,
+		timeout:   c.Timeout,
+		config:    c,
 	}
+	client.httpClient = client.newHTTPClient(c.HttpClient.Transport)
 
 	if token := os.Getenv("VAULT_TOKEN"); token != "" {
 		client.SetToken(token)
 	}
 
+	if namespace := os.Getenv(EnvVaultNamespace); namespace != "" {
+		client.SetNamespace(namespace)
+	}
+
 	return client, nil
 }
 
 // Sets the address of Vault in the client. The format of address should be
-// "<Scheme>://<Host>:<Port>". Setting this on a client will override the
-// value of VAULT_ADDR environment variable.
+// "<Scheme>://<Host>:<Port>", or a comma-separated list of such addresses.
+// Setting this on a client will override the value of VAULT_ADDR environment
+// variable.
 func (c *Client) SetAddress(addr string) error {
-	var err error
-	if c.addr, err = url.Parse(addr); err != nil {
+	addrs, err := parseAddresses(addr, c.config.SRVLookup)
+	if err != nil {
 		return fmt.Errorf("failed to set address: %v", err)
 	}
 
+	c.modifyLock.Lock()
+	defer c.modifyLock.Unlock()
+	if err := configureUnixSocket(c.transport, addrs[0]); err != nil {
+		return fmt.Errorf("failed to set address: %v", err)
+	}
+	c.addr = addrs[0]
+	c.addrs = addrs
+
+	return nil
+}
+
+// Address returns the address of Vault the client sends its requests to,
+// the address it failed over to if it was given several.
+func (c *Client) Address() string {
+	c.modifyLock.RLock()
+	defer c.modifyLock.RUnlock()
+	return c.addr.String()
+}
+
+// lookupSRV is net.LookupSRV, replaced by the tests
+var lookupSRV = net.LookupSRV
+
+// parseAddresses parses the comma-separated addresses of Vault. When
+// srvLookup is set, the addresses without a port are replaced with the
+// targets of the _vault._tcp SRV records of their host, by priority.
+func parseAddresses(addr string, srvLookup bool) ([]*url.URL, error) {
+	var addrs []*url.URL
+	for _, a := range strings.Split(addr, ",") {
+		u, err := url.Parse(strings.TrimSpace(a))
+		if err != nil {
+			return nil, err
+		}
+		if !srvLookup || u.Scheme == "unix" {
+			addrs = append(addrs, u)
+			continue
+		}
+		if _, _, err := net.SplitHostPort(u.Host); err == nil {
+			addrs = append(addrs, u)
+			continue
+		}
+
+		// The host has no port, but IPv6 addresses are still bracketed
+		host := strings.TrimSuffix(strings.TrimPrefix(u.Host, "["), "]")
+		_, records, err := lookupSRV("vault", "tcp", host)
+		if err != nil {
+			return nil, fmt.Errorf("failed to look up the SRV records of %s: %v", u.Host, err)
+		}
+		if len(records) == 0 {
+			return nil, fmt.Errorf("no SRV records found for %s", u.Host)
+		}
+		for _, record := range records {
+			target := strings.TrimSuffix(record.Target, ".")
+			addrs = append(addrs, &url.URL{
+				Scheme: u.Scheme,
+				Host:   net.JoinHostPort(target, strconv.Itoa(int(record.Port))),
+				Path:   u.Path,
+			})
+		}
+	}
+
+	for _, u := range addrs {
+		if u.Scheme == "unix" && len(addrs) > 1 {
+			return nil, fmt.Errorf("a unix socket address can't be used with other addresses")
+		}
+	}
+	return addrs, nil
+}
+
+// failover moves the request to the next address of Vault if the address it
+// was sent to is the current one, so that the following requests are sent
+// there too, or to the current address if another request already failed
+// over. It returns false once the request failed over to all the addresses,
+// or if it wasn't sent to one of them, such as the active node a standby
+// redirected it to.
+func (c *Client) failover(r *Request, failovers int) bool {
+	c.modifyLock.Lock()
+	defer c.modifyLock.Unlock()
+
+	if failovers >= len(c.addrs)-1 {
+		return false
+	}
+
+	index := -1
+	for i, u := range c.addrs {
+		if u.Scheme == r.URL.Scheme && u.Host == r.URL.Host {
+			index = i
+		}
+	}
+	if index < 0 {
+		return false
+	}
+	if c.addrs[index] == c.addr {
+		c.addr = c.addrs[(index+1)%len(c.addrs)]
+	}
+
+	r.URL.Scheme = c.addr.Scheme
+	r.URL.Host = c.addr.Host
+	return true
+}
+
+// configureUnixSocket makes the transport connect to the unix socket of an
+// address such as unix:///var/run/vault.sock, and rewrites the address to the
+// HTTP URL the requests are made to
+func configureUnixSocket(transport *http.Transport, u *url.URL) error {
+	if u.Scheme != "unix" {
+		return nil
+	}
+
+	if transport == nil {
+		return fmt.Errorf("the HTTP client transport doesn't support unix sockets")
+	}
+
+	socket := u.Path
+	dialer := &net.Dialer{}
+	transport.Dial = nil
+	transport.DialContext = func(ctx context.Context, _, _ string) (net.Conn, error) {
+		return dialer.DialContext(ctx, "unix", socket)
+	}
+
+	u.Scheme = "http"
+	u.Host = "localhost"
+	u.Path = ""
 	return nil
 }
 
@@ -312,18 +617,171 @@ func (c *Client) ClearToken() {
 	c.token = ""
 }
 
+// Namespace returns the path of the namespace the requests of this client
+// are made in. It will return the empty string for the root namespace.
+func (c *Client) Namespace() string {
+	return c.namespace
+}
+
+// SetNamespace sets the path of the namespace the requests of this client
+// are made in, such as "team-a/dev".
+func (c *Client) SetNamespace(namespace string) {
+	c.namespace = namespace
+}
+
+// ClearNamespace makes the requests of this client in the root namespace.
+func (c *Client) ClearNamespace() {
+	c.namespace = ""
+}
+
+// SetLimiter limits the rate of the requests of the client to rateLimit
+// requests per second, with bursts of up to burst requests. A rateLimit of
+// zero removes the limit.
+func (c *Client) SetLimiter(rateLimit float64, burst int) {
+	c.modifyLock.Lock()
+	defer c.modifyLock.Unlock()
+
+	if rateLimit == 0 {
+		c.limiter = nil
+		return
+	}
+	c.limiter = rate.NewLimiter(rate.Limit(rateLimit), burst)
+}
+
+// SetClientTimeout sets the timeout of each try of the requests of the
+// client. A timeout of zero falls back to the timeout of the HTTP client of
+// the configuration.
+func (c *Client) SetClientTimeout(timeout time.Duration) {
+	c.modifyLock.Lock()
+	defer c.modifyLock.Unlock()
+
+	// The transport is only replaced if it is an http.Transport
+	var transport http.RoundTripper = c.transport
+	if c.transport == nil {
+		transport = c.config.HttpClient.Transport
+	}
+	c.timeout = timeout
+	c.httpClient = c.newHTTPClient(transport)
+}
+
+// SetTLSConfig applies the TLS configuration to the client without
+// recreating it, such as when its client certificate is rotated. The CA
+// certificates, client certificate and server name which aren't set are
+// kept. The requests in progress complete with the previous configuration.
+func (c *Client) SetTLSConfig(t *TLSConfig) error {
+	c.modifyLock.Lock()
+	defer c.modifyLock.Unlock()
+
+	if c.transport == nil {
+		return fmt.Errorf("the HTTP client transport doesn't support TLS configuration")
+	}
+
+	// The transport is cloned, as it can't be modified while in use
+	transport := cloneTransport(c.transport)
+	if transport.TLSClientConfig == nil {
+		transport.TLSClientConfig = &tls.Config{}
+	}
+	if err := configureTLS(transport.TLSClientConfig, t); err != nil {
+		return err
+	}
+
+	c.transport.CloseIdleConnections()
+	c.transport = transport
+	c.httpClient = c.newHTTPClient(transport)
+	return nil
+}
+
+// cloneTransport returns a copy of the transport, with a copy of its TLS
+// configuration, without its connections
+func cloneTransport(t *http.Transport) *http.Transport {
+	clone := &http.Transport{
+		Proxy:                  t.Proxy,
+		DialContext:            t.DialContext,
+		Dial:                   t.Dial,
+		DialTLS:                t.DialTLS,
+		TLSHandshakeTimeout:    t.TLSHandshakeTimeout,
+		DisableKeepAlives:      t.DisableKeepAlives,
+		DisableCompression:     t.DisableCompression,
+		MaxIdleConns:           t.MaxIdleConns,
+		MaxIdleConnsPerHost:    t.MaxIdleConnsPerHost,
+		IdleConnTimeout:        t.IdleConnTimeout,
+		ResponseHeaderTimeout:  t.ResponseHeaderTimeout,
+		ExpectContinueTimeout:  t.ExpectContinueTimeout,
+		MaxResponseHeaderBytes: t.MaxResponseHeaderBytes,
+	}
+	if t.TLSClientConfig != nil {
+		clone.TLSClientConfig = cloneTLSConfig(t.TLSClientConfig)
+	}
+	if t.TLSNextProto != nil {
+		clone.TLSNextProto = make(map[string]func(string, *tls.Conn) http.RoundTripper, len(t.TLSNextProto))
+		for k, v := range t.TLSNextProto {
+			clone.TLSNextProto[k] = v
+		}
+	}
+	return clone
+}
+
+// cloneTLSConfig returns a copy of the client settings of the TLS
+// configuration
+func cloneTLSConfig(c *tls.Config) *tls.Config {
+	return &tls.Config{
+		Rand:                        c.Rand,
+		Time:                        c.Time,
+		Certificates:                c.Certificates,
+		RootCAs:                     c.RootCAs,
+		NextProtos:                  c.NextProtos,
+		ServerName:                  c.ServerName,
+		InsecureSkipVerify:          c.InsecureSkipVerify,
+		CipherSuites:                c.CipherSuites,
+		SessionTicketsDisabled:      c.SessionTicketsDisabled,
+		ClientSessionCache:          c.ClientSessionCache,
+		MinVersion:                  c.MinVersion,
+		MaxVersion:                  c.MaxVersion,
+		CurvePreferences:            c.CurvePreferences,
+		DynamicRecordSizingDisabled: c.DynamicRecordSizingDisabled,
+		Renegotiation:               c.Renegotiation,
+	}
+}
+
+// newHTTPClient returns a copy of the HTTP client of the configuration
+// sending the requests with the transport, wrapped by WrapTransport, within
+// the timeout of the client
+func (c *Client) newHTTPClient(transport http.RoundTripper) *http.Client {
+	httpClient := *c.config.HttpClient
+	httpClient.Transport = transport
+	if c.config.WrapTransport != nil {
+		httpClient.Transport = c.config.WrapTransport(transport)
+	}
+	if c.timeout != 0 {
+		httpClient.Timeout = c.timeout
+	}
+	return &httpClient
+}
+
+// currentHTTPClient returns the HTTP client of the requests
+func (c *Client) currentHTTPClient() *http.Client {
+	c.modifyLock.RLock()
+	defer c.modifyLock.RUnlock()
+	return c.httpClient
+}
+
 // NewRequest creates a new raw request object to query the Vault server
 // configured for this client. This is an advanced method and generally
 // doesn't need to be called externally.
 func (c *Client) NewRequest(method, path string) *Request {
+	c.modifyLock.RLock()
+	addr := c.addr
+	c.modifyLock.RUnlock()
+
 	req := &Request{
 		Method: method,
 		URL: &url.URL{
-			Scheme: c.addr.Scheme,
-			Host:   c.addr.Host,
+			Scheme: addr.Scheme,
+			Host:   addr.Host,
 			Path:   path,
 		},
 		ClientToken: c.token,
+		Namespace:   c.namespace,
 		Params:      make(map[string][]string),
 	}
 
@@ -347,22 +805,86 @@ func (c *Client) NewRequest(method, path string) *Request {
 // a Vault server not configured with this client. This is an advanced operation
 // that generally won't need to be called externally.
 func (c *Client) RawRequest(r *Request) (*Response, error) {
+	return c.RawRequestWithContext(context.Background(), r)
+}
+
+// RawRequestWithContext performs the raw request given, which is canceled
+// when the context is done. This is an advanced operation that generally
+// won't need to be called externally.
+func (c *Client) RawRequestWithContext(ctx context.Context, r *Request) (*Response, error) {
+	return c.rawRequest(ctx, r, c.currentHTTPClient())
+}
+
+// rawRequest performs the request with the HTTP client, retrying it on the
+// errors which may be transient
+func (c *Client) rawRequest(ctx context.Context, r *Request, httpClient *http.Client) (*Response, error) {
+	// The body is buffered so that it can be sent again when the request is
+	// retried or redirected
+	var body []byte
+	if r.Body != nil {
+		var err error
+		if body, err = ioutil.ReadAll(r.Body); err != nil {
+			return nil, err
+		}
+	}
+
+	maxRetries := c.config.MaxRetries
+	if r.MaxRetries != nil {
+		maxRetries = *r.MaxRetries
+	}
+	backoff := c.config.Backoff
+	if backoff == nil {
+		backoff = DefaultBackoff
+	}
+
 	redirectCount := 0
+	retry := 0
+	failovers := 0
 START:
+	c.modifyLock.RLock()
+	limiter := c.limiter
+	c.modifyLock.RUnlock()
+	if limiter != nil {
+		if err := limiter.Wait(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	if r.Body != nil {
+		r.Body = bytes.NewReader(body)
+	}
 	req, err := r.ToHTTP()
 	if err != nil {
 		return nil, err
 	}
-
-	client := pester.NewExtendedClient(c.config.HttpClient)
-	client.Backoff = pester.LinearJitterBackoff
-	client.MaxRetries = c.config.MaxRetries
+	req = req.WithContext(ctx)
 
 	var result *Response
-	resp, err := client.Do(req)
+	resp, err := httpClient.Do(req)
 	if resp != nil {
 		result = &Response{Response: resp}
 	}
+
+	// The other servers are tried right away when the server can't be
+	// reached, before retrying
+	if err != nil && ctx.Err() == nil && c.failover(r, failovers) {
+		failovers++
+		goto START
+	}
+
+	if retry < maxRetries && shouldRetry(resp, err) && ctx.Err() == nil {
+		if resp != nil {
+			resp.Body.Close()
+		}
+		retry++
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(backoff(retry)):
+		}
+		goto START
+	}
+
 	if err != nil {
 		if strings.Contains(err.Error(), "tls: oversized") {
 			err = fmt.Errorf(
@@ -396,11 +918,6 @@ START:
 		// Update the request
 		r.URL = respLoc
 
-		// Reset the request body if any
-		if err := r.ResetJSONBody(); err != nil {
-			return result, err
-		}
-
 		// Retry the request
 		redirectCount++
 		goto START
@@ -412,3 +929,28 @@ START:
 
 	return result, nil
 }
+
+// shouldRetry returns whether a request which failed with the error or the
+// response may succeed when retried: the connection errors, the server
+// errors such as those of a standby losing its active node, and the requests
+// which were rate limited
+func shouldRetry(resp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	return resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests
+}
+
+// DefaultBackoff is the BackoffFunc of the clients by default. The first
+// retry waits 500ms, and the wait doubles at each retry up to 30 seconds,
+// with a random jitter of up to a third of the wait.
+func DefaultBackoff(retry int) time.Duration {
+	wait := 500 * time.Millisecond
+	for i := 1; i < retry && wait < 30*time.Second; i++ {
+		wait *= 2
+	}
+	if wait > 30*time.Second {
+		wait = 30 * time.Second
+	}
+	return wait - time.Duration(rand.Int63n(int64(wait/3)+1))
+}
@@ -2,10 +2,20 @@ package api
 
 import (
 	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
 	"io"
+	"io/ioutil"
+	"net"
 	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"os"
+	"path/filepath"
+	"reflect"
 	"testing"
+	"time"
 )
 
 func init() {
@@ -123,6 +133,256 @@ func TestClientRedirect(t *testing.T) {
 	}
 }
 
+func TestClientContext(t *testing.T) {
+	doneCh := make(chan struct{})
+	defer close(doneCh)
+	handler := func(w http.ResponseWriter, req *http.Request) {
+		select {
+		case <-doneCh:
+		case <-req.Context().Done():
+		}
+	}
+	config, ln := testHTTPServer(t, http.HandlerFunc(handler))
+	defer ln.Close()
+
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	// The request to the hung server is canceled at the deadline
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if _, err := client.Logical().ReadWithContext(ctx, "secret/foo"); err == nil {
+		t.Fatal("expected error")
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Fatalf("request wasn't canceled: %s", elapsed)
+	}
+}
+
+func TestClientRetry(t *testing.T) {
+	var requests int
+	handler := func(w http.ResponseWriter, req *http.Request) {
+		requests++
+		body, _ := ioutil.ReadAll(req.Body)
+		if string(body) != "{\"foo\":\"bar\"}\n" {
+			t.Errorf("bad body: %q", body)
+		}
+		switch requests {
+		case 1:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case 2:
+			w.WriteHeader(http.StatusTooManyRequests)
+		default:
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}
+	config, ln := testHTTPServer(t, http.HandlerFunc(handler))
+	defer ln.Close()
+
+	config.Backoff = func(retry int) time.Duration {
+		return time.Millisecond
+	}
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	// The 5xx and 429 errors are retried with the body
+	if _, err := client.Logical().Write("secret/foo", map[string]interface{}{"foo": "bar"}); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if requests != 3 {
+		t.Fatalf("bad: %d", requests)
+	}
+
+	// The retries can be disabled for a request
+	requests = 0
+	r := client.NewRequest("PUT", "/v1/secret/foo")
+	r.MaxRetries = new(int)
+	if err := r.SetJSONBody(map[string]interface{}{"foo": "bar"}); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if _, err := client.RawRequest(r); err == nil {
+		t.Fatal("expected error")
+	}
+	if requests != 1 {
+		t.Fatalf("bad: %d", requests)
+	}
+}
+
+func TestClientLimiter(t *testing.T) {
+	handler := func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}
+	config, ln := testHTTPServer(t, http.HandlerFunc(handler))
+	defer ln.Close()
+
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	// The requests after the burst wait for the limiter
+	client.SetLimiter(20, 2)
+	start := time.Now()
+	for i := 0; i < 4; i++ {
+		if _, err := client.RawRequest(client.NewRequest("GET", "/v1/sys/health")); err != nil {
+			t.Fatalf("err: %s", err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 90*time.Millisecond {
+		t.Fatalf("requests weren't limited: %s", elapsed)
+	}
+
+	// A request waiting past its deadline fails
+	client.SetLimiter(0.1, 1)
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if _, err := client.RawRequestWithContext(ctx, client.NewRequest("GET", "/v1/sys/health")); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if _, err := client.RawRequestWithContext(ctx, client.NewRequest("GET", "/v1/sys/health")); err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+func TestParseRateLimit(t *testing.T) {
+	cases := map[string]struct {
+		limit float64
+		burst int
+	}{
+		"10":    {10, 10},
+		"0.5":   {0.5, 1},
+		"10:20": {10, 20},
+	}
+	for v, expected := range cases {
+		limiter, err := parseRateLimit(v)
+		if err != nil {
+			t.Fatalf("%s: %s", v, err)
+		}
+		if float64(limiter.Limit()) != expected.limit || limiter.Burst() != expected.burst {
+			t.Fatalf("%s: bad: %v %d", v, limiter.Limit(), limiter.Burst())
+		}
+	}
+
+	for _, v := range []string{"", "foo", "-1", "10:", "10:0"} {
+		if _, err := parseRateLimit(v); err == nil {
+			t.Fatalf("%s: expected error", v)
+		}
+	}
+}
+
+func TestDefaultBackoff(t *testing.T) {
+	for retry, expected := range []time.Duration{500 * time.Millisecond, time.Second, 2 * time.Second} {
+		wait := DefaultBackoff(retry + 1)
+		if wait > expected || wait < expected*2/3 {
+			t.Fatalf("bad wait of retry %d: %s", retry+1, wait)
+		}
+	}
+	if wait := DefaultBackoff(20); wait > 30*time.Second || wait < 20*time.Second {
+		t.Fatalf("bad: %s", wait)
+	}
+}
+
+func TestClientFailover(t *testing.T) {
+	handler := func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}
+	config, ln := testHTTPServer(t, http.HandlerFunc(handler))
+	defer ln.Close()
+
+	// An address nothing listens on
+	down, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	downAddr := "http://" + down.Addr().String()
+	down.Close()
+
+	config.Address = downAddr + ", " + config.Address
+	config.MaxRetries = 0
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if client.Address() != downAddr {
+		t.Fatalf("bad: %s", client.Address())
+	}
+
+	// The request fails over to the server which is up, and the following
+	// requests are sent there
+	for i := 0; i < 2; i++ {
+		if _, err := client.RawRequest(client.NewRequest("GET", "/v1/sys/health")); err != nil {
+			t.Fatalf("err: %s", err)
+		}
+		if client.Address() != "http://"+ln.Addr().String() {
+			t.Fatalf("bad: %s", client.Address())
+		}
+	}
+
+	// The request fails once all the servers are down
+	if err := client.SetAddress(downAddr + "," + downAddr); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if _, err := client.RawRequest(client.NewRequest("GET", "/v1/sys/health")); err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+func TestParseAddresses(t *testing.T) {
+	defer func(f func(string, string, string) (string, []*net.SRV, error)) {
+		lookupSRV = f
+	}(lookupSRV)
+	lookupSRV = func(service, proto, name string) (string, []*net.SRV, error) {
+		if service != "vault" || proto != "tcp" || name != "vault.example.com" {
+			return "", nil, fmt.Errorf("no such host")
+		}
+		return "", []*net.SRV{
+			{Target: "vault1.example.com.", Port: 8200},
+			{Target: "vault2.example.com.", Port: 8300},
+		}, nil
+	}
+
+	addrs, err := parseAddresses("https://vault.example.com,https://vault.example.com:8200", true)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	var actual []string
+	for _, u := range addrs {
+		actual = append(actual, u.String())
+	}
+	expected := []string{
+		"https://vault1.example.com:8200",
+		"https://vault2.example.com:8300",
+		"https://vault.example.com:8200",
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("bad: %#v", actual)
+	}
+
+	// The addresses are only resolved with SRV lookups
+	addrs, err = parseAddresses("https://vault.example.com", false)
+	if err != nil || len(addrs) != 1 || addrs[0].Host != "vault.example.com" {
+		t.Fatalf("bad: %#v, %v", addrs, err)
+	}
+
+	// The addresses with a port aren't resolved, IPv6 ones included
+	addrs, err = parseAddresses("https://[::1]:8200", true)
+	if err != nil || len(addrs) != 1 || addrs[0].Host != "[::1]:8200" {
+		t.Fatalf("bad: %#v, %v", addrs, err)
+	}
+
+	if _, err := parseAddresses("https://unknown.example.com", true); err == nil {
+		t.Fatal("expected error")
+	}
+	if _, err := parseAddresses("unix:///var/run/vault.sock,https://vault.example.com:8200", false); err == nil {
+		t.Fatal("expected error")
+	}
+}
+
 func TestClientEnvSettings(t *testing.T) {
 	cwd, _ := os.Getwd()
 	oldCACert := os.Getenv(EnvVaultCACert)
@@ -131,18 +391,24 @@ func TestClientEnvSettings(t *testing.T) {
 	oldClientKey := os.Getenv(EnvVaultClientKey)
 	oldSkipVerify := os.Getenv(EnvVaultInsecure)
 	oldMaxRetries := os.Getenv(EnvVaultMaxRetries)
+	oldClientTimeout := os.Getenv(EnvVaultClientTimeout)
+	oldHTTPProxy := os.Getenv(EnvVaultHTTPProxy)
 	os.Setenv(EnvVaultCACert, cwd+"/test-fixtures/keys/cert.pem")
 	os.Setenv(EnvVaultCAPath, cwd+"/test-fixtures/keys")
 	os.Setenv(EnvVaultClientCert, cwd+"/test-fixtures/keys/cert.pem")
 	os.Setenv(EnvVaultClientKey, cwd+"/test-fixtures/keys/key.pem")
 	os.Setenv(EnvVaultInsecure, "true")
 	os.Setenv(EnvVaultMaxRetries, "5")
+	os.Setenv(EnvVaultClientTimeout, "10s")
+	os.Setenv(EnvVaultHTTPProxy, "http://proxy.example.com:3128")
 	defer os.Setenv(EnvVaultCACert, oldCACert)
 	defer os.Setenv(EnvVaultCAPath, oldCAPath)
 	defer os.Setenv(EnvVaultClientCert, oldClientCert)
 	defer os.Setenv(EnvVaultClientKey, oldClientKey)
 	defer os.Setenv(EnvVaultInsecure, oldSkipVerify)
 	defer os.Setenv(EnvVaultMaxRetries, oldMaxRetries)
+	defer os.Setenv(EnvVaultClientTimeout, oldClientTimeout)
+	defer os.Setenv(EnvVaultHTTPProxy, oldHTTPProxy)
 
 	config := DefaultConfig()
 	if err := config.ReadEnvironment(); err != nil {
@@ -159,4 +425,197 @@ func TestClientEnvSettings(t *testing.T) {
 	if tlsConfig.InsecureSkipVerify != true {
 		t.Fatalf("bad: %v", tlsConfig.InsecureSkipVerify)
 	}
+	if config.MaxRetries != 5 {
+		t.Fatalf("bad: %d", config.MaxRetries)
+	}
+	if config.Timeout != 10*time.Second {
+		t.Fatalf("bad: %s", config.Timeout)
+	}
+	req, _ := http.NewRequest("GET", "https://vault.example.com:8200", nil)
+	if proxy, err := config.Proxy(req); err != nil || proxy.String() != "http://proxy.example.com:3128" {
+		t.Fatalf("bad: %v, %v", proxy, err)
+	}
+}
+
+func TestClientSetTLSConfig(t *testing.T) {
+	handler := func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}
+	server := httptest.NewUnstartedServer(http.HandlerFunc(handler))
+	server.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert}
+	server.StartTLS()
+	defer server.Close()
+
+	var requests int
+	config := DefaultConfig()
+	config.Address = server.URL
+	config.WrapTransport = func(rt http.RoundTripper) http.RoundTripper {
+		return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			requests++
+			return rt.RoundTrip(req)
+		})
+	}
+	if err := config.ConfigureTLS(&TLSConfig{Insecure: true}); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	config.MaxRetries = 0
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	// The server requires a client certificate
+	if _, err := client.RawRequest(client.NewRequest("GET", "/v1/sys/health")); err == nil {
+		t.Fatal("expected error")
+	}
+
+	cwd, _ := os.Getwd()
+	if err := client.SetTLSConfig(&TLSConfig{
+		ClientCert: cwd + "/test-fixtures/keys/cert.pem",
+		ClientKey:  cwd + "/test-fixtures/keys/key.pem",
+		Insecure:   true,
+	}); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if _, err := client.RawRequest(client.NewRequest("GET", "/v1/sys/health")); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	// The transport is wrapped, including once replaced
+	if requests != 2 {
+		t.Fatalf("bad: %d", requests)
+	}
+}
+
+func TestClientProxyAndDialer(t *testing.T) {
+	// The proxy answers the requests itself
+	var proxied string
+	handler := func(w http.ResponseWriter, req *http.Request) {
+		proxied = req.URL.String()
+		w.WriteHeader(http.StatusNoContent)
+	}
+	proxy := httptest.NewServer(http.HandlerFunc(handler))
+	defer proxy.Close()
+	proxyURL, _ := url.Parse(proxy.URL)
+
+	var dialed []string
+	config := DefaultConfig()
+	config.Address = "http://vault.example.com:8200"
+	config.Proxy = http.ProxyURL(proxyURL)
+	config.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		dialed = append(dialed, addr)
+		return (&net.Dialer{}).DialContext(ctx, network, addr)
+	}
+	config.MaxRetries = 0
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if _, err := client.RawRequest(client.NewRequest("GET", "/v1/sys/health")); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if proxied != "http://vault.example.com:8200/v1/sys/health" {
+		t.Fatalf("bad: %s", proxied)
+	}
+	if !reflect.DeepEqual(dialed, []string{proxyURL.Host}) {
+		t.Fatalf("bad: %#v", dialed)
+	}
+}
+
+func TestClientTimeout(t *testing.T) {
+	doneCh := make(chan struct{})
+	defer close(doneCh)
+	handler := func(w http.ResponseWriter, req *http.Request) {
+		select {
+		case <-doneCh:
+		case <-req.Context().Done():
+		}
+	}
+	config, ln := testHTTPServer(t, http.HandlerFunc(handler))
+	defer ln.Close()
+
+	config.MaxRetries = 0
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	// The request to the hung server times out
+	client.SetClientTimeout(100 * time.Millisecond)
+	start := time.Now()
+	if _, err := client.Logical().Read("secret/foo"); err == nil {
+		t.Fatal("expected error")
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Fatalf("request didn't time out: %s", elapsed)
+	}
+}
+
+func TestParseTimeout(t *testing.T) {
+	cases := map[string]time.Duration{
+		"30":   30 * time.Second,
+		"1m":   time.Minute,
+		"1.5s": 1500 * time.Millisecond,
+	}
+	for v, expected := range cases {
+		timeout, err := parseTimeout(v)
+		if err != nil {
+			t.Fatalf("%s: err: %s", v, err)
+		}
+		if timeout != expected {
+			t.Fatalf("%s: bad: %s", v, timeout)
+		}
+	}
+
+	for _, v := range []string{"foo", "-1s", "-1"} {
+		if _, err := parseTimeout(v); err == nil {
+			t.Fatalf("%s: expected error", v)
+		}
+	}
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestClientUnixSocket(t *testing.T) {
+	td, err := ioutil.TempDir("", "vault-test")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	defer os.RemoveAll(td)
+
+	socket := filepath.Join(td, "vault.sock")
+	ln, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	defer ln.Close()
+
+	handler := func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte(req.URL.Path))
+	}
+	go (&http.Server{Handler: http.HandlerFunc(handler)}).Serve(ln)
+
+	config := DefaultConfig()
+	config.Address = "unix://" + socket
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	resp, err := client.RawRequest(client.NewRequest("GET", "/v1/sys/health"))
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	defer resp.Body.Close()
+
+	var buf bytes.Buffer
+	io.Copy(&buf, resp.Body)
+	if buf.String() != "/v1/sys/health" {
+		t.Fatalf("bad: %s", buf.String())
+	}
 }
@@ -22,4 +22,8 @@ func (c *Client) Help(path string) (*Help, error) {
 type Help struct {
 	Help    string   `json:"help"`
 	SeeAlso []string `json:"see_also"`
+
+	// OpenAPI is the OpenAPI document of the path, with its operations and
+	// the types of its fields, for the backends documenting their paths
+	OpenAPI map[string]interface{} `json:"openapi,omitempty"`
 }
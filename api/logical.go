@@ -2,7 +2,9 @@ package api
 
 import (
 	"bytes"
+	"context"
 	"fmt"
+	"strconv"
 
 	"github.com/hashicorp/vault/helper/jsonutil"
 )
@@ -14,6 +16,9 @@ const (
 // Logical is used to perform logical backend operations on Vault.
 type Logical struct {
 	c *Client
+
+	// wrapTTL is the TTL the responses are wrapped with, if set
+	wrapTTL string
 }
 
 // Logical is used to return the client for logical-backend API calls.
@@ -21,9 +26,45 @@ func (c *Client) Logical() *Logical {
 	return &Logical{c: c}
 }
 
+// WithWrapTTL returns a Logical whose responses are wrapped in
+// response-wrapping tokens of the TTL, such as "5m", whatever the wrapping
+// lookup function of the client. Their wrapping token is in WrapInfo.
+func (c *Logical) WithWrapTTL(ttl string) *Logical {
+	return &Logical{c: c.c, wrapTTL: ttl}
+}
+
+// newRequest returns a request to the path, wrapped if wrapTTL is set
+func (c *Logical) newRequest(method, path string) *Request {
+	r := c.c.NewRequest(method, path)
+	if c.wrapTTL != "" {
+		r.WrapTTL = c.wrapTTL
+	}
+	return r
+}
+
 func (c *Logical) Read(path string) (*Secret, error) {
-	r := c.c.NewRequest("GET", "/v1/"+path)
-	resp, err := c.c.RawRequest(r)
+	return c.ReadWithContext(context.Background(), path)
+}
+
+// ReadWithContext is the same as Read, with a context for the request
+func (c *Logical) ReadWithContext(ctx context.Context, path string) (*Secret, error) {
+	return c.ReadWithDataWithContext(ctx, path, nil)
+}
+
+// ReadWithData reads the given path, passing data as query parameters, such
+// as the version of a versioned secret
+func (c *Logical) ReadWithData(path string, data map[string][]string) (*Secret, error) {
+	return c.ReadWithDataWithContext(context.Background(), path, data)
+}
+
+// ReadWithDataWithContext is the same as ReadWithData, with a context for the
+// request
+func (c *Logical) ReadWithDataWithContext(ctx context.Context, path string, data map[string][]string) (*Secret, error) {
+	r := c.newRequest("GET", "/v1/"+path)
+	for k, v := range data {
+		r.Params[k] = v
+	}
+	resp, err := c.c.RawRequestWithContext(ctx, r)
 	if resp != nil {
 		defer resp.Body.Close()
 	}
@@ -38,12 +79,49 @@ func (c *Logical) Read(path string) (*Secret, error) {
 }
 
 func (c *Logical) List(path string) (*Secret, error) {
-	r := c.c.NewRequest("LIST", "/v1/"+path)
+	return c.ListWithContext(context.Background(), path)
+}
+
+// ListWithContext is the same as List, with a context for the request
+func (c *Logical) ListWithContext(ctx context.Context, path string) (*Secret, error) {
+	r := c.newRequest("LIST", "/v1/"+path)
 	// Set this for broader compatibility, but we use LIST above to be able to
 	// handle the wrapping lookup function
 	r.Method = "GET"
 	r.Params.Set("list", "true")
-	resp, err := c.c.RawRequest(r)
+	resp, err := c.c.RawRequestWithContext(ctx, r)
+	if resp != nil {
+		defer resp.Body.Close()
+	}
+	if resp != nil && resp.StatusCode == 404 {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseSecret(resp.Body)
+}
+
+// ListPage lists the keys at the path after the key after, and at most limit
+// of them if it is positive, so that the paths with many keys can be listed
+// incrementally
+func (c *Logical) ListPage(path, after string, limit int) (*Secret, error) {
+	return c.ListPageWithContext(context.Background(), path, after, limit)
+}
+
+// ListPageWithContext is the same as ListPage, with a context for the request
+func (c *Logical) ListPageWithContext(ctx context.Context, path, after string, limit int) (*Secret, error) {
+	r := c.newRequest("LIST", "/v1/"+path)
+	r.Method = "GET"
+	r.Params.Set("list", "true")
+	if after != "" {
+		r.Params.Set("after", after)
+	}
+	if limit > 0 {
+		r.Params.Set("limit", strconv.Itoa(limit))
+	}
+	resp, err := c.c.RawRequestWithContext(ctx, r)
 	if resp != nil {
 		defer resp.Body.Close()
 	}
@@ -58,12 +136,46 @@ func (c *Logical) List(path string) (*Secret, error) {
 }
 
 func (c *Logical) Write(path string, data map[string]interface{}) (*Secret, error) {
-	r := c.c.NewRequest("PUT", "/v1/"+path)
+	return c.WriteWithContext(context.Background(), path, data)
+}
+
+// WriteWithContext is the same as Write, with a context for the request
+func (c *Logical) WriteWithContext(ctx context.Context, path string, data map[string]interface{}) (*Secret, error) {
+	r := c.newRequest("PUT", "/v1/"+path)
 	if err := r.SetJSONBody(data); err != nil {
 		return nil, err
 	}
 
-	resp, err := c.c.RawRequest(r)
+	resp, err := c.c.RawRequestWithContext(ctx, r)
+	if resp != nil {
+		defer resp.Body.Close()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode == 200 {
+		return ParseSecret(resp.Body)
+	}
+
+	return nil, nil
+}
+
+// Patch partially updates the resource at the path with the data, a JSON
+// merge patch: the fields of the data replace those of the resource, and the
+// nil fields reset them
+func (c *Logical) Patch(path string, data map[string]interface{}) (*Secret, error) {
+	return c.PatchWithContext(context.Background(), path, data)
+}
+
+// PatchWithContext is the same as Patch, with a context for the request
+func (c *Logical) PatchWithContext(ctx context.Context, path string, data map[string]interface{}) (*Secret, error) {
+	r := c.newRequest("PATCH", "/v1/"+path)
+	if err := r.SetJSONBody(data); err != nil {
+		return nil, err
+	}
+
+	resp, err := c.c.RawRequestWithContext(ctx, r)
 	if resp != nil {
 		defer resp.Body.Close()
 	}
@@ -79,8 +191,13 @@ func (c *Logical) Write(path string, data map[string]interface{}) (*Secret, erro
 }
 
 func (c *Logical) Delete(path string) (*Secret, error) {
-	r := c.c.NewRequest("DELETE", "/v1/"+path)
-	resp, err := c.c.RawRequest(r)
+	return c.DeleteWithContext(context.Background(), path)
+}
+
+// DeleteWithContext is the same as Delete, with a context for the request
+func (c *Logical) DeleteWithContext(ctx context.Context, path string) (*Secret, error) {
+	r := c.newRequest("DELETE", "/v1/"+path)
+	resp, err := c.c.RawRequestWithContext(ctx, r)
 	if resp != nil {
 		defer resp.Body.Close()
 	}
@@ -95,13 +212,41 @@ func (c *Logical) Delete(path string) (*Secret, error) {
 	return nil, nil
 }
 
+// Unwrap returns the response wrapped in the given response-wrapping token,
+// or in the client token if wrappingToken is empty. The wrapping token is
+// used up in the process.
 func (c *Logical) Unwrap(wrappingToken string) (*Secret, error) {
-	origToken := c.c.Token()
-	defer c.c.SetToken(origToken)
+	return c.UnwrapWithContext(context.Background(), wrappingToken)
+}
+
+// UnwrapWithContext is the same as Unwrap, with a context for the request
+func (c *Logical) UnwrapWithContext(ctx context.Context, wrappingToken string) (*Secret, error) {
+	if wrappingToken != "" {
+		origToken := c.c.Token()
+		defer c.c.SetToken(origToken)
 
-	c.c.SetToken(wrappingToken)
+		c.c.SetToken(wrappingToken)
+	}
 
-	secret, err := c.Read(wrappedResponseLocation)
+	r := c.c.NewRequest("PUT", "/v1/sys/wrapping/unwrap")
+	resp, err := c.c.RawRequestWithContext(ctx, r)
+	if resp != nil {
+		defer resp.Body.Close()
+	}
+	if err != nil && (resp == nil || resp.StatusCode != 404) {
+		return nil, err
+	}
+
+	switch resp.StatusCode {
+	case 200:
+		return ParseSecret(resp.Body)
+	case 204:
+		return nil, nil
+	}
+
+	// Older servers do not have the unwrap endpoint, so read the wrapped
+	// response from the cubbyhole instead
+	secret, err := c.ReadWithContext(ctx, wrappedResponseLocation)
 	if err != nil {
 		return nil, fmt.Errorf("error reading %s: %s", wrappedResponseLocation, err)
 	}
@@ -123,3 +268,57 @@ func (c *Logical) Unwrap(wrappingToken string) (*Secret, error) {
 
 	return wrappedSecret, nil
 }
+
+// Wrap wraps the data in a response-wrapping token of the TTL, such as "5m",
+// which can be unwrapped with Unwrap
+func (c *Logical) Wrap(data map[string]interface{}, ttl string) (*SecretWrapInfo, error) {
+	return c.WrapWithContext(context.Background(), data, ttl)
+}
+
+// WrapWithContext is the same as Wrap, with a context for the request
+func (c *Logical) WrapWithContext(ctx context.Context, data map[string]interface{}, ttl string) (*SecretWrapInfo, error) {
+	r := c.c.NewRequest("PUT", "/v1/sys/wrapping/wrap")
+	r.WrapTTL = ttl
+	if err := r.SetJSONBody(data); err != nil {
+		return nil, err
+	}
+
+	return c.wrapInfo(ctx, r)
+}
+
+// Rewrap moves the response wrapped in the response-wrapping token to a new
+// token of the same TTL, and revokes the token
+func (c *Logical) Rewrap(wrappingToken string) (*SecretWrapInfo, error) {
+	return c.RewrapWithContext(context.Background(), wrappingToken)
+}
+
+// RewrapWithContext is the same as Rewrap, with a context for the request
+func (c *Logical) RewrapWithContext(ctx context.Context, wrappingToken string) (*SecretWrapInfo, error) {
+	r := c.c.NewRequest("PUT", "/v1/sys/wrapping/rewrap")
+	body := map[string]interface{}{"token": wrappingToken}
+	if err := r.SetJSONBody(body); err != nil {
+		return nil, err
+	}
+
+	return c.wrapInfo(ctx, r)
+}
+
+// wrapInfo returns the wrapping information of the response to the request
+func (c *Logical) wrapInfo(ctx context.Context, r *Request) (*SecretWrapInfo, error) {
+	resp, err := c.c.RawRequestWithContext(ctx, r)
+	if resp != nil {
+		defer resp.Body.Close()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := ParseSecret(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if secret.WrapInfo == nil {
+		return nil, fmt.Errorf("the response wasn't wrapped")
+	}
+	return secret.WrapInfo, nil
+}
@@ -0,0 +1,164 @@
+package api
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/vault/http"
+	"github.com/hashicorp/vault/vault"
+)
+
+func TestLogicalWrapping(t *testing.T) {
+	core, _, token := vault.TestCoreUnsealed(t)
+	ln, addr := http.TestServer(t, core)
+	defer ln.Close()
+
+	config := DefaultConfig()
+	config.Address = addr
+
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.SetToken(token)
+
+	if _, err := client.Logical().Write("secret/foo", map[string]interface{}{"value": "bar"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// The response of the read is wrapped
+	secret, err := client.Logical().WithWrapTTL("5m").Read("secret/foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if secret.WrappingToken() == "" || secret.WrapTTL().Minutes() != 5 || secret.Data != nil {
+		t.Fatalf("bad: %#v", secret)
+	}
+
+	// The response is moved to a new token
+	wrapInfo, err := client.Logical().Rewrap(secret.WrappingToken())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if wrapInfo.Token == "" || wrapInfo.Token == secret.WrappingToken() || wrapInfo.TTL != 300 {
+		t.Fatalf("bad: %#v", wrapInfo)
+	}
+	if _, err := client.Logical().Unwrap(secret.WrappingToken()); err == nil {
+		t.Fatal("expected error")
+	}
+
+	unwrapped, err := client.Logical().Unwrap(wrapInfo.Token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if unwrapped.Data["value"] != "bar" {
+		t.Fatalf("bad: %#v", unwrapped)
+	}
+
+	// The data is wrapped as is
+	wrapInfo, err = client.Logical().Wrap(map[string]interface{}{"foo": "bar"}, "1m")
+	if err != nil {
+		t.Fatal(err)
+	}
+	unwrapped, err = client.Logical().Unwrap(wrapInfo.Token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if unwrapped.Data["foo"] != "bar" {
+		t.Fatalf("bad: %#v", unwrapped)
+	}
+
+	// The responses of the client itself aren't wrapped
+	secret, err = client.Logical().Read("secret/foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if secret.WrapInfo != nil || secret.Data["value"] != "bar" {
+		t.Fatalf("bad: %#v", secret)
+	}
+}
+
+func TestLogicalListPage(t *testing.T) {
+	core, _, token := vault.TestCoreUnsealed(t)
+	ln, addr := http.TestServer(t, core)
+	defer ln.Close()
+
+	config := DefaultConfig()
+	config.Address = addr
+
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.SetToken(token)
+
+	for _, key := range []string{"a", "b", "c", "d/e"} {
+		if _, err := client.Logical().Write("secret/"+key, map[string]interface{}{"value": key}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The keys are listed two at a time
+	var keys []interface{}
+	after := ""
+	for i := 0; i < 3; i++ {
+		secret, err := client.Logical().ListPage("secret/", after, 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if secret == nil {
+			break
+		}
+		page := secret.Data["keys"].([]interface{})
+		keys = append(keys, page...)
+		after = page[len(page)-1].(string)
+	}
+	if !reflect.DeepEqual(keys, []interface{}{"a", "b", "c", "d/"}) {
+		t.Fatalf("bad: %#v", keys)
+	}
+}
+
+func TestLogicalPatch(t *testing.T) {
+	core, _, token := vault.TestCoreUnsealed(t)
+	ln, addr := http.TestServer(t, core)
+	defer ln.Close()
+
+	config := DefaultConfig()
+	config.Address = addr
+
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.SetToken(token)
+
+	// A missing secret can't be patched
+	if _, err := client.Logical().Patch("secret/foo", map[string]interface{}{"a": "b"}); err == nil {
+		t.Fatal("expected error")
+	}
+
+	data := map[string]interface{}{
+		"a": "b",
+		"c": "d",
+	}
+	if _, err := client.Logical().Write("secret/foo", data); err != nil {
+		t.Fatal(err)
+	}
+
+	patch := map[string]interface{}{
+		"a": "z",
+		"c": nil,
+		"e": "f",
+	}
+	if _, err := client.Logical().Patch("secret/foo", patch); err != nil {
+		t.Fatal(err)
+	}
+
+	secret, err := client.Logical().Read("secret/foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(secret.Data, map[string]interface{}{"a": "z", "e": "f"}) {
+		t.Fatalf("bad: %#v", secret.Data)
+	}
+}
@@ -0,0 +1,237 @@
+package api
+
+import (
+	"errors"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+var (
+	ErrRenewerMissingInput  = errors.New("missing input to renewer")
+	ErrRenewerMissingSecret = errors.New("missing secret to renew")
+	ErrRenewerNotRenewable  = errors.New("secret is not renewable")
+	ErrRenewerNoSecretData  = errors.New("returned empty secret data")
+)
+
+// Renewer renews a secret, or the token of a login response, in the
+// background until it can't be renewed anymore. Renew is run in a
+// goroutine, and the renewals are sent to RenewCh. Once the renewer stops,
+// DoneCh receives nil if the lease reached its maximum TTL, or the error
+// which stopped it; in both cases, the secret should be read again or the
+// client login again before the lease expires.
+//
+//	renewer, err := client.NewRenewer(&api.RenewerInput{Secret: secret})
+//	if err != nil {
+//		return err
+//	}
+//	go renewer.Renew()
+//	defer renewer.Stop()
+//
+//	for {
+//		select {
+//		case err := <-renewer.DoneCh():
+//			// Read the secret again
+//		case renewal := <-renewer.RenewCh():
+//			log.Printf("renewed at %s", renewal.RenewedAt)
+//		}
+//	}
+type Renewer struct {
+	l sync.Mutex
+
+	client    *Client
+	secret    *Secret
+	grace     time.Duration
+	random    *rand.Rand
+	increment int
+	doneCh    chan error
+	renewCh   chan *RenewOutput
+
+	stopped bool
+	stopCh  chan struct{}
+}
+
+// RenewerInput is the input of NewRenewer
+type RenewerInput struct {
+	// Secret is the secret to renew. If it has an Auth, its token is renewed
+	// with auth/token/renew-self, otherwise its lease with sys/renew.
+	Secret *Secret
+
+	// Grace is the remaining TTL at which the renewer stops, as the lease
+	// reached its maximum TTL. It is one tenth of the TTL of the secret by
+	// default.
+	Grace time.Duration
+
+	// Rand is the source of the jitter of the renewals, so that the clients
+	// renewing together don't renew at the same time
+	Rand *rand.Rand
+
+	// Increment is the increment requested by the renewals, the TTL of the
+	// backend or the token when zero
+	Increment int
+}
+
+// RenewOutput is a renewal of the secret sent to RenewCh
+type RenewOutput struct {
+	// RenewedAt is the time of the renewal
+	RenewedAt time.Time
+
+	// Secret is the response of the renewal
+	Secret *Secret
+}
+
+// NewRenewer returns a Renewer of the secret
+func (c *Client) NewRenewer(i *RenewerInput) (*Renewer, error) {
+	if i == nil {
+		return nil, ErrRenewerMissingInput
+	}
+
+	secret := i.Secret
+	if secret == nil {
+		return nil, ErrRenewerMissingSecret
+	}
+
+	grace := i.Grace
+	if grace == 0 {
+		ttl := secret.LeaseDuration
+		if secret.Auth != nil {
+			ttl = secret.Auth.LeaseDuration
+		}
+		grace = time.Duration(ttl) * time.Second / 10
+	}
+
+	random := i.Rand
+	if random == nil {
+		random = rand.New(rand.NewSource(time.Now().UnixNano()))
+	}
+
+	return &Renewer{
+		client:    c,
+		secret:    secret,
+		grace:     grace,
+		random:    random,
+		increment: i.Increment,
+		doneCh:    make(chan error, 1),
+		renewCh:   make(chan *RenewOutput, 5),
+		stopCh:    make(chan struct{}),
+	}, nil
+}
+
+// DoneCh returns the channel receiving nil or the error which stopped the
+// renewer once it stops
+func (r *Renewer) DoneCh() <-chan error {
+	return r.doneCh
+}
+
+// RenewCh returns the channel the renewals are sent to. The renewals which
+// aren't received in time are dropped.
+func (r *Renewer) RenewCh() <-chan *RenewOutput {
+	return r.renewCh
+}
+
+// Stop stops the renewer. It can be called more than once.
+func (r *Renewer) Stop() {
+	r.l.Lock()
+	defer r.l.Unlock()
+
+	if !r.stopped {
+		close(r.stopCh)
+		r.stopped = true
+	}
+}
+
+// Renew renews the secret until it can't be renewed anymore or the renewer
+// is stopped, and then sends the result to DoneCh. It blocks, so it is
+// meant to be run in a goroutine.
+func (r *Renewer) Renew() {
+	var result error
+	if r.secret.Auth != nil {
+		result = r.renewAuth()
+	} else {
+		result = r.renewLease()
+	}
+
+	r.doneCh <- result
+}
+
+// renewAuth renews the token of the secret, authenticating with the token
+// itself so that it doesn't depend on the policy of the client token
+func (r *Renewer) renewAuth() error {
+	if !r.secret.Auth.Renewable || r.secret.Auth.ClientToken == "" {
+		return ErrRenewerNotRenewable
+	}
+
+	token := r.secret.Auth.ClientToken
+	return r.renew(func() (int, bool, *Secret, error) {
+		renewal, err := r.client.Auth().Token().RenewTokenAsSelf(token, r.increment)
+		if err != nil {
+			return 0, false, nil, err
+		}
+		if renewal == nil || renewal.Auth == nil {
+			return 0, false, nil, ErrRenewerNoSecretData
+		}
+		return renewal.Auth.LeaseDuration, renewal.Auth.Renewable, renewal, nil
+	})
+}
+
+// renewLease renews the lease of the secret
+func (r *Renewer) renewLease() error {
+	if !r.secret.Renewable || r.secret.LeaseID == "" {
+		return ErrRenewerNotRenewable
+	}
+
+	leaseID := r.secret.LeaseID
+	return r.renew(func() (int, bool, *Secret, error) {
+		renewal, err := r.client.Sys().Renew(leaseID, r.increment)
+		if err != nil {
+			return 0, false, nil, err
+		}
+		if renewal == nil {
+			return 0, false, nil, ErrRenewerNoSecretData
+		}
+		return renewal.LeaseDuration, renewal.Renewable, renewal, nil
+	})
+}
+
+// renew renews with f until the remaining TTL is within the grace period,
+// the renewal isn't renewable anymore, or the renewer is stopped
+func (r *Renewer) renew(f func() (int, bool, *Secret, error)) error {
+	for {
+		ttl, renewable, renewal, err := f()
+		if err != nil {
+			return err
+		}
+
+		select {
+		case r.renewCh <- &RenewOutput{RenewedAt: time.Now().UTC(), Secret: renewal}:
+		default:
+		}
+
+		if !renewable {
+			return ErrRenewerNotRenewable
+		}
+
+		// A lease which can't be extended past the grace period reached
+		// its maximum TTL
+		leaseDuration := time.Duration(ttl) * time.Second
+		sleepDuration := r.sleepDuration(leaseDuration)
+		if leaseDuration <= r.grace || sleepDuration <= r.grace {
+			return nil
+		}
+
+		select {
+		case <-r.stopCh:
+			return nil
+		case <-time.After(sleepDuration):
+		}
+	}
+}
+
+// sleepDuration returns the time to wait before renewing a lease of the
+// TTL, between a sixth and a third of the TTL, so that the lease is renewed
+// well before it expires
+func (r *Renewer) sleepDuration(ttl time.Duration) time.Duration {
+	sleep := float64(ttl) / 3.0
+	sleep = sleep * (r.random.Float64() + 1) / 2.0
+	return time.Duration(sleep)
+}
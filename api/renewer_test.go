@@ -0,0 +1,154 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	vaulthttp "github.com/hashicorp/vault/http"
+	"github.com/hashicorp/vault/vault"
+)
+
+func TestRenewer_input(t *testing.T) {
+	client, err := NewClient(DefaultConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := client.NewRenewer(nil); err != ErrRenewerMissingInput {
+		t.Fatalf("bad: %v", err)
+	}
+	if _, err := client.NewRenewer(&RenewerInput{}); err != ErrRenewerMissingSecret {
+		t.Fatalf("bad: %v", err)
+	}
+
+	renewer, err := client.NewRenewer(&RenewerInput{
+		Secret: &Secret{LeaseID: "foo", LeaseDuration: 60},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if renewer.grace != 6*time.Second {
+		t.Fatalf("bad: %s", renewer.grace)
+	}
+
+	go renewer.Renew()
+	select {
+	case err := <-renewer.DoneCh():
+		if err != ErrRenewerNotRenewable {
+			t.Fatalf("bad: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("renewer didn't stop")
+	}
+}
+
+func TestRenewer_lease(t *testing.T) {
+	// The lease can be renewed once before reaching its maximum TTL
+	var renewals int32
+	handler := func(w http.ResponseWriter, req *http.Request) {
+		leaseDuration := 2
+		if atomic.AddInt32(&renewals, 1) > 1 {
+			leaseDuration = 0
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"lease_id":       "secret/foo/1234",
+			"lease_duration": leaseDuration,
+			"renewable":      true,
+		})
+	}
+
+	config, ln := testHTTPServer(t, http.HandlerFunc(handler))
+	defer ln.Close()
+
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	renewer, err := client.NewRenewer(&RenewerInput{
+		Secret: &Secret{
+			LeaseID:       "secret/foo/1234",
+			LeaseDuration: 2,
+			Renewable:     true,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	go renewer.Renew()
+	defer renewer.Stop()
+
+	select {
+	case err := <-renewer.DoneCh():
+		if err != nil {
+			t.Fatalf("bad: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("renewer didn't stop")
+	}
+
+	if n := len(renewer.RenewCh()); n != 2 {
+		t.Fatalf("bad: %d", n)
+	}
+	renewal := <-renewer.RenewCh()
+	if renewal.Secret.LeaseID != "secret/foo/1234" || renewal.RenewedAt.IsZero() {
+		t.Fatalf("bad: %#v", renewal)
+	}
+}
+
+func TestRenewer_token(t *testing.T) {
+	core, _, token := vault.TestCoreUnsealed(t)
+	ln, addr := vaulthttp.TestServer(t, core)
+	defer ln.Close()
+
+	config := DefaultConfig()
+	config.Address = addr
+
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.SetToken(token)
+
+	renewable := true
+	secret, err := client.Auth().Token().Create(&TokenCreateRequest{
+		TTL:       "1h",
+		Renewable: &renewable,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The token is renewed as itself, whatever the token of the client
+	client.ClearToken()
+	renewer, err := client.NewRenewer(&RenewerInput{Secret: secret})
+	if err != nil {
+		t.Fatal(err)
+	}
+	go renewer.Renew()
+
+	select {
+	case renewal := <-renewer.RenewCh():
+		if renewal.Secret.Auth.ClientToken != secret.Auth.ClientToken {
+			t.Fatalf("bad: %#v", renewal.Secret.Auth)
+		}
+	case err := <-renewer.DoneCh():
+		t.Fatalf("err: %v", err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("token wasn't renewed")
+	}
+
+	renewer.Stop()
+	renewer.Stop()
+	select {
+	case err := <-renewer.DoneCh():
+		if err != nil {
+			t.Fatalf("bad: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("renewer didn't stop")
+	}
+}
@@ -15,10 +15,15 @@ type Request struct {
 	URL         *url.URL
 	Params      url.Values
 	ClientToken string
+	Namespace   string
 	WrapTTL     string
 	Obj         interface{}
 	Body        io.Reader
 	BodySize    int64
+
+	// MaxRetries overrides the MaxRetries of the client for the request if
+	// it is set
+	MaxRetries *int
 }
 
 // SetJSONBody is used to set a request body that is a JSON-encoded value.
@@ -63,9 +68,18 @@ func (r *Request) ToHTTP() (*http.Request, error) {
 		req.Header.Set("X-Vault-Token", r.ClientToken)
 	}
 
+	if len(r.Namespace) != 0 {
+		req.Header.Set("X-Vault-Namespace", r.Namespace)
+	}
+
 	if len(r.WrapTTL) != 0 {
 		req.Header.Set("X-Vault-Wrap-TTL", r.WrapTTL)
 	}
 
+	// The bodies of the patches are JSON merge patches
+	if r.Method == "PATCH" {
+		req.Header.Set("Content-Type", "application/merge-patch+json")
+	}
+
 	return req, nil
 }
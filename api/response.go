@@ -61,6 +61,12 @@ func (r *Response) Error() error {
 	for _, err := range resp.Errors {
 		errBody.WriteString(fmt.Sprintf("* %s", err))
 	}
+	if len(resp.Warnings) > 0 {
+		errBody.WriteString("\n\nWarnings:\n")
+		for _, warning := range resp.Warnings {
+			errBody.WriteString(fmt.Sprintf("\n* %s", warning))
+		}
+	}
 
 	return fmt.Errorf(errBody.String())
 }
@@ -68,5 +74,6 @@ func (r *Response) Error() error {
 // ErrorResponse is the raw structure of errors when they're returned by the
 // HTTP API.
 type ErrorResponse struct {
-	Errors []string
+	Errors   []string
+	Warnings []string
 }
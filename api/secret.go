@@ -40,11 +40,39 @@ type Secret struct {
 // available in WrappedAccessor.
 type SecretWrapInfo struct {
 	Token           string    `json:"token"`
+	Accessor        string    `json:"accessor"`
 	TTL             int       `json:"ttl"`
 	CreationTime    time.Time `json:"creation_time"`
 	WrappedAccessor string    `json:"wrapped_accessor"`
 }
 
+// WrappingToken returns the response-wrapping token the response was
+// wrapped in, empty if it wasn't wrapped
+func (s *Secret) WrappingToken() string {
+	if s == nil || s.WrapInfo == nil {
+		return ""
+	}
+	return s.WrapInfo.Token
+}
+
+// WrapTTL returns the TTL of the response-wrapping token the response was
+// wrapped in, zero if it wasn't wrapped
+func (s *Secret) WrapTTL() time.Duration {
+	if s == nil || s.WrapInfo == nil {
+		return 0
+	}
+	return time.Duration(s.WrapInfo.TTL) * time.Second
+}
+
+// WrappedAccessor returns the accessor of the token wrapped in the response,
+// empty if it wasn't wrapped or isn't a token
+func (s *Secret) WrappedAccessor() string {
+	if s == nil || s.WrapInfo == nil {
+		return ""
+	}
+	return s.WrapInfo.WrappedAccessor
+}
+
 // SecretAuth is the structure containing auth information if we have it.
 type SecretAuth struct {
 	ClientToken string            `json:"client_token"`
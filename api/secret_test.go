@@ -55,3 +55,24 @@ func TestParseSecret(t *testing.T) {
 		t.Fatalf("bad:\ngot\n%#v\nexpected\n%#v\n", secret, expected)
 	}
 }
+
+func TestSecretWrapInfo(t *testing.T) {
+	secret := &Secret{
+		WrapInfo: &SecretWrapInfo{
+			Token:           "token",
+			TTL:             60,
+			WrappedAccessor: "abcd1234",
+		},
+	}
+	if secret.WrappingToken() != "token" || secret.WrapTTL() != time.Minute ||
+		secret.WrappedAccessor() != "abcd1234" {
+		t.Fatalf("bad: %#v", secret.WrapInfo)
+	}
+
+	// The secrets which aren't wrapped have no wrap info
+	for _, secret := range []*Secret{nil, &Secret{}} {
+		if secret.WrappingToken() != "" || secret.WrapTTL() != 0 || secret.WrappedAccessor() != "" {
+			t.Fatalf("bad: %#v", secret)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package api
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"sync"
+)
+
+// maxStreamLineSize is the maximum size of the lines of a stream
+const maxStreamLineSize = 1024 * 1024
+
+// Stream is the body of the response of a long-lived endpoint, such as
+// sys/monitor, read as the server streams it. It can be read directly, or its
+// lines or JSON entries received with Lines or JSON, but not both. It must be
+// closed once done with.
+type Stream struct {
+	body io.ReadCloser
+
+	closeOnce sync.Once
+	closeCh   chan struct{}
+
+	l   sync.Mutex
+	err error
+}
+
+// RawStream performs the request of a long-lived endpoint and returns the
+// body of its response as it is streamed. Unlike RawRequest, the request
+// isn't subject to the timeout of the client. This is an advanced operation
+// that generally won't need to be called externally.
+func (c *Client) RawStream(r *Request) (*Stream, error) {
+	return c.RawStreamWithContext(context.Background(), r)
+}
+
+// RawStreamWithContext is the same as RawStream, with a context for the
+// request, which closes the stream when it is done
+func (c *Client) RawStreamWithContext(ctx context.Context, r *Request) (*Stream, error) {
+	httpClient := *c.currentHTTPClient()
+	httpClient.Timeout = 0
+	resp, err := c.rawRequest(ctx, r, &httpClient)
+	if err != nil {
+		if resp != nil {
+			resp.Body.Close()
+		}
+		return nil, err
+	}
+
+	return &Stream{
+		body:    resp.Body,
+		closeCh: make(chan struct{}),
+	}, nil
+}
+
+// Read reads the body of the response
+func (s *Stream) Read(p []byte) (int, error) {
+	return s.body.Read(p)
+}
+
+// Close closes the stream, which interrupts the reads in progress and closes
+// the channels of Lines and JSON. It can be called more than once.
+func (s *Stream) Close() error {
+	var err error
+	s.closeOnce.Do(func() {
+		close(s.closeCh)
+		err = s.body.Close()
+	})
+	return err
+}
+
+// Err returns the error which closed the channel of Lines or JSON, nil if
+// the server ended the stream or it was closed
+func (s *Stream) Err() error {
+	s.l.Lock()
+	defer s.l.Unlock()
+	return s.err
+}
+
+// Lines returns a channel receiving the lines of the stream, without their
+// line ending, which is closed when the stream ends or is closed
+func (s *Stream) Lines() <-chan string {
+	lineCh := make(chan string)
+	go func() {
+		defer close(lineCh)
+
+		scanner := bufio.NewScanner(s.body)
+		scanner.Buffer(make([]byte, 64*1024), maxStreamLineSize)
+		for scanner.Scan() {
+			select {
+			case lineCh <- scanner.Text():
+			case <-s.closeCh:
+				return
+			}
+		}
+		s.setErr(scanner.Err())
+	}()
+	return lineCh
+}
+
+// JSON returns a channel receiving the JSON entries of the stream, such as
+// one object per line, which is closed when the stream ends or is closed
+func (s *Stream) JSON() <-chan map[string]interface{} {
+	entryCh := make(chan map[string]interface{})
+	go func() {
+		defer close(entryCh)
+
+		dec := json.NewDecoder(s.body)
+		dec.UseNumber()
+		for {
+			var entry map[string]interface{}
+			if err := dec.Decode(&entry); err != nil {
+				if err != io.EOF {
+					s.setErr(err)
+				}
+				return
+			}
+
+			select {
+			case entryCh <- entry:
+			case <-s.closeCh:
+				return
+			}
+		}
+	}()
+	return entryCh
+}
+
+// setErr records the error which ended the stream, unless it was closed
+func (s *Stream) setErr(err error) {
+	select {
+	case <-s.closeCh:
+		return
+	default:
+	}
+
+	s.l.Lock()
+	defer s.l.Unlock()
+	s.err = err
+}
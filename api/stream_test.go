@@ -0,0 +1,88 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestClientRawStream(t *testing.T) {
+	doneCh := make(chan struct{})
+	defer close(doneCh)
+	handler := func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		for i := 0; i < 3; i++ {
+			fmt.Fprintf(w, "{\"index\": %d}\n", i)
+			w.(http.Flusher).Flush()
+		}
+		if req.URL.Query().Get("hang") != "" {
+			select {
+			case <-doneCh:
+			case <-req.Context().Done():
+			}
+		}
+	}
+	config, ln := testHTTPServer(t, http.HandlerFunc(handler))
+	defer ln.Close()
+
+	// The stream outlasts the timeout of the client
+	config.Timeout = 100 * time.Millisecond
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	stream, err := client.RawStream(client.NewRequest("GET", "/v1/sys/stream"))
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	var lines []string
+	for line := range stream.Lines() {
+		lines = append(lines, line)
+	}
+	stream.Close()
+	if err := stream.Err(); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	expected := []string{`{"index": 0}`, `{"index": 1}`, `{"index": 2}`}
+	if !reflect.DeepEqual(lines, expected) {
+		t.Fatalf("bad: %#v", lines)
+	}
+
+	// The entries are received as the server sends them, until the context
+	// is done
+	ctx, cancel := context.WithCancel(context.Background())
+	r := client.NewRequest("GET", "/v1/sys/stream")
+	r.Params.Set("hang", "true")
+	stream, err = client.RawStreamWithContext(ctx, r)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	defer stream.Close()
+
+	entryCh := stream.JSON()
+	for i := 0; i < 3; i++ {
+		select {
+		case entry := <-entryCh:
+			if entry["index"].(json.Number).String() != fmt.Sprint(i) {
+				t.Fatalf("bad: %#v", entry)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out")
+		}
+	}
+	time.Sleep(200 * time.Millisecond)
+	cancel()
+	select {
+	case _, ok := <-entryCh:
+		if ok {
+			t.Fatal("expected the stream to end")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("stream wasn't canceled")
+	}
+}
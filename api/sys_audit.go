@@ -1,12 +1,18 @@
 package api
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/mitchellh/mapstructure"
 )
 
 func (c *Sys) AuditHash(path string, input string) (string, error) {
+	return c.AuditHashWithContext(context.Background(), path, input)
+}
+
+// AuditHashWithContext is the same as AuditHash, with a context for the request
+func (c *Sys) AuditHashWithContext(ctx context.Context, path string, input string) (string, error) {
 	body := map[string]interface{}{
 		"input": input,
 	}
@@ -16,7 +22,7 @@ func (c *Sys) AuditHash(path string, input string) (string, error) {
 		return "", err
 	}
 
-	resp, err := c.c.RawRequest(r)
+	resp, err := c.c.RawRequestWithContext(ctx, r)
 	if err != nil {
 		return "", err
 	}
@@ -36,8 +42,13 @@ func (c *Sys) AuditHash(path string, input string) (string, error) {
 }
 
 func (c *Sys) ListAudit() (map[string]*Audit, error) {
+	return c.ListAuditWithContext(context.Background())
+}
+
+// ListAuditWithContext is the same as ListAudit, with a context for the request
+func (c *Sys) ListAuditWithContext(ctx context.Context) (map[string]*Audit, error) {
 	r := c.c.NewRequest("GET", "/v1/sys/audit")
-	resp, err := c.c.RawRequest(r)
+	resp, err := c.c.RawRequestWithContext(ctx, r)
 	if err != nil {
 		return nil, err
 	}
@@ -71,20 +82,34 @@ func (c *Sys) ListAudit() (map[string]*Audit, error) {
 	return mounts, nil
 }
 
-func (c *Sys) EnableAudit(
-	path string, auditType string, desc string, opts map[string]string) error {
-	body := map[string]interface{}{
-		"type":        auditType,
-		"description": desc,
-		"options":     opts,
-	}
+func (c *Sys) EnableAudit(path string, auditType string, desc string, opts map[string]string) error {
+	return c.EnableAuditWithContext(context.Background(), path, auditType, desc, opts)
+}
+
+// EnableAuditWithContext is the same as EnableAudit, with a context for the
+// request
+func (c *Sys) EnableAuditWithContext(ctx context.Context, path string, auditType string, desc string, opts map[string]string) error {
+	return c.EnableAuditWithOptionsWithContext(ctx, path, &EnableAuditOptions{
+		Type:        auditType,
+		Description: desc,
+		Options:     opts,
+	})
+}
+
+// EnableAuditWithOptions enables an audit backend at the path
+func (c *Sys) EnableAuditWithOptions(path string, options *EnableAuditOptions) error {
+	return c.EnableAuditWithOptionsWithContext(context.Background(), path, options)
+}
 
+// EnableAuditWithOptionsWithContext is the same as EnableAuditWithOptions,
+// with a context for the request
+func (c *Sys) EnableAuditWithOptionsWithContext(ctx context.Context, path string, options *EnableAuditOptions) error {
 	r := c.c.NewRequest("PUT", fmt.Sprintf("/v1/sys/audit/%s", path))
-	if err := r.SetJSONBody(body); err != nil {
+	if err := r.SetJSONBody(options); err != nil {
 		return err
 	}
 
-	resp, err := c.c.RawRequest(r)
+	resp, err := c.c.RawRequestWithContext(ctx, r)
 	if err != nil {
 		return err
 	}
@@ -94,8 +119,14 @@ func (c *Sys) EnableAudit(
 }
 
 func (c *Sys) DisableAudit(path string) error {
+	return c.DisableAuditWithContext(context.Background(), path)
+}
+
+// DisableAuditWithContext is the same as DisableAudit, with a context for the
+// request
+func (c *Sys) DisableAuditWithContext(ctx context.Context, path string) error {
 	r := c.c.NewRequest("DELETE", fmt.Sprintf("/v1/sys/audit/%s", path))
-	resp, err := c.c.RawRequest(r)
+	resp, err := c.c.RawRequestWithContext(ctx, r)
 	if err == nil {
 		defer resp.Body.Close()
 	}
@@ -106,6 +137,12 @@ func (c *Sys) DisableAudit(path string) error {
 // individually documented because the map almost directly to the raw HTTP API
 // documentation. Please refer to that documentation for more details.
 
+type EnableAuditOptions struct {
+	Type        string            `json:"type" structs:"type"`
+	Description string            `json:"description" structs:"description"`
+	Options     map[string]string `json:"options" structs:"options"`
+}
+
 type Audit struct {
 	Path        string
 	Type        string
@@ -1,12 +1,27 @@
 package api
 
-import "fmt"
+import (
+	"context"
+	"fmt"
+)
 
 func (c *Sys) CapabilitiesSelf(path string) ([]string, error) {
-	return c.Capabilities(c.c.Token(), path)
+	return c.CapabilitiesSelfWithContext(context.Background(), path)
+}
+
+// CapabilitiesSelfWithContext is the same as CapabilitiesSelf, with a context
+// for the request
+func (c *Sys) CapabilitiesSelfWithContext(ctx context.Context, path string) ([]string, error) {
+	return c.CapabilitiesWithContext(ctx, c.c.Token(), path)
 }
 
 func (c *Sys) Capabilities(token, path string) ([]string, error) {
+	return c.CapabilitiesWithContext(context.Background(), token, path)
+}
+
+// CapabilitiesWithContext is the same as Capabilities, with a context for the
+// request
+func (c *Sys) CapabilitiesWithContext(ctx context.Context, token, path string) ([]string, error) {
 	body := map[string]string{
 		"token": token,
 		"path":  path,
@@ -17,12 +32,33 @@ func (c *Sys) Capabilities(token, path string) ([]string, error) {
 		reqPath = fmt.Sprintf("%s-self", reqPath)
 	}
 
+	return c.capabilities(ctx, reqPath, body)
+}
+
+// CapabilitiesAccessor returns the capabilities on the path of the token
+// associated with the accessor
+func (c *Sys) CapabilitiesAccessor(accessor, path string) ([]string, error) {
+	return c.CapabilitiesAccessorWithContext(context.Background(), accessor, path)
+}
+
+// CapabilitiesAccessorWithContext is the same as CapabilitiesAccessor, with a
+// context for the request
+func (c *Sys) CapabilitiesAccessorWithContext(ctx context.Context, accessor, path string) ([]string, error) {
+	body := map[string]string{
+		"accessor": accessor,
+		"path":     path,
+	}
+
+	return c.capabilities(ctx, "/v1/sys/capabilities-accessor", body)
+}
+
+func (c *Sys) capabilities(ctx context.Context, reqPath string, body map[string]string) ([]string, error) {
 	r := c.c.NewRequest("POST", reqPath)
 	if err := r.SetJSONBody(body); err != nil {
 		return nil, err
 	}
 
-	resp, err := c.c.RawRequest(r)
+	resp, err := c.c.RawRequestWithContext(ctx, r)
 	if err != nil {
 		return nil, err
 	}
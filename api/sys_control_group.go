@@ -0,0 +1,53 @@
+package api
+
+import "context"
+
+func (c *Sys) ControlGroupAuthorize(accessor string) (*Secret, error) {
+	return c.ControlGroupAuthorizeWithContext(context.Background(), accessor)
+}
+
+// ControlGroupAuthorizeWithContext is the same as ControlGroupAuthorize, with a
+// context for the request
+func (c *Sys) ControlGroupAuthorizeWithContext(ctx context.Context, accessor string) (*Secret, error) {
+	r := c.c.NewRequest("PUT", "/v1/sys/control-group/authorize")
+
+	body := map[string]interface{}{
+		"accessor": accessor,
+	}
+	if err := r.SetJSONBody(body); err != nil {
+		return nil, err
+	}
+
+	resp, err := c.c.RawRequestWithContext(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	return ParseSecret(resp.Body)
+}
+
+func (c *Sys) ControlGroupRequest(accessor string) (*Secret, error) {
+	return c.ControlGroupRequestWithContext(context.Background(), accessor)
+}
+
+// ControlGroupRequestWithContext is the same as ControlGroupRequest, with a
+// context for the request
+func (c *Sys) ControlGroupRequestWithContext(ctx context.Context, accessor string) (*Secret, error) {
+	r := c.c.NewRequest("PUT", "/v1/sys/control-group/request")
+
+	body := map[string]interface{}{
+		"accessor": accessor,
+	}
+	if err := r.SetJSONBody(body); err != nil {
+		return nil, err
+	}
+
+	resp, err := c.c.RawRequestWithContext(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	return ParseSecret(resp.Body)
+}
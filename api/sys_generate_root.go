@@ -1,8 +1,16 @@
 package api
 
+import "context"
+
 func (c *Sys) GenerateRootStatus() (*GenerateRootStatusResponse, error) {
+	return c.GenerateRootStatusWithContext(context.Background())
+}
+
+// GenerateRootStatusWithContext is the same as GenerateRootStatus, with a
+// context for the request
+func (c *Sys) GenerateRootStatusWithContext(ctx context.Context) (*GenerateRootStatusResponse, error) {
 	r := c.c.NewRequest("GET", "/v1/sys/generate-root/attempt")
-	resp, err := c.c.RawRequest(r)
+	resp, err := c.c.RawRequestWithContext(ctx, r)
 	if err != nil {
 		return nil, err
 	}
@@ -14,6 +22,12 @@ func (c *Sys) GenerateRootStatus() (*GenerateRootStatusResponse, error) {
 }
 
 func (c *Sys) GenerateRootInit(otp, pgpKey string) (*GenerateRootStatusResponse, error) {
+	return c.GenerateRootInitWithContext(context.Background(), otp, pgpKey)
+}
+
+// GenerateRootInitWithContext is the same as GenerateRootInit, with a context
+// for the request
+func (c *Sys) GenerateRootInitWithContext(ctx context.Context, otp, pgpKey string) (*GenerateRootStatusResponse, error) {
 	body := map[string]interface{}{
 		"otp":     otp,
 		"pgp_key": pgpKey,
@@ -24,7 +38,7 @@ func (c *Sys) GenerateRootInit(otp, pgpKey string) (*GenerateRootStatusResponse,
 		return nil, err
 	}
 
-	resp, err := c.c.RawRequest(r)
+	resp, err := c.c.RawRequestWithContext(ctx, r)
 	if err != nil {
 		return nil, err
 	}
@@ -36,8 +50,14 @@ func (c *Sys) GenerateRootInit(otp, pgpKey string) (*GenerateRootStatusResponse,
 }
 
 func (c *Sys) GenerateRootCancel() error {
+	return c.GenerateRootCancelWithContext(context.Background())
+}
+
+// GenerateRootCancelWithContext is the same as GenerateRootCancel, with a
+// context for the request
+func (c *Sys) GenerateRootCancelWithContext(ctx context.Context) error {
 	r := c.c.NewRequest("DELETE", "/v1/sys/generate-root/attempt")
-	resp, err := c.c.RawRequest(r)
+	resp, err := c.c.RawRequestWithContext(ctx, r)
 	if err == nil {
 		defer resp.Body.Close()
 	}
@@ -45,6 +65,12 @@ func (c *Sys) GenerateRootCancel() error {
 }
 
 func (c *Sys) GenerateRootUpdate(shard, nonce string) (*GenerateRootStatusResponse, error) {
+	return c.GenerateRootUpdateWithContext(context.Background(), shard, nonce)
+}
+
+// GenerateRootUpdateWithContext is the same as GenerateRootUpdate, with a
+// context for the request
+func (c *Sys) GenerateRootUpdateWithContext(ctx context.Context, shard, nonce string) (*GenerateRootStatusResponse, error) {
 	body := map[string]interface{}{
 		"key":   shard,
 		"nonce": nonce,
@@ -55,7 +81,7 @@ func (c *Sys) GenerateRootUpdate(shard, nonce string) (*GenerateRootStatusRespon
 		return nil, err
 	}
 
-	resp, err := c.c.RawRequest(r)
+	resp, err := c.c.RawRequestWithContext(ctx, r)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,39 @@
+package api
+
+import "context"
+
+// Health returns the health of the server. Unlike the sys/health endpoint,
+// it doesn't fail when the server is uninitialized, sealed or a standby.
+func (c *Sys) Health() (*HealthResponse, error) {
+	return c.HealthWithContext(context.Background())
+}
+
+// HealthWithContext is the same as Health, with a context for the request
+func (c *Sys) HealthWithContext(ctx context.Context) (*HealthResponse, error) {
+	r := c.c.NewRequest("GET", "/v1/sys/health")
+	// The status codes of the states which aren't active are set to a
+	// success code, so that their body can be read
+	r.Params.Set("uninitcode", "299")
+	r.Params.Set("sealedcode", "299")
+	r.Params.Set("standbycode", "299")
+
+	resp, err := c.c.RawRequestWithContext(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var result HealthResponse
+	err = resp.DecodeJSON(&result)
+	return &result, err
+}
+
+type HealthResponse struct {
+	Initialized   bool   `json:"initialized"`
+	Sealed        bool   `json:"sealed"`
+	Standby       bool   `json:"standby"`
+	ServerTimeUTC int64  `json:"server_time_utc"`
+	Version       string `json:"version"`
+	ClusterName   string `json:"cluster_name,omitempty"`
+	ClusterID     string `json:"cluster_id,omitempty"`
+}
@@ -0,0 +1,44 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/hashicorp/vault/http"
+	"github.com/hashicorp/vault/vault"
+)
+
+func TestSysHealth(t *testing.T) {
+	core := vault.TestCore(t)
+	ln, addr := http.TestServer(t, core)
+	defer ln.Close()
+
+	config := DefaultConfig()
+	config.Address = addr
+
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The health of an uninitialized server is returned without error
+	health, err := client.Sys().Health()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if health.Initialized || !health.Sealed || health.Version == "" {
+		t.Fatalf("bad: %#v", health)
+	}
+
+	key, _ := vault.TestCoreInit(t, core)
+	if _, err := core.Unseal(vault.TestKeyCopy(key)); err != nil {
+		t.Fatal(err)
+	}
+
+	health, err = client.Sys().Health()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !health.Initialized || health.Sealed || health.Standby || health.ClusterID == "" {
+		t.Fatalf("bad: %#v", health)
+	}
+}
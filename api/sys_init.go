@@ -1,8 +1,16 @@
 package api
 
+import "context"
+
 func (c *Sys) InitStatus() (bool, error) {
+	return c.InitStatusWithContext(context.Background())
+}
+
+// InitStatusWithContext is the same as InitStatus, with a context for the
+// request
+func (c *Sys) InitStatusWithContext(ctx context.Context) (bool, error) {
 	r := c.c.NewRequest("GET", "/v1/sys/init")
-	resp, err := c.c.RawRequest(r)
+	resp, err := c.c.RawRequestWithContext(ctx, r)
 	if err != nil {
 		return false, err
 	}
@@ -14,12 +22,17 @@ func (c *Sys) InitStatus() (bool, error) {
 }
 
 func (c *Sys) Init(opts *InitRequest) (*InitResponse, error) {
+	return c.InitWithContext(context.Background(), opts)
+}
+
+// InitWithContext is the same as Init, with a context for the request
+func (c *Sys) InitWithContext(ctx context.Context, opts *InitRequest) (*InitResponse, error) {
 	r := c.c.NewRequest("PUT", "/v1/sys/init")
 	if err := r.SetJSONBody(opts); err != nil {
 		return nil, err
 	}
 
-	resp, err := c.c.RawRequest(r)
+	resp, err := c.c.RawRequestWithContext(ctx, r)
 	if err != nil {
 		return nil, err
 	}
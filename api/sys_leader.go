@@ -1,8 +1,15 @@
 package api
 
+import "context"
+
 func (c *Sys) Leader() (*LeaderResponse, error) {
+	return c.LeaderWithContext(context.Background())
+}
+
+// LeaderWithContext is the same as Leader, with a context for the request
+func (c *Sys) LeaderWithContext(ctx context.Context) (*LeaderResponse, error) {
 	r := c.c.NewRequest("GET", "/v1/sys/leader")
-	resp, err := c.c.RawRequest(r)
+	resp, err := c.c.RawRequestWithContext(ctx, r)
 	if err != nil {
 		return nil, err
 	}
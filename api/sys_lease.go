@@ -1,6 +1,18 @@
 package api
 
+import (
+	"context"
+	"time"
+
+	"github.com/mitchellh/mapstructure"
+)
+
 func (c *Sys) Renew(id string, increment int) (*Secret, error) {
+	return c.RenewWithContext(context.Background(), id, increment)
+}
+
+// RenewWithContext is the same as Renew, with a context for the request
+func (c *Sys) RenewWithContext(ctx context.Context, id string, increment int) (*Secret, error) {
 	r := c.c.NewRequest("PUT", "/v1/sys/renew")
 
 	body := map[string]interface{}{
@@ -11,7 +23,113 @@ func (c *Sys) Renew(id string, increment int) (*Secret, error) {
 		return nil, err
 	}
 
-	resp, err := c.c.RawRequest(r)
+	resp, err := c.c.RawRequestWithContext(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	return ParseSecret(resp.Body)
+}
+
+func (c *Sys) LookupLease(id string) (*Secret, error) {
+	return c.LookupLeaseWithContext(context.Background(), id)
+}
+
+// LookupLeaseWithContext is the same as LookupLease, with a context for the
+// request
+func (c *Sys) LookupLeaseWithContext(ctx context.Context, id string) (*Secret, error) {
+	r := c.c.NewRequest("PUT", "/v1/sys/leases/lookup")
+
+	body := map[string]interface{}{
+		"lease_id": id,
+	}
+	if err := r.SetJSONBody(body); err != nil {
+		return nil, err
+	}
+
+	resp, err := c.c.RawRequestWithContext(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	return ParseSecret(resp.Body)
+}
+
+// LookupLeaseInfo returns the metadata of the lease, nil if it doesn't exist
+func (c *Sys) LookupLeaseInfo(id string) (*LeaseInfo, error) {
+	return c.LookupLeaseInfoWithContext(context.Background(), id)
+}
+
+// LookupLeaseInfoWithContext is the same as LookupLeaseInfo, with a context
+// for the request
+func (c *Sys) LookupLeaseInfoWithContext(ctx context.Context, id string) (*LeaseInfo, error) {
+	r := c.c.NewRequest("PUT", "/v1/sys/leases/lookup")
+
+	body := map[string]interface{}{
+		"lease_id": id,
+	}
+	if err := r.SetJSONBody(body); err != nil {
+		return nil, err
+	}
+
+	resp, err := c.c.RawRequestWithContext(ctx, r)
+	if resp != nil {
+		defer resp.Body.Close()
+	}
+	if resp != nil && resp.StatusCode == 400 {
+		// An unknown lease is an invalid request
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		Data *LeaseInfo `json:"data"`
+	}
+	if err := resp.DecodeJSON(&result); err != nil {
+		return nil, err
+	}
+	return result.Data, nil
+}
+
+// ListLeaseKeys returns the leases and the sub-prefixes under the prefix,
+// which ends with a slash
+func (c *Sys) ListLeaseKeys(prefix string) ([]string, error) {
+	return c.ListLeaseKeysWithContext(context.Background(), prefix)
+}
+
+// ListLeaseKeysWithContext is the same as ListLeaseKeys, with a context for
+// the request
+func (c *Sys) ListLeaseKeysWithContext(ctx context.Context, prefix string) ([]string, error) {
+	secret, err := c.ListLeasesWithContext(ctx, prefix)
+	if err != nil {
+		return nil, err
+	}
+	if secret == nil || secret.Data["keys"] == nil {
+		return nil, nil
+	}
+
+	var keys []string
+	if err := mapstructure.Decode(secret.Data["keys"], &keys); err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+func (c *Sys) ListLeases(prefix string) (*Secret, error) {
+	return c.ListLeasesWithContext(context.Background(), prefix)
+}
+
+// ListLeasesWithContext is the same as ListLeases, with a context for the
+// request
+func (c *Sys) ListLeasesWithContext(ctx context.Context, prefix string) (*Secret, error) {
+	r := c.c.NewRequest("GET", "/v1/sys/leases/lookup/"+prefix)
+	r.Params.Set("list", "true")
+
+	resp, err := c.c.RawRequestWithContext(ctx, r)
 	if err != nil {
 		return nil, err
 	}
@@ -21,8 +139,13 @@ func (c *Sys) Renew(id string, increment int) (*Secret, error) {
 }
 
 func (c *Sys) Revoke(id string) error {
+	return c.RevokeWithContext(context.Background(), id)
+}
+
+// RevokeWithContext is the same as Revoke, with a context for the request
+func (c *Sys) RevokeWithContext(ctx context.Context, id string) error {
 	r := c.c.NewRequest("PUT", "/v1/sys/revoke/"+id)
-	resp, err := c.c.RawRequest(r)
+	resp, err := c.c.RawRequestWithContext(ctx, r)
 	if err == nil {
 		defer resp.Body.Close()
 	}
@@ -30,8 +153,14 @@ func (c *Sys) Revoke(id string) error {
 }
 
 func (c *Sys) RevokePrefix(id string) error {
+	return c.RevokePrefixWithContext(context.Background(), id)
+}
+
+// RevokePrefixWithContext is the same as RevokePrefix, with a context for the
+// request
+func (c *Sys) RevokePrefixWithContext(ctx context.Context, id string) error {
 	r := c.c.NewRequest("PUT", "/v1/sys/revoke-prefix/"+id)
-	resp, err := c.c.RawRequest(r)
+	resp, err := c.c.RawRequestWithContext(ctx, r)
 	if err == nil {
 		defer resp.Body.Close()
 	}
@@ -39,10 +168,27 @@ func (c *Sys) RevokePrefix(id string) error {
 }
 
 func (c *Sys) RevokeForce(id string) error {
+	return c.RevokeForceWithContext(context.Background(), id)
+}
+
+// RevokeForceWithContext is the same as RevokeForce, with a context for the
+// request
+func (c *Sys) RevokeForceWithContext(ctx context.Context, id string) error {
 	r := c.c.NewRequest("PUT", "/v1/sys/revoke-force/"+id)
-	resp, err := c.c.RawRequest(r)
+	resp, err := c.c.RawRequestWithContext(ctx, r)
 	if err == nil {
 		defer resp.Body.Close()
 	}
 	return err
 }
+
+// LeaseInfo is the metadata of a lease. The expiration and renewal times are
+// nil for the leases which don't expire or weren't renewed.
+type LeaseInfo struct {
+	ID          string     `json:"id"`
+	IssueTime   time.Time  `json:"issue_time"`
+	ExpireTime  *time.Time `json:"expire_time"`
+	LastRenewal *time.Time `json:"last_renewal"`
+	Renewable   bool       `json:"renewable"`
+	TTL         int        `json:"ttl"`
+}
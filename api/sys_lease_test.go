@@ -0,0 +1,56 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/hashicorp/vault/http"
+	"github.com/hashicorp/vault/vault"
+)
+
+func TestSysLookupLeaseInfo(t *testing.T) {
+	core, _, token := vault.TestCoreUnsealed(t)
+	ln, addr := http.TestServer(t, core)
+	defer ln.Close()
+
+	config := DefaultConfig()
+	config.Address = addr
+
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.SetToken(token)
+
+	if _, err := client.Logical().Write("secret/foo", map[string]interface{}{
+		"data":  "bar",
+		"lease": "1h",
+	}); err != nil {
+		t.Fatal(err)
+	}
+	secret, err := client.Logical().Read("secret/foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := client.Sys().LookupLeaseInfo(secret.LeaseID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.ID != secret.LeaseID || info.IssueTime.IsZero() || info.ExpireTime == nil ||
+		info.LastRenewal != nil || info.TTL <= 0 || info.TTL > 3600 {
+		t.Fatalf("bad: %#v", info)
+	}
+
+	info, err = client.Sys().LookupLeaseInfo("secret/foo/unknown")
+	if err != nil || info != nil {
+		t.Fatalf("bad: %#v, %v", info, err)
+	}
+
+	keys, err := client.Sys().ListLeaseKeys("secret/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 1 || keys[0] != "foo/" {
+		t.Fatalf("bad: %#v", keys)
+	}
+}
@@ -0,0 +1,51 @@
+package api
+
+import "context"
+
+// Monitor streams the log lines of the server at the level, such as debug,
+// or at info if it is empty. The lines are sent on the returned channel,
+// which is closed when stopCh is closed or the server ends the stream. The
+// token must have the sudo capability on sys/monitor.
+func (c *Sys) Monitor(level string, stopCh <-chan struct{}) (<-chan string, error) {
+	stream, err := c.MonitorStream(context.Background(), level)
+	if err != nil {
+		return nil, err
+	}
+
+	logCh := make(chan string)
+	go func() {
+		defer close(logCh)
+		defer stream.Close()
+
+		lines := stream.Lines()
+		for {
+			select {
+			case line, ok := <-lines:
+				if !ok {
+					return
+				}
+				select {
+				case logCh <- line:
+				case <-stopCh:
+					return
+				}
+			case <-stopCh:
+				return
+			}
+		}
+	}()
+
+	return logCh, nil
+}
+
+// MonitorStream returns the stream of the log lines of the server at the
+// level, or at info if it is empty, which lasts until it is closed or the
+// context is done
+func (c *Sys) MonitorStream(ctx context.Context, level string) (*Stream, error) {
+	r := c.c.NewRequest("GET", "/v1/sys/monitor")
+	if level != "" {
+		r.Params.Set("log_level", level)
+	}
+
+	return c.c.RawStreamWithContext(ctx, r)
+}
@@ -1,15 +1,23 @@
 package api
 
 import (
+	"context"
 	"fmt"
+	"time"
 
 	"github.com/fatih/structs"
 	"github.com/mitchellh/mapstructure"
 )
 
 func (c *Sys) ListMounts() (map[string]*MountOutput, error) {
+	return c.ListMountsWithContext(context.Background())
+}
+
+// ListMountsWithContext is the same as ListMounts, with a context for the
+// request
+func (c *Sys) ListMountsWithContext(ctx context.Context) (map[string]*MountOutput, error) {
 	r := c.c.NewRequest("GET", "/v1/sys/mounts")
-	resp, err := c.c.RawRequest(r)
+	resp, err := c.c.RawRequestWithContext(ctx, r)
 	if err != nil {
 		return nil, err
 	}
@@ -44,6 +52,11 @@ func (c *Sys) ListMounts() (map[string]*MountOutput, error) {
 }
 
 func (c *Sys) Mount(path string, mountInfo *MountInput) error {
+	return c.MountWithContext(context.Background(), path, mountInfo)
+}
+
+// MountWithContext is the same as Mount, with a context for the request
+func (c *Sys) MountWithContext(ctx context.Context, path string, mountInfo *MountInput) error {
 	body := structs.Map(mountInfo)
 
 	r := c.c.NewRequest("POST", fmt.Sprintf("/v1/sys/mounts/%s", path))
@@ -51,7 +64,7 @@ func (c *Sys) Mount(path string, mountInfo *MountInput) error {
 		return err
 	}
 
-	resp, err := c.c.RawRequest(r)
+	resp, err := c.c.RawRequestWithContext(ctx, r)
 	if err != nil {
 		return err
 	}
@@ -61,15 +74,66 @@ func (c *Sys) Mount(path string, mountInfo *MountInput) error {
 }
 
 func (c *Sys) Unmount(path string) error {
+	return c.UnmountWithContext(context.Background(), path)
+}
+
+// UnmountWithContext is the same as Unmount, with a context for the request
+func (c *Sys) UnmountWithContext(ctx context.Context, path string) error {
 	r := c.c.NewRequest("DELETE", fmt.Sprintf("/v1/sys/mounts/%s", path))
-	resp, err := c.c.RawRequest(r)
+	resp, err := c.c.RawRequestWithContext(ctx, r)
 	if err == nil {
 		defer resp.Body.Close()
 	}
 	return err
 }
 
+// Remount moves a mount to a new path, waiting for its leases to be moved
+// along with it
 func (c *Sys) Remount(from, to string) error {
+	return c.RemountWithContext(context.Background(), from, to)
+}
+
+// RemountWithContext is the same as Remount, with a context for the request
+func (c *Sys) RemountWithContext(ctx context.Context, from, to string) error {
+	migrationID, err := c.StartRemountWithContext(ctx, from, to)
+	if err != nil {
+		return err
+	}
+
+	// Servers that don't report remount status remount synchronously
+	if migrationID == "" {
+		return nil
+	}
+
+	for {
+		status, err := c.RemountStatusWithContext(ctx, migrationID)
+		if err != nil {
+			return err
+		}
+		switch status.MigrationInfo.MigrationStatus {
+		case "success":
+			return nil
+		case "failure":
+			return fmt.Errorf("remount of '%s' to '%s' failed", from, to)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(remountPollInterval):
+		}
+	}
+}
+
+// StartRemount starts moving a mount to a new path, returning the ID of
+// the migration to check its status with
+func (c *Sys) StartRemount(from, to string) (string, error) {
+	return c.StartRemountWithContext(context.Background(), from, to)
+}
+
+// StartRemountWithContext is the same as StartRemount, with a context for the
+// request
+func (c *Sys) StartRemountWithContext(ctx context.Context, from, to string) (string, error) {
 	body := map[string]interface{}{
 		"from": from,
 		"to":   to,
@@ -77,24 +141,65 @@ func (c *Sys) Remount(from, to string) error {
 
 	r := c.c.NewRequest("POST", "/v1/sys/remount")
 	if err := r.SetJSONBody(body); err != nil {
-		return err
+		return "", err
 	}
 
-	resp, err := c.c.RawRequest(r)
-	if err == nil {
-		defer resp.Body.Close()
+	resp, err := c.c.RawRequestWithContext(ctx, r)
+	if err != nil {
+		return "", err
 	}
-	return err
+	defer resp.Body.Close()
+
+	if resp.StatusCode == 204 {
+		return "", nil
+	}
+
+	var result MountMigrationOutput
+	if err := resp.DecodeJSON(&result); err != nil {
+		return "", err
+	}
+	return result.MigrationID, nil
+}
+
+// RemountStatus returns the status of a remount started with StartRemount
+func (c *Sys) RemountStatus(migrationID string) (*MountMigrationOutput, error) {
+	return c.RemountStatusWithContext(context.Background(), migrationID)
+}
+
+// RemountStatusWithContext is the same as RemountStatus, with a context for the
+// request
+func (c *Sys) RemountStatusWithContext(ctx context.Context, migrationID string) (*MountMigrationOutput, error) {
+	r := c.c.NewRequest("GET", fmt.Sprintf("/v1/sys/remount/status/%s", migrationID))
+
+	resp, err := c.c.RawRequestWithContext(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var result MountMigrationOutput
+	if err := resp.DecodeJSON(&result); err != nil {
+		return nil, err
+	}
+	if result.MigrationInfo == nil {
+		return nil, fmt.Errorf("no status returned for remount '%s'", migrationID)
+	}
+	return &result, nil
 }
 
 func (c *Sys) TuneMount(path string, config MountConfigInput) error {
+	return c.TuneMountWithContext(context.Background(), path, config)
+}
+
+// TuneMountWithContext is the same as TuneMount, with a context for the request
+func (c *Sys) TuneMountWithContext(ctx context.Context, path string, config MountConfigInput) error {
 	body := structs.Map(config)
 	r := c.c.NewRequest("POST", fmt.Sprintf("/v1/sys/mounts/%s/tune", path))
 	if err := r.SetJSONBody(body); err != nil {
 		return err
 	}
 
-	resp, err := c.c.RawRequest(r)
+	resp, err := c.c.RawRequestWithContext(ctx, r)
 	if err == nil {
 		defer resp.Body.Close()
 	}
@@ -102,9 +207,15 @@ func (c *Sys) TuneMount(path string, config MountConfigInput) error {
 }
 
 func (c *Sys) MountConfig(path string) (*MountConfigOutput, error) {
+	return c.MountConfigWithContext(context.Background(), path)
+}
+
+// MountConfigWithContext is the same as MountConfig, with a context for the
+// request
+func (c *Sys) MountConfigWithContext(ctx context.Context, path string) (*MountConfigOutput, error) {
 	r := c.c.NewRequest("GET", fmt.Sprintf("/v1/sys/mounts/%s/tune", path))
 
-	resp, err := c.c.RawRequest(r)
+	resp, err := c.c.RawRequestWithContext(ctx, r)
 	if err != nil {
 		return nil, err
 	}
@@ -119,24 +230,58 @@ func (c *Sys) MountConfig(path string) (*MountConfigOutput, error) {
 	return &result, err
 }
 
+// remountPollInterval is how often Remount checks whether a remount is done
+const remountPollInterval = 100 * time.Millisecond
+
 type MountInput struct {
 	Type        string           `json:"type" structs:"type"`
 	Description string           `json:"description" structs:"description"`
 	Config      MountConfigInput `json:"config" structs:"config"`
+	SealWrap    bool             `json:"seal_wrap" structs:"seal_wrap"`
 }
 
 type MountConfigInput struct {
 	DefaultLeaseTTL string `json:"default_lease_ttl" structs:"default_lease_ttl" mapstructure:"default_lease_ttl"`
 	MaxLeaseTTL     string `json:"max_lease_ttl" structs:"max_lease_ttl" mapstructure:"max_lease_ttl"`
+	Description     string `json:"description,omitempty" structs:"description,omitempty" mapstructure:"description"`
+
+	AuditNonHMACRequestKeys  string `json:"audit_non_hmac_request_keys,omitempty" structs:"audit_non_hmac_request_keys,omitempty" mapstructure:"audit_non_hmac_request_keys"`
+	AuditNonHMACResponseKeys string `json:"audit_non_hmac_response_keys,omitempty" structs:"audit_non_hmac_response_keys,omitempty" mapstructure:"audit_non_hmac_response_keys"`
+
+	PeriodicInterval string `json:"periodic_interval,omitempty" structs:"periodic_interval,omitempty" mapstructure:"periodic_interval"`
+
+	PluginName string `json:"plugin_name,omitempty" structs:"plugin_name,omitempty" mapstructure:"plugin_name"`
 }
 
 type MountOutput struct {
 	Type        string            `json:"type" structs:"type"`
 	Description string            `json:"description" structs:"description"`
 	Config      MountConfigOutput `json:"config" structs:"config"`
+	SealWrap    bool              `json:"seal_wrap" structs:"seal_wrap" mapstructure:"seal_wrap"`
 }
 
 type MountConfigOutput struct {
-	DefaultLeaseTTL int `json:"default_lease_ttl" structs:"default_lease_ttl" mapstructure:"default_lease_ttl"`
-	MaxLeaseTTL     int `json:"max_lease_ttl" structs:"max_lease_ttl" mapstructure:"max_lease_ttl"`
+	DefaultLeaseTTL int    `json:"default_lease_ttl" structs:"default_lease_ttl" mapstructure:"default_lease_ttl"`
+	MaxLeaseTTL     int    `json:"max_lease_ttl" structs:"max_lease_ttl" mapstructure:"max_lease_ttl"`
+	Description     string `json:"description,omitempty" structs:"description,omitempty" mapstructure:"description"`
+
+	AuditNonHMACRequestKeys  []string `json:"audit_non_hmac_request_keys,omitempty" structs:"audit_non_hmac_request_keys,omitempty" mapstructure:"audit_non_hmac_request_keys"`
+	AuditNonHMACResponseKeys []string `json:"audit_non_hmac_response_keys,omitempty" structs:"audit_non_hmac_response_keys,omitempty" mapstructure:"audit_non_hmac_response_keys"`
+
+	PeriodicInterval int `json:"periodic_interval,omitempty" structs:"periodic_interval,omitempty" mapstructure:"periodic_interval"`
+
+	PluginName string `json:"plugin_name,omitempty" structs:"plugin_name,omitempty" mapstructure:"plugin_name"`
+}
+
+type MountMigrationOutput struct {
+	MigrationID   string              `json:"migration_id" structs:"migration_id" mapstructure:"migration_id"`
+	MigrationInfo *MountMigrationInfo `json:"migration_info" structs:"migration_info" mapstructure:"migration_info"`
+}
+
+type MountMigrationInfo struct {
+	SourceMount     string `json:"source_mount" structs:"source_mount" mapstructure:"source_mount"`
+	TargetMount     string `json:"target_mount" structs:"target_mount" mapstructure:"target_mount"`
+	MigrationStatus string `json:"status" structs:"status" mapstructure:"status"`
+	StartTime       string `json:"start_time" structs:"start_time" mapstructure:"start_time"`
+	EndTime         string `json:"end_time" structs:"end_time" mapstructure:"end_time"`
 }
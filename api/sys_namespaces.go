@@ -0,0 +1,126 @@
+package api
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mitchellh/mapstructure"
+)
+
+// NamespaceInfo is the information of a namespace, whose path is relative
+// to the namespace of the client
+type NamespaceInfo struct {
+	ID   string `json:"id" mapstructure:"id"`
+	Path string `json:"path" mapstructure:"path"`
+}
+
+// ListNamespaces returns the paths of the namespaces directly beneath the
+// namespace of the client
+func (c *Sys) ListNamespaces() ([]string, error) {
+	return c.ListNamespacesWithContext(context.Background())
+}
+
+// ListNamespacesWithContext is the same as ListNamespaces, with a context
+// for the request
+func (c *Sys) ListNamespacesWithContext(ctx context.Context) ([]string, error) {
+	r := c.c.NewRequest("LIST", "/v1/sys/namespaces")
+
+	resp, err := c.c.RawRequestWithContext(ctx, r)
+	if resp != nil {
+		defer resp.Body.Close()
+		if resp.StatusCode == 404 {
+			return nil, nil
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := ParseSecret(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if secret == nil || secret.Data["keys"] == nil {
+		return nil, nil
+	}
+
+	var keys []string
+	if err := mapstructure.Decode(secret.Data["keys"], &keys); err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+// GetNamespace returns the namespace with the given path, nil if it doesn't
+// exist
+func (c *Sys) GetNamespace(path string) (*NamespaceInfo, error) {
+	return c.GetNamespaceWithContext(context.Background(), path)
+}
+
+// GetNamespaceWithContext is the same as GetNamespace, with a context for
+// the request
+func (c *Sys) GetNamespaceWithContext(ctx context.Context, path string) (*NamespaceInfo, error) {
+	r := c.c.NewRequest("GET", fmt.Sprintf("/v1/sys/namespaces/%s", path))
+
+	resp, err := c.c.RawRequestWithContext(ctx, r)
+	if resp != nil {
+		defer resp.Body.Close()
+		if resp.StatusCode == 404 {
+			return nil, nil
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		Data *NamespaceInfo `json:"data"`
+	}
+	if err := resp.DecodeJSON(&result); err != nil {
+		return nil, err
+	}
+	return result.Data, nil
+}
+
+// CreateNamespace creates a namespace with the given path. Its parent
+// namespace must exist.
+func (c *Sys) CreateNamespace(path string) (*NamespaceInfo, error) {
+	return c.CreateNamespaceWithContext(context.Background(), path)
+}
+
+// CreateNamespaceWithContext is the same as CreateNamespace, with a context
+// for the request
+func (c *Sys) CreateNamespaceWithContext(ctx context.Context, path string) (*NamespaceInfo, error) {
+	r := c.c.NewRequest("PUT", fmt.Sprintf("/v1/sys/namespaces/%s", path))
+
+	resp, err := c.c.RawRequestWithContext(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		Data *NamespaceInfo `json:"data"`
+	}
+	if err := resp.DecodeJSON(&result); err != nil {
+		return nil, err
+	}
+	return result.Data, nil
+}
+
+// DeleteNamespace deletes the namespace with the given path
+func (c *Sys) DeleteNamespace(path string) error {
+	return c.DeleteNamespaceWithContext(context.Background(), path)
+}
+
+// DeleteNamespaceWithContext is the same as DeleteNamespace, with a context
+// for the request
+func (c *Sys) DeleteNamespaceWithContext(ctx context.Context, path string) error {
+	r := c.c.NewRequest("DELETE", fmt.Sprintf("/v1/sys/namespaces/%s", path))
+
+	resp, err := c.c.RawRequestWithContext(ctx, r)
+	if err == nil {
+		defer resp.Body.Close()
+	}
+	return err
+}
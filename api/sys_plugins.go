@@ -0,0 +1,177 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/mitchellh/mapstructure"
+)
+
+// RegisterPluginInput is used as input to the RegisterPlugin function.
+type RegisterPluginInput struct {
+	// Command is the plugin binary, relative to the plugin directory.
+	Command string
+
+	// Args are the arguments given to the plugin binary.
+	Args []string
+
+	// SHA256 is the hex-encoded SHA256 checksum of the plugin binary.
+	SHA256 string
+}
+
+// ReloadPluginInput is used as input to the ReloadPlugin function.
+type ReloadPluginInput struct {
+	// Plugin is the name of the plugin whose mounts are reloaded.
+	Plugin string
+
+	// Mounts are the paths of the mounts to reload, instead of the mounts
+	// of a plugin. Auth mounts start with "auth/".
+	Mounts []string
+}
+
+// GetPluginResponse is the response of the GetPlugin function.
+type GetPluginResponse struct {
+	Name    string   `json:"name" mapstructure:"name"`
+	Command string   `json:"command" mapstructure:"command"`
+	Args    []string `json:"args" mapstructure:"args"`
+	SHA256  string   `json:"sha256" mapstructure:"sha256"`
+}
+
+// ListPlugins lists the plugins in the catalog.
+func (c *Sys) ListPlugins() ([]string, error) {
+	return c.ListPluginsWithContext(context.Background())
+}
+
+// ListPluginsWithContext is the same as ListPlugins, with a context for the
+// request
+func (c *Sys) ListPluginsWithContext(ctx context.Context) ([]string, error) {
+	r := c.c.NewRequest("LIST", "/v1/sys/plugins/catalog")
+	resp, err := c.c.RawRequestWithContext(ctx, r)
+	if resp != nil {
+		defer resp.Body.Close()
+		if resp.StatusCode == 404 {
+			return nil, nil
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := ParseSecret(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if secret == nil || secret.Data == nil {
+		return nil, nil
+	}
+
+	var names []string
+	if err := mapstructure.Decode(secret.Data["keys"], &names); err != nil {
+		return nil, err
+	}
+	return names, nil
+}
+
+// GetPlugin returns a plugin of the catalog, or nil if there is none with
+// the given name.
+func (c *Sys) GetPlugin(name string) (*GetPluginResponse, error) {
+	return c.GetPluginWithContext(context.Background(), name)
+}
+
+// GetPluginWithContext is the same as GetPlugin, with a context for the request
+func (c *Sys) GetPluginWithContext(ctx context.Context, name string) (*GetPluginResponse, error) {
+	r := c.c.NewRequest("GET", fmt.Sprintf("/v1/sys/plugins/catalog/%s", name))
+	resp, err := c.c.RawRequestWithContext(ctx, r)
+	if resp != nil {
+		defer resp.Body.Close()
+		if resp.StatusCode == 404 {
+			return nil, nil
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := ParseSecret(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if secret == nil || secret.Data == nil {
+		return nil, nil
+	}
+
+	var result GetPluginResponse
+	if err := mapstructure.Decode(secret.Data, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// RegisterPlugin registers a plugin in the catalog.
+func (c *Sys) RegisterPlugin(name string, input *RegisterPluginInput) error {
+	return c.RegisterPluginWithContext(context.Background(), name, input)
+}
+
+// RegisterPluginWithContext is the same as RegisterPlugin, with a context for
+// the request
+func (c *Sys) RegisterPluginWithContext(ctx context.Context, name string, input *RegisterPluginInput) error {
+	body := map[string]interface{}{
+		"command": input.Command,
+		"args":    strings.Join(input.Args, ","),
+		"sha256":  input.SHA256,
+	}
+
+	r := c.c.NewRequest("PUT", fmt.Sprintf("/v1/sys/plugins/catalog/%s", name))
+	if err := r.SetJSONBody(body); err != nil {
+		return err
+	}
+
+	resp, err := c.c.RawRequestWithContext(ctx, r)
+	if err == nil {
+		defer resp.Body.Close()
+	}
+	return err
+}
+
+// DeregisterPlugin removes a plugin from the catalog.
+func (c *Sys) DeregisterPlugin(name string) error {
+	return c.DeregisterPluginWithContext(context.Background(), name)
+}
+
+// DeregisterPluginWithContext is the same as DeregisterPlugin, with a context
+// for the request
+func (c *Sys) DeregisterPluginWithContext(ctx context.Context, name string) error {
+	r := c.c.NewRequest("DELETE", fmt.Sprintf("/v1/sys/plugins/catalog/%s", name))
+	resp, err := c.c.RawRequestWithContext(ctx, r)
+	if err == nil {
+		defer resp.Body.Close()
+	}
+	return err
+}
+
+// ReloadPlugin restarts the plugin backends of mounts without unmounting
+// them.
+func (c *Sys) ReloadPlugin(input *ReloadPluginInput) error {
+	return c.ReloadPluginWithContext(context.Background(), input)
+}
+
+// ReloadPluginWithContext is the same as ReloadPlugin, with a context for the
+// request
+func (c *Sys) ReloadPluginWithContext(ctx context.Context, input *ReloadPluginInput) error {
+	body := map[string]interface{}{
+		"plugin": input.Plugin,
+		"mounts": strings.Join(input.Mounts, ","),
+	}
+
+	r := c.c.NewRequest("PUT", "/v1/sys/plugins/reload/backend")
+	if err := r.SetJSONBody(body); err != nil {
+		return err
+	}
+
+	resp, err := c.c.RawRequestWithContext(ctx, r)
+	if err == nil {
+		defer resp.Body.Close()
+	}
+	return err
+}
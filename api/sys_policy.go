@@ -1,10 +1,19 @@
 package api
 
-import "fmt"
+import (
+	"context"
+	"fmt"
+)
 
 func (c *Sys) ListPolicies() ([]string, error) {
+	return c.ListPoliciesWithContext(context.Background())
+}
+
+// ListPoliciesWithContext is the same as ListPolicies, with a context for the
+// request
+func (c *Sys) ListPoliciesWithContext(ctx context.Context) ([]string, error) {
 	r := c.c.NewRequest("GET", "/v1/sys/policy")
-	resp, err := c.c.RawRequest(r)
+	resp, err := c.c.RawRequestWithContext(ctx, r)
 	if err != nil {
 		return nil, err
 	}
@@ -32,8 +41,13 @@ func (c *Sys) ListPolicies() ([]string, error) {
 }
 
 func (c *Sys) GetPolicy(name string) (string, error) {
+	return c.GetPolicyWithContext(context.Background(), name)
+}
+
+// GetPolicyWithContext is the same as GetPolicy, with a context for the request
+func (c *Sys) GetPolicyWithContext(ctx context.Context, name string) (string, error) {
 	r := c.c.NewRequest("GET", fmt.Sprintf("/v1/sys/policy/%s", name))
-	resp, err := c.c.RawRequest(r)
+	resp, err := c.c.RawRequestWithContext(ctx, r)
 	if resp != nil {
 		defer resp.Body.Close()
 		if resp.StatusCode == 404 {
@@ -59,6 +73,11 @@ func (c *Sys) GetPolicy(name string) (string, error) {
 }
 
 func (c *Sys) PutPolicy(name, rules string) error {
+	return c.PutPolicyWithContext(context.Background(), name, rules)
+}
+
+// PutPolicyWithContext is the same as PutPolicy, with a context for the request
+func (c *Sys) PutPolicyWithContext(ctx context.Context, name, rules string) error {
 	body := map[string]string{
 		"rules": rules,
 	}
@@ -68,7 +87,7 @@ func (c *Sys) PutPolicy(name, rules string) error {
 		return err
 	}
 
-	resp, err := c.c.RawRequest(r)
+	resp, err := c.c.RawRequestWithContext(ctx, r)
 	if err != nil {
 		return err
 	}
@@ -78,8 +97,14 @@ func (c *Sys) PutPolicy(name, rules string) error {
 }
 
 func (c *Sys) DeletePolicy(name string) error {
+	return c.DeletePolicyWithContext(context.Background(), name)
+}
+
+// DeletePolicyWithContext is the same as DeletePolicy, with a context for the
+// request
+func (c *Sys) DeletePolicyWithContext(ctx context.Context, name string) error {
 	r := c.c.NewRequest("DELETE", fmt.Sprintf("/v1/sys/policy/%s", name))
-	resp, err := c.c.RawRequest(r)
+	resp, err := c.c.RawRequestWithContext(ctx, r)
 	if err == nil {
 		defer resp.Body.Close()
 	}
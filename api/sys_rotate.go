@@ -1,10 +1,18 @@
 package api
 
-import "time"
+import (
+	"context"
+	"time"
+)
 
 func (c *Sys) Rotate() error {
+	return c.RotateWithContext(context.Background())
+}
+
+// RotateWithContext is the same as Rotate, with a context for the request
+func (c *Sys) RotateWithContext(ctx context.Context) error {
 	r := c.c.NewRequest("POST", "/v1/sys/rotate")
-	resp, err := c.c.RawRequest(r)
+	resp, err := c.c.RawRequestWithContext(ctx, r)
 	if err == nil {
 		defer resp.Body.Close()
 	}
@@ -12,8 +20,13 @@ func (c *Sys) Rotate() error {
 }
 
 func (c *Sys) KeyStatus() (*KeyStatus, error) {
+	return c.KeyStatusWithContext(context.Background())
+}
+
+// KeyStatusWithContext is the same as KeyStatus, with a context for the request
+func (c *Sys) KeyStatusWithContext(ctx context.Context) (*KeyStatus, error) {
 	r := c.c.NewRequest("GET", "/v1/sys/key-status")
-	resp, err := c.c.RawRequest(r)
+	resp, err := c.c.RawRequestWithContext(ctx, r)
 	if err != nil {
 		return nil, err
 	}
@@ -1,13 +1,26 @@
 package api
 
+import "context"
+
 func (c *Sys) SealStatus() (*SealStatusResponse, error) {
+	return c.SealStatusWithContext(context.Background())
+}
+
+// SealStatusWithContext is the same as SealStatus, with a context for the
+// request
+func (c *Sys) SealStatusWithContext(ctx context.Context) (*SealStatusResponse, error) {
 	r := c.c.NewRequest("GET", "/v1/sys/seal-status")
-	return sealStatusRequest(c, r)
+	return sealStatusRequest(ctx, c, r)
 }
 
 func (c *Sys) Seal() error {
+	return c.SealWithContext(context.Background())
+}
+
+// SealWithContext is the same as Seal, with a context for the request
+func (c *Sys) SealWithContext(ctx context.Context) error {
 	r := c.c.NewRequest("PUT", "/v1/sys/seal")
-	resp, err := c.c.RawRequest(r)
+	resp, err := c.c.RawRequestWithContext(ctx, r)
 	if err == nil {
 		defer resp.Body.Close()
 	}
@@ -15,6 +28,12 @@ func (c *Sys) Seal() error {
 }
 
 func (c *Sys) ResetUnsealProcess() (*SealStatusResponse, error) {
+	return c.ResetUnsealProcessWithContext(context.Background())
+}
+
+// ResetUnsealProcessWithContext is the same as ResetUnsealProcess, with a
+// context for the request
+func (c *Sys) ResetUnsealProcessWithContext(ctx context.Context) (*SealStatusResponse, error) {
 	body := map[string]interface{}{"reset": true}
 
 	r := c.c.NewRequest("PUT", "/v1/sys/unseal")
@@ -22,10 +41,15 @@ func (c *Sys) ResetUnsealProcess() (*SealStatusResponse, error) {
 		return nil, err
 	}
 
-	return sealStatusRequest(c, r)
+	return sealStatusRequest(ctx, c, r)
 }
 
 func (c *Sys) Unseal(shard string) (*SealStatusResponse, error) {
+	return c.UnsealWithContext(context.Background(), shard)
+}
+
+// UnsealWithContext is the same as Unseal, with a context for the request
+func (c *Sys) UnsealWithContext(ctx context.Context, shard string) (*SealStatusResponse, error) {
 	body := map[string]interface{}{"key": shard}
 
 	r := c.c.NewRequest("PUT", "/v1/sys/unseal")
@@ -33,11 +57,31 @@ func (c *Sys) Unseal(shard string) (*SealStatusResponse, error) {
 		return nil, err
 	}
 
-	return sealStatusRequest(c, r)
+	return sealStatusRequest(ctx, c, r)
+}
+
+// UnsealMigrate provides a key to unseal Vault while migrating its seal.
+// The key is an unseal key of the current seal if it is a Shamir seal, or
+// a recovery key if it is an auto-unseal seal.
+func (c *Sys) UnsealMigrate(shard string) (*SealStatusResponse, error) {
+	return c.UnsealMigrateWithContext(context.Background(), shard)
+}
+
+// UnsealMigrateWithContext is the same as UnsealMigrate, with a context for the
+// request
+func (c *Sys) UnsealMigrateWithContext(ctx context.Context, shard string) (*SealStatusResponse, error) {
+	body := map[string]interface{}{"key": shard, "migrate": true}
+
+	r := c.c.NewRequest("PUT", "/v1/sys/unseal")
+	if err := r.SetJSONBody(body); err != nil {
+		return nil, err
+	}
+
+	return sealStatusRequest(ctx, c, r)
 }
 
-func sealStatusRequest(c *Sys, r *Request) (*SealStatusResponse, error) {
-	resp, err := c.c.RawRequest(r)
+func sealStatusRequest(ctx context.Context, c *Sys, r *Request) (*SealStatusResponse, error) {
+	resp, err := c.c.RawRequestWithContext(ctx, r)
 	if err != nil {
 		return nil, err
 	}
@@ -49,7 +93,9 @@ func sealStatusRequest(c *Sys, r *Request) (*SealStatusResponse, error) {
 }
 
 type SealStatusResponse struct {
+	Type        string `json:"type"`
 	Sealed      bool   `json:"sealed"`
+	Migration   bool   `json:"migration,omitempty"`
 	T           int    `json:"t"`
 	N           int    `json:"n"`
 	Progress    int    `json:"progress"`
@@ -1,8 +1,15 @@
 package api
 
+import "context"
+
 func (c *Sys) StepDown() error {
+	return c.StepDownWithContext(context.Background())
+}
+
+// StepDownWithContext is the same as StepDown, with a context for the request
+func (c *Sys) StepDownWithContext(ctx context.Context) error {
 	r := c.c.NewRequest("PUT", "/v1/sys/step-down")
-	resp, err := c.c.RawRequest(r)
+	resp, err := c.c.RawRequestWithContext(ctx, r)
 	if err == nil {
 		defer resp.Body.Close()
 	}
@@ -11,16 +11,16 @@ import (
 // or other external services.
 type Backend interface {
 	// LogRequest is used to synchronously log a request. This is done after the
-	// request is authorized but before the request is executed. The arguments
+	// request is authorized but before the request is executed. The inputs
 	// MUST not be modified in anyway. They should be deep copied if this is
 	// a possibility.
-	LogRequest(*logical.Auth, *logical.Request, error) error
+	LogRequest(*LogInput) error
 
 	// LogResponse is used to synchronously log a response. This is done after
-	// the request is processed but before the response is sent. The arguments
+	// the request is processed but before the response is sent. The inputs
 	// MUST not be modified in anyway. They should be deep copied if this is
 	// a possibility.
-	LogResponse(*logical.Auth, *logical.Request, *logical.Response, error) error
+	LogResponse(*LogInput) error
 
 	// GetHash is used to return the given data with the backend's hash,
 	// so that a caller can determine if a value in the audit log matches
@@ -28,6 +28,20 @@ type Backend interface {
 	GetHash(string) string
 }
 
+// LogInput contains the inputs of LogRequest and LogResponse
+type LogInput struct {
+	Auth     *logical.Auth
+	Request  *logical.Request
+	Response *logical.Response
+	OuterErr error
+
+	// NonHMACReqDataKeys and NonHMACRespDataKeys are the keys of the
+	// request and response data whose values are logged without being
+	// HMAC'd, as configured on the mount serving the request
+	NonHMACReqDataKeys  []string
+	NonHMACRespDataKeys []string
+}
+
 type BackendConfig struct {
 	// The salt that should be used for any secret obfuscation
 	Salt *salt.Salt
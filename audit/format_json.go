@@ -45,9 +45,11 @@ func (f *FormatJSON) FormatRequest(
 			ID:          req.ID,
 			Operation:   req.Operation,
 			Path:        req.Path,
+			Namespace:   req.Namespace,
 			Data:        req.Data,
 			RemoteAddr:  getRemoteAddr(req),
 			WrapTTL:     int(req.WrapTTL / time.Second),
+			Headers:     req.Headers,
 		},
 	})
 }
@@ -93,6 +95,7 @@ func (f *FormatJSON) FormatResponse(
 		respWrapInfo = &JSONWrapInfo{
 			TTL:             int(resp.WrapInfo.TTL / time.Second),
 			Token:           resp.WrapInfo.Token,
+			Accessor:        resp.WrapInfo.Accessor,
 			CreationTime:    resp.WrapInfo.CreationTime,
 			WrappedAccessor: resp.WrapInfo.WrappedAccessor,
 		}
@@ -116,9 +119,11 @@ func (f *FormatJSON) FormatResponse(
 			ID:          req.ID,
 			Operation:   req.Operation,
 			Path:        req.Path,
+			Namespace:   req.Namespace,
 			Data:        req.Data,
 			RemoteAddr:  getRemoteAddr(req),
 			WrapTTL:     int(req.WrapTTL / time.Second),
+			Headers:     req.Headers,
 		},
 
 		Response: JSONResponse{
@@ -155,9 +160,11 @@ type JSONRequest struct {
 	Operation   logical.Operation      `json:"operation"`
 	ClientToken string                 `json:"client_token"`
 	Path        string                 `json:"path"`
+	Namespace   string                 `json:"namespace,omitempty"`
 	Data        map[string]interface{} `json:"data"`
 	RemoteAddr  string                 `json:"remote_address"`
 	WrapTTL     int                    `json:"wrap_ttl"`
+	Headers     map[string][]string    `json:"headers,omitempty"`
 }
 
 type JSONResponse struct {
@@ -183,6 +190,7 @@ type JSONSecret struct {
 type JSONWrapInfo struct {
 	TTL             int       `json:"ttl"`
 	Token           string    `json:"token"`
+	Accessor        string    `json:"accessor,omitempty"`
 	CreationTime    time.Time `json:"creation_time"`
 	WrappedAccessor string    `json:"wrapped_accessor,omitempty"`
 }
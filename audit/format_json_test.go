@@ -33,6 +33,21 @@ func TestFormatJSON_formatRequest(t *testing.T) {
 			errors.New("this is an error"),
 			testFormatJSONReqBasicStr,
 		},
+		"request with headers": {
+			&logical.Auth{ClientToken: "foo", Policies: []string{"root"}},
+			&logical.Request{
+				Operation: logical.UpdateOperation,
+				Path:      "/foo",
+				Connection: &logical.Connection{
+					RemoteAddr: "127.0.0.1",
+				},
+				Headers: map[string][]string{
+					"x-forwarded-for": []string{"10.0.0.1"},
+				},
+			},
+			nil,
+			testFormatJSONReqHeadersStr,
+		},
 	}
 
 	for name, tc := range cases {
@@ -69,3 +84,6 @@ func TestFormatJSON_formatRequest(t *testing.T) {
 
 const testFormatJSONReqBasicStr = `{"time":"2015-08-05T13:45:46Z","type":"request","auth":{"display_name":"","policies":["root"],"metadata":null},"request":{"operation":"update","path":"/foo","data":null,"wrap_ttl":60,"remote_address":"127.0.0.1"},"error":"this is an error"}
 `
+
+const testFormatJSONReqHeadersStr = `{"time":"2015-08-05T13:45:46Z","type":"request","auth":{"display_name":"","policies":["root"],"metadata":null},"request":{"operation":"update","path":"/foo","data":null,"wrap_ttl":0,"remote_address":"127.0.0.1","headers":{"x-forwarded-for":["10.0.0.1"]}},"error":""}
+`
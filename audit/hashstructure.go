@@ -17,10 +17,11 @@ func HashString(salter *salt.Salt, data string) string {
 
 // Hash will hash the given type. This has built-in support for auth,
 // requests, and responses. If it is a type that isn't recognized, then
-// it will be passed through.
+// it will be passed through. The values of the top-level keys of request
+// and response data listed in nonHMACDataKeys are left as they are.
 //
 // The structure is modified in-place.
-func Hash(salter *salt.Salt, raw interface{}) error {
+func Hash(salter *salt.Salt, raw interface{}, nonHMACDataKeys []string) error {
 	fn := salter.GetIdentifiedHMAC
 
 	switch s := raw.(type) {
@@ -40,7 +41,7 @@ func Hash(salter *salt.Salt, raw interface{}) error {
 			return nil
 		}
 		if s.Auth != nil {
-			if err := Hash(salter, s.Auth); err != nil {
+			if err := Hash(salter, s.Auth, nil); err != nil {
 				return err
 			}
 		}
@@ -49,12 +50,12 @@ func Hash(salter *salt.Salt, raw interface{}) error {
 			s.ClientToken = fn(s.ClientToken)
 		}
 
-		data, err := HashStructure(s.Data, fn)
+		data, err := hashData(s.Data, fn, nonHMACDataKeys)
 		if err != nil {
 			return err
 		}
 
-		s.Data = data.(map[string]interface{})
+		s.Data = data
 
 	case *logical.Response:
 		if s == nil {
@@ -62,23 +63,23 @@ func Hash(salter *salt.Salt, raw interface{}) error {
 		}
 
 		if s.Auth != nil {
-			if err := Hash(salter, s.Auth); err != nil {
+			if err := Hash(salter, s.Auth, nil); err != nil {
 				return err
 			}
 		}
 
 		if s.WrapInfo != nil {
-			if err := Hash(salter, s.WrapInfo); err != nil {
+			if err := Hash(salter, s.WrapInfo, nil); err != nil {
 				return err
 			}
 		}
 
-		data, err := HashStructure(s.Data, fn)
+		data, err := hashData(s.Data, fn, nonHMACDataKeys)
 		if err != nil {
 			return err
 		}
 
-		s.Data = data.(map[string]interface{})
+		s.Data = data
 
 	case *logical.WrapInfo:
 		if s == nil {
@@ -87,6 +88,10 @@ func Hash(salter *salt.Salt, raw interface{}) error {
 
 		s.Token = fn(s.Token)
 
+		if s.Accessor != "" {
+			s.Accessor = fn(s.Accessor)
+		}
+
 		if s.WrappedAccessor != "" {
 			s.WrappedAccessor = fn(s.WrappedAccessor)
 		}
@@ -95,6 +100,23 @@ func Hash(salter *salt.Salt, raw interface{}) error {
 	return nil
 }
 
+// hashData hashes the values of the given request or response data, except
+// for those of the top-level keys listed in nonHMACDataKeys
+func hashData(data map[string]interface{}, cb HashCallback, nonHMACDataKeys []string) (map[string]interface{}, error) {
+	hashed, err := HashStructure(data, cb)
+	if err != nil {
+		return nil, err
+	}
+
+	out := hashed.(map[string]interface{})
+	for _, k := range nonHMACDataKeys {
+		if v, ok := data[k]; ok {
+			out[k] = v
+		}
+	}
+	return out, nil
+}
+
 // HashStructure takes an interface and hashes all the values within
 // the structure. Only _values_ are hashed: keys of objects are not.
 //
@@ -112,12 +112,14 @@ func TestHash(t *testing.T) {
 	now := time.Now()
 
 	cases := []struct {
-		Input  interface{}
-		Output interface{}
+		Input           interface{}
+		Output          interface{}
+		NonHMACDataKeys []string
 	}{
 		{
 			&logical.Auth{ClientToken: "foo"},
 			&logical.Auth{ClientToken: "hmac-sha256:08ba357e274f528065766c770a639abf6809b39ccfd37c2a3157c7f51954da0a"},
+			nil,
 		},
 		{
 			&logical.Request{
@@ -132,6 +134,7 @@ func TestHash(t *testing.T) {
 					"private_key_type": "hmac-sha256:995230dca56fffd310ff591aa404aab52b2abb41703c787cfa829eceb4595bf1",
 				},
 			},
+			nil,
 		},
 		{
 			&logical.Response{
@@ -156,10 +159,42 @@ func TestHash(t *testing.T) {
 					WrappedAccessor: "hmac-sha256:f9320baf0249169e73850cd6156ded0106e2bb6ad8cab01b7bbbebe6d1065317",
 				},
 			},
+			nil,
+		},
+		{
+			&logical.Request{
+				Data: map[string]interface{}{
+					"foo":  "bar",
+					"role": "baz",
+				},
+			},
+			&logical.Request{
+				Data: map[string]interface{}{
+					"foo":  "hmac-sha256:f9320baf0249169e73850cd6156ded0106e2bb6ad8cab01b7bbbebe6d1065317",
+					"role": "baz",
+				},
+			},
+			[]string{"role", "unknown"},
+		},
+		{
+			&logical.Response{
+				Data: map[string]interface{}{
+					"foo":  "bar",
+					"role": "baz",
+				},
+			},
+			&logical.Response{
+				Data: map[string]interface{}{
+					"foo":  "hmac-sha256:f9320baf0249169e73850cd6156ded0106e2bb6ad8cab01b7bbbebe6d1065317",
+					"role": "baz",
+				},
+			},
+			[]string{"role"},
 		},
 		{
 			"foo",
 			"foo",
+			nil,
 		},
 		{
 			&logical.Auth{
@@ -178,6 +213,7 @@ func TestHash(t *testing.T) {
 
 				ClientToken: "hmac-sha256:08ba357e274f528065766c770a639abf6809b39ccfd37c2a3157c7f51954da0a",
 			},
+			nil,
 		},
 	}
 
@@ -195,7 +231,7 @@ func TestHash(t *testing.T) {
 	}
 	for _, tc := range cases {
 		input := fmt.Sprintf("%#v", tc.Input)
-		if err := Hash(localSalt, tc.Input); err != nil {
+		if err := Hash(localSalt, tc.Input, tc.NonHMACDataKeys); err != nil {
 			t.Fatalf("err: %s\n\n%s", err, input)
 		}
 		if !reflect.DeepEqual(tc.Input, tc.Output) {
@@ -82,7 +82,8 @@ func (b *Backend) GetHash(data string) string {
 	return audit.HashString(b.salt, data)
 }
 
-func (b *Backend) LogRequest(auth *logical.Auth, req *logical.Request, outerErr error) error {
+func (b *Backend) LogRequest(in *audit.LogInput) error {
+	auth, req, outerErr := in.Auth, in.Request, in.OuterErr
 	if err := b.open(); err != nil {
 		return err
 	}
@@ -112,10 +113,10 @@ func (b *Backend) LogRequest(auth *logical.Auth, req *logical.Request, outerErr
 		req = cp.(*logical.Request)
 
 		// Hash any sensitive information
-		if err := audit.Hash(b.salt, auth); err != nil {
+		if err := audit.Hash(b.salt, auth, nil); err != nil {
 			return err
 		}
-		if err := audit.Hash(b.salt, req); err != nil {
+		if err := audit.Hash(b.salt, req, in.NonHMACReqDataKeys); err != nil {
 			return err
 		}
 
@@ -125,11 +126,8 @@ func (b *Backend) LogRequest(auth *logical.Auth, req *logical.Request, outerErr
 	return format.FormatRequest(b.f, auth, req, outerErr)
 }
 
-func (b *Backend) LogResponse(
-	auth *logical.Auth,
-	req *logical.Request,
-	resp *logical.Response,
-	err error) error {
+func (b *Backend) LogResponse(in *audit.LogInput) error {
+	auth, req, resp, err := in.Auth, in.Request, in.Response, in.OuterErr
 	if err := b.open(); err != nil {
 		return err
 	}
@@ -171,14 +169,14 @@ func (b *Backend) LogResponse(
 		if !b.hmacAccessor && auth != nil && auth.Accessor != "" {
 			accessor = auth.Accessor
 		}
-		if err := audit.Hash(b.salt, auth); err != nil {
+		if err := audit.Hash(b.salt, auth, nil); err != nil {
 			return err
 		}
 		if accessor != "" {
 			auth.Accessor = accessor
 		}
 
-		if err := audit.Hash(b.salt, req); err != nil {
+		if err := audit.Hash(b.salt, req, in.NonHMACReqDataKeys); err != nil {
 			return err
 		}
 
@@ -190,7 +188,7 @@ func (b *Backend) LogResponse(
 		if !b.hmacAccessor && resp != nil && resp.WrapInfo != nil && resp.WrapInfo.WrappedAccessor != "" {
 			wrappedAccessor = resp.WrapInfo.WrappedAccessor
 		}
-		if err := audit.Hash(b.salt, resp); err != nil {
+		if err := audit.Hash(b.salt, resp, in.NonHMACRespDataKeys); err != nil {
 			return err
 		}
 		if accessor != "" {
@@ -0,0 +1,270 @@
+package socket
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/vault/audit"
+	"github.com/hashicorp/vault/helper/duration"
+	"github.com/hashicorp/vault/helper/salt"
+	"github.com/hashicorp/vault/logical"
+	"github.com/mitchellh/copystructure"
+)
+
+func Factory(conf *audit.BackendConfig) (audit.Backend, error) {
+	if conf.Salt == nil {
+		return nil, fmt.Errorf("nil salt")
+	}
+
+	address, ok := conf.Config["address"]
+	if !ok {
+		return nil, fmt.Errorf("address is required")
+	}
+
+	// Get the socket type or default to TCP
+	socketType, ok := conf.Config["socket_type"]
+	if !ok {
+		socketType = "tcp"
+	}
+	switch socketType {
+	case "tcp", "udp", "unix":
+	default:
+		return nil, fmt.Errorf("unsupported socket_type %q, must be one of tcp, udp or unix", socketType)
+	}
+
+	// Get the write timeout or default to two seconds
+	writeDuration, ok := conf.Config["write_timeout"]
+	if !ok {
+		writeDuration = "2s"
+	}
+	writeDeadline, err := duration.ParseDurationSecond(writeDuration)
+	if err != nil {
+		return nil, err
+	}
+
+	// Check if hashing of accessor is disabled
+	hmacAccessor := true
+	if hmacAccessorRaw, ok := conf.Config["hmac_accessor"]; ok {
+		value, err := strconv.ParseBool(hmacAccessorRaw)
+		if err != nil {
+			return nil, err
+		}
+		hmacAccessor = value
+	}
+
+	// Check if raw logging is enabled
+	logRaw := false
+	if raw, ok := conf.Config["log_raw"]; ok {
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return nil, err
+		}
+		logRaw = b
+	}
+
+	b := &Backend{
+		address:       address,
+		socketType:    socketType,
+		writeDeadline: writeDeadline,
+		logRaw:        logRaw,
+		hmacAccessor:  hmacAccessor,
+		salt:          conf.Salt,
+	}
+
+	return b, nil
+}
+
+// Backend is the audit backend for the socket-based audit store. Entries
+// are written to a single connection, which is established on the first
+// write rather than when the backend is created, so that Vault can unseal
+// while the collector is unreachable. The connection is reestablished once
+// if a write fails.
+type Backend struct {
+	address       string
+	socketType    string
+	writeDeadline time.Duration
+	logRaw        bool
+	hmacAccessor  bool
+	salt          *salt.Salt
+
+	l    sync.Mutex
+	conn net.Conn
+}
+
+func (b *Backend) GetHash(data string) string {
+	return audit.HashString(b.salt, data)
+}
+
+func (b *Backend) LogRequest(in *audit.LogInput) error {
+	auth, req, outerErr := in.Auth, in.Request, in.OuterErr
+	if !b.logRaw {
+		// Before we copy the structure we must nil out some data
+		// otherwise we will cause reflection to panic and die
+		if req.Connection != nil && req.Connection.ConnState != nil {
+			origReq := req
+			origState := req.Connection.ConnState
+			req.Connection.ConnState = nil
+			defer func() {
+				origReq.Connection.ConnState = origState
+			}()
+		}
+
+		// Copy the structures
+		cp, err := copystructure.Copy(auth)
+		if err != nil {
+			return err
+		}
+		auth = cp.(*logical.Auth)
+
+		cp, err = copystructure.Copy(req)
+		if err != nil {
+			return err
+		}
+		req = cp.(*logical.Request)
+
+		// Hash any sensitive information
+		if err := audit.Hash(b.salt, auth, nil); err != nil {
+			return err
+		}
+		if err := audit.Hash(b.salt, req, in.NonHMACReqDataKeys); err != nil {
+			return err
+		}
+	}
+
+	// Encode the entry as JSON
+	var buf bytes.Buffer
+	var format audit.FormatJSON
+	if err := format.FormatRequest(&buf, auth, req, outerErr); err != nil {
+		return err
+	}
+
+	return b.write(buf.Bytes())
+}
+
+func (b *Backend) LogResponse(in *audit.LogInput) error {
+	auth, req, resp, err := in.Auth, in.Request, in.Response, in.OuterErr
+	if !b.logRaw {
+		// Before we copy the structure we must nil out some data
+		// otherwise we will cause reflection to panic and die
+		if req.Connection != nil && req.Connection.ConnState != nil {
+			origReq := req
+			origState := req.Connection.ConnState
+			req.Connection.ConnState = nil
+			defer func() {
+				origReq.Connection.ConnState = origState
+			}()
+		}
+
+		// Copy the structure
+		cp, err := copystructure.Copy(auth)
+		if err != nil {
+			return err
+		}
+		auth = cp.(*logical.Auth)
+
+		cp, err = copystructure.Copy(req)
+		if err != nil {
+			return err
+		}
+		req = cp.(*logical.Request)
+
+		cp, err = copystructure.Copy(resp)
+		if err != nil {
+			return err
+		}
+		resp = cp.(*logical.Response)
+
+		// Hash any sensitive information
+
+		// Cache and restore accessor in the auth
+		var accessor, wrappedAccessor string
+		if !b.hmacAccessor && auth != nil && auth.Accessor != "" {
+			accessor = auth.Accessor
+		}
+		if err := audit.Hash(b.salt, auth, nil); err != nil {
+			return err
+		}
+		if accessor != "" {
+			auth.Accessor = accessor
+		}
+
+		if err := audit.Hash(b.salt, req, in.NonHMACReqDataKeys); err != nil {
+			return err
+		}
+
+		// Cache and restore accessor in the response
+		accessor = ""
+		if !b.hmacAccessor && resp != nil && resp.Auth != nil && resp.Auth.Accessor != "" {
+			accessor = resp.Auth.Accessor
+		}
+		if !b.hmacAccessor && resp != nil && resp.WrapInfo != nil && resp.WrapInfo.WrappedAccessor != "" {
+			wrappedAccessor = resp.WrapInfo.WrappedAccessor
+		}
+		if err := audit.Hash(b.salt, resp, in.NonHMACRespDataKeys); err != nil {
+			return err
+		}
+		if accessor != "" {
+			resp.Auth.Accessor = accessor
+		}
+		if wrappedAccessor != "" {
+			resp.WrapInfo.WrappedAccessor = wrappedAccessor
+		}
+	}
+
+	// Encode the entry as JSON
+	var buf bytes.Buffer
+	var format audit.FormatJSON
+	if err := format.FormatResponse(&buf, auth, req, resp, err); err != nil {
+		return err
+	}
+
+	return b.write(buf.Bytes())
+}
+
+// write sends an encoded entry over the connection, reconnecting and
+// trying again once if the write fails
+func (b *Backend) write(buf []byte) error {
+	b.l.Lock()
+	defer b.l.Unlock()
+
+	if b.conn != nil {
+		if err := b.writeConn(buf); err == nil {
+			return nil
+		}
+	}
+
+	if err := b.reconnect(); err != nil {
+		return err
+	}
+	return b.writeConn(buf)
+}
+
+func (b *Backend) writeConn(buf []byte) error {
+	if err := b.conn.SetWriteDeadline(time.Now().Add(b.writeDeadline)); err != nil {
+		return err
+	}
+	_, err := b.conn.Write(buf)
+	return err
+}
+
+// reconnect replaces the connection with a new one
+func (b *Backend) reconnect() error {
+	if b.conn != nil {
+		b.conn.Close()
+		b.conn = nil
+	}
+
+	dialer := net.Dialer{
+		Timeout: b.writeDeadline,
+	}
+	conn, err := dialer.Dial(b.socketType, b.address)
+	if err != nil {
+		return err
+	}
+	b.conn = conn
+	return nil
+}
@@ -1,4 +1,4 @@
-package file
+package syslog
 
 import (
 	"bytes"
@@ -14,7 +14,7 @@ import (
 
 func Factory(conf *audit.BackendConfig) (audit.Backend, error) {
 	if conf.Salt == nil {
-		return nil, fmt.Errorf("Nil salt passed in")
+		return nil, fmt.Errorf("nil salt")
 	}
 
 	// Get facility or default to AUTH
@@ -76,7 +76,8 @@ func (b *Backend) GetHash(data string) string {
 	return audit.HashString(b.salt, data)
 }
 
-func (b *Backend) LogRequest(auth *logical.Auth, req *logical.Request, outerErr error) error {
+func (b *Backend) LogRequest(in *audit.LogInput) error {
+	auth, req, outerErr := in.Auth, in.Request, in.OuterErr
 	if !b.logRaw {
 		// Before we copy the structure we must nil out some data
 		// otherwise we will cause reflection to panic and die
@@ -103,10 +104,10 @@ func (b *Backend) LogRequest(auth *logical.Auth, req *logical.Request, outerErr
 		req = cp.(*logical.Request)
 
 		// Hash any sensitive information
-		if err := audit.Hash(b.salt, auth); err != nil {
+		if err := audit.Hash(b.salt, auth, nil); err != nil {
 			return err
 		}
-		if err := audit.Hash(b.salt, req); err != nil {
+		if err := audit.Hash(b.salt, req, in.NonHMACReqDataKeys); err != nil {
 			return err
 		}
 	}
@@ -123,8 +124,8 @@ func (b *Backend) LogRequest(auth *logical.Auth, req *logical.Request, outerErr
 	return err
 }
 
-func (b *Backend) LogResponse(auth *logical.Auth, req *logical.Request,
-	resp *logical.Response, err error) error {
+func (b *Backend) LogResponse(in *audit.LogInput) error {
+	auth, req, resp, err := in.Auth, in.Request, in.Response, in.OuterErr
 	if !b.logRaw {
 		// Before we copy the structure we must nil out some data
 		// otherwise we will cause reflection to panic and die
@@ -163,14 +164,14 @@ func (b *Backend) LogResponse(auth *logical.Auth, req *logical.Request,
 		if !b.hmacAccessor && auth != nil && auth.Accessor != "" {
 			accessor = auth.Accessor
 		}
-		if err := audit.Hash(b.salt, auth); err != nil {
+		if err := audit.Hash(b.salt, auth, nil); err != nil {
 			return err
 		}
 		if accessor != "" {
 			auth.Accessor = accessor
 		}
 
-		if err := audit.Hash(b.salt, req); err != nil {
+		if err := audit.Hash(b.salt, req, in.NonHMACReqDataKeys); err != nil {
 			return err
 		}
 
@@ -182,7 +183,7 @@ func (b *Backend) LogResponse(auth *logical.Auth, req *logical.Request,
 		if !b.hmacAccessor && resp != nil && resp.WrapInfo != nil && resp.WrapInfo.WrappedAccessor != "" {
 			wrappedAccessor = resp.WrapInfo.WrappedAccessor
 		}
-		if err := audit.Hash(b.salt, resp); err != nil {
+		if err := audit.Hash(b.salt, resp, in.NonHMACRespDataKeys); err != nil {
 			return err
 		}
 		if accessor != "" {
@@ -200,7 +201,7 @@ func (b *Backend) LogResponse(auth *logical.Auth, req *logical.Request,
 		return err
 	}
 
-	// Write otu to syslog
+	// Write out to syslog
 	_, err = b.logger.Write(buf.Bytes())
 	return err
 }
@@ -218,7 +218,7 @@ It is possible to authorize multiple app IDs with each
 user ID by writing them as comma-separated values to the map/user-id/<user-id>
 path.
 
-It is also possible to renew the auth tokens with 'vault token-renew <token>' command.
+It is also possible to renew the auth tokens with 'vault token renew <token>' command.
 Before the token is renewed, the validity of app ID, user ID and the associated
 policies are checked again.
 `
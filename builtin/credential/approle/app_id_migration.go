@@ -0,0 +1,223 @@
+package approle
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/go-uuid"
+	"github.com/hashicorp/vault/helper/policyutil"
+	"github.com/hashicorp/vault/helper/salt"
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+)
+
+// appIDMappings gives access to the mappings of the app-id backend. When an
+// app-id mount is converted to AppRole, its data stays in the mount's
+// storage and is migrated to roles and SecretIDs as clients log in, since
+// the app-id backend only stores salted app and user IDs.
+type appIDMappings struct {
+	appIDs  *framework.PolicyMap
+	userIDs *framework.PathMap
+}
+
+// newAppIDMappings returns the app-id mappings stored in the given storage,
+// or nil if there aren't any
+func newAppIDMappings(s logical.Storage) (*appIDMappings, error) {
+	keys, err := s.List("struct/map/user-id/")
+	if err != nil {
+		return nil, err
+	}
+	if len(keys) == 0 {
+		return nil, nil
+	}
+
+	// The app-id backend uses the same salt location, but hashes with SHA1
+	appIDSalt, err := salt.NewSalt(s, &salt.Config{
+		HashFunc: salt.SHA1Hash,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &appIDMappings{
+		appIDs: &framework.PolicyMap{
+			PathMap: framework.PathMap{
+				Name: "app-id",
+				Salt: appIDSalt,
+			},
+			DefaultKey: "default",
+		},
+		userIDs: &framework.PathMap{
+			Name: "user-id",
+			Salt: appIDSalt,
+		},
+	}, nil
+}
+
+// migrateAppID creates a role for the given app ID and registers the given
+// user ID as a SecretID of it, if the pair is mapped in the app-id data of
+// the mount. The role is named after the app ID and uses it as its RoleID,
+// so clients keep their credentials and only need to switch to the AppRole
+// login endpoint. User IDs restricted to a CIDR block are not migrated,
+// since SecretIDs can't carry such a restriction.
+func (b *backend) migrateAppID(s logical.Storage, appID, userID string) error {
+	if b.appIDMappings == nil || appID == "" || userID == "" {
+		return nil
+	}
+
+	userMap, err := b.appIDMappings.userIDs.Get(s, userID)
+	if err != nil {
+		return err
+	}
+	if userMap == nil {
+		return nil
+	}
+	if cidrBlock, ok := userMap["cidr_block"].(string); ok && cidrBlock != "" {
+		b.Logger().Warn("approle: not migrating app-id user ID restricted to a CIDR block")
+		return nil
+	}
+
+	apps, _ := userMap["value"].(string)
+	found := false
+	for _, app := range strings.Split(apps, ",") {
+		// Protect against a timing attack with the app ID comparison
+		if subtle.ConstantTimeCompare([]byte(strings.TrimSpace(app)), []byte(appID)) == 1 {
+			found = true
+		}
+	}
+	if !found {
+		return nil
+	}
+
+	appMap, err := b.appIDMappings.appIDs.Get(s, appID)
+	if err != nil {
+		return err
+	}
+	if appMap == nil {
+		return nil
+	}
+
+	policies, err := b.appIDMappings.appIDs.Policies(s, appID)
+	if err != nil {
+		return err
+	}
+
+	roleName := strings.ToLower(appID)
+	role, err := b.migrateAppIDRole(s, roleName, appID, policies)
+	if err != nil {
+		return err
+	}
+	if role == nil {
+		return nil
+	}
+
+	secretIDHMAC, err := createHMAC(role.HMACKey, userID)
+	if err != nil {
+		return err
+	}
+	roleNameHMAC, err := createHMAC(role.HMACKey, roleName)
+	if err != nil {
+		return err
+	}
+	entry, err := s.Get(fmt.Sprintf("secret_id/%s/%s", roleNameHMAC, secretIDHMAC))
+	if err != nil {
+		return err
+	}
+	if entry != nil {
+		return nil
+	}
+
+	if _, err := b.registerSecretIDEntry(s, roleName, userID, role.HMACKey, &secretIDStorageEntry{
+		Metadata: map[string]string{
+			"migrated_from": "app-id",
+		},
+	}); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// appIDRoleName returns the name of the role migrated from the app ID of a
+// token issued by the app-id backend before the mount was converted. The
+// app ID and user ID of the token are migrated if they haven't been yet,
+// and must still be a valid RoleID and SecretID pair.
+func (b *backend) appIDRoleName(req *logical.Request) (string, error) {
+	appID, _ := req.Auth.InternalData["app-id"].(string)
+	userID, _ := req.Auth.InternalData["user-id"].(string)
+	if appID == "" || userID == "" {
+		return "", fmt.Errorf("failed to fetch role_name during renewal")
+	}
+
+	if err := b.migrateAppID(req.Storage, appID, userID); err != nil {
+		return "", fmt.Errorf("failed to migrate app-id credentials during renewal: %v", err)
+	}
+
+	roleName := strings.ToLower(appID)
+	role, err := b.roleEntry(req.Storage, roleName)
+	if err != nil {
+		return "", fmt.Errorf("failed to validate role %s during renewal:%s", roleName, err)
+	}
+	if role == nil || subtle.ConstantTimeCompare([]byte(role.RoleID), []byte(appID)) != 1 {
+		return "", fmt.Errorf("app ID of the token has no migrated role")
+	}
+
+	secretIDHMAC, err := createHMAC(role.HMACKey, userID)
+	if err != nil {
+		return "", err
+	}
+	roleNameHMAC, err := createHMAC(role.HMACKey, roleName)
+	if err != nil {
+		return "", err
+	}
+	entry, err := req.Storage.Get(fmt.Sprintf("secret_id/%s/%s", roleNameHMAC, secretIDHMAC))
+	if err != nil {
+		return "", err
+	}
+	if entry == nil {
+		return "", fmt.Errorf("user ID of the token is not a SecretID of role %s", roleName)
+	}
+
+	return roleName, nil
+}
+
+// migrateAppIDRole returns the role migrated from the given app ID,
+// creating it if needed. It returns nil if a role of the same name
+// that was not migrated from the app ID already exists.
+func (b *backend) migrateAppIDRole(s logical.Storage, roleName, appID string, policies []string) (*roleStorageEntry, error) {
+	lock := b.roleLock(roleName)
+	lock.Lock()
+	defer lock.Unlock()
+
+	entry, err := s.Get("role/" + roleName)
+	if err != nil {
+		return nil, err
+	}
+	if entry != nil {
+		var role roleStorageEntry
+		if err := entry.DecodeJSON(&role); err != nil {
+			return nil, err
+		}
+		if subtle.ConstantTimeCompare([]byte(role.RoleID), []byte(appID)) != 1 {
+			return nil, nil
+		}
+		return &role, nil
+	}
+
+	hmacKey, err := uuid.GenerateUUID()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create HMAC key: %v", err)
+	}
+	role := &roleStorageEntry{
+		RoleID:       appID,
+		HMACKey:      hmacKey,
+		Policies:     policyutil.SanitizePolicies(policies, true),
+		BindSecretID: true,
+	}
+	if err := b.setRoleEntry(s, roleName, role, ""); err != nil {
+		return nil, err
+	}
+
+	return role, nil
+}
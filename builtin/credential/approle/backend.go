@@ -36,6 +36,10 @@ type backend struct {
 	// when the backend is created, and will be indexed based on the HMAC-ed
 	// SecretIDs.
 	secretIDLocksMap map[string]*sync.RWMutex
+
+	// Mappings of the app-id backend, set if the mount was converted from
+	// app-id and its mappings are yet to be migrated
+	appIDMappings *appIDMappings
 }
 
 func Factory(conf *logical.BackendConfig) (logical.Backend, error) {
@@ -93,6 +97,11 @@ func Backend(conf *logical.BackendConfig) (*backend, error) {
 	b.secretIDLocksMap["custom"] = &sync.RWMutex{}
 	b.roleIDLocksMap["custom"] = &sync.RWMutex{}
 
+	// Pick up the mappings of a mount converted from app-id
+	if b.appIDMappings, err = newAppIDMappings(conf.StorageView); err != nil {
+		return nil, fmt.Errorf("failed to read app-id mappings: %v", err)
+	}
+
 	// Attach the paths and secrets that are to be handled by the backend
 	b.Backend = &framework.Backend{
 		// Register a periodic function that deletes the expired SecretID entries
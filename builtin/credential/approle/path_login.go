@@ -43,10 +43,16 @@ func (b *backend) pathLoginUpdate(req *logical.Request, data *framework.FieldDat
 		InternalData: map[string]interface{}{
 			"role_name": roleName,
 		},
-		Metadata: metadata,
-		Policies: role.Policies,
+		Metadata:       metadata,
+		Policies:       role.Policies,
+		BoundCIDRs:     role.TokenBoundCIDRs,
+		TokenType:      role.TokenType,
+		ExplicitMaxTTL: role.TokenExplicitMaxTTL,
+		Alias: &logical.Alias{
+			Name: role.RoleID,
+		},
 		LeaseOptions: logical.LeaseOptions{
-			Renewable: true,
+			Renewable: role.TokenType != logical.TokenTypeBatch,
 		},
 	}
 
@@ -65,9 +71,15 @@ func (b *backend) pathLoginUpdate(req *logical.Request, data *framework.FieldDat
 
 // Invoked when the token issued by this backend is attempting a renewal.
 func (b *backend) pathLoginRenew(req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
-	roleName := req.Auth.InternalData["role_name"].(string)
+	// Tokens issued by the app-id backend before the mount was converted
+	// don't carry a role name, their role is the one migrated from their
+	// app ID
+	roleName, _ := req.Auth.InternalData["role_name"].(string)
 	if roleName == "" {
-		return nil, fmt.Errorf("failed to fetch role_name during renewal")
+		var err error
+		if roleName, err = b.appIDRoleName(req); err != nil {
+			return nil, err
+		}
 	}
 
 	// Ensure that the Role still exists.
@@ -86,6 +98,10 @@ func (b *backend) pathLoginRenew(req *logical.Request, data *framework.FieldData
 		// token will bear the updated 'Period' value as its TTL.
 		req.Auth.TTL = role.Period
 		return &logical.Response{Auth: req.Auth}, nil
+	} else if req.Auth.ExplicitMaxTTL > time.Duration(0) {
+		// The explicit max TTL the token was issued with is not affected by
+		// later changes to the role
+		return framework.LeaseExtendExplicitMax(role.TokenTTL, req.Auth.ExplicitMaxTTL, b.System())(req, data)
 	} else {
 		return framework.LeaseExtend(role.TokenTTL, role.TokenMaxTTL, b.System())(req, data)
 	}
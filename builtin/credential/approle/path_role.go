@@ -8,6 +8,7 @@ import (
 
 	"github.com/fatih/structs"
 	"github.com/hashicorp/go-uuid"
+	"github.com/hashicorp/vault/helper/cidrutil"
 	"github.com/hashicorp/vault/helper/policyutil"
 	"github.com/hashicorp/vault/helper/strutil"
 	"github.com/hashicorp/vault/logical"
@@ -41,18 +42,30 @@ type roleStorageEntry struct {
 	// Duration after which an issued token should not be allowed to be renewed
 	TokenMaxTTL time.Duration `json:"token_max_ttl" structs:"token_max_ttl" mapstructure:"token_max_ttl"`
 
+	// If set, the issued tokens carry this explicit maximum TTL, which
+	// takes the place of the mount's maximum TTL and may exceed it
+	TokenExplicitMaxTTL time.Duration `json:"token_explicit_max_ttl" structs:"token_explicit_max_ttl" mapstructure:"token_explicit_max_ttl"`
+
 	// A constraint, if set, requires 'secret_id' credential to be presented during login
 	BindSecretID bool `json:"bind_secret_id" structs:"bind_secret_id" mapstructure:"bind_secret_id"`
 
 	// A constraint, if set, specifies the CIDR blocks from which logins should be allowed
 	BoundCIDRList string `json:"bound_cidr_list" structs:"bound_cidr_list" mapstructure:"bound_cidr_list"`
 
+	// If set, the tokens issued using this role can only be used from
+	// network addresses within these CIDR blocks
+	TokenBoundCIDRs []string `json:"token_bound_cidrs" structs:"token_bound_cidrs" mapstructure:"token_bound_cidrs"`
+
 	// Period, if set, indicates that the token generated using this role
 	// should never expire. The token should be renewed within the duration
 	// specified by this value. The renewal duration will be fixed if the
 	// value is not modified on the role. If the `Period` in the role is modified,
 	// a token will pick up the new value during its next renewal.
 	Period time.Duration `json:"period" mapstructure:"period" structs:"period"`
+
+	// The type of the tokens issued using this role; either "service"
+	// or "batch"
+	TokenType string `json:"token_type" structs:"token_type" mapstructure:"token_type"`
 }
 
 // roleIDStorageEntry represents the reverse mapping from RoleID to Role
@@ -72,6 +85,7 @@ type roleIDStorageEntry struct {
 // role/<role_name>/token-max-ttl - For updating the param
 // role/<role_name>/bind-secret-id - For updating the param
 // role/<role_name>/bound-cidr-list - For updating the param
+// role/<role_name>/token-bound-cidrs - For updating the param
 // role/<role_name>/period - For updating the param
 // role/<role_name>/role-id - For fetching the role_id of an role
 // role/<role_name>/secret-id - For issuing a secret_id against an role, also to list the secret_id_accessorss
@@ -105,6 +119,11 @@ func rolePaths(b *backend) []*framework.Path {
 					Type: framework.TypeString,
 					Description: `Comma separated list of CIDR blocks, if set, specifies blocks of IP
 addresses which can perform the login operation`,
+				},
+				"token_bound_cidrs": &framework.FieldSchema{
+					Type: framework.TypeString,
+					Description: `Comma separated list of CIDR blocks, if set, specifies blocks of IP
+addresses which can use the tokens issued using this role`,
 				},
 				"policies": &framework.FieldSchema{
 					Type:        framework.TypeString,
@@ -130,6 +149,13 @@ to 0, in which case the value will fall back to the system/mount defaults.`,
 					Type: framework.TypeDurationSecond,
 					Description: `Duration in seconds after which the issued token should not be allowed to
 be renewed. Defaults to 0, in which case the value will fall back to the system/mount defaults.`,
+				},
+				"token_explicit_max_ttl": &framework.FieldSchema{
+					Type: framework.TypeDurationSecond,
+					Description: `If set, the issued token carries an explicit maximum TTL in seconds
+that takes the place of the system/mount maximum TTL and may exceed it.
+Defaults to 0, in which case the token's maximum TTL is governed by
+'token_max_ttl' and the system/mount defaults.`,
 				},
 				"period": &framework.FieldSchema{
 					Type:    framework.TypeDurationSecond,
@@ -143,6 +169,12 @@ TTL will be set to the value of this parameter.`,
 					Type:        framework.TypeString,
 					Description: "Identifier of the role. Defaults to a UUID.",
 				},
+				"token_type": &framework.FieldSchema{
+					Type:    framework.TypeString,
+					Default: logical.TokenTypeService,
+					Description: `The type of token to issue on login; either "service" or "batch".
+Defaults to "service".`,
+				},
 			},
 			ExistenceCheck: b.pathRoleExistenceCheck,
 			Callbacks: map[logical.Operation]framework.OperationFunc{
@@ -196,6 +228,27 @@ addresses which can perform the login operation`,
 			HelpSynopsis:    strings.TrimSpace(roleHelp["role-bound-cidr-list"][0]),
 			HelpDescription: strings.TrimSpace(roleHelp["role-bound-cidr-list"][1]),
 		},
+		&framework.Path{
+			Pattern: "role/" + framework.GenericNameRegex("role_name") + "/token-bound-cidrs$",
+			Fields: map[string]*framework.FieldSchema{
+				"role_name": &framework.FieldSchema{
+					Type:        framework.TypeString,
+					Description: "Name of the role.",
+				},
+				"token_bound_cidrs": &framework.FieldSchema{
+					Type: framework.TypeString,
+					Description: `Comma separated list of CIDR blocks, if set, specifies blocks of IP
+addresses which can use the tokens issued using this role`,
+				},
+			},
+			Callbacks: map[logical.Operation]framework.OperationFunc{
+				logical.UpdateOperation: b.pathRoleTokenBoundCIDRsUpdate,
+				logical.ReadOperation:   b.pathRoleTokenBoundCIDRsRead,
+				logical.DeleteOperation: b.pathRoleTokenBoundCIDRsDelete,
+			},
+			HelpSynopsis:    strings.TrimSpace(roleHelp["role-token-bound-cidrs"][0]),
+			HelpDescription: strings.TrimSpace(roleHelp["role-token-bound-cidrs"][1]),
+		},
 		&framework.Path{
 			Pattern: "role/" + framework.GenericNameRegex("role_name") + "/bind-secret-id$",
 			Fields: map[string]*framework.FieldSchema{
@@ -676,6 +729,13 @@ func (b *backend) pathRoleCreateUpdate(req *logical.Request, data *framework.Fie
 		return logical.ErrorResponse(fmt.Sprintf("failed to validate CIDR blocks: %s", err)), nil
 	}
 
+	if tokenBoundCIDRsRaw, ok := data.GetOk("token_bound_cidrs"); ok {
+		role.TokenBoundCIDRs, err = cidrutil.ParseCIDRList(tokenBoundCIDRsRaw.(string), ",")
+		if err != nil {
+			return logical.ErrorResponse(fmt.Sprintf("failed to validate token CIDR blocks: %s", err)), nil
+		}
+	}
+
 	if policiesRaw, ok := data.GetOk("policies"); ok {
 		role.Policies = policyutil.ParsePolicies(policiesRaw.(string))
 	} else if req.Operation == logical.CreateOperation {
@@ -692,6 +752,22 @@ func (b *backend) pathRoleCreateUpdate(req *logical.Request, data *framework.Fie
 		return logical.ErrorResponse(fmt.Sprintf("'period' of '%s' is greater than the backend's maximum lease TTL of '%s'", role.Period.String(), b.System().MaxLeaseTTL().String())), nil
 	}
 
+	if tokenTypeRaw, ok := data.GetOk("token_type"); ok {
+		role.TokenType = tokenTypeRaw.(string)
+	} else if req.Operation == logical.CreateOperation {
+		role.TokenType = data.Get("token_type").(string)
+	}
+	switch role.TokenType {
+	case "", logical.TokenTypeService:
+		role.TokenType = logical.TokenTypeService
+	case logical.TokenTypeBatch:
+		if role.Period > time.Duration(0) {
+			return logical.ErrorResponse("'period' cannot be set when 'token_type' is 'batch'"), nil
+		}
+	default:
+		return logical.ErrorResponse(fmt.Sprintf("invalid token_type %q", role.TokenType)), nil
+	}
+
 	if secretIDNumUsesRaw, ok := data.GetOk("secret_id_num_uses"); ok {
 		role.SecretIDNumUses = secretIDNumUsesRaw.(int)
 	} else if req.Operation == logical.CreateOperation {
@@ -726,6 +802,18 @@ func (b *backend) pathRoleCreateUpdate(req *logical.Request, data *framework.Fie
 		return logical.ErrorResponse("token_ttl should not be greater than token_max_ttl"), nil
 	}
 
+	if tokenExplicitMaxTTLRaw, ok := data.GetOk("token_explicit_max_ttl"); ok {
+		role.TokenExplicitMaxTTL = time.Second * time.Duration(tokenExplicitMaxTTLRaw.(int))
+	} else if req.Operation == logical.CreateOperation {
+		role.TokenExplicitMaxTTL = time.Second * time.Duration(data.Get("token_explicit_max_ttl").(int))
+	}
+	if role.TokenExplicitMaxTTL < time.Duration(0) {
+		return logical.ErrorResponse("token_explicit_max_ttl cannot be negative"), nil
+	}
+	if role.TokenExplicitMaxTTL > time.Duration(0) && role.TokenTTL > role.TokenExplicitMaxTTL {
+		return logical.ErrorResponse("token_ttl should not be greater than token_explicit_max_ttl"), nil
+	}
+
 	var resp *logical.Response
 	if role.TokenMaxTTL > b.System().MaxLeaseTTL() {
 		resp = &logical.Response{}
@@ -752,6 +840,7 @@ func (b *backend) pathRoleRead(req *logical.Request, data *framework.FieldData)
 		role.SecretIDTTL /= time.Second
 		role.TokenTTL /= time.Second
 		role.TokenMaxTTL /= time.Second
+		role.TokenExplicitMaxTTL /= time.Second
 		role.Period /= time.Second
 
 		// Create a map of data to be returned and remove sensitive information from it
@@ -1108,6 +1197,80 @@ func (b *backend) pathRoleBoundCIDRListDelete(req *logical.Request, data *framew
 	return nil, b.setRoleEntry(req.Storage, roleName, role, "")
 }
 
+func (b *backend) pathRoleTokenBoundCIDRsUpdate(req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	roleName := data.Get("role_name").(string)
+	if roleName == "" {
+		return logical.ErrorResponse("missing role_name"), nil
+	}
+
+	role, err := b.roleEntry(req.Storage, strings.ToLower(roleName))
+	if err != nil {
+		return nil, err
+	}
+	if role == nil {
+		return nil, nil
+	}
+
+	lock := b.roleLock(roleName)
+
+	lock.Lock()
+	defer lock.Unlock()
+
+	role.TokenBoundCIDRs, err = cidrutil.ParseCIDRList(data.Get("token_bound_cidrs").(string), ",")
+	if err != nil {
+		return logical.ErrorResponse(fmt.Sprintf("failed to validate token CIDR blocks: %s", err)), nil
+	}
+	if len(role.TokenBoundCIDRs) == 0 {
+		return logical.ErrorResponse("missing token_bound_cidrs"), nil
+	}
+
+	return nil, b.setRoleEntry(req.Storage, roleName, role, "")
+}
+
+func (b *backend) pathRoleTokenBoundCIDRsRead(req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	roleName := data.Get("role_name").(string)
+	if roleName == "" {
+		return logical.ErrorResponse("missing role_name"), nil
+	}
+
+	if role, err := b.roleEntry(req.Storage, strings.ToLower(roleName)); err != nil {
+		return nil, err
+	} else if role == nil {
+		return nil, nil
+	} else {
+		return &logical.Response{
+			Data: map[string]interface{}{
+				"token_bound_cidrs": role.TokenBoundCIDRs,
+			},
+		}, nil
+	}
+}
+
+func (b *backend) pathRoleTokenBoundCIDRsDelete(req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	roleName := data.Get("role_name").(string)
+	if roleName == "" {
+		return logical.ErrorResponse("missing role_name"), nil
+	}
+
+	role, err := b.roleEntry(req.Storage, strings.ToLower(roleName))
+	if err != nil {
+		return nil, err
+	}
+	if role == nil {
+		return nil, nil
+	}
+
+	lock := b.roleLock(roleName)
+
+	lock.Lock()
+	defer lock.Unlock()
+
+	// Deleting a field implies setting the value to it's default value.
+	role.TokenBoundCIDRs = nil
+
+	return nil, b.setRoleEntry(req.Storage, roleName, role, "")
+}
+
 func (b *backend) pathRoleBindSecretIDUpdate(req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
 	roleName := data.Get("role_name").(string)
 	if roleName == "" {
@@ -1842,6 +2005,13 @@ addresses which can perform the login operation`,
 		`During login, the IP address of the client will be checked to see if it
 belongs to the CIDR blocks specified. If CIDR blocks were set and if the
 IP is not encompassed by it, login fails`,
+	},
+	"role-token-bound-cidrs": {
+		`Comma separated list of CIDR blocks, if set, specifies blocks of IP
+addresses which can use the tokens issued using this role`,
+		`The tokens issued during login using this role will only be usable from
+IP addresses belonging to the CIDR blocks specified. Requests using such a
+token from any other address will be denied.`,
 	},
 	"role-policies": {
 		"Policies of the role.",
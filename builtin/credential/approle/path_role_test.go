@@ -434,12 +434,14 @@ func TestAppRole_RoleCRUD(t *testing.T) {
 	b, storage := createBackendWithStorage(t)
 
 	roleData := map[string]interface{}{
-		"policies":           "p,q,r,s",
-		"secret_id_num_uses": 10,
-		"secret_id_ttl":      300,
-		"token_ttl":          400,
-		"token_max_ttl":      500,
-		"bound_cidr_list":    "127.0.0.1/32,127.0.0.1/16",
+		"policies":               "p,q,r,s",
+		"secret_id_num_uses":     10,
+		"secret_id_ttl":          300,
+		"token_ttl":              400,
+		"token_max_ttl":          500,
+		"token_explicit_max_ttl": 600,
+		"bound_cidr_list":        "127.0.0.1/32,127.0.0.1/16",
+		"token_bound_cidrs":      "127.0.0.1/32",
 	}
 	roleReq := &logical.Request{
 		Operation: logical.CreateOperation,
@@ -460,13 +462,16 @@ func TestAppRole_RoleCRUD(t *testing.T) {
 	}
 
 	expected := map[string]interface{}{
-		"bind_secret_id":     true,
-		"policies":           []string{"default", "p", "q", "r", "s"},
-		"secret_id_num_uses": 10,
-		"secret_id_ttl":      300,
-		"token_ttl":          400,
-		"token_max_ttl":      500,
-		"bound_cidr_list":    "127.0.0.1/32,127.0.0.1/16",
+		"bind_secret_id":         true,
+		"policies":               []string{"default", "p", "q", "r", "s"},
+		"secret_id_num_uses":     10,
+		"secret_id_ttl":          300,
+		"token_ttl":              400,
+		"token_max_ttl":          500,
+		"token_explicit_max_ttl": 600,
+		"bound_cidr_list":        "127.0.0.1/32,127.0.0.1/16",
+		"token_bound_cidrs":      []string{"127.0.0.1/32"},
+		"token_type":             "service",
 	}
 	var expectedStruct roleStorageEntry
 	err = mapstructure.Decode(expected, &expectedStruct)
@@ -486,12 +491,13 @@ func TestAppRole_RoleCRUD(t *testing.T) {
 	}
 
 	roleData = map[string]interface{}{
-		"role_id":            "test_role_id",
-		"policies":           "a,b,c,d",
-		"secret_id_num_uses": 100,
-		"secret_id_ttl":      3000,
-		"token_ttl":          4000,
-		"token_max_ttl":      5000,
+		"role_id":                "test_role_id",
+		"policies":               "a,b,c,d",
+		"secret_id_num_uses":     100,
+		"secret_id_ttl":          3000,
+		"token_ttl":              4000,
+		"token_max_ttl":          5000,
+		"token_explicit_max_ttl": 6000,
 	}
 	roleReq.Data = roleData
 	roleReq.Operation = logical.UpdateOperation
@@ -508,11 +514,12 @@ func TestAppRole_RoleCRUD(t *testing.T) {
 	}
 
 	expected = map[string]interface{}{
-		"policies":           []string{"a", "b", "c", "d", "default"},
-		"secret_id_num_uses": 100,
-		"secret_id_ttl":      3000,
-		"token_ttl":          4000,
-		"token_max_ttl":      5000,
+		"policies":               []string{"a", "b", "c", "d", "default"},
+		"secret_id_num_uses":     100,
+		"secret_id_ttl":          3000,
+		"token_ttl":              4000,
+		"token_max_ttl":          5000,
+		"token_explicit_max_ttl": 6000,
 	}
 	err = mapstructure.Decode(expected, &expectedStruct)
 	if err != nil {
@@ -104,6 +104,12 @@ func (b *backend) validateCredentials(req *logical.Request, data *framework.Fiel
 		return nil, "", metadata, fmt.Errorf("missing role_id")
 	}
 
+	// Migrate the credentials if they are mapped in the app-id data of a
+	// mount converted from app-id
+	if err := b.migrateAppID(req.Storage, roleID, strings.TrimSpace(data.Get("secret_id").(string))); err != nil {
+		return nil, "", metadata, fmt.Errorf("failed to migrate app-id credentials: %v", err)
+	}
+
 	// Validate the RoleID and get the Role entry
 	role, roleName, err := b.validateRoleID(req.Storage, roleID)
 	if err != nil {
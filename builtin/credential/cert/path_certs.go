@@ -54,6 +54,7 @@ certificate.`,
 				Type: framework.TypeInt,
 				Description: `Deprecated: use "ttl" instead. TTL time in
 seconds. Defaults to system/backend default TTL.`,
+				Deprecated: true,
 			},
 
 			"ttl": &framework.FieldSchema{
@@ -26,7 +26,8 @@ func pathLogin(b *backend) *framework.Path {
 		},
 
 		Callbacks: map[logical.Operation]framework.OperationFunc{
-			logical.UpdateOperation: b.pathLogin,
+			logical.UpdateOperation:         b.pathLogin,
+			logical.AliasLookaheadOperation: b.pathLoginAliasLookahead,
 		},
 
 		HelpSynopsis:    pathLoginSyn,
@@ -34,12 +35,32 @@ func pathLogin(b *backend) *framework.Path {
 	}
 }
 
+func (b *backend) pathLoginAliasLookahead(
+	req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	username := d.Get("username").(string)
+	if username == "" {
+		return nil, fmt.Errorf("missing username")
+	}
+
+	// LDAP servers usually match usernames case insensitively, so the case
+	// variants of a username must be the same alias for the login lockout
+	username = strings.ToLower(username)
+
+	return &logical.Response{
+		Auth: &logical.Auth{
+			Alias: &logical.Alias{
+				Name: username,
+			},
+		},
+	}, nil
+}
+
 func (b *backend) pathLogin(
 	req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
 	username := d.Get("username").(string)
 	password := d.Get("password").(string)
 
-	policies, resp, err := b.Login(req, username, password)
+	policies, resp, groupNames, err := b.Login(req, username, password)
 	// Handle an internal error
 	if err != nil {
 		return nil, err
@@ -65,6 +86,10 @@ func (b *backend) pathLogin(
 			"password": password,
 		},
 		DisplayName: username,
+		Alias: &logical.Alias{
+			Name: username,
+		},
+		GroupAliases: groupAliases(groupNames),
 		LeaseOptions: logical.LeaseOptions{
 			Renewable: true,
 		},
@@ -78,7 +103,7 @@ func (b *backend) pathLoginRenew(
 	username := req.Auth.Metadata["username"]
 	password := req.Auth.InternalData["password"].(string)
 
-	loginPolicies, resp, err := b.Login(req, username, password)
+	loginPolicies, resp, _, err := b.Login(req, username, password)
 	if len(loginPolicies) == 0 {
 		return resp, err
 	}
@@ -90,6 +115,18 @@ func (b *backend) pathLoginRenew(
 	return framework.LeaseExtend(0, 0, b.System())(req, d)
 }
 
+// groupAliases converts the names of the groups a user is a member of into
+// the group aliases reported to core
+func groupAliases(groupNames []string) []*logical.Alias {
+	aliases := make([]*logical.Alias, 0, len(groupNames))
+	for _, groupName := range groupNames {
+		aliases = append(aliases, &logical.Alias{
+			Name: groupName,
+		})
+	}
+	return aliases
+}
+
 const pathLoginSyn = `
 Log in with a username and password.
 `
@@ -30,7 +30,9 @@ func Backend() *backend {
 			secretAccessKeys(&b),
 		},
 
-		WALRollback:       walRollback,
+		WALRollbackKinds: map[string]framework.WALRollbackFunc{
+			"user": pathUserRollback,
+		},
 		WALRollbackMinAge: 5 * time.Minute,
 	}
 
@@ -3,9 +3,7 @@ package cassandra
 import (
 	"fmt"
 
-	"github.com/fatih/structs"
 	"github.com/hashicorp/vault/helper/certutil"
-	"github.com/hashicorp/vault/helper/tlsutil"
 	"github.com/hashicorp/vault/logical"
 	"github.com/hashicorp/vault/logical/framework"
 )
@@ -17,16 +15,19 @@ func pathConfigConnection(b *backend) *framework.Path {
 			"hosts": &framework.FieldSchema{
 				Type:        framework.TypeString,
 				Description: "Comma-separated list of hosts",
+				Required:    true,
 			},
 
 			"username": &framework.FieldSchema{
 				Type:        framework.TypeString,
 				Description: "The username to use for connecting to the cluster",
+				Required:    true,
 			},
 
 			"password": &framework.FieldSchema{
 				Type:        framework.TypeString,
 				Description: "The password to use for connecting to the cluster",
+				Required:    true,
 			},
 
 			"tls": &framework.FieldSchema{
@@ -42,9 +43,10 @@ effect if a CA certificate is provided`,
 			},
 
 			"tls_min_version": &framework.FieldSchema{
-				Type:        framework.TypeString,
-				Default:     "tls12",
-				Description: "Minimum TLS version to use. Accepted values are 'tls10', 'tls11' or 'tls12'. Defaults to 'tls12'",
+				Type:          framework.TypeString,
+				Default:       "tls12",
+				Description:   "Minimum TLS version to use. Accepted values are 'tls10', 'tls11' or 'tls12'. Defaults to 'tls12'",
+				AllowedValues: []interface{}{"tls10", "tls11", "tls12"},
 			},
 
 			"pem_bundle": &framework.FieldSchema{
@@ -78,6 +80,7 @@ take precedence.`,
 		Callbacks: map[logical.Operation]framework.OperationFunc{
 			logical.ReadOperation:   b.pathConnectionRead,
 			logical.UpdateOperation: b.pathConnectionWrite,
+			logical.PatchOperation:  b.pathConnectionPatch,
 		},
 
 		HelpSynopsis:    pathConfigConnectionHelpSyn,
@@ -100,59 +103,69 @@ func (b *backend) pathConnectionRead(
 		return nil, err
 	}
 
-	config.Password = "**********"
-	if len(config.PrivateKey) > 0 {
-		config.PrivateKey = "**********"
-	}
-
 	return &logical.Response{
-		Data: structs.New(config).Map(),
+		Data: framework.RedactedMap(config),
 	}, nil
 }
 
 func (b *backend) pathConnectionWrite(
 	req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
-	hosts := data.Get("hosts").(string)
-	username := data.Get("username").(string)
-	password := data.Get("password").(string)
-
-	switch {
-	case len(hosts) == 0:
-		return logical.ErrorResponse("Hosts cannot be empty"), nil
-	case len(username) == 0:
-		return logical.ErrorResponse("Username cannot be empty"), nil
-	case len(password) == 0:
-		return logical.ErrorResponse("Password cannot be empty"), nil
-	}
-
 	config := &sessionConfig{
-		Hosts:           hosts,
-		Username:        username,
-		Password:        password,
+		Hosts:           data.Get("hosts").(string),
+		Username:        data.Get("username").(string),
+		Password:        data.Get("password").(string),
 		TLS:             data.Get("tls").(bool),
 		InsecureTLS:     data.Get("insecure_tls").(bool),
+		TLSMinVersion:   data.Get("tls_min_version").(string),
 		ProtocolVersion: data.Get("protocol_version").(int),
 		ConnectTimeout:  data.Get("connect_timeout").(int),
 	}
 
-	config.TLSMinVersion = data.Get("tls_min_version").(string)
-	if config.TLSMinVersion == "" {
-		return logical.ErrorResponse("failed to get 'tls_min_version' value"), nil
+	return b.saveConnection(req, config, data.Get("pem_json").(string), data.Get("pem_bundle").(string))
+}
+
+// pathConnectionPatch patches the connection configuration. The certificate
+// is kept unless pem_json or pem_bundle are given, and the null fields reset
+// to their default.
+func (b *backend) pathConnectionPatch(
+	req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	patch := make(map[string]interface{})
+	for name := range data.Raw {
+		if _, ok := data.Schema[name]; ok && name != "pem_json" && name != "pem_bundle" {
+			patch[name] = data.Get(name)
+		}
 	}
 
-	var ok bool
-	_, ok = tlsutil.TLSLookup[config.TLSMinVersion]
-	if !ok {
-		return logical.ErrorResponse("invalid 'tls_min_version'"), nil
+	config := &sessionConfig{}
+	found, err := framework.PatchStorageEntry(req.Storage, "config/connection", patch, config)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return logical.ErrorResponse("Configure the DB connection with config/connection first"), nil
+	}
+
+	switch {
+	case config.Hosts == "":
+		return logical.ErrorResponse(`"hosts" is required`), nil
+	case config.Username == "":
+		return logical.ErrorResponse(`"username" is required`), nil
+	case config.Password == "":
+		return logical.ErrorResponse(`"password" is required`), nil
 	}
 
+	return b.saveConnection(req, config, data.Get("pem_json").(string), data.Get("pem_bundle").(string))
+}
+
+// saveConnection sets the certificate of the configuration from the PEM JSON
+// or bundle if one is given, checks that the cluster can be connected to, and
+// stores the configuration
+func (b *backend) saveConnection(
+	req *logical.Request, config *sessionConfig, pemJSON, pemBundle string) (*logical.Response, error) {
 	if config.InsecureTLS {
 		config.TLS = true
 	}
 
-	pemBundle := data.Get("pem_bundle").(string)
-	pemJSON := data.Get("pem_json").(string)
-
 	var certBundle *certutil.CertBundle
 	var parsedCertBundle *certutil.ParsedCertBundle
 	var err error
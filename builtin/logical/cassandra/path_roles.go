@@ -58,10 +58,13 @@ template values are '{{username}}' and
 
 		Callbacks: map[logical.Operation]framework.OperationFunc{
 			logical.ReadOperation:   b.pathRoleRead,
+			logical.CreateOperation: b.pathRoleCreate,
 			logical.UpdateOperation: b.pathRoleCreate,
 			logical.DeleteOperation: b.pathRoleDelete,
 		},
 
+		ExistenceCheck: framework.StorageExistenceCheck("role/", "name"),
+
 		HelpSynopsis:    pathRoleHelpSyn,
 		HelpDescription: pathRoleHelpDesc,
 	}
@@ -22,6 +22,7 @@ func pathConfigConnection(b *backend) *framework.Path {
 				Type: framework.TypeString,
 				Description: `DB connection string. Use 'connection_url' instead.
 This name is deprecated.`,
+				Deprecated: true,
 			},
 			"max_open_connections": &framework.FieldSchema{
 				Type:        framework.TypeInt,
@@ -22,7 +22,8 @@ func pathConfigConnection(b *backend) *framework.Path {
 			"value": &framework.FieldSchema{
 				Type: framework.TypeString,
 				Description: `DB connection string. Use 'connection_url' instead.
-This will be deprecated.`,
+This name is deprecated.`,
+				Deprecated: true,
 			},
 
 			"verify_connection": &framework.FieldSchema{
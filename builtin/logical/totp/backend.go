@@ -0,0 +1,75 @@
+package totp
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+)
+
+// Factory creates and configures the backend
+func Factory(conf *logical.BackendConfig) (logical.Backend, error) {
+	return Backend().Setup(conf)
+}
+
+// Creates a new backend with all the paths belonging to it
+func Backend() *backend {
+	b := &backend{
+		usedCodes: make(map[string]time.Time),
+	}
+
+	b.Backend = &framework.Backend{
+		Help: strings.TrimSpace(backendHelp),
+
+		Paths: []*framework.Path{
+			pathListKeys(b),
+			pathKeys(b),
+			pathCode(b),
+		},
+	}
+
+	return b
+}
+
+type backend struct {
+	*framework.Backend
+
+	// usedCodes tracks the codes that were successfully validated, keyed
+	// by key name and code, so that a code cannot be used twice within
+	// the window it is valid for
+	usedCodes map[string]time.Time
+	usedLock  sync.Mutex
+}
+
+// markCodeUsed records the code as used until the given time. It returns
+// false if the code was already used.
+func (b *backend) markCodeUsed(name, code string, until time.Time) bool {
+	b.usedLock.Lock()
+	defer b.usedLock.Unlock()
+
+	// Expire codes that are no longer valid
+	now := time.Now()
+	for k, expiration := range b.usedCodes {
+		if now.After(expiration) {
+			delete(b.usedCodes, k)
+		}
+	}
+
+	usedKey := name + "_" + code
+	if _, ok := b.usedCodes[usedKey]; ok {
+		return false
+	}
+	b.usedCodes[usedKey] = until
+	return true
+}
+
+const backendHelp = `
+The TOTP backend dynamically generates time-based one-time use passwords.
+
+Keys can either be generated by Vault, in which case the backend can act as a
+provider and the key can be shared with a client such as an authenticator app
+via its URL or QR code, or imported from a provider, in which case Vault acts
+as a generator of codes, e.g. for accounts shared by a team.
+`
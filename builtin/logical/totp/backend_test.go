@@ -0,0 +1,243 @@
+package totp
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/logical"
+	otplib "github.com/pquerna/otp"
+	totplib "github.com/pquerna/otp/totp"
+)
+
+func createBackendWithStorage(t *testing.T) (logical.Backend, logical.Storage) {
+	config := logical.TestBackendConfig()
+	config.StorageView = &logical.InmemStorage{}
+
+	b, err := Factory(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b == nil {
+		t.Fatalf("failed to create backend")
+	}
+	return b, config.StorageView
+}
+
+func TestBackend_keyImport(t *testing.T) {
+	b, storage := createBackendWithStorage(t)
+
+	key := "HXDMVJECJJWSRB3HWIZR4IFUGFTMXBOZ"
+
+	resp, err := b.HandleRequest(&logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "keys/test",
+		Storage:   storage,
+		Data: map[string]interface{}{
+			"key":          key,
+			"issuer":       "Vault",
+			"account_name": "test@email.com",
+			"digits":       8,
+			"algorithm":    "sha256",
+		},
+	})
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("bad: err: %v\nresp: %#v", err, resp)
+	}
+
+	resp, err = b.HandleRequest(&logical.Request{
+		Operation: logical.ReadOperation,
+		Path:      "keys/test",
+		Storage:   storage,
+	})
+	if err != nil || resp == nil || resp.IsError() {
+		t.Fatalf("bad: err: %v\nresp: %#v", err, resp)
+	}
+	if _, ok := resp.Data["key"]; ok {
+		t.Fatalf("key should not be returned")
+	}
+	if resp.Data["algorithm"] != "SHA256" || resp.Data["digits"] != 8 || resp.Data["period"] != int64(30) {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+
+	// The generated code must match the one computed from the key
+	resp, err = b.HandleRequest(&logical.Request{
+		Operation: logical.ReadOperation,
+		Path:      "code/test",
+		Storage:   storage,
+	})
+	if err != nil || resp == nil || resp.IsError() {
+		t.Fatalf("bad: err: %v\nresp: %#v", err, resp)
+	}
+	expected, err := totplib.GenerateCodeCustom(key, time.Now(), totplib.ValidateOpts{
+		Period:    30,
+		Digits:    otplib.DigitsEight,
+		Algorithm: otplib.AlgorithmSHA256,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Data["code"] != expected {
+		t.Fatalf("bad: expected %q, got %q", expected, resp.Data["code"])
+	}
+
+	// Validating the code succeeds once but not twice
+	for i, valid := range []bool{true, false} {
+		resp, err = b.HandleRequest(&logical.Request{
+			Operation: logical.UpdateOperation,
+			Path:      "code/test",
+			Storage:   storage,
+			Data: map[string]interface{}{
+				"code": expected,
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if valid && (resp == nil || resp.Data["valid"] != true) {
+			t.Fatalf("%d: bad: %#v", i, resp)
+		}
+		if !valid && (resp == nil || !resp.IsError()) {
+			t.Fatalf("%d: expected error on reused code, got %#v", i, resp)
+		}
+	}
+
+	resp, err = b.HandleRequest(&logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "code/test",
+		Storage:   storage,
+		Data: map[string]interface{}{
+			"code": "00000000",
+		},
+	})
+	if err != nil || resp == nil || resp.Data["valid"] != false {
+		t.Fatalf("bad: err: %v\nresp: %#v", err, resp)
+	}
+}
+
+func TestBackend_keyGenerate(t *testing.T) {
+	b, storage := createBackendWithStorage(t)
+
+	resp, err := b.HandleRequest(&logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "keys/test",
+		Storage:   storage,
+		Data: map[string]interface{}{
+			"generate":     true,
+			"issuer":       "Vault",
+			"account_name": "test@email.com",
+		},
+	})
+	if err != nil || resp == nil || resp.IsError() {
+		t.Fatalf("bad: err: %v\nresp: %#v", err, resp)
+	}
+	if resp.Data["barcode"] == "" {
+		t.Fatalf("expected a barcode")
+	}
+
+	key, err := otplib.NewKeyFromURL(resp.Data["url"].(string))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if key.Issuer() != "Vault" || key.AccountName() != "test@email.com" {
+		t.Fatalf("bad: %s", key.String())
+	}
+
+	code, err := totplib.GenerateCode(key.Secret(), time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err = b.HandleRequest(&logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "code/test",
+		Storage:   storage,
+		Data: map[string]interface{}{
+			"code": code,
+		},
+	})
+	if err != nil || resp == nil || resp.Data["valid"] != true {
+		t.Fatalf("bad: err: %v\nresp: %#v", err, resp)
+	}
+
+	// Generated keys require an issuer and an account name
+	resp, err = b.HandleRequest(&logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "keys/test2",
+		Storage:   storage,
+		Data: map[string]interface{}{
+			"generate": true,
+		},
+	})
+	if err != nil || resp == nil || !resp.IsError() {
+		t.Fatalf("expected error, got err: %v\nresp: %#v", err, resp)
+	}
+}
+
+func TestBackend_keyURL(t *testing.T) {
+	b, storage := createBackendWithStorage(t)
+
+	url := "otpauth://totp/Vault:test@email.com?secret=HXDMVJECJJWSRB3HWIZR4IFUGFTMXBOZ&algorithm=SHA512&digits=6&period=60"
+
+	resp, err := b.HandleRequest(&logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "keys/test",
+		Storage:   storage,
+		Data: map[string]interface{}{
+			"url": url,
+		},
+	})
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("bad: err: %v\nresp: %#v", err, resp)
+	}
+
+	resp, err = b.HandleRequest(&logical.Request{
+		Operation: logical.ReadOperation,
+		Path:      "keys/test",
+		Storage:   storage,
+	})
+	if err != nil || resp == nil {
+		t.Fatalf("bad: err: %v\nresp: %#v", err, resp)
+	}
+	expected := map[string]interface{}{
+		"issuer":       "Vault",
+		"account_name": "test@email.com",
+		"period":       int64(60),
+		"algorithm":    "SHA512",
+		"digits":       6,
+	}
+	for k, v := range expected {
+		if resp.Data[k] != v {
+			t.Fatalf("bad: %s: expected %v, got %v", k, v, resp.Data[k])
+		}
+	}
+
+	resp, err = b.HandleRequest(&logical.Request{
+		Operation: logical.ListOperation,
+		Path:      "keys/",
+		Storage:   storage,
+	})
+	if err != nil || resp == nil {
+		t.Fatalf("bad: err: %v\nresp: %#v", err, resp)
+	}
+	if keys := resp.Data["keys"].([]string); len(keys) != 1 || keys[0] != "test" {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+
+	resp, err = b.HandleRequest(&logical.Request{
+		Operation: logical.DeleteOperation,
+		Path:      "keys/test",
+		Storage:   storage,
+	})
+	if err != nil || resp != nil {
+		t.Fatalf("bad: err: %v\nresp: %#v", err, resp)
+	}
+
+	resp, err = b.HandleRequest(&logical.Request{
+		Operation: logical.ReadOperation,
+		Path:      "code/test",
+		Storage:   storage,
+	})
+	if err != nil || resp == nil || !resp.IsError() {
+		t.Fatalf("expected error, got err: %v\nresp: %#v", err, resp)
+	}
+}
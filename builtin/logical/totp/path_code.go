@@ -0,0 +1,137 @@
+package totp
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+	otplib "github.com/pquerna/otp"
+	totplib "github.com/pquerna/otp/totp"
+)
+
+func pathCode(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "code/" + framework.GenericNameRegex("name"),
+		Fields: map[string]*framework.FieldSchema{
+			"name": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: "Name of the key.",
+			},
+			"code": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: "TOTP code to be validated.",
+			},
+		},
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ReadOperation:   b.pathReadCode,
+			logical.UpdateOperation: b.pathValidateCode,
+		},
+		HelpSynopsis:    pathCodeHelpSyn,
+		HelpDescription: pathCodeHelpDesc,
+	}
+}
+
+// Generates a code for the current time
+func (b *backend) pathReadCode(req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	name := d.Get("name").(string)
+	if name == "" {
+		return logical.ErrorResponse("missing name"), nil
+	}
+
+	key, err := b.Key(req.Storage, name)
+	if err != nil {
+		return nil, err
+	}
+	if key == nil {
+		return logical.ErrorResponse(fmt.Sprintf("unknown key: %s", name)), nil
+	}
+
+	code, err := totplib.GenerateCodeCustom(key.Key, time.Now(), key.validateOpts())
+	if err != nil {
+		return nil, fmt.Errorf("error generating code: %v", err)
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"code": code,
+		},
+	}, nil
+}
+
+// Validates a code against the current time. A code that was successfully
+// validated cannot be used again.
+func (b *backend) pathValidateCode(req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	name := d.Get("name").(string)
+	if name == "" {
+		return logical.ErrorResponse("missing name"), nil
+	}
+
+	code := d.Get("code").(string)
+	if code == "" {
+		return logical.ErrorResponse("missing code"), nil
+	}
+
+	key, err := b.Key(req.Storage, name)
+	if err != nil {
+		return nil, err
+	}
+	if key == nil {
+		return logical.ErrorResponse(fmt.Sprintf("unknown key: %s", name)), nil
+	}
+
+	now := time.Now()
+	valid, err := totplib.ValidateCustom(code, key.Key, now, key.validateOpts())
+	if err != nil && err != otplib.ErrValidateInputInvalidLength {
+		return logical.ErrorResponse(fmt.Sprintf("error validating code: %v", err)), nil
+	}
+
+	// A code is valid for its own period and for the skewed periods on
+	// either side of it
+	if valid {
+		until := now.Add(key.Period * time.Duration(2*key.Skew+1))
+		if !b.markCodeUsed(name, code, until) {
+			return logical.ErrorResponse("code already used; wait until the next time period"), nil
+		}
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"valid": valid,
+		},
+	}, nil
+}
+
+func (k *keyEntry) validateOpts() totplib.ValidateOpts {
+	opts := totplib.ValidateOpts{
+		Period: uint(k.Period.Seconds()),
+		Skew:   k.Skew,
+		Digits: otplib.DigitsSix,
+	}
+
+	if k.Digits == 8 {
+		opts.Digits = otplib.DigitsEight
+	}
+
+	switch k.Algorithm {
+	case "SHA256":
+		opts.Algorithm = otplib.AlgorithmSHA256
+	case "SHA512":
+		opts.Algorithm = otplib.AlgorithmSHA512
+	default:
+		opts.Algorithm = otplib.AlgorithmSHA1
+	}
+
+	return opts
+}
+
+const pathCodeHelpSyn = `
+Request a time-based one-time use password or validate a password for a certain key.
+`
+
+const pathCodeHelpDesc = `
+This path generates and validates time-based one-time use passwords for a
+certain key. Reading this path returns the code for the current time period.
+Writing a code to this path validates it; a code that was valid cannot be
+validated again until its time period has passed.
+`
@@ -0,0 +1,381 @@
+package totp
+
+import (
+	"bytes"
+	"encoding/base32"
+	"encoding/base64"
+	"fmt"
+	"image/png"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+	otplib "github.com/pquerna/otp"
+	totplib "github.com/pquerna/otp/totp"
+)
+
+func pathListKeys(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "keys/?$",
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ListOperation: b.pathKeyList,
+		},
+		HelpSynopsis:    pathKeyHelpSyn,
+		HelpDescription: pathKeyHelpDesc,
+	}
+}
+
+func pathKeys(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "keys/" + framework.GenericNameRegex("name"),
+		Fields: map[string]*framework.FieldSchema{
+			"name": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: "Name of the key.",
+			},
+			"generate": &framework.FieldSchema{
+				Type:        framework.TypeBool,
+				Default:     false,
+				Description: "Determines if a key should be generated by Vault or if a key is being passed from another service.",
+			},
+			"exported": &framework.FieldSchema{
+				Type:        framework.TypeBool,
+				Default:     true,
+				Description: "Determines if a QR code and url are returned upon generating a key. Only used if generate is true.",
+			},
+			"key_size": &framework.FieldSchema{
+				Type:        framework.TypeInt,
+				Default:     20,
+				Description: "Determines the size in bytes of the generated key. Only used if generate is true.",
+			},
+			"url": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: "A TOTP url string containing all of the parameters for key setup. Only used if generate is false.",
+			},
+			"key": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: "The shared master key used to generate a TOTP token. Only used if generate is false.",
+			},
+			"issuer": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: "The name of the key's issuing organization.",
+			},
+			"account_name": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: "The name of the account associated with the key.",
+			},
+			"period": &framework.FieldSchema{
+				Type:        framework.TypeDurationSecond,
+				Default:     30,
+				Description: "The length of time used to generate a counter for the TOTP token calculation.",
+			},
+			"algorithm": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Default:     "SHA1",
+				Description: "The hashing algorithm used to generate the TOTP token. Options include SHA1, SHA256 and SHA512.",
+			},
+			"digits": &framework.FieldSchema{
+				Type:        framework.TypeInt,
+				Default:     6,
+				Description: "The number of digits in the generated TOTP token. This value can either be 6 or 8.",
+			},
+			"skew": &framework.FieldSchema{
+				Type:        framework.TypeInt,
+				Default:     1,
+				Description: "The number of delay periods that are allowed when validating a TOTP token. This value can either be 0 or 1.",
+			},
+			"qr_size": &framework.FieldSchema{
+				Type:        framework.TypeInt,
+				Default:     200,
+				Description: "The pixel size of the generated square QR code. Only used if generate is true and exported is true. If this value is 0, a QR code will not be returned.",
+			},
+		},
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ReadOperation:   b.pathKeyRead,
+			logical.UpdateOperation: b.pathKeyCreate,
+			logical.DeleteOperation: b.pathKeyDelete,
+		},
+		HelpSynopsis:    pathKeyHelpSyn,
+		HelpDescription: pathKeyHelpDesc,
+	}
+}
+
+// Key reads the key entry from storage
+func (b *backend) Key(s logical.Storage, n string) (*keyEntry, error) {
+	entry, err := s.Get("key/" + n)
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		return nil, nil
+	}
+
+	var result keyEntry
+	if err := entry.DecodeJSON(&result); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+// Deletes an existing key
+func (b *backend) pathKeyDelete(req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	name := d.Get("name").(string)
+	if name == "" {
+		return logical.ErrorResponse("missing name"), nil
+	}
+
+	return nil, req.Storage.Delete("key/" + name)
+}
+
+// Reads an existing key. The shared secret is never returned.
+func (b *backend) pathKeyRead(req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	name := d.Get("name").(string)
+	if name == "" {
+		return logical.ErrorResponse("missing name"), nil
+	}
+
+	key, err := b.Key(req.Storage, name)
+	if err != nil {
+		return nil, err
+	}
+	if key == nil {
+		return nil, nil
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"issuer":       key.Issuer,
+			"account_name": key.AccountName,
+			"period":       int64(key.Period.Seconds()),
+			"algorithm":    key.Algorithm,
+			"digits":       key.Digits,
+		},
+	}, nil
+}
+
+// Lists all the keys registered with the backend
+func (b *backend) pathKeyList(req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	keys, err := req.Storage.List("key/")
+	if err != nil {
+		return nil, err
+	}
+
+	return logical.ListResponse(keys), nil
+}
+
+// Creates a new key, either by generating it or by importing it from a key
+// or url given by another provider
+func (b *backend) pathKeyCreate(req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	name := d.Get("name").(string)
+	if name == "" {
+		return logical.ErrorResponse("missing name"), nil
+	}
+
+	generate := d.Get("generate").(bool)
+	exported := d.Get("exported").(bool)
+	keyString := d.Get("key").(string)
+	keyURL := d.Get("url").(string)
+	issuer := d.Get("issuer").(string)
+	accountName := d.Get("account_name").(string)
+	period := d.Get("period").(int)
+	algorithm := strings.ToUpper(d.Get("algorithm").(string))
+	digits := d.Get("digits").(int)
+	skew := d.Get("skew").(int)
+	qrSize := d.Get("qr_size").(int)
+	keySize := d.Get("key_size").(int)
+
+	// When importing from a url, the parameters embedded in it take the
+	// place of the defaults but not of explicitly given values
+	if !generate && keyURL != "" {
+		if keyString != "" {
+			return logical.ErrorResponse("only one of url and key can be given"), nil
+		}
+
+		parsedKey, err := otplib.NewKeyFromURL(keyURL)
+		if err != nil {
+			return logical.ErrorResponse(fmt.Sprintf("error parsing url: %v", err)), nil
+		}
+		if parsedKey.Type() != "totp" {
+			return logical.ErrorResponse("url must be a totp url"), nil
+		}
+
+		u, err := url.Parse(keyURL)
+		if err != nil {
+			return logical.ErrorResponse(fmt.Sprintf("error parsing url: %v", err)), nil
+		}
+		query := u.Query()
+
+		keyString = parsedKey.Secret()
+		if _, ok := d.GetOk("issuer"); !ok {
+			issuer = parsedKey.Issuer()
+		}
+		if _, ok := d.GetOk("account_name"); !ok {
+			accountName = parsedKey.AccountName()
+		}
+		if _, ok := d.GetOk("algorithm"); !ok && query.Get("algorithm") != "" {
+			algorithm = strings.ToUpper(query.Get("algorithm"))
+		}
+		if _, ok := d.GetOk("period"); !ok && query.Get("period") != "" {
+			period, err = strconv.Atoi(query.Get("period"))
+			if err != nil {
+				return logical.ErrorResponse(fmt.Sprintf("invalid period in url: %v", err)), nil
+			}
+		}
+		if _, ok := d.GetOk("digits"); !ok && query.Get("digits") != "" {
+			digits, err = strconv.Atoi(query.Get("digits"))
+			if err != nil {
+				return logical.ErrorResponse(fmt.Sprintf("invalid digits in url: %v", err)), nil
+			}
+		}
+	}
+
+	if period <= 0 {
+		return logical.ErrorResponse("the period value must be greater than zero"), nil
+	}
+
+	var keyAlgorithm otplib.Algorithm
+	switch algorithm {
+	case "SHA1":
+		keyAlgorithm = otplib.AlgorithmSHA1
+	case "SHA256":
+		keyAlgorithm = otplib.AlgorithmSHA256
+	case "SHA512":
+		keyAlgorithm = otplib.AlgorithmSHA512
+	default:
+		return logical.ErrorResponse("the algorithm value is not valid"), nil
+	}
+
+	var keyDigits otplib.Digits
+	switch digits {
+	case 6:
+		keyDigits = otplib.DigitsSix
+	case 8:
+		keyDigits = otplib.DigitsEight
+	default:
+		return logical.ErrorResponse("the digits value can only be 6 or 8"), nil
+	}
+
+	if skew != 0 && skew != 1 {
+		return logical.ErrorResponse("the skew value must be 0 or 1"), nil
+	}
+
+	if qrSize < 0 {
+		return logical.ErrorResponse("the qr_size value must be greater than or equal to zero"), nil
+	}
+
+	if keySize <= 0 {
+		return logical.ErrorResponse("the key_size value must be greater than zero"), nil
+	}
+
+	var resp *logical.Response
+	if generate {
+		if keyString != "" || keyURL != "" {
+			return logical.ErrorResponse("a key or url cannot be given when generate is true"), nil
+		}
+		if issuer == "" {
+			return logical.ErrorResponse("the issuer value is required when generate is true"), nil
+		}
+		if accountName == "" {
+			return logical.ErrorResponse("the account_name value is required when generate is true"), nil
+		}
+
+		generated, err := totplib.Generate(totplib.GenerateOpts{
+			Issuer:      issuer,
+			AccountName: accountName,
+			Period:      uint(period),
+			Digits:      keyDigits,
+			Algorithm:   keyAlgorithm,
+			SecretSize:  uint(keySize),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("error generating key: %v", err)
+		}
+		keyString = generated.Secret()
+
+		if exported {
+			resp = &logical.Response{
+				Data: map[string]interface{}{
+					"url": generated.String(),
+				},
+			}
+
+			if qrSize > 0 {
+				barcode, err := generated.Image(qrSize, qrSize)
+				if err != nil {
+					return nil, fmt.Errorf("error generating QR code: %v", err)
+				}
+
+				var buf bytes.Buffer
+				if err := png.Encode(&buf, barcode); err != nil {
+					return nil, fmt.Errorf("error encoding QR code: %v", err)
+				}
+				resp.Data["barcode"] = base64.StdEncoding.EncodeToString(buf.Bytes())
+			}
+		}
+	} else {
+		if keyString == "" {
+			return logical.ErrorResponse("the key value is required when generate is false"), nil
+		}
+	}
+
+	// Validate that the key is base32 encoded so that codes can be
+	// generated from it later on
+	keyString = padBase32(strings.ToUpper(strings.Replace(keyString, " ", "", -1)))
+	if _, err := base32.StdEncoding.DecodeString(keyString); err != nil {
+		return logical.ErrorResponse(fmt.Sprintf("invalid key value: %v", err)), nil
+	}
+
+	entry, err := logical.StorageEntryJSON("key/"+name, &keyEntry{
+		Key:         keyString,
+		Issuer:      issuer,
+		AccountName: accountName,
+		Period:      time.Duration(period) * time.Second,
+		Algorithm:   algorithm,
+		Digits:      digits,
+		Skew:        uint(skew),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := req.Storage.Put(entry); err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// padBase32 adds the padding that is usually omitted from TOTP keys
+func padBase32(s string) string {
+	if rem := len(s) % 8; rem != 0 {
+		s += strings.Repeat("=", 8-rem)
+	}
+	return s
+}
+
+type keyEntry struct {
+	Key         string        `json:"key" mapstructure:"key" structs:"key"`
+	Issuer      string        `json:"issuer" mapstructure:"issuer" structs:"issuer"`
+	AccountName string        `json:"account_name" mapstructure:"account_name" structs:"account_name"`
+	Period      time.Duration `json:"period" mapstructure:"period" structs:"period"`
+	Algorithm   string        `json:"algorithm" mapstructure:"algorithm" structs:"algorithm"`
+	Digits      int           `json:"digits" mapstructure:"digits" structs:"digits"`
+	Skew        uint          `json:"skew" mapstructure:"skew" structs:"skew"`
+}
+
+const pathKeyHelpSyn = `
+Manage the keys that can be created with this backend.
+`
+
+const pathKeyHelpDesc = `
+This path lets you manage the keys that can be created with this backend.
+
+A key can either be generated by Vault, in which case Vault acts as the
+provider and returns a url and a base64-encoded PNG QR code that can be
+given to a client, or imported from another provider using either a TOTP
+url or the base32-encoded shared key, in which case Vault generates codes
+on behalf of the holders of the key.
+`
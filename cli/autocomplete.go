@@ -0,0 +1,319 @@
+package cli
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/vault/api"
+	"github.com/hashicorp/vault/command"
+	"github.com/mitchellh/cli"
+	"github.com/mitchellh/go-homedir"
+)
+
+const (
+	// envCompLine and envCompPoint are set by bash when it runs vault to
+	// complete a command line, once installed with "complete -C"
+	envCompLine  = "COMP_LINE"
+	envCompPoint = "COMP_POINT"
+)
+
+// autocompleteFlags are the flags taken before the command
+var autocompleteFlags = []string{
+	"-autocomplete-install",
+	"-autocomplete-uninstall",
+	"-help",
+	"-version",
+}
+
+// pathCommands are the commands taking a Vault path, whose arguments are
+// completed with the mounts of the server
+var pathCommands = map[string]struct{}{
+	"delete":             struct{}{},
+	"list":               struct{}{},
+	"path-help":          struct{}{},
+	"read":               struct{}{},
+	"write":              struct{}{},
+	"kv delete":          struct{}{},
+	"kv destroy":         struct{}{},
+	"kv get":             struct{}{},
+	"kv list":            struct{}{},
+	"kv metadata delete": struct{}{},
+	"kv metadata get":    struct{}{},
+	"kv metadata put":    struct{}{},
+	"kv patch":           struct{}{},
+	"kv put":             struct{}{},
+	"kv rollback":        struct{}{},
+	"kv undelete":        struct{}{},
+}
+
+// autocompleteRCFiles are the shell configuration files the completion is
+// installed in, with the lines installing it. %s is the path of vault.
+var autocompleteRCFiles = []struct {
+	Name  string
+	Lines []string
+}{
+	{".bashrc", []string{"complete -C %s vault"}},
+	{".zshrc", []string{
+		"autoload -U +X bashcompinit && bashcompinit",
+		"complete -o nospace -C %s vault",
+	}},
+}
+
+// flagRe matches the flags of the help of a command
+var flagRe = regexp.MustCompile(`(?m)^\s+(-[a-zA-Z0-9-]+)`)
+
+// autocomplete returns the completions of the last word of the command line,
+// which is empty when the line ends with a space. paths returns the Vault
+// paths starting with the given prefix.
+func autocomplete(commands map[string]cli.CommandFactory, line string, paths func(string) []string) []string {
+	words := strings.Fields(line)
+	last := ""
+	if len(words) > 0 && !strings.HasSuffix(line, " ") {
+		last = words[len(words)-1]
+		words = words[:len(words)-1]
+	}
+	if len(words) == 0 {
+		return nil
+	}
+
+	// The first word is vault itself, and the longest run of the following
+	// words naming a command is the command
+	args := words[1:]
+	name := ""
+	n := 0
+	for i := len(args); i > 0; i-- {
+		if _, ok := commands[strings.Join(args[:i], " ")]; ok {
+			name = strings.Join(args[:i], " ")
+			n = i
+			break
+		}
+	}
+	if name == "" && len(args) > 0 {
+		return nil
+	}
+
+	var result []string
+	if n == len(args) {
+		// The last word may be a command, or a subcommand of the command
+		parent := ""
+		if name != "" {
+			parent = name + " "
+		}
+		for k := range commands {
+			if !strings.HasPrefix(k, parent+last) {
+				continue
+			}
+			word := strings.TrimPrefix(k, parent)
+			if !strings.Contains(word, " ") {
+				result = append(result, word)
+			}
+		}
+	}
+
+	switch {
+	case name == "" && strings.HasPrefix(last, "-"):
+		result = appendPrefixed(result, autocompleteFlags, last)
+
+	case name == "":
+
+	case strings.HasPrefix(last, "-"):
+		c, err := commands[name]()
+		if err != nil {
+			return nil
+		}
+		var flags []string
+		for _, m := range flagRe.FindAllStringSubmatch(c.Help(), -1) {
+			flags = append(flags, m[1])
+		}
+		result = appendPrefixed(result, flags, last)
+
+	default:
+		if _, ok := pathCommands[name]; ok && paths != nil {
+			result = append(result, paths(last)...)
+		}
+	}
+
+	return uniqueSorted(result)
+}
+
+// autocompletePaths returns the mounts of the server starting with prefix,
+// and the keys under the path of prefix once it's inside a mount. Nothing is
+// returned without a token or a reachable server.
+func autocompletePaths(prefix string) []string {
+	config := api.DefaultConfig()
+	if err := config.ReadEnvironment(); err != nil {
+		return nil
+	}
+	config.HttpClient.Timeout = 2 * time.Second
+
+	client, err := api.NewClient(config)
+	if err != nil {
+		return nil
+	}
+	if client.Token() == "" {
+		helper, err := command.DefaultTokenHelper()
+		if err != nil {
+			return nil
+		}
+		token, err := helper.Get()
+		if err != nil || token == "" {
+			return nil
+		}
+		client.SetToken(token)
+	}
+
+	mounts, err := client.Sys().ListMounts()
+	if err != nil {
+		return nil
+	}
+
+	var result []string
+	for mount := range mounts {
+		if strings.HasPrefix(mount, prefix) {
+			result = append(result, mount)
+			continue
+		}
+		if !strings.HasPrefix(prefix, mount) {
+			continue
+		}
+
+		dir := prefix[:strings.LastIndex(prefix, "/")+1]
+		secret, err := client.Logical().List(dir)
+		if err != nil || secret == nil {
+			continue
+		}
+		keys, _ := secret.Data["keys"].([]interface{})
+		for _, k := range keys {
+			if key, ok := k.(string); ok && strings.HasPrefix(dir+key, prefix) {
+				result = append(result, dir+key)
+			}
+		}
+	}
+	return result
+}
+
+// installAutocomplete installs or uninstalls the completion of the vault
+// binary at bin in the shell configuration files of the home directory
+func installAutocomplete(bin, home string, uninstall bool) error {
+	found := false
+	for _, rc := range autocompleteRCFiles {
+		path := filepath.Join(home, rc.Name)
+		contents, err := ioutil.ReadFile(path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		found = true
+
+		lines := make([]string, len(rc.Lines))
+		for i, l := range rc.Lines {
+			if strings.Contains(l, "%s") {
+				l = fmt.Sprintf(l, bin)
+			}
+			lines[i] = l
+		}
+		installed := strings.Contains(string(contents), lines[len(lines)-1])
+
+		switch {
+		case uninstall && installed:
+			var kept []string
+			for _, l := range strings.SplitAfter(string(contents), "\n") {
+				if !containsLine(lines, strings.TrimSuffix(l, "\n")) {
+					kept = append(kept, l)
+				}
+			}
+			contents = []byte(strings.Join(kept, ""))
+
+		case !uninstall && !installed:
+			if len(contents) > 0 && !strings.HasSuffix(string(contents), "\n") {
+				contents = append(contents, '\n')
+			}
+			contents = append(contents, []byte(strings.Join(lines, "\n")+"\n")...)
+
+		default:
+			continue
+		}
+
+		if err := ioutil.WriteFile(path, contents, 0644); err != nil {
+			return err
+		}
+	}
+
+	if !found {
+		return fmt.Errorf("no shell configuration file found in %s", home)
+	}
+	return nil
+}
+
+// runAutocompleteInstall handles the -autocomplete-install and
+// -autocomplete-uninstall flags
+func runAutocompleteInstall(uninstall bool) int {
+	// The binary is looked up as it was run, in the PATH if it was run by
+	// its name
+	bin, err := exec.LookPath(os.Args[0])
+	if err == nil {
+		bin, err = filepath.Abs(bin)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error finding the vault binary: %s\n", err)
+		return 1
+	}
+
+	home, err := homedir.Dir()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error finding the home directory: %s\n", err)
+		return 1
+	}
+
+	if err := installAutocomplete(bin, home, uninstall); err != nil {
+		fmt.Fprintf(os.Stderr, "Error installing autocompletion: %s\n", err)
+		return 1
+	}
+
+	if uninstall {
+		fmt.Println("Autocompletion uninstalled. Restart your shell for it to take effect.")
+	} else {
+		fmt.Println("Autocompletion installed. Restart your shell for it to take effect.")
+	}
+	return 0
+}
+
+func appendPrefixed(result, values []string, prefix string) []string {
+	for _, v := range values {
+		if strings.HasPrefix(v, prefix) {
+			result = append(result, v)
+		}
+	}
+	return result
+}
+
+func containsLine(lines []string, line string) bool {
+	for _, l := range lines {
+		if l == line {
+			return true
+		}
+	}
+	return false
+}
+
+func uniqueSorted(values []string) []string {
+	seen := make(map[string]struct{}, len(values))
+	result := make([]string, 0, len(values))
+	for _, v := range values {
+		if _, ok := seen[v]; !ok {
+			seen[v] = struct{}{}
+			result = append(result, v)
+		}
+	}
+	sort.Strings(result)
+	return result
+}
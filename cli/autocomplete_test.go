@@ -0,0 +1,86 @@
+package cli
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/vault/meta"
+)
+
+func TestAutocomplete(t *testing.T) {
+	commands := Commands(&meta.Meta{})
+	paths := func(prefix string) []string {
+		return []string{prefix + "bar/", prefix + "baz"}
+	}
+
+	cases := []struct {
+		Line     string
+		Expected []string
+	}{
+		{"vault rea", []string{"read"}},
+		{"vault kv ", []string{"delete", "destroy", "get", "list", "metadata", "patch", "put", "rollback", "undelete"}},
+		{"vault kv me", []string{"metadata"}},
+		{"vault kv metadata g", []string{"get"}},
+		{"vault -autocomplete-i", []string{"-autocomplete-install"}},
+		{"vault read -fi", []string{"-field"}},
+		{"vault kv get -ver", []string{"-version"}},
+		{"vault read -addr", []string{"-address"}},
+		{"vault read secret/", []string{"secret/bar/", "secret/baz"}},
+		{"vault kv get -field=a secret/", []string{"secret/bar/", "secret/baz"}},
+		{"vault status secret/", []string{}},
+		{"vault nope ", nil},
+	}
+
+	for _, tc := range cases {
+		actual := autocomplete(commands, tc.Line, paths)
+		if !reflect.DeepEqual(actual, tc.Expected) {
+			t.Fatalf("%q: bad: %#v", tc.Line, actual)
+		}
+	}
+}
+
+func TestInstallAutocomplete(t *testing.T) {
+	home, err := ioutil.TempDir("", "vault")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	defer os.RemoveAll(home)
+
+	if err := installAutocomplete("/bin/vault", home, false); err == nil {
+		t.Fatal("expected error without shell configuration files")
+	}
+
+	bashrc := filepath.Join(home, ".bashrc")
+	if err := ioutil.WriteFile(bashrc, []byte("export FOO=bar"), 0644); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	// Installing twice only adds the completion once
+	for i := 0; i < 2; i++ {
+		if err := installAutocomplete("/bin/vault", home, false); err != nil {
+			t.Fatalf("err: %s", err)
+		}
+	}
+	contents, err := ioutil.ReadFile(bashrc)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if string(contents) != "export FOO=bar\ncomplete -C /bin/vault vault\n" {
+		t.Fatalf("bad: %q", contents)
+	}
+
+	if err := installAutocomplete("/bin/vault", home, true); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	contents, err = ioutil.ReadFile(bashrc)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if strings.Contains(string(contents), "complete") {
+		t.Fatalf("bad: %q", contents)
+	}
+}
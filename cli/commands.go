@@ -4,6 +4,7 @@ import (
 	"os"
 
 	auditFile "github.com/hashicorp/vault/builtin/audit/file"
+	auditSocket "github.com/hashicorp/vault/builtin/audit/socket"
 	auditSyslog "github.com/hashicorp/vault/builtin/audit/syslog"
 	"github.com/hashicorp/vault/command/server"
 	"github.com/hashicorp/vault/version"
@@ -26,6 +27,7 @@ import (
 	"github.com/hashicorp/vault/builtin/logical/postgresql"
 	"github.com/hashicorp/vault/builtin/logical/rabbitmq"
 	"github.com/hashicorp/vault/builtin/logical/ssh"
+	"github.com/hashicorp/vault/builtin/logical/totp"
 	"github.com/hashicorp/vault/builtin/logical/transit"
 
 	"github.com/hashicorp/vault/audit"
@@ -58,11 +60,19 @@ func Commands(metaPtr *meta.Meta) map[string]cli.CommandFactory {
 			}, nil
 		},
 
+		"agent": func() (cli.Command, error) {
+			return &command.AgentCommand{
+				Meta:       *metaPtr,
+				ShutdownCh: command.MakeShutdownCh(),
+			}, nil
+		},
+
 		"server": func() (cli.Command, error) {
 			return &command.ServerCommand{
 				Meta: *metaPtr,
 				AuditBackends: map[string]audit.Factory{
 					"file":   auditFile.Factory,
+					"socket": auditSocket.Factory,
 					"syslog": auditSyslog.Factory,
 				},
 				CredentialBackends: map[string]logical.Factory{
@@ -86,6 +96,7 @@ func Commands(metaPtr *meta.Meta) map[string]cli.CommandFactory {
 					"mysql":      mysql.Factory,
 					"ssh":        ssh.Factory,
 					"rabbitmq":   rabbitmq.Factory,
+					"totp":       totp.Factory,
 				},
 				ShutdownCh:  command.MakeShutdownCh(),
 				SighupCh:    command.MakeSighupCh(),
@@ -117,6 +128,19 @@ func Commands(metaPtr *meta.Meta) map[string]cli.CommandFactory {
 			}, nil
 		},
 
+		"login": func() (cli.Command, error) {
+			return &command.LoginCommand{
+				Meta: *metaPtr,
+				Handlers: map[string]command.AuthHandler{
+					"github":   &credGitHub.CLIHandler{},
+					"userpass": &credUserpass.CLIHandler{},
+					"ldap":     &credLdap.CLIHandler{},
+					"cert":     &credCert.CLIHandler{},
+					"oidc":     &command.OIDCAuthHandler{},
+				},
+			}, nil
+		},
+
 		"auth-enable": func() (cli.Command, error) {
 			return &command.AuthEnableCommand{
 				Meta: *metaPtr,
@@ -159,6 +183,18 @@ func Commands(metaPtr *meta.Meta) map[string]cli.CommandFactory {
 			}, nil
 		},
 
+		"policy": func() (cli.Command, error) {
+			return &command.PolicyCommand{
+				Meta: *metaPtr,
+			}, nil
+		},
+
+		"policy fmt": func() (cli.Command, error) {
+			return &command.PolicyFmtCommand{
+				Meta: *metaPtr,
+			}, nil
+		},
+
 		"policy-delete": func() (cli.Command, error) {
 			return &command.PolicyDeleteCommand{
 				Meta: *metaPtr,
@@ -201,18 +237,134 @@ func Commands(metaPtr *meta.Meta) map[string]cli.CommandFactory {
 			}, nil
 		},
 
+		"kv": func() (cli.Command, error) {
+			return &command.KVCommand{
+				Meta: *metaPtr,
+			}, nil
+		},
+
+		"kv get": func() (cli.Command, error) {
+			return &command.KVGetCommand{
+				Meta: *metaPtr,
+			}, nil
+		},
+
+		"kv put": func() (cli.Command, error) {
+			return &command.KVPutCommand{
+				Meta: *metaPtr,
+			}, nil
+		},
+
+		"kv patch": func() (cli.Command, error) {
+			return &command.KVPatchCommand{
+				Meta: *metaPtr,
+			}, nil
+		},
+
+		"kv list": func() (cli.Command, error) {
+			return &command.KVListCommand{
+				Meta: *metaPtr,
+			}, nil
+		},
+
+		"kv delete": func() (cli.Command, error) {
+			return &command.KVDeleteCommand{
+				Meta: *metaPtr,
+			}, nil
+		},
+
+		"kv undelete": func() (cli.Command, error) {
+			return &command.KVUndeleteCommand{
+				Meta: *metaPtr,
+			}, nil
+		},
+
+		"kv destroy": func() (cli.Command, error) {
+			return &command.KVDestroyCommand{
+				Meta: *metaPtr,
+			}, nil
+		},
+
+		"kv rollback": func() (cli.Command, error) {
+			return &command.KVRollbackCommand{
+				Meta: *metaPtr,
+			}, nil
+		},
+
+		"kv metadata": func() (cli.Command, error) {
+			return &command.KVMetadataCommand{
+				Meta: *metaPtr,
+			}, nil
+		},
+
+		"kv metadata get": func() (cli.Command, error) {
+			return &command.KVMetadataGetCommand{
+				Meta: *metaPtr,
+			}, nil
+		},
+
+		"kv metadata put": func() (cli.Command, error) {
+			return &command.KVMetadataPutCommand{
+				Meta: *metaPtr,
+			}, nil
+		},
+
+		"kv metadata delete": func() (cli.Command, error) {
+			return &command.KVMetadataDeleteCommand{
+				Meta: *metaPtr,
+			}, nil
+		},
+
 		"rekey": func() (cli.Command, error) {
 			return &command.RekeyCommand{
 				Meta: *metaPtr,
 			}, nil
 		},
 
+		"debug": func() (cli.Command, error) {
+			return &command.DebugCommand{
+				Meta:       *metaPtr,
+				ShutdownCh: command.MakeShutdownCh(),
+			}, nil
+		},
+
+		"monitor": func() (cli.Command, error) {
+			return &command.MonitorCommand{
+				Meta:       *metaPtr,
+				ShutdownCh: command.MakeShutdownCh(),
+			}, nil
+		},
+
 		"generate-root": func() (cli.Command, error) {
 			return &command.GenerateRootCommand{
 				Meta: *metaPtr,
 			}, nil
 		},
 
+		"lease": func() (cli.Command, error) {
+			return &command.LeaseCommand{
+				Meta: *metaPtr,
+			}, nil
+		},
+
+		"lease lookup": func() (cli.Command, error) {
+			return &command.LeaseLookupCommand{
+				Meta: *metaPtr,
+			}, nil
+		},
+
+		"lease renew": func() (cli.Command, error) {
+			return &command.LeaseRenewCommand{
+				Meta: *metaPtr,
+			}, nil
+		},
+
+		"lease revoke": func() (cli.Command, error) {
+			return &command.LeaseRevokeCommand{
+				Meta: *metaPtr,
+			}, nil
+		},
+
 		"renew": func() (cli.Command, error) {
 			return &command.RenewCommand{
 				Meta: *metaPtr,
@@ -249,6 +401,12 @@ func Commands(metaPtr *meta.Meta) map[string]cli.CommandFactory {
 			}, nil
 		},
 
+		"operator migrate": func() (cli.Command, error) {
+			return &command.OperatorMigrateCommand{
+				Meta: *metaPtr,
+			}, nil
+		},
+
 		"mount": func() (cli.Command, error) {
 			return &command.MountCommand{
 				Meta: *metaPtr,
@@ -285,33 +443,94 @@ func Commands(metaPtr *meta.Meta) map[string]cli.CommandFactory {
 			}, nil
 		},
 
-		"token-create": func() (cli.Command, error) {
+		"token": func() (cli.Command, error) {
+			return &command.TokenCommand{
+				Meta: *metaPtr,
+			}, nil
+		},
+
+		"token capabilities": func() (cli.Command, error) {
+			return &command.CapabilitiesCommand{
+				Meta: *metaPtr,
+			}, nil
+		},
+
+		"token create": func() (cli.Command, error) {
 			return &command.TokenCreateCommand{
 				Meta: *metaPtr,
 			}, nil
 		},
 
-		"token-lookup": func() (cli.Command, error) {
+		"token lookup": func() (cli.Command, error) {
 			return &command.TokenLookupCommand{
 				Meta: *metaPtr,
 			}, nil
 		},
 
-		"token-renew": func() (cli.Command, error) {
+		"token renew": func() (cli.Command, error) {
 			return &command.TokenRenewCommand{
 				Meta: *metaPtr,
 			}, nil
 		},
 
-		"token-revoke": func() (cli.Command, error) {
+		"token revoke": func() (cli.Command, error) {
 			return &command.TokenRevokeCommand{
 				Meta: *metaPtr,
 			}, nil
 		},
 
+		"token-create": func() (cli.Command, error) {
+			return &command.DeprecatedCommand{
+				Command: &command.TokenCreateCommand{
+					Meta: *metaPtr,
+				},
+				Old: "token-create",
+				New: "token create",
+				Ui:  metaPtr.Ui,
+			}, nil
+		},
+
+		"token-lookup": func() (cli.Command, error) {
+			return &command.DeprecatedCommand{
+				Command: &command.TokenLookupCommand{
+					Meta: *metaPtr,
+				},
+				Old: "token-lookup",
+				New: "token lookup",
+				Ui:  metaPtr.Ui,
+			}, nil
+		},
+
+		"token-renew": func() (cli.Command, error) {
+			return &command.DeprecatedCommand{
+				Command: &command.TokenRenewCommand{
+					Meta: *metaPtr,
+				},
+				Old: "token-renew",
+				New: "token renew",
+				Ui:  metaPtr.Ui,
+			}, nil
+		},
+
+		"token-revoke": func() (cli.Command, error) {
+			return &command.DeprecatedCommand{
+				Command: &command.TokenRevokeCommand{
+					Meta: *metaPtr,
+				},
+				Old: "token-revoke",
+				New: "token revoke",
+				Ui:  metaPtr.Ui,
+			}, nil
+		},
+
 		"capabilities": func() (cli.Command, error) {
-			return &command.CapabilitiesCommand{
-				Meta: *metaPtr,
+			return &command.DeprecatedCommand{
+				Command: &command.CapabilitiesCommand{
+					Meta: *metaPtr,
+				},
+				Old: "capabilities",
+				New: "token capabilities",
+				Ui:  metaPtr.Ui,
 			}, nil
 		},
 
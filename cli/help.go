@@ -13,13 +13,13 @@ import (
 func HelpFunc(commands map[string]cli.CommandFactory) string {
 	commonNames := map[string]struct{}{
 		"delete":    struct{}{},
+		"lease":     struct{}{},
 		"path-help": struct{}{},
 		"read":      struct{}{},
-		"renew":     struct{}{},
-		"revoke":    struct{}{},
 		"write":     struct{}{},
 		"server":    struct{}{},
 		"status":    struct{}{},
+		"token":     struct{}{},
 		"unwrap":    struct{}{},
 	}
 
@@ -40,7 +40,7 @@ func HelpFunc(commands map[string]cli.CommandFactory) string {
 	}
 
 	var buf bytes.Buffer
-	buf.WriteString("usage: vault [-version] [-help] <command> [args]\n\n")
+	buf.WriteString("usage: vault [-version] [-help] [-autocomplete-install] <command> [args]\n\n")
 	buf.WriteString("Common commands:\n")
 	buf.WriteString(listCommands(commonCommands, maxKeyLen))
 	buf.WriteString("\nAll other commands:\n")
@@ -3,6 +3,7 @@ package cli
 import (
 	"fmt"
 	"os"
+	"strconv"
 
 	"github.com/mitchellh/cli"
 )
@@ -12,6 +13,27 @@ func Run(args []string) int {
 }
 
 func RunCustom(args []string, commands map[string]cli.CommandFactory) int {
+	// When run by the shell to complete a command line, output the
+	// completions instead of running a command
+	if line := os.Getenv(envCompLine); line != "" {
+		if point, err := strconv.Atoi(os.Getenv(envCompPoint)); err == nil && point >= 0 && point < len(line) {
+			line = line[:point]
+		}
+		for _, completion := range autocomplete(commands, line, autocompletePaths) {
+			fmt.Println(completion)
+		}
+		return 0
+	}
+
+	for _, arg := range args {
+		switch arg {
+		case "-autocomplete-install":
+			return runAutocompleteInstall(false)
+		case "-autocomplete-uninstall":
+			return runAutocompleteInstall(true)
+		}
+	}
+
 	// Get the command line args. We shortcut "--version" and "-v" to
 	// just show the version.
 	for _, arg := range args {
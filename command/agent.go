@@ -0,0 +1,351 @@
+package command
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+
+	colorable "github.com/mattn/go-colorable"
+	log "github.com/mgutz/logxi/v1"
+
+	"github.com/hashicorp/vault/api"
+	"github.com/hashicorp/vault/command/agent/auth"
+	"github.com/hashicorp/vault/command/agent/cache"
+	agentConfig "github.com/hashicorp/vault/command/agent/config"
+	"github.com/hashicorp/vault/command/agent/proxy"
+	"github.com/hashicorp/vault/command/agent/sink"
+	"github.com/hashicorp/vault/command/agent/template"
+	"github.com/hashicorp/vault/command/server"
+	"github.com/hashicorp/vault/helper/gated-writer"
+	"github.com/hashicorp/vault/helper/logformat"
+	"github.com/hashicorp/vault/meta"
+	"github.com/hashicorp/vault/version"
+)
+
+// AgentCommand is a Command that starts a Vault agent, which authenticates
+// with an auth method, keeps its token renewed, writes it to sinks, renders
+// templates with secrets, and proxies requests to the Vault server with it.
+type AgentCommand struct {
+	meta.Meta
+
+	ShutdownCh chan struct{}
+
+	logger log.Logger
+}
+
+func (c *AgentCommand) Run(args []string) int {
+	var configPath, logLevel string
+	flags := c.Meta.FlagSet("agent", meta.FlagSetNone)
+	flags.StringVar(&configPath, "config", "", "")
+	flags.StringVar(&logLevel, "log-level", "info", "")
+	flags.Usage = func() { c.Ui.Error(c.Help()) }
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	if configPath == "" {
+		c.Ui.Error("A config path must be specified with -config")
+		flags.Usage()
+		return 1
+	}
+
+	config, err := agentConfig.LoadConfig(configPath)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error loading configuration from %s: %s", configPath, err))
+		return 1
+	}
+	if config.AutoAuth == nil && len(config.Templates) > 0 {
+		c.Ui.Error("An 'auto_auth' block is required to render templates")
+		return 1
+	}
+	if config.AutoAuth == nil && len(config.Listeners) == 0 {
+		c.Ui.Error("No 'auto_auth' nor 'listener' block found in the configuration")
+		return 1
+	}
+
+	// Create a logger. We wrap it in a gated writer so that it doesn't
+	// start logging too early.
+	logGate := &gatedwriter.Writer{Writer: colorable.NewColorable(os.Stderr)}
+	var level int
+	switch logLevel {
+	case "trace":
+		level = log.LevelTrace
+	case "debug":
+		level = log.LevelDebug
+	case "info":
+		level = log.LevelInfo
+	case "notice":
+		level = log.LevelNotice
+	case "warn":
+		level = log.LevelWarn
+	case "err":
+		level = log.LevelError
+	default:
+		c.Ui.Error(fmt.Sprintf("Unknown log level %s", logLevel))
+		return 1
+	}
+	c.logger = logformat.NewVaultLoggerWithWriter(logGate, level)
+
+	apiConfig, err := agentAPIConfig(config.Vault)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error configuring the Vault client: %s", err))
+		return 1
+	}
+
+	// Every component gets its own client since their tokens differ
+	newClient := func() (*api.Client, error) {
+		client, err := api.NewClient(apiConfig)
+		if err != nil {
+			return nil, err
+		}
+		client.ClearToken()
+		return client, nil
+	}
+
+	info := map[string]string{
+		"log level":     logLevel,
+		"vault address": apiConfig.Address,
+		"version":       version.GetVersion().FullVersionNumber(),
+	}
+	infoKeys := []string{"log level", "vault address", "version"}
+
+	shutdownCh := make(chan struct{})
+	defer close(shutdownCh)
+
+	var authHandler *auth.Handler
+	var method auth.AuthMethod
+	var sinkServer *sink.Server
+	var renderer *template.Renderer
+	if config.AutoAuth != nil {
+		methodConfig := config.AutoAuth.Method
+		method, err = auth.NewMethod(methodConfig.Type, &auth.MethodConfig{
+			Logger:    c.logger,
+			MountPath: methodConfig.MountPath,
+			Config:    methodConfig.Config,
+		})
+		if err != nil {
+			c.Ui.Error(fmt.Sprintf("Error creating the %s auth method: %s", methodConfig.Type, err))
+			return 1
+		}
+		info["auth method"] = fmt.Sprintf("%s (mount path: %q)", methodConfig.Type, methodConfig.MountPath)
+		infoKeys = append(infoKeys, "auth method")
+
+		var sinks []sink.Sink
+		for i, sc := range config.AutoAuth.Sinks {
+			s, err := sink.NewSink(sc.Type, sc.Config)
+			if err != nil {
+				c.Ui.Error(fmt.Sprintf("Error creating the %s sink: %s", sc.Type, err))
+				return 1
+			}
+			sinks = append(sinks, s)
+
+			key := fmt.Sprintf("sink %d", i+1)
+			info[key] = sc.Type
+			infoKeys = append(infoKeys, key)
+		}
+		sinkServer = sink.NewServer(c.logger, sinks)
+
+		client, err := newClient()
+		if err != nil {
+			c.Ui.Error(fmt.Sprintf("Error creating the Vault client: %s", err))
+			return 1
+		}
+		authHandler = auth.NewHandler(&auth.HandlerConfig{
+			Logger: c.logger,
+			Client: client,
+		})
+
+		if len(config.Templates) > 0 {
+			client, err := newClient()
+			if err != nil {
+				c.Ui.Error(fmt.Sprintf("Error creating the Vault client: %s", err))
+				return 1
+			}
+			renderer = template.NewRenderer(c.logger, client, config.Templates)
+			info["templates"] = fmt.Sprintf("%d", len(config.Templates))
+			infoKeys = append(infoKeys, "templates")
+		}
+	}
+
+	var tokenProxy *proxy.Proxy
+	var handler http.Handler
+	if len(config.Listeners) > 0 {
+		tokenProxy, err = proxy.NewProxy(c.logger, apiConfig.Address, apiConfig.HttpClient.Transport)
+		if err != nil {
+			c.Ui.Error(fmt.Sprintf("Error creating the proxy: %s", err))
+			return 1
+		}
+		handler = tokenProxy
+
+		if config.Cache != nil {
+			leaseCache := cache.NewLeaseCache(&cache.LeaseCacheConfig{
+				Logger:    c.logger,
+				APIConfig: apiConfig,
+				Proxier:   tokenProxy,
+				TokenFunc: tokenProxy.Token,
+			})
+			defer leaseCache.Shutdown()
+			handler = leaseCache
+			info["cache"] = "enabled"
+			infoKeys = append(infoKeys, "cache")
+		}
+	} else if config.Cache != nil {
+		c.Ui.Error("A 'listener' block is required by the cache")
+		return 1
+	}
+
+	for i, lnConfig := range config.Listeners {
+		ln, props, _, err := server.NewListener(lnConfig.Type, lnConfig.Config, logGate)
+		if err != nil {
+			c.Ui.Error(fmt.Sprintf("Error initializing listener of type %s: %s", lnConfig.Type, err))
+			return 1
+		}
+		defer ln.Close()
+
+		propsList := make([]string, 0, len(props))
+		for k, v := range props {
+			propsList = append(propsList, fmt.Sprintf("%s: %q", k, v))
+		}
+		sort.Strings(propsList)
+		key := fmt.Sprintf("listener %d", i+1)
+		info[key] = fmt.Sprintf("%s (%s)", lnConfig.Type, strings.Join(propsList, ", "))
+		infoKeys = append(infoKeys, key)
+
+		go (&http.Server{Handler: handler}).Serve(ln)
+	}
+
+	if config.PidFile != "" {
+		if err := ioutil.WriteFile(config.PidFile, []byte(fmt.Sprintf("%d", os.Getpid())), 0644); err != nil {
+			c.Ui.Error(fmt.Sprintf("Error writing the pid file: %s", err))
+			return 1
+		}
+		defer func() {
+			if err := os.Remove(config.PidFile); err != nil {
+				c.Ui.Error(fmt.Sprintf("Error removing the pid file: %s", err))
+			}
+		}()
+	}
+
+	// Agent configuration output
+	padding := 24
+	sort.Strings(infoKeys)
+	c.Ui.Output("==> Vault agent configuration:\n")
+	for _, k := range infoKeys {
+		c.Ui.Output(fmt.Sprintf(
+			"%s%s: %s",
+			strings.Repeat(" ", padding-len(k)),
+			strings.Title(k),
+			info[k]))
+	}
+	c.Ui.Output("")
+	c.Ui.Output("==> Vault agent started! Log data will stream in below:\n")
+	logGate.Flush()
+
+	if authHandler != nil {
+		go authHandler.Run(method, shutdownCh)
+
+		var templateCh chan string
+		if renderer != nil {
+			templateCh = make(chan string)
+			go renderer.Run(templateCh, shutdownCh)
+		}
+
+		// The tokens are sent to the sinks, the proxy and the templates
+		go func() {
+			for {
+				var token string
+				select {
+				case <-shutdownCh:
+					return
+				case token = <-authHandler.OutputCh:
+				}
+
+				sinkServer.WriteToken(token)
+				if tokenProxy != nil {
+					tokenProxy.SetToken(token)
+				}
+				if templateCh != nil {
+					select {
+					case <-shutdownCh:
+						return
+					case templateCh <- token:
+					}
+				}
+			}
+		}()
+	}
+
+	<-c.ShutdownCh
+	c.Ui.Output("==> Vault agent shutdown triggered")
+	return 0
+}
+
+// agentAPIConfig returns the configuration of the clients of the agent, read
+// from the environment and overridden by the vault block of the
+// configuration
+func agentAPIConfig(v *agentConfig.Vault) (*api.Config, error) {
+	config := api.DefaultConfig()
+	if err := config.ReadEnvironment(); err != nil {
+		return nil, err
+	}
+	if v == nil {
+		return config, nil
+	}
+
+	if v.Address != "" {
+		config.Address = v.Address
+	}
+	if v.CACert != "" || v.CAPath != "" || v.ClientCert != "" || v.ClientKey != "" || v.TLSServerName != "" || v.TLSSkipVerify {
+		t := &api.TLSConfig{
+			CACert:        v.CACert,
+			CAPath:        v.CAPath,
+			ClientCert:    v.ClientCert,
+			ClientKey:     v.ClientKey,
+			TLSServerName: v.TLSServerName,
+			Insecure:      v.TLSSkipVerify,
+		}
+		if err := config.ConfigureTLS(t); err != nil {
+			return nil, err
+		}
+	}
+	return config, nil
+}
+
+func (c *AgentCommand) Synopsis() string {
+	return "Start a Vault agent"
+}
+
+func (c *AgentCommand) Help() string {
+	helpText := `
+Usage: vault agent [options]
+
+  Start a Vault agent.
+
+  The agent authenticates with the auth method of its configuration, keeps
+  its token renewed, and authenticates again once the token can't be renewed
+  anymore. The token is written to the configured sinks, used to render the
+  configured templates with secrets, and added to the requests proxied by
+  the configured listeners to the Vault server, so that applications don't
+  need to authenticate themselves.
+
+  With a cache block, the responses of the proxied requests which created a
+  lease or a token are cached, so that identical requests get the same
+  credentials. The cached leases and tokens are renewed, and evicted when
+  they expire or are revoked through the agent.
+
+  The supported auth methods are "approle", "aws", "cert" and "kubernetes".
+  See the documentation of the agent for the configuration format.
+
+Agent Options:
+
+  -config=<path>          Path to the configuration file. Required.
+
+  -log-level=info         Log verbosity. Defaults to "info", will be output to
+                          stderr. Supported values: "trace", "debug", "info",
+                          "warn", "err"
+`
+	return strings.TrimSpace(helpText)
+}
@@ -0,0 +1,87 @@
+package auth
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/vault/api"
+	log "github.com/mgutz/logxi/v1"
+)
+
+// appRoleMethod logs in with an AppRole backend, reading the RoleID and the
+// SecretID from files
+type appRoleMethod struct {
+	logger             log.Logger
+	mountPath          string
+	roleIDFilePath     string
+	secretIDFilePath   string
+	removeSecretIDFile bool
+	cachedSecretID     string
+}
+
+// NewAppRoleMethod returns the approle auth method. The SecretID file is
+// removed once read unless remove_secret_id_file_after_reading is false, and
+// the SecretID is then kept to login again.
+func NewAppRoleMethod(conf *MethodConfig) (AuthMethod, error) {
+	m := &appRoleMethod{
+		logger:             conf.Logger,
+		mountPath:          conf.MountPath,
+		removeSecretIDFile: true,
+	}
+
+	var err error
+	if m.roleIDFilePath, err = stringConfig(conf.Config, "role_id_file_path"); err != nil {
+		return nil, err
+	}
+	if m.roleIDFilePath == "" {
+		return nil, fmt.Errorf("'role_id_file_path' must be specified")
+	}
+	if m.secretIDFilePath, err = stringConfig(conf.Config, "secret_id_file_path"); err != nil {
+		return nil, err
+	}
+
+	remove, err := stringConfig(conf.Config, "remove_secret_id_file_after_reading")
+	if err != nil {
+		return nil, err
+	}
+	if remove != "" {
+		if m.removeSecretIDFile, err = strconv.ParseBool(remove); err != nil {
+			return nil, fmt.Errorf("invalid 'remove_secret_id_file_after_reading': %s", err)
+		}
+	}
+
+	return m, nil
+}
+
+func (m *appRoleMethod) Login(client *api.Client) (*api.Secret, error) {
+	roleID, err := ioutil.ReadFile(m.roleIDFilePath)
+	if err != nil {
+		return nil, fmt.Errorf("error reading the RoleID file: %s", err)
+	}
+
+	data := map[string]interface{}{
+		"role_id": strings.TrimSpace(string(roleID)),
+	}
+
+	if m.secretIDFilePath != "" {
+		secretID, err := ioutil.ReadFile(m.secretIDFilePath)
+		switch {
+		case err == nil:
+			m.cachedSecretID = strings.TrimSpace(string(secretID))
+			if m.removeSecretIDFile {
+				if err := os.Remove(m.secretIDFilePath); err != nil {
+					m.logger.Warn("auth.approle: error removing the SecretID file", "error", err)
+				}
+			}
+		case os.IsNotExist(err) && m.cachedSecretID != "":
+		default:
+			return nil, fmt.Errorf("error reading the SecretID file: %s", err)
+		}
+		data["secret_id"] = m.cachedSecretID
+	}
+
+	return client.Logical().Write(m.mountPath+"/login", data)
+}
@@ -0,0 +1,179 @@
+package auth
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/vault/api"
+	log "github.com/mgutz/logxi/v1"
+)
+
+// AuthMethod is implemented by the auth methods of the agent, which login
+// with an auth backend
+type AuthMethod interface {
+	// Login logs in with the auth backend and returns the login response
+	Login(client *api.Client) (*api.Secret, error)
+}
+
+// MethodConfig is the configuration of an auth method
+type MethodConfig struct {
+	Logger    log.Logger
+	MountPath string
+	Config    map[string]interface{}
+}
+
+// Factory creates an auth method
+type Factory func(*MethodConfig) (AuthMethod, error)
+
+// BuiltinMethods are the auth methods of the agent
+var BuiltinMethods = map[string]Factory{
+	"approle":    NewAppRoleMethod,
+	"aws":        NewAWSMethod,
+	"cert":       NewCertMethod,
+	"kubernetes": NewKubernetesMethod,
+}
+
+// NewMethod creates the auth method of the given type
+func NewMethod(t string, config *MethodConfig) (AuthMethod, error) {
+	f, ok := BuiltinMethods[t]
+	if !ok {
+		return nil, fmt.Errorf("unknown auth method type %s", t)
+	}
+	return f(config)
+}
+
+// HandlerConfig is the configuration of a Handler
+type HandlerConfig struct {
+	Logger log.Logger
+	Client *api.Client
+
+	// RetryBackoff is the time waited before logging in again after a
+	// failure, 5 seconds by default
+	RetryBackoff time.Duration
+}
+
+// Handler logs in with an auth method, keeps the token renewed, and logs in
+// again once it can't be renewed anymore. The tokens are sent to OutputCh.
+type Handler struct {
+	OutputCh chan string
+	DoneCh   chan struct{}
+
+	logger       log.Logger
+	client       *api.Client
+	retryBackoff time.Duration
+}
+
+// NewHandler returns a Handler for the given configuration
+func NewHandler(conf *HandlerConfig) *Handler {
+	retryBackoff := conf.RetryBackoff
+	if retryBackoff == 0 {
+		retryBackoff = 5 * time.Second
+	}
+
+	return &Handler{
+		OutputCh:     make(chan string),
+		DoneCh:       make(chan struct{}),
+		logger:       conf.Logger,
+		client:       conf.Client,
+		retryBackoff: retryBackoff,
+	}
+}
+
+// Run logs in with the auth method until shutdownCh is closed, and closes
+// DoneCh once it stops
+func (h *Handler) Run(method AuthMethod, shutdownCh <-chan struct{}) {
+	defer close(h.DoneCh)
+
+	for {
+		h.client.ClearToken()
+		secret, err := method.Login(h.client)
+		if err == nil && (secret == nil || secret.Auth == nil || secret.Auth.ClientToken == "") {
+			err = fmt.Errorf("no token returned by the auth method")
+		}
+		if err != nil {
+			h.logger.Error("auth: error logging in", "error", err, "backoff", h.retryBackoff)
+			select {
+			case <-shutdownCh:
+				return
+			case <-time.After(h.retryBackoff):
+				continue
+			}
+		}
+
+		h.logger.Info("auth: authentication successful, sending token to sinks")
+		select {
+		case <-shutdownCh:
+			return
+		case h.OutputCh <- secret.Auth.ClientToken:
+		}
+
+		if !h.renew(secret.Auth, shutdownCh) {
+			return
+		}
+	}
+}
+
+// renew renews the token until it can't be renewed anymore, and returns
+// false once shutdownCh is closed
+func (h *Handler) renew(auth *api.SecretAuth, shutdownCh <-chan struct{}) bool {
+	h.client.SetToken(auth.ClientToken)
+
+	// Tokens without a TTL don't need to be renewed nor replaced
+	if auth.LeaseDuration <= 0 {
+		<-shutdownCh
+		return false
+	}
+
+	// The tokens which aren't renewable are replaced at two thirds of their
+	// TTL
+	if !auth.Renewable {
+		select {
+		case <-shutdownCh:
+			return false
+		case <-time.After(time.Duration(auth.LeaseDuration) * time.Second * 2 / 3):
+		}
+		h.logger.Info("auth: token isn't renewable, logging in again")
+		return true
+	}
+
+	renewer, err := h.client.NewRenewer(&api.RenewerInput{
+		Secret: &api.Secret{Auth: auth},
+	})
+	if err != nil {
+		h.logger.Warn("auth: error creating renewer, logging in again", "error", err)
+		return true
+	}
+	go renewer.Renew()
+	defer renewer.Stop()
+
+	for {
+		select {
+		case <-shutdownCh:
+			return false
+
+		case err := <-renewer.DoneCh():
+			if err != nil {
+				h.logger.Warn("auth: error renewing token, logging in again", "error", err)
+			} else {
+				h.logger.Info("auth: token is reaching its maximum TTL, logging in again")
+			}
+			return true
+
+		case renewal := <-renewer.RenewCh():
+			h.logger.Debug("auth: renewed token", "ttl", renewal.Secret.Auth.LeaseDuration)
+		}
+	}
+}
+
+// stringConfig returns the string value of the config key
+func stringConfig(config map[string]interface{}, key string) (string, error) {
+	raw, ok := config[key]
+	if !ok {
+		return "", nil
+	}
+	v, ok := raw.(string)
+	if !ok {
+		return "", fmt.Errorf("'%s' must be a string", key)
+	}
+	return v, nil
+}
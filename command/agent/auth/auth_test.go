@@ -0,0 +1,75 @@
+package auth
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/api"
+	"github.com/hashicorp/vault/helper/logformat"
+	log "github.com/mgutz/logxi/v1"
+)
+
+type testMethod struct {
+	logins int
+}
+
+func (m *testMethod) Login(client *api.Client) (*api.Secret, error) {
+	m.logins++
+	if m.logins == 1 {
+		return nil, fmt.Errorf("first login fails")
+	}
+	return &api.Secret{
+		Auth: &api.SecretAuth{
+			ClientToken:   fmt.Sprintf("token-%d", m.logins),
+			LeaseDuration: 1,
+		},
+	}, nil
+}
+
+func TestHandler(t *testing.T) {
+	client, err := api.NewClient(api.DefaultConfig())
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	h := NewHandler(&HandlerConfig{
+		Logger:       logformat.NewVaultLogger(log.LevelTrace),
+		Client:       client,
+		RetryBackoff: 10 * time.Millisecond,
+	})
+	shutdownCh := make(chan struct{})
+	go h.Run(&testMethod{}, shutdownCh)
+
+	// The failed login is retried, and the token which isn't renewable is
+	// replaced before it expires
+	for _, expected := range []string{"token-2", "token-3"} {
+		select {
+		case token := <-h.OutputCh:
+			if token != expected {
+				t.Fatalf("bad: %s", token)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for a token")
+		}
+	}
+
+	close(shutdownCh)
+	select {
+	case <-h.DoneCh:
+	case <-time.After(5 * time.Second):
+		t.Fatal("handler didn't stop")
+	}
+}
+
+func TestNewMethod_bad(t *testing.T) {
+	if _, err := NewMethod("unknown", &MethodConfig{}); err == nil {
+		t.Fatal("expected error")
+	}
+	if _, err := NewMethod("approle", &MethodConfig{Config: map[string]interface{}{}}); err == nil {
+		t.Fatal("expected error")
+	}
+	if _, err := NewMethod("kubernetes", &MethodConfig{Config: map[string]interface{}{}}); err == nil {
+		t.Fatal("expected error")
+	}
+}
@@ -0,0 +1,81 @@
+package auth
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/vault/api"
+	log "github.com/mgutz/logxi/v1"
+)
+
+// awsPKCS7URL is the URL of the PKCS#7 signature of the identity document of
+// the EC2 instance
+const awsPKCS7URL = "http://169.254.169.254/latest/dynamic/instance-identity/pkcs7"
+
+// awsMethod logs in with an aws-ec2 backend using the PKCS#7 signature of the
+// identity document of the instance
+type awsMethod struct {
+	logger    log.Logger
+	mountPath string
+	role      string
+	nonce     string
+	pkcs7URL  string
+}
+
+// NewAWSMethod returns the aws auth method. The nonce returned by the first
+// login is kept to login again.
+func NewAWSMethod(conf *MethodConfig) (AuthMethod, error) {
+	m := &awsMethod{
+		logger:    conf.Logger,
+		mountPath: conf.MountPath,
+		pkcs7URL:  awsPKCS7URL,
+	}
+
+	var err error
+	if m.role, err = stringConfig(conf.Config, "role"); err != nil {
+		return nil, err
+	}
+	if m.nonce, err = stringConfig(conf.Config, "nonce"); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+func (m *awsMethod) Login(client *api.Client) (*api.Secret, error) {
+	httpClient := &http.Client{Timeout: 10 * time.Second}
+	resp, err := httpClient.Get(m.pkcs7URL)
+	if err != nil {
+		return nil, fmt.Errorf("error reading the instance identity: %s", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("error reading the instance identity: status %d", resp.StatusCode)
+	}
+	pkcs7, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("error reading the instance identity: %s", err)
+	}
+
+	data := map[string]interface{}{
+		"pkcs7": strings.Replace(strings.TrimSpace(string(pkcs7)), "\n", "", -1),
+	}
+	if m.role != "" {
+		data["role"] = m.role
+	}
+	if m.nonce != "" {
+		data["nonce"] = m.nonce
+	}
+
+	secret, err := client.Logical().Write(m.mountPath+"/login", data)
+	if err != nil {
+		return nil, err
+	}
+	if secret != nil && secret.Auth != nil && m.nonce == "" {
+		m.nonce = secret.Auth.Metadata["nonce"]
+	}
+	return secret, nil
+}
@@ -0,0 +1,37 @@
+package auth
+
+import (
+	"github.com/hashicorp/vault/api"
+	log "github.com/mgutz/logxi/v1"
+)
+
+// certMethod logs in with a cert backend using the client certificate of the
+// vault block of the configuration
+type certMethod struct {
+	logger    log.Logger
+	mountPath string
+	name      string
+}
+
+// NewCertMethod returns the cert auth method
+func NewCertMethod(conf *MethodConfig) (AuthMethod, error) {
+	m := &certMethod{
+		logger:    conf.Logger,
+		mountPath: conf.MountPath,
+	}
+
+	var err error
+	if m.name, err = stringConfig(conf.Config, "name"); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+func (m *certMethod) Login(client *api.Client) (*api.Secret, error) {
+	data := map[string]interface{}{}
+	if m.name != "" {
+		data["name"] = m.name
+	}
+	return client.Logical().Write(m.mountPath+"/login", data)
+}
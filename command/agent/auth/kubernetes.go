@@ -0,0 +1,59 @@
+package auth
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/hashicorp/vault/api"
+	log "github.com/mgutz/logxi/v1"
+)
+
+// kubernetesTokenPath is the path of the service account token of the pods
+const kubernetesTokenPath = "/var/run/secrets/kubernetes.io/serviceaccount/token"
+
+// kubernetesMethod logs in with a kubernetes backend using the service
+// account token of the pod
+type kubernetesMethod struct {
+	logger    log.Logger
+	mountPath string
+	role      string
+	tokenPath string
+}
+
+// NewKubernetesMethod returns the kubernetes auth method
+func NewKubernetesMethod(conf *MethodConfig) (AuthMethod, error) {
+	m := &kubernetesMethod{
+		logger:    conf.Logger,
+		mountPath: conf.MountPath,
+	}
+
+	var err error
+	if m.role, err = stringConfig(conf.Config, "role"); err != nil {
+		return nil, err
+	}
+	if m.role == "" {
+		return nil, fmt.Errorf("'role' must be specified")
+	}
+	if m.tokenPath, err = stringConfig(conf.Config, "token_path"); err != nil {
+		return nil, err
+	}
+	if m.tokenPath == "" {
+		m.tokenPath = kubernetesTokenPath
+	}
+
+	return m, nil
+}
+
+func (m *kubernetesMethod) Login(client *api.Client) (*api.Secret, error) {
+	// The token is read on every login since it can be rotated
+	jwt, err := ioutil.ReadFile(m.tokenPath)
+	if err != nil {
+		return nil, fmt.Errorf("error reading the service account token: %s", err)
+	}
+
+	return client.Logical().Write(m.mountPath+"/login", map[string]interface{}{
+		"role": m.role,
+		"jwt":  strings.TrimSpace(string(jwt)),
+	})
+}
@@ -0,0 +1,472 @@
+package cache
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/vault/api"
+	log "github.com/mgutz/logxi/v1"
+)
+
+// tokenHeader is the header the Vault tokens are sent in
+const tokenHeader = "X-Vault-Token"
+
+// wrapTTLHeader is the header requesting a wrapped response. A wrapped
+// request mustn't be answered with the unwrapped response of the cache, so
+// the header is part of the cache key.
+const wrapTTLHeader = "X-Vault-Wrap-TTL"
+
+// ClearPath is the path of the agent endpoint evicting entries of the cache
+const ClearPath = "/agent/v1/cache-clear"
+
+// LeaseCacheConfig is the configuration of a LeaseCache
+type LeaseCacheConfig struct {
+	Logger log.Logger
+
+	// APIConfig is the configuration of the clients renewing the leases
+	// and the tokens
+	APIConfig *api.Config
+
+	// Proxier proxies the requests which aren't answered from the cache
+	Proxier http.Handler
+
+	// TokenFunc returns the token the proxier adds to requests without one
+	TokenFunc func() string
+}
+
+// LeaseCache caches the responses of the requests proxied to the Vault
+// server which returned a lease or a token, so that identical requests get
+// the same credentials instead of new ones. The leases and the tokens are
+// renewed until they can't be anymore, and the entries are evicted when
+// they are revoked through the agent.
+type LeaseCache struct {
+	logger    log.Logger
+	apiConfig *api.Config
+	proxier   http.Handler
+	tokenFunc func() string
+
+	l       sync.Mutex
+	entries map[string]*entry
+
+	shutdownCh chan struct{}
+	once       sync.Once
+}
+
+// entry is a cached response
+type entry struct {
+	key string
+
+	// token is the token of the request
+	token string
+
+	// leaseID is the lease of the response, and clientToken and accessor
+	// the token it created
+	leaseID     string
+	clientToken string
+	accessor    string
+
+	statusCode int
+	header     http.Header
+	body       []byte
+
+	stopCh chan struct{}
+}
+
+// NewLeaseCache returns a LeaseCache for the given configuration
+func NewLeaseCache(conf *LeaseCacheConfig) *LeaseCache {
+	tokenFunc := conf.TokenFunc
+	if tokenFunc == nil {
+		tokenFunc = func() string { return "" }
+	}
+
+	return &LeaseCache{
+		logger:     conf.Logger,
+		apiConfig:  conf.APIConfig,
+		proxier:    conf.Proxier,
+		tokenFunc:  tokenFunc,
+		entries:    make(map[string]*entry),
+		shutdownCh: make(chan struct{}),
+	}
+}
+
+// Shutdown stops the renewals of the cached leases and tokens
+func (c *LeaseCache) Shutdown() {
+	c.once.Do(func() { close(c.shutdownCh) })
+}
+
+func (c *LeaseCache) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == ClearPath {
+		c.handleClear(w, r)
+		return
+	}
+
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	r.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	token := r.Header.Get(tokenHeader)
+	if token == "" {
+		token = c.tokenFunc()
+	}
+	key := cacheKey(r, body, token)
+
+	c.l.Lock()
+	e, ok := c.entries[key]
+	c.l.Unlock()
+	if ok {
+		c.logger.Debug("cache: returning cached response", "path", r.URL.Path)
+		writeEntry(w, e)
+		return
+	}
+
+	rec := newRecorder()
+	c.proxier.ServeHTTP(rec, r)
+	e = &entry{
+		key:        key,
+		token:      token,
+		statusCode: rec.statusCode,
+		header:     rec.header,
+		body:       rec.body.Bytes(),
+		stopCh:     make(chan struct{}),
+	}
+	writeEntry(w, e)
+
+	if rec.statusCode >= 400 {
+		return
+	}
+	c.handleRevocation(r, body, token)
+
+	if !cacheable(r.URL.Path) {
+		return
+	}
+	secret, err := api.ParseSecret(bytes.NewReader(e.body))
+	if err != nil || secret == nil {
+		return
+	}
+	switch {
+	case secret.Auth != nil && secret.Auth.ClientToken != "":
+		e.clientToken = secret.Auth.ClientToken
+		e.accessor = secret.Auth.Accessor
+		c.store(e)
+		go c.renewToken(e, secret.Auth)
+	case secret.LeaseID != "" && secret.LeaseDuration > 0:
+		e.leaseID = secret.LeaseID
+		c.store(e)
+		go c.renewLease(e, secret)
+	}
+}
+
+// store adds the entry to the cache
+func (c *LeaseCache) store(e *entry) {
+	c.logger.Debug("cache: caching response", "lease_id", e.leaseID)
+
+	c.l.Lock()
+	defer c.l.Unlock()
+	if existing, ok := c.entries[e.key]; ok {
+		close(existing.stopCh)
+	}
+	c.entries[e.key] = e
+}
+
+// evict removes the entries matching f from the cache, and returns the
+// client tokens of the evicted entries
+func (c *LeaseCache) evict(f func(*entry) bool) []string {
+	c.l.Lock()
+	defer c.l.Unlock()
+
+	var tokens []string
+	for key, e := range c.entries {
+		if !f(e) {
+			continue
+		}
+		close(e.stopCh)
+		delete(c.entries, key)
+		if e.clientToken != "" {
+			tokens = append(tokens, e.clientToken)
+		}
+	}
+	return tokens
+}
+
+// evictEntry removes the entry from the cache, if it's still cached
+func (c *LeaseCache) evictEntry(e *entry) {
+	c.evict(func(o *entry) bool { return o == e })
+}
+
+// evictToken removes the entries of the token, and of the leases and tokens
+// created with it, which are revoked with the token
+func (c *LeaseCache) evictToken(token string) {
+	tokens := c.evict(func(e *entry) bool {
+		return e.clientToken == token || e.token == token
+	})
+	for _, t := range tokens {
+		if t != token {
+			c.evictToken(t)
+		}
+	}
+}
+
+// handleRevocation evicts the entries revoked by a successful request
+func (c *LeaseCache) handleRevocation(r *http.Request, body []byte, token string) {
+	if r.Method != "PUT" && r.Method != "POST" {
+		return
+	}
+
+	var data map[string]interface{}
+	json.Unmarshal(body, &data)
+	param := func(prefix, name string) string {
+		if v := strings.TrimPrefix(r.URL.Path, prefix); v != r.URL.Path && v != "" {
+			return strings.TrimPrefix(v, "/")
+		}
+		v, _ := data[name].(string)
+		return v
+	}
+
+	switch path := r.URL.Path; {
+	case strings.HasPrefix(path, "/v1/sys/revoke-prefix/"):
+		prefix := strings.TrimPrefix(path, "/v1/sys/revoke-prefix/")
+		c.evictLeases(func(id string) bool { return strings.HasPrefix(id, prefix) })
+	case strings.HasPrefix(path, "/v1/sys/revoke-force/"):
+		prefix := strings.TrimPrefix(path, "/v1/sys/revoke-force/")
+		c.evictLeases(func(id string) bool { return strings.HasPrefix(id, prefix) })
+	case strings.HasPrefix(path, "/v1/sys/revoke/"):
+		id := strings.TrimPrefix(path, "/v1/sys/revoke/")
+		c.evictLeases(func(leaseID string) bool { return leaseID == id })
+	case path == "/v1/auth/token/revoke-self":
+		c.evictToken(token)
+	case strings.HasPrefix(path, "/v1/auth/token/revoke-accessor"):
+		accessor := param("/v1/auth/token/revoke-accessor", "accessor")
+		c.l.Lock()
+		var revoked string
+		for _, e := range c.entries {
+			if accessor != "" && e.accessor == accessor {
+				revoked = e.clientToken
+			}
+		}
+		c.l.Unlock()
+		if revoked != "" {
+			c.evictToken(revoked)
+		}
+	case strings.HasPrefix(path, "/v1/auth/token/revoke-orphan"):
+		// The children of an orphaned token are kept
+		revoked := param("/v1/auth/token/revoke-orphan", "token")
+		c.evict(func(e *entry) bool {
+			return e.clientToken == revoked || (e.token == revoked && e.leaseID != "")
+		})
+	case strings.HasPrefix(path, "/v1/auth/token/revoke"):
+		if revoked := param("/v1/auth/token/revoke", "token"); revoked != "" {
+			c.evictToken(revoked)
+		}
+	}
+}
+
+// evictLeases removes the entries of the leases matching f
+func (c *LeaseCache) evictLeases(f func(string) bool) {
+	c.evict(func(e *entry) bool {
+		return e.leaseID != "" && f(e.leaseID)
+	})
+}
+
+// handleClear evicts the entries given by the request from the cache: all of
+// them, the ones of a lease or a lease prefix, or the ones of a token
+func (c *LeaseCache) handleClear(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "PUT" && r.Method != "POST" {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req struct {
+		Type  string `json:"type"`
+		Value string `json:"value"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, fmt.Sprintf("error parsing the request: %s", err), http.StatusBadRequest)
+		return
+	}
+
+	switch req.Type {
+	case "all":
+		c.evict(func(*entry) bool { return true })
+	case "lease":
+		c.evictLeases(func(id string) bool { return id == req.Value })
+	case "prefix":
+		c.evictLeases(func(id string) bool { return strings.HasPrefix(id, req.Value) })
+	case "token":
+		c.evictToken(req.Value)
+	default:
+		http.Error(w, fmt.Sprintf("invalid type %q", req.Type), http.StatusBadRequest)
+		return
+	}
+
+	c.logger.Info("cache: cleared cache", "type", req.Type)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// renewLease renews the lease of the entry until it can't be renewed
+// anymore, and evicts the entry once it expires
+func (c *LeaseCache) renewLease(e *entry, secret *api.Secret) {
+	client, err := c.client(e.token)
+	if err != nil {
+		c.logger.Error("cache: error creating client", "error", err)
+		c.evictEntry(e)
+		return
+	}
+
+	c.renew(e, secret.LeaseDuration, secret.Renewable, func() (int, bool, error) {
+		secret, err := client.Sys().Renew(e.leaseID, 0)
+		if err != nil {
+			return 0, false, err
+		}
+		if secret == nil {
+			return 0, false, fmt.Errorf("empty response")
+		}
+		return secret.LeaseDuration, secret.Renewable, nil
+	})
+}
+
+// renewToken renews the token of the entry until it can't be renewed
+// anymore, and evicts the entry once it expires
+func (c *LeaseCache) renewToken(e *entry, auth *api.SecretAuth) {
+	if auth.LeaseDuration <= 0 {
+		// Tokens without a TTL are only evicted when revoked
+		return
+	}
+
+	client, err := c.client(e.clientToken)
+	if err != nil {
+		c.logger.Error("cache: error creating client", "error", err)
+		c.evictEntry(e)
+		return
+	}
+
+	c.renew(e, auth.LeaseDuration, auth.Renewable, func() (int, bool, error) {
+		secret, err := client.Auth().Token().RenewSelf(0)
+		if err != nil {
+			return 0, false, err
+		}
+		if secret == nil || secret.Auth == nil {
+			return 0, false, fmt.Errorf("empty response")
+		}
+		return secret.Auth.LeaseDuration, secret.Auth.Renewable, nil
+	})
+}
+
+// renew renews with f at two thirds of the TTL, and evicts the entry when
+// the renewal fails or once the TTL can't be extended anymore
+func (c *LeaseCache) renew(e *entry, ttl int, renewable bool, f func() (int, bool, error)) {
+	defer c.evictEntry(e)
+
+	for {
+		wait := time.Duration(ttl) * time.Second
+		if renewable {
+			wait = wait * 2 / 3
+		}
+
+		select {
+		case <-c.shutdownCh:
+			return
+		case <-e.stopCh:
+			return
+		case <-time.After(wait):
+		}
+
+		if !renewable {
+			c.logger.Debug("cache: lease expired, evicting", "lease_id", e.leaseID)
+			return
+		}
+
+		newTTL, newRenewable, err := f()
+		if err != nil {
+			c.logger.Warn("cache: error renewing, evicting", "lease_id", e.leaseID, "error", err)
+			return
+		}
+
+		// A TTL which didn't increase means that the maximum TTL is
+		// reached, so the entry is kept until it expires
+		if newTTL < ttl*2/3 {
+			renewable = false
+		}
+		ttl = newTTL
+		renewable = renewable && newRenewable
+	}
+}
+
+// client returns a client with the given token
+func (c *LeaseCache) client(token string) (*api.Client, error) {
+	client, err := api.NewClient(c.apiConfig)
+	if err != nil {
+		return nil, err
+	}
+	client.SetToken(token)
+	return client, nil
+}
+
+// cacheable returns whether the response of a request to the path can be
+// cached if it has a lease or a token. The responses of renewals have the
+// lease they renewed, and must reach the server every time.
+func cacheable(path string) bool {
+	for _, prefix := range []string{
+		"/v1/sys/renew",
+		"/v1/sys/leases/",
+		"/v1/auth/token/renew",
+	} {
+		if strings.HasPrefix(path, prefix) {
+			return false
+		}
+	}
+	return true
+}
+
+// cacheKey returns the key of the request in the cache
+func cacheKey(r *http.Request, body []byte, token string) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\n%s\n%s\n%s\n", r.Method, r.URL.RequestURI(), token, r.Header.Get(wrapTTLHeader))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// writeEntry writes the response of the entry
+func writeEntry(w http.ResponseWriter, e *entry) {
+	for k, v := range e.header {
+		w.Header()[k] = v
+	}
+	w.WriteHeader(e.statusCode)
+	w.Write(e.body)
+}
+
+// recorder records the response of the proxier
+type recorder struct {
+	statusCode int
+	header     http.Header
+	body       bytes.Buffer
+}
+
+func newRecorder() *recorder {
+	return &recorder{
+		statusCode: http.StatusOK,
+		header:     make(http.Header),
+	}
+}
+
+func (r *recorder) Header() http.Header {
+	return r.header
+}
+
+func (r *recorder) Write(b []byte) (int, error) {
+	return r.body.Write(b)
+}
+
+func (r *recorder) WriteHeader(statusCode int) {
+	r.statusCode = statusCode
+}
@@ -0,0 +1,210 @@
+package cache
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/api"
+	"github.com/hashicorp/vault/command/agent/proxy"
+	"github.com/hashicorp/vault/helper/logformat"
+	log "github.com/mgutz/logxi/v1"
+)
+
+// testVault is a Vault server counting the requests to its paths
+type testVault struct {
+	sync.Mutex
+	requests map[string]int
+	leases   int
+}
+
+func (v *testVault) count(path string) int {
+	v.Lock()
+	defer v.Unlock()
+	return v.requests[path]
+}
+
+func (v *testVault) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	v.Lock()
+	defer v.Unlock()
+	v.requests[r.URL.Path]++
+
+	switch r.URL.Path {
+	case "/v1/database/creds/app":
+		v.leases++
+		fmt.Fprintf(w, `{"lease_id":"database/creds/app/%d","lease_duration":%d,"renewable":true,"data":{"username":"user-%d"}}`,
+			v.leases, 1, v.leases)
+	case "/v1/sys/renew":
+		fmt.Fprint(w, `{"lease_id":"database/creds/app/1","lease_duration":1,"renewable":true}`)
+	case "/v1/auth/token/create":
+		v.leases++
+		fmt.Fprintf(w, `{"auth":{"client_token":"child-%d","accessor":"accessor-%d","lease_duration":0}}`, v.leases, v.leases)
+	case "/v1/secret/foo":
+		fmt.Fprint(w, `{"data":{"bar":"baz"}}`)
+	default:
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func testLeaseCache(t *testing.T) (*testVault, *LeaseCache, *httptest.Server) {
+	vault := &testVault{requests: make(map[string]int)}
+	backend := httptest.NewServer(vault)
+
+	logger := logformat.NewVaultLogger(log.LevelTrace)
+	p, err := proxy.NewProxy(logger, backend.URL, http.DefaultTransport)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	p.SetToken("agent")
+
+	apiConfig := api.DefaultConfig()
+	apiConfig.Address = backend.URL
+	c := NewLeaseCache(&LeaseCacheConfig{
+		Logger:    logger,
+		APIConfig: apiConfig,
+		Proxier:   p,
+		TokenFunc: p.Token,
+	})
+	ts := httptest.NewServer(c)
+
+	return vault, c, ts
+}
+
+func testRequest(t *testing.T, method, url, token, body string) string {
+	req, err := http.NewRequest(method, url, bytes.NewBufferString(body))
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if token != "" {
+		req.Header.Set("X-Vault-Token", token)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		t.Fatalf("bad: %d", resp.StatusCode)
+	}
+	d, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	return string(d)
+}
+
+func TestLeaseCache_lease(t *testing.T) {
+	vault, c, ts := testLeaseCache(t)
+	defer ts.Close()
+	defer c.Shutdown()
+
+	url := ts.URL + "/v1/database/creds/app"
+	first := testRequest(t, "GET", url, "", "")
+	if second := testRequest(t, "GET", url, "", ""); second != first {
+		t.Fatalf("bad: %s", second)
+	}
+	if count := vault.count("/v1/database/creds/app"); count != 1 {
+		t.Fatalf("bad: %d", count)
+	}
+
+	// Another token gets other credentials
+	if other := testRequest(t, "GET", url, "other", ""); other == first {
+		t.Fatalf("bad: %s", other)
+	}
+
+	// Responses without a lease aren't cached
+	testRequest(t, "GET", ts.URL+"/v1/secret/foo", "", "")
+	testRequest(t, "GET", ts.URL+"/v1/secret/foo", "", "")
+	if count := vault.count("/v1/secret/foo"); count != 2 {
+		t.Fatalf("bad: %d", count)
+	}
+
+	// The lease is renewed
+	time.Sleep(1500 * time.Millisecond)
+	if count := vault.count("/v1/sys/renew"); count == 0 {
+		t.Fatal("lease not renewed")
+	}
+
+	// Revoking the lease evicts it
+	testRequest(t, "PUT", ts.URL+"/v1/sys/revoke/database/creds/app/1", "", "")
+	if third := testRequest(t, "GET", url, "", ""); third == first {
+		t.Fatalf("bad: %s", third)
+	}
+
+	// Clearing the cache evicts everything
+	testRequest(t, "POST", ts.URL+ClearPath, "", `{"type":"all"}`)
+	c.l.Lock()
+	entries := len(c.entries)
+	c.l.Unlock()
+	if entries != 0 {
+		t.Fatalf("bad: %d", entries)
+	}
+}
+
+func TestLeaseCache_wrapped(t *testing.T) {
+	vault, c, ts := testLeaseCache(t)
+	defer ts.Close()
+	defer c.Shutdown()
+
+	url := ts.URL + "/v1/database/creds/app"
+	testRequest(t, "GET", url, "", "")
+
+	// A wrapped request isn't answered with the cached unwrapped response
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	req.Header.Set("X-Vault-Wrap-TTL", "5m")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	resp.Body.Close()
+	if count := vault.count("/v1/database/creds/app"); count != 2 {
+		t.Fatalf("bad: %d", count)
+	}
+}
+
+func TestLeaseCache_token(t *testing.T) {
+	vault, c, ts := testLeaseCache(t)
+	defer ts.Close()
+	defer c.Shutdown()
+
+	url := ts.URL + "/v1/auth/token/create"
+	first := testRequest(t, "POST", url, "", `{"policies":["app"]}`)
+	if second := testRequest(t, "POST", url, "", `{"policies":["app"]}`); second != first {
+		t.Fatalf("bad: %s", second)
+	}
+	if count := vault.count("/v1/auth/token/create"); count != 1 {
+		t.Fatalf("bad: %d", count)
+	}
+
+	// Another body gets another token
+	if other := testRequest(t, "POST", url, "", `{"policies":["other"]}`); other == first {
+		t.Fatalf("bad: %s", other)
+	}
+
+	// The child of the token is evicted with it
+	testRequest(t, "GET", ts.URL+"/v1/database/creds/app", "child-1", "")
+	testRequest(t, "POST", ts.URL+"/v1/auth/token/revoke-self", "child-1", "")
+	c.l.Lock()
+	entries := len(c.entries)
+	c.l.Unlock()
+	if entries != 1 {
+		t.Fatalf("bad: %d", entries)
+	}
+
+	// The token is evicted by its accessor
+	testRequest(t, "POST", ts.URL+"/v1/auth/token/revoke-accessor", "", `{"accessor":"accessor-2"}`)
+	c.l.Lock()
+	entries = len(c.entries)
+	c.l.Unlock()
+	if entries != 0 {
+		t.Fatalf("bad: %d", entries)
+	}
+}
@@ -0,0 +1,346 @@
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/hashicorp/hcl"
+	"github.com/hashicorp/hcl/hcl/ast"
+)
+
+// Config is the configuration of the Vault agent
+type Config struct {
+	AutoAuth  *AutoAuth
+	Cache     *Cache
+	Vault     *Vault
+	Templates []*Template
+	Listeners []*Listener
+	PidFile   string `hcl:"pid_file"`
+}
+
+// Vault is the configuration of the client of the agent to the Vault server
+type Vault struct {
+	Address       string `hcl:"address"`
+	CACert        string `hcl:"ca_cert"`
+	CAPath        string `hcl:"ca_path"`
+	ClientCert    string `hcl:"client_cert"`
+	ClientKey     string `hcl:"client_key"`
+	TLSServerName string `hcl:"tls_server_name"`
+	TLSSkipVerify bool   `hcl:"tls_skip_verify"`
+}
+
+// AutoAuth is the configuration of the authentication of the agent and of
+// the sinks its tokens are written to
+type AutoAuth struct {
+	Method *Method
+	Sinks  []*Sink
+}
+
+// Method is the configuration of the auth method of the agent
+type Method struct {
+	Type      string
+	MountPath string
+	Config    map[string]interface{}
+}
+
+// Sink is the configuration of a sink the tokens of the agent are written to
+type Sink struct {
+	Type   string
+	Config map[string]interface{}
+}
+
+// Cache is the configuration of the cache of the listeners, which is enabled
+// by the cache block
+type Cache struct{}
+
+// Template is the configuration of a template rendered with the secrets read
+// with the token of the agent
+type Template struct {
+	Source      string      `hcl:"source"`
+	Contents    string      `hcl:"contents"`
+	Destination string      `hcl:"destination"`
+	PermsRaw    string      `hcl:"perms"`
+	Perms       os.FileMode `hcl:"-"`
+}
+
+// Listener is the configuration of a listener proxying requests to the Vault
+// server, with the same options as the listeners of the server
+type Listener struct {
+	Type   string
+	Config map[string]string
+}
+
+// LoadConfig loads the configuration of the agent from the given file
+func LoadConfig(path string) (*Config, error) {
+	d, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseConfig(string(d))
+}
+
+// ParseConfig parses the configuration of the agent
+func ParseConfig(d string) (*Config, error) {
+	obj, err := hcl.Parse(d)
+	if err != nil {
+		return nil, err
+	}
+
+	list, ok := obj.Node.(*ast.ObjectList)
+	if !ok {
+		return nil, fmt.Errorf("error parsing: file doesn't contain a root object")
+	}
+
+	valid := []string{
+		"auto_auth",
+		"cache",
+		"listener",
+		"pid_file",
+		"template",
+		"vault",
+	}
+	if err := checkHCLKeys(list, valid); err != nil {
+		return nil, err
+	}
+
+	var result Config
+	if o := list.Filter("pid_file"); len(o.Items) > 0 {
+		if err := hcl.DecodeObject(&result.PidFile, o.Items[0].Val); err != nil {
+			return nil, fmt.Errorf("error parsing 'pid_file': %s", err)
+		}
+	}
+
+	if o := list.Filter("vault"); len(o.Items) > 0 {
+		if err := parseVault(&result, o); err != nil {
+			return nil, fmt.Errorf("error parsing 'vault': %s", err)
+		}
+	}
+
+	if o := list.Filter("auto_auth"); len(o.Items) > 0 {
+		if err := parseAutoAuth(&result, o); err != nil {
+			return nil, fmt.Errorf("error parsing 'auto_auth': %s", err)
+		}
+	}
+
+	if o := list.Filter("cache"); len(o.Items) > 0 {
+		if err := parseCache(&result, o); err != nil {
+			return nil, fmt.Errorf("error parsing 'cache': %s", err)
+		}
+	}
+
+	if o := list.Filter("template"); len(o.Items) > 0 {
+		if err := parseTemplates(&result, o); err != nil {
+			return nil, fmt.Errorf("error parsing 'template': %s", err)
+		}
+	}
+
+	if o := list.Filter("listener"); len(o.Items) > 0 {
+		if err := parseListeners(&result, o); err != nil {
+			return nil, fmt.Errorf("error parsing 'listener': %s", err)
+		}
+	}
+
+	return &result, nil
+}
+
+func parseVault(result *Config, list *ast.ObjectList) error {
+	if len(list.Items) > 1 {
+		return fmt.Errorf("only one 'vault' block is permitted")
+	}
+	item := list.Items[0]
+
+	valid := []string{
+		"address",
+		"ca_cert",
+		"ca_path",
+		"client_cert",
+		"client_key",
+		"tls_server_name",
+		"tls_skip_verify",
+	}
+	if err := checkHCLKeys(item.Val, valid); err != nil {
+		return multierror.Prefix(err, "vault:")
+	}
+
+	var v Vault
+	if err := hcl.DecodeObject(&v, item.Val); err != nil {
+		return multierror.Prefix(err, "vault:")
+	}
+	result.Vault = &v
+	return nil
+}
+
+func parseAutoAuth(result *Config, list *ast.ObjectList) error {
+	if len(list.Items) > 1 {
+		return fmt.Errorf("only one 'auto_auth' block is permitted")
+	}
+
+	var body *ast.ObjectList
+	if ot, ok := list.Items[0].Val.(*ast.ObjectType); ok {
+		body = ot.List
+	} else {
+		return fmt.Errorf("auto_auth must be an object")
+	}
+
+	if err := checkHCLKeys(body, []string{"method", "sink"}); err != nil {
+		return multierror.Prefix(err, "auto_auth:")
+	}
+
+	var autoAuth AutoAuth
+
+	methods := body.Filter("method")
+	if len(methods.Items) != 1 {
+		return fmt.Errorf("exactly one 'method' block must be specified")
+	}
+	item := methods.Items[0]
+	if len(item.Keys) == 0 {
+		return fmt.Errorf("method type must be specified")
+	}
+	methodType := strings.ToLower(item.Keys[0].Token.Value().(string))
+
+	if err := checkHCLKeys(item.Val, []string{"config", "mount_path"}); err != nil {
+		return multierror.Prefix(err, fmt.Sprintf("method.%s:", methodType))
+	}
+	var method struct {
+		MountPath string                 `hcl:"mount_path"`
+		Config    map[string]interface{} `hcl:"config"`
+	}
+	if err := hcl.DecodeObject(&method, item.Val); err != nil {
+		return multierror.Prefix(err, fmt.Sprintf("method.%s:", methodType))
+	}
+	if method.MountPath == "" {
+		method.MountPath = "auth/" + methodType
+	}
+	autoAuth.Method = &Method{
+		Type:      methodType,
+		MountPath: strings.TrimSuffix(method.MountPath, "/"),
+		Config:    method.Config,
+	}
+
+	for _, item := range body.Filter("sink").Items {
+		if len(item.Keys) == 0 {
+			return fmt.Errorf("sink type must be specified")
+		}
+		sinkType := strings.ToLower(item.Keys[0].Token.Value().(string))
+
+		if err := checkHCLKeys(item.Val, []string{"config"}); err != nil {
+			return multierror.Prefix(err, fmt.Sprintf("sink.%s:", sinkType))
+		}
+		var sink struct {
+			Config map[string]interface{} `hcl:"config"`
+		}
+		if err := hcl.DecodeObject(&sink, item.Val); err != nil {
+			return multierror.Prefix(err, fmt.Sprintf("sink.%s:", sinkType))
+		}
+
+		autoAuth.Sinks = append(autoAuth.Sinks, &Sink{
+			Type:   sinkType,
+			Config: sink.Config,
+		})
+	}
+
+	result.AutoAuth = &autoAuth
+	return nil
+}
+
+func parseCache(result *Config, list *ast.ObjectList) error {
+	if len(list.Items) > 1 {
+		return fmt.Errorf("only one 'cache' block is permitted")
+	}
+	if err := checkHCLKeys(list.Items[0].Val, nil); err != nil {
+		return multierror.Prefix(err, "cache:")
+	}
+
+	result.Cache = &Cache{}
+	return nil
+}
+
+func parseTemplates(result *Config, list *ast.ObjectList) error {
+	for i, item := range list.Items {
+		valid := []string{
+			"contents",
+			"destination",
+			"perms",
+			"source",
+		}
+		if err := checkHCLKeys(item.Val, valid); err != nil {
+			return multierror.Prefix(err, fmt.Sprintf("template.%d:", i))
+		}
+
+		var t Template
+		if err := hcl.DecodeObject(&t, item.Val); err != nil {
+			return multierror.Prefix(err, fmt.Sprintf("template.%d:", i))
+		}
+
+		if t.Destination == "" {
+			return fmt.Errorf("template.%d: 'destination' must be specified", i)
+		}
+		if (t.Source == "") == (t.Contents == "") {
+			return fmt.Errorf("template.%d: exactly one of 'source' and 'contents' must be specified", i)
+		}
+
+		t.Perms = 0640
+		if t.PermsRaw != "" {
+			perms, err := strconv.ParseUint(t.PermsRaw, 8, 32)
+			if err != nil {
+				return fmt.Errorf("template.%d: invalid 'perms' %q", i, t.PermsRaw)
+			}
+			t.Perms = os.FileMode(perms)
+		}
+
+		result.Templates = append(result.Templates, &t)
+	}
+	return nil
+}
+
+func parseListeners(result *Config, list *ast.ObjectList) error {
+	for _, item := range list.Items {
+		if len(item.Keys) == 0 {
+			return fmt.Errorf("listener type must be specified")
+		}
+		key := strings.ToLower(item.Keys[0].Token.Value().(string))
+
+		var m map[string]string
+		if err := hcl.DecodeObject(&m, item.Val); err != nil {
+			return multierror.Prefix(err, fmt.Sprintf("listeners.%s:", key))
+		}
+
+		result.Listeners = append(result.Listeners, &Listener{
+			Type:   key,
+			Config: m,
+		})
+	}
+	return nil
+}
+
+func checkHCLKeys(node ast.Node, valid []string) error {
+	var list *ast.ObjectList
+	switch n := node.(type) {
+	case *ast.ObjectList:
+		list = n
+	case *ast.ObjectType:
+		list = n.List
+	default:
+		return fmt.Errorf("cannot check HCL keys of type %T", n)
+	}
+
+	validMap := make(map[string]struct{}, len(valid))
+	for _, v := range valid {
+		validMap[v] = struct{}{}
+	}
+
+	var result error
+	for _, item := range list.Items {
+		key := item.Keys[0].Token.Value().(string)
+		if _, ok := validMap[key]; !ok {
+			result = multierror.Append(result, fmt.Errorf(
+				"invalid key '%s' on line %d", key, item.Assign.Line))
+		}
+	}
+
+	return result
+}
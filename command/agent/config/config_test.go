@@ -0,0 +1,123 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLoadConfig(t *testing.T) {
+	config, err := LoadConfig("./test-fixtures/config.hcl")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	expected := &Config{
+		PidFile: "./pidfile",
+		Vault: &Vault{
+			Address: "https://127.0.0.1:8200",
+			CACert:  "/path/to/ca.pem",
+		},
+		AutoAuth: &AutoAuth{
+			Method: &Method{
+				Type:      "approle",
+				MountPath: "auth/approle-dev",
+				Config: map[string]interface{}{
+					"role_id_file_path":   "/etc/vault/role-id",
+					"secret_id_file_path": "/etc/vault/secret-id",
+				},
+			},
+			Sinks: []*Sink{
+				&Sink{
+					Type: "file",
+					Config: map[string]interface{}{
+						"path": "/tmp/vault-token",
+					},
+				},
+			},
+		},
+		Cache: &Cache{},
+		Templates: []*Template{
+			&Template{
+				Source:      "/etc/vault/app.tpl",
+				Destination: "/etc/app/config",
+				PermsRaw:    "0600",
+				Perms:       0600,
+			},
+		},
+		Listeners: []*Listener{
+			&Listener{
+				Type: "tcp",
+				Config: map[string]string{
+					"address":     "127.0.0.1:8100",
+					"tls_disable": "true",
+				},
+			},
+		},
+	}
+	if !reflect.DeepEqual(config, expected) {
+		t.Fatalf("expected \n\n%#v\n\n to be \n\n%#v\n\n", config, expected)
+	}
+}
+
+func TestParseConfig_defaults(t *testing.T) {
+	config, err := ParseConfig(`
+auto_auth {
+	method "kubernetes" {
+		config = {
+			role = "app"
+		}
+	}
+}
+
+template {
+	contents = "{{ with secret \"secret/foo\" }}{{ .Data.bar }}{{ end }}"
+	destination = "/tmp/out"
+}
+`)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if mountPath := config.AutoAuth.Method.MountPath; mountPath != "auth/kubernetes" {
+		t.Fatalf("bad: %s", mountPath)
+	}
+	if perms := config.Templates[0].Perms; perms != 0640 {
+		t.Fatalf("bad: %o", perms)
+	}
+}
+
+func TestParseConfig_bad(t *testing.T) {
+	cases := map[string]string{
+		`nope = "yes"`: "invalid key 'nope'",
+		`auto_auth {
+	sink "file" {}
+}`: "exactly one 'method'",
+		`auto_auth {
+	method "approle" {
+		bad = "one"
+	}
+}`: "invalid key 'bad'",
+		`template {
+	destination = "/tmp/out"
+}`: "exactly one of 'source' and 'contents'",
+		`template {
+	source = "/tmp/in"
+}`: "'destination' must be specified",
+		`template {
+	source = "/tmp/in"
+	destination = "/tmp/out"
+	perms = "rw"
+}`: "invalid 'perms'",
+		`cache {
+	ttl = "5m"
+}`: "invalid key 'ttl'",
+	}
+
+	for config, expected := range cases {
+		_, err := ParseConfig(config)
+		if err == nil || !strings.Contains(err.Error(), expected) {
+			t.Fatalf("%s: bad error: %v", config, err)
+		}
+	}
+}
@@ -0,0 +1,35 @@
+pid_file = "./pidfile"
+
+vault {
+	address = "https://127.0.0.1:8200"
+	ca_cert = "/path/to/ca.pem"
+}
+
+auto_auth {
+	method "approle" {
+		mount_path = "auth/approle-dev"
+		config = {
+			role_id_file_path = "/etc/vault/role-id"
+			secret_id_file_path = "/etc/vault/secret-id"
+		}
+	}
+
+	sink "file" {
+		config = {
+			path = "/tmp/vault-token"
+		}
+	}
+}
+
+cache {}
+
+template {
+	source = "/etc/vault/app.tpl"
+	destination = "/etc/app/config"
+	perms = "0600"
+}
+
+listener "tcp" {
+	address = "127.0.0.1:8100"
+	tls_disable = "true"
+}
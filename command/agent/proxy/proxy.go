@@ -0,0 +1,72 @@
+package proxy
+
+import (
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"sync"
+
+	log "github.com/mgutz/logxi/v1"
+)
+
+// tokenHeader is the header the Vault tokens are sent in
+const tokenHeader = "X-Vault-Token"
+
+// Proxy proxies requests to the Vault server, adding the token of the agent
+// to the requests which don't have one, so that applications don't need to
+// authenticate themselves
+type Proxy struct {
+	logger log.Logger
+	proxy  *httputil.ReverseProxy
+
+	l     sync.RWMutex
+	token string
+}
+
+// NewProxy returns a Proxy to the Vault server at the given address, using
+// the given transport to connect to it
+func NewProxy(logger log.Logger, address string, transport http.RoundTripper) (*Proxy, error) {
+	target, err := url.Parse(address)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &Proxy{
+		logger: logger,
+	}
+	p.proxy = httputil.NewSingleHostReverseProxy(target)
+	p.proxy.Transport = transport
+
+	director := p.proxy.Director
+	p.proxy.Director = func(r *http.Request) {
+		director(r)
+		// The Host header is the one of the server, which matters with TLS
+		r.Host = target.Host
+		if r.Header.Get(tokenHeader) == "" {
+			if token := p.Token(); token != "" {
+				r.Header.Set(tokenHeader, token)
+			}
+		}
+	}
+
+	return p, nil
+}
+
+// SetToken sets the token added to the requests
+func (p *Proxy) SetToken(token string) {
+	p.l.Lock()
+	defer p.l.Unlock()
+	p.token = token
+}
+
+// Token returns the token added to the requests
+func (p *Proxy) Token() string {
+	p.l.RLock()
+	defer p.l.RUnlock()
+	return p.token
+}
+
+func (p *Proxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	p.logger.Trace("proxy: proxying request", "method", r.Method, "path", r.URL.Path)
+	p.proxy.ServeHTTP(w, r)
+}
@@ -0,0 +1,60 @@
+package proxy
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hashicorp/vault/helper/logformat"
+	log "github.com/mgutz/logxi/v1"
+)
+
+func TestProxy(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.URL.Path + " " + r.Header.Get("X-Vault-Token")))
+	}))
+	defer backend.Close()
+
+	p, err := NewProxy(logformat.NewVaultLogger(log.LevelTrace), backend.URL, http.DefaultTransport)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	ts := httptest.NewServer(p)
+	defer ts.Close()
+
+	get := func(token string) string {
+		req, err := http.NewRequest("GET", ts.URL+"/v1/secret/foo", nil)
+		if err != nil {
+			t.Fatalf("err: %s", err)
+		}
+		if token != "" {
+			req.Header.Set("X-Vault-Token", token)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("err: %s", err)
+		}
+		defer resp.Body.Close()
+		body, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatalf("err: %s", err)
+		}
+		return string(body)
+	}
+
+	if actual := get(""); actual != "/v1/secret/foo " {
+		t.Fatalf("bad: %q", actual)
+	}
+
+	// The token of the agent is added
+	p.SetToken("agent")
+	if actual := get(""); actual != "/v1/secret/foo agent" {
+		t.Fatalf("bad: %q", actual)
+	}
+
+	// The token of the request is kept
+	if actual := get("app"); actual != "/v1/secret/foo app" {
+		t.Fatalf("bad: %q", actual)
+	}
+}
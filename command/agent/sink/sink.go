@@ -0,0 +1,114 @@
+package sink
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	log "github.com/mgutz/logxi/v1"
+)
+
+// Sink is implemented by the sinks the tokens of the agent are written to
+type Sink interface {
+	// WriteToken writes the token to the sink
+	WriteToken(token string) error
+}
+
+// NewSink creates the sink of the given type
+func NewSink(t string, config map[string]interface{}) (Sink, error) {
+	switch t {
+	case "file":
+		return NewFileSink(config)
+	default:
+		return nil, fmt.Errorf("unknown sink type %s", t)
+	}
+}
+
+// FileSink writes the tokens to a file
+type FileSink struct {
+	path string
+	mode os.FileMode
+}
+
+// NewFileSink returns a FileSink. The file is written with the mode 0640
+// unless mode is set.
+func NewFileSink(config map[string]interface{}) (*FileSink, error) {
+	path, ok := config["path"].(string)
+	if !ok || path == "" {
+		return nil, fmt.Errorf("'path' must be specified for the file sink")
+	}
+
+	s := &FileSink{
+		path: path,
+		mode: 0640,
+	}
+	if raw, ok := config["mode"]; ok {
+		var mode uint64
+		var err error
+		switch v := raw.(type) {
+		case string:
+			mode, err = strconv.ParseUint(v, 8, 32)
+		case int:
+			mode = uint64(v)
+		default:
+			err = fmt.Errorf("unexpected type %T", raw)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid 'mode' for the file sink: %s", err)
+		}
+		s.mode = os.FileMode(mode)
+	}
+
+	return s, nil
+}
+
+// WriteToken writes the token to a temporary file which then replaces the
+// file, so that readers never see a partially written token
+func (s *FileSink) WriteToken(token string) error {
+	f, err := ioutil.TempFile(filepath.Dir(s.path), ".vault-agent-token")
+	if err != nil {
+		return fmt.Errorf("error creating the temporary file: %s", err)
+	}
+	tmp := f.Name()
+
+	_, err = f.WriteString(token)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(tmp, s.mode)
+	}
+	if err == nil {
+		err = os.Rename(tmp, s.path)
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("error writing the token to %s: %s", s.path, err)
+	}
+	return nil
+}
+
+// Server writes each token it receives to all of its sinks
+type Server struct {
+	logger log.Logger
+	sinks  []Sink
+}
+
+// NewServer returns a Server writing to the given sinks
+func NewServer(logger log.Logger, sinks []Sink) *Server {
+	return &Server{
+		logger: logger,
+		sinks:  sinks,
+	}
+}
+
+// WriteToken writes the token to all of the sinks, logging the errors
+func (s *Server) WriteToken(token string) {
+	for _, sink := range s.sinks {
+		if err := sink.WriteToken(token); err != nil {
+			s.logger.Error("sink: error writing token", "error", err)
+		}
+	}
+}
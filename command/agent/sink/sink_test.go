@@ -0,0 +1,67 @@
+package sink
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFileSink(t *testing.T) {
+	dir, err := ioutil.TempDir("", "vault-agent-sink")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "token")
+
+	s, err := NewSink("file", map[string]interface{}{
+		"path": path,
+		"mode": "600",
+	})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	for _, token := range []string{"foo", "bar"} {
+		if err := s.WriteToken(token); err != nil {
+			t.Fatalf("err: %s", err)
+		}
+		actual, err := ioutil.ReadFile(path)
+		if err != nil {
+			t.Fatalf("err: %s", err)
+		}
+		if string(actual) != token {
+			t.Fatalf("bad: %s", actual)
+		}
+	}
+
+	fi, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if fi.Mode().Perm() != 0600 {
+		t.Fatalf("bad: %s", fi.Mode())
+	}
+
+	// The temporary files are removed
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if len(files) != 1 {
+		t.Fatalf("bad: %d", len(files))
+	}
+}
+
+func TestNewSink_bad(t *testing.T) {
+	if _, err := NewSink("file", map[string]interface{}{}); err == nil {
+		t.Fatal("expected error")
+	}
+	if _, err := NewSink("file", map[string]interface{}{"path": "foo", "mode": "999"}); err == nil {
+		t.Fatal("expected error")
+	}
+	if _, err := NewSink("unknown", nil); err == nil {
+		t.Fatal("expected error")
+	}
+}
@@ -0,0 +1,149 @@
+package template
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"text/template"
+	"time"
+
+	"github.com/hashicorp/vault/api"
+	"github.com/hashicorp/vault/command/agent/config"
+	log "github.com/mgutz/logxi/v1"
+)
+
+// DefaultRenderInterval is the interval the templates are rendered at when
+// none of the secrets they read has a lease
+const DefaultRenderInterval = 5 * time.Minute
+
+// Renderer renders templates with the secrets read with the token of the
+// agent. The secrets are read with the function secret, for example:
+//
+//	{{ with secret "secret/foo" }}{{ .Data.password }}{{ end }}
+type Renderer struct {
+	logger    log.Logger
+	client    *api.Client
+	templates []*config.Template
+}
+
+// NewRenderer returns a Renderer for the given templates. The client must not
+// be used by anything else, since its token is replaced by the tokens of the
+// agent.
+func NewRenderer(logger log.Logger, client *api.Client, templates []*config.Template) *Renderer {
+	return &Renderer{
+		logger:    logger,
+		client:    client,
+		templates: templates,
+	}
+}
+
+// Run renders the templates whenever a token is received on tokenCh, and
+// again before the leases of the secrets they read expire, until shutdownCh
+// is closed
+func (r *Renderer) Run(tokenCh <-chan string, shutdownCh <-chan struct{}) {
+	var renderCh <-chan time.Time
+	for {
+		select {
+		case <-shutdownCh:
+			return
+		case token := <-tokenCh:
+			r.client.SetToken(token)
+		case <-renderCh:
+		}
+
+		interval, err := r.Render()
+		if err != nil {
+			r.logger.Error("template: error rendering templates", "error", err)
+		}
+		renderCh = time.After(interval)
+	}
+}
+
+// Render renders all of the templates, and returns the interval they should
+// be rendered again at
+func (r *Renderer) Render() (time.Duration, error) {
+	interval := DefaultRenderInterval
+	for _, t := range r.templates {
+		leaseDuration, err := r.render(t)
+		if err != nil {
+			return interval, fmt.Errorf("error rendering %s: %s", t.Destination, err)
+		}
+		if leaseDuration > 0 {
+			if i := leaseDuration * 2 / 3; i < interval {
+				interval = i
+			}
+		}
+	}
+	return interval, nil
+}
+
+// render renders the template, and returns the shortest lease duration of
+// the secrets it read
+func (r *Renderer) render(t *config.Template) (time.Duration, error) {
+	contents := t.Contents
+	if t.Source != "" {
+		d, err := ioutil.ReadFile(t.Source)
+		if err != nil {
+			return 0, err
+		}
+		contents = string(d)
+	}
+
+	var leaseDuration time.Duration
+	funcs := template.FuncMap{
+		"secret": func(path string) (*api.Secret, error) {
+			secret, err := r.client.Logical().Read(path)
+			if err != nil {
+				return nil, err
+			}
+			if secret == nil {
+				return nil, fmt.Errorf("no secret found at %s", path)
+			}
+			d := time.Duration(secret.LeaseDuration) * time.Second
+			if d > 0 && (leaseDuration == 0 || d < leaseDuration) {
+				leaseDuration = d
+			}
+			return secret, nil
+		},
+	}
+
+	tmpl, err := template.New(t.Destination).Funcs(funcs).Option("missingkey=error").Parse(contents)
+	if err != nil {
+		return 0, err
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, nil); err != nil {
+		return 0, err
+	}
+
+	// The destination is only written when it changed, so that the
+	// applications watching it aren't reloaded for nothing
+	if existing, err := ioutil.ReadFile(t.Destination); err == nil && bytes.Equal(existing, buf.Bytes()) {
+		return leaseDuration, os.Chmod(t.Destination, t.Perms)
+	}
+
+	f, err := ioutil.TempFile(filepath.Dir(t.Destination), ".vault-agent-template")
+	if err != nil {
+		return 0, err
+	}
+	tmp := f.Name()
+	_, err = f.Write(buf.Bytes())
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(tmp, t.Perms)
+	}
+	if err == nil {
+		err = os.Rename(tmp, t.Destination)
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return 0, err
+	}
+
+	r.logger.Info("template: rendered template", "destination", t.Destination)
+	return leaseDuration, nil
+}
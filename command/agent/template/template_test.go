@@ -0,0 +1,85 @@
+package template
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/api"
+	"github.com/hashicorp/vault/command/agent/config"
+	"github.com/hashicorp/vault/helper/logformat"
+	log "github.com/mgutz/logxi/v1"
+)
+
+func TestRenderer(t *testing.T) {
+	password := "foo"
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/secret/app" || r.Header.Get("X-Vault-Token") != "agent" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		fmt.Fprintf(w, `{"lease_duration":60,"data":{"password":%q}}`, password)
+	}))
+	defer ts.Close()
+
+	dir, err := ioutil.TempDir("", "vault-agent-template")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	defer os.RemoveAll(dir)
+	destination := filepath.Join(dir, "password")
+
+	apiConfig := api.DefaultConfig()
+	apiConfig.Address = ts.URL
+	client, err := api.NewClient(apiConfig)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	client.SetToken("agent")
+
+	r := NewRenderer(logformat.NewVaultLogger(log.LevelTrace), client, []*config.Template{
+		{
+			Contents:    `password={{ with secret "secret/app" }}{{ .Data.password }}{{ end }}`,
+			Destination: destination,
+			Perms:       0600,
+		},
+	})
+
+	for _, expected := range []string{"foo", "bar"} {
+		password = expected
+		interval, err := r.Render()
+		if err != nil {
+			t.Fatalf("err: %s", err)
+		}
+		if interval != 40*time.Second {
+			t.Fatalf("bad: %s", interval)
+		}
+
+		actual, err := ioutil.ReadFile(destination)
+		if err != nil {
+			t.Fatalf("err: %s", err)
+		}
+		if string(actual) != "password="+expected {
+			t.Fatalf("bad: %s", actual)
+		}
+	}
+
+	fi, err := os.Stat(destination)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if fi.Mode().Perm() != 0600 {
+		t.Fatalf("bad: %s", fi.Mode())
+	}
+
+	// Missing secrets are errors
+	r.templates[0].Contents = `{{ with secret "secret/missing" }}{{ end }}`
+	if _, err := r.Render(); err == nil {
+		t.Fatal("expected error")
+	}
+}
@@ -0,0 +1,212 @@
+package command
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/api"
+	credAppRole "github.com/hashicorp/vault/builtin/credential/approle"
+	"github.com/hashicorp/vault/helper/logformat"
+	vaulthttp "github.com/hashicorp/vault/http"
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/meta"
+	"github.com/hashicorp/vault/physical"
+	"github.com/hashicorp/vault/vault"
+	log "github.com/mgutz/logxi/v1"
+	"github.com/mitchellh/cli"
+)
+
+func TestAgent_appRole(t *testing.T) {
+	logger := logformat.NewVaultLogger(log.LevelTrace)
+	core, err := vault.NewCore(&vault.CoreConfig{
+		Physical: physical.NewInmem(logger),
+		CredentialBackends: map[string]logical.Factory{
+			"approle": credAppRole.Factory,
+		},
+		DisableMlock: true,
+		Logger:       logger,
+	})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	key, token := vault.TestCoreInit(t, core)
+	if _, err := vault.TestCoreUnseal(core, vault.TestKeyCopy(key)); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	ln, addr := vaulthttp.TestServer(t, core)
+	defer ln.Close()
+
+	config := api.DefaultConfig()
+	config.Address = addr
+	client, err := api.NewClient(config)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	client.SetToken(token)
+
+	if err := client.Sys().EnableAuth("approle", "approle", ""); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if _, err := client.Logical().Write("auth/approle/role/app", map[string]interface{}{
+		"policies": "app",
+	}); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	secret, err := client.Logical().Read("auth/approle/role/app/role-id")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	roleID := secret.Data["role_id"].(string)
+	secret, err = client.Logical().Write("auth/approle/role/app/secret-id", nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	secretID := secret.Data["secret_id"].(string)
+
+	if _, err := client.Logical().Write("secret/app", map[string]interface{}{
+		"password": "hunter2",
+	}); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if _, err := client.Logical().Write("sys/policy/app", map[string]interface{}{
+		"rules": `path "secret/app" { policy = "read" }`,
+	}); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	dir, err := ioutil.TempDir("", "vault-agent")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	roleIDPath := filepath.Join(dir, "role-id")
+	secretIDPath := filepath.Join(dir, "secret-id")
+	sinkPath := filepath.Join(dir, "token")
+	templatePath := filepath.Join(dir, "password")
+	for path, contents := range map[string]string{roleIDPath: roleID, secretIDPath: secretID} {
+		if err := ioutil.WriteFile(path, []byte(contents), 0600); err != nil {
+			t.Fatalf("err: %s", err)
+		}
+	}
+
+	configPath := filepath.Join(dir, "agent.hcl")
+	if err := ioutil.WriteFile(configPath, []byte(fmt.Sprintf(`
+vault {
+  address = %q
+}
+
+auto_auth {
+  method "approle" {
+    config {
+      role_id_file_path   = %q
+      secret_id_file_path = %q
+    }
+  }
+
+  sink "file" {
+    config {
+      path = %q
+    }
+  }
+}
+
+template {
+  contents    = "{{ with secret \"secret/app\" }}{{ .Data.password }}{{ end }}"
+  destination = %q
+}
+`, addr, roleIDPath, secretIDPath, sinkPath, templatePath)), 0600); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	ui := new(cli.MockUi)
+	shutdownCh := make(chan struct{})
+	c := &AgentCommand{
+		Meta: meta.Meta{
+			Ui: ui,
+		},
+		ShutdownCh: shutdownCh,
+	}
+
+	doneCh := make(chan int)
+	go func() {
+		doneCh <- c.Run([]string{"-config", configPath})
+	}()
+
+	// Wait for the template to be rendered
+	var rendered []byte
+	for i := 0; i < 50; i++ {
+		if rendered, err = ioutil.ReadFile(templatePath); err == nil {
+			break
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	if string(rendered) != "hunter2" {
+		t.Fatalf("bad: %q\n\n%s", rendered, ui.ErrorWriter.String())
+	}
+
+	// The token of the sink is usable
+	agentToken, err := ioutil.ReadFile(sinkPath)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	client.SetToken(string(agentToken))
+	secret, err = client.Auth().Token().LookupSelf()
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if secret.Data["path"] != "auth/approle/login" {
+		t.Fatalf("bad: %#v", secret.Data)
+	}
+
+	// The SecretID file is removed once read
+	if _, err := os.Stat(secretIDPath); !os.IsNotExist(err) {
+		t.Fatalf("bad: %v", err)
+	}
+
+	close(shutdownCh)
+	select {
+	case code := <-doneCh:
+		if code != 0 {
+			t.Fatalf("bad: %d\n\n%s", code, ui.ErrorWriter.String())
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("agent didn't shut down")
+	}
+}
+
+func TestAgent_badConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "vault-agent")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	configPath := filepath.Join(dir, "agent.hcl")
+	if err := ioutil.WriteFile(configPath, []byte(`
+template {
+  contents    = "foo"
+  destination = "bar"
+}
+`), 0600); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	ui := new(cli.MockUi)
+	c := &AgentCommand{
+		Meta: meta.Meta{
+			Ui: ui,
+		},
+	}
+
+	if code := c.Run([]string{"-config", configPath}); code != 1 {
+		t.Fatalf("bad: %d", code)
+	}
+	if code := c.Run(nil); code != 1 {
+		t.Fatalf("bad: %d", code)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/hashicorp/vault/api"
 	"github.com/hashicorp/vault/meta"
 )
 
@@ -13,10 +14,13 @@ type AuthEnableCommand struct {
 }
 
 func (c *AuthEnableCommand) Run(args []string) int {
-	var description, path string
+	var description, path, pluginName string
+	var sealWrap bool
 	flags := c.Meta.FlagSet("auth-enable", meta.FlagSetDefault)
 	flags.StringVar(&description, "description", "", "")
 	flags.StringVar(&path, "path", "", "")
+	flags.StringVar(&pluginName, "plugin-name", "", "")
+	flags.BoolVar(&sealWrap, "seal-wrap", false, "")
 	flags.Usage = func() { c.Ui.Error(c.Help()) }
 	if err := flags.Parse(args); err != nil {
 		return 1
@@ -32,9 +36,13 @@ func (c *AuthEnableCommand) Run(args []string) int {
 
 	authType := args[0]
 
-	// If no path is specified, we default the path to the backend type
+	// If no path is specified, we default the path to the backend type, or
+	// to the plugin name for plugin backends
 	if path == "" {
 		path = authType
+		if pluginName != "" {
+			path = pluginName
+		}
 	}
 
 	client, err := c.Client()
@@ -44,7 +52,14 @@ func (c *AuthEnableCommand) Run(args []string) int {
 		return 2
 	}
 
-	if err := client.Sys().EnableAuth(path, authType, description); err != nil {
+	if err := client.Sys().EnableAuthWithOptions(path, &api.EnableAuthOptions{
+		Type:        authType,
+		Description: description,
+		Config: api.AuthConfigInput{
+			PluginName: pluginName,
+		},
+		SealWrap: sealWrap,
+	}); err != nil {
 		c.Ui.Error(fmt.Sprintf(
 			"Error: %s", err))
 		return 2
@@ -82,6 +97,14 @@ Auth Enable Options:
                           to the type of the mount. This will make the auth
                           provider available at "/auth/<path>"
 
+  -plugin-name=<name>     Name of the plugin to run, for providers of the
+                          "plugin" type. The plugin must be registered in the
+                          plugin catalog. The mount point defaults to the
+                          plugin name.
+
+  -seal-wrap              Also encrypt the values written by the provider
+                          with the seal. This requires an auto seal.
+
 `
 	return strings.TrimSpace(helpText)
 }
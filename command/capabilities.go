@@ -7,13 +7,15 @@ import (
 	"github.com/hashicorp/vault/meta"
 )
 
-// CapabilitiesCommand is a Command that enables a new endpoint.
+// CapabilitiesCommand is a Command that fetches the capabilities of a token.
 type CapabilitiesCommand struct {
 	meta.Meta
 }
 
 func (c *CapabilitiesCommand) Run(args []string) int {
-	flags := c.Meta.FlagSet("capabilities", meta.FlagSetDefault)
+	var accessor bool
+	flags := c.Meta.FlagSet("token capabilities", meta.FlagSetDefault)
+	flags.BoolVar(&accessor, "accessor", false, "")
 	flags.Usage = func() { c.Ui.Error(c.Help()) }
 	if err := flags.Parse(args); err != nil {
 		return 1
@@ -23,7 +25,7 @@ func (c *CapabilitiesCommand) Run(args []string) int {
 	if len(args) > 2 {
 		flags.Usage()
 		c.Ui.Error(fmt.Sprintf(
-			"\ncapabilities expects at most two arguments"))
+			"\ntoken capabilities expects at most two arguments"))
 		return 1
 	}
 
@@ -37,7 +39,12 @@ func (c *CapabilitiesCommand) Run(args []string) int {
 		path = args[1]
 	default:
 		flags.Usage()
-		c.Ui.Error(fmt.Sprintf("\ncapabilities expects at least one argument"))
+		c.Ui.Error(fmt.Sprintf("\ntoken capabilities expects at least one argument"))
+		return 1
+	}
+	if accessor && token == "" {
+		flags.Usage()
+		c.Ui.Error("\ntoken capabilities expects an accessor and a path when the accessor flag is set")
 		return 1
 	}
 
@@ -49,9 +56,12 @@ func (c *CapabilitiesCommand) Run(args []string) int {
 	}
 
 	var capabilities []string
-	if token == "" {
+	switch {
+	case accessor:
+		capabilities, err = client.Sys().CapabilitiesAccessor(token, path)
+	case token == "":
 		capabilities, err = client.Sys().CapabilitiesSelf(path)
-	} else {
+	default:
 		capabilities, err = client.Sys().Capabilities(token, path)
 	}
 	if err != nil {
@@ -70,18 +80,27 @@ func (c *CapabilitiesCommand) Synopsis() string {
 
 func (c *CapabilitiesCommand) Help() string {
 	helpText := `
-Usage: vault capabilities [options] [token] path
+Usage: vault token capabilities [options] [token|accessor] path
 
   Fetch the capabilities of a token on a given path.
   If a token is provided as an argument, the '/sys/capabilities' endpoint will be invoked
   with the given token; otherwise the '/sys/capabilities-self' endpoint will be invoked
-  with the client token.
+  with the client token. With the '-accessor' flag, the first argument is the accessor
+  of the token and the '/sys/capabilities-accessor' endpoint is invoked instead.
 
   If a token does not have any capability on a given path, or if any of the policies
   belonging to the token explicitly have ["deny"] capability, or if the argument path
   is invalid, this command will respond with a ["deny"].
 
+      $ vault token capabilities secret/foo
+      $ vault token capabilities -accessor 2c84f488-2133-4ced-87b0-570f93a76830 secret/foo
+
 General Options:
-` + meta.GeneralOptionsUsage()
+` + meta.GeneralOptionsUsage() + `
+Token Capabilities Options:
+
+  -accessor               A boolean flag, if set, treats the first argument as
+                          an accessor of the token.
+`
 	return strings.TrimSpace(helpText)
 }
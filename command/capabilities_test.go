@@ -1,6 +1,7 @@
 package command
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/hashicorp/vault/http"
@@ -43,3 +44,41 @@ func TestCapabilities_Basic(t *testing.T) {
 		t.Fatalf("expected failure due to invalid token")
 	}
 }
+
+func TestCapabilities_accessor(t *testing.T) {
+	core, _, token := vault.TestCoreUnsealed(t)
+	ln, addr := http.TestServer(t, core)
+	defer ln.Close()
+	ui := new(cli.MockUi)
+	c := &CapabilitiesCommand{
+		Meta: meta.Meta{
+			ClientToken: token,
+			Ui:          ui,
+		},
+	}
+
+	client := testClient(t, addr, token)
+	secret, err := client.Auth().Token().LookupSelf()
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	accessor := secret.Data["accessor"].(string)
+
+	args := []string{"-address", addr, "-accessor", "test"}
+	if code := c.Run(args); code == 0 {
+		t.Fatalf("expected failure due to no path")
+	}
+
+	args = []string{"-address", addr, "-accessor", accessor, "test"}
+	if code := c.Run(args); code != 0 {
+		t.Fatalf("bad: %d\n\n%s", code, ui.ErrorWriter.String())
+	}
+	if output := ui.OutputWriter.String(); !strings.Contains(output, "root") {
+		t.Fatalf("bad: %s", output)
+	}
+
+	args = []string{"-address", addr, "-accessor", "invalidaccessor", "test"}
+	if code := c.Run(args); code == 0 {
+		t.Fatalf("expected failure due to invalid accessor")
+	}
+}
@@ -0,0 +1,346 @@
+package command
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/vault/api"
+	"github.com/hashicorp/vault/meta"
+	"github.com/hashicorp/vault/version"
+)
+
+// debugRedacted replaces the sensitive values in the debug bundles
+const debugRedacted = "[redacted]"
+
+// debugTargets are the information polled by vault debug, with the paths
+// they're read from
+var debugTargets = map[string]string{
+	"health":      "/v1/sys/health",
+	"host":        "/v1/sys/host-info",
+	"ha":          "/v1/sys/leader",
+	"metrics":     "/v1/sys/metrics",
+	"replication": "/v1/sys/replication/status",
+	"seal-status": "/v1/sys/seal-status",
+}
+
+// debugSensitiveKeys are the substrings of the keys whose values are
+// redacted from the debug bundles
+var debugSensitiveKeys = []string{
+	"accessor",
+	"credential",
+	"hmac",
+	"passphrase",
+	"password",
+	"private_key",
+	"secret",
+	"token",
+	"unseal_key",
+}
+
+// DebugCommand is a Command that polls the status of the server and
+// packages it into an archive for support.
+type DebugCommand struct {
+	meta.Meta
+
+	ShutdownCh chan struct{}
+}
+
+// debugSample is the response of a target at a point in time
+type debugSample struct {
+	Timestamp time.Time   `json:"timestamp"`
+	Status    int         `json:"status,omitempty"`
+	Data      interface{} `json:"data,omitempty"`
+	Error     string      `json:"error,omitempty"`
+}
+
+// debugIndex describes a debug bundle
+type debugIndex struct {
+	VaultAddress string              `json:"vault_address"`
+	CLIVersion   string              `json:"cli_version"`
+	Start        time.Time           `json:"start"`
+	End          time.Time           `json:"end"`
+	Duration     string              `json:"duration"`
+	Interval     string              `json:"interval"`
+	Targets      []string            `json:"targets"`
+	Errors       map[string][]string `json:"errors,omitempty"`
+}
+
+func (c *DebugCommand) Run(args []string) int {
+	var duration, interval time.Duration
+	var output, targetsRaw string
+	flags := c.Meta.FlagSet("debug", meta.FlagSetDefault)
+	flags.DurationVar(&duration, "duration", 2*time.Minute, "")
+	flags.DurationVar(&interval, "interval", 30*time.Second, "")
+	flags.StringVar(&output, "output", "", "")
+	flags.StringVar(&targetsRaw, "targets", "", "")
+	flags.Usage = func() { c.Ui.Error(c.Help()) }
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	if len(flags.Args()) != 0 {
+		flags.Usage()
+		c.Ui.Error("\ndebug expects no arguments")
+		return 1
+	}
+	if interval <= 0 || duration < interval {
+		c.Ui.Error("The interval must be positive, and at most the duration")
+		return 1
+	}
+
+	targets := make([]string, 0, len(debugTargets))
+	if targetsRaw == "" {
+		for target := range debugTargets {
+			targets = append(targets, target)
+		}
+	} else {
+		for _, target := range strings.Split(targetsRaw, ",") {
+			target = strings.TrimSpace(target)
+			if _, ok := debugTargets[target]; !ok {
+				c.Ui.Error(fmt.Sprintf("Unknown target %q", target))
+				return 1
+			}
+			targets = append(targets, target)
+		}
+	}
+	sort.Strings(targets)
+
+	start := time.Now().UTC()
+	name := fmt.Sprintf("vault-debug-%s", start.Format("2006-01-02T15-04-05Z"))
+	if output == "" {
+		output = name + ".tar.gz"
+	}
+	if _, err := os.Stat(output); err == nil {
+		c.Ui.Error(fmt.Sprintf("Output file %s already exists", output))
+		return 1
+	}
+
+	client, err := c.Client()
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf(
+			"Error initializing client: %s", err))
+		return 2
+	}
+
+	c.Ui.Output(fmt.Sprintf(
+		"==> Polling %s every %s for %s: %s",
+		client.Address(), interval, duration, strings.Join(targets, ", ")))
+
+	samples := make(map[string][]*debugSample, len(targets))
+	deadline := time.After(duration)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+POLL:
+	for {
+		for _, target := range targets {
+			samples[target] = append(samples[target], debugPoll(client, debugTargets[target]))
+		}
+
+		select {
+		case <-ticker.C:
+		case <-deadline:
+			break POLL
+		case <-c.ShutdownCh:
+			c.Ui.Output("==> Interrupted, writing the samples collected so far")
+			break POLL
+		}
+	}
+
+	index := &debugIndex{
+		VaultAddress: client.Address(),
+		CLIVersion:   version.GetVersion().FullVersionNumber(),
+		Start:        start,
+		End:          time.Now().UTC(),
+		Duration:     duration.String(),
+		Interval:     interval.String(),
+		Targets:      targets,
+	}
+	for _, target := range targets {
+		for _, sample := range samples[target] {
+			if sample.Error == "" {
+				continue
+			}
+			if index.Errors == nil {
+				index.Errors = make(map[string][]string)
+			}
+			index.Errors[target] = append(index.Errors[target], sample.Error)
+		}
+	}
+
+	files := map[string]interface{}{
+		"index.json": index,
+	}
+	for _, target := range targets {
+		files[target+".json"] = samples[target]
+	}
+	if err := writeDebugBundle(output, name, files); err != nil {
+		c.Ui.Error(fmt.Sprintf("Error writing %s: %s", output, err))
+		return 1
+	}
+
+	for _, target := range targets {
+		if errs := index.Errors[target]; len(errs) > 0 {
+			c.Ui.Warn(fmt.Sprintf("Target %s failed %d time(s): %s", target, len(errs), errs[len(errs)-1]))
+		}
+	}
+	c.Ui.Output(fmt.Sprintf("Success! Debug bundle written to %s", output))
+	return 0
+}
+
+// debugPoll reads the path, and returns the redacted response
+func debugPoll(client *api.Client, path string) *debugSample {
+	sample := &debugSample{
+		Timestamp: time.Now().UTC(),
+	}
+
+	// The health endpoint reports the state of the server with its status
+	// code, so responses with errors are still recorded
+	r := client.NewRequest("GET", path)
+	resp, err := client.RawRequest(r)
+	if resp != nil {
+		defer resp.Body.Close()
+		sample.Status = resp.StatusCode
+
+		var data interface{}
+		if decodeErr := json.NewDecoder(resp.Body).Decode(&data); decodeErr == nil {
+			sample.Data = redactDebugData(data)
+		}
+	}
+	if err != nil && (resp == nil || resp.StatusCode != 429 && resp.StatusCode != 501 && resp.StatusCode != 503) {
+		sample.Error = err.Error()
+		if resp != nil && resp.StatusCode == 404 {
+			sample.Error = fmt.Sprintf("%s isn't supported by the server", path)
+		}
+	}
+
+	return sample
+}
+
+// redactDebugData replaces the values of the sensitive keys
+func redactDebugData(data interface{}) interface{} {
+	switch v := data.(type) {
+	case map[string]interface{}:
+		for key, value := range v {
+			if isDebugSensitive(key) {
+				v[key] = debugRedacted
+			} else {
+				v[key] = redactDebugData(value)
+			}
+		}
+	case []interface{}:
+		for i, value := range v {
+			v[i] = redactDebugData(value)
+		}
+	}
+	return data
+}
+
+func isDebugSensitive(key string) bool {
+	key = strings.ToLower(key)
+	for _, s := range debugSensitiveKeys {
+		if strings.Contains(key, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// writeDebugBundle writes the files as JSON into a gzipped tar archive,
+// under the directory name
+func writeDebugBundle(output, name string, files map[string]interface{}) error {
+	f, err := os.OpenFile(output, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+
+	fileNames := make([]string, 0, len(files))
+	for fileName := range files {
+		fileNames = append(fileNames, fileName)
+	}
+	sort.Strings(fileNames)
+
+	now := time.Now()
+	for _, fileName := range fileNames {
+		d, err := json.MarshalIndent(files[fileName], "", "  ")
+		if err != nil {
+			return err
+		}
+		if err := tw.WriteHeader(&tar.Header{
+			Name:    filepath.ToSlash(filepath.Join(name, fileName)),
+			Mode:    0600,
+			Size:    int64(len(d)),
+			ModTime: now,
+		}); err != nil {
+			return err
+		}
+		if _, err := tw.Write(d); err != nil {
+			return err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+func (c *DebugCommand) Synopsis() string {
+	return "Collect the status of the server for support"
+}
+
+func (c *DebugCommand) Help() string {
+	helpText := `
+Usage: vault debug [options]
+
+  Poll the status of the server for a duration, and write the responses into
+  a gzipped tar archive which can be sent to support.
+
+  The targets are polled at every interval:
+
+      health         The health of the server (sys/health)
+      seal-status    The seal status (sys/seal-status)
+      ha             The HA status and the leader (sys/leader)
+      metrics        The telemetry metrics (sys/metrics)
+      host           The host of the server (sys/host-info)
+      replication    The replication status (sys/replication/status)
+
+  The targets which aren't supported by the server are recorded as errors in
+  the index.json file of the archive, with the addresses and times of the
+  collection. The values of keys which look sensitive, such as tokens,
+  accessors, passwords and secrets, are redacted. Polling stops early on
+  interrupt, and the samples collected so far are still written.
+
+      $ vault debug -duration=5m -interval=10s -targets=health,ha
+
+General Options:
+` + meta.GeneralOptionsUsage() + `
+Debug Options:
+
+  -duration=2m            How long to poll the server for.
+
+  -interval=30s           How often to poll the server.
+
+  -output=<path>          The archive to write. Defaults to
+                          "vault-debug-<timestamp>.tar.gz" in the current
+                          directory. Existing files aren't overwritten.
+
+  -targets=<list>         Comma-separated targets to poll. Defaults to all of
+                          them.
+`
+	return strings.TrimSpace(helpText)
+}
@@ -0,0 +1,136 @@
+package command
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/vault/http"
+	"github.com/hashicorp/vault/meta"
+	"github.com/hashicorp/vault/vault"
+	"github.com/mitchellh/cli"
+)
+
+func TestDebug(t *testing.T) {
+	core, _, token := vault.TestCoreUnsealed(t)
+	ln, addr := http.TestServer(t, core)
+	defer ln.Close()
+
+	dir, err := ioutil.TempDir("", "vault-debug")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	defer os.RemoveAll(dir)
+	output := filepath.Join(dir, "bundle.tar.gz")
+
+	ui := new(cli.MockUi)
+	c := &DebugCommand{
+		Meta: meta.Meta{
+			ClientToken: token,
+			Ui:          ui,
+		},
+	}
+
+	args := []string{
+		"-address", addr,
+		"-duration", "250ms",
+		"-interval", "100ms",
+		"-targets", "health,metrics,seal-status",
+		"-output", output,
+	}
+	if code := c.Run(args); code != 0 {
+		t.Fatalf("bad: %d\n\n%s", code, ui.ErrorWriter.String())
+	}
+
+	f, err := os.Open(output)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	tr := tar.NewReader(gz)
+
+	files := make(map[string][]byte)
+	for {
+		hdr, err := tr.Next()
+		if err != nil {
+			break
+		}
+		d, err := ioutil.ReadAll(tr)
+		if err != nil {
+			t.Fatalf("err: %s", err)
+		}
+		files[filepath.Base(hdr.Name)] = d
+	}
+
+	var names []string
+	for name := range files {
+		names = append(names, name)
+	}
+	expected := []string{"health.json", "index.json", "metrics.json", "seal-status.json"}
+	for _, name := range expected {
+		if _, ok := files[name]; !ok {
+			t.Fatalf("bad: %v", names)
+		}
+	}
+
+	var health []*debugSample
+	if err := json.Unmarshal(files["health.json"], &health); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if len(health) < 2 || health[0].Status != 200 || health[0].Error != "" {
+		t.Fatalf("bad: %s", files["health.json"])
+	}
+
+	// The metrics aren't supported by this server
+	var index debugIndex
+	if err := json.Unmarshal(files["index.json"], &index); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if index.VaultAddress != addr || len(index.Errors["metrics"]) == 0 || len(index.Errors["health"]) != 0 {
+		t.Fatalf("bad: %s", files["index.json"])
+	}
+	if !strings.Contains(ui.ErrorWriter.String(), "metrics") {
+		t.Fatalf("bad: %s", ui.ErrorWriter.String())
+	}
+
+	// Existing files aren't overwritten
+	if code := c.Run(args); code != 1 {
+		t.Fatalf("bad: %d", code)
+	}
+}
+
+func TestRedactDebugData(t *testing.T) {
+	var data interface{}
+	if err := json.Unmarshal([]byte(`{
+  "cluster_name": "vault",
+  "client_token": "foo",
+  "nested": [{"DB_Password": "bar", "accessor_list": ["baz"], "ok": 1}]
+}`), &data); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	expected := map[string]interface{}{
+		"cluster_name": "vault",
+		"client_token": debugRedacted,
+		"nested": []interface{}{
+			map[string]interface{}{
+				"DB_Password":   debugRedacted,
+				"accessor_list": debugRedacted,
+				"ok":            float64(1),
+			},
+		},
+	}
+	if actual := redactDebugData(data); !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("bad: %#v", actual)
+	}
+}
@@ -0,0 +1,34 @@
+package command
+
+import (
+	"fmt"
+
+	"github.com/mitchellh/cli"
+)
+
+// DeprecatedCommand runs a command under the name it had before it was
+// renamed, warning that the name is deprecated.
+type DeprecatedCommand struct {
+	cli.Command
+
+	// Old and New are the deprecated and current names of the command
+	Old string
+	New string
+
+	Ui cli.Ui
+}
+
+func (c *DeprecatedCommand) Run(args []string) int {
+	c.Ui.Warn(fmt.Sprintf(
+		"The \"vault %s\" command is deprecated, use \"vault %s\" instead.", c.Old, c.New))
+	return c.Command.Run(args)
+}
+
+func (c *DeprecatedCommand) Synopsis() string {
+	return fmt.Sprintf("%s (deprecated, see %s)", c.Command.Synopsis(), c.New)
+}
+
+func (c *DeprecatedCommand) Help() string {
+	return fmt.Sprintf(
+		"This command is deprecated, use \"vault %s\" instead.\n\n%s", c.New, c.Command.Help())
+}
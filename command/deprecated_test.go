@@ -0,0 +1,48 @@
+package command
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/vault/http"
+	"github.com/hashicorp/vault/meta"
+	"github.com/hashicorp/vault/vault"
+	"github.com/mitchellh/cli"
+)
+
+func TestDeprecatedCommand(t *testing.T) {
+	core, _, token := vault.TestCoreUnsealed(t)
+	ln, addr := http.TestServer(t, core)
+	defer ln.Close()
+
+	ui := new(cli.MockUi)
+	c := &DeprecatedCommand{
+		Command: &TokenLookupCommand{
+			Meta: meta.Meta{
+				ClientToken: token,
+				Ui:          ui,
+			},
+		},
+		Old: "token-lookup",
+		New: "token lookup",
+		Ui:  ui,
+	}
+
+	if code := c.Run([]string{"-address", addr}); code != 0 {
+		t.Fatalf("bad: %d\n\n%s", code, ui.ErrorWriter.String())
+	}
+	expected := `The "vault token-lookup" command is deprecated, use "vault token lookup" instead.`
+	if errOutput := ui.ErrorWriter.String(); !strings.Contains(errOutput, expected) {
+		t.Fatalf("bad: %s", errOutput)
+	}
+	if !strings.Contains(ui.OutputWriter.String(), token) {
+		t.Fatalf("bad: %s", ui.OutputWriter.String())
+	}
+
+	if synopsis := c.Synopsis(); !strings.HasSuffix(synopsis, "(deprecated, see token lookup)") {
+		t.Fatalf("bad: %s", synopsis)
+	}
+	if help := c.Help(); !strings.Contains(help, "Usage: vault token lookup") {
+		t.Fatalf("bad: %s", help)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"os"
 	"sort"
 	"strconv"
 	"strings"
@@ -17,6 +18,19 @@ import (
 	"github.com/ryanuber/columnize"
 )
 
+// EnvVaultFormat is the environment variable setting the output format of
+// the commands when -format isn't given
+const EnvVaultFormat = "VAULT_FORMAT"
+
+// DefaultFormat returns the output format used when -format isn't given,
+// set by VAULT_FORMAT, or the table format
+func DefaultFormat() string {
+	if format := os.Getenv(EnvVaultFormat); format != "" {
+		return format
+	}
+	return "table"
+}
+
 func OutputSecret(ui cli.Ui, format string, secret *api.Secret) int {
 	return outputWithFormat(ui, format, secret, secret)
 }
@@ -25,6 +39,12 @@ func OutputList(ui cli.Ui, format string, secret *api.Secret) int {
 	return outputWithFormat(ui, format, secret, secret.Data["keys"])
 }
 
+// OutputData outputs data other than a secret, such as a status, in the json
+// or yaml format. Commands output such data themselves in the table format.
+func OutputData(ui cli.Ui, format string, data interface{}) int {
+	return outputWithFormat(ui, format, nil, data)
+}
+
 func outputWithFormat(ui cli.Ui, format string, secret *api.Secret, data interface{}) int {
 	formatter, ok := Formatters[strings.ToLower(format)]
 	if !ok {
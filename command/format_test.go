@@ -1,6 +1,7 @@
 package command
 
 import (
+	"os"
 	"strings"
 	"testing"
 
@@ -80,3 +81,17 @@ func TestTableFormatter(t *testing.T) {
 		t.Fatal("did not find 'something'")
 	}
 }
+
+func TestDefaultFormat(t *testing.T) {
+	defer os.Setenv(EnvVaultFormat, os.Getenv(EnvVaultFormat))
+
+	os.Setenv(EnvVaultFormat, "")
+	if format := DefaultFormat(); format != "table" {
+		t.Fatalf("bad: %s", format)
+	}
+
+	os.Setenv(EnvVaultFormat, "json")
+	if format := DefaultFormat(); format != "json" {
+		t.Fatalf("bad: %s", format)
+	}
+}
@@ -23,7 +23,7 @@ func (c *InitCommand) Run(args []string) int {
 	var threshold, shares, storedShares, recoveryThreshold, recoveryShares int
 	var pgpKeys, recoveryPgpKeys, rootTokenPgpKey pgpkeys.PubKeyFilesFlag
 	var auto, check bool
-	var consulServiceName string
+	var consulServiceName, format string
 	flags := c.Meta.FlagSet("init", meta.FlagSetDefault)
 	flags.Usage = func() { c.Ui.Error(c.Help()) }
 	flags.IntVar(&shares, "key-shares", 5, "")
@@ -35,6 +35,8 @@ func (c *InitCommand) Run(args []string) int {
 	flags.IntVar(&recoveryThreshold, "recovery-threshold", 3, "")
 	flags.Var(&recoveryPgpKeys, "recovery-pgp-keys", "")
 	flags.BoolVar(&check, "check", false, "")
+	flags.BoolVar(&check, "status", false, "")
+	flags.StringVar(&format, "format", DefaultFormat(), "")
 	flags.BoolVar(&auto, "auto", false, "")
 	flags.StringVar(&consulServiceName, "consul-service", physical.DefaultServiceName, "")
 	if err := flags.Parse(args); err != nil {
@@ -153,7 +155,7 @@ func (c *InitCommand) Run(args []string) int {
 			c.Ui.Output(fmt.Sprintf("Discovered Vault at %+q using Consul service name %+q\n", vaultURL.String(), consulServiceName))
 
 			// Attempt initializing it
-			ret := c.runInit(check, initRequest)
+			ret := c.runInit(check, format, initRequest)
 
 			// Regardless of success or failure, instruct client to update VAULT_ADDR
 			c.Ui.Output("\nSet the following environment variable to operate on the discovered Vault:\n")
@@ -179,10 +181,10 @@ func (c *InitCommand) Run(args []string) int {
 		}
 	}
 
-	return c.runInit(check, initRequest)
+	return c.runInit(check, format, initRequest)
 }
 
-func (c *InitCommand) runInit(check bool, initRequest *api.InitRequest) int {
+func (c *InitCommand) runInit(check bool, format string, initRequest *api.InitRequest) int {
 	client, err := c.Client()
 	if err != nil {
 		c.Ui.Error(fmt.Sprintf(
@@ -191,7 +193,7 @@ func (c *InitCommand) runInit(check bool, initRequest *api.InitRequest) int {
 	}
 
 	if check {
-		return c.checkStatus(client)
+		return c.checkStatus(client, format)
 	}
 
 	resp, err := client.Sys().Init(initRequest)
@@ -201,6 +203,21 @@ func (c *InitCommand) runInit(check bool, initRequest *api.InitRequest) int {
 		return 1
 	}
 
+	if strings.ToLower(format) != "table" {
+		out := &initOutput{
+			InitResponse: resp,
+		}
+		if len(resp.Keys) > 0 {
+			out.UnsealShares = initRequest.SecretShares
+			out.UnsealThreshold = initRequest.SecretThreshold
+		}
+		if len(resp.RecoveryKeys) > 0 {
+			out.RecoveryShares = initRequest.RecoveryShares
+			out.RecoveryThreshold = initRequest.RecoveryThreshold
+		}
+		return OutputData(c.Ui, format, out)
+	}
+
 	for i, key := range resp.Keys {
 		if resp.KeysB64 != nil && len(resp.KeysB64) == len(resp.Keys) {
 			c.Ui.Output(fmt.Sprintf("Unseal Key %d: %s", i+1, resp.KeysB64[i]))
@@ -218,7 +235,9 @@ func (c *InitCommand) runInit(check bool, initRequest *api.InitRequest) int {
 
 	c.Ui.Output(fmt.Sprintf("Initial Root Token: %s", resp.RootToken))
 
-	if initRequest.StoredShares < 1 {
+	// The master key is stored by the seals supporting it, which don't
+	// return any unseal key
+	if len(resp.Keys) > 0 {
 		c.Ui.Output(fmt.Sprintf(
 			"\n"+
 				"Vault initialized with %d keys and a key threshold of %d. Please\n"+
@@ -251,13 +270,27 @@ func (c *InitCommand) runInit(check bool, initRequest *api.InitRequest) int {
 	return 0
 }
 
-func (c *InitCommand) checkStatus(client *api.Client) int {
+func (c *InitCommand) checkStatus(client *api.Client, format string) int {
 	inited, err := client.Sys().InitStatus()
-	switch {
-	case err != nil:
+	if err != nil {
 		c.Ui.Error(fmt.Sprintf(
 			"Error checking initialization status: %s", err))
 		return 1
+	}
+
+	if strings.ToLower(format) != "table" {
+		if ret := OutputData(c.Ui, format, map[string]interface{}{
+			"initialized": inited,
+		}); ret != 0 {
+			return ret
+		}
+		if !inited {
+			return 2
+		}
+		return 0
+	}
+
+	switch {
 	case inited:
 		c.Ui.Output("Vault has been initialized")
 		return 0
@@ -267,6 +300,15 @@ func (c *InitCommand) checkStatus(client *api.Client) int {
 	}
 }
 
+// initOutput is the init output in the json and yaml formats
+type initOutput struct {
+	*api.InitResponse
+	UnsealShares      int `json:"unseal_shares,omitempty"`
+	UnsealThreshold   int `json:"unseal_threshold,omitempty"`
+	RecoveryShares    int `json:"recovery_shares,omitempty"`
+	RecoveryThreshold int `json:"recovery_threshold,omitempty"`
+}
+
 func (c *InitCommand) Synopsis() string {
 	return "Initialize a new Vault server"
 }
@@ -283,24 +325,42 @@ Usage: vault init [options]
 
   This command can't be called on an already-initialized Vault.
 
+  With a seal supporting it, such as awskms or pkcs11, the master key is
+  stored by the seal and used to unseal automatically, and recovery keys are
+  generated instead of unseal keys:
+
+      $ vault init -recovery-shares=5 -recovery-threshold=3
+
+  The keys can be encrypted with PGP keys, or Keybase users, so that each
+  holder can only decrypt their own:
+
+      $ vault init -key-shares=3 -key-threshold=2 \
+          -pgp-keys="keybase:jeff,keybase:vishal,keybase:seth"
+
 General Options:
 ` + meta.GeneralOptionsUsage() + `
 Init Options:
 
-  -check                    Don't actually initialize, just check if Vault is
+  -check | -status          Don't actually initialize, just check if Vault is
                             already initialized. A return code of 0 means Vault
                             is initialized; a return code of 2 means Vault is not
                             initialized; a return code of 1 means an error was
                             encountered.
 
+  -format=table             The format for output. By default it is a
+                            human-readable list. This can also be json or yaml,
+                            for automation. Overrides the VAULT_FORMAT
+                            environment variable if set.
+
   -key-shares=5             The number of key shares to split the master key
                             into.
 
   -key-threshold=3          The number of key shares required to reconstruct
                             the master key.
 
-  -stored-shares=0          The number of unseal keys to store. Only used with 
-                            Vault HSM. Must currently be equivalent to the
+  -stored-shares=0          The number of unseal keys to store. Only used with
+                            seals storing the master key, which store it by
+                            default. Must currently be equivalent to the
                             number of shares.
 
   -pgp-keys                 If provided, must be a comma-separated list of
@@ -322,13 +382,16 @@ Init Options:
                             to base64-decode and decrypt the result.
 
   -recovery-shares=5        The number of key shares to split the recovery key
-                            into. Only used with Vault HSM.
+                            into. Only used with seals supporting recovery
+                            keys, such as awskms and pkcs11.
 
   -recovery-threshold=3     The number of key shares required to reconstruct
-                            the recovery key. Only used with Vault HSM.
+                            the recovery key. Only used with seals supporting
+                            recovery keys.
 
   -recovery-pgp-keys        If provided, behaves like "pgp-keys" but for the
-                            recovery key shares. Only used with Vault HSM.
+                            recovery key shares. Only used with seals
+                            supporting recovery keys.
 
   -auto                     If set, performs service discovery using Consul. 
                             When all the nodes of a Vault cluster are
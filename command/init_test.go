@@ -3,6 +3,7 @@ package command
 import (
 	"bytes"
 	"encoding/base64"
+	"encoding/json"
 	"os"
 	"reflect"
 	"regexp"
@@ -95,6 +96,19 @@ func TestInit_Check(t *testing.T) {
 		t.Fatalf("bad: %d\n\n%s", code, ui.ErrorWriter.String())
 	}
 
+	ui.OutputWriter.Reset()
+	args = []string{"-address", addr, "-status", "-format", "json"}
+	if code := c.Run(args); code != 0 {
+		t.Fatalf("bad: %d\n\n%s", code, ui.ErrorWriter.String())
+	}
+	var status map[string]interface{}
+	if err := json.Unmarshal(ui.OutputWriter.Bytes(), &status); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if status["initialized"] != true {
+		t.Fatalf("bad: %#v", status)
+	}
+
 	init, err := core.Initialized()
 	if err != nil {
 		t.Fatalf("err: %s", err)
@@ -104,6 +118,53 @@ func TestInit_Check(t *testing.T) {
 	}
 }
 
+func TestInit_recoveryShares(t *testing.T) {
+	ui := new(cli.MockUi)
+	c := &InitCommand{
+		Meta: meta.Meta{
+			Ui: ui,
+		},
+	}
+
+	core := vault.TestCoreWithSeal(t, &vault.TestSeal{})
+	ln, addr := http.TestServer(t, core)
+	defer ln.Close()
+
+	// The master key is stored by the seal without having to set the
+	// unseal shares
+	args := []string{
+		"-address", addr,
+		"-recovery-shares", "3",
+		"-recovery-threshold", "2",
+		"-format", "json",
+	}
+	if code := c.Run(args); code != 0 {
+		t.Fatalf("bad: %d\n\n%s", code, ui.ErrorWriter.String())
+	}
+
+	var out map[string]interface{}
+	if err := json.Unmarshal(ui.OutputWriter.Bytes(), &out); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if keys, _ := out["keys"].([]interface{}); len(keys) != 0 {
+		t.Fatalf("bad: %#v", out)
+	}
+	if keys, _ := out["recovery_keys_base64"].([]interface{}); len(keys) != 3 {
+		t.Fatalf("bad: %#v", out)
+	}
+	if out["recovery_threshold"] != float64(2) || out["root_token"] == "" {
+		t.Fatalf("bad: %#v", out)
+	}
+
+	sealConf, err := core.SealAccess().BarrierConfig()
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if sealConf.SecretShares != 1 || sealConf.StoredShares != 1 {
+		t.Fatalf("bad: %#v", sealConf)
+	}
+}
+
 func TestInit_custom(t *testing.T) {
 	ui := new(cli.MockUi)
 	c := &InitCommand{
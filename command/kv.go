@@ -0,0 +1,206 @@
+package command
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/vault/api"
+	"github.com/hashicorp/vault/helper/kv-builder"
+	"github.com/hashicorp/vault/meta"
+	"github.com/mitchellh/cli"
+)
+
+// KVCommand is the parent of the kv subcommands, which only outputs their
+// help.
+type KVCommand struct {
+	meta.Meta
+}
+
+func (c *KVCommand) Run(args []string) int {
+	return cli.RunResultHelp
+}
+
+func (c *KVCommand) Synopsis() string {
+	return "Interact with key/value secrets"
+}
+
+func (c *KVCommand) Help() string {
+	helpText := `
+Usage: vault kv <subcommand> [options] [args]
+
+  Interact with the secrets of the generic backend, and of versioned
+  key/value mounts on the servers supporting them.
+
+  The subcommands take the path of the secret, such as "secret/foo", and
+  work out whether it belongs to a versioned mount, in which case the data
+  and the metadata of the secret are at "secret/data/foo" and
+  "secret/metadata/foo". The versions of a secret can be deleted, undeleted,
+  destroyed and rolled back to on versioned mounts only.
+
+      $ vault kv put secret/foo bar=baz
+      $ vault kv get secret/foo
+      $ vault kv patch secret/foo other=value
+      $ vault kv delete secret/foo
+
+  Run a subcommand with -help to see its usage and options.
+`
+	return strings.TrimSpace(helpText)
+}
+
+// kvMount is the mount of a KV secret
+type kvMount struct {
+	// Path is the path of the mount, with a trailing slash, or empty if the
+	// server doesn't report mounts
+	Path string
+
+	// Version is 2 for versioned mounts, and 1 otherwise
+	Version int
+}
+
+// kvPreflight returns the mount of the secret at path. Servers which don't
+// report the mount of a path only have unversioned mounts.
+func kvPreflight(client *api.Client, path string) (*kvMount, error) {
+	r := client.NewRequest("GET", "/v1/sys/internal/ui/mounts/"+path)
+	resp, err := client.RawRequest(r)
+	if resp != nil {
+		defer resp.Body.Close()
+	}
+	if resp != nil && resp.StatusCode == 404 {
+		return &kvMount{Version: 1}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := api.ParseSecret(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if secret == nil || secret.Data == nil {
+		return nil, fmt.Errorf("no mount information returned for %s", path)
+	}
+
+	mount := &kvMount{Version: 1}
+	mount.Path, _ = secret.Data["path"].(string)
+	if options, ok := secret.Data["options"].(map[string]interface{}); ok {
+		if version, _ := options["version"].(string); version == "2" {
+			mount.Version = 2
+		}
+	}
+	return mount, nil
+}
+
+// versionedPath returns the path of the secret under the given prefix of
+// a versioned mount, such as "secret/data/foo" for "secret/foo"
+func (m *kvMount) versionedPath(path, prefix string) string {
+	rel := strings.TrimPrefix(path, m.Path)
+	if path+"/" == m.Path {
+		rel = ""
+	}
+	return m.Path + prefix + "/" + rel
+}
+
+// kvClient returns a client and the mount of the secret at path, outputting
+// the errors
+func kvClient(m *meta.Meta, path string) (*api.Client, *kvMount, int) {
+	client, err := m.Client()
+	if err != nil {
+		m.Ui.Error(fmt.Sprintf(
+			"Error initializing client: %s", err))
+		return nil, nil, 2
+	}
+
+	mount, err := kvPreflight(client, path)
+	if err != nil {
+		m.Ui.Error(fmt.Sprintf(
+			"Error determining the mount of %s: %s", path, err))
+		return nil, nil, 2
+	}
+	return client, mount, 0
+}
+
+// kvPath returns the single path argument of a kv subcommand
+func kvPath(ui cli.Ui, name string, args []string) (string, bool) {
+	if len(args) != 1 || len(args[0]) == 0 {
+		ui.Error(fmt.Sprintf("%s expects one argument", name))
+		return "", false
+	}
+	return strings.TrimPrefix(args[0], "/"), true
+}
+
+// kvData parses the key=value arguments of a kv subcommand
+func kvData(stdin io.Reader, args []string) (map[string]interface{}, error) {
+	if stdin == nil {
+		stdin = os.Stdin
+	}
+
+	builder := &kvbuilder.Builder{Stdin: stdin}
+	if err := builder.Add(args...); err != nil {
+		return nil, err
+	}
+	return builder.Map(), nil
+}
+
+// kvVersions parses the comma-separated versions of the -versions flag
+func kvVersions(versions string) ([]int, error) {
+	var result []int
+	for _, v := range strings.Split(versions, ",") {
+		v = strings.TrimSpace(v)
+		if v == "" {
+			continue
+		}
+		version, err := strconv.Atoi(v)
+		if err != nil || version <= 0 {
+			return nil, fmt.Errorf("invalid version %q", v)
+		}
+		result = append(result, version)
+	}
+	if len(result) == 0 {
+		return nil, fmt.Errorf("no versions given")
+	}
+	return result, nil
+}
+
+// kvVersionedOnly outputs an error if the mount isn't versioned
+func kvVersionedOnly(ui cli.Ui, name string, mount *kvMount) bool {
+	if mount.Version == 2 {
+		return true
+	}
+	ui.Error(fmt.Sprintf(
+		"%s is only supported on versioned key/value mounts", name))
+	return false
+}
+
+// kvSecretData returns the data and the metadata of a secret read from the
+// data path of a versioned mount. Both are nil for deleted or destroyed
+// versions.
+func kvSecretData(secret *api.Secret) (map[string]interface{}, map[string]interface{}) {
+	data, _ := secret.Data["data"].(map[string]interface{})
+	metadata, _ := secret.Data["metadata"].(map[string]interface{})
+	return data, metadata
+}
+
+// kvWriteVersions writes the versions of the -versions flag to the delete,
+// undelete or destroy path of a versioned secret
+func kvWriteVersions(ui cli.Ui, client *api.Client, path, versions string) int {
+	parsed, err := kvVersions(versions)
+	if err != nil {
+		ui.Error(fmt.Sprintf("Error parsing -versions: %s", err))
+		return 1
+	}
+
+	data := map[string]interface{}{
+		"versions": parsed,
+	}
+	if _, err := client.Logical().Write(path, data); err != nil {
+		ui.Error(fmt.Sprintf(
+			"Error writing data to %s: %s", path, err))
+		return 1
+	}
+
+	ui.Output(fmt.Sprintf("Success! Data written to: %s", path))
+	return 0
+}
@@ -0,0 +1,82 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/vault/meta"
+)
+
+// KVDeleteCommand is a Command that deletes a key/value secret, or versions
+// of a versioned secret.
+type KVDeleteCommand struct {
+	meta.Meta
+}
+
+func (c *KVDeleteCommand) Run(args []string) int {
+	var versions string
+	flags := c.Meta.FlagSet("kv delete", meta.FlagSetDefault)
+	flags.StringVar(&versions, "versions", "", "")
+	flags.Usage = func() { c.Ui.Error(c.Help()) }
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	path, ok := kvPath(c.Ui, "kv delete", flags.Args())
+	if !ok {
+		flags.Usage()
+		return 1
+	}
+
+	client, mount, code := kvClient(&c.Meta, path)
+	if code != 0 {
+		return code
+	}
+
+	if versions == "" {
+		if mount.Version == 2 {
+			path = mount.versionedPath(path, "data")
+		}
+		if _, err := client.Logical().Delete(path); err != nil {
+			c.Ui.Error(fmt.Sprintf(
+				"Error deleting '%s': %s", path, err))
+			return 1
+		}
+
+		c.Ui.Output(fmt.Sprintf("Success! Deleted '%s' if it existed.", path))
+		return 0
+	}
+
+	if !kvVersionedOnly(c.Ui, "-versions", mount) {
+		return 1
+	}
+	return kvWriteVersions(c.Ui, client, mount.versionedPath(path, "delete"), versions)
+}
+
+func (c *KVDeleteCommand) Synopsis() string {
+	return "Delete a key/value secret or versions of it"
+}
+
+func (c *KVDeleteCommand) Help() string {
+	helpText := `
+Usage: vault kv delete [options] path
+
+  Delete a key/value secret.
+
+  On versioned mounts, this deletes the latest version of the secret, or the
+  versions given with -versions. Deleted versions keep their data, and can
+  be undeleted with "vault kv undelete" until they are destroyed.
+
+      $ vault kv delete secret/foo
+      $ vault kv delete -versions=1,2 secret/foo
+
+General Options:
+` + meta.GeneralOptionsUsage() + `
+KV Delete Options:
+
+  -versions=""            The comma-separated versions of the secret to
+                          delete on versioned mounts.
+
+`
+	return strings.TrimSpace(helpText)
+}
@@ -0,0 +1,69 @@
+package command
+
+import (
+	"strings"
+
+	"github.com/hashicorp/vault/meta"
+)
+
+// KVDestroyCommand is a Command that permanently removes the data of
+// versions of a versioned key/value secret.
+type KVDestroyCommand struct {
+	meta.Meta
+}
+
+func (c *KVDestroyCommand) Run(args []string) int {
+	var versions string
+	flags := c.Meta.FlagSet("kv destroy", meta.FlagSetDefault)
+	flags.StringVar(&versions, "versions", "", "")
+	flags.Usage = func() { c.Ui.Error(c.Help()) }
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	path, ok := kvPath(c.Ui, "kv destroy", flags.Args())
+	if !ok {
+		flags.Usage()
+		return 1
+	}
+	if versions == "" {
+		c.Ui.Error("kv destroy requires -versions")
+		flags.Usage()
+		return 1
+	}
+
+	client, mount, code := kvClient(&c.Meta, path)
+	if code != 0 {
+		return code
+	}
+	if !kvVersionedOnly(c.Ui, "kv destroy", mount) {
+		return 1
+	}
+
+	return kvWriteVersions(c.Ui, client, mount.versionedPath(path, "destroy"), versions)
+}
+
+func (c *KVDestroyCommand) Synopsis() string {
+	return "Permanently remove versions of a versioned key/value secret"
+}
+
+func (c *KVDestroyCommand) Help() string {
+	helpText := `
+Usage: vault kv destroy [options] path
+
+  Permanently remove the data of versions of a secret on a versioned
+  key/value mount. Destroyed versions can't be undeleted; their metadata is
+  kept until the metadata of the secret is deleted.
+
+      $ vault kv destroy -versions=1,2 secret/foo
+
+General Options:
+` + meta.GeneralOptionsUsage() + `
+KV Destroy Options:
+
+  -versions=""            The comma-separated versions of the secret to
+                          destroy. Required.
+
+`
+	return strings.TrimSpace(helpText)
+}
@@ -0,0 +1,149 @@
+package command
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/vault/api"
+	"github.com/hashicorp/vault/meta"
+)
+
+// KVGetCommand is a Command that reads a key/value secret, or a version of a
+// versioned secret.
+type KVGetCommand struct {
+	meta.Meta
+}
+
+func (c *KVGetCommand) Run(args []string) int {
+	var format, field string
+	var version int
+	flags := c.Meta.FlagSet("kv get", meta.FlagSetDefault)
+	flags.StringVar(&format, "format", DefaultFormat(), "")
+	flags.StringVar(&field, "field", "", "")
+	flags.IntVar(&version, "version", 0, "")
+	flags.Usage = func() { c.Ui.Error(c.Help()) }
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	path, ok := kvPath(c.Ui, "kv get", flags.Args())
+	if !ok {
+		flags.Usage()
+		return 1
+	}
+
+	client, mount, code := kvClient(&c.Meta, path)
+	if code != 0 {
+		return code
+	}
+
+	if mount.Version != 2 {
+		if version != 0 {
+			c.Ui.Error("-version is only supported on versioned key/value mounts")
+			return 1
+		}
+
+		secret, err := client.Logical().Read(path)
+		if err != nil {
+			c.Ui.Error(fmt.Sprintf(
+				"Error reading %s: %s", path, err))
+			return 1
+		}
+		if secret == nil {
+			c.Ui.Error(fmt.Sprintf(
+				"No value found at %s", path))
+			return 1
+		}
+		if field != "" {
+			return PrintRawField(c.Ui, secret, field)
+		}
+		return OutputSecret(c.Ui, format, secret)
+	}
+
+	var params map[string][]string
+	if version > 0 {
+		params = map[string][]string{
+			"version": {strconv.Itoa(version)},
+		}
+	}
+
+	dataPath := mount.versionedPath(path, "data")
+	secret, err := client.Logical().ReadWithData(dataPath, params)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf(
+			"Error reading %s: %s", dataPath, err))
+		return 1
+	}
+	if secret == nil {
+		c.Ui.Error(fmt.Sprintf(
+			"No value found at %s", dataPath))
+		return 1
+	}
+
+	data, metadata := kvSecretData(secret)
+	if data == nil {
+		c.Ui.Error(fmt.Sprintf(
+			"No value found at %s; the version may be deleted or destroyed", dataPath))
+		return 1
+	}
+
+	if field != "" {
+		return PrintRawField(c.Ui, &api.Secret{Data: data}, field)
+	}
+
+	// Only the table output separates the metadata from the data, the
+	// other formats output the response as is
+	if strings.ToLower(format) != "table" {
+		return OutputSecret(c.Ui, format, secret)
+	}
+
+	if metadata != nil {
+		c.Ui.Output("====== Metadata ======")
+		if code := OutputSecret(c.Ui, format, &api.Secret{Data: metadata}); code != 0 {
+			return code
+		}
+	}
+	c.Ui.Output("==== Data ====")
+	return OutputSecret(c.Ui, format, &api.Secret{
+		Data:     data,
+		Warnings: secret.Warnings,
+	})
+}
+
+func (c *KVGetCommand) Synopsis() string {
+	return "Read a key/value secret"
+}
+
+func (c *KVGetCommand) Help() string {
+	helpText := `
+Usage: vault kv get [options] path
+
+  Read a key/value secret.
+
+  On versioned mounts, the latest version of the secret is read from the
+  data path of the secret, unless -version is given. The table output shows
+  the metadata of the version separately from its data.
+
+      $ vault kv get secret/foo
+      $ vault kv get -version=2 secret/foo
+
+General Options:
+` + meta.GeneralOptionsUsage() + `
+KV Get Options:
+
+  -format=table           The format for output. By default it is a whitespace-
+                          delimited table. This can also be json or yaml.
+                          Overrides the VAULT_FORMAT environment variable if
+                          set.
+
+  -field=field            If included, the raw value of the specified field
+                          of the secret data will be output raw to stdout,
+                          without a newline. Lists and maps are output as JSON.
+
+  -version=0              The version of the secret to read on versioned
+                          mounts. By default the latest version is read.
+
+`
+	return strings.TrimSpace(helpText)
+}
@@ -0,0 +1,79 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/vault/meta"
+)
+
+// KVListCommand is a Command that lists the key/value secrets under a path.
+type KVListCommand struct {
+	meta.Meta
+}
+
+func (c *KVListCommand) Run(args []string) int {
+	var format string
+	flags := c.Meta.FlagSet("kv list", meta.FlagSetDefault)
+	flags.StringVar(&format, "format", DefaultFormat(), "")
+	flags.Usage = func() { c.Ui.Error(c.Help()) }
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	path, ok := kvPath(c.Ui, "kv list", flags.Args())
+	if !ok {
+		flags.Usage()
+		return 1
+	}
+	if !strings.HasSuffix(path, "/") {
+		path = path + "/"
+	}
+
+	client, mount, code := kvClient(&c.Meta, path)
+	if code != 0 {
+		return code
+	}
+	if mount.Version == 2 {
+		path = mount.versionedPath(path, "metadata")
+	}
+
+	secret, err := client.Logical().List(path)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf(
+			"Error reading %s: %s", path, err))
+		return 1
+	}
+	if secret == nil || secret.Data["keys"] == nil {
+		c.Ui.Error("No entries found")
+		return 0
+	}
+
+	return OutputList(c.Ui, format, secret)
+}
+
+func (c *KVListCommand) Synopsis() string {
+	return "List the key/value secrets under a path"
+}
+
+func (c *KVListCommand) Help() string {
+	helpText := `
+Usage: vault kv list [options] path
+
+  List the key/value secrets under a path. On versioned mounts, the
+  secrets are listed from their metadata path.
+
+      $ vault kv list secret/
+
+General Options:
+` + meta.GeneralOptionsUsage() + `
+KV List Options:
+
+  -format=table           The format for output. By default it is a whitespace-
+                          delimited table. This can also be json or yaml.
+                          Overrides the VAULT_FORMAT environment variable if
+                          set.
+
+`
+	return strings.TrimSpace(helpText)
+}
@@ -0,0 +1,258 @@
+package command
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/vault/meta"
+	"github.com/mitchellh/cli"
+)
+
+// KVMetadataCommand is the parent of the kv metadata subcommands, which only
+// outputs their help.
+type KVMetadataCommand struct {
+	meta.Meta
+}
+
+func (c *KVMetadataCommand) Run(args []string) int {
+	return cli.RunResultHelp
+}
+
+func (c *KVMetadataCommand) Synopsis() string {
+	return "Interact with the metadata of versioned key/value secrets"
+}
+
+func (c *KVMetadataCommand) Help() string {
+	helpText := `
+Usage: vault kv metadata <subcommand> [options] path
+
+  Interact with the metadata of secrets on versioned key/value mounts, which
+  holds the settings of the secret and the state of each of its versions.
+
+      $ vault kv metadata get secret/foo
+      $ vault kv metadata put -max-versions=5 secret/foo
+      $ vault kv metadata delete secret/foo
+
+  Run a subcommand with -help to see its usage and options.
+`
+	return strings.TrimSpace(helpText)
+}
+
+// KVMetadataGetCommand is a Command that reads the metadata of a versioned
+// key/value secret.
+type KVMetadataGetCommand struct {
+	meta.Meta
+}
+
+func (c *KVMetadataGetCommand) Run(args []string) int {
+	var format, field string
+	flags := c.Meta.FlagSet("kv metadata get", meta.FlagSetDefault)
+	flags.StringVar(&format, "format", DefaultFormat(), "")
+	flags.StringVar(&field, "field", "", "")
+	flags.Usage = func() { c.Ui.Error(c.Help()) }
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	path, ok := kvPath(c.Ui, "kv metadata get", flags.Args())
+	if !ok {
+		flags.Usage()
+		return 1
+	}
+
+	client, mount, code := kvClient(&c.Meta, path)
+	if code != 0 {
+		return code
+	}
+	if !kvVersionedOnly(c.Ui, "kv metadata get", mount) {
+		return 1
+	}
+
+	path = mount.versionedPath(path, "metadata")
+	secret, err := client.Logical().Read(path)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf(
+			"Error reading %s: %s", path, err))
+		return 1
+	}
+	if secret == nil {
+		c.Ui.Error(fmt.Sprintf(
+			"No value found at %s", path))
+		return 1
+	}
+
+	if field != "" {
+		return PrintRawField(c.Ui, secret, field)
+	}
+
+	return OutputSecret(c.Ui, format, secret)
+}
+
+func (c *KVMetadataGetCommand) Synopsis() string {
+	return "Read the metadata of a versioned key/value secret"
+}
+
+func (c *KVMetadataGetCommand) Help() string {
+	helpText := `
+Usage: vault kv metadata get [options] path
+
+  Read the metadata of a secret on a versioned key/value mount, including
+  its settings and the creation, deletion and destruction of its versions.
+
+      $ vault kv metadata get secret/foo
+
+General Options:
+` + meta.GeneralOptionsUsage() + `
+KV Metadata Get Options:
+
+  -format=table           The format for output. By default it is a whitespace-
+                          delimited table. This can also be json or yaml.
+                          Overrides the VAULT_FORMAT environment variable if
+                          set.
+
+  -field=field            If included, the raw value of the specified field
+                          will be output raw to stdout, without a newline.
+                          Lists and maps are output as JSON.
+
+`
+	return strings.TrimSpace(helpText)
+}
+
+// KVMetadataPutCommand is a Command that writes the settings of a versioned
+// key/value secret.
+type KVMetadataPutCommand struct {
+	meta.Meta
+}
+
+func (c *KVMetadataPutCommand) Run(args []string) int {
+	var maxVersions int
+	var casRequired bool
+	flags := c.Meta.FlagSet("kv metadata put", meta.FlagSetDefault)
+	flags.IntVar(&maxVersions, "max-versions", 0, "")
+	flags.BoolVar(&casRequired, "cas-required", false, "")
+	flags.Usage = func() { c.Ui.Error(c.Help()) }
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	path, ok := kvPath(c.Ui, "kv metadata put", flags.Args())
+	if !ok {
+		flags.Usage()
+		return 1
+	}
+
+	// Only the settings given are written, so that the others keep their
+	// values
+	data := make(map[string]interface{})
+	flags.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "max-versions":
+			data["max_versions"] = maxVersions
+		case "cas-required":
+			data["cas_required"] = casRequired
+		}
+	})
+
+	client, mount, code := kvClient(&c.Meta, path)
+	if code != 0 {
+		return code
+	}
+	if !kvVersionedOnly(c.Ui, "kv metadata put", mount) {
+		return 1
+	}
+
+	path = mount.versionedPath(path, "metadata")
+	if _, err := client.Logical().Write(path, data); err != nil {
+		c.Ui.Error(fmt.Sprintf(
+			"Error writing data to %s: %s", path, err))
+		return 1
+	}
+
+	c.Ui.Output(fmt.Sprintf("Success! Data written to: %s", path))
+	return 0
+}
+
+func (c *KVMetadataPutCommand) Synopsis() string {
+	return "Write the settings of a versioned key/value secret"
+}
+
+func (c *KVMetadataPutCommand) Help() string {
+	helpText := `
+Usage: vault kv metadata put [options] path
+
+  Write the settings of a secret on a versioned key/value mount. Settings
+  which aren't given keep their values.
+
+      $ vault kv metadata put -max-versions=5 -cas-required secret/foo
+
+General Options:
+` + meta.GeneralOptionsUsage() + `
+KV Metadata Put Options:
+
+  -max-versions=0         The number of versions of the secret to keep. The
+                          oldest versions are removed past it. 0 uses the
+                          setting of the mount.
+
+  -cas-required           Whether writes to the secret must give the
+                          check-and-set version with -cas.
+
+`
+	return strings.TrimSpace(helpText)
+}
+
+// KVMetadataDeleteCommand is a Command that deletes the metadata and all the
+// versions of a versioned key/value secret.
+type KVMetadataDeleteCommand struct {
+	meta.Meta
+}
+
+func (c *KVMetadataDeleteCommand) Run(args []string) int {
+	flags := c.Meta.FlagSet("kv metadata delete", meta.FlagSetDefault)
+	flags.Usage = func() { c.Ui.Error(c.Help()) }
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	path, ok := kvPath(c.Ui, "kv metadata delete", flags.Args())
+	if !ok {
+		flags.Usage()
+		return 1
+	}
+
+	client, mount, code := kvClient(&c.Meta, path)
+	if code != 0 {
+		return code
+	}
+	if !kvVersionedOnly(c.Ui, "kv metadata delete", mount) {
+		return 1
+	}
+
+	path = mount.versionedPath(path, "metadata")
+	if _, err := client.Logical().Delete(path); err != nil {
+		c.Ui.Error(fmt.Sprintf(
+			"Error deleting '%s': %s", path, err))
+		return 1
+	}
+
+	c.Ui.Output(fmt.Sprintf("Success! Deleted '%s' if it existed.", path))
+	return 0
+}
+
+func (c *KVMetadataDeleteCommand) Synopsis() string {
+	return "Delete a versioned key/value secret and all its versions"
+}
+
+func (c *KVMetadataDeleteCommand) Help() string {
+	helpText := `
+Usage: vault kv metadata delete [options] path
+
+  Delete the metadata and all the versions of a secret on a versioned
+  key/value mount, permanently removing their data.
+
+      $ vault kv metadata delete secret/foo
+
+General Options:
+` + meta.GeneralOptionsUsage()
+	return strings.TrimSpace(helpText)
+}
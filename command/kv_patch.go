@@ -0,0 +1,153 @@
+package command
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/hashicorp/vault/meta"
+)
+
+// KVPatchCommand is a Command that updates some of the keys of a key/value
+// secret, keeping its other keys.
+type KVPatchCommand struct {
+	meta.Meta
+
+	// The fields below can be overwritten for tests
+	testStdin io.Reader
+}
+
+func (c *KVPatchCommand) Run(args []string) int {
+	var format, field string
+	flags := c.Meta.FlagSet("kv patch", meta.FlagSetDefault)
+	flags.StringVar(&format, "format", DefaultFormat(), "")
+	flags.StringVar(&field, "field", "", "")
+	flags.Usage = func() { c.Ui.Error(c.Help()) }
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	args = flags.Args()
+	if len(args) < 2 {
+		c.Ui.Error("kv patch expects at least two arguments")
+		flags.Usage()
+		return 1
+	}
+
+	path, ok := kvPath(c.Ui, "kv patch", args[:1])
+	if !ok {
+		flags.Usage()
+		return 1
+	}
+
+	patch, err := kvData(c.testStdin, args[1:])
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf(
+			"Error loading data: %s", err))
+		return 1
+	}
+
+	client, mount, code := kvClient(&c.Meta, path)
+	if code != 0 {
+		return code
+	}
+
+	readPath := path
+	if mount.Version == 2 {
+		readPath = mount.versionedPath(path, "data")
+	}
+
+	secret, err := client.Logical().Read(readPath)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf(
+			"Error reading %s: %s", readPath, err))
+		return 1
+	}
+	if secret == nil {
+		c.Ui.Error(fmt.Sprintf(
+			"No value found at %s", readPath))
+		return 1
+	}
+
+	data := secret.Data
+	var metadata map[string]interface{}
+	if mount.Version == 2 {
+		data, metadata = kvSecretData(secret)
+		if data == nil {
+			c.Ui.Error(fmt.Sprintf(
+				"No value found at %s; the latest version may be deleted or destroyed", readPath))
+			return 1
+		}
+	}
+
+	for k, v := range patch {
+		data[k] = v
+	}
+
+	// The version read is used as the check-and-set version on versioned
+	// mounts, so that concurrent writes aren't lost
+	body := data
+	if mount.Version == 2 {
+		body = map[string]interface{}{
+			"data": data,
+			"options": map[string]interface{}{
+				"cas": metadata["version"],
+			},
+		}
+	}
+
+	secret, err = client.Logical().Write(readPath, body)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf(
+			"Error writing data to %s: %s", readPath, err))
+		return 1
+	}
+
+	if secret == nil {
+		// Don't output anything if people aren't using the "human" output
+		if format == "table" {
+			c.Ui.Output(fmt.Sprintf("Success! Data written to: %s", readPath))
+		}
+		return 0
+	}
+
+	if field != "" {
+		return PrintRawField(c.Ui, secret, field)
+	}
+
+	return OutputSecret(c.Ui, format, secret)
+}
+
+func (c *KVPatchCommand) Synopsis() string {
+	return "Update some of the keys of a key/value secret"
+}
+
+func (c *KVPatchCommand) Help() string {
+	helpText := `
+Usage: vault kv patch [options] path key=value [key=value...]
+
+  Update some of the keys of an existing key/value secret, keeping its other
+  keys.
+
+  The secret is read, merged with the given data and written back. On
+  versioned mounts, the write uses the version read as its check-and-set
+  version, so that it fails instead of overwriting a concurrent write.
+
+      $ vault kv patch secret/foo bar=qux
+
+General Options:
+` + meta.GeneralOptionsUsage() + `
+KV Patch Options:
+
+  -format=table           The format for output. By default it is a whitespace-
+                          delimited table. This can also be json or yaml.
+                          Overrides the VAULT_FORMAT environment variable if
+                          set.
+
+  -field=field            If included, the raw value of the specified field
+                          of the response will be output raw to stdout,
+                          without a newline.
+
+`
+	return strings.TrimSpace(helpText)
+}
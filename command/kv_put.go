@@ -0,0 +1,136 @@
+package command
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/hashicorp/vault/meta"
+)
+
+// KVPutCommand is a Command that writes a key/value secret, creating a new
+// version of versioned secrets.
+type KVPutCommand struct {
+	meta.Meta
+
+	// The fields below can be overwritten for tests
+	testStdin io.Reader
+}
+
+func (c *KVPutCommand) Run(args []string) int {
+	var format, field string
+	var cas int
+	flags := c.Meta.FlagSet("kv put", meta.FlagSetDefault)
+	flags.StringVar(&format, "format", DefaultFormat(), "")
+	flags.StringVar(&field, "field", "", "")
+	flags.IntVar(&cas, "cas", -1, "")
+	flags.Usage = func() { c.Ui.Error(c.Help()) }
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	args = flags.Args()
+	if len(args) < 2 {
+		c.Ui.Error("kv put expects at least two arguments")
+		flags.Usage()
+		return 1
+	}
+
+	path, ok := kvPath(c.Ui, "kv put", args[:1])
+	if !ok {
+		flags.Usage()
+		return 1
+	}
+
+	data, err := kvData(c.testStdin, args[1:])
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf(
+			"Error loading data: %s", err))
+		return 1
+	}
+
+	client, mount, code := kvClient(&c.Meta, path)
+	if code != 0 {
+		return code
+	}
+
+	if mount.Version != 2 {
+		if cas >= 0 {
+			c.Ui.Error("-cas is only supported on versioned key/value mounts")
+			return 1
+		}
+	} else {
+		body := map[string]interface{}{
+			"data": data,
+		}
+		if cas >= 0 {
+			body["options"] = map[string]interface{}{
+				"cas": cas,
+			}
+		}
+		path = mount.versionedPath(path, "data")
+		data = body
+	}
+
+	secret, err := client.Logical().Write(path, data)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf(
+			"Error writing data to %s: %s", path, err))
+		return 1
+	}
+
+	if secret == nil {
+		// Don't output anything if people aren't using the "human" output
+		if format == "table" {
+			c.Ui.Output(fmt.Sprintf("Success! Data written to: %s", path))
+		}
+		return 0
+	}
+
+	if field != "" {
+		return PrintRawField(c.Ui, secret, field)
+	}
+
+	return OutputSecret(c.Ui, format, secret)
+}
+
+func (c *KVPutCommand) Synopsis() string {
+	return "Write a key/value secret"
+}
+
+func (c *KVPutCommand) Help() string {
+	helpText := `
+Usage: vault kv put [options] path key=value [key=value...]
+
+  Write a key/value secret, replacing its data.
+
+  On versioned mounts, this creates a new version of the secret and outputs
+  its metadata. The data is given in the same way as with "vault write",
+  including "@file" values and "-" to read JSON from stdin.
+
+      $ vault kv put secret/foo bar=baz
+      $ vault kv put -cas=1 secret/foo bar=qux
+
+General Options:
+` + meta.GeneralOptionsUsage() + `
+KV Put Options:
+
+  -format=table           The format for output. By default it is a whitespace-
+                          delimited table. This can also be json or yaml.
+                          Overrides the VAULT_FORMAT environment variable if
+                          set.
+
+  -field=field            If included, the raw value of the specified field
+                          of the response will be output raw to stdout,
+                          without a newline.
+
+  -cas=-1                 Check-and-set version on versioned mounts. The
+                          write only succeeds if the current version of the
+                          secret is the given one, and 0 only allows the
+                          write if the secret doesn't exist. By default the
+                          write is unconditional, unless the mount requires
+                          check-and-set.
+
+`
+	return strings.TrimSpace(helpText)
+}
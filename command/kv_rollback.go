@@ -0,0 +1,134 @@
+package command
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/vault/meta"
+)
+
+// KVRollbackCommand is a Command that writes the data of a previous version
+// of a versioned key/value secret as its new version.
+type KVRollbackCommand struct {
+	meta.Meta
+}
+
+func (c *KVRollbackCommand) Run(args []string) int {
+	var format string
+	var version int
+	flags := c.Meta.FlagSet("kv rollback", meta.FlagSetDefault)
+	flags.StringVar(&format, "format", DefaultFormat(), "")
+	flags.IntVar(&version, "version", 0, "")
+	flags.Usage = func() { c.Ui.Error(c.Help()) }
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	path, ok := kvPath(c.Ui, "kv rollback", flags.Args())
+	if !ok {
+		flags.Usage()
+		return 1
+	}
+	if version <= 0 {
+		c.Ui.Error("kv rollback requires a positive -version")
+		flags.Usage()
+		return 1
+	}
+
+	client, mount, code := kvClient(&c.Meta, path)
+	if code != 0 {
+		return code
+	}
+	if !kvVersionedOnly(c.Ui, "kv rollback", mount) {
+		return 1
+	}
+
+	// The current version is used as the check-and-set version, so that a
+	// concurrent write isn't overwritten by the rollback
+	metadataPath := mount.versionedPath(path, "metadata")
+	secret, err := client.Logical().Read(metadataPath)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf(
+			"Error reading %s: %s", metadataPath, err))
+		return 1
+	}
+	if secret == nil {
+		c.Ui.Error(fmt.Sprintf(
+			"No value found at %s", metadataPath))
+		return 1
+	}
+	current := secret.Data["current_version"]
+
+	dataPath := mount.versionedPath(path, "data")
+	secret, err = client.Logical().ReadWithData(dataPath, map[string][]string{
+		"version": {strconv.Itoa(version)},
+	})
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf(
+			"Error reading %s: %s", dataPath, err))
+		return 1
+	}
+	var data map[string]interface{}
+	if secret != nil {
+		data, _ = kvSecretData(secret)
+	}
+	if data == nil {
+		c.Ui.Error(fmt.Sprintf(
+			"Version %d of %s doesn't exist, or is deleted or destroyed", version, path))
+		return 1
+	}
+
+	secret, err = client.Logical().Write(dataPath, map[string]interface{}{
+		"data": data,
+		"options": map[string]interface{}{
+			"cas": current,
+		},
+	})
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf(
+			"Error writing data to %s: %s", dataPath, err))
+		return 1
+	}
+
+	if secret == nil {
+		// Don't output anything if people aren't using the "human" output
+		if format == "table" {
+			c.Ui.Output(fmt.Sprintf("Success! Data written to: %s", dataPath))
+		}
+		return 0
+	}
+
+	return OutputSecret(c.Ui, format, secret)
+}
+
+func (c *KVRollbackCommand) Synopsis() string {
+	return "Roll back a versioned key/value secret to a previous version"
+}
+
+func (c *KVRollbackCommand) Help() string {
+	helpText := `
+Usage: vault kv rollback [options] path
+
+  Roll back a secret on a versioned key/value mount to a previous version.
+
+  The data of the given version is written as a new version of the secret,
+  so the versions in between are kept. The rollback fails if the secret is
+  written concurrently, or if the version is deleted or destroyed.
+
+      $ vault kv rollback -version=2 secret/foo
+
+General Options:
+` + meta.GeneralOptionsUsage() + `
+KV Rollback Options:
+
+  -format=table           The format for output. By default it is a whitespace-
+                          delimited table. This can also be json or yaml.
+                          Overrides the VAULT_FORMAT environment variable if
+                          set.
+
+  -version=0              The version of the secret to roll back to. Required.
+
+`
+	return strings.TrimSpace(helpText)
+}
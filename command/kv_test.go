@@ -0,0 +1,313 @@
+package command
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	vaulthttp "github.com/hashicorp/vault/http"
+	"github.com/hashicorp/vault/meta"
+	"github.com/hashicorp/vault/vault"
+	"github.com/mitchellh/cli"
+)
+
+func TestKV_unversioned(t *testing.T) {
+	core, _, token := vault.TestCoreUnsealed(t)
+	ln, addr := vaulthttp.TestServer(t, core)
+	defer ln.Close()
+
+	run := func(c cli.Command, ui *cli.MockUi, args ...string) {
+		args = append([]string{"-address", addr}, args...)
+		if code := c.Run(args); code != 0 {
+			t.Fatalf("%v: bad: %d\n\n%s", args, code, ui.ErrorWriter.String())
+		}
+	}
+	newMeta := func() (meta.Meta, *cli.MockUi) {
+		ui := new(cli.MockUi)
+		return meta.Meta{ClientToken: token, Ui: ui}, ui
+	}
+
+	m, ui := newMeta()
+	run(&KVPutCommand{Meta: m}, ui, "secret/foo", "a=b", "c=d")
+
+	m, ui = newMeta()
+	patch := &KVPatchCommand{Meta: m}
+	run(patch, ui, "secret/foo", "c=e")
+
+	client, err := patch.Client()
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	secret, err := client.Logical().Read("secret/foo")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	expected := map[string]interface{}{"a": "b", "c": "e"}
+	if !reflect.DeepEqual(secret.Data, expected) {
+		t.Fatalf("bad: %#v", secret.Data)
+	}
+
+	m, ui = newMeta()
+	run(&KVGetCommand{Meta: m}, ui, "-field", "c", "secret/foo")
+	if out := ui.OutputWriter.String(); out != "e\n" {
+		t.Fatalf("bad: %q", out)
+	}
+
+	m, ui = newMeta()
+	run(&KVListCommand{Meta: m}, ui, "secret")
+	if !strings.Contains(ui.OutputWriter.String(), "foo") {
+		t.Fatalf("bad: %s", ui.OutputWriter.String())
+	}
+
+	// Versions are only supported on versioned mounts
+	m, ui = newMeta()
+	c := &KVDestroyCommand{Meta: m}
+	if code := c.Run([]string{"-address", addr, "-versions", "1", "secret/foo"}); code != 1 {
+		t.Fatalf("bad: %d", code)
+	}
+	if !strings.Contains(ui.ErrorWriter.String(), "only supported on versioned") {
+		t.Fatalf("bad: %s", ui.ErrorWriter.String())
+	}
+
+	m, ui = newMeta()
+	run(&KVDeleteCommand{Meta: m}, ui, "secret/foo")
+	secret, err = client.Logical().Read("secret/foo")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if secret != nil {
+		t.Fatalf("bad: %#v", secret)
+	}
+}
+
+// kvTestRequest is a request received by the versioned mount of a
+// kvTestServer
+type kvTestRequest struct {
+	Method string
+	Path   string
+	Query  string
+	Body   map[string]interface{}
+}
+
+// kvTestServer fakes a server with a versioned mount at secret/, holding
+// version 3 of secret/foo
+func kvTestServer(t *testing.T) (*httptest.Server, *[]kvTestRequest) {
+	var l sync.Mutex
+	var requests []kvTestRequest
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/v1/sys/internal/ui/mounts/") {
+			fmt.Fprint(w, `{"data":{"path":"secret/","type":"kv","options":{"version":"2"}}}`)
+			return
+		}
+
+		req := kvTestRequest{
+			Method: r.Method,
+			Path:   strings.TrimPrefix(r.URL.Path, "/v1/"),
+			Query:  r.URL.RawQuery,
+		}
+		if r.ContentLength > 0 {
+			if err := json.NewDecoder(r.Body).Decode(&req.Body); err != nil {
+				t.Fatalf("err: %s", err)
+			}
+		}
+		l.Lock()
+		requests = append(requests, req)
+		l.Unlock()
+
+		switch {
+		case r.Method == "GET" && req.Path == "secret/data/foo":
+			fmt.Fprint(w, `{"data":{"data":{"a":"b"},"metadata":{"version":3}}}`)
+		case r.Method == "GET" && req.Path == "secret/metadata/foo":
+			fmt.Fprint(w, `{"data":{"current_version":3,"max_versions":0}}`)
+		case r.Method == "PUT" && req.Path == "secret/data/foo":
+			fmt.Fprint(w, `{"data":{"version":4}}`)
+		case r.Method == "GET" && req.Query == "list=true":
+			w.WriteHeader(http.StatusNotFound)
+		default:
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	return ts, &requests
+}
+
+func TestKV_versioned(t *testing.T) {
+	cases := []struct {
+		Name     string
+		Command  func(meta.Meta) cli.Command
+		Args     []string
+		Requests []kvTestRequest
+	}{
+		{
+			"get",
+			func(m meta.Meta) cli.Command { return &KVGetCommand{Meta: m} },
+			[]string{"-version", "2", "secret/foo"},
+			[]kvTestRequest{
+				{Method: "GET", Path: "secret/data/foo", Query: "version=2"},
+			},
+		},
+		{
+			"put",
+			func(m meta.Meta) cli.Command { return &KVPutCommand{Meta: m} },
+			[]string{"-cas", "0", "secret/foo", "a=b"},
+			[]kvTestRequest{
+				{Method: "PUT", Path: "secret/data/foo", Body: map[string]interface{}{
+					"data":    map[string]interface{}{"a": "b"},
+					"options": map[string]interface{}{"cas": float64(0)},
+				}},
+			},
+		},
+		{
+			"patch",
+			func(m meta.Meta) cli.Command { return &KVPatchCommand{Meta: m} },
+			[]string{"secret/foo", "c=d"},
+			[]kvTestRequest{
+				{Method: "GET", Path: "secret/data/foo"},
+				{Method: "PUT", Path: "secret/data/foo", Body: map[string]interface{}{
+					"data":    map[string]interface{}{"a": "b", "c": "d"},
+					"options": map[string]interface{}{"cas": float64(3)},
+				}},
+			},
+		},
+		{
+			"delete",
+			func(m meta.Meta) cli.Command { return &KVDeleteCommand{Meta: m} },
+			[]string{"secret/foo"},
+			[]kvTestRequest{
+				{Method: "DELETE", Path: "secret/data/foo"},
+			},
+		},
+		{
+			"delete versions",
+			func(m meta.Meta) cli.Command { return &KVDeleteCommand{Meta: m} },
+			[]string{"-versions", "1,2", "secret/foo"},
+			[]kvTestRequest{
+				{Method: "PUT", Path: "secret/delete/foo", Body: map[string]interface{}{
+					"versions": []interface{}{float64(1), float64(2)},
+				}},
+			},
+		},
+		{
+			"undelete",
+			func(m meta.Meta) cli.Command { return &KVUndeleteCommand{Meta: m} },
+			[]string{"-versions", "2", "secret/foo"},
+			[]kvTestRequest{
+				{Method: "PUT", Path: "secret/undelete/foo", Body: map[string]interface{}{
+					"versions": []interface{}{float64(2)},
+				}},
+			},
+		},
+		{
+			"destroy",
+			func(m meta.Meta) cli.Command { return &KVDestroyCommand{Meta: m} },
+			[]string{"-versions", "2", "secret/foo"},
+			[]kvTestRequest{
+				{Method: "PUT", Path: "secret/destroy/foo", Body: map[string]interface{}{
+					"versions": []interface{}{float64(2)},
+				}},
+			},
+		},
+		{
+			"rollback",
+			func(m meta.Meta) cli.Command { return &KVRollbackCommand{Meta: m} },
+			[]string{"-version", "1", "secret/foo"},
+			[]kvTestRequest{
+				{Method: "GET", Path: "secret/metadata/foo"},
+				{Method: "GET", Path: "secret/data/foo", Query: "version=1"},
+				{Method: "PUT", Path: "secret/data/foo", Body: map[string]interface{}{
+					"data":    map[string]interface{}{"a": "b"},
+					"options": map[string]interface{}{"cas": float64(3)},
+				}},
+			},
+		},
+		{
+			"list",
+			func(m meta.Meta) cli.Command { return &KVListCommand{Meta: m} },
+			[]string{"secret/"},
+			[]kvTestRequest{
+				{Method: "GET", Path: "secret/metadata/", Query: "list=true"},
+			},
+		},
+		{
+			"metadata get",
+			func(m meta.Meta) cli.Command { return &KVMetadataGetCommand{Meta: m} },
+			[]string{"secret/foo"},
+			[]kvTestRequest{
+				{Method: "GET", Path: "secret/metadata/foo"},
+			},
+		},
+		{
+			"metadata put",
+			func(m meta.Meta) cli.Command { return &KVMetadataPutCommand{Meta: m} },
+			[]string{"-max-versions", "5", "secret/foo"},
+			[]kvTestRequest{
+				{Method: "PUT", Path: "secret/metadata/foo", Body: map[string]interface{}{
+					"max_versions": float64(5),
+				}},
+			},
+		},
+		{
+			"metadata delete",
+			func(m meta.Meta) cli.Command { return &KVMetadataDeleteCommand{Meta: m} },
+			[]string{"secret/foo"},
+			[]kvTestRequest{
+				{Method: "DELETE", Path: "secret/metadata/foo"},
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		ts, requests := kvTestServer(t)
+
+		ui := new(cli.MockUi)
+		c := tc.Command(meta.Meta{ClientToken: "foo", Ui: ui})
+		args := append([]string{"-address", ts.URL}, tc.Args...)
+		code := c.Run(args)
+		ts.Close()
+		if code != 0 {
+			t.Fatalf("%s: bad: %d\n\n%s", tc.Name, code, ui.ErrorWriter.String())
+		}
+		if !reflect.DeepEqual(*requests, tc.Requests) {
+			t.Fatalf("%s: bad: %#v", tc.Name, *requests)
+		}
+	}
+}
+
+func TestKVGet_versionedTable(t *testing.T) {
+	ts, _ := kvTestServer(t)
+	defer ts.Close()
+
+	ui := new(cli.MockUi)
+	c := &KVGetCommand{Meta: meta.Meta{ClientToken: "foo", Ui: ui}}
+	if code := c.Run([]string{"-address", ts.URL, "-format", "table", "secret/foo"}); code != 0 {
+		t.Fatalf("bad: %d\n\n%s", code, ui.ErrorWriter.String())
+	}
+
+	out := ui.OutputWriter.String()
+	for _, s := range []string{"Metadata", "version", "Data", "a"} {
+		if !strings.Contains(out, s) {
+			t.Fatalf("missing %q: %s", s, out)
+		}
+	}
+}
+
+func TestKVVersions(t *testing.T) {
+	versions, err := kvVersions("1, 2,,3")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if !reflect.DeepEqual(versions, []int{1, 2, 3}) {
+		t.Fatalf("bad: %#v", versions)
+	}
+
+	for _, v := range []string{"", "a", "0", "1,-2"} {
+		if _, err := kvVersions(v); err == nil {
+			t.Fatalf("%q: expected error", v)
+		}
+	}
+}
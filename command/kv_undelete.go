@@ -0,0 +1,68 @@
+package command
+
+import (
+	"strings"
+
+	"github.com/hashicorp/vault/meta"
+)
+
+// KVUndeleteCommand is a Command that undeletes versions of a versioned
+// key/value secret.
+type KVUndeleteCommand struct {
+	meta.Meta
+}
+
+func (c *KVUndeleteCommand) Run(args []string) int {
+	var versions string
+	flags := c.Meta.FlagSet("kv undelete", meta.FlagSetDefault)
+	flags.StringVar(&versions, "versions", "", "")
+	flags.Usage = func() { c.Ui.Error(c.Help()) }
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	path, ok := kvPath(c.Ui, "kv undelete", flags.Args())
+	if !ok {
+		flags.Usage()
+		return 1
+	}
+	if versions == "" {
+		c.Ui.Error("kv undelete requires -versions")
+		flags.Usage()
+		return 1
+	}
+
+	client, mount, code := kvClient(&c.Meta, path)
+	if code != 0 {
+		return code
+	}
+	if !kvVersionedOnly(c.Ui, "kv undelete", mount) {
+		return 1
+	}
+
+	return kvWriteVersions(c.Ui, client, mount.versionedPath(path, "undelete"), versions)
+}
+
+func (c *KVUndeleteCommand) Synopsis() string {
+	return "Undelete versions of a versioned key/value secret"
+}
+
+func (c *KVUndeleteCommand) Help() string {
+	helpText := `
+Usage: vault kv undelete [options] path
+
+  Undelete versions of a secret on a versioned key/value mount, restoring
+  the data of deleted versions which weren't destroyed.
+
+      $ vault kv undelete -versions=2 secret/foo
+
+General Options:
+` + meta.GeneralOptionsUsage() + `
+KV Undelete Options:
+
+  -versions=""            The comma-separated versions of the secret to
+                          undelete. Required.
+
+`
+	return strings.TrimSpace(helpText)
+}
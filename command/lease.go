@@ -0,0 +1,38 @@
+package command
+
+import (
+	"strings"
+
+	"github.com/hashicorp/vault/meta"
+	"github.com/mitchellh/cli"
+)
+
+// LeaseCommand is the parent of the lease subcommands, which only outputs
+// their help.
+type LeaseCommand struct {
+	meta.Meta
+}
+
+func (c *LeaseCommand) Run(args []string) int {
+	return cli.RunResultHelp
+}
+
+func (c *LeaseCommand) Synopsis() string {
+	return "Interact with leases"
+}
+
+func (c *LeaseCommand) Help() string {
+	helpText := `
+Usage: vault lease <subcommand> [options] [args]
+
+  Interact with the leases of secrets. Every dynamic secret has a lease
+  which is revoked once it expires, unless it is renewed.
+
+      $ vault lease lookup database/creds/readonly/2f6a614c...
+      $ vault lease renew -increment=1h database/creds/readonly/2f6a614c...
+      $ vault lease revoke -prefix database/creds/readonly
+
+  Run a subcommand with -help to see its usage and options.
+`
+	return strings.TrimSpace(helpText)
+}
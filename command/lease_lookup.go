@@ -0,0 +1,104 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/vault/meta"
+)
+
+// LeaseLookupCommand is a Command that outputs the metadata of a lease, or
+// lists the leases with a prefix.
+type LeaseLookupCommand struct {
+	meta.Meta
+}
+
+func (c *LeaseLookupCommand) Run(args []string) int {
+	var format string
+	var prefix bool
+	flags := c.Meta.FlagSet("lease lookup", meta.FlagSetDefault)
+	flags.StringVar(&format, "format", DefaultFormat(), "")
+	flags.BoolVar(&prefix, "prefix", false, "")
+	flags.Usage = func() { c.Ui.Error(c.Help()) }
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	args = flags.Args()
+	if len(args) != 1 {
+		flags.Usage()
+		c.Ui.Error(fmt.Sprintf(
+			"\nlease lookup expects one argument: the lease ID or prefix to look up"))
+		return 1
+	}
+	leaseID := strings.TrimSpace(args[0])
+
+	client, err := c.Client()
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf(
+			"Error initializing client: %s", err))
+		return 2
+	}
+
+	if prefix {
+		if !strings.HasSuffix(leaseID, "/") {
+			leaseID = leaseID + "/"
+		}
+		secret, err := client.Sys().ListLeases(leaseID)
+		if err != nil {
+			c.Ui.Error(fmt.Sprintf(
+				"Error listing the leases of %s: %s", leaseID, err))
+			return 1
+		}
+		if secret == nil || secret.Data["keys"] == nil {
+			c.Ui.Error("No leases found")
+			return 0
+		}
+		return OutputList(c.Ui, format, secret)
+	}
+
+	secret, err := client.Sys().LookupLease(leaseID)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf(
+			"Error looking up %s: %s", leaseID, err))
+		return 1
+	}
+	if secret == nil {
+		c.Ui.Error(fmt.Sprintf("No lease found for %s", leaseID))
+		return 1
+	}
+
+	return OutputSecret(c.Ui, format, secret)
+}
+
+func (c *LeaseLookupCommand) Synopsis() string {
+	return "Look up the metadata of a lease"
+}
+
+func (c *LeaseLookupCommand) Help() string {
+	helpText := `
+Usage: vault lease lookup [options] id
+
+  Output the metadata of a lease: when it was issued, last renewed and
+  expires, its remaining TTL in seconds, and whether it can be renewed.
+
+      $ vault lease lookup database/creds/readonly/2f6a614c...
+
+  With the -prefix flag, the leases and the sub-prefixes under the given
+  prefix are listed instead.
+
+      $ vault lease lookup -prefix database/creds/readonly
+
+General Options:
+` + meta.GeneralOptionsUsage() + `
+Lease Lookup Options:
+
+  -prefix=false           List the leases under the given prefix.
+
+  -format=table           The format for output. By default it is a whitespace-
+                          delimited table. This can also be json or yaml.
+                          Overrides the VAULT_FORMAT environment variable if
+                          set.
+`
+	return strings.TrimSpace(helpText)
+}
@@ -0,0 +1,97 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/vault/helper/duration"
+	"github.com/hashicorp/vault/meta"
+)
+
+// LeaseRenewCommand is a Command that renews the lease of a secret.
+type LeaseRenewCommand struct {
+	meta.Meta
+}
+
+func (c *LeaseRenewCommand) Run(args []string) int {
+	var format, increment string
+	flags := c.Meta.FlagSet("lease renew", meta.FlagSetDefault)
+	flags.StringVar(&format, "format", DefaultFormat(), "")
+	flags.StringVar(&increment, "increment", "", "")
+	flags.Usage = func() { c.Ui.Error(c.Help()) }
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	args = flags.Args()
+	if len(args) != 1 {
+		flags.Usage()
+		c.Ui.Error(fmt.Sprintf(
+			"\nlease renew expects one argument: the lease ID to renew"))
+		return 1
+	}
+	leaseID := strings.TrimSpace(args[0])
+
+	var inc int
+	if increment != "" {
+		dur, err := duration.ParseDurationSecond(increment)
+		if err != nil {
+			c.Ui.Error(fmt.Sprintf("Invalid increment: %s", err))
+			return 1
+		}
+		inc = int(dur / time.Second)
+	}
+
+	client, err := c.Client()
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf(
+			"Error initializing client: %s", err))
+		return 2
+	}
+
+	secret, err := client.Sys().Renew(leaseID, inc)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf(
+			"Error renewing %s: %s", leaseID, err))
+		return 1
+	}
+	if secret == nil {
+		c.Ui.Error(fmt.Sprintf("No lease found for %s", leaseID))
+		return 1
+	}
+
+	return OutputSecret(c.Ui, format, secret)
+}
+
+func (c *LeaseRenewCommand) Synopsis() string {
+	return "Renew the lease of a secret"
+}
+
+func (c *LeaseRenewCommand) Help() string {
+	helpText := `
+Usage: vault lease renew [options] id
+
+  Renew the lease of a secret, extending the time it can be used before it
+  is revoked by Vault. Renewing the lease doesn't change the secret.
+
+  The lease is renewed by its default TTL, unless a specific increment is
+  requested. Vault is not required to honor the increment, and the lease
+  can't be renewed past its maximum TTL. The new lease duration is output.
+
+      $ vault lease renew -increment=1h database/creds/readonly/2f6a614c...
+
+General Options:
+` + meta.GeneralOptionsUsage() + `
+Lease Renew Options:
+
+  -increment=<duration>   The requested increment of the lease, such as "1h"
+                          or "3600" seconds.
+
+  -format=table           The format for output. By default it is a whitespace-
+                          delimited table. This can also be json or yaml.
+                          Overrides the VAULT_FORMAT environment variable if
+                          set.
+`
+	return strings.TrimSpace(helpText)
+}
@@ -0,0 +1,109 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/vault/meta"
+)
+
+// LeaseRevokeCommand is a Command that revokes the lease of a secret, or all
+// of the leases with a prefix.
+type LeaseRevokeCommand struct {
+	meta.Meta
+}
+
+func (c *LeaseRevokeCommand) Run(args []string) int {
+	var prefix, force bool
+	flags := c.Meta.FlagSet("lease revoke", meta.FlagSetDefault)
+	flags.BoolVar(&prefix, "prefix", false, "")
+	flags.BoolVar(&force, "force", false, "")
+	flags.Usage = func() { c.Ui.Error(c.Help()) }
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	args = flags.Args()
+	if len(args) != 1 {
+		flags.Usage()
+		c.Ui.Error(fmt.Sprintf(
+			"\nlease revoke expects one argument: the lease ID or prefix to revoke"))
+		return 1
+	}
+	leaseID := strings.TrimSpace(args[0])
+
+	if force && !prefix {
+		c.Ui.Error("-force requires -prefix")
+		return 1
+	}
+
+	// Prefixes are matched up to a slash by the server, which rejects
+	// trailing slashes
+	if prefix {
+		leaseID = strings.TrimSuffix(leaseID, "/")
+	}
+
+	client, err := c.Client()
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf(
+			"Error initializing client: %s", err))
+		return 2
+	}
+
+	switch {
+	case force:
+		err = client.Sys().RevokeForce(leaseID)
+	case prefix:
+		err = client.Sys().RevokePrefix(leaseID)
+	default:
+		err = client.Sys().Revoke(leaseID)
+	}
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf(
+			"Error revoking %s: %s", leaseID, err))
+		return 1
+	}
+
+	switch {
+	case force:
+		c.Ui.Output(fmt.Sprintf("Success! Force revoked the leases with prefix '%s'.", leaseID))
+	case prefix:
+		c.Ui.Output(fmt.Sprintf("Success! Revoked the leases with prefix '%s'.", leaseID))
+	default:
+		c.Ui.Output(fmt.Sprintf("Success! Revoked the lease '%s', if it existed.", leaseID))
+	}
+	return 0
+}
+
+func (c *LeaseRevokeCommand) Synopsis() string {
+	return "Revoke the lease of a secret"
+}
+
+func (c *LeaseRevokeCommand) Help() string {
+	helpText := `
+Usage: vault lease revoke [options] id
+
+  Revoke the lease of a secret, which invalidates the secret.
+
+  With the -prefix flag, all of the leases with the given prefix are
+  revoked. Lease IDs start with the path the secret was read from, so all
+  of the secrets of a role or of a mount can be revoked at once.
+
+      $ vault lease revoke -prefix database/creds/readonly
+
+  With the -force flag, the leases are removed from Vault even if their
+  revocation fails, for example because the credentials were already
+  removed from the database. This is meant for recovery scenarios and
+  should not be used lightly. This flag requires -prefix.
+
+General Options:
+` + meta.GeneralOptionsUsage() + `
+Lease Revoke Options:
+
+  -prefix=false           Revoke all of the leases with the given prefix.
+
+  -force=false            Remove the leases even if their revocation fails.
+                          Requires -prefix.
+`
+	return strings.TrimSpace(helpText)
+}
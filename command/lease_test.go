@@ -0,0 +1,138 @@
+package command
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/vault/http"
+	"github.com/hashicorp/vault/meta"
+	"github.com/hashicorp/vault/vault"
+	"github.com/mitchellh/cli"
+)
+
+func testLeaseSecret(t *testing.T, addr, token string) string {
+	client := testClient(t, addr, token)
+	_, err := client.Logical().Write("secret/foo", map[string]interface{}{
+		"key": "value",
+		"ttl": "1m",
+	})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	secret, err := client.Logical().Read("secret/foo")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	return secret.LeaseID
+}
+
+func TestLeaseRenew(t *testing.T) {
+	core, _, token := vault.TestCoreUnsealed(t)
+	ln, addr := http.TestServer(t, core)
+	defer ln.Close()
+
+	leaseID := testLeaseSecret(t, addr, token)
+
+	ui := new(cli.MockUi)
+	c := &LeaseRenewCommand{
+		Meta: meta.Meta{
+			ClientToken: token,
+			Ui:          ui,
+		},
+	}
+
+	args := []string{
+		"-address", addr,
+		"-increment", "1h",
+		leaseID,
+	}
+	if code := c.Run(args); code != 0 {
+		t.Fatalf("bad: %d\n\n%s", code, ui.ErrorWriter.String())
+	}
+
+	output := ui.OutputWriter.String()
+	if !strings.Contains(output, leaseID) || !strings.Contains(output, "lease_renewable") {
+		t.Fatalf("bad: %s", output)
+	}
+
+	// The increment must be a duration
+	ui = new(cli.MockUi)
+	c.Meta.Ui = ui
+	if code := c.Run([]string{"-address", addr, "-increment", "nope", leaseID}); code != 1 {
+		t.Fatalf("bad: %d", code)
+	}
+}
+
+func TestLeaseLookup(t *testing.T) {
+	core, _, token := vault.TestCoreUnsealed(t)
+	ln, addr := http.TestServer(t, core)
+	defer ln.Close()
+
+	leaseID := testLeaseSecret(t, addr, token)
+
+	ui := new(cli.MockUi)
+	c := &LeaseLookupCommand{
+		Meta: meta.Meta{
+			ClientToken: token,
+			Ui:          ui,
+		},
+	}
+
+	if code := c.Run([]string{"-address", addr, leaseID}); code != 0 {
+		t.Fatalf("bad: %d\n\n%s", code, ui.ErrorWriter.String())
+	}
+	output := ui.OutputWriter.String()
+	for _, expected := range []string{"expire_time", "issue_time", "renewable", "ttl"} {
+		if !strings.Contains(output, expected) {
+			t.Fatalf("bad: %s", output)
+		}
+	}
+
+	// The leases are listed by prefix
+	ui = new(cli.MockUi)
+	c.Meta.Ui = ui
+	if code := c.Run([]string{"-address", addr, "-prefix", "secret/foo"}); code != 0 {
+		t.Fatalf("bad: %d\n\n%s", code, ui.ErrorWriter.String())
+	}
+	output = ui.OutputWriter.String()
+	if !strings.Contains(output, strings.TrimPrefix(leaseID, "secret/foo/")) {
+		t.Fatalf("bad: %s", output)
+	}
+}
+
+func TestLeaseRevoke(t *testing.T) {
+	core, _, token := vault.TestCoreUnsealed(t)
+	ln, addr := http.TestServer(t, core)
+	defer ln.Close()
+
+	client := testClient(t, addr, token)
+	ui := new(cli.MockUi)
+	c := &LeaseRevokeCommand{
+		Meta: meta.Meta{
+			ClientToken: token,
+			Ui:          ui,
+		},
+	}
+
+	leaseID := testLeaseSecret(t, addr, token)
+	if code := c.Run([]string{"-address", addr, leaseID}); code != 0 {
+		t.Fatalf("bad: %d\n\n%s", code, ui.ErrorWriter.String())
+	}
+	if _, err := client.Sys().LookupLease(leaseID); err == nil {
+		t.Fatal("lease not revoked")
+	}
+
+	leaseID = testLeaseSecret(t, addr, token)
+	if code := c.Run([]string{"-address", addr, "-prefix", "secret/"}); code != 0 {
+		t.Fatalf("bad: %d\n\n%s", code, ui.ErrorWriter.String())
+	}
+	if _, err := client.Sys().LookupLease(leaseID); err == nil {
+		t.Fatal("lease not revoked")
+	}
+
+	// -force requires -prefix
+	if code := c.Run([]string{"-address", addr, "-force", leaseID}); code != 1 {
+		t.Fatalf("bad: %d", code)
+	}
+}
@@ -20,7 +20,7 @@ func (c *ListCommand) Run(args []string) int {
 	var secret *api.Secret
 	var flags *flag.FlagSet
 	flags = c.Meta.FlagSet("list", meta.FlagSetDefault)
-	flags.StringVar(&format, "format", "table", "")
+	flags.StringVar(&format, "format", DefaultFormat(), "")
 	flags.Usage = func() { c.Ui.Error(c.Help()) }
 	if err := flags.Parse(args); err != nil {
 		return 1
@@ -92,6 +92,8 @@ Read Options:
 
   -format=table           The format for output. By default it is a whitespace-
                           delimited table. This can also be json or yaml.
+                          Overrides the VAULT_FORMAT environment variable if
+                          set.
 `
 	return strings.TrimSpace(helpText)
 }
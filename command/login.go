@@ -0,0 +1,290 @@
+package command
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/vault/api"
+	"github.com/hashicorp/vault/helper/kv-builder"
+	"github.com/hashicorp/vault/meta"
+	"github.com/mitchellh/mapstructure"
+)
+
+// LoginCommand is a Command that authenticates with an auth backend and
+// stores the resulting token with the token helper.
+type LoginCommand struct {
+	meta.Meta
+
+	Handlers map[string]AuthHandler
+
+	// The fields below can be overwritten for tests
+	testStdin io.Reader
+}
+
+func (c *LoginCommand) Run(args []string) int {
+	var method, authPath, format, field string
+	var methodHelp, noStore bool
+	flags := c.Meta.FlagSet("login", meta.FlagSetDefault)
+	flags.StringVar(&method, "method", "token", "")
+	flags.StringVar(&authPath, "path", "", "")
+	flags.StringVar(&format, "format", DefaultFormat(), "")
+	flags.StringVar(&field, "field", "", "")
+	flags.BoolVar(&methodHelp, "method-help", false, "")
+	flags.BoolVar(&noStore, "no-store", false, "")
+	flags.Usage = func() { c.Ui.Error(c.Help()) }
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	args = flags.Args()
+
+	var stdin io.Reader = os.Stdin
+	if c.testStdin != nil {
+		stdin = c.testStdin
+	}
+
+	var handler AuthHandler
+	switch method {
+	case "", "token":
+		switch authPath {
+		case "", "auth/token":
+		default:
+			c.Ui.Error("Token authentication does not support custom paths")
+			return 1
+		}
+
+		// Read the token from stdin if the argument is exactly "-"
+		token := ""
+		if len(args) > 0 {
+			token = args[0]
+		}
+		if token == "-" {
+			var err error
+			token, err = bufio.NewReader(stdin).ReadString('\n')
+			if err != nil && err != io.EOF {
+				c.Ui.Error(fmt.Sprintf("Error reading from stdin: %s", err))
+				return 1
+			}
+			token = strings.TrimSpace(token)
+		}
+
+		handler = &tokenAuthHandler{Token: token}
+		args = nil
+
+	default:
+		handler = c.Handlers[method]
+	}
+
+	if handler == nil {
+		methods := make([]string, 0, len(c.Handlers)+1)
+		methods = append(methods, "token")
+		for k := range c.Handlers {
+			methods = append(methods, k)
+		}
+		sort.Strings(methods)
+
+		c.Ui.Error(fmt.Sprintf(
+			"Unknown authentication method: %s\n\n"+
+				"Please use a supported authentication method. The list of supported\n"+
+				"authentication methods is shown below. For auth methods unsupported\n"+
+				"by the CLI, please use the HTTP API.\n\n"+
+				"%s",
+			method,
+			strings.Join(methods, ", ")))
+		return 1
+	}
+
+	if methodHelp {
+		c.Ui.Output(handler.Help())
+		return 0
+	}
+
+	vars := make(map[string]string)
+	if len(args) > 0 {
+		builder := kvbuilder.Builder{Stdin: stdin}
+		if err := builder.Add(args...); err != nil {
+			c.Ui.Error(err.Error())
+			return 1
+		}
+
+		if err := mapstructure.Decode(builder.Map(), &vars); err != nil {
+			c.Ui.Error(fmt.Sprintf("Error parsing options: %s", err))
+			return 1
+		}
+	}
+	if authPath != "" {
+		vars["mount"] = authPath
+	}
+
+	client, err := c.Client()
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf(
+			"Error initializing client to login: %s", err))
+		return 1
+	}
+
+	token, err := handler.Auth(client, vars)
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return 1
+	}
+
+	// Look the token up before storing it, so that an invalid token doesn't
+	// replace the stored one
+	client.SetToken(token)
+	lookup, err := client.Auth().Token().LookupSelf()
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf(
+			"Error validating token: %s", err))
+		return 1
+	}
+	if lookup == nil {
+		c.Ui.Error("Error: Invalid token")
+		return 1
+	}
+
+	if !noStore {
+		tokenHelper, err := c.TokenHelper()
+		if err != nil {
+			c.Ui.Error(fmt.Sprintf(
+				"Error initializing token helper: %s\n\n"+
+					"Please verify that the token helper is available and properly\n"+
+					"configured for your system. Please refer to the documentation\n"+
+					"on token helpers for more information.",
+				err))
+			return 1
+		}
+
+		if err := tokenHelper.Store(token); err != nil {
+			c.Ui.Error(fmt.Sprintf(
+				"Error storing token: %s\n\n"+
+					"Authentication was successful, but the token was not stored.\n"+
+					"Please fix the issue above and login again.",
+				err))
+			return 1
+		}
+	}
+
+	secret := loginSecret(token, lookup)
+
+	if field != "" {
+		return PrintRawField(c.Ui, secret, field)
+	}
+
+	if format == "table" {
+		// Warn if the VAULT_TOKEN environment variable is set, as that will
+		// take precedence
+		if os.Getenv("VAULT_TOKEN") != "" && !noStore {
+			c.Ui.Output("==> WARNING: VAULT_TOKEN environment variable set!\n")
+			c.Ui.Output("  The environment variable takes precedence over the value")
+			c.Ui.Output("  set by the login command. Either update the value of the")
+			c.Ui.Output("  environment variable or unset it to use the new token.\n")
+		}
+
+		if noStore {
+			c.Ui.Output("Success! You are now authenticated. The token below was not\n" +
+				"stored, use it with VAULT_TOKEN or -no-store=false.\n")
+		} else {
+			c.Ui.Output("Success! You are now authenticated. The token below is already\n" +
+				"stored in the token helper, you do not need to login again.\n")
+		}
+	}
+
+	return OutputSecret(c.Ui, format, secret)
+}
+
+// loginSecret returns the secret output by the login command, holding the
+// token and its lookup
+func loginSecret(token string, lookup *api.Secret) *api.Secret {
+	auth := &api.SecretAuth{
+		ClientToken: token,
+	}
+	auth.Accessor, _ = lookup.Data["accessor"].(string)
+	auth.Renewable, _ = lookup.Data["renewable"].(bool)
+	if ttl, ok := lookup.Data["ttl"].(json.Number); ok {
+		if v, err := ttl.Int64(); err == nil {
+			auth.LeaseDuration = int(v)
+		}
+	}
+	if policies, ok := lookup.Data["policies"].([]interface{}); ok {
+		for _, p := range policies {
+			if policy, ok := p.(string); ok {
+				auth.Policies = append(auth.Policies, policy)
+			}
+		}
+	}
+	if metadata, ok := lookup.Data["meta"].(map[string]interface{}); ok {
+		auth.Metadata = make(map[string]string, len(metadata))
+		for k, v := range metadata {
+			auth.Metadata[k] = fmt.Sprintf("%v", v)
+		}
+	}
+
+	return &api.Secret{
+		Auth:     auth,
+		Warnings: lookup.Warnings,
+	}
+}
+
+func (c *LoginCommand) Synopsis() string {
+	return "Authenticate and store the token with the token helper"
+}
+
+func (c *LoginCommand) Help() string {
+	helpText := `
+Usage: vault login [options] [auth-information]
+
+  Authenticate with Vault with a token or any supported auth backend.
+
+  The login exchange of the backend selected with -method is performed, the
+  resulting token is stored with the token helper, and its policies and TTL
+  are output. Subsequent commands use the stored token, so it doesn't need
+  to be exported.
+
+  By default, the method is token, and the token is the argument. If it's
+  "-", it is read from stdin, and if it's missing, it is prompted for:
+
+      $ vault login 96ddf4bc-d217-f3ba-f9bd-017055595017
+
+  Other methods take their information as "key=value" pairs:
+
+      $ vault login -method=userpass username=my-username
+      $ vault login -method=ldap username=my-username
+      $ vault login -method=github token=my-github-token
+      $ vault login -method=cert
+      $ vault login -method=oidc role=my-role
+
+  Use -method-help to see the information a method takes. If the auth
+  backend is mounted at a different path than its name, give it with -path.
+
+General Options:
+` + meta.GeneralOptionsUsage() + `
+Login Options:
+
+  -method=token           The auth method to use: token, userpass, ldap,
+                          github, cert or oidc.
+
+  -method-help            Output the help of the auth method and exit.
+
+  -path=""                The path at which the auth backend is mounted.
+
+  -no-store               Don't store the token with the token helper, only
+                          output it.
+
+  -format=table           The format for output. By default it is a whitespace-
+                          delimited table. This can also be json or yaml.
+                          Overrides the VAULT_FORMAT environment variable if
+                          set.
+
+  -field=field            If included, the raw value of the specified field
+                          will be output raw to stdout, without a newline,
+                          such as token or token_policies.
+
+`
+	return strings.TrimSpace(helpText)
+}
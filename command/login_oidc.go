@@ -0,0 +1,182 @@
+package command
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/vault/api"
+	"github.com/mitchellh/mapstructure"
+)
+
+// oidcCallbackTimeout is how long the OIDC login waits for the provider to
+// redirect the browser back
+const oidcCallbackTimeout = 2 * time.Minute
+
+// OIDCAuthHandler logs in with an OIDC auth backend by sending the browser
+// to the authorization URL of the provider, and receiving its redirect on a
+// local listener.
+type OIDCAuthHandler struct {
+	// openURL opens the authorization URL, and can be overwritten for tests
+	openURL func(string) error
+}
+
+func (h *OIDCAuthHandler) Auth(c *api.Client, m map[string]string) (string, error) {
+	var data struct {
+		Mount         string `mapstructure:"mount"`
+		Role          string `mapstructure:"role"`
+		ListenAddress string `mapstructure:"listenaddress"`
+		Port          string `mapstructure:"port"`
+		SkipBrowser   bool   `mapstructure:"skip_browser"`
+	}
+	if err := mapstructure.WeakDecode(m, &data); err != nil {
+		return "", err
+	}
+	if data.Mount == "" {
+		data.Mount = "oidc"
+	}
+	if data.ListenAddress == "" {
+		data.ListenAddress = "localhost"
+	}
+	if data.Port == "" {
+		data.Port = "8250"
+	}
+
+	ln, err := net.Listen("tcp", net.JoinHostPort(data.ListenAddress, data.Port))
+	if err != nil {
+		return "", fmt.Errorf("error listening for the OIDC callback: %s", err)
+	}
+	defer ln.Close()
+
+	_, port, err := net.SplitHostPort(ln.Addr().String())
+	if err != nil {
+		return "", err
+	}
+	redirectURI := fmt.Sprintf("http://%s/oidc/callback", net.JoinHostPort(data.ListenAddress, port))
+
+	secret, err := c.Logical().Write(fmt.Sprintf("auth/%s/oidc/auth_url", data.Mount), map[string]interface{}{
+		"role":         data.Role,
+		"redirect_uri": redirectURI,
+	})
+	if err != nil {
+		return "", err
+	}
+	var authURL string
+	if secret != nil {
+		authURL, _ = secret.Data["auth_url"].(string)
+	}
+	if authURL == "" {
+		return "", fmt.Errorf("unable to authorize role %q, check the role and the server logs", data.Role)
+	}
+
+	fmt.Fprintf(os.Stderr, "Complete the login with your OIDC provider at:\n\n    %s\n\n", authURL)
+	if !data.SkipBrowser {
+		openURL := h.openURL
+		if openURL == nil {
+			openURL = openBrowser
+		}
+		if err := openURL(authURL); err != nil {
+			fmt.Fprintf(os.Stderr, "Error opening the browser, open the URL above: %s\n\n", err)
+		}
+	}
+
+	type result struct {
+		secret *api.Secret
+		err    error
+	}
+	doneCh := make(chan result, 1)
+
+	// Only the first callback is waited for
+	sendResult := func(res result) {
+		select {
+		case doneCh <- res:
+		default:
+		}
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/oidc/callback", func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if e := q.Get("error"); e != "" {
+			fmt.Fprintf(w, "Vault login failed: %s", e)
+			sendResult(result{err: fmt.Errorf("error from the OIDC provider: %s %s", e, q.Get("error_description"))})
+			return
+		}
+
+		secret, err := c.Logical().ReadWithData(fmt.Sprintf("auth/%s/oidc/callback", data.Mount), map[string][]string{
+			"state":    {q.Get("state")},
+			"code":     {q.Get("code")},
+			"id_token": {q.Get("id_token")},
+		})
+		if err != nil {
+			fmt.Fprint(w, "Vault login failed, see the output of the CLI.")
+		} else {
+			fmt.Fprint(w, "Vault login successful, this window can be closed.")
+		}
+		sendResult(result{secret: secret, err: err})
+	})
+	server := &http.Server{Handler: mux}
+	go server.Serve(ln)
+
+	var res result
+	select {
+	case res = <-doneCh:
+	case <-time.After(oidcCallbackTimeout):
+		return "", fmt.Errorf("timed out waiting for the OIDC callback")
+	}
+	if res.err != nil {
+		return "", res.err
+	}
+	if res.secret == nil || res.secret.Auth == nil {
+		return "", fmt.Errorf("empty response from credential provider")
+	}
+
+	return res.secret.Auth.ClientToken, nil
+}
+
+func (h *OIDCAuthHandler) Help() string {
+	help := `
+The OIDC method authenticates with an OIDC provider in the browser. The
+authorization URL of the role is opened in the browser, and the provider
+redirects it to a listener started by the CLI, which completes the login.
+The redirect URI, "http://localhost:8250/oidc/callback" by default, must be
+allowed by the role.
+
+    Example: vault login -method=oidc role=my-role
+
+Key/Value Pairs:
+
+    mount=oidc            The mountpoint for the OIDC auth backend.
+
+    role=<string>         The role to login with. Defaults to the default
+                          role of the backend.
+
+    listenaddress=localhost
+                          The address of the callback listener.
+
+    port=8250             The port of the callback listener.
+
+    skip_browser=false    Only output the authorization URL, without opening
+                          the browser.
+`
+	return strings.TrimSpace(help)
+}
+
+// openBrowser opens the URL in the default browser
+func openBrowser(url string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", url)
+	case "windows":
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", url)
+	default:
+		cmd = exec.Command("xdg-open", url)
+	}
+	return cmd.Start()
+}
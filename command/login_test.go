@@ -0,0 +1,220 @@
+package command
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/vault/api"
+	vaulthttp "github.com/hashicorp/vault/http"
+	"github.com/hashicorp/vault/meta"
+	"github.com/hashicorp/vault/vault"
+	"github.com/mitchellh/cli"
+)
+
+func TestLogin_token(t *testing.T) {
+	core, _, token := vault.TestCoreUnsealed(t)
+	ln, addr := vaulthttp.TestServer(t, core)
+	defer ln.Close()
+
+	testAuthInit(t)
+
+	ui := new(cli.MockUi)
+	c := &LoginCommand{
+		Meta: meta.Meta{
+			Ui:          ui,
+			TokenHelper: DefaultTokenHelper,
+		},
+	}
+
+	args := []string{
+		"-address", addr,
+		token,
+	}
+	if code := c.Run(args); code != 0 {
+		t.Fatalf("bad: %d\n\n%s", code, ui.ErrorWriter.String())
+	}
+
+	output := ui.OutputWriter.String()
+	if !strings.Contains(output, "token_policies") || !strings.Contains(output, "root") {
+		t.Fatalf("bad: %s", output)
+	}
+
+	helper, err := c.TokenHelper()
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	actual, err := helper.Get()
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if actual != token {
+		t.Fatalf("bad: %s", actual)
+	}
+}
+
+func TestLogin_method(t *testing.T) {
+	core, _, token := vault.TestCoreUnsealed(t)
+	ln, addr := vaulthttp.TestServer(t, core)
+	defer ln.Close()
+
+	testAuthInit(t)
+
+	ui := new(cli.MockUi)
+	c := &LoginCommand{
+		Handlers: map[string]AuthHandler{
+			"test": &testAuthHandler{},
+		},
+		Meta: meta.Meta{
+			Ui:          ui,
+			TokenHelper: DefaultTokenHelper,
+		},
+	}
+
+	args := []string{
+		"-address", addr,
+		"-method=test",
+		"-no-store",
+		"-field=token",
+		"foo=" + token,
+	}
+
+	// The stored token is kept with -no-store
+	helper, err := c.TokenHelper()
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if err := helper.Store("previous"); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if code := c.Run(args); code != 0 {
+		t.Fatalf("bad: %d\n\n%s", code, ui.ErrorWriter.String())
+	}
+	if output := ui.OutputWriter.String(); output != token+"\n" {
+		t.Fatalf("bad: %q", output)
+	}
+
+	actual, err := helper.Get()
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if actual != "previous" {
+		t.Fatalf("bad: %s", actual)
+	}
+}
+
+func TestLogin_badToken(t *testing.T) {
+	core, _, _ := vault.TestCoreUnsealed(t)
+	ln, addr := vaulthttp.TestServer(t, core)
+	defer ln.Close()
+
+	testAuthInit(t)
+
+	ui := new(cli.MockUi)
+	c := &LoginCommand{
+		Meta: meta.Meta{
+			Ui:          ui,
+			TokenHelper: DefaultTokenHelper,
+		},
+	}
+
+	args := []string{
+		"-address", addr,
+		"not-a-valid-token",
+	}
+
+	// The stored token is kept
+	helper, err := c.TokenHelper()
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if err := helper.Store("previous"); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if code := c.Run(args); code != 1 {
+		t.Fatalf("bad: %d", code)
+	}
+
+	actual, err := helper.Get()
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if actual != "previous" {
+		t.Fatalf("bad: %s", actual)
+	}
+}
+
+func TestOIDCAuthHandler(t *testing.T) {
+	var redirectURI string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/auth/my-oidc/oidc/auth_url":
+			var body map[string]interface{}
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+				t.Fatalf("err: %s", err)
+			}
+			if body["role"] != "dev" {
+				t.Fatalf("bad: %#v", body)
+			}
+			redirectURI = body["redirect_uri"].(string)
+			fmt.Fprint(w, `{"data":{"auth_url":"https://provider/authorize"}}`)
+
+		case "/v1/auth/my-oidc/oidc/callback":
+			q := r.URL.Query()
+			if q.Get("state") != "st" || q.Get("code") != "cd" {
+				t.Fatalf("bad: %s", r.URL.RawQuery)
+			}
+			fmt.Fprint(w, `{"auth":{"client_token":"oidc-token"}}`)
+
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	config := api.DefaultConfig()
+	config.Address = ts.URL
+	client, err := api.NewClient(config)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	// The browser is redirected back by the provider with the state and
+	// the code
+	h := &OIDCAuthHandler{
+		openURL: func(authURL string) error {
+			if authURL != "https://provider/authorize" {
+				t.Fatalf("bad: %s", authURL)
+			}
+			go func() {
+				resp, err := http.Get(redirectURI + "?" + url.Values{
+					"state": {"st"},
+					"code":  {"cd"},
+				}.Encode())
+				if err == nil {
+					resp.Body.Close()
+				}
+			}()
+			return nil
+		},
+	}
+
+	token, err := h.Auth(client, map[string]string{
+		"mount":         "my-oidc",
+		"role":          "dev",
+		"listenaddress": "127.0.0.1",
+		"port":          "0",
+	})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if token != "oidc-token" {
+		t.Fatalf("bad: %s", token)
+	}
+}
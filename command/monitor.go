@@ -0,0 +1,92 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/vault/helper/logformat"
+	"github.com/hashicorp/vault/meta"
+)
+
+// MonitorCommand is a Command that streams the log lines of the server.
+type MonitorCommand struct {
+	meta.Meta
+
+	ShutdownCh chan struct{}
+}
+
+func (c *MonitorCommand) Run(args []string) int {
+	var logLevel string
+	flags := c.Meta.FlagSet("monitor", meta.FlagSetDefault)
+	flags.StringVar(&logLevel, "log-level", "info", "")
+	flags.Usage = func() { c.Ui.Error(c.Help()) }
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	if len(flags.Args()) != 0 {
+		flags.Usage()
+		c.Ui.Error("\nmonitor expects no arguments")
+		return 1
+	}
+	if _, err := logformat.ParseLevel(logLevel); err != nil {
+		c.Ui.Error(fmt.Sprintf("Unknown log level %s", logLevel))
+		return 1
+	}
+
+	client, err := c.Client()
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf(
+			"Error initializing client: %s", err))
+		return 2
+	}
+
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	logCh, err := client.Sys().Monitor(logLevel, stopCh)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error starting the monitor: %s", err))
+		return 1
+	}
+
+	c.Ui.Output(fmt.Sprintf("==> Monitoring %s at log level %s", client.Address(), logLevel))
+	for {
+		select {
+		case line, ok := <-logCh:
+			if !ok {
+				c.Ui.Error("==> The server closed the stream")
+				return 1
+			}
+			c.Ui.Output(line)
+		case <-c.ShutdownCh:
+			return 0
+		}
+	}
+}
+
+func (c *MonitorCommand) Synopsis() string {
+	return "Stream the log lines of the server"
+}
+
+func (c *MonitorCommand) Help() string {
+	helpText := `
+Usage: vault monitor [options]
+
+  Stream the log lines of the server at the given level, until interrupted.
+  The level is independent of the level the server logs at, so debug lines
+  can be watched while the server only writes info lines to its output.
+
+  The token must have the sudo capability on the sys/monitor path. Standby
+  nodes redirect to the active node, whose lines are streamed.
+
+      $ vault monitor -log-level=debug
+
+General Options:
+` + meta.GeneralOptionsUsage() + `
+Monitor Options:
+
+  -log-level=info         The level of the lines to stream: trace, debug,
+                          info, notice, warn or err.
+`
+	return strings.TrimSpace(helpText)
+}
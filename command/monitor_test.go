@@ -0,0 +1,72 @@
+package command
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/helper/logformat"
+	vaulthttp "github.com/hashicorp/vault/http"
+	"github.com/hashicorp/vault/meta"
+	"github.com/hashicorp/vault/vault"
+	log "github.com/mgutz/logxi/v1"
+	"github.com/mitchellh/cli"
+)
+
+func TestMonitor(t *testing.T) {
+	core, _, token := vault.TestCoreUnsealed(t)
+	logger := logformat.NewMonitorLogger(logformat.NewVaultLoggerWithWriter(ioutil.Discard, log.LevelInfo))
+
+	mux := http.NewServeMux()
+	mux.Handle(vaulthttp.MonitorPath, vaulthttp.MonitorHandler(core, logger))
+	mux.Handle("/", vaulthttp.Handler(core))
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	go func() {
+		for {
+			logger.Debug("core: monitored")
+			select {
+			case <-time.After(10 * time.Millisecond):
+			case <-stopCh:
+				return
+			}
+		}
+	}()
+
+	ui := new(cli.MockUi)
+	c := &MonitorCommand{
+		Meta: meta.Meta{
+			ClientToken: token,
+			Ui:          ui,
+		},
+		ShutdownCh: make(chan struct{}),
+	}
+	time.AfterFunc(500*time.Millisecond, func() { close(c.ShutdownCh) })
+
+	args := []string{"-address", srv.URL, "-log-level", "debug"}
+	if code := c.Run(args); code != 0 {
+		t.Fatalf("bad: %d\n\n%s", code, ui.ErrorWriter.String())
+	}
+	if out := ui.OutputWriter.String(); !strings.Contains(out, "[DEBUG] core: monitored") {
+		t.Fatalf("bad: %s", out)
+	}
+}
+
+func TestMonitor_badLevel(t *testing.T) {
+	ui := new(cli.MockUi)
+	c := &MonitorCommand{
+		Meta: meta.Meta{
+			Ui: ui,
+		},
+	}
+
+	if code := c.Run([]string{"-log-level", "verbose"}); code != 1 {
+		t.Fatalf("bad: %d", code)
+	}
+}
@@ -14,12 +14,15 @@ type MountCommand struct {
 }
 
 func (c *MountCommand) Run(args []string) int {
-	var description, path, defaultLeaseTTL, maxLeaseTTL string
+	var description, path, defaultLeaseTTL, maxLeaseTTL, pluginName string
+	var sealWrap bool
 	flags := c.Meta.FlagSet("mount", meta.FlagSetDefault)
 	flags.StringVar(&description, "description", "", "")
 	flags.StringVar(&path, "path", "", "")
 	flags.StringVar(&defaultLeaseTTL, "default-lease-ttl", "", "")
 	flags.StringVar(&maxLeaseTTL, "max-lease-ttl", "", "")
+	flags.StringVar(&pluginName, "plugin-name", "", "")
+	flags.BoolVar(&sealWrap, "seal-wrap", false, "")
 	flags.Usage = func() { c.Ui.Error(c.Help()) }
 	if err := flags.Parse(args); err != nil {
 		return 1
@@ -35,9 +38,13 @@ func (c *MountCommand) Run(args []string) int {
 
 	mountType := args[0]
 
-	// If no path is specified, we default the path to the backend type
+	// If no path is specified, we default the path to the backend type, or
+	// to the plugin name for plugin backends
 	if path == "" {
 		path = mountType
+		if pluginName != "" {
+			path = pluginName
+		}
 	}
 
 	client, err := c.Client()
@@ -53,7 +60,9 @@ func (c *MountCommand) Run(args []string) int {
 		Config: api.MountConfigInput{
 			DefaultLeaseTTL: defaultLeaseTTL,
 			MaxLeaseTTL:     maxLeaseTTL,
+			PluginName:      pluginName,
 		},
+		SealWrap: sealWrap,
 	}
 
 	if err := client.Sys().Mount(path, mountInfo); err != nil {
@@ -102,6 +111,14 @@ Mount Options:
                                  the previously set value. Set to '0' to
                                  explicitly set it to use the global default.
 
+  -plugin-name=<name>            Name of the plugin to run, for backends of the
+                                 "plugin" type. The plugin must be registered
+                                 in the plugin catalog. The mount point
+                                 defaults to the plugin name.
+
+  -seal-wrap                     Also encrypt the values written by the backend
+                                 with the seal. This requires an auto seal.
+
 `
 	return strings.TrimSpace(helpText)
 }
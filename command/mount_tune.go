@@ -15,10 +15,14 @@ type MountTuneCommand struct {
 }
 
 func (c *MountTuneCommand) Run(args []string) int {
-	var defaultLeaseTTL, maxLeaseTTL string
+	var defaultLeaseTTL, maxLeaseTTL, description string
+	var auditNonHMACRequestKeys, auditNonHMACResponseKeys string
 	flags := c.Meta.FlagSet("mount-tune", meta.FlagSetDefault)
 	flags.StringVar(&defaultLeaseTTL, "default-lease-ttl", "", "")
 	flags.StringVar(&maxLeaseTTL, "max-lease-ttl", "", "")
+	flags.StringVar(&description, "description", "", "")
+	flags.StringVar(&auditNonHMACRequestKeys, "audit-non-hmac-request-keys", "", "")
+	flags.StringVar(&auditNonHMACResponseKeys, "audit-non-hmac-response-keys", "", "")
 	flags.Usage = func() { c.Ui.Error(c.Help()) }
 	if err := flags.Parse(args); err != nil {
 		return 1
@@ -37,6 +41,10 @@ func (c *MountTuneCommand) Run(args []string) int {
 	mountConfig := api.MountConfigInput{
 		DefaultLeaseTTL: defaultLeaseTTL,
 		MaxLeaseTTL:     maxLeaseTTL,
+		Description:     description,
+
+		AuditNonHMACRequestKeys:  auditNonHMACRequestKeys,
+		AuditNonHMACResponseKeys: auditNonHMACResponseKeys,
 	}
 
 	client, err := c.Client()
@@ -84,6 +92,19 @@ Mount Options:
                                  the previously set value. Set to 'system' to
                                  explicitly set it to use the system default.
 
+  -description=<desc>            Human-friendly description of the purpose for
+                                 the mount. This shows up in the mounts command.
+
+  -audit-non-hmac-request-keys=<keys>
+                                 Comma-separated list of keys of the request
+                                 data that audit backends log without HMACing
+                                 their values.
+
+  -audit-non-hmac-response-keys=<keys>
+                                 Comma-separated list of keys of the response
+                                 data that audit backends log without HMACing
+                                 their values.
+
 `
 	return strings.TrimSpace(helpText)
 }
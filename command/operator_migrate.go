@@ -0,0 +1,435 @@
+package command
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/hashicorp/hcl"
+	"github.com/hashicorp/hcl/hcl/ast"
+	"github.com/hashicorp/vault/command/server"
+	"github.com/hashicorp/vault/helper/logformat"
+	"github.com/hashicorp/vault/meta"
+	"github.com/hashicorp/vault/physical"
+	log "github.com/mgutz/logxi/v1"
+)
+
+// migrateLockPath is the path of the lock held by the active Vault server,
+// which must not be held while the storage is migrated
+const migrateLockPath = "core/lock"
+
+// migrateExcludedPrefixes are the prefixes of the keys which belong to the
+// running cluster rather than to the data, and which aren't migrated
+var migrateExcludedPrefixes = []string{
+	"core/lock",
+	"core/leader/",
+}
+
+// migrateStatusInterval is the minimum interval between two updates of
+// the status file
+var migrateStatusInterval = time.Second
+
+// OperatorMigrateCommand is a Command that copies the data of a physical
+// backend to another one.
+type OperatorMigrateCommand struct {
+	meta.Meta
+}
+
+// migrateConfig is the configuration of a migration
+type migrateConfig struct {
+	Source      *server.Backend
+	Destination *server.Backend
+}
+
+// migrateStatus is the content of the status file, recording the last key
+// such that all the keys up to it were copied
+type migrateStatus struct {
+	LastKey string `json:"last_key"`
+}
+
+func (c *OperatorMigrateCommand) Run(args []string) int {
+	var configPath, start, statusFile string
+	var maxParallel int
+	flags := c.Meta.FlagSet("operator migrate", meta.FlagSetNone)
+	flags.StringVar(&configPath, "config", "", "")
+	flags.StringVar(&start, "start", "", "")
+	flags.StringVar(&statusFile, "status-file", "", "")
+	flags.IntVar(&maxParallel, "max-parallel", 10, "")
+	flags.Usage = func() { c.Ui.Error(c.Help()) }
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	if configPath == "" {
+		c.Ui.Error("A config path must be specified with -config")
+		flags.Usage()
+		return 1
+	}
+	if maxParallel < 1 {
+		c.Ui.Error("-max-parallel must be at least 1")
+		return 1
+	}
+
+	config, err := loadMigrateConfig(configPath)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error loading configuration from %s: %s", configPath, err))
+		return 1
+	}
+
+	// Resume from the status file of an interrupted migration
+	if statusFile != "" && start == "" {
+		status, err := readMigrateStatus(statusFile)
+		if err != nil {
+			c.Ui.Error(fmt.Sprintf("Error reading the status file: %s", err))
+			return 1
+		}
+		if status != nil {
+			start = status.LastKey
+			c.Ui.Output(fmt.Sprintf("Resuming the migration after %q", start))
+		}
+	}
+
+	logger := logformat.NewVaultLoggerWithWriter(os.Stderr, log.LevelInfo)
+	from, err := physical.NewBackend(config.Source.Type, logger, config.Source.Config)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error initializing the source storage of type %s: %s", config.Source.Type, err))
+		return 1
+	}
+	to, err := physical.NewBackend(config.Destination.Type, logger, config.Destination.Config)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error initializing the destination storage of type %s: %s", config.Destination.Type, err))
+		return 1
+	}
+
+	if err := checkMigrateLock(from); err != nil {
+		c.Ui.Error(err.Error())
+		return 1
+	}
+
+	keys, err := migrateKeys(from, start)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error listing the keys of the source storage: %s", err))
+		return 1
+	}
+
+	var progress func(string) error
+	if statusFile != "" {
+		progress = func(key string) error {
+			return writeMigrateStatus(statusFile, &migrateStatus{LastKey: key})
+		}
+	}
+	if err := migrateStorage(from, to, keys, maxParallel, progress); err != nil {
+		c.Ui.Error(fmt.Sprintf("Error migrating the storage: %s", err))
+		return 1
+	}
+
+	// The migration is complete, the next one must start from scratch
+	if statusFile != "" {
+		if err := os.Remove(statusFile); err != nil && !os.IsNotExist(err) {
+			c.Ui.Error(fmt.Sprintf("Error removing the status file: %s", err))
+			return 1
+		}
+	}
+
+	c.Ui.Output(fmt.Sprintf("Success! Migrated %d keys.", len(keys)))
+	return 0
+}
+
+// loadMigrateConfig loads the configuration of a migration, made of a
+// storage_source and a storage_destination blocks, of the same format as
+// the backend block of the server configuration
+func loadMigrateConfig(path string) (*migrateConfig, error) {
+	d, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	obj, err := hcl.Parse(string(d))
+	if err != nil {
+		return nil, err
+	}
+	list, ok := obj.Node.(*ast.ObjectList)
+	if !ok {
+		return nil, fmt.Errorf("error parsing: file doesn't contain a root object")
+	}
+
+	for _, item := range list.Items {
+		key := item.Keys[0].Token.Value().(string)
+		if key != "storage_source" && key != "storage_destination" {
+			return nil, fmt.Errorf("invalid key '%s' on line %d", key, item.Keys[0].Token.Pos.Line)
+		}
+	}
+
+	var config migrateConfig
+	if config.Source, err = parseMigrateStorage(list, "storage_source"); err != nil {
+		return nil, err
+	}
+	if config.Destination, err = parseMigrateStorage(list, "storage_destination"); err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
+func parseMigrateStorage(list *ast.ObjectList, name string) (*server.Backend, error) {
+	o := list.Filter(name)
+	switch {
+	case len(o.Items) == 0:
+		return nil, fmt.Errorf("missing '%s' block", name)
+	case len(o.Items) > 1:
+		return nil, fmt.Errorf("only one '%s' block is permitted", name)
+	}
+
+	item := o.Items[0]
+	if len(item.Keys) == 0 {
+		return nil, fmt.Errorf("'%s' type must be specified", name)
+	}
+	key := item.Keys[0].Token.Value().(string)
+
+	var m map[string]string
+	if err := hcl.DecodeObject(&m, item.Val); err != nil {
+		return nil, multierror.Prefix(err, fmt.Sprintf("%s.%s:", name, key))
+	}
+
+	return &server.Backend{
+		Type:   strings.ToLower(key),
+		Config: m,
+	}, nil
+}
+
+// checkMigrateLock returns an error if an active Vault server holds the
+// lock of the storage
+func checkMigrateLock(b physical.Backend) error {
+	ha, ok := b.(physical.HABackend)
+	if !ok || !ha.HAEnabled() {
+		return nil
+	}
+
+	lock, err := ha.LockWith(migrateLockPath, "migrate")
+	if err != nil {
+		return fmt.Errorf("Error checking the lock of the source storage: %s", err)
+	}
+	held, _, err := lock.Value()
+	if err != nil {
+		return fmt.Errorf("Error checking the lock of the source storage: %s", err)
+	}
+	if held {
+		return fmt.Errorf("The source storage is in use by an active Vault server. " +
+			"All the Vault servers must be stopped during the migration.")
+	}
+	return nil
+}
+
+// migrateKeys returns the sorted keys of the backend after start, excluding
+// those of the running cluster
+func migrateKeys(b physical.Backend, start string) ([]string, error) {
+	var keys []string
+	var walk func(prefix string) error
+	walk = func(prefix string) error {
+		children, err := b.List(prefix)
+		if err != nil {
+			return err
+		}
+		for _, child := range children {
+			key := prefix + child
+			if strings.HasSuffix(key, "/") {
+				if err := walk(key); err != nil {
+					return err
+				}
+				continue
+			}
+			if key > start && !migrateExcluded(key) {
+				keys = append(keys, key)
+			}
+		}
+		return nil
+	}
+	if err := walk(""); err != nil {
+		return nil, err
+	}
+
+	sort.Strings(keys)
+	return keys, nil
+}
+
+func migrateExcluded(key string) bool {
+	for _, prefix := range migrateExcludedPrefixes {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+type migrateResult struct {
+	index int
+	err   error
+}
+
+// migrateStorage copies the keys from a backend to another with parallel
+// workers. progress, if not nil, is called with the last key such that all
+// the keys up to it were copied.
+func migrateStorage(from, to physical.Backend, keys []string, maxParallel int, progress func(string) error) error {
+	jobs := make(chan int)
+	results := make(chan migrateResult)
+	quitCh := make(chan struct{})
+
+	go func() {
+		defer close(jobs)
+		for i := range keys {
+			select {
+			case jobs <- i:
+			case <-quitCh:
+				return
+			}
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for i := 0; i < maxParallel; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results <- migrateResult{
+					index: i,
+					err:   migrateKey(from, to, keys[i]),
+				}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	var retErr error
+	fail := func(err error) {
+		if retErr == nil {
+			retErr = err
+			close(quitCh)
+		}
+	}
+
+	done := make([]bool, len(keys))
+	next := 0
+	var lastUpdate time.Time
+	for result := range results {
+		if result.err != nil {
+			fail(fmt.Errorf("error migrating %q: %s", keys[result.index], result.err))
+			continue
+		}
+		done[result.index] = true
+
+		previous := next
+		for next < len(keys) && done[next] {
+			next++
+		}
+		if progress != nil && next > previous && time.Since(lastUpdate) >= migrateStatusInterval {
+			if err := progress(keys[next-1]); err != nil {
+				fail(fmt.Errorf("error updating the status: %s", err))
+			}
+			lastUpdate = time.Now()
+		}
+	}
+
+	// Record where an interrupted migration stopped
+	if progress != nil && next > 0 {
+		if err := progress(keys[next-1]); err != nil && retErr == nil {
+			retErr = fmt.Errorf("error updating the status: %s", err)
+		}
+	}
+	return retErr
+}
+
+func migrateKey(from, to physical.Backend, key string) error {
+	entry, err := from.Get(key)
+	if err != nil {
+		return err
+	}
+	// The key was deleted since it was listed
+	if entry == nil {
+		return nil
+	}
+	return to.Put(entry)
+}
+
+func readMigrateStatus(path string) (*migrateStatus, error) {
+	d, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var status migrateStatus
+	if err := json.Unmarshal(d, &status); err != nil {
+		return nil, err
+	}
+	return &status, nil
+}
+
+func writeMigrateStatus(path string, status *migrateStatus) error {
+	d, err := json.Marshal(status)
+	if err != nil {
+		return err
+	}
+
+	// Write the status atomically, so that an interruption doesn't leave a
+	// truncated file
+	tmpPath := path + ".tmp"
+	if err := ioutil.WriteFile(tmpPath, d, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, path)
+}
+
+func (c *OperatorMigrateCommand) Synopsis() string {
+	return "Copy the data of a storage backend to another"
+}
+
+func (c *OperatorMigrateCommand) Help() string {
+	helpText := `
+Usage: vault operator migrate [options]
+
+  Copy all the data of a storage backend to another.
+
+  This command copies the keys of the storage_source backend to the
+  storage_destination backend of the configuration file, for example:
+
+      storage_source "consul" {
+        address = "127.0.0.1:8500"
+        path    = "vault"
+      }
+
+      storage_destination "file" {
+        path = "/var/lib/vault"
+      }
+
+  The configuration of the backends is the same as the backend block of the
+  server configuration. The keys are copied as is, without being decrypted.
+  All the Vault servers using the source storage must be stopped during the
+  migration.
+
+Migrate Options:
+
+  -config=path            The path to the configuration file of the
+                          migration. This is required.
+
+  -max-parallel=10        The number of keys to copy in parallel.
+
+  -start=key              Only copy the keys sorting after this one.
+
+  -status-file=path       The path of a file recording the progress of the
+                          migration. If the migration is interrupted, running
+                          it again with the same status file resumes it. The
+                          file is removed once the migration succeeds.
+`
+	return strings.TrimSpace(helpText)
+}
@@ -0,0 +1,178 @@
+package command
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/vault/helper/logformat"
+	"github.com/hashicorp/vault/meta"
+	"github.com/hashicorp/vault/physical"
+	log "github.com/mgutz/logxi/v1"
+	"github.com/mitchellh/cli"
+)
+
+func TestOperatorMigrate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "vault-migrate")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	logger := logformat.NewVaultLogger(log.LevelTrace)
+	from, err := physical.NewBackend("file", logger, map[string]string{
+		"path": filepath.Join(dir, "from"),
+	})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	keys := []string{"core/keyring", "core/leader/1234", "logical/a/b", "logical/a/c", "sys/token/id/d"}
+	for _, key := range keys {
+		if err := from.Put(&physical.Entry{Key: key, Value: []byte(key)}); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+	}
+
+	configPath := filepath.Join(dir, "migrate.hcl")
+	config := fmt.Sprintf(`
+storage_source "file" {
+  path = %q
+}
+
+storage_destination "file" {
+  path = %q
+}
+`, filepath.Join(dir, "from"), filepath.Join(dir, "to"))
+	if err := ioutil.WriteFile(configPath, []byte(config), 0600); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Resume the migration after the first data key
+	statusFile := filepath.Join(dir, "status")
+	if err := writeMigrateStatus(statusFile, &migrateStatus{LastKey: "logical/a/b"}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	ui := new(cli.MockUi)
+	c := &OperatorMigrateCommand{
+		Meta: meta.Meta{
+			Ui: ui,
+		},
+	}
+	args := []string{
+		"-config", configPath,
+		"-status-file", statusFile,
+		"-max-parallel", "2",
+	}
+	if code := c.Run(args); code != 0 {
+		t.Fatalf("bad: %d\n\n%s", code, ui.ErrorWriter.String())
+	}
+
+	to, err := physical.NewBackend("file", logger, map[string]string{
+		"path": filepath.Join(dir, "to"),
+	})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	actual, err := migrateKeys(to, "")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	expected := []string{"logical/a/c", "sys/token/id/d"}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("bad: %#v", actual)
+	}
+	if _, err := os.Stat(statusFile); !os.IsNotExist(err) {
+		t.Fatalf("status file should be removed: %v", err)
+	}
+
+	// Without a status file, every key but those of the cluster is copied
+	if code := c.Run(args[:2]); code != 0 {
+		t.Fatalf("bad: %d\n\n%s", code, ui.ErrorWriter.String())
+	}
+	actual, err = migrateKeys(to, "")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	expected = []string{"core/keyring", "logical/a/b", "logical/a/c", "sys/token/id/d"}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("bad: %#v", actual)
+	}
+	entry, err := to.Get("logical/a/b")
+	if err != nil || entry == nil || string(entry.Value) != "logical/a/b" {
+		t.Fatalf("bad: %#v %v", entry, err)
+	}
+}
+
+// testMigrateFailingBackend fails to write a key
+type testMigrateFailingBackend struct {
+	physical.Backend
+	key string
+}
+
+func (b *testMigrateFailingBackend) Put(entry *physical.Entry) error {
+	if entry.Key == b.key {
+		return fmt.Errorf("failure")
+	}
+	return b.Backend.Put(entry)
+}
+
+func TestMigrateStorage_status(t *testing.T) {
+	logger := logformat.NewVaultLogger(log.LevelTrace)
+	from := physical.NewInmem(logger)
+	var keys []string
+	for i := 0; i < 100; i++ {
+		key := fmt.Sprintf("key%02d", i)
+		keys = append(keys, key)
+		from.Put(&physical.Entry{Key: key})
+	}
+
+	to := &testMigrateFailingBackend{
+		Backend: physical.NewInmem(logger),
+		key:     "key50",
+	}
+	var last string
+	err := migrateStorage(from, to, keys, 4, func(key string) error {
+		last = key
+		return nil
+	})
+	if err == nil {
+		t.Fatal("expected error")
+	}
+
+	// The status is before the failed key
+	if last == "" || last >= "key50" {
+		t.Fatalf("bad: %q", last)
+	}
+	for _, key := range keys {
+		if key > last {
+			break
+		}
+		if entry, err := to.Get(key); err != nil || entry == nil {
+			t.Fatalf("bad: %s: %v", key, err)
+		}
+	}
+}
+
+func TestCheckMigrateLock(t *testing.T) {
+	logger := logformat.NewVaultLogger(log.LevelTrace)
+	b := physical.NewInmemHA(logger)
+	if err := checkMigrateLock(b); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	lock, err := b.LockWith(migrateLockPath, "active")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if _, err := lock.Lock(nil); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	defer lock.Unlock()
+	if err := checkMigrateLock(b); err == nil {
+		t.Fatal("expected error")
+	}
+}
@@ -7,13 +7,15 @@ import (
 	"github.com/hashicorp/vault/meta"
 )
 
-// PathHelpCommand is a Command that lists the mounts.
+// PathHelpCommand is a Command that looks up the help for a path.
 type PathHelpCommand struct {
 	meta.Meta
 }
 
 func (c *PathHelpCommand) Run(args []string) int {
-	flags := c.Meta.FlagSet("help", meta.FlagSetDefault)
+	var format string
+	flags := c.Meta.FlagSet("path-help", meta.FlagSetDefault)
+	flags.StringVar(&format, "format", "table", "")
 	flags.Usage = func() { c.Ui.Error(c.Help()) }
 	if err := flags.Parse(args); err != nil {
 		return 1
@@ -49,6 +51,10 @@ mount points of secret backends are known.`)
 		return 1
 	}
 
+	if strings.ToLower(format) != "table" {
+		return OutputData(c.Ui, format, help)
+	}
+
 	c.Ui.Output(help.Help)
 	return 0
 }
@@ -70,7 +76,18 @@ Usage: vault path-help [options] path
   The command requires that the Vault be unsealed, because otherwise
   the mount points of the backends are unknown.
 
+  With the json or yaml format, the help is output along with an OpenAPI
+  document of the path, describing its operations and the types of its
+  fields, so that tools can introspect the API of a backend:
+
+      $ vault path-help -format=json secret/foo
+
 General Options:
-` + meta.GeneralOptionsUsage()
+` + meta.GeneralOptionsUsage() + `
+Path Help Options:
+
+  -format=table           The format for output. By default the help is
+                          output as text. This can also be json or yaml.
+`
 	return strings.TrimSpace(helpText)
 }
@@ -1,6 +1,8 @@
 package command
 
 import (
+	"encoding/json"
+	"strings"
 	"testing"
 
 	"github.com/hashicorp/vault/http"
@@ -30,3 +32,57 @@ func TestHelp(t *testing.T) {
 		t.Fatalf("bad: %d\n\n%s", code, ui.ErrorWriter.String())
 	}
 }
+
+func TestHelp_json(t *testing.T) {
+	core, _, token := vault.TestCoreUnsealed(t)
+	ln, addr := http.TestServer(t, core)
+	defer ln.Close()
+
+	ui := new(cli.MockUi)
+	c := &PathHelpCommand{
+		Meta: meta.Meta{
+			ClientToken: token,
+			Ui:          ui,
+		},
+	}
+
+	args := []string{
+		"-address", addr,
+		"-format", "json",
+		"secret/foo",
+	}
+	if code := c.Run(args); code != 0 {
+		t.Fatalf("bad: %d\n\n%s", code, ui.ErrorWriter.String())
+	}
+
+	var help struct {
+		Help    string `json:"help"`
+		OpenAPI struct {
+			Paths map[string]struct {
+				Parameters []map[string]interface{} `json:"parameters"`
+				Get        interface{}              `json:"get"`
+				Post       interface{}              `json:"post"`
+				Delete     interface{}              `json:"delete"`
+			} `json:"paths"`
+		} `json:"openapi"`
+	}
+	if err := json.Unmarshal(ui.OutputWriter.Bytes(), &help); err != nil {
+		t.Fatalf("err: %s\n\n%s", err, ui.OutputWriter.String())
+	}
+	if !strings.Contains(help.Help, "Pass-through secret storage") {
+		t.Fatalf("bad: %s", help.Help)
+	}
+
+	// The path is documented under its mount, with its operations and the
+	// parameter captured from the path
+	path, ok := help.OpenAPI.Paths["/secret/{path}"]
+	if !ok || len(help.OpenAPI.Paths) != 1 {
+		t.Fatalf("bad: %#v", help.OpenAPI.Paths)
+	}
+	if path.Get == nil || path.Post == nil || path.Delete == nil {
+		t.Fatalf("bad: %#v", path)
+	}
+	if len(path.Parameters) != 1 || path.Parameters[0]["name"] != "path" || path.Parameters[0]["in"] != "path" {
+		t.Fatalf("bad: %#v", path.Parameters)
+	}
+}
@@ -0,0 +1,36 @@
+package command
+
+import (
+	"strings"
+
+	"github.com/hashicorp/vault/meta"
+	"github.com/mitchellh/cli"
+)
+
+// PolicyCommand is the parent of the policy subcommands, which only outputs
+// their help.
+type PolicyCommand struct {
+	meta.Meta
+}
+
+func (c *PolicyCommand) Run(args []string) int {
+	return cli.RunResultHelp
+}
+
+func (c *PolicyCommand) Synopsis() string {
+	return "Interact with policy files"
+}
+
+func (c *PolicyCommand) Help() string {
+	helpText := `
+Usage: vault policy <subcommand> [options] [args]
+
+  Interact with policy files. The policies of the server are managed with
+  the "policies", "policy-write" and "policy-delete" commands.
+
+      $ vault policy fmt my-policy.hcl
+
+  Run a subcommand with -help to see its usage and options.
+`
+	return strings.TrimSpace(helpText)
+}
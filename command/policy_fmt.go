@@ -0,0 +1,364 @@
+package command
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/hcl/ast"
+	hclParser "github.com/hashicorp/hcl/hcl/parser"
+	"github.com/hashicorp/hcl/hcl/token"
+	"github.com/hashicorp/vault/meta"
+	"github.com/hashicorp/vault/vault"
+)
+
+// PolicyFmtCommand is a Command that formats policy files canonically.
+type PolicyFmtCommand struct {
+	meta.Meta
+}
+
+func (c *PolicyFmtCommand) Run(args []string) int {
+	var strict, check bool
+	flags := c.Meta.FlagSet("policy fmt", meta.FlagSetNone)
+	flags.BoolVar(&strict, "strict", false, "")
+	flags.BoolVar(&check, "check", false, "")
+	flags.Usage = func() { c.Ui.Error(c.Help()) }
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	args = flags.Args()
+	if len(args) == 0 {
+		flags.Usage()
+		c.Ui.Error(fmt.Sprintf(
+			"\npolicy fmt expects at least one argument: the policy files to format"))
+		return 1
+	}
+
+	code := 0
+	for _, path := range args {
+		d, err := ioutil.ReadFile(path)
+		if err != nil {
+			c.Ui.Error(fmt.Sprintf("Error reading %s: %s", path, err))
+			code = 1
+			continue
+		}
+
+		if strict {
+			if _, err := vault.Parse(string(d)); err != nil {
+				c.Ui.Error(fmt.Sprintf("Invalid policy %s: %s", path, err))
+				code = 1
+				continue
+			}
+		}
+
+		formatted, err := formatPolicy(d)
+		if err != nil {
+			c.Ui.Error(fmt.Sprintf("Error formatting %s: %s", path, err))
+			code = 1
+			continue
+		}
+		if bytes.Equal(d, formatted) {
+			continue
+		}
+
+		if check {
+			c.Ui.Error(fmt.Sprintf("Policy isn't formatted: %s", path))
+			code = 1
+			continue
+		}
+
+		fi, err := os.Stat(path)
+		if err != nil {
+			c.Ui.Error(fmt.Sprintf("Error reading %s: %s", path, err))
+			code = 1
+			continue
+		}
+		if err := ioutil.WriteFile(path, formatted, fi.Mode()); err != nil {
+			c.Ui.Error(fmt.Sprintf("Error writing %s: %s", path, err))
+			code = 1
+			continue
+		}
+		c.Ui.Output(fmt.Sprintf("Success! Formatted policy: %s", path))
+	}
+
+	return code
+}
+
+func (c *PolicyFmtCommand) Synopsis() string {
+	return "Format policy files canonically"
+}
+
+func (c *PolicyFmtCommand) Help() string {
+	helpText := `
+Usage: vault policy fmt [options] path...
+
+  Format HCL policy files canonically, in place: blocks are separated by
+  blank lines, bodies are indented with two spaces, the equal signs of
+  consecutive assignments are aligned, and lists of capabilities are written
+  on one line. Comments are kept.
+
+  Only the HCL syntax is checked by default. With -strict, the policies are
+  also validated like the server does, which rejects unknown keys and
+  invalid capabilities. With -check, the files aren't written, and the
+  command fails if any of them isn't formatted, so that policies can be
+  linted before being written:
+
+      $ vault policy fmt -strict -check policies/*.hcl
+
+  This command doesn't talk to the server.
+
+Policy Fmt Options:
+
+  -strict=false           Validate the policies, rejecting unknown keys and
+                          invalid capabilities.
+
+  -check=false            Only check whether the files are formatted, and fail
+                          if they aren't.
+`
+	return strings.TrimSpace(helpText)
+}
+
+// formatPolicy formats the HCL policy canonically
+func formatPolicy(d []byte) ([]byte, error) {
+	file, err := hclParser.Parse(d)
+	if err != nil {
+		return nil, err
+	}
+	list, ok := file.Node.(*ast.ObjectList)
+	if !ok {
+		return nil, fmt.Errorf("policy doesn't contain a root object")
+	}
+
+	p := &policyPrinter{
+		attached: make(map[*ast.CommentGroup]struct{}),
+	}
+	p.collectAttached(list)
+	for _, c := range file.Comments {
+		if _, ok := p.attached[c]; !ok {
+			p.floating = append(p.floating, c)
+		}
+	}
+	sort.Sort(commentsByPos(p.floating))
+
+	p.printList(list, 0, true, token.Pos{})
+	out := bytes.TrimSpace(p.buf.Bytes())
+	if len(out) == 0 {
+		return out, nil
+	}
+	return append(out, '\n'), nil
+}
+
+// policyPrinter prints an HCL AST canonically
+type policyPrinter struct {
+	buf bytes.Buffer
+
+	// attached are the comments of the items and values, and floating the
+	// other comments, which are printed before the next item
+	attached map[*ast.CommentGroup]struct{}
+	floating []*ast.CommentGroup
+}
+
+type commentsByPos []*ast.CommentGroup
+
+func (c commentsByPos) Len() int           { return len(c) }
+func (c commentsByPos) Swap(i, j int)      { c[i], c[j] = c[j], c[i] }
+func (c commentsByPos) Less(i, j int) bool { return c[i].Pos().Before(c[j].Pos()) }
+
+func (p *policyPrinter) collectAttached(n ast.Node) {
+	switch n := n.(type) {
+	case *ast.ObjectList:
+		for _, item := range n.Items {
+			p.collectAttached(item)
+		}
+	case *ast.ObjectItem:
+		if n.LeadComment != nil {
+			p.attached[n.LeadComment] = struct{}{}
+		}
+		if n.LineComment != nil {
+			p.attached[n.LineComment] = struct{}{}
+		}
+		p.collectAttached(n.Val)
+	case *ast.ObjectType:
+		p.collectAttached(n.List)
+	case *ast.ListType:
+		for _, elem := range n.List {
+			p.collectAttached(elem)
+		}
+	case *ast.LiteralType:
+		if n.LineComment != nil {
+			p.attached[n.LineComment] = struct{}{}
+		}
+	}
+}
+
+// printList prints the items of the list, separated by blank lines at the
+// top level and around blocks, and the floating comments before end
+func (p *policyPrinter) printList(list *ast.ObjectList, indent int, top bool, end token.Pos) {
+	prevSingle := false
+	first := true
+	separate := func(single bool) {
+		if !first && (top || !single || !prevSingle) {
+			p.buf.WriteString("\n")
+		}
+		first = false
+		prevSingle = single
+	}
+
+	items := list.Items
+	for i := 0; i < len(items); i++ {
+		item := items[i]
+
+		for len(p.floating) > 0 && p.floating[0].Pos().Before(item.Pos()) {
+			separate(false)
+			p.printComment(p.floating[0], indent)
+			p.floating = p.floating[1:]
+		}
+
+		if !p.isSingleLine(item) {
+			separate(false)
+			p.printItem(item, indent, 0)
+			continue
+		}
+
+		// Consecutive single line assignments are aligned
+		j := i + 1
+		for j < len(items) && p.isSingleLine(items[j]) && items[j].LeadComment == nil && !p.floatingBefore(items[j]) {
+			j++
+		}
+		width := 0
+		for _, item := range items[i:j] {
+			if w := len(itemKeys(item)); w > width {
+				width = w
+			}
+		}
+		separate(true)
+		for _, item := range items[i:j] {
+			p.printItem(item, indent, width)
+		}
+		i = j - 1
+	}
+
+	for len(p.floating) > 0 && (!end.IsValid() || p.floating[0].Pos().Before(end)) {
+		separate(false)
+		p.printComment(p.floating[0], indent)
+		p.floating = p.floating[1:]
+	}
+}
+
+// floatingBefore returns whether a floating comment precedes the item
+func (p *policyPrinter) floatingBefore(item *ast.ObjectItem) bool {
+	return len(p.floating) > 0 && p.floating[0].Pos().Before(item.Pos())
+}
+
+// isSingleLine returns whether the item is an assignment printed on one line
+func (p *policyPrinter) isSingleLine(item *ast.ObjectItem) bool {
+	if !item.Assign.IsValid() {
+		return false
+	}
+	switch v := item.Val.(type) {
+	case *ast.LiteralType:
+		return v.Token.Type != token.HEREDOC
+	case *ast.ListType:
+		return p.isSingleLineList(v)
+	}
+	return false
+}
+
+func (p *policyPrinter) isSingleLineList(l *ast.ListType) bool {
+	for _, elem := range l.List {
+		lit, ok := elem.(*ast.LiteralType)
+		if !ok || lit.LineComment != nil || lit.Token.Type == token.HEREDOC {
+			return false
+		}
+	}
+	return true
+}
+
+func itemKeys(item *ast.ObjectItem) string {
+	keys := make([]string, 0, len(item.Keys))
+	for _, k := range item.Keys {
+		keys = append(keys, k.Token.Text)
+	}
+	return strings.Join(keys, " ")
+}
+
+func (p *policyPrinter) printComment(c *ast.CommentGroup, indent int) {
+	for _, comment := range c.List {
+		p.buf.WriteString(strings.Repeat("  ", indent))
+		p.buf.WriteString(comment.Text)
+		p.buf.WriteString("\n")
+	}
+}
+
+// printItem prints the item, with its key padded to width for assignments
+func (p *policyPrinter) printItem(item *ast.ObjectItem, indent, width int) {
+	if item.LeadComment != nil {
+		p.printComment(item.LeadComment, indent)
+	}
+
+	keys := itemKeys(item)
+	p.buf.WriteString(strings.Repeat("  ", indent))
+	p.buf.WriteString(keys)
+	if item.Assign.IsValid() {
+		if width > len(keys) {
+			p.buf.WriteString(strings.Repeat(" ", width-len(keys)))
+		}
+		p.buf.WriteString(" =")
+	}
+	p.buf.WriteString(" ")
+	p.printValue(item.Val, indent)
+
+	if item.LineComment != nil {
+		p.buf.WriteString(" ")
+		p.buf.WriteString(item.LineComment.List[0].Text)
+	}
+	p.buf.WriteString("\n")
+}
+
+func (p *policyPrinter) printValue(n ast.Node, indent int) {
+	switch v := n.(type) {
+	case *ast.LiteralType:
+		p.buf.WriteString(v.Token.Text)
+
+	case *ast.ListType:
+		if p.isSingleLineList(v) {
+			p.buf.WriteString("[")
+			for i, elem := range v.List {
+				if i > 0 {
+					p.buf.WriteString(", ")
+				}
+				p.printValue(elem, indent)
+			}
+			p.buf.WriteString("]")
+			return
+		}
+
+		p.buf.WriteString("[\n")
+		for _, elem := range v.List {
+			p.buf.WriteString(strings.Repeat("  ", indent+1))
+			p.printValue(elem, indent+1)
+			p.buf.WriteString(",")
+			if lit, ok := elem.(*ast.LiteralType); ok && lit.LineComment != nil {
+				p.buf.WriteString(" ")
+				p.buf.WriteString(lit.LineComment.List[0].Text)
+			}
+			p.buf.WriteString("\n")
+		}
+		p.buf.WriteString(strings.Repeat("  ", indent))
+		p.buf.WriteString("]")
+
+	case *ast.ObjectType:
+		if len(v.List.Items) == 0 && !(len(p.floating) > 0 && p.floating[0].Pos().Before(v.Rbrace)) {
+			p.buf.WriteString("{}")
+			return
+		}
+		p.buf.WriteString("{\n")
+		p.printList(v.List, indent+1, false, v.Rbrace)
+		p.buf.WriteString(strings.Repeat("  ", indent))
+		p.buf.WriteString("}")
+	}
+}
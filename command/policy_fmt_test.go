@@ -0,0 +1,168 @@
+package command
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hashicorp/vault/meta"
+	"github.com/mitchellh/cli"
+)
+
+const testPolicyUnformatted = `# Policy of the app
+
+# The secrets of the app
+path "secret/app/*" {
+capabilities = [ "read","list" ] # no writes
+    allowed_parameters = {
+  "foo" = []
+  "bar" = ["baz", "qux"]
+    }
+}
+path "sys/renew" {   policy = "write" }
+
+
+path "secret/other" {
+	capabilities = [
+		"read", # comment
+		"update",
+	]
+	# end of block
+}
+`
+
+const testPolicyFormatted = `# Policy of the app
+
+# The secrets of the app
+path "secret/app/*" {
+  capabilities = ["read", "list"] # no writes
+
+  allowed_parameters = {
+    "foo" = []
+    "bar" = ["baz", "qux"]
+  }
+}
+
+path "sys/renew" {
+  policy = "write"
+}
+
+path "secret/other" {
+  capabilities = [
+    "read", # comment
+    "update",
+  ]
+
+  # end of block
+}
+`
+
+func testPolicyFile(t *testing.T, contents string) (string, func()) {
+	dir, err := ioutil.TempDir("", "vault-policy-fmt")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	path := filepath.Join(dir, "policy.hcl")
+	if err := ioutil.WriteFile(path, []byte(contents), 0644); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	return path, func() { os.RemoveAll(dir) }
+}
+
+func TestFormatPolicy(t *testing.T) {
+	formatted, err := formatPolicy([]byte(testPolicyUnformatted))
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if string(formatted) != testPolicyFormatted {
+		t.Fatalf("bad:\n%s", formatted)
+	}
+
+	// Formatting is idempotent
+	again, err := formatPolicy(formatted)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if string(again) != testPolicyFormatted {
+		t.Fatalf("bad:\n%s", again)
+	}
+
+	// Consecutive assignments are aligned
+	formatted, err = formatPolicy([]byte(`path "a" {
+  capabilities = ["read"]
+  required_parameters = ["foo"]
+}`))
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	expected := `path "a" {
+  capabilities        = ["read"]
+  required_parameters = ["foo"]
+}
+`
+	if string(formatted) != expected {
+		t.Fatalf("bad:\n%s", formatted)
+	}
+
+	if _, err := formatPolicy([]byte(`path "a" {`)); err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+func TestPolicyFmt(t *testing.T) {
+	path, cleanup := testPolicyFile(t, testPolicyUnformatted)
+	defer cleanup()
+
+	ui := new(cli.MockUi)
+	c := &PolicyFmtCommand{
+		Meta: meta.Meta{
+			Ui: ui,
+		},
+	}
+
+	// -check doesn't write the file
+	if code := c.Run([]string{"-check", path}); code != 1 {
+		t.Fatalf("bad: %d", code)
+	}
+
+	if code := c.Run([]string{"-strict", path}); code != 0 {
+		t.Fatalf("bad: %d\n\n%s", code, ui.ErrorWriter.String())
+	}
+	actual, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if string(actual) != testPolicyFormatted {
+		t.Fatalf("bad:\n%s", actual)
+	}
+
+	if code := c.Run([]string{"-check", path}); code != 0 {
+		t.Fatalf("bad: %d\n\n%s", code, ui.ErrorWriter.String())
+	}
+}
+
+func TestPolicyFmt_strict(t *testing.T) {
+	policy := `path "secret/foo" {
+  capabilities = ["read"]
+  unknown      = "key"
+}
+`
+	path, cleanup := testPolicyFile(t, policy)
+	defer cleanup()
+
+	ui := new(cli.MockUi)
+	c := &PolicyFmtCommand{
+		Meta: meta.Meta{
+			Ui: ui,
+		},
+	}
+
+	// Unknown keys are only rejected with -strict
+	if code := c.Run([]string{"-check", path}); code != 0 {
+		t.Fatalf("bad: %d\n\n%s", code, ui.ErrorWriter.String())
+	}
+	if code := c.Run([]string{"-strict", "-check", path}); code != 1 {
+		t.Fatalf("bad: %d", code)
+	}
+}
@@ -21,7 +21,7 @@ func (c *ReadCommand) Run(args []string) int {
 	var secret *api.Secret
 	var flags *flag.FlagSet
 	flags = c.Meta.FlagSet("read", meta.FlagSetDefault)
-	flags.StringVar(&format, "format", "table", "")
+	flags.StringVar(&format, "format", DefaultFormat(), "")
 	flags.StringVar(&field, "field", "", "")
 	flags.Usage = func() { c.Ui.Error(c.Help()) }
 	if err := flags.Parse(args); err != nil {
@@ -88,9 +88,14 @@ Read Options:
 
   -format=table           The format for output. By default it is a whitespace-
                           delimited table. This can also be json or yaml.
+                          Overrides the VAULT_FORMAT environment variable if
+                          set.
 
   -field=field            If included, the raw value of the specified field
-                          will be output raw to stdout.
+                          will be output raw to stdout, without a newline.
+                          Lists and maps are output as JSON. The lease_id,
+                          lease_duration and lease_renewable of dynamic
+                          secrets can also be selected.
 
 `
 	return strings.TrimSpace(helpText)
@@ -40,7 +40,7 @@ func (c *RemountCommand) Run(args []string) int {
 
 	if err := client.Sys().Remount(from, to); err != nil {
 		c.Ui.Error(fmt.Sprintf(
-			"Unmount error: %s", err))
+			"Remount error: %s", err))
 		return 2
 	}
 
@@ -61,9 +61,10 @@ Usage: vault remount [options] from to
   Remount a mounted secret backend to a new path.
 
   This command remounts a secret backend that is already mounted to
-  a new path. All the secrets from the old path will be revoked, but
-  the Vault data associated with the backend will be preserved (such
-  as configuration data).
+  a new path. The Vault data associated with the backend is preserved
+  (such as configuration data), and the leases of the secrets it issued
+  are moved to the new path, so they can still be renewed and revoked.
+  The command waits for the remount to finish.
 
   Example: vault remount secret/ generic/
 
@@ -16,12 +16,14 @@ type RenewCommand struct {
 func (c *RenewCommand) Run(args []string) int {
 	var format string
 	flags := c.Meta.FlagSet("renew", meta.FlagSetDefault)
-	flags.StringVar(&format, "format", "table", "")
+	flags.StringVar(&format, "format", DefaultFormat(), "")
 	flags.Usage = func() { c.Ui.Error(c.Help()) }
 	if err := flags.Parse(args); err != nil {
 		return 1
 	}
 
+	c.Ui.Warn(`The "vault renew" command is deprecated, use "vault lease renew" instead.`)
+
 	args = flags.Args()
 	if len(args) < 1 || len(args) >= 3 {
 		flags.Usage()
@@ -61,7 +63,7 @@ func (c *RenewCommand) Run(args []string) int {
 }
 
 func (c *RenewCommand) Synopsis() string {
-	return "Renew the lease of a secret"
+	return "Renew the lease of a secret (deprecated, see lease renew)"
 }
 
 func (c *RenewCommand) Help() string {
@@ -71,6 +73,8 @@ Usage: vault renew [options] id [increment]
   Renew the lease on a secret, extending the time that it can be used
   before it is revoked by Vault.
 
+  This command is deprecated, use "vault lease renew" instead.
+
   Every secret in Vault has a lease associated with it. If the user of
   the secret wants to use it longer than the lease, then it must be
   renewed. Renewing the lease will not change the contents of the secret.
@@ -85,6 +89,8 @@ Renew Options:
 
   -format=table           The format for output. By default it is a whitespace-
                           delimited table. This can also be json or yaml.
+                          Overrides the VAULT_FORMAT environment variable if
+                          set.
 `
 	return strings.TrimSpace(helpText)
 }
@@ -22,6 +22,8 @@ func (c *RevokeCommand) Run(args []string) int {
 		return 1
 	}
 
+	c.Ui.Warn(`The "vault revoke" command is deprecated, use "vault lease revoke" instead.`)
+
 	args = flags.Args()
 	if len(args) != 1 {
 		flags.Usage()
@@ -61,7 +63,7 @@ func (c *RevokeCommand) Run(args []string) int {
 }
 
 func (c *RevokeCommand) Synopsis() string {
-	return "Revoke a secret."
+	return "Revoke a secret (deprecated, see lease revoke)"
 }
 
 func (c *RevokeCommand) Help() string {
@@ -70,6 +72,8 @@ Usage: vault revoke [options] id
 
   Revoke a secret by its lease ID.
 
+  This command is deprecated, use "vault lease revoke" instead.
+
   This command revokes a secret by its lease ID that was returned with it. Once
   the key is revoked, it is no longer valid.
 
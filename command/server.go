@@ -1,13 +1,18 @@
 package command
 
 import (
+	"crypto/sha256"
 	"encoding/base64"
+	"encoding/hex"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"net"
 	"net/http"
 	"net/url"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"runtime"
 	"sort"
 	"strconv"
@@ -30,12 +35,16 @@ import (
 	"github.com/hashicorp/vault/helper/flag-slice"
 	"github.com/hashicorp/vault/helper/gated-writer"
 	"github.com/hashicorp/vault/helper/logformat"
+	"github.com/hashicorp/vault/helper/metricsutil"
 	"github.com/hashicorp/vault/helper/mlock"
+	"github.com/hashicorp/vault/helper/strutil"
 	vaulthttp "github.com/hashicorp/vault/http"
 	"github.com/hashicorp/vault/logical"
 	"github.com/hashicorp/vault/meta"
 	"github.com/hashicorp/vault/physical"
 	"github.com/hashicorp/vault/vault"
+	"github.com/hashicorp/vault/vault/seal/awskms"
+	"github.com/hashicorp/vault/vault/seal/pkcs11"
 	"github.com/hashicorp/vault/version"
 )
 
@@ -58,13 +67,15 @@ type ServerCommand struct {
 }
 
 func (c *ServerCommand) Run(args []string) int {
-	var dev, verifyOnly, devHA bool
+	var dev, verifyOnly, devHA, devTLS bool
 	var configPath []string
-	var logLevel, devRootTokenID, devListenAddress string
+	var logLevel, devRootTokenID, devListenAddress, devPluginDir string
 	flags := c.Meta.FlagSet("server", meta.FlagSetDefault)
 	flags.BoolVar(&dev, "dev", false, "")
 	flags.StringVar(&devRootTokenID, "dev-root-token-id", "", "")
 	flags.StringVar(&devListenAddress, "dev-listen-address", "", "")
+	flags.BoolVar(&devTLS, "dev-tls", false, "")
+	flags.StringVar(&devPluginDir, "dev-plugin-dir", "", "")
 	flags.StringVar(&logLevel, "log-level", "info", "")
 	flags.BoolVar(&verifyOnly, "verify-only", false, "")
 	flags.BoolVar(&devHA, "dev-ha", false, "")
@@ -77,31 +88,24 @@ func (c *ServerCommand) Run(args []string) int {
 	// Create a logger. We wrap it in a gated writer so that it doesn't
 	// start logging too early.
 	logGate := &gatedwriter.Writer{Writer: colorable.NewColorable(os.Stderr)}
-	var level int
-	switch logLevel {
-	case "trace":
-		level = log.LevelTrace
-	case "debug":
-		level = log.LevelDebug
-	case "info":
-		level = log.LevelInfo
-	case "notice":
-		level = log.LevelNotice
-	case "warn":
-		level = log.LevelWarn
-	case "err":
-		level = log.LevelError
-	default:
+	level, err := logformat.ParseLevel(logLevel)
+	if err != nil {
 		c.Ui.Output(fmt.Sprintf("Unknown log level %s", logLevel))
 		return 1
 	}
+	var logger log.Logger
 	switch strings.ToLower(os.Getenv("LOGXI_FORMAT")) {
 	case "vault", "vault_json", "vault-json", "vaultjson", "":
-		c.logger = logformat.NewVaultLoggerWithWriter(logGate, level)
+		logger = logformat.NewVaultLoggerWithWriter(logGate, level)
 	default:
-		c.logger = log.NewLogger(logGate, "vault")
-		c.logger.SetLevel(level)
+		logger = log.NewLogger(logGate, "vault")
+		logger.SetLevel(level)
 	}
+
+	// The log lines are streamed to the operators monitoring the server
+	// through sys/monitor, at the level of their choice
+	monitorLogger := logformat.NewMonitorLogger(logger)
+	c.logger = monitorLogger
 	grpclog.SetLogger(&grpclogFaker{
 		logger: c.logger,
 	})
@@ -129,16 +133,56 @@ func (c *ServerCommand) Run(args []string) int {
 			c.Ui.Error("Root token ID can only be specified with -dev")
 			flags.Usage()
 			return 1
+		case devTLS:
+			c.Ui.Error("Dev TLS can only be enabled with -dev")
+			flags.Usage()
+			return 1
+		case devPluginDir != "":
+			c.Ui.Error("Plugin directory can only be specified with -dev")
+			flags.Usage()
+			return 1
 		}
 	}
 
 	// Load the configuration
 	var config *server.Config
+	var devCACert string
 	if dev {
 		config = server.DevConfig(devHA)
 		if devListenAddress != "" {
 			config.Listeners[0].Config["address"] = devListenAddress
 		}
+
+		// The certificates of a dev TLS listener are signed by a throwaway
+		// CA, which is deleted along with them on shutdown
+		if devTLS {
+			devTLSDir, err := ioutil.TempDir("", "vault-dev-tls")
+			if err != nil {
+				c.Ui.Error(fmt.Sprintf("Error creating the dev TLS directory: %s", err))
+				return 1
+			}
+			defer os.RemoveAll(devTLSDir)
+
+			host, _, _ := net.SplitHostPort(config.Listeners[0].Config["address"])
+			if err := server.GenerateDevTLS(devTLSDir, host); err != nil {
+				c.Ui.Error(fmt.Sprintf("Error generating the dev TLS certificates: %s", err))
+				return 1
+			}
+
+			delete(config.Listeners[0].Config, "tls_disable")
+			config.Listeners[0].Config["tls_cert_file"] = filepath.Join(devTLSDir, server.DevCertFileName)
+			config.Listeners[0].Config["tls_key_file"] = filepath.Join(devTLSDir, server.DevKeyFileName)
+			devCACert = filepath.Join(devTLSDir, server.DevCACertFileName)
+		}
+
+		if devPluginDir != "" {
+			dir, err := filepath.Abs(devPluginDir)
+			if err != nil {
+				c.Ui.Error(fmt.Sprintf("Error resolving the plugin directory: %s", err))
+				return 1
+			}
+			config.PluginDirectory = dir
+		}
 	}
 	for _, path := range configPath {
 		current, err := server.LoadConfig(path, c.logger)
@@ -176,7 +220,8 @@ func (c *ServerCommand) Run(args []string) int {
 		c.Ui.Output("  Vault on an mlockall(2) enabled system is much more secure.\n")
 	}
 
-	if err := c.setupTelemetry(config); err != nil {
+	inmemMetrics, err := c.setupTelemetry(config)
+	if err != nil {
 		c.Ui.Error(fmt.Sprintf("Error initializing telemetry: %s", err))
 		return 1
 	}
@@ -194,21 +239,61 @@ func (c *ServerCommand) Run(args []string) int {
 	infoKeys := make([]string, 0, 10)
 	info := make(map[string]string)
 
-	var seal vault.Seal = &vault.DefaultSeal{}
+	// A disabled seal is the seal Vault is currently sealed with, which is
+	// migrated to the enabled seal, or to Shamir if there is none
+	var seal, migrationSeal vault.Seal
+	var seals []vault.Seal
 
-	// Ensure that the seal finalizer is called, even if using verify-only
+	// Ensure that the seal finalizers are called, even if using verify-only
 	defer func() {
-		err = seal.Finalize()
-		if err != nil {
-			c.Ui.Error(fmt.Sprintf("Error finalizing seals: %v", err))
+		for _, s := range seals {
+			if err := s.Finalize(); err != nil {
+				c.Ui.Error(fmt.Sprintf("Error finalizing seals: %v", err))
+			}
 		}
 	}()
 
+	if len(config.Seals) > 0 && dev {
+		c.Ui.Error("A seal cannot be configured in dev mode")
+		return 1
+	}
+	for _, sealConfig := range config.Seals {
+		s, err := newSeal(sealConfig)
+		if err != nil {
+			c.Ui.Error(fmt.Sprintf(
+				"Error initializing seal of type %s: %s",
+				sealConfig.Type, err))
+			return 1
+		}
+		seals = append(seals, s)
+		if sealConfig.Disabled {
+			seal = s
+		} else {
+			migrationSeal = s
+		}
+	}
+	switch {
+	case seal == nil && migrationSeal == nil:
+		seal = &vault.DefaultSeal{}
+	case seal == nil:
+		seal, migrationSeal = migrationSeal, nil
+	case migrationSeal == nil:
+		migrationSeal = &vault.DefaultSeal{}
+	}
+	if len(config.Seals) > 0 {
+		info["seal"] = seal.BarrierType()
+		if migrationSeal != nil {
+			info["seal"] += fmt.Sprintf(" (migrating to %s)", migrationSeal.BarrierType())
+		}
+		infoKeys = append(infoKeys, "seal")
+	}
+
 	coreConfig := &vault.CoreConfig{
 		Physical:           backend,
 		RedirectAddr:       config.Backend.RedirectAddr,
 		HAPhysical:         nil,
 		Seal:               seal,
+		MigrationSeal:      migrationSeal,
 		AuditBackends:      c.AuditBackends,
 		CredentialBackends: c.CredentialBackends,
 		LogicalBackends:    c.LogicalBackends,
@@ -219,6 +304,12 @@ func (c *ServerCommand) Run(args []string) int {
 		DefaultLeaseTTL:    config.DefaultLeaseTTL,
 		ClusterName:        config.ClusterName,
 		CacheSize:          config.CacheSize,
+		PluginDirectory:    config.PluginDirectory,
+	}
+
+	// The cache size of the storage stanza overrides the top-level one
+	if config.Backend.CacheSize != 0 {
+		coreConfig.CacheSize = config.Backend.CacheSize
 	}
 
 	var disableClustering bool
@@ -370,7 +461,63 @@ func (c *ServerCommand) Run(args []string) int {
 
 	// Initialize the listeners
 	lns := make([]net.Listener, 0, len(config.Listeners))
+	maxRequestSizes := make([]int64, 0, len(config.Listeners))
+	maxRequestDurations := make([]time.Duration, 0, len(config.Listeners))
+	lnPurposes := make([][]string, 0, len(config.Listeners))
+	lnForwardedFor := make([]*server.ForwardedForConfig, 0, len(config.Listeners))
+	lnUnauthenticatedMetrics := make([]bool, 0, len(config.Listeners))
+	lnHTTPTimeouts := make([]*server.HTTPTimeouts, 0, len(config.Listeners))
 	for i, lnConfig := range config.Listeners {
+		purposes, err := server.ListenerPurposes(lnConfig.Config)
+		if err != nil {
+			c.Ui.Error(fmt.Sprintf(
+				"Error initializing listener of type %s: %s",
+				lnConfig.Type, err))
+			return 1
+		}
+
+		maxRequestSize, maxRequestDuration, err := server.RequestLimits(lnConfig.Config)
+		if err != nil {
+			c.Ui.Error(fmt.Sprintf(
+				"Error initializing listener of type %s: %s",
+				lnConfig.Type, err))
+			return 1
+		}
+		if maxRequestSize == 0 {
+			maxRequestSize = vaulthttp.DefaultMaxRequestSize
+		}
+		if maxRequestDuration == 0 {
+			maxRequestDuration = vaulthttp.DefaultMaxRequestDuration
+		}
+
+		forwardedFor, err := server.ForwardedFor(lnConfig.Config)
+		if err != nil {
+			c.Ui.Error(fmt.Sprintf(
+				"Error initializing listener of type %s: %s",
+				lnConfig.Type, err))
+			return 1
+		}
+
+		unauthenticatedMetrics, err := server.UnauthenticatedMetricsAccess(lnConfig.Config)
+		if err != nil {
+			c.Ui.Error(fmt.Sprintf(
+				"Error initializing listener of type %s: %s",
+				lnConfig.Type, err))
+			return 1
+		}
+
+		httpTimeouts, err := server.ListenerHTTPTimeouts(lnConfig.Config)
+		if err != nil {
+			c.Ui.Error(fmt.Sprintf(
+				"Error initializing listener of type %s: %s",
+				lnConfig.Type, err))
+			return 1
+		}
+		for _, key := range server.UnsupportedHTTPTimeouts(lnConfig.Config) {
+			c.logger.Warn("server: listener option requires Go 1.8 and is ignored",
+				"type", lnConfig.Type, "option", key)
+		}
+
 		ln, props, reloadFunc, err := server.NewListener(lnConfig.Type, lnConfig.Config, logGate)
 		if err != nil {
 			c.Ui.Error(fmt.Sprintf(
@@ -380,6 +527,12 @@ func (c *ServerCommand) Run(args []string) int {
 		}
 
 		lns = append(lns, ln)
+		maxRequestSizes = append(maxRequestSizes, maxRequestSize)
+		maxRequestDurations = append(maxRequestDurations, maxRequestDuration)
+		lnPurposes = append(lnPurposes, purposes)
+		lnForwardedFor = append(lnForwardedFor, forwardedFor)
+		lnUnauthenticatedMetrics = append(lnUnauthenticatedMetrics, unauthenticatedMetrics)
+		lnHTTPTimeouts = append(lnHTTPTimeouts, httpTimeouts)
 
 		if reloadFunc != nil {
 			relSlice := c.ReloadFuncs["listener|"+lnConfig.Type]
@@ -387,7 +540,9 @@ func (c *ServerCommand) Run(args []string) int {
 			c.ReloadFuncs["listener|"+lnConfig.Type] = relSlice
 		}
 
-		if !disableClustering && lnConfig.Type == "tcp" {
+		props["purpose"] = strings.Join(purposes, ",")
+
+		if !disableClustering && lnConfig.Type == "tcp" && strutil.StrListContains(purposes, server.ListenerPurposeCluster) {
 			var addr string
 			var ok bool
 			if addr, ok = lnConfig.Config["cluster_address"]; ok {
@@ -506,6 +661,16 @@ func (c *ServerCommand) Run(args []string) int {
 			return 1
 		}
 
+		var plugins []string
+		if devPluginDir != "" {
+			plugins, err = c.addDevPlugins(core, init.RootToken, config.PluginDirectory)
+			if err != nil {
+				c.Ui.Error(fmt.Sprintf(
+					"Error registering the dev plugins: %s", err))
+				return 1
+			}
+		}
+
 		export := "export"
 		quote := "'"
 		if runtime.GOOS == "windows" {
@@ -513,6 +678,13 @@ func (c *ServerCommand) Run(args []string) int {
 			quote = ""
 		}
 
+		scheme := "http"
+		var caCertEnv string
+		if devCACert != "" {
+			scheme = "https"
+			caCertEnv = "    " + export + " VAULT_CACERT=" + quote + devCACert + quote + "\n\n"
+		}
+
 		c.Ui.Output(fmt.Sprintf(
 			"==> WARNING: Dev mode is enabled!\n\n"+
 				"In this mode, Vault is completely in-memory and unsealed.\n"+
@@ -521,20 +693,55 @@ func (c *ServerCommand) Run(args []string) int {
 				"immediately begin using the Vault CLI.\n\n"+
 				"The only step you need to take is to set the following\n"+
 				"environment variables:\n\n"+
-				"    "+export+" VAULT_ADDR="+quote+"http://"+config.Listeners[0].Config["address"]+quote+"\n\n"+
+				"    "+export+" VAULT_ADDR="+quote+scheme+"://"+config.Listeners[0].Config["address"]+quote+"\n\n"+
+				caCertEnv+
 				"The unseal key and root token are reproduced below in case you\n"+
 				"want to seal/unseal the Vault or play with authentication.\n\n"+
 				"Unseal Key: %s\nRoot Token: %s\n",
 			base64.StdEncoding.EncodeToString(init.SecretShares[0]),
 			init.RootToken,
 		))
+
+		if len(plugins) > 0 {
+			c.Ui.Output(fmt.Sprintf(
+				"The plugins of %s are registered in the catalog:\n\n    %s\n",
+				config.PluginDirectory, strings.Join(plugins, ", ")))
+		}
 	}
 
-	// Initialize the HTTP server
-	server := &http.Server{}
-	server.Handler = handler
-	for _, ln := range lns {
-		go server.Serve(ln)
+	// Initialize an HTTP server per listener, serving the API and the
+	// metrics according to its purposes and enforcing its request limits.
+	// Behind trusted proxies, the client address is taken from the
+	// X-Forwarded-For header, and the custom headers of the listener are
+	// added to every response. TLS listeners negotiate HTTP/2 with the
+	// clients supporting it.
+	for i, ln := range lns {
+		mux := http.NewServeMux()
+		switch {
+		case strutil.StrListContains(lnPurposes[i], server.ListenerPurposeMetrics), lnUnauthenticatedMetrics[i]:
+			mux.Handle(vaulthttp.MetricsPath, vaulthttp.MetricsHandler(inmemMetrics))
+		case strutil.StrListContains(lnPurposes[i], server.ListenerPurposeAPI):
+			mux.Handle(vaulthttp.MetricsPath, vaulthttp.AuthenticatedMetricsHandler(core, inmemMetrics))
+		}
+		if strutil.StrListContains(lnPurposes[i], server.ListenerPurposeAPI) {
+			mux.Handle(vaulthttp.MonitorPath, vaulthttp.MonitorHandler(core, monitorLogger))
+			mux.Handle("/", handler)
+		}
+
+		var lnHandler http.Handler = mux
+		if ff := lnForwardedFor[i]; ff != nil {
+			lnHandler = vaulthttp.WrapForwardedForHandler(lnHandler, ff.AuthorizedAddrs,
+				ff.RejectNotPresent, ff.RejectNotAuthorized, ff.HopSkips)
+		}
+
+		lnHandler = vaulthttp.WrapRequestLimits(lnHandler, maxRequestSizes[i], maxRequestDurations[i])
+		lnHandler = vaulthttp.WrapCustomHeadersHandler(lnHandler, config.Listeners[i].CustomResponseHeaders)
+
+		srv := &http.Server{
+			Handler: lnHandler,
+		}
+		lnHTTPTimeouts[i].ConfigureServer(srv)
+		go srv.Serve(ln)
 	}
 
 	if newCoreError != nil {
@@ -572,6 +779,26 @@ func (c *ServerCommand) Run(args []string) int {
 	return 0
 }
 
+// newSeal creates the seal configured by a seal stanza
+func newSeal(config *server.Seal) (vault.Seal, error) {
+	switch config.Type {
+	case awskms.SealType:
+		wrapper, err := awskms.NewWrapper(config.Config)
+		if err != nil {
+			return nil, err
+		}
+		return vault.NewAutoSeal(wrapper), nil
+	case pkcs11.SealType:
+		wrapper, err := pkcs11.NewWrapper(config.Config)
+		if err != nil {
+			return nil, err
+		}
+		return vault.NewAutoSeal(wrapper), nil
+	default:
+		return nil, fmt.Errorf("unknown seal type %s", config.Type)
+	}
+}
+
 func (c *ServerCommand) enableDev(core *vault.Core, rootTokenID string) (*vault.InitResult, error) {
 	// Initialize it with a basic single key
 	init, err := core.Initialize(&vault.InitParams{
@@ -666,6 +893,53 @@ func (c *ServerCommand) enableDev(core *vault.Core, rootTokenID string) (*vault.
 	return init, nil
 }
 
+// addDevPlugins registers the executables of the plugin directory in the
+// catalog, named after their file, and returns their names
+func (c *ServerCommand) addDevPlugins(core *vault.Core, rootToken, dir string) ([]string, error) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var plugins []string
+	for _, fi := range files {
+		if !fi.Mode().IsRegular() || fi.Mode().Perm()&0111 == 0 {
+			continue
+		}
+
+		f, err := os.Open(filepath.Join(dir, fi.Name()))
+		if err != nil {
+			return nil, err
+		}
+		hash := sha256.New()
+		_, err = io.Copy(hash, f)
+		f.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		resp, err := core.HandleRequest(&logical.Request{
+			ID:          "dev-register-plugin",
+			Operation:   logical.UpdateOperation,
+			ClientToken: rootToken,
+			Path:        "sys/plugins/catalog/" + fi.Name(),
+			Data: map[string]interface{}{
+				"command": fi.Name(),
+				"sha256":  hex.EncodeToString(hash.Sum(nil)),
+			},
+		})
+		if err == nil && resp.IsError() {
+			err = resp.Error()
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to register plugin %s: %s", fi.Name(), err)
+		}
+		plugins = append(plugins, fi.Name())
+	}
+
+	return plugins, nil
+}
+
 // detectRedirect is used to attempt redirect address detection
 func (c *ServerCommand) detectRedirect(detect physical.RedirectDetect,
 	config *server.Config) (string, error) {
@@ -736,8 +1010,9 @@ func (c *ServerCommand) detectRedirect(detect physical.RedirectDetect,
 	return url.String(), nil
 }
 
-// setupTelemetry is used to setup the telemetry sub-systems
-func (c *ServerCommand) setupTelemetry(config *server.Config) error {
+// setupTelemetry is used to setup the telemetry sub-systems, returning the
+// in-memory sink aggregating the metrics
+func (c *ServerCommand) setupTelemetry(config *server.Config) (*metrics.InmemSink, error) {
 	/* Setup telemetry
 	Aggregate on 10 second intervals for 1 minute. Expose the
 	metrics over stderr when there is a SIGUSR1 received.
@@ -760,7 +1035,7 @@ func (c *ServerCommand) setupTelemetry(config *server.Config) error {
 	if telConfig.StatsiteAddr != "" {
 		sink, err := metrics.NewStatsiteSink(telConfig.StatsiteAddr)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		fanout = append(fanout, sink)
 	}
@@ -769,7 +1044,21 @@ func (c *ServerCommand) setupTelemetry(config *server.Config) error {
 	if telConfig.StatsdAddr != "" {
 		sink, err := metrics.NewStatsdSink(telConfig.StatsdAddr)
 		if err != nil {
-			return err
+			return nil, err
+		}
+		fanout = append(fanout, sink)
+	}
+
+	// Configure the DogStatsD sink, which replaces the hostname in the keys
+	// with a tag
+	if telConfig.DogStatsdAddr != "" {
+		var hostName string
+		if metricsConf.EnableHostname {
+			hostName = metricsConf.HostName
+		}
+		sink, err := metricsutil.NewDogStatsdSink(c.logger, telConfig.DogStatsdAddr, hostName, telConfig.DogStatsdTags)
+		if err != nil {
+			return nil, err
 		}
 		fanout = append(fanout, sink)
 	}
@@ -799,7 +1088,7 @@ func (c *ServerCommand) setupTelemetry(config *server.Config) error {
 
 		sink, err := circonus.NewCirconusSink(cfg)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		sink.Start()
 		fanout = append(fanout, sink)
@@ -813,7 +1102,7 @@ func (c *ServerCommand) setupTelemetry(config *server.Config) error {
 		metricsConf.EnableHostname = false
 		metrics.NewGlobal(metricsConf, inm)
 	}
-	return nil
+	return inm, nil
 }
 
 func (c *ServerCommand) Reload(configPath []string) error {
@@ -897,6 +1186,15 @@ General Options:
                           with the VAULT_DEV_LISTEN_ADDRESS environment
                           variable.
 
+  -dev-tls                Serve TLS in Dev mode, with a certificate signed by
+                          a throwaway CA. The CA certificate is written to a
+                          temporary directory, which is printed on start and
+                          deleted on shutdown.
+
+  -dev-plugin-dir=""      If set, the plugin directory in Dev mode, whose
+                          executables are registered in the plugin catalog
+                          under their file name.
+
   -log-level=info         Log verbosity. Defaults to "info", will be output to
                           stderr. Supported values: "trace", "debug", "info",
                           "warn", "err"
@@ -25,6 +25,7 @@ type Config struct {
 	Listeners []*Listener `hcl:"-"`
 	Backend   *Backend    `hcl:"-"`
 	HABackend *Backend    `hcl:"-"`
+	Seals     []*Seal     `hcl:"-"`
 
 	CacheSize    int  `hcl:"cache_size"`
 	DisableCache bool `hcl:"disable_cache"`
@@ -38,6 +39,8 @@ type Config struct {
 	DefaultLeaseTTLRaw string        `hcl:"default_lease_ttl"`
 
 	ClusterName string `hcl:"cluster_name"`
+
+	PluginDirectory string `hcl:"plugin_directory"`
 }
 
 // DevConfig is a Config that is used for dev mode of Vault.
@@ -77,6 +80,10 @@ func DevConfig(ha bool) *Config {
 type Listener struct {
 	Type   string
 	Config map[string]string
+
+	// CustomResponseHeaders are the headers added to the responses, keyed
+	// by "default", a class of status codes such as "4xx" or a status code
+	CustomResponseHeaders map[string]map[string]string
 }
 
 func (l *Listener) GoString() string {
@@ -89,6 +96,7 @@ type Backend struct {
 	RedirectAddr      string
 	ClusterAddr       string
 	DisableClustering bool
+	CacheSize         int
 	Config            map[string]string
 }
 
@@ -96,6 +104,19 @@ func (b *Backend) GoString() string {
 	return fmt.Sprintf("*%#v", *b)
 }
 
+// Seal is the seal configuration for the server. If no seal is enabled,
+// the master key is split into unseal keys with Shamir's secret sharing. A
+// disabled seal is the seal to migrate away from.
+type Seal struct {
+	Type     string
+	Disabled bool
+	Config   map[string]string
+}
+
+func (s *Seal) GoString() string {
+	return fmt.Sprintf("*%#v", *s)
+}
+
 // Telemetry is the telemetry configuration for the server
 type Telemetry struct {
 	StatsiteAddr string `hcl:"statsite_address"`
@@ -103,6 +124,11 @@ type Telemetry struct {
 
 	DisableHostname bool `hcl:"disable_hostname"`
 
+	// DogStatsdAddr is the address of a DogStatsD agent to send the metrics
+	// to, tagged with DogStatsdTags, such as "env:prod"
+	DogStatsdAddr string   `hcl:"dogstatsd_addr"`
+	DogStatsdTags []string `hcl:"dogstatsd_tags"`
+
 	// Circonus: see https://github.com/circonus-labs/circonus-gometrics
 	// for more details on the various configuration options.
 	// Valid configuration combinations:
@@ -196,6 +222,11 @@ func (c *Config) Merge(c2 *Config) *Config {
 		result.HABackend = c2.HABackend
 	}
 
+	result.Seals = c.Seals
+	if len(c2.Seals) > 0 {
+		result.Seals = c2.Seals
+	}
+
 	result.Telemetry = c.Telemetry
 	if c2.Telemetry != nil {
 		result.Telemetry = c2.Telemetry
@@ -233,6 +264,11 @@ func (c *Config) Merge(c2 *Config) *Config {
 		result.ClusterName = c2.ClusterName
 	}
 
+	result.PluginDirectory = c.PluginDirectory
+	if c2.PluginDirectory != "" {
+		result.PluginDirectory = c2.PluginDirectory
+	}
+
 	return result
 }
 
@@ -294,6 +330,7 @@ func ParseConfig(d string, logger log.Logger) (*Config, error) {
 		"atlas",
 		"backend",
 		"ha_backend",
+		"seal",
 		"listener",
 		"cache_size",
 		"disable_cache",
@@ -302,6 +339,7 @@ func ParseConfig(d string, logger log.Logger) (*Config, error) {
 		"default_lease_ttl",
 		"max_lease_ttl",
 		"cluster_name",
+		"plugin_directory",
 	}
 	if err := checkHCLKeys(list, valid); err != nil {
 		return nil, err
@@ -319,6 +357,12 @@ func ParseConfig(d string, logger log.Logger) (*Config, error) {
 		}
 	}
 
+	if o := list.Filter("seal"); len(o.Items) > 0 {
+		if err := parseSeals(&result, o); err != nil {
+			return nil, fmt.Errorf("error parsing 'seal': %s", err)
+		}
+	}
+
 	if o := list.Filter("listener"); len(o.Items) > 0 {
 		if err := parseListeners(&result, o); err != nil {
 			return nil, fmt.Errorf("error parsing 'listener': %s", err)
@@ -457,10 +501,21 @@ func parseBackends(result *Config, list *ast.ObjectList) error {
 		delete(m, "disable_clustering")
 	}
 
+	// Pull out the cache size, which overrides the top-level one
+	var cacheSize int
+	if v, ok := m["cache_size"]; ok {
+		cacheSize, err = strconv.Atoi(v)
+		if err != nil {
+			return multierror.Prefix(err, fmt.Sprintf("backend.%s:", key))
+		}
+		delete(m, "cache_size")
+	}
+
 	result.Backend = &Backend{
 		RedirectAddr:      redirectAddr,
 		ClusterAddr:       clusterAddr,
 		DisableClustering: disableClustering,
+		CacheSize:         cacheSize,
 		Type:              strings.ToLower(key),
 		Config:            m,
 	}
@@ -523,6 +578,53 @@ func parseHABackends(result *Config, list *ast.ObjectList) error {
 	return nil
 }
 
+func parseSeals(result *Config, list *ast.ObjectList) error {
+	if len(list.Items) > 2 {
+		return fmt.Errorf("only two 'seal' blocks are permitted")
+	}
+
+	seals := make([]*Seal, 0, len(list.Items))
+	enabled := 0
+	for _, item := range list.Items {
+		if len(item.Keys) == 0 {
+			return fmt.Errorf("seal type must be specified")
+		}
+		key := item.Keys[0].Token.Value().(string)
+
+		var m map[string]string
+		if err := hcl.DecodeObject(&m, item.Val); err != nil {
+			return multierror.Prefix(err, fmt.Sprintf("seal.%s:", key))
+		}
+
+		// Pull out the disabled flag since it's common to all seals
+		var disabled bool
+		if v, ok := m["disabled"]; ok {
+			var err error
+			disabled, err = strconv.ParseBool(v)
+			if err != nil {
+				return multierror.Prefix(err, fmt.Sprintf("seal.%s:", key))
+			}
+			delete(m, "disabled")
+		}
+		if !disabled {
+			enabled++
+		}
+
+		seals = append(seals, &Seal{
+			Type:     strings.ToLower(key),
+			Disabled: disabled,
+			Config:   m,
+		})
+	}
+
+	if len(seals) == 2 && enabled != 1 {
+		return fmt.Errorf("with two 'seal' blocks, exactly one must be disabled")
+	}
+
+	result.Seals = seals
+	return nil
+}
+
 func parseListeners(result *Config, list *ast.ObjectList) error {
 	var foundAtlas bool
 
@@ -536,21 +638,48 @@ func parseListeners(result *Config, list *ast.ObjectList) error {
 		valid := []string{
 			"address",
 			"cluster_address",
+			"custom_response_headers",
 			"endpoint",
+			"http_idle_timeout",
+			"http_read_header_timeout",
+			"http_read_timeout",
+			"http_write_timeout",
 			"infrastructure",
+			"max_request_duration",
+			"max_request_size",
 			"node_id",
+			"purpose",
+			"socket_mode",
+			"socket_user",
+			"socket_group",
 			"tls_disable",
 			"tls_cert_file",
 			"tls_key_file",
 			"tls_min_version",
+			"tls_cipher_suites",
+			"tls_prefer_server_cipher_suites",
+			"tls_require_and_verify_client_cert",
+			"tls_client_ca_file",
 			"token",
+			"unauthenticated_metrics_access",
+			"x_forwarded_for_authorized_addrs",
+			"x_forwarded_for_hop_skips",
+			"x_forwarded_for_reject_not_authorized",
+			"x_forwarded_for_reject_not_present",
 		}
 		if err := checkHCLKeys(item.Val, valid); err != nil {
 			return multierror.Prefix(err, fmt.Sprintf("listeners.%s:", key))
 		}
 
+		// The custom response headers are nested objects, decoded apart from
+		// the other options
+		val, customHeaders, err := parseCustomResponseHeaders(item.Val)
+		if err != nil {
+			return multierror.Prefix(err, fmt.Sprintf("listeners.%s:", key))
+		}
+
 		var m map[string]string
-		if err := hcl.DecodeObject(&m, item.Val); err != nil {
+		if err := hcl.DecodeObject(&m, val); err != nil {
 			return multierror.Prefix(err, fmt.Sprintf("listeners.%s:", key))
 		}
 
@@ -573,9 +702,14 @@ func parseListeners(result *Config, list *ast.ObjectList) error {
 			}
 		}
 
+		if _, err := ListenerPurposes(m); err != nil {
+			return multierror.Prefix(err, fmt.Sprintf("listeners.%s", key))
+		}
+
 		listeners = append(listeners, &Listener{
-			Type:   lnType,
-			Config: m,
+			Type:                  lnType,
+			Config:                m,
+			CustomResponseHeaders: customHeaders,
 		})
 	}
 
@@ -583,6 +717,58 @@ func parseListeners(result *Config, list *ast.ObjectList) error {
 	return nil
 }
 
+// parseCustomResponseHeaders returns the custom_response_headers object of
+// a listener, keyed by status code class, and the listener without it
+func parseCustomResponseHeaders(node ast.Node) (ast.Node, map[string]map[string]string, error) {
+	obj, ok := node.(*ast.ObjectType)
+	if !ok {
+		return node, nil, nil
+	}
+
+	var items []*ast.ObjectItem
+	var headersItem *ast.ObjectItem
+	for _, item := range obj.List.Items {
+		if item.Keys[0].Token.Value().(string) != "custom_response_headers" {
+			items = append(items, item)
+			continue
+		}
+		if headersItem != nil {
+			return nil, nil, fmt.Errorf("only one 'custom_response_headers' block is permitted")
+		}
+		headersItem = item
+	}
+	if headersItem == nil {
+		return node, nil, nil
+	}
+
+	var headers map[string]map[string]string
+	if err := hcl.DecodeObject(&headers, headersItem.Val); err != nil {
+		return nil, nil, multierror.Prefix(err, "custom_response_headers:")
+	}
+	for class := range headers {
+		if !validStatusClass(class) {
+			return nil, nil, fmt.Errorf("custom_response_headers: invalid status code class %q, must be \"default\", a class such as \"4xx\" or a status code", class)
+		}
+	}
+
+	return &ast.ObjectType{List: &ast.ObjectList{Items: items}}, headers, nil
+}
+
+// validStatusClass returns whether class is "default", a class of status
+// codes such as "4xx", or a status code
+func validStatusClass(class string) bool {
+	if class == "default" {
+		return true
+	}
+	if len(class) != 3 || class[0] < '1' || class[0] > '5' {
+		return false
+	}
+	if class[1:] == "xx" {
+		return true
+	}
+	return class[1] >= '0' && class[1] <= '9' && class[2] >= '0' && class[2] <= '9'
+}
+
 func parseTelemetry(result *Config, list *ast.ObjectList) error {
 	if len(list.Items) > 1 {
 		return fmt.Errorf("only one 'telemetry' block is permitted")
@@ -605,6 +791,8 @@ func parseTelemetry(result *Config, list *ast.ObjectList) error {
 		"circonus_broker_id",
 		"circonus_broker_select_tag",
 		"disable_hostname",
+		"dogstatsd_addr",
+		"dogstatsd_tags",
 		"statsd_address",
 		"statsite_address",
 	}
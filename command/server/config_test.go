@@ -44,6 +44,7 @@ func TestLoadConfigFile(t *testing.T) {
 				"foo": "bar",
 			},
 			DisableClustering: true,
+			CacheSize:         2048,
 		},
 
 		HABackend: &Backend{
@@ -54,10 +55,32 @@ func TestLoadConfigFile(t *testing.T) {
 			},
 		},
 
+		Seals: []*Seal{
+			&Seal{
+				Type: "awskms",
+				Config: map[string]string{
+					"region":     "us-east-1",
+					"kms_key_id": "alias/vault",
+				},
+			},
+			&Seal{
+				Type:     "pkcs11",
+				Disabled: true,
+				Config: map[string]string{
+					"lib":       "/usr/lib/libsofthsm2.so",
+					"slot":      "0",
+					"pin":       "1234",
+					"key_label": "vault",
+				},
+			},
+		},
+
 		Telemetry: &Telemetry{
 			StatsdAddr:      "bar",
 			StatsiteAddr:    "foo",
 			DisableHostname: false,
+			DogStatsdAddr:   "127.0.0.1:8125",
+			DogStatsdTags:   []string{"env:test", "role:vault"},
 		},
 
 		DisableCache: true,
@@ -288,6 +311,58 @@ listener "tcp" {
 	}
 }
 
+func TestParseConfig_customResponseHeaders(t *testing.T) {
+	logger := logformat.NewVaultLogger(log.LevelTrace)
+
+	config, err := ParseConfig(strings.TrimSpace(`
+listener "tcp" {
+	address = "127.0.0.1:8200"
+	custom_response_headers {
+		"default" {
+			"Strict-Transport-Security" = "max-age=31536000"
+		}
+		"4xx" {
+			"Cache-Control" = "no-store"
+		}
+	}
+}
+`), logger)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	expected := []*Listener{
+		&Listener{
+			Type: "tcp",
+			Config: map[string]string{
+				"address": "127.0.0.1:8200",
+			},
+			CustomResponseHeaders: map[string]map[string]string{
+				"default": {"Strict-Transport-Security": "max-age=31536000"},
+				"4xx":     {"Cache-Control": "no-store"},
+			},
+		},
+	}
+	if !reflect.DeepEqual(config.Listeners, expected) {
+		t.Fatalf("bad: %#v", config.Listeners[0])
+	}
+
+	for _, class := range []string{"6xx", "4x", "40", "abc"} {
+		_, err := ParseConfig(`
+listener "tcp" {
+	custom_response_headers {
+		"`+class+`" {
+			"Cache-Control" = "no-store"
+		}
+	}
+}
+`, logger)
+		if err == nil || !strings.Contains(err.Error(), "invalid status code class") {
+			t.Fatalf("%s: bad error: %v", class, err)
+		}
+	}
+}
+
 func TestParseConfig_badTelemetry(t *testing.T) {
 	logger := logformat.NewVaultLogger(log.LevelTrace)
 
@@ -311,3 +386,25 @@ telemetry {
 		t.Errorf("bad error: %q", err)
 	}
 }
+
+func TestParseConfig_badSeals(t *testing.T) {
+	logger := logformat.NewVaultLogger(log.LevelTrace)
+
+	_, err := ParseConfig(strings.TrimSpace(`
+seal "awskms" {
+	kms_key_id = "alias/vault"
+}
+
+seal "pkcs11" {
+	key_label = "vault"
+}
+`), logger)
+
+	if err == nil {
+		t.Fatal("expected error")
+	}
+
+	if !strings.Contains(err.Error(), "exactly one must be disabled") {
+		t.Errorf("bad error: %q", err)
+	}
+}
@@ -0,0 +1,111 @@
+package server
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"path/filepath"
+	"time"
+)
+
+const (
+	// DevCACertFileName, DevCertFileName and DevKeyFileName are the names of
+	// the files written by GenerateDevTLS
+	DevCACertFileName = "vault-ca.pem"
+	DevCertFileName   = "vault-cert.pem"
+	DevKeyFileName    = "vault-key.pem"
+
+	// devTLSValidity is how long the dev certificates are valid for
+	devTLSValidity = 30 * 24 * time.Hour
+)
+
+// GenerateDevTLS writes a throwaway CA certificate into the directory, with
+// a server certificate and its key signed by the CA, valid for localhost,
+// the loopback addresses and the extra hosts. The key of the CA isn't kept.
+func GenerateDevTLS(dir string, hosts ...string) error {
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return fmt.Errorf("failed to generate CA key: %v", err)
+	}
+	notBefore := time.Now().Add(-time.Minute)
+	caTemplate := &x509.Certificate{
+		SerialNumber:          devSerialNumber(),
+		Subject:               pkix.Name{CommonName: "Vault Dev CA"},
+		NotBefore:             notBefore,
+		NotAfter:              notBefore.Add(devTLSValidity),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, caKey.Public(), caKey)
+	if err != nil {
+		return fmt.Errorf("failed to generate CA certificate: %v", err)
+	}
+	caCert, err := x509.ParseCertificate(caDER)
+	if err != nil {
+		return fmt.Errorf("failed to parse CA certificate: %v", err)
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return fmt.Errorf("failed to generate key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: devSerialNumber(),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		NotBefore:    notBefore,
+		NotAfter:     notBefore.Add(devTLSValidity),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		DNSNames:     []string{"localhost"},
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1), net.IPv6loopback},
+	}
+	for _, host := range hosts {
+		if ip := net.ParseIP(host); ip != nil {
+			if !ip.IsUnspecified() {
+				template.IPAddresses = append(template.IPAddresses, ip)
+			}
+		} else if host != "" {
+			template.DNSNames = append(template.DNSNames, host)
+		}
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, caCert, key.Public(), caKey)
+	if err != nil {
+		return fmt.Errorf("failed to generate certificate: %v", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return fmt.Errorf("failed to encode key: %v", err)
+	}
+
+	files := []struct {
+		name  string
+		block *pem.Block
+	}{
+		{DevCACertFileName, &pem.Block{Type: "CERTIFICATE", Bytes: caDER}},
+		{DevCertFileName, &pem.Block{Type: "CERTIFICATE", Bytes: der}},
+		{DevKeyFileName, &pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}},
+	}
+	for _, f := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, f.name), pem.EncodeToMemory(f.block), 0600); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func devSerialNumber() *big.Int {
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		// The serial number only has to be unique for the CA
+		return big.NewInt(time.Now().UnixNano())
+	}
+	return serial
+}
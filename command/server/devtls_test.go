@@ -0,0 +1,49 @@
+package server
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestGenerateDevTLS(t *testing.T) {
+	dir, err := ioutil.TempDir("", "vault-dev-tls")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	if err := GenerateDevTLS(dir, "vault.local", "0.0.0.0"); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	caPEM, err := ioutil.ReadFile(filepath.Join(dir, DevCACertFileName))
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(caPEM) {
+		t.Fatal("bad CA certificate")
+	}
+
+	pair, err := tls.LoadX509KeyPair(filepath.Join(dir, DevCertFileName), filepath.Join(dir, DevKeyFileName))
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	cert, err := x509.ParseCertificate(pair.Certificate[0])
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	for _, host := range []string{"localhost", "127.0.0.1", "::1", "vault.local"} {
+		if _, err := cert.Verify(x509.VerifyOptions{DNSName: host, Roots: pool}); err != nil {
+			t.Fatalf("%s: err: %s", host, err)
+		}
+	}
+	if _, err := cert.Verify(x509.VerifyOptions{DNSName: "0.0.0.0", Roots: pool}); err == nil {
+		t.Fatal("the unspecified address shouldn't be valid")
+	}
+}
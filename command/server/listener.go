@@ -5,21 +5,42 @@ import (
 	// certificates that use it can be parsed.
 	_ "crypto/sha512"
 	"crypto/tls"
+	"crypto/x509"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"net"
+	"net/http"
 	"strconv"
+	"strings"
 	"sync"
+	"time"
 
+	"github.com/hashicorp/vault/helper/duration"
+	"github.com/hashicorp/vault/helper/strutil"
 	"github.com/hashicorp/vault/helper/tlsutil"
 )
 
+const (
+	// ListenerPurposeAPI is the purpose of the listeners serving the API
+	ListenerPurposeAPI = "api"
+
+	// ListenerPurposeCluster is the purpose of the listeners whose cluster
+	// address is used for request forwarding
+	ListenerPurposeCluster = "cluster"
+
+	// ListenerPurposeMetrics is the purpose of the listeners serving the
+	// telemetry metrics
+	ListenerPurposeMetrics = "metrics"
+)
+
 // ListenerFactory is the factory function to create a listener.
 type ListenerFactory func(map[string]string, io.Writer) (net.Listener, map[string]string, ReloadFunc, error)
 
 // BuiltinListeners is the list of built-in listener types.
 var BuiltinListeners = map[string]ListenerFactory{
 	"tcp":   tcpListenerFactory,
+	"unix":  unixListenerFactory,
 	"atlas": atlasListenerFactory,
 }
 
@@ -34,6 +55,204 @@ func NewListener(t string, config map[string]string, logger io.Writer) (net.List
 	return f(config, logger)
 }
 
+// RequestLimits returns the maximum request size and duration configured
+// for a listener. Unset limits are returned as 0 so that the defaults
+// apply; limits set to 0 or less are returned as negative, disabling them.
+func RequestLimits(config map[string]string) (int64, time.Duration, error) {
+	var maxSize int64
+	var maxDuration time.Duration
+
+	if v, ok := config["max_request_size"]; ok {
+		size, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return 0, 0, fmt.Errorf("invalid value for 'max_request_size': %v", err)
+		}
+		maxSize = size
+		if maxSize <= 0 {
+			maxSize = -1
+		}
+	}
+
+	if v, ok := config["max_request_duration"]; ok {
+		dur, err := duration.ParseDurationSecond(v)
+		if err != nil {
+			return 0, 0, fmt.Errorf("invalid value for 'max_request_duration': %v", err)
+		}
+		maxDuration = dur
+		if maxDuration <= 0 {
+			maxDuration = -1
+		}
+	}
+
+	return maxSize, maxDuration, nil
+}
+
+// HTTPTimeouts are the timeouts of the HTTP server of a listener. A timeout
+// of zero or less isn't enforced.
+type HTTPTimeouts struct {
+	// ReadHeader is the time allowed to read the request headers
+	ReadHeader time.Duration
+
+	// Read is the time allowed to read the whole request
+	Read time.Duration
+
+	// Write is the time allowed to write the response, starting at the end
+	// of the headers for TLS connections
+	Write time.Duration
+
+	// Idle is how long keep-alive connections are kept between requests
+	Idle time.Duration
+}
+
+// ConfigureServer sets the timeouts of the HTTP server. The read header and
+// idle timeouts require Go 1.8. Older versions don't enforce the idle
+// timeout, and enforce the read header timeout on the whole request unless a
+// read timeout is set.
+func (t *HTTPTimeouts) ConfigureServer(srv *http.Server) {
+	srv.ReadTimeout = t.Read
+	srv.WriteTimeout = t.Write
+	configureServerTimeouts(srv, t)
+}
+
+// ListenerHTTPTimeouts returns the timeouts of the HTTP server of a listener,
+// set by its http_read_header_timeout, http_read_timeout, http_write_timeout
+// and http_idle_timeout options
+func ListenerHTTPTimeouts(config map[string]string) (*HTTPTimeouts, error) {
+	timeouts := &HTTPTimeouts{
+		ReadHeader: 10 * time.Second,
+		Idle:       5 * time.Minute,
+	}
+
+	for key, timeout := range map[string]*time.Duration{
+		"http_read_header_timeout": &timeouts.ReadHeader,
+		"http_read_timeout":        &timeouts.Read,
+		"http_write_timeout":       &timeouts.Write,
+		"http_idle_timeout":        &timeouts.Idle,
+	} {
+		v, ok := config[key]
+		if !ok {
+			continue
+		}
+		dur, err := duration.ParseDurationSecond(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value for '%s': %v", key, err)
+		}
+		*timeout = dur
+	}
+
+	return timeouts, nil
+}
+
+// ListenerPurposes returns what a listener is used for, set by its
+// comma-separated purpose option. Listeners serve the API and are used for
+// request forwarding by default.
+func ListenerPurposes(config map[string]string) ([]string, error) {
+	v, ok := config["purpose"]
+	if !ok {
+		return []string{ListenerPurposeAPI, ListenerPurposeCluster}, nil
+	}
+
+	var purposes []string
+	for _, purpose := range strings.Split(v, ",") {
+		purpose = strings.ToLower(strings.TrimSpace(purpose))
+		switch purpose {
+		case ListenerPurposeAPI, ListenerPurposeCluster, ListenerPurposeMetrics:
+		default:
+			return nil, fmt.Errorf("invalid value for 'purpose': %q", purpose)
+		}
+		if !strutil.StrListContains(purposes, purpose) {
+			purposes = append(purposes, purpose)
+		}
+	}
+
+	if strutil.StrListContains(purposes, ListenerPurposeCluster) &&
+		!strutil.StrListContains(purposes, ListenerPurposeAPI) {
+		return nil, fmt.Errorf("the %q purpose requires the %q purpose", ListenerPurposeCluster, ListenerPurposeAPI)
+	}
+	return purposes, nil
+}
+
+// UnauthenticatedMetricsAccess returns whether the API listener serves the
+// metrics to clients without a token
+func UnauthenticatedMetricsAccess(config map[string]string) (bool, error) {
+	v, ok := config["unauthenticated_metrics_access"]
+	if !ok {
+		return false, nil
+	}
+
+	unauthenticated, err := strconv.ParseBool(v)
+	if err != nil {
+		return false, fmt.Errorf("invalid value for 'unauthenticated_metrics_access': %v", err)
+	}
+	return unauthenticated, nil
+}
+
+// ForwardedForConfig is the configuration of the X-Forwarded-For handling of
+// a listener
+type ForwardedForConfig struct {
+	// AuthorizedAddrs are the addresses of the proxies trusted to set the
+	// X-Forwarded-For header
+	AuthorizedAddrs []*net.IPNet
+
+	// HopSkips is the number of addresses to skip from the end of the
+	// header, set by the trusted proxies in front of the authorized one
+	HopSkips int
+
+	// RejectNotAuthorized rejects the requests with the header coming from
+	// unauthorized addresses, rather than ignoring the header
+	RejectNotAuthorized bool
+
+	// RejectNotPresent rejects the requests without the header
+	RejectNotPresent bool
+}
+
+// ForwardedFor returns the X-Forwarded-For configuration of a listener, or
+// nil if the client addresses of the requests aren't taken from the header
+func ForwardedFor(config map[string]string) (*ForwardedForConfig, error) {
+	v, ok := config["x_forwarded_for_authorized_addrs"]
+	if !ok {
+		return nil, nil
+	}
+
+	result := &ForwardedForConfig{
+		RejectNotAuthorized: true,
+		RejectNotPresent:    true,
+	}
+	for _, cidr := range strings.Split(v, ",") {
+		_, ipNet, err := net.ParseCIDR(strings.TrimSpace(cidr))
+		if err != nil {
+			return nil, fmt.Errorf("invalid value for 'x_forwarded_for_authorized_addrs': %v", err)
+		}
+		result.AuthorizedAddrs = append(result.AuthorizedAddrs, ipNet)
+	}
+
+	if v, ok := config["x_forwarded_for_hop_skips"]; ok {
+		hopSkips, err := strconv.Atoi(v)
+		if err != nil || hopSkips < 0 {
+			return nil, fmt.Errorf("invalid value for 'x_forwarded_for_hop_skips': %q", v)
+		}
+		result.HopSkips = hopSkips
+	}
+
+	if v, ok := config["x_forwarded_for_reject_not_authorized"]; ok {
+		reject, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value for 'x_forwarded_for_reject_not_authorized': %v", err)
+		}
+		result.RejectNotAuthorized = reject
+	}
+
+	if v, ok := config["x_forwarded_for_reject_not_present"]; ok {
+		reject, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value for 'x_forwarded_for_reject_not_present': %v", err)
+		}
+		result.RejectNotPresent = reject
+	}
+
+	return result, nil
+}
+
 func listenerWrapTLS(
 	ln net.Listener,
 	props map[string]string,
@@ -82,6 +301,41 @@ func listenerWrapTLS(
 	}
 	tlsConf.ClientAuth = tls.RequestClientCert
 
+	if v, ok := config["tls_cipher_suites"]; ok {
+		ciphers, err := tlsutil.ParseCiphers(v)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("invalid value for 'tls_cipher_suites': %v", err)
+		}
+		tlsConf.CipherSuites = ciphers
+	}
+	if v, ok := config["tls_require_and_verify_client_cert"]; ok {
+		require, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("invalid value for 'tls_require_and_verify_client_cert': %v", err)
+		}
+		if require {
+			tlsConf.ClientAuth = tls.RequireAndVerifyClientCert
+		}
+	}
+	if caFile, ok := config["tls_client_ca_file"]; ok {
+		caPool := x509.NewCertPool()
+		data, err := ioutil.ReadFile(caFile)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("failed to read 'tls_client_ca_file': %v", err)
+		}
+		if !caPool.AppendCertsFromPEM(data) {
+			return nil, nil, nil, fmt.Errorf("failed to parse CA certificate in 'tls_client_ca_file'")
+		}
+		tlsConf.ClientCAs = caPool
+	}
+	if v, ok := config["tls_prefer_server_cipher_suites"]; ok {
+		prefer, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("invalid value for 'tls_prefer_server_cipher_suites': %v", err)
+		}
+		tlsConf.PreferServerCipherSuites = prefer
+	}
+
 	ln = tls.NewListener(ln, tlsConf)
 	props["tls"] = "enabled"
 	return ln, props, cg.reload, nil
@@ -0,0 +1,31 @@
+// +build !go1.8
+
+package server
+
+import "net/http"
+
+// configureServerTimeouts enforces the read header timeout on the whole
+// request when no read timeout is set, since the HTTP servers have no read
+// header and idle timeouts before Go 1.8. This keeps clients that send their
+// headers slowly from holding connections open.
+func configureServerTimeouts(srv *http.Server, t *HTTPTimeouts) {
+	if srv.ReadTimeout <= 0 {
+		srv.ReadTimeout = t.ReadHeader
+	}
+}
+
+// UnsupportedHTTPTimeouts returns the HTTP timeout options of a listener
+// which aren't enforced before Go 1.8: the idle timeout, and the read
+// header timeout when a read timeout is set.
+func UnsupportedHTTPTimeouts(config map[string]string) []string {
+	var keys []string
+	if _, ok := config["http_read_header_timeout"]; ok {
+		if timeouts, err := ListenerHTTPTimeouts(config); err == nil && timeouts.Read > 0 {
+			keys = append(keys, "http_read_header_timeout")
+		}
+	}
+	if _, ok := config["http_idle_timeout"]; ok {
+		keys = append(keys, "http_idle_timeout")
+	}
+	return keys
+}
@@ -0,0 +1,46 @@
+// +build !go1.8
+
+package server
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestHTTPTimeouts_ConfigureServer(t *testing.T) {
+	// The read header timeout applies to the whole request
+	srv := &http.Server{}
+	timeouts := &HTTPTimeouts{ReadHeader: 10 * time.Second}
+	timeouts.ConfigureServer(srv)
+	if srv.ReadTimeout != 10*time.Second {
+		t.Fatalf("bad: %#v", srv)
+	}
+
+	srv = &http.Server{}
+	timeouts.Read = time.Minute
+	timeouts.ConfigureServer(srv)
+	if srv.ReadTimeout != time.Minute {
+		t.Fatalf("bad: %#v", srv)
+	}
+}
+
+func TestUnsupportedHTTPTimeouts(t *testing.T) {
+	keys := UnsupportedHTTPTimeouts(map[string]string{
+		"http_read_header_timeout": "5s",
+		"http_idle_timeout":        "1m",
+	})
+	if !reflect.DeepEqual(keys, []string{"http_idle_timeout"}) {
+		t.Fatalf("bad: %#v", keys)
+	}
+
+	// The read header timeout is ignored when a read timeout is set
+	keys = UnsupportedHTTPTimeouts(map[string]string{
+		"http_read_header_timeout": "5s",
+		"http_read_timeout":        "30s",
+	})
+	if !reflect.DeepEqual(keys, []string{"http_read_header_timeout"}) {
+		t.Fatalf("bad: %#v", keys)
+	}
+}
@@ -0,0 +1,18 @@
+// +build go1.8
+
+package server
+
+import "net/http"
+
+// configureServerTimeouts sets the timeouts of the HTTP server which require
+// Go 1.8
+func configureServerTimeouts(srv *http.Server, t *HTTPTimeouts) {
+	srv.ReadHeaderTimeout = t.ReadHeader
+	srv.IdleTimeout = t.Idle
+}
+
+// UnsupportedHTTPTimeouts returns the HTTP timeout options of a listener
+// which aren't enforced, none since Go 1.8
+func UnsupportedHTTPTimeouts(config map[string]string) []string {
+	return nil
+}
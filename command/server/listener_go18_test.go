@@ -0,0 +1,31 @@
+// +build go1.8
+
+package server
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestHTTPTimeouts_ConfigureServer(t *testing.T) {
+	srv := &http.Server{}
+	timeouts := &HTTPTimeouts{
+		ReadHeader: 10 * time.Second,
+		Idle:       5 * time.Minute,
+	}
+	timeouts.ConfigureServer(srv)
+	if srv.ReadHeaderTimeout != 10*time.Second || srv.ReadTimeout != 0 || srv.IdleTimeout != 5*time.Minute {
+		t.Fatalf("bad: %#v", srv)
+	}
+}
+
+func TestUnsupportedHTTPTimeouts(t *testing.T) {
+	keys := UnsupportedHTTPTimeouts(map[string]string{
+		"http_read_header_timeout": "5s",
+		"http_idle_timeout":        "1m",
+	})
+	if len(keys) != 0 {
+		t.Fatalf("bad: %#v", keys)
+	}
+}
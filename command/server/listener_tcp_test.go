@@ -7,9 +7,12 @@ import (
 	"io/ioutil"
 	"math/rand"
 	"net"
+	"net/http"
 	"os"
 	"testing"
 	"time"
+
+	"golang.org/x/net/http2"
 )
 
 func TestTCPListener(t *testing.T) {
@@ -72,3 +75,193 @@ func TestTCPListener_tls(t *testing.T) {
 
 	testListenerImpl(t, ln, connFn, "foo.example.com")
 }
+
+func TestTCPListener_tlsCipherSuites(t *testing.T) {
+	wd, _ := os.Getwd()
+	wd += "/test-fixtures/reload/"
+
+	inBytes, _ := ioutil.ReadFile(wd + "reload_ca.pem")
+	certPool := x509.NewCertPool()
+	if !certPool.AppendCertsFromPEM(inBytes) {
+		t.Fatal("not ok when appending CA cert")
+	}
+
+	ln, _, _, err := tcpListenerFactory(map[string]string{
+		"address":                         "127.0.0.1:0",
+		"tls_cert_file":                   wd + "reload_foo.pem",
+		"tls_key_file":                    wd + "reload_foo.key",
+		"tls_cipher_suites":               "TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384",
+		"tls_prefer_server_cipher_suites": "true",
+	}, nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	defer ln.Close()
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			conn.(*tls.Conn).Handshake()
+			conn.Close()
+		}
+	}()
+
+	dial := func(suites ...uint16) (*tls.Conn, error) {
+		return tls.Dial("tcp", ln.Addr().String(), &tls.Config{
+			RootCAs:      certPool,
+			MaxVersion:   tls.VersionTLS12,
+			CipherSuites: suites,
+		})
+	}
+
+	// A client without any of the configured suites is rejected
+	if conn, err := dial(tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256); err == nil {
+		conn.Close()
+		t.Fatal("expected handshake error")
+	}
+
+	conn, err := dial(tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256, tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	defer conn.Close()
+	if suite := conn.ConnectionState().CipherSuite; suite != tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384 {
+		t.Fatalf("bad: cipher suite %x", suite)
+	}
+
+	_, _, _, err = tcpListenerFactory(map[string]string{
+		"address":           "127.0.0.1:0",
+		"tls_cert_file":     wd + "reload_foo.pem",
+		"tls_key_file":      wd + "reload_foo.key",
+		"tls_cipher_suites": "TLS_BOGUS",
+	}, nil)
+	if err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+func TestTCPListener_tlsClientCert(t *testing.T) {
+	wd, _ := os.Getwd()
+	wd += "/test-fixtures/reload/"
+
+	inBytes, _ := ioutil.ReadFile(wd + "reload_ca.pem")
+	certPool := x509.NewCertPool()
+	if !certPool.AppendCertsFromPEM(inBytes) {
+		t.Fatal("not ok when appending CA cert")
+	}
+
+	clientCert, err := tls.LoadX509KeyPair(wd+"reload_bar.pem", wd+"reload_bar.key")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	ln, _, _, err := tcpListenerFactory(map[string]string{
+		"address":                            "127.0.0.1:0",
+		"tls_cert_file":                      wd + "reload_foo.pem",
+		"tls_key_file":                       wd + "reload_foo.key",
+		"tls_client_ca_file":                 wd + "reload_ca.pem",
+		"tls_require_and_verify_client_cert": "true",
+	}, nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	defer ln.Close()
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			if err := conn.(*tls.Conn).Handshake(); err == nil {
+				conn.Write([]byte("a"))
+			}
+			conn.Close()
+		}
+	}()
+
+	// The server writes a byte once the handshake succeeds, which is only
+	// known to the client after its first read with TLS 1.3
+	connect := func(certs []tls.Certificate) error {
+		conn, err := tls.Dial("tcp", ln.Addr().String(), &tls.Config{
+			RootCAs:      certPool,
+			Certificates: certs,
+		})
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+		_, err = conn.Read(make([]byte, 1))
+		return err
+	}
+
+	if err := connect(nil); err == nil {
+		t.Fatal("expected error without a client certificate")
+	}
+	if err := connect([]tls.Certificate{clientCert}); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	_, _, _, err = tcpListenerFactory(map[string]string{
+		"address":            "127.0.0.1:0",
+		"tls_cert_file":      wd + "reload_foo.pem",
+		"tls_key_file":       wd + "reload_foo.key",
+		"tls_client_ca_file": wd + "missing.pem",
+	}, nil)
+	if err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+func TestTCPListener_http2(t *testing.T) {
+	wd, _ := os.Getwd()
+	wd += "/test-fixtures/reload/"
+
+	inBytes, _ := ioutil.ReadFile(wd + "reload_ca.pem")
+	certPool := x509.NewCertPool()
+	if !certPool.AppendCertsFromPEM(inBytes) {
+		t.Fatal("not ok when appending CA cert")
+	}
+
+	ln, _, _, err := tcpListenerFactory(map[string]string{
+		"address":       "127.0.0.1:0",
+		"tls_cert_file": wd + "reload_foo.pem",
+		"tls_key_file":  wd + "reload_foo.key",
+	}, nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	defer ln.Close()
+
+	srv := &http.Server{
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(r.Proto))
+		}),
+	}
+	go srv.Serve(ln)
+
+	transport := &http.Transport{
+		TLSClientConfig: &tls.Config{
+			RootCAs:    certPool,
+			ServerName: "foo.example.com",
+		},
+	}
+	if err := http2.ConfigureTransport(transport); err != nil {
+		t.Fatal(err)
+	}
+	client := &http.Client{Transport: transport}
+
+	resp, err := client.Get("https://" + ln.Addr().String() + "/")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := ioutil.ReadAll(resp.Body)
+	if resp.ProtoMajor != 2 || string(body) != "HTTP/2.0" {
+		t.Fatalf("bad: %s %s", resp.Proto, body)
+	}
+}
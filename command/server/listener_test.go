@@ -5,7 +5,10 @@ import (
 	"crypto/tls"
 	"io"
 	"net"
+	"net/http"
+	"reflect"
 	"testing"
+	"time"
 )
 
 type testListenerConnFn func(net.Listener) (net.Conn, error)
@@ -62,3 +65,135 @@ func testListenerImpl(t *testing.T, ln net.Listener, connFn testListenerConnFn,
 		t.Fatalf("bad: %v", buf.String())
 	}
 }
+
+func TestRequestLimits(t *testing.T) {
+	maxSize, maxDuration, err := RequestLimits(map[string]string{})
+	if err != nil || maxSize != 0 || maxDuration != 0 {
+		t.Fatalf("bad: %d %s %v", maxSize, maxDuration, err)
+	}
+
+	maxSize, maxDuration, err = RequestLimits(map[string]string{
+		"max_request_size":     "1024",
+		"max_request_duration": "30s",
+	})
+	if err != nil || maxSize != 1024 || maxDuration != 30*time.Second {
+		t.Fatalf("bad: %d %s %v", maxSize, maxDuration, err)
+	}
+
+	maxSize, maxDuration, err = RequestLimits(map[string]string{
+		"max_request_size":     "0",
+		"max_request_duration": "-1",
+	})
+	if err != nil || maxSize >= 0 || maxDuration >= 0 {
+		t.Fatalf("bad: %d %s %v", maxSize, maxDuration, err)
+	}
+
+	if _, _, err := RequestLimits(map[string]string{"max_request_size": "big"}); err == nil {
+		t.Fatal("expected an error")
+	}
+}
+
+func TestListenerPurposes(t *testing.T) {
+	purposes, err := ListenerPurposes(map[string]string{})
+	if err != nil || !reflect.DeepEqual(purposes, []string{"api", "cluster"}) {
+		t.Fatalf("bad: %v %v", purposes, err)
+	}
+
+	purposes, err = ListenerPurposes(map[string]string{"purpose": "metrics"})
+	if err != nil || !reflect.DeepEqual(purposes, []string{"metrics"}) {
+		t.Fatalf("bad: %v %v", purposes, err)
+	}
+
+	purposes, err = ListenerPurposes(map[string]string{"purpose": "API, metrics, api"})
+	if err != nil || !reflect.DeepEqual(purposes, []string{"api", "metrics"}) {
+		t.Fatalf("bad: %v %v", purposes, err)
+	}
+
+	for _, invalid := range []string{"admin", "cluster", "metrics,cluster", ""} {
+		if _, err := ListenerPurposes(map[string]string{"purpose": invalid}); err == nil {
+			t.Fatalf("expected error for %q", invalid)
+		}
+	}
+}
+
+func TestForwardedFor(t *testing.T) {
+	ff, err := ForwardedFor(map[string]string{})
+	if err != nil || ff != nil {
+		t.Fatalf("bad: %#v %v", ff, err)
+	}
+
+	ff, err = ForwardedFor(map[string]string{
+		"x_forwarded_for_authorized_addrs": "10.0.0.0/8, 127.0.0.1/32",
+	})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(ff.AuthorizedAddrs) != 2 || ff.AuthorizedAddrs[1].String() != "127.0.0.1/32" {
+		t.Fatalf("bad: %v", ff.AuthorizedAddrs)
+	}
+	if ff.HopSkips != 0 || !ff.RejectNotAuthorized || !ff.RejectNotPresent {
+		t.Fatalf("bad: %#v", ff)
+	}
+
+	ff, err = ForwardedFor(map[string]string{
+		"x_forwarded_for_authorized_addrs":      "10.0.0.0/8",
+		"x_forwarded_for_hop_skips":             "2",
+		"x_forwarded_for_reject_not_authorized": "false",
+		"x_forwarded_for_reject_not_present":    "false",
+	})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if ff.HopSkips != 2 || ff.RejectNotAuthorized || ff.RejectNotPresent {
+		t.Fatalf("bad: %#v", ff)
+	}
+
+	for _, invalid := range []map[string]string{
+		{"x_forwarded_for_authorized_addrs": "10.0.0.1"},
+		{"x_forwarded_for_authorized_addrs": "10.0.0.0/8", "x_forwarded_for_hop_skips": "-1"},
+		{"x_forwarded_for_authorized_addrs": "10.0.0.0/8", "x_forwarded_for_reject_not_present": "maybe"},
+	} {
+		if _, err := ForwardedFor(invalid); err == nil {
+			t.Fatalf("expected error for %v", invalid)
+		}
+	}
+}
+
+func TestListenerHTTPTimeouts(t *testing.T) {
+	timeouts, err := ListenerHTTPTimeouts(map[string]string{})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	expected := &HTTPTimeouts{ReadHeader: 10 * time.Second, Idle: 5 * time.Minute}
+	if !reflect.DeepEqual(timeouts, expected) {
+		t.Fatalf("bad: %#v", timeouts)
+	}
+
+	timeouts, err = ListenerHTTPTimeouts(map[string]string{
+		"http_read_header_timeout": "5s",
+		"http_read_timeout":        "30s",
+		"http_write_timeout":       "1m",
+		"http_idle_timeout":        "0",
+	})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	expected = &HTTPTimeouts{
+		ReadHeader: 5 * time.Second,
+		Read:       30 * time.Second,
+		Write:      time.Minute,
+	}
+	if !reflect.DeepEqual(timeouts, expected) {
+		t.Fatalf("bad: %#v", timeouts)
+	}
+
+	srv := &http.Server{}
+	timeouts.ConfigureServer(srv)
+	if srv.ReadTimeout != 30*time.Second || srv.WriteTimeout != time.Minute {
+		t.Fatalf("bad: %#v", srv)
+	}
+
+	if _, err := ListenerHTTPTimeouts(map[string]string{"http_idle_timeout": "soon"}); err == nil {
+		t.Fatal("expected an error")
+	}
+}
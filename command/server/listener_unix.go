@@ -0,0 +1,94 @@
+package server
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/user"
+	"strconv"
+)
+
+func unixListenerFactory(config map[string]string, _ io.Writer) (net.Listener, map[string]string, ReloadFunc, error) {
+	addr, ok := config["address"]
+	if !ok {
+		return nil, nil, nil, fmt.Errorf("'address' must be set to the path of the socket")
+	}
+
+	// Remove the socket left by a previous run, but nothing else
+	if fi, err := os.Lstat(addr); err == nil {
+		if fi.Mode()&os.ModeSocket == 0 {
+			return nil, nil, nil, fmt.Errorf("%s already exists and is not a socket", addr)
+		}
+		if err := os.Remove(addr); err != nil {
+			return nil, nil, nil, fmt.Errorf("failed to remove the existing socket: %v", err)
+		}
+	}
+
+	ln, err := net.Listen("unix", addr)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	if err := setFilePermissions(addr, config); err != nil {
+		ln.Close()
+		return nil, nil, nil, err
+	}
+
+	props := map[string]string{"addr": addr}
+	return listenerWrapTLS(ln, props, config)
+}
+
+// setFilePermissions sets the owner, group and mode of the socket file from
+// the socket_user, socket_group and socket_mode options
+func setFilePermissions(path string, config map[string]string) error {
+	uid, gid := -1, -1
+
+	if v, ok := config["socket_user"]; ok {
+		id, err := strconv.Atoi(v)
+		if err != nil {
+			u, err := user.Lookup(v)
+			if err != nil {
+				return fmt.Errorf("invalid value for 'socket_user': %v", err)
+			}
+			id, err = strconv.Atoi(u.Uid)
+			if err != nil {
+				return fmt.Errorf("invalid uid for 'socket_user': %v", err)
+			}
+		}
+		uid = id
+	}
+
+	if v, ok := config["socket_group"]; ok {
+		id, err := strconv.Atoi(v)
+		if err != nil {
+			g, err := user.LookupGroup(v)
+			if err != nil {
+				return fmt.Errorf("invalid value for 'socket_group': %v", err)
+			}
+			id, err = strconv.Atoi(g.Gid)
+			if err != nil {
+				return fmt.Errorf("invalid gid for 'socket_group': %v", err)
+			}
+		}
+		gid = id
+	}
+
+	if uid != -1 || gid != -1 {
+		if err := os.Chown(path, uid, gid); err != nil {
+			return fmt.Errorf("failed to set the owner of the socket: %v", err)
+		}
+	}
+
+	if v, ok := config["socket_mode"]; ok {
+		mode, err := strconv.ParseUint(v, 8, 32)
+		if err != nil {
+			return fmt.Errorf("invalid value for 'socket_mode': %v", err)
+		}
+		if err := os.Chmod(path, os.FileMode(mode)); err != nil {
+			return fmt.Errorf("failed to set the mode of the socket: %v", err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,64 @@
+package server
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+func TestUnixListener(t *testing.T) {
+	td, err := ioutil.TempDir("", "vault-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(td)
+
+	path := filepath.Join(td, "vault.sock")
+
+	// A socket left by a previous run is replaced
+	stale, err := net.ListenPacket("unixgram", path)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	stale.Close()
+
+	ln, _, _, err := unixListenerFactory(map[string]string{
+		"address":      path,
+		"tls_disable":  "1",
+		"socket_mode":  "0600",
+		"socket_user":  strconv.Itoa(os.Getuid()),
+		"socket_group": strconv.Itoa(os.Getgid()),
+	}, nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	fi, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if fi.Mode().Perm() != 0600 {
+		t.Fatalf("bad: mode %v", fi.Mode())
+	}
+
+	connFn := func(lnReal net.Listener) (net.Conn, error) {
+		return net.Dial("unix", path)
+	}
+
+	testListenerImpl(t, ln, connFn, "")
+
+	// Files other than sockets aren't removed
+	regular := filepath.Join(td, "regular")
+	if err := ioutil.WriteFile(regular, nil, 0600); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if _, _, _, err := unixListenerFactory(map[string]string{
+		"address":     regular,
+		"tls_disable": "1",
+	}, nil); err == nil {
+		t.Fatal("expected error")
+	}
+}
@@ -15,6 +15,7 @@ listener "tcp" {
 backend "consul" {
     foo = "bar"
     advertise_addr = "foo"
+    cache_size = "2048"
 }
 
 ha_backend "consul" {
@@ -23,9 +24,24 @@ ha_backend "consul" {
     disable_clustering = "false"
 }
 
+seal "awskms" {
+    region = "us-east-1"
+    kms_key_id = "alias/vault"
+}
+
+seal "pkcs11" {
+    disabled = "true"
+    lib = "/usr/lib/libsofthsm2.so"
+    slot = "0"
+    pin = "1234"
+    key_label = "vault"
+}
+
 telemetry {
     statsd_address = "bar"
     statsite_address = "foo"
+    dogstatsd_addr = "127.0.0.1:8125"
+    dogstatsd_tags = ["env:test", "role:vault"]
 }
 
 max_lease_ttl = "10h"
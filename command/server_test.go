@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"math/rand"
+	"net/http"
 	"os"
 	"strings"
 	"sync"
@@ -15,6 +16,7 @@ import (
 	"time"
 
 	"github.com/hashicorp/vault/command/server"
+	"github.com/hashicorp/vault/command/token"
 	"github.com/hashicorp/vault/meta"
 	"github.com/mitchellh/cli"
 )
@@ -253,3 +255,104 @@ func TestServer_ReloadListener(t *testing.T) {
 
 	wg.Wait()
 }
+
+type testTokenHelper struct {
+	token string
+}
+
+func (h *testTokenHelper) Path() string             { return "" }
+func (h *testTokenHelper) Erase() error             { h.token = ""; return nil }
+func (h *testTokenHelper) Get() (string, error)     { return h.token, nil }
+func (h *testTokenHelper) Store(token string) error { h.token = token; return nil }
+
+func TestServer_DevTLSPlugins(t *testing.T) {
+	td, err := ioutil.TempDir("", "vault-test-plugins")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(td)
+	if err := ioutil.WriteFile(td+"/myplugin", []byte("#!/bin/sh\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(td+"/README", []byte("not a plugin\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	ui := new(cli.MockUi)
+	tokenHelper := &testTokenHelper{}
+	c := &ServerCommand{
+		Meta: meta.Meta{
+			Ui:          ui,
+			TokenHelper: func() (token.TokenHelper, error) { return tokenHelper, nil },
+		},
+		ShutdownCh:  make(chan struct{}),
+		SighupCh:    MakeSighupCh(),
+		ReloadFuncs: map[string][]server.ReloadFunc{},
+	}
+
+	args := []string{
+		"-dev",
+		"-dev-tls",
+		"-dev-listen-address", "127.0.0.1:8219",
+		"-dev-root-token-id", "root",
+		"-dev-plugin-dir", td,
+	}
+	doneCh := make(chan int)
+	go func() {
+		doneCh <- c.Run(args)
+	}()
+
+	client := &http.Client{
+		Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+		},
+	}
+	var resp *http.Response
+	for i := 0; i < 100; i++ {
+		req, _ := http.NewRequest("GET", "https://127.0.0.1:8219/v1/sys/plugins/catalog/myplugin", nil)
+		req.Header.Set("X-Vault-Token", "root")
+		resp, err = client.Do(req)
+		if err == nil && resp.StatusCode == 200 {
+			break
+		}
+		if resp != nil {
+			resp.Body.Close()
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		t.Fatalf("bad: %d", resp.StatusCode)
+	}
+	if issuer := resp.TLS.PeerCertificates[0].Issuer.CommonName; issuer != "Vault Dev CA" {
+		t.Fatalf("bad: %s", issuer)
+	}
+
+	close(c.ShutdownCh)
+	if code := <-doneCh; code != 0 {
+		t.Fatalf("bad: %d\n\n%s", code, ui.ErrorWriter.String())
+	}
+
+	output := ui.OutputWriter.String()
+	if !strings.Contains(output, "VAULT_ADDR='https://127.0.0.1:8219'") {
+		t.Fatalf("bad: %s", output)
+	}
+	if !strings.Contains(output, "are registered in the catalog:\n\n    myplugin\n") {
+		t.Fatalf("bad: %s", output)
+	}
+	i := strings.Index(output, "VAULT_CACERT='")
+	if i < 0 {
+		t.Fatalf("bad: %s", output)
+	}
+	caCert := output[i+len("VAULT_CACERT='"):]
+	caCert = caCert[:strings.Index(caCert, "'")]
+	if _, err := os.Stat(caCert); !os.IsNotExist(err) {
+		t.Fatalf("the dev TLS directory should be removed: %v", err)
+	}
+	if tokenHelper.token != "root" {
+		t.Fatalf("bad: %s", tokenHelper.token)
+	}
+}
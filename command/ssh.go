@@ -38,7 +38,7 @@ func (c *SSHCommand) Run(args []string) int {
 	flags := c.Meta.FlagSet("ssh", meta.FlagSetDefault)
 	flags.StringVar(&strictHostKeyChecking, "strict-host-key-checking", "", "")
 	flags.StringVar(&userKnownHostsFile, "user-known-hosts-file", "", "")
-	flags.StringVar(&format, "format", "table", "")
+	flags.StringVar(&format, "format", DefaultFormat(), "")
 	flags.StringVar(&role, "role", "", "")
 	flags.StringVar(&mountPoint, "mount-point", "ssh", "")
 	flags.BoolVar(&noExec, "no-exec", false, "")
@@ -307,7 +307,8 @@ SSH Options:
 	-format				If no-exec option is enabled, then the credentials will be
 					printed out and SSH connection will not be established. The
 					format of the output can be 'json' or 'table'. JSON output
-					is useful when writing scripts. Default is 'table'.
+					is useful when writing scripts. Default is 'table', or
+					the VAULT_FORMAT environment variable if set.
 
 	-strict-host-key-checking	This option corresponds to StrictHostKeyChecking of SSH configuration.
 					If 'sshpass' is employed to enable automated login, then if host key
@@ -15,7 +15,9 @@ type StatusCommand struct {
 }
 
 func (c *StatusCommand) Run(args []string) int {
+	var format string
 	flags := c.Meta.FlagSet("status", meta.FlagSetDefault)
+	flags.StringVar(&format, "format", DefaultFormat(), "")
 	flags.Usage = func() { c.Ui.Error(c.Help()) }
 	if err := flags.Parse(args); err != nil {
 		return 1
@@ -35,37 +37,61 @@ func (c *StatusCommand) Run(args []string) int {
 		return 1
 	}
 
+	// Mask the 'Vault is sealed' error, since this means HA is enabled,
+	// but that we cannot query for the leader since we are sealed.
+	leaderStatus, err := client.Sys().Leader()
+	if err != nil && strings.Contains(err.Error(), "Vault is sealed") {
+		leaderStatus = &api.LeaderResponse{HAEnabled: true}
+		err = nil
+	}
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf(
+			"Error checking leader status: %s", err))
+		return 1
+	}
+
+	if strings.ToLower(format) != "table" {
+		status := &statusOutput{
+			SealStatusResponse: sealStatus,
+			HAEnabled:          leaderStatus.HAEnabled,
+		}
+		if leaderStatus.HAEnabled && !sealStatus.Sealed {
+			status.IsSelf = leaderStatus.IsSelf
+			status.LeaderAddress = leaderStatus.LeaderAddress
+		}
+		if ret := OutputData(c.Ui, format, status); ret != 0 {
+			return ret
+		}
+		if sealStatus.Sealed {
+			return 2
+		}
+		return 0
+	}
+
 	outStr := fmt.Sprintf(
-		"Sealed: %v\n"+
+		"Type: %s\n"+
+			"Sealed: %v\n"+
 			"Key Shares: %d\n"+
 			"Key Threshold: %d\n"+
 			"Unseal Progress: %d\n"+
 			"Version: %s",
+		sealStatus.Type,
 		sealStatus.Sealed,
 		sealStatus.N,
 		sealStatus.T,
 		sealStatus.Progress,
 		sealStatus.Version)
 
+	if sealStatus.Migration {
+		outStr = fmt.Sprintf("%s\nSeal Migration: in progress", outStr)
+	}
+
 	if sealStatus.ClusterName != "" && sealStatus.ClusterID != "" {
 		outStr = fmt.Sprintf("%s\nCluster Name: %s\nCluster ID: %s", outStr, sealStatus.ClusterName, sealStatus.ClusterID)
 	}
 
 	c.Ui.Output(outStr)
 
-	// Mask the 'Vault is sealed' error, since this means HA is enabled,
-	// but that we cannot query for the leader since we are sealed.
-	leaderStatus, err := client.Sys().Leader()
-	if err != nil && strings.Contains(err.Error(), "Vault is sealed") {
-		leaderStatus = &api.LeaderResponse{HAEnabled: true}
-		err = nil
-	}
-	if err != nil {
-		c.Ui.Error(fmt.Sprintf(
-			"Error checking leader status: %s", err))
-		return 1
-	}
-
 	// Output if HA is enabled
 	c.Ui.Output("")
 	c.Ui.Output(fmt.Sprintf("High-Availability Enabled: %v", leaderStatus.HAEnabled))
@@ -93,6 +119,14 @@ func (c *StatusCommand) Run(args []string) int {
 	}
 }
 
+// statusOutput is the status output in the json and yaml formats
+type statusOutput struct {
+	*api.SealStatusResponse
+	HAEnabled     bool   `json:"ha_enabled"`
+	IsSelf        bool   `json:"is_self,omitempty"`
+	LeaderAddress string `json:"leader_address,omitempty"`
+}
+
 func (c *StatusCommand) Synopsis() string {
 	return "Outputs status of whether Vault is sealed and if HA mode is enabled"
 }
@@ -107,6 +141,13 @@ Usage: vault status [options]
   code also reflects the seal status (0 unsealed, 2 sealed, 1 error).
 
 General Options:
-` + meta.GeneralOptionsUsage()
+` + meta.GeneralOptionsUsage() + `
+Status Options:
+
+  -format=table           The format for output. By default it is a
+                          human-readable list. This can also be json or yaml.
+                          Overrides the VAULT_FORMAT environment variable if
+                          set.
+`
 	return strings.TrimSpace(helpText)
 }
@@ -0,0 +1,42 @@
+package command
+
+import (
+	"strings"
+
+	"github.com/hashicorp/vault/meta"
+	"github.com/mitchellh/cli"
+)
+
+// TokenCommand is the parent of the token subcommands, which only outputs
+// their help.
+type TokenCommand struct {
+	meta.Meta
+}
+
+func (c *TokenCommand) Run(args []string) int {
+	return cli.RunResultHelp
+}
+
+func (c *TokenCommand) Synopsis() string {
+	return "Interact with tokens"
+}
+
+func (c *TokenCommand) Help() string {
+	helpText := `
+Usage: vault token <subcommand> [options] [args]
+
+  Interact with auth tokens. Tokens can be looked up, renewed and revoked
+  by their ID, or by their accessor with the "-accessor" flag when the ID
+  isn't known.
+
+      $ vault token create -policy=my-policy -ttl=8h
+      $ vault token create -role=nomad
+      $ vault token lookup -accessor 2c84f488-2133-4ced-87b0-570f93a76830
+      $ vault token renew -accessor 2c84f488-2133-4ced-87b0-570f93a76830
+      $ vault token revoke -accessor 2c84f488-2133-4ced-87b0-570f93a76830
+      $ vault token capabilities secret/foo
+
+  Run a subcommand with -help to see its usage and options.
+`
+	return strings.TrimSpace(helpText)
+}
@@ -8,6 +8,8 @@ import (
 	"path/filepath"
 	"runtime"
 	"strings"
+
+	"github.com/mitchellh/go-homedir"
 )
 
 // ExternalTokenHelperPath takes the configured path to a helper and expands it to
@@ -19,18 +21,30 @@ import (
 // As an additional result, only absolute paths are now allowed. Looking in the
 // path or a current directory for an arbitrary executable could allow someone
 // to switch the expected binary for one further up the path (or in the current
-// directory), potentially opening up execution of an arbitrary binary.
+// directory), potentially opening up execution of an arbitrary binary. A
+// leading "~" is expanded to the home directory.
 func ExternalTokenHelperPath(path string) (string, error) {
+	path, err := homedir.Expand(path)
+	if err != nil {
+		return "", fmt.Errorf("error expanding the token helper path %s: %s", path, err)
+	}
+
 	if !filepath.IsAbs(path) {
-		var err error
 		path, err = filepath.Abs(path)
 		if err != nil {
 			return "", err
 		}
 	}
 
-	if _, err := os.Stat(path); err != nil {
-		return "", fmt.Errorf("unknown error getting the external helper path")
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", fmt.Errorf("error finding the token helper %s: %s", path, err)
+	}
+	if info.IsDir() {
+		return "", fmt.Errorf("the token helper %s is a directory", path)
+	}
+	if runtime.GOOS != "windows" && info.Mode()&0111 == 0 {
+		return "", fmt.Errorf("the token helper %s is not executable", path)
 	}
 
 	return path, nil
@@ -41,7 +55,8 @@ func ExternalTokenHelperPath(path string) (string, error) {
 // BinaryPath is executed within a shell with environment Env. The last argument
 // appended will be the operation, which is:
 //
-//   * "get" - Read the value of the token and write it to stdout.
+//   * "get" - Read the value of the token and write it to stdout. Surrounding
+//       whitespace, such as a trailing newline, is ignored.
 //   * "store" - Store the value of the token which is on stdin. Output
 //       nothing.
 //   * "erase" - Erase the contents stored. Output nothing.
@@ -80,7 +95,7 @@ func (h *ExternalTokenHelper) Get() (string, error) {
 			"Error: %s\n\n%s", err, stderr.String())
 	}
 
-	return buf.String(), nil
+	return strings.TrimSpace(buf.String()), nil
 }
 
 // Store stores the token value into the helper.
@@ -5,55 +5,72 @@ import (
 	"io"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"runtime"
 	"strings"
 	"testing"
 )
 
 func TestExternalTokenHelperPath(t *testing.T) {
-	cases := map[string]string{}
+	dir, err := ioutil.TempDir("", "vault")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	helper := filepath.Join(dir, "helper")
+	if err := ioutil.WriteFile(helper, []byte("#!/bin/sh\n"), 0755); err != nil {
+		t.Fatalf("err: %s", err)
+	}
 
-	unixCases := map[string]string{
-		"/foo": "/foo",
+	actual, err := ExternalTokenHelperPath(helper)
+	if err != nil {
+		t.Fatalf("err: %s", err)
 	}
-	windowsCases := map[string]string{
-		"C:/foo":           "C:/foo",
-		`C:\Program Files`: `C:\Program Files`,
+	if actual != helper {
+		t.Fatalf("expected: %s, got: %s", helper, actual)
 	}
 
-	var runtimeCases map[string]string
-	if runtime.GOOS == "windows" {
-		runtimeCases = windowsCases
-	} else {
-		runtimeCases = unixCases
-	}
-
-	for k, v := range runtimeCases {
-		cases[k] = v
-	}
-
-	// We don't expect those to actually exist, so we expect an error. For now,
-	// I'm commenting out the rest of this code as we don't have real external
-	// helpers to test with and the os.Stat will fail with our fake test cases.
-	/*
-		for k, v := range cases {
-			actual, err := ExternalTokenHelperPath(k)
-				if err != nil {
-					t.Fatalf("error getting external helper path: %v", err)
-				}
-				if actual != v {
-					t.Fatalf(
-						"input: %s, expected: %s, got: %s",
-						k, v, actual)
-				}
+	// Missing helpers and directories are rejected
+	for _, path := range []string{filepath.Join(dir, "missing"), dir} {
+		if _, err := ExternalTokenHelperPath(path); err == nil {
+			t.Fatalf("%s: expected error", path)
 		}
-	*/
+	}
+
+	if runtime.GOOS == "windows" {
+		return
+	}
+
+	notExecutable := filepath.Join(dir, "not-executable")
+	if err := ioutil.WriteFile(notExecutable, []byte("#!/bin/sh\n"), 0644); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	_, err = ExternalTokenHelperPath(notExecutable)
+	if err == nil || !strings.Contains(err.Error(), "not executable") {
+		t.Fatalf("bad: %v", err)
+	}
 }
 
 func TestExternalTokenHelper(t *testing.T) {
 	Test(t, testExternalTokenHelper(t))
 }
 
+func TestExternalTokenHelper_trailingNewline(t *testing.T) {
+	h := testExternalTokenHelper(t)
+	if err := h.Store("foo\n"); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	v, err := h.Get()
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if v != "foo" {
+		t.Fatalf("bad: %#v", v)
+	}
+}
+
 func testExternalTokenHelper(t *testing.T) *ExternalTokenHelper {
 	return &ExternalTokenHelper{BinaryPath: helperPath("helper"), Env: helperEnv()}
 }
@@ -10,20 +10,20 @@ import (
 	"github.com/hashicorp/vault/meta"
 )
 
-// TokenCreateCommand is a Command that mounts a new mount.
+// TokenCreateCommand is a Command that creates a new auth token.
 type TokenCreateCommand struct {
 	meta.Meta
 }
 
 func (c *TokenCreateCommand) Run(args []string) int {
 	var format string
-	var id, displayName, lease, ttl, explicitMaxTTL, period, role string
+	var id, displayName, lease, ttl, explicitMaxTTL, period, role, tokenType string
 	var orphan, noDefaultPolicy, renewable bool
 	var metadata map[string]string
 	var numUses int
 	var policies []string
-	flags := c.Meta.FlagSet("mount", meta.FlagSetDefault)
-	flags.StringVar(&format, "format", "table", "")
+	flags := c.Meta.FlagSet("token create", meta.FlagSetDefault)
+	flags.StringVar(&format, "format", DefaultFormat(), "")
 	flags.StringVar(&displayName, "display-name", "", "")
 	flags.StringVar(&id, "id", "", "")
 	flags.StringVar(&lease, "lease", "", "")
@@ -31,6 +31,7 @@ func (c *TokenCreateCommand) Run(args []string) int {
 	flags.StringVar(&explicitMaxTTL, "explicit-max-ttl", "", "")
 	flags.StringVar(&period, "period", "", "")
 	flags.StringVar(&role, "role", "", "")
+	flags.StringVar(&tokenType, "type", "", "")
 	flags.BoolVar(&orphan, "orphan", false, "")
 	flags.BoolVar(&renewable, "renewable", true, "")
 	flags.BoolVar(&noDefaultPolicy, "no-default-policy", false, "")
@@ -46,7 +47,7 @@ func (c *TokenCreateCommand) Run(args []string) int {
 	if len(args) != 0 {
 		flags.Usage()
 		c.Ui.Error(fmt.Sprintf(
-			"\ntoken-create expects no arguments"))
+			"\ntoken create expects no arguments"))
 		return 1
 	}
 
@@ -73,6 +74,7 @@ func (c *TokenCreateCommand) Run(args []string) int {
 		Renewable:       new(bool),
 		ExplicitMaxTTL:  explicitMaxTTL,
 		Period:          period,
+		Type:            tokenType,
 	}
 	*tcr.Renewable = renewable
 
@@ -86,7 +88,7 @@ func (c *TokenCreateCommand) Run(args []string) int {
 	if err != nil {
 		c.Ui.Error(fmt.Sprintf(
 			"Error creating token: %s", err))
-		return 2
+		return 1
 	}
 
 	return OutputSecret(c.Ui, format, secret)
@@ -98,7 +100,7 @@ func (c *TokenCreateCommand) Synopsis() string {
 
 func (c *TokenCreateCommand) Help() string {
 	helpText := `
-Usage: vault token-create [options]
+Usage: vault token create [options]
 
   Create a new auth token.
 
@@ -115,9 +117,12 @@ Usage: vault token-create [options]
 
   If a role is specified, the role may override parameters specified here.
 
+      $ vault token create -policy=my-policy -ttl=8h
+      $ vault token create -role=nomad -display-name=worker
+
 General Options:
 ` + meta.GeneralOptionsUsage() + `
-Token Options:
+Token Create Options:
 
   -id="7699125c-d8...."   The token value that clients will use to authenticate
                           with vault. If not provided this defaults to a 36
@@ -147,6 +152,10 @@ Token Options:
                           This defaults to true; set to false to disable
                           renewal of this token.
 
+  -type="service"         The type of token to create; either "service" or
+                          "batch". Batch tokens are not persisted and cannot
+                          be renewed, revoked or used to create child tokens.
+
   -metadata="key=value"   Metadata to associate with the token. This shows
                           up in the audit log. This can be specified multiple
                           times.
@@ -166,6 +175,8 @@ Token Options:
 
   -format=table           The format for output. By default it is a whitespace-
                           delimited table. This can also be json or yaml.
+                          Overrides the VAULT_FORMAT environment variable if
+                          set.
 
   -role=name              If set, the token will be created against the named
                           role. The role may override other parameters. This
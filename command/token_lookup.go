@@ -17,9 +17,9 @@ type TokenLookupCommand struct {
 func (c *TokenLookupCommand) Run(args []string) int {
 	var format string
 	var accessor bool
-	flags := c.Meta.FlagSet("token-lookup", meta.FlagSetDefault)
+	flags := c.Meta.FlagSet("token lookup", meta.FlagSetDefault)
 	flags.BoolVar(&accessor, "accessor", false, "")
-	flags.StringVar(&format, "format", "table", "")
+	flags.StringVar(&format, "format", DefaultFormat(), "")
 	flags.Usage = func() { c.Ui.Error(c.Help()) }
 	if err := flags.Parse(args); err != nil {
 		return 1
@@ -29,14 +29,14 @@ func (c *TokenLookupCommand) Run(args []string) int {
 	if len(args) > 1 {
 		flags.Usage()
 		c.Ui.Error(fmt.Sprintf(
-			"\ntoken-lookup expects at most one argument"))
+			"\ntoken lookup expects at most one argument"))
 		return 1
 	}
 
 	client, err := c.Client()
 	if err != nil {
 		c.Ui.Error(fmt.Sprintf(
-			"error initializing client: %s", err))
+			"Error initializing client: %s", err))
 		return 2
 	}
 
@@ -50,13 +50,13 @@ func (c *TokenLookupCommand) Run(args []string) int {
 		secret, err = client.Auth().Token().LookupAccessor(args[0])
 	default:
 		// This happens only when accessor is set and no argument is passed
-		c.Ui.Error(fmt.Sprintf("token-lookup expects an argument when accessor flag is set"))
+		c.Ui.Error(fmt.Sprintf("token lookup expects an argument when the accessor flag is set"))
 		return 1
 	}
 
 	if err != nil {
 		c.Ui.Error(fmt.Sprintf(
-			"error looking up token: %s", err))
+			"Error looking up token: %s", err))
 		return 1
 	}
 	return OutputSecret(c.Ui, format, secret)
@@ -77,23 +77,30 @@ func (c *TokenLookupCommand) Synopsis() string {
 
 func (c *TokenLookupCommand) Help() string {
 	helpText := `
-Usage: vault token-lookup [options] [token|accessor]
+Usage: vault token lookup [options] [token|accessor]
 
   Displays information about the specified token. If no token is specified, the
   operation is performed on the currently authenticated token i.e. lookup-self.
-  Information about the token can be retrieved using the token accessor via the 
+  Information about the token can be retrieved using the token accessor via the
   '-accessor' flag.
 
+      $ vault token lookup
+      $ vault token lookup -accessor 2c84f488-2133-4ced-87b0-570f93a76830
+
 General Options:
 ` + meta.GeneralOptionsUsage() + `
 Token Lookup Options:
+
   -accessor               A boolean flag, if set, treats the argument as an accessor of the token.
                           Note that the response of the command when this is set, will not contain
                           the token ID. Accessor is only meant for looking up the token properties
-                          (and for revocation via '/auth/token/revoke-accessor/<accessor>' endpoint).
+                          (and for renewal and revocation with 'vault token renew -accessor' and
+                          'vault token revoke -accessor').
 
   -format=table           The format for output. By default it is a whitespace-
                           delimited table. This can also be json or yaml.
+                          Overrides the VAULT_FORMAT environment variable if
+                          set.
 
 `
 	return strings.TrimSpace(helpText)
@@ -10,15 +10,17 @@ import (
 	"github.com/hashicorp/vault/meta"
 )
 
-// TokenRenewCommand is a Command that mounts a new mount.
+// TokenRenewCommand is a Command that renews an auth token.
 type TokenRenewCommand struct {
 	meta.Meta
 }
 
 func (c *TokenRenewCommand) Run(args []string) int {
 	var format, increment string
-	flags := c.Meta.FlagSet("token-renew", meta.FlagSetDefault)
-	flags.StringVar(&format, "format", "table", "")
+	var accessor bool
+	flags := c.Meta.FlagSet("token renew", meta.FlagSetDefault)
+	flags.BoolVar(&accessor, "accessor", false, "")
+	flags.StringVar(&format, "format", DefaultFormat(), "")
 	flags.StringVar(&increment, "increment", "", "")
 	flags.Usage = func() { c.Ui.Error(c.Help()) }
 	if err := flags.Parse(args); err != nil {
@@ -29,7 +31,7 @@ func (c *TokenRenewCommand) Run(args []string) int {
 	if len(args) > 2 {
 		flags.Usage()
 		c.Ui.Error(fmt.Sprintf(
-			"\ntoken-renew expects at most two arguments"))
+			"\ntoken renew expects at most two arguments"))
 		return 1
 	}
 
@@ -37,6 +39,10 @@ func (c *TokenRenewCommand) Run(args []string) int {
 	if len(args) > 0 {
 		token = args[0]
 	}
+	if accessor && token == "" {
+		c.Ui.Error("token renew expects an argument when the accessor flag is set")
+		return 1
+	}
 
 	var inc int
 	// If both are specified prefer the argument
@@ -63,9 +69,12 @@ func (c *TokenRenewCommand) Run(args []string) int {
 	// If the given token is the same as the client's, use renew-self instead
 	// as this is far more likely to be allowed via policy
 	var secret *api.Secret
-	if token == "" {
+	switch {
+	case accessor:
+		secret, err = client.Auth().Token().RenewAccessor(token, inc)
+	case token == "":
 		secret, err = client.Auth().Token().RenewSelf(inc)
-	} else {
+	default:
 		secret, err = client.Auth().Token().Renew(token, inc)
 	}
 	if err != nil {
@@ -83,25 +92,34 @@ func (c *TokenRenewCommand) Synopsis() string {
 
 func (c *TokenRenewCommand) Help() string {
 	helpText := `
-Usage: vault token-renew [options] [token] [increment]
+Usage: vault token renew [options] [token|accessor] [increment]
 
   Renew an auth token, extending the amount of time it can be used. If a token
   is given to the command, '/auth/token/renew' will be called with the given
   token; otherwise, '/auth/token/renew-self' will be called with the client
-  token.
+  token. With the '-accessor' flag, the argument is the accessor of the token
+  and '/auth/token/renew-accessor' is called instead; the response doesn't
+  contain the token ID.
 
-  This command is similar to "renew", but "renew" is only for leases; this
-  command is only for tokens.
+  This command is similar to "vault lease renew", but "vault lease renew" is
+  only for leases; this command is only for tokens.
 
   An optional increment can be given to request a certain number of seconds to
   increment the lease. This request is advisory; Vault may not adhere to it at
   all. If a token is being passed in on the command line, the increment can as
   well; otherwise it must be passed in via the '-increment' flag.
 
+      $ vault token renew
+      $ vault token renew 96ddf4bc-d217-f3ba-f9bd-017055595017 1h
+      $ vault token renew -accessor -increment=1h 2c84f488-2133-4ced-87b0-570f93a76830
+
 General Options:
 ` + meta.GeneralOptionsUsage() + `
 Token Renew Options:
 
+  -accessor               A boolean flag, if set, treats the argument as an
+                          accessor of the token.
+
   -increment=3600         The desired increment. If not supplied, Vault will
                           use the default TTL. If supplied, it may still be
                           ignored. This can be submitted as an integer number
@@ -109,6 +127,8 @@ Token Renew Options:
 
   -format=table           The format for output. By default it is a whitespace-
                           delimited table. This can also be json or yaml.
+                          Overrides the VAULT_FORMAT environment variable if
+                          set.
 
 `
 	return strings.TrimSpace(helpText)
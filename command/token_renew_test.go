@@ -1,6 +1,7 @@
 package command
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/hashicorp/vault/api"
@@ -175,3 +176,43 @@ func TestTokenRenewSelfWithIncrement(t *testing.T) {
 		t.Fatalf("bad: %d\n\n%s", code, ui.ErrorWriter.String())
 	}
 }
+
+func TestTokenRenew_accessor(t *testing.T) {
+	core, _, token := vault.TestCoreUnsealed(t)
+	ln, addr := http.TestServer(t, core)
+	defer ln.Close()
+
+	ui := new(cli.MockUi)
+	c := &TokenRenewCommand{
+		Meta: meta.Meta{
+			ClientToken: token,
+			Ui:          ui,
+		},
+	}
+
+	// Create a token
+	client := testClient(t, addr, token)
+	resp, err := client.Auth().Token().Create(&api.TokenCreateRequest{
+		Lease: "1h",
+	})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	args := []string{"-address", addr, "-accessor"}
+	if code := c.Run(args); code != 1 {
+		t.Fatalf("expected failure without an accessor: %d", code)
+	}
+
+	args = append(args, "-format", "json", resp.Auth.Accessor, "2h")
+	if code := c.Run(args); code != 0 {
+		t.Fatalf("bad: %d\n\n%s", code, ui.ErrorWriter.String())
+	}
+	output := ui.OutputWriter.String()
+	if strings.Contains(output, resp.Auth.ClientToken) {
+		t.Fatalf("the token ID must not be output: %s", output)
+	}
+	if !strings.Contains(output, `"lease_duration": 7200`) {
+		t.Fatalf("bad: %s", output)
+	}
+}
@@ -15,7 +15,7 @@ type TokenRevokeCommand struct {
 func (c *TokenRevokeCommand) Run(args []string) int {
 	var mode string
 	var accessor bool
-	flags := c.Meta.FlagSet("token-revoke", meta.FlagSetDefault)
+	flags := c.Meta.FlagSet("token revoke", meta.FlagSetDefault)
 	flags.BoolVar(&accessor, "accessor", false, "")
 	flags.StringVar(&mode, "mode", "", "")
 	flags.Usage = func() { c.Ui.Error(c.Help()) }
@@ -27,7 +27,7 @@ func (c *TokenRevokeCommand) Run(args []string) int {
 	if len(args) != 1 {
 		flags.Usage()
 		c.Ui.Error(fmt.Sprintf(
-			"\ntoken-revoke expects one argument"))
+			"\ntoken revoke expects one argument"))
 		return 1
 	}
 
@@ -52,17 +52,20 @@ func (c *TokenRevokeCommand) Run(args []string) int {
 	case accessor && mode == "":
 		fn = client.Auth().Token().RevokeAccessor
 	case accessor && mode == "orphan":
-		c.Ui.Error("token-revoke cannot be run for 'orphan' mode when 'accessor' flag is set")
+		c.Ui.Error("token revoke cannot be run for 'orphan' mode when the accessor flag is set")
 		return 1
 	case accessor && mode == "path":
-		c.Ui.Error("token-revoke cannot be run for 'path' mode when 'accessor' flag is set")
+		c.Ui.Error("token revoke cannot be run for 'path' mode when the accessor flag is set")
+		return 1
+	default:
+		c.Ui.Error(fmt.Sprintf("Unknown revocation mode %q", mode))
 		return 1
 	}
 
 	if err := fn(token); err != nil {
 		c.Ui.Error(fmt.Sprintf(
 			"Error revoking token: %s", err))
-		return 2
+		return 1
 	}
 
 	c.Ui.Output("Success! Token revoked if it existed.")
@@ -75,12 +78,12 @@ func (c *TokenRevokeCommand) Synopsis() string {
 
 func (c *TokenRevokeCommand) Help() string {
 	helpText := `
-Usage: vault token-revoke [options] [token|accessor]
+Usage: vault token revoke [options] [token|accessor]
 
   Revoke one or more auth tokens.
 
-  This command revokes auth tokens. Use the "revoke" command for
-  revoking secrets.
+  This command revokes auth tokens. Use the "vault lease revoke" command
+  for revoking secrets.
 
   Depending on the flags used, auth tokens can be revoked in multiple ways
   depending on the "-mode" flag:
@@ -101,16 +104,19 @@ Usage: vault token-revoke [options] [token|accessor]
   '-mode' should not be set for 'orphan' or 'path'. This is because,
   a token accessor always revokes the token along with it's child tokens.
 
+      $ vault token revoke 96ddf4bc-d217-f3ba-f9bd-017055595017
+      $ vault token revoke -mode=orphan 96ddf4bc-d217-f3ba-f9bd-017055595017
+      $ vault token revoke -accessor 2c84f488-2133-4ced-87b0-570f93a76830
+
 General Options:
 ` + meta.GeneralOptionsUsage() + `
-Token Options:
+Token Revoke Options:
 
   -accessor               A boolean flag, if set, treats the argument as an accessor of the token.
                           Note that accessor can also be used for looking up the token properties
-                          via '/auth/token/lookup-accessor/<accessor>' endpoint.
+                          with 'vault token lookup -accessor'.
                           Accessor is used when there is no access to token ID.
 
-
   -mode=value             The type of revocation to do. See the documentation
                           above for more information.
 
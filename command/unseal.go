@@ -19,9 +19,10 @@ type UnsealCommand struct {
 }
 
 func (c *UnsealCommand) Run(args []string) int {
-	var reset bool
+	var reset, migrate bool
 	flags := c.Meta.FlagSet("unseal", meta.FlagSetDefault)
 	flags.BoolVar(&reset, "reset", false, "")
+	flags.BoolVar(&migrate, "migrate", false, "")
 	flags.Usage = func() { c.Ui.Error(c.Help()) }
 	if err := flags.Parse(args); err != nil {
 		return 1
@@ -71,7 +72,11 @@ func (c *UnsealCommand) Run(args []string) int {
 				return 1
 			}
 		}
-		sealStatus, err = client.Sys().Unseal(strings.TrimSpace(value))
+		if migrate {
+			sealStatus, err = client.Sys().UnsealMigrate(strings.TrimSpace(value))
+		} else {
+			sealStatus, err = client.Sys().Unseal(strings.TrimSpace(value))
+		}
 	}
 
 	if err != nil {
@@ -90,6 +95,9 @@ func (c *UnsealCommand) Run(args []string) int {
 		sealStatus.T,
 		sealStatus.Progress,
 	))
+	if sealStatus.Migration {
+		c.Ui.Output("Seal Migration: in progress")
+	}
 
 	return 0
 }
@@ -121,6 +129,10 @@ Unseal Options:
   -reset                  Reset the unsealing process by throwing away
                           prior keys in process to unseal the vault.
 
+  -migrate                Unseal while migrating the seal. The keys entered
+                          are the unseal keys of the previous seal, or its
+                          recovery keys if it is an auto-unseal seal.
+
 `
 	return strings.TrimSpace(helpText)
 }
@@ -23,7 +23,7 @@ func (c *UnwrapCommand) Run(args []string) int {
 	var secret *api.Secret
 	var flags *flag.FlagSet
 	flags = c.Meta.FlagSet("unwrap", meta.FlagSetDefault)
-	flags.StringVar(&format, "format", "table", "")
+	flags.StringVar(&format, "format", DefaultFormat(), "")
 	flags.StringVar(&field, "field", "", "")
 	flags.Usage = func() { c.Ui.Error(c.Help()) }
 	if err := flags.Parse(args); err != nil {
@@ -31,18 +31,22 @@ func (c *UnwrapCommand) Run(args []string) int {
 	}
 
 	args = flags.Args()
-	if len(args) != 1 || len(args[0]) == 0 {
-		c.Ui.Error("Unwrap expects one argument: the ID of the wrapping token")
+	if len(args) > 1 {
+		c.Ui.Error("Unwrap expects at most one argument: the ID of the wrapping token")
 		flags.Usage()
 		return 1
 	}
 
-	tokenID := args[0]
-	_, err = uuid.ParseUUID(tokenID)
-	if err != nil {
-		c.Ui.Error(fmt.Sprintf(
-			"Given token could not be parsed as a UUID: %s", err))
-		return 1
+	// Without an argument the client token is unwrapped
+	var tokenID string
+	if len(args) == 1 {
+		tokenID = args[0]
+		_, err = uuid.ParseUUID(tokenID)
+		if err != nil {
+			c.Ui.Error(fmt.Sprintf(
+				"Given token could not be parsed as a UUID: %s", err))
+			return 1
+		}
 	}
 
 	client, err := c.Client()
@@ -85,22 +89,29 @@ func (c *UnwrapCommand) Synopsis() string {
 
 func (c *UnwrapCommand) Help() string {
 	helpText := `
-Usage: vault unwrap [options] <wrapping token ID>
+Usage: vault unwrap [options] [wrapping token ID]
 
   Unwrap a wrapped secret.
 
-  Unwraps the data wrapped by the given token ID. The returned result is the
-  same as a 'read' operation on a non-wrapped secret.
+  Unwraps the data wrapped by the given token ID, or by the client token if
+  no token ID is given. The returned result is the same as a 'read'
+  operation on a non-wrapped secret.
+
+      $ vault unwrap -field=value 2a5f8a46-6d46-1b6b-ab9f-3b4e8a2bd1e5
+      $ VAULT_TOKEN=2a5f8a46-6d46-1b6b-ab9f-3b4e8a2bd1e5 vault unwrap
 
 General Options:
 ` + meta.GeneralOptionsUsage() + `
-Read Options:
+Unwrap Options:
 
   -format=table           The format for output. By default it is a whitespace-
                           delimited table. This can also be json or yaml.
+                          Overrides the VAULT_FORMAT environment variable if
+                          set.
 
   -field=field            If included, the raw value of the specified field
-                          will be output raw to stdout.
+                          will be output raw to stdout, without a newline.
+                          Lists and maps are output as JSON.
 
 `
 	return strings.TrimSpace(helpText)
@@ -105,3 +105,53 @@ func TestUnwrap(t *testing.T) {
 		t.Fatalf("unexpected output:\n%s", output)
 	}
 }
+
+func TestUnwrap_clientToken(t *testing.T) {
+	core, _, token := vault.TestCoreUnsealed(t)
+	ln, addr := http.TestServer(t, core)
+	defer ln.Close()
+
+	ui := new(cli.MockUi)
+	c := &UnwrapCommand{
+		Meta: meta.Meta{
+			ClientToken: token,
+			Ui:          ui,
+		},
+	}
+
+	client, err := c.Client()
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if err := client.SetAddress(addr); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	client.SetWrappingLookupFunc(func(method, path string) string {
+		if path == "sys/wrapping/wrap" {
+			return "60s"
+		}
+		return ""
+	})
+	outer, err := client.Logical().Write("sys/wrapping/wrap", map[string]interface{}{"zip": "zap"})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if outer == nil || outer.WrapInfo == nil {
+		t.Fatalf("bad: %#v", outer)
+	}
+
+	// Without an argument, the client token is the wrapping token
+	c.Meta.ClientToken = outer.WrapInfo.Token
+	args := []string{"-address", addr, "-field", "zip"}
+	if code := c.Run(args); code != 0 {
+		t.Fatalf("bad: %d\n\n%s", code, ui.ErrorWriter.String())
+	}
+	if output := ui.OutputWriter.String(); output != "zap\n" {
+		t.Fatalf("unexpected output:\n%s", output)
+	}
+
+	// The wrapping token is single use
+	if code := c.Run(args); code != 1 {
+		t.Fatalf("bad: %d", code)
+	}
+}
@@ -1,6 +1,7 @@
 package command
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
 	"reflect"
@@ -62,6 +63,18 @@ func PrintRawField(ui cli.Ui, secret *api.Secret, field string) int {
 			val = secret.Data[field]
 		}
 
+	case secret.LeaseID != "":
+		switch field {
+		case "lease_id":
+			val = secret.LeaseID
+		case "lease_duration":
+			val = secret.LeaseDuration
+		case "lease_renewable":
+			val = secret.Renewable
+		default:
+			val = secret.Data[field]
+		}
+
 	default:
 		switch field {
 		case "refresh_interval":
@@ -71,21 +84,46 @@ func PrintRawField(ui cli.Ui, secret *api.Secret, field string) int {
 		}
 	}
 
-	if val != nil {
-		// c.Ui.Output() prints a CR character which in this case is
-		// not desired. Since Vault CLI currently only uses BasicUi,
-		// which writes to standard output, os.Stdout is used here to
-		// directly print the message. If mitchellh/cli exposes method
-		// to print without CR, this check needs to be removed.
-		if reflect.TypeOf(ui).String() == "*cli.BasicUi" {
-			fmt.Fprintf(os.Stdout, fmt.Sprintf("%v", val))
-		} else {
-			ui.Output(fmt.Sprintf("%v", val))
-		}
-		return 0
-	} else {
+	if val == nil {
 		ui.Error(fmt.Sprintf(
 			"Field %s not present in secret", field))
 		return 1
 	}
+
+	out, err := rawFieldValue(val)
+	if err != nil {
+		ui.Error(fmt.Sprintf(
+			"Error formatting field %s: %s", field, err))
+		return 1
+	}
+
+	// c.Ui.Output() prints a CR character which in this case is
+	// not desired. Since Vault CLI currently only uses BasicUi,
+	// which writes to standard output, os.Stdout is used here to
+	// directly print the message. If mitchellh/cli exposes method
+	// to print without CR, this check needs to be removed.
+	if reflect.TypeOf(ui).String() == "*cli.BasicUi" {
+		fmt.Fprint(os.Stdout, out)
+	} else {
+		ui.Output(out)
+	}
+	return 0
+}
+
+// rawFieldValue returns the value of a field as printed by -field: strings
+// as they are, so that they can be captured by scripts, structured values
+// as JSON, and other values in their default format
+func rawFieldValue(val interface{}) (string, error) {
+	switch v := val.(type) {
+	case string:
+		return v, nil
+	case map[string]interface{}, []interface{}:
+		b, err := json.Marshal(v)
+		if err != nil {
+			return "", err
+		}
+		return string(b), nil
+	default:
+		return fmt.Sprintf("%v", v), nil
+	}
 }
@@ -0,0 +1,49 @@
+package command
+
+import (
+	"testing"
+
+	"github.com/hashicorp/vault/api"
+	"github.com/mitchellh/cli"
+)
+
+func TestPrintRawField(t *testing.T) {
+	secret := &api.Secret{
+		LeaseID:       "cassandra/creds/readonly/abcd",
+		LeaseDuration: 3600,
+		Renewable:     true,
+		Data: map[string]interface{}{
+			"username": "vault-readonly",
+			"password": "p%sw0rd",
+			"roles":    []interface{}{"a", "b"},
+			"meta":     map[string]interface{}{"key": "value"},
+		},
+	}
+
+	cases := []struct {
+		field    string
+		expected string
+	}{
+		{"password", "p%sw0rd\n"},
+		{"roles", `["a","b"]` + "\n"},
+		{"meta", `{"key":"value"}` + "\n"},
+		{"lease_id", "cassandra/creds/readonly/abcd\n"},
+		{"lease_duration", "3600\n"},
+		{"lease_renewable", "true\n"},
+	}
+
+	for _, tc := range cases {
+		ui := new(cli.MockUi)
+		if code := PrintRawField(ui, secret, tc.field); code != 0 {
+			t.Fatalf("%s: bad: %d\n\n%s", tc.field, code, ui.ErrorWriter.String())
+		}
+		if output := ui.OutputWriter.String(); output != tc.expected {
+			t.Fatalf("%s: bad: %q", tc.field, output)
+		}
+	}
+
+	ui := new(cli.MockUi)
+	if code := PrintRawField(ui, secret, "nope"); code != 1 {
+		t.Fatalf("bad: %d", code)
+	}
+}
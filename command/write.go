@@ -22,7 +22,7 @@ func (c *WriteCommand) Run(args []string) int {
 	var field, format string
 	var force bool
 	flags := c.Meta.FlagSet("write", meta.FlagSetDefault)
-	flags.StringVar(&format, "format", "table", "")
+	flags.StringVar(&format, "format", DefaultFormat(), "")
 	flags.StringVar(&field, "field", "", "")
 	flags.BoolVar(&force, "force", false, "")
 	flags.BoolVar(&force, "f", false, "")
@@ -112,9 +112,16 @@ Usage: vault write [options] path [data]
   using for more information on key structure.
 
   Data is sent via additional arguments in "key=value" pairs. If value begins
-  with an "@", then it is loaded from a file. Write expects data in the file to
-  be in JSON format. If you want to start the value with a literal "@", then
-  prefix the "@" with a slash: "\@".
+  with an "@", then it is loaded as is from a file, and if it is "-" or "@-",
+  from stdin, so that values such as PEM bundles don't have to be escaped. If
+  you want to start the value with a literal "@", then prefix the "@" with a
+  slash: "\@".
+
+  An argument which is "-" or "@-" reads a JSON object of data from stdin, and
+  an argument "@file" from a file:
+
+      $ vault write pki/config/ca pem_bundle=@bundle.pem
+      $ echo '{"value": "itsasecret"}' | vault write secret/password -
 
 General Options:
 ` + meta.GeneralOptionsUsage() + `
@@ -126,9 +133,14 @@ Write Options:
 
   -format=table           The format for output. By default it is a whitespace-
                           delimited table. This can also be json or yaml.
+                          Overrides the VAULT_FORMAT environment variable if
+                          set.
 
   -field=field            If included, the raw value of the specified field
-                          will be output raw to stdout.
+                          will be output raw to stdout, without a newline.
+                          Lists and maps are output as JSON. The lease_id,
+                          lease_duration and lease_renewable of dynamic
+                          secrets can also be selected.
 
 `
 	return strings.TrimSpace(helpText)
@@ -0,0 +1,145 @@
+package cidrutil
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/hashicorp/vault/helper/strutil"
+)
+
+// IPBelongsToCIDR checks if the given IP is encompassed by the given CIDR block
+func IPBelongsToCIDR(ipAddr string, cidr string) (bool, error) {
+	if ipAddr == "" {
+		return false, fmt.Errorf("missing IP address")
+	}
+
+	ip := net.ParseIP(ipAddr)
+	if ip == nil {
+		return false, fmt.Errorf("invalid IP address")
+	}
+
+	_, ipnet, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return false, err
+	}
+
+	if !ipnet.Contains(ip) {
+		return false, nil
+	}
+
+	return true, nil
+}
+
+// IPBelongsToCIDRBlocksString checks if the given IP is encompassed by any of
+// the given CIDR blocks, when the input is a string composed by joining all
+// the CIDR blocks using a separator. The input is separated based on the given
+// separator and the IP is checked to be belonged by any CIDR block.
+func IPBelongsToCIDRBlocksString(ipAddr string, cidrList, separator string) (bool, error) {
+	if ipAddr == "" {
+		return false, fmt.Errorf("missing IP address")
+	}
+
+	if cidrList == "" {
+		return false, fmt.Errorf("missing CIDR list")
+	}
+
+	if separator == "" {
+		return false, fmt.Errorf("missing separator")
+	}
+
+	if ip := net.ParseIP(ipAddr); ip == nil {
+		return false, fmt.Errorf("invalid IP address")
+	}
+
+	return IPBelongsToCIDRBlocksSlice(ipAddr, strutil.ParseDedupAndSortStrings(cidrList, separator))
+}
+
+// IPBelongsToCIDRBlocksSlice checks if the given IP is encompassed by any of
+// the given CIDR blocks
+func IPBelongsToCIDRBlocksSlice(ipAddr string, cidrs []string) (bool, error) {
+	if ipAddr == "" {
+		return false, fmt.Errorf("missing IP address")
+	}
+
+	if len(cidrs) == 0 {
+		return false, fmt.Errorf("missing CIDR blocks to be checked against")
+	}
+
+	if ip := net.ParseIP(ipAddr); ip == nil {
+		return false, fmt.Errorf("invalid IP address")
+	}
+
+	for _, cidr := range cidrs {
+		belongs, err := IPBelongsToCIDR(ipAddr, strings.TrimSpace(cidr))
+		if err != nil {
+			return false, err
+		}
+		if belongs {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// ValidateCIDRListString checks if the list of CIDR blocks are valid, given
+// that the input is a string composed by joining all the CIDR blocks using a
+// separator. The input is separated based on the given separator and validity
+// of each is checked.
+func ValidateCIDRListString(cidrList string, separator string) (bool, error) {
+	if cidrList == "" {
+		return false, fmt.Errorf("missing CIDR list that needs validation")
+	}
+	if separator == "" {
+		return false, fmt.Errorf("missing separator")
+	}
+
+	return ValidateCIDRListSlice(strutil.ParseDedupAndSortStrings(cidrList, separator))
+}
+
+// ValidateCIDRListSlice checks if the given list of CIDR blocks are valid
+func ValidateCIDRListSlice(cidrBlocks []string) (bool, error) {
+	if len(cidrBlocks) == 0 {
+		return false, fmt.Errorf("missing CIDR blocks that needs validation")
+	}
+
+	for _, block := range cidrBlocks {
+		if _, _, err := net.ParseCIDR(strings.TrimSpace(block)); err != nil {
+			return false, err
+		}
+	}
+
+	return true, nil
+}
+
+// ParseCIDRList splits a separated list of CIDR blocks, validating each one.
+// An empty input results in an empty (nil) list.
+func ParseCIDRList(cidrList string, separator string) ([]string, error) {
+	cidrBlocks := strutil.ParseDedupAndSortStrings(cidrList, separator)
+	if len(cidrBlocks) == 0 {
+		return nil, nil
+	}
+
+	if _, err := ValidateCIDRListSlice(cidrBlocks); err != nil {
+		return nil, err
+	}
+
+	return cidrBlocks, nil
+}
+
+// RemoteAddrIsOk checks whether the given remote address is permitted by the
+// given list of bound CIDR blocks. An empty list of blocks permits any address.
+func RemoteAddrIsOk(remoteAddr string, boundCIDRs []string) bool {
+	if len(boundCIDRs) == 0 {
+		// There's no CIDR restriction.
+		return true
+	}
+
+	belongs, err := IPBelongsToCIDRBlocksSlice(remoteAddr, boundCIDRs)
+	if err != nil {
+		return false
+	}
+
+	return belongs
+}
@@ -0,0 +1,120 @@
+package cidrutil
+
+import "testing"
+
+func TestCIDRUtil_IPBelongsToCIDR(t *testing.T) {
+	ip := "192.168.25.30"
+	cidr := "192.168.26.30/16"
+
+	belongs, err := IPBelongsToCIDR(ip, cidr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !belongs {
+		t.Fatalf("expected IP %q to belong to CIDR %q", ip, cidr)
+	}
+
+	ip = "192.168.25.30"
+	cidr = "192.168.26.30/24"
+	belongs, err = IPBelongsToCIDR(ip, cidr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if belongs {
+		t.Fatalf("expected IP %q to not belong to CIDR %q", ip, cidr)
+	}
+
+	ip = "192.168.25.30.100"
+	cidr = "192.168.26.30/24"
+	belongs, err = IPBelongsToCIDR(ip, cidr)
+	if err == nil {
+		t.Fatalf("expected an error")
+	}
+}
+
+func TestCIDRUtil_IPBelongsToCIDRBlocksString(t *testing.T) {
+	ip := "192.168.27.29"
+	cidrList := "172.169.100.200/18,192.168.0.0/16,10.10.20.20/24"
+
+	belongs, err := IPBelongsToCIDRBlocksString(ip, cidrList, ",")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !belongs {
+		t.Fatalf("expected IP %q to belong to one of the CIDRs in %q", ip, cidrList)
+	}
+
+	ip = "192.168.27.29"
+	cidrList = "172.169.100.200/18,192.168.0.0.0/16,10.10.20.20/24"
+
+	belongs, err = IPBelongsToCIDRBlocksString(ip, cidrList, ",")
+	if err == nil {
+		t.Fatalf("expected an error")
+	}
+
+	ip = "30.40.50.60"
+	cidrList = "172.169.100.200/18,192.168.0.0/16,10.10.20.20/24"
+
+	belongs, err = IPBelongsToCIDRBlocksString(ip, cidrList, ",")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if belongs {
+		t.Fatalf("expected IP %q to not belong to one of the CIDRs in %q", ip, cidrList)
+	}
+}
+
+func TestCIDRUtil_ValidateCIDRListString(t *testing.T) {
+	cidrList := "172.169.100.200/18,192.168.0.0/16,10.10.20.20/24"
+
+	valid, err := ValidateCIDRListString(cidrList, ",")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !valid {
+		t.Fatalf("expected CIDR list %q to be valid", cidrList)
+	}
+
+	cidrList = "172.169.100.200,192.168.0.0/16,10.10.20.20/24"
+	valid, err = ValidateCIDRListString(cidrList, ",")
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+}
+
+func TestCIDRUtil_ParseCIDRList(t *testing.T) {
+	cidrs, err := ParseCIDRList("", ",")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cidrs) != 0 {
+		t.Fatalf("expected no CIDR blocks, got %v", cidrs)
+	}
+
+	cidrs, err = ParseCIDRList("10.0.0.0/8, 127.0.0.1/32,10.0.0.0/8", ",")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cidrs) != 2 {
+		t.Fatalf("expected two CIDR blocks, got %v", cidrs)
+	}
+
+	if _, err := ParseCIDRList("10.0.0.0/8,notacidr", ","); err == nil {
+		t.Fatal("expected an error")
+	}
+}
+
+func TestCIDRUtil_RemoteAddrIsOk(t *testing.T) {
+	if !RemoteAddrIsOk("10.1.2.3", nil) {
+		t.Fatal("expected an unrestricted address to be allowed")
+	}
+	if !RemoteAddrIsOk("10.1.2.3", []string{"127.0.0.1/32", "10.0.0.0/8"}) {
+		t.Fatal("expected address to be allowed")
+	}
+	if RemoteAddrIsOk("192.168.1.1", []string{"127.0.0.1/32", "10.0.0.0/8"}) {
+		t.Fatal("expected address to be rejected")
+	}
+	if RemoteAddrIsOk("", []string{"10.0.0.0/8"}) {
+		t.Fatal("expected an empty address to be rejected")
+	}
+}
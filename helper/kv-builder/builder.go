@@ -47,18 +47,14 @@ func (b *Builder) add(raw string) error {
 		return nil
 	}
 
-	// If the arg is exactly "-", then we need to read from stdin
+	// If the arg is exactly "-" or "@-", then we need to read from stdin
 	// and merge the results into the resulting structure.
-	if raw == "-" {
-		if b.Stdin == nil {
-			return fmt.Errorf("stdin is not supported")
-		}
-		if b.stdin {
-			return fmt.Errorf("stdin already consumed")
+	if raw == "-" || raw == "@-" {
+		stdin, err := b.readStdin()
+		if err != nil {
+			return err
 		}
-
-		b.stdin = true
-		return b.addReader(b.Stdin)
+		return b.addReader(stdin)
 	}
 
 	// If the arg begins with "@" then we need to read a file directly
@@ -79,38 +75,48 @@ func (b *Builder) add(raw string) error {
 	}
 	key, value := parts[0], parts[1]
 
-	if len(value) > 0 {
-		if value[0] == '@' {
-			contents, err := ioutil.ReadFile(value[1:])
-			if err != nil {
-				return fmt.Errorf("error reading file: %s", err)
-			}
-
-			value = string(contents)
-		} else if value[0] == '\\' && value[1] == '@' {
-			value = value[1:]
-		} else if value == "-" {
-			if b.Stdin == nil {
-				return fmt.Errorf("stdin is not supported")
-			}
-			if b.stdin {
-				return fmt.Errorf("stdin already consumed")
-			}
-			b.stdin = true
-
-			var buf bytes.Buffer
-			if _, err := io.Copy(&buf, b.Stdin); err != nil {
-				return err
-			}
-
-			value = buf.String()
+	switch {
+	case value == "-" || value == "@-":
+		// The value is read from stdin as is
+		stdin, err := b.readStdin()
+		if err != nil {
+			return err
+		}
+
+		var buf bytes.Buffer
+		if _, err := io.Copy(&buf, stdin); err != nil {
+			return err
+		}
+
+		value = buf.String()
+	case strings.HasPrefix(value, "@"):
+		contents, err := ioutil.ReadFile(value[1:])
+		if err != nil {
+			return fmt.Errorf("error reading file: %s", err)
 		}
+
+		value = string(contents)
+	case strings.HasPrefix(value, "\\@"):
+		value = value[1:]
 	}
 
 	b.result[key] = value
 	return nil
 }
 
+// readStdin returns stdin, which can only be read once
+func (b *Builder) readStdin() (io.Reader, error) {
+	if b.Stdin == nil {
+		return nil, fmt.Errorf("stdin is not supported")
+	}
+	if b.stdin {
+		return nil, fmt.Errorf("stdin already consumed")
+	}
+
+	b.stdin = true
+	return b.Stdin, nil
+}
+
 func (b *Builder) addReader(r io.Reader) error {
 	return jsonutil.DecodeJSONFromReader(r, &b.result)
 }
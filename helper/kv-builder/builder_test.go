@@ -2,6 +2,8 @@ package kvbuilder
 
 import (
 	"bytes"
+	"io/ioutil"
+	"os"
 	"reflect"
 	"testing"
 )
@@ -41,6 +43,46 @@ func TestBuilder_escapedAt(t *testing.T) {
 	}
 }
 
+func TestBuilder_backslash(t *testing.T) {
+	var b Builder
+	err := b.Add("foo=\\")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	expected := map[string]interface{}{
+		"foo": "\\",
+	}
+	actual := b.Map()
+	if !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("bad: %#v", actual)
+	}
+}
+
+func TestBuilder_fileValue(t *testing.T) {
+	f, err := ioutil.TempFile("", "vault")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	defer os.Remove(f.Name())
+	pem := "-----BEGIN CERTIFICATE-----\nMIIB\n-----END CERTIFICATE-----\n"
+	f.WriteString(pem)
+	f.Close()
+
+	var b Builder
+	if err := b.Add("pem_bundle=@" + f.Name()); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	expected := map[string]interface{}{
+		"pem_bundle": pem,
+	}
+	actual := b.Map()
+	if !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("bad: %#v", actual)
+	}
+}
+
 func TestBuilder_stdin(t *testing.T) {
 	var b Builder
 	b.Stdin = bytes.NewBufferString("baz")
@@ -59,6 +101,32 @@ func TestBuilder_stdin(t *testing.T) {
 	}
 }
 
+func TestBuilder_stdinAt(t *testing.T) {
+	var b Builder
+	b.Stdin = bytes.NewBufferString("baz")
+	err := b.Add("foo=bar", "bar=@-")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	expected := map[string]interface{}{
+		"foo": "bar",
+		"bar": "baz",
+	}
+	actual := b.Map()
+	if !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("bad: %#v", actual)
+	}
+
+	b = Builder{Stdin: bytes.NewBufferString(`{"foo": "bar"}`)}
+	if err := b.Add("@-"); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if actual := b.Map(); actual["foo"] != "bar" {
+		t.Fatalf("bad: %#v", actual)
+	}
+}
+
 func TestBuilder_stdinMap(t *testing.T) {
 	var b Builder
 	b.Stdin = bytes.NewBufferString(`{"foo": "bar"}`)
@@ -84,4 +152,9 @@ func TestBuilder_stdinTwice(t *testing.T) {
 	if err == nil {
 		t.Fatal("should error")
 	}
+
+	b = Builder{Stdin: bytes.NewBufferString("baz")}
+	if err := b.Add("foo=@-", "bar=-"); err == nil {
+		t.Fatal("should error")
+	}
 }
@@ -0,0 +1,188 @@
+package logformat
+
+import (
+	"bytes"
+	"fmt"
+	"sync"
+
+	log "github.com/mgutz/logxi/v1"
+)
+
+// ParseLevel returns the level named trace, debug, info, notice, warn or err
+func ParseLevel(name string) (int, error) {
+	switch name {
+	case "trace":
+		return log.LevelTrace, nil
+	case "debug":
+		return log.LevelDebug, nil
+	case "info":
+		return log.LevelInfo, nil
+	case "notice":
+		return log.LevelNotice, nil
+	case "warn":
+		return log.LevelWarn, nil
+	case "err":
+		return log.LevelError, nil
+	default:
+		return 0, fmt.Errorf("unknown log level %s", name)
+	}
+}
+
+// MonitorLogger is a logger which also sends the entries to the monitors
+// registered with it, each monitor filtering the entries at its own level
+// independently of the level of the logger.
+type MonitorLogger struct {
+	log.Logger
+
+	l        sync.RWMutex
+	monitors map[*Monitor]struct{}
+}
+
+// NewMonitorLogger wraps the logger so that its entries can be monitored
+func NewMonitorLogger(logger log.Logger) *MonitorLogger {
+	return &MonitorLogger{
+		Logger:   logger,
+		monitors: make(map[*Monitor]struct{}),
+	}
+}
+
+// Monitor registers a monitor receiving the entries at the level, formatted
+// by a Vault formatter. Up to bufferSize lines are buffered, and the lines
+// which don't fit in the buffer are dropped rather than blocking the
+// logger. The monitor must be stopped with StopMonitor.
+func (m *MonitorLogger) Monitor(level, bufferSize int) *Monitor {
+	mon := &Monitor{
+		LogCh: make(chan string, bufferSize),
+	}
+	mon.logger = NewVaultLoggerWithWriter(mon, level)
+
+	m.l.Lock()
+	m.monitors[mon] = struct{}{}
+	m.l.Unlock()
+	return mon
+}
+
+// StopMonitor unregisters the monitor, and closes its channel
+func (m *MonitorLogger) StopMonitor(mon *Monitor) {
+	m.l.Lock()
+	defer m.l.Unlock()
+	if _, ok := m.monitors[mon]; !ok {
+		return
+	}
+	delete(m.monitors, mon)
+
+	// The monitor can't be written to anymore once it is unregistered
+	close(mon.LogCh)
+}
+
+func (m *MonitorLogger) Trace(msg string, args ...interface{}) {
+	m.Log(log.LevelTrace, msg, args)
+}
+
+func (m *MonitorLogger) Debug(msg string, args ...interface{}) {
+	m.Log(log.LevelDebug, msg, args)
+}
+
+func (m *MonitorLogger) Info(msg string, args ...interface{}) {
+	m.Log(log.LevelInfo, msg, args)
+}
+
+func (m *MonitorLogger) Warn(msg string, args ...interface{}) error {
+	m.monitor(log.LevelWarn, msg, args)
+	return m.Logger.Warn(msg, args...)
+}
+
+func (m *MonitorLogger) Error(msg string, args ...interface{}) error {
+	m.monitor(log.LevelError, msg, args)
+	return m.Logger.Error(msg, args...)
+}
+
+func (m *MonitorLogger) Fatal(msg string, args ...interface{}) {
+	m.monitor(log.LevelFatal, msg, args)
+	m.Logger.Fatal(msg, args...)
+}
+
+func (m *MonitorLogger) Log(level int, msg string, args []interface{}) {
+	m.monitor(level, msg, args)
+	m.Logger.Log(level, msg, args)
+}
+
+// The levels are enabled if they are enabled for the logger or any monitor,
+// so that the callers checking them before logging expensive entries still
+// log them while they are monitored
+
+func (m *MonitorLogger) IsTrace() bool {
+	return m.Logger.IsTrace() || m.anyMonitor(log.Logger.IsTrace)
+}
+
+func (m *MonitorLogger) IsDebug() bool {
+	return m.Logger.IsDebug() || m.anyMonitor(log.Logger.IsDebug)
+}
+
+func (m *MonitorLogger) IsInfo() bool {
+	return m.Logger.IsInfo() || m.anyMonitor(log.Logger.IsInfo)
+}
+
+func (m *MonitorLogger) IsWarn() bool {
+	return m.Logger.IsWarn() || m.anyMonitor(log.Logger.IsWarn)
+}
+
+func (m *MonitorLogger) anyMonitor(enabled func(log.Logger) bool) bool {
+	m.l.RLock()
+	defer m.l.RUnlock()
+	for mon := range m.monitors {
+		if enabled(mon.logger) {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *MonitorLogger) monitor(level int, msg string, args []interface{}) {
+	m.l.RLock()
+	defer m.l.RUnlock()
+	for mon := range m.monitors {
+		mon.logger.Log(level, msg, args)
+	}
+}
+
+// Monitor receives the entries of a MonitorLogger as lines, without their
+// trailing newline, on LogCh
+type Monitor struct {
+	LogCh chan string
+
+	logger log.Logger
+
+	l       sync.Mutex
+	buf     bytes.Buffer
+	dropped int
+}
+
+// Dropped returns the number of lines dropped as the buffer was full
+func (m *Monitor) Dropped() int {
+	m.l.Lock()
+	defer m.l.Unlock()
+	return m.dropped
+}
+
+// Write buffers the output of the formatter, which writes the entries in
+// several parts, and sends the complete lines
+func (m *Monitor) Write(p []byte) (int, error) {
+	m.l.Lock()
+	defer m.l.Unlock()
+
+	m.buf.Write(p)
+	for {
+		i := bytes.IndexByte(m.buf.Bytes(), '\n')
+		if i < 0 {
+			break
+		}
+		line := string(m.buf.Next(i + 1))
+		select {
+		case m.LogCh <- line[:len(line)-1]:
+		default:
+			m.dropped++
+		}
+	}
+	return len(p), nil
+}
@@ -0,0 +1,47 @@
+package logformat
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	log "github.com/mgutz/logxi/v1"
+)
+
+func TestMonitorLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewMonitorLogger(NewVaultLoggerWithWriter(&buf, log.LevelInfo))
+
+	mon := logger.Monitor(log.LevelDebug, 1)
+	logger.Debug("first")
+	logger.Debug("second")
+	logger.Info("third")
+
+	if line := <-mon.LogCh; !strings.HasSuffix(line, "[DEBUG] first") {
+		t.Fatalf("bad: %q", line)
+	}
+	if mon.Dropped() != 2 {
+		t.Fatalf("bad: %d", mon.Dropped())
+	}
+	if out := buf.String(); strings.Contains(out, "first") || !strings.Contains(out, "[INFO ] third") {
+		t.Fatalf("bad: %q", out)
+	}
+
+	logger.StopMonitor(mon)
+	if _, ok := <-mon.LogCh; ok {
+		t.Fatal("the channel should be closed")
+	}
+	if logger.IsDebug() {
+		t.Fatal("the debug level shouldn't be enabled")
+	}
+	logger.Debug("fourth")
+}
+
+func TestParseLevel(t *testing.T) {
+	if level, err := ParseLevel("debug"); err != nil || level != log.LevelDebug {
+		t.Fatalf("bad: %d %v", level, err)
+	}
+	if _, err := ParseLevel("verbose"); err == nil {
+		t.Fatal("should error")
+	}
+}
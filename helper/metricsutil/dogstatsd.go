@@ -0,0 +1,180 @@
+// Package metricsutil provides metrics sinks not available in go-metrics.
+package metricsutil
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	log "github.com/mgutz/logxi/v1"
+)
+
+const (
+	// dogStatsdMaxLen is the maximum size of a packet sent to DogStatsD
+	dogStatsdMaxLen = 1400
+
+	// dogStatsdFlushInterval is how often the buffered metrics are sent
+	dogStatsdFlushInterval = 100 * time.Millisecond
+)
+
+// DogStatsdSink is a metrics.MetricSink sending the metrics to a DogStatsD
+// agent over UDP, with the configured tags. As with StatsdSink, metrics are
+// dropped when the agent can't keep up.
+type DogStatsdSink struct {
+	logger      log.Logger
+	addr        string
+	hostName    string
+	tags        []string
+	metricQueue chan string
+}
+
+// NewDogStatsdSink returns a DogStatsdSink sending metrics to the agent at
+// addr with the given tags, such as "env:prod". If hostName is set, the
+// hostname prefixed by go-metrics to gauge keys is replaced by a "host" tag.
+// Errors sending the metrics are logged to logger.
+func NewDogStatsdSink(logger log.Logger, addr, hostName string, tags []string) (*DogStatsdSink, error) {
+	if addr == "" {
+		return nil, fmt.Errorf("DogStatsD address is required")
+	}
+
+	s := &DogStatsdSink{
+		logger:      logger,
+		addr:        addr,
+		hostName:    hostName,
+		tags:        tags,
+		metricQueue: make(chan string, 4096),
+	}
+	go s.flushMetrics()
+	return s, nil
+}
+
+// Shutdown stops flushing the metrics to DogStatsD
+func (s *DogStatsdSink) Shutdown() {
+	close(s.metricQueue)
+}
+
+func (s *DogStatsdSink) SetGauge(key []string, val float32) {
+	s.pushMetric(s.format(key, val, "g"))
+}
+
+func (s *DogStatsdSink) EmitKey(key []string, val float32) {
+	s.pushMetric(s.format(key, val, "kv"))
+}
+
+func (s *DogStatsdSink) IncrCounter(key []string, val float32) {
+	s.pushMetric(s.format(key, val, "c"))
+}
+
+func (s *DogStatsdSink) AddSample(key []string, val float32) {
+	s.pushMetric(s.format(key, val, "ms"))
+}
+
+// format returns the DogStatsD datagram of a metric
+func (s *DogStatsdSink) format(key []string, val float32, typ string) string {
+	tags := s.tags
+
+	// go-metrics inserts the hostname after the service name
+	if s.hostName != "" && len(key) > 1 && key[1] == s.hostName {
+		key = append([]string{key[0]}, key[2:]...)
+		tags = append(append([]string{}, tags...), "host:"+s.hostName)
+	}
+
+	metric := fmt.Sprintf("%s:%f|%s", flattenKey(key), val, typ)
+	if len(tags) > 0 {
+		metric += "|#" + strings.Join(tags, ",")
+	}
+	return metric + "\n"
+}
+
+// flattenKey joins the parts of a key with dots, replacing the characters
+// with a meaning in the datagrams
+func flattenKey(parts []string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case ':', '|', '@', '#', ' ':
+			return '_'
+		default:
+			return r
+		}
+	}, strings.Join(parts, "."))
+}
+
+// pushMetric queues a metric without blocking
+func (s *DogStatsdSink) pushMetric(m string) {
+	select {
+	case s.metricQueue <- m:
+	default:
+	}
+}
+
+// flushMetrics sends the queued metrics in packets of at most
+// dogStatsdMaxLen bytes, reconnecting after errors
+func (s *DogStatsdSink) flushMetrics() {
+	ticker := time.NewTicker(dogStatsdFlushInterval)
+	defer ticker.Stop()
+
+	for {
+		sock, err := net.Dial("udp", s.addr)
+		if err != nil {
+			s.logger.Error("metrics: error connecting to DogStatsD", "error", err)
+		} else {
+			err = s.sendMetrics(sock, ticker.C)
+			sock.Close()
+			if err == nil {
+				return
+			}
+			s.logger.Error("metrics: error writing to DogStatsD", "error", err)
+		}
+
+		// Drop the metrics for a while before reconnecting
+		wait := time.After(5 * time.Second)
+	WAIT:
+		for {
+			select {
+			case _, ok := <-s.metricQueue:
+				if !ok {
+					return
+				}
+			case <-wait:
+				break WAIT
+			}
+		}
+	}
+}
+
+// sendMetrics writes the queued metrics to sock until the queue is closed,
+// in which case it returns nil, or until a write fails
+func (s *DogStatsdSink) sendMetrics(sock net.Conn, tick <-chan time.Time) error {
+	var buf bytes.Buffer
+	for {
+		select {
+		case metric, ok := <-s.metricQueue:
+			if !ok {
+				if buf.Len() > 0 {
+					sock.Write(buf.Bytes())
+				}
+				return nil
+			}
+			if len(metric)+buf.Len() > dogStatsdMaxLen {
+				_, err := sock.Write(buf.Bytes())
+				buf.Reset()
+				if err != nil {
+					return err
+				}
+			}
+			buf.WriteString(metric)
+
+		case <-tick:
+			if buf.Len() == 0 {
+				continue
+			}
+			_, err := sock.Write(buf.Bytes())
+			buf.Reset()
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package metricsutil
+
+import (
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/helper/logformat"
+	log "github.com/mgutz/logxi/v1"
+)
+
+func TestDogStatsdSink(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	sink, err := NewDogStatsdSink(logformat.NewVaultLogger(log.LevelTrace), conn.LocalAddr().String(), "myhost", []string{"env:test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sink.Shutdown()
+
+	sink.SetGauge([]string{"vault", "myhost", "expire", "num_leases"}, 3)
+	sink.IncrCounter([]string{"vault", "core", "login_lockout"}, 1)
+	sink.AddSample([]string{"vault", "route", "read", "secret/"}, 1.5)
+
+	var received string
+	buf := make([]byte, 1500)
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	for strings.Count(received, "\n") < 3 {
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			t.Fatalf("err: %v, received: %q", err, received)
+		}
+		received += string(buf[:n])
+	}
+
+	expected := "vault.expire.num_leases:3.000000|g|#env:test,host:myhost\n" +
+		"vault.core.login_lockout:1.000000|c|#env:test\n" +
+		"vault.route.read.secret/:1.500000|ms|#env:test\n"
+	if received != expected {
+		t.Fatalf("bad: %q", received)
+	}
+}
+
+func TestDogStatsdSink_noAddr(t *testing.T) {
+	if _, err := NewDogStatsdSink(logformat.NewVaultLogger(log.LevelTrace), "", "", nil); err == nil {
+		t.Fatal("expected an error")
+	}
+}
@@ -26,20 +26,18 @@ func (p *PubKeyFilesFlag) Set(value string) error {
 
 	splitValues := strings.Split(value, ",")
 
-	keybaseMap, err := FetchKeybasePubkeys(splitValues)
+	// Keybase entries are resolved here, by the client; the server only
+	// accepts the public keys themselves
+	resolved, err := ResolveKeybasePubkeys(splitValues)
 	if err != nil {
 		return err
 	}
 
 	// Now go through the actual flag, and substitute in resolved keybase
 	// entries where appropriate
-	for _, keyfile := range splitValues {
+	for i, keyfile := range splitValues {
 		if strings.HasPrefix(keyfile, kbPrefix) {
-			key := keybaseMap[keyfile]
-			if key == "" {
-				return fmt.Errorf("key for keybase user %s was not found in the map", strings.TrimPrefix(keyfile, kbPrefix))
-			}
-			*p = append(*p, key)
+			*p = append(*p, resolved[i])
 			continue
 		}
 
@@ -114,3 +114,31 @@ func FetchKeybasePubkeys(input []string) (map[string]string, error) {
 
 	return ret, nil
 }
+
+// ResolveKeybasePubkeys returns the given list of base64-encoded public keys
+// with any "keybase:<username>" entries replaced by the user's key fetched
+// from Keybase. Ordering is preserved.
+func ResolveKeybasePubkeys(input []string) ([]string, error) {
+	keybaseMap, err := FetchKeybasePubkeys(input)
+	if err != nil {
+		return nil, err
+	}
+	if len(keybaseMap) == 0 {
+		return input, nil
+	}
+
+	ret := make([]string, 0, len(input))
+	for _, v := range input {
+		if !strings.HasPrefix(v, kbPrefix) {
+			ret = append(ret, v)
+			continue
+		}
+		key := keybaseMap[v]
+		if key == "" {
+			return nil, fmt.Errorf("key for keybase user %s was not found in the map", strings.TrimPrefix(v, kbPrefix))
+		}
+		ret = append(ret, key)
+	}
+
+	return ret, nil
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"encoding/base64"
 	"encoding/hex"
+	"os"
 	"reflect"
 	"testing"
 
@@ -11,6 +12,34 @@ import (
 	"github.com/keybase/go-crypto/openpgp/packet"
 )
 
+func TestResolveKeybasePubkeys_noKeybase(t *testing.T) {
+	input := []string{pubKey1, pubKey2}
+	ret, err := ResolveKeybasePubkeys(input)
+	if err != nil {
+		t.Fatalf("bad: %v", err)
+	}
+	if !reflect.DeepEqual(ret, input) {
+		t.Fatalf("bad: %v", ret)
+	}
+}
+
+func TestResolveKeybasePubkeys(t *testing.T) {
+	if os.Getenv("VAULT_ACC") == "" {
+		t.Skip("Acceptance tests skipped unless env 'VAULT_ACC' set")
+		return
+	}
+	ret, err := ResolveKeybasePubkeys([]string{pubKey1, "keybase:jefferai"})
+	if err != nil {
+		t.Fatalf("bad: %v", err)
+	}
+	if len(ret) != 2 || ret[0] != pubKey1 {
+		t.Fatalf("bad: %v", ret)
+	}
+	if _, err := base64.StdEncoding.DecodeString(ret[1]); err != nil {
+		t.Fatalf("error decoding key for keybase user: %v", err)
+	}
+}
+
 func TestFetchKeybasePubkeys(t *testing.T) {
 	testset := []string{"keybase:jefferai", "keybase:hashicorp"}
 	ret, err := FetchKeybasePubkeys(testset)
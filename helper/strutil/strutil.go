@@ -18,6 +18,18 @@ func StrListContains(haystack []string, needle string) bool {
 	return false
 }
 
+// StrListDelete returns a copy of the list with all occurrences of the
+// given item removed.
+func StrListDelete(list []string, item string) []string {
+	var result []string
+	for _, entry := range list {
+		if entry != item {
+			result = append(result, entry)
+		}
+	}
+	return result
+}
+
 // StrListSubset checks if a given list is a subset
 // of another set
 func StrListSubset(super, sub []string) bool {
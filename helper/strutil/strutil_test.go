@@ -35,6 +35,25 @@ func TestStrutil_ListContains(t *testing.T) {
 	}
 }
 
+func TestStrutil_ListDelete(t *testing.T) {
+	list := []string{
+		"dev",
+		"ops",
+		"dev",
+		"prod",
+	}
+	expected := []string{
+		"ops",
+		"prod",
+	}
+	if actual := StrListDelete(list, "dev"); !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("bad: %#v", actual)
+	}
+	if len(list) != 4 {
+		t.Fatalf("expected the input list to be left unmodified")
+	}
+}
+
 func TestStrutil_ListSubset(t *testing.T) {
 	parent := []string{
 		"dev",
@@ -0,0 +1,25 @@
+package tlsutil
+
+import (
+	"crypto/tls"
+	"reflect"
+	"testing"
+)
+
+func TestParseCiphers(t *testing.T) {
+	ciphers, err := ParseCiphers("TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256, TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	expected := []uint16{
+		tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+		tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
+	}
+	if !reflect.DeepEqual(ciphers, expected) {
+		t.Fatalf("bad: %v", ciphers)
+	}
+
+	if _, err := ParseCiphers("TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,TLS_BOGUS"); err == nil {
+		t.Fatal("expected error")
+	}
+}
@@ -0,0 +1,72 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/hashicorp/vault/vault"
+)
+
+// allowedMethods are the methods allowed in cross-origin requests
+var allowedMethods = []string{
+	http.MethodDelete,
+	http.MethodGet,
+	http.MethodOptions,
+	http.MethodPatch,
+	http.MethodPost,
+	http.MethodPut,
+	"LIST",
+}
+
+// wrapCORSHandler wraps a handler to answer the CORS preflight requests and
+// to allow the cross-origin requests from the origins configured via
+// sys/config/cors
+func wrapCORSHandler(h http.Handler, core *vault.Core) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		corsConf := core.CORSConfig()
+
+		origin := req.Header.Get("Origin")
+		requestMethod := req.Header.Get("Access-Control-Request-Method")
+
+		// Requests without an origin aren't cross-origin
+		if origin == "" || !corsConf.IsEnabled() {
+			h.ServeHTTP(w, req)
+			return
+		}
+
+		if !corsConf.IsValidOrigin(origin) {
+			respondError(w, http.StatusForbidden, fmt.Errorf("origin not allowed"))
+			return
+		}
+
+		if req.Method == http.MethodOptions && !validMethod(requestMethod) {
+			respondError(w, http.StatusMethodNotAllowed, fmt.Errorf("method not allowed"))
+			return
+		}
+
+		w.Header().Set("Access-Control-Allow-Origin", origin)
+		w.Header().Set("Access-Control-Expose-Headers", RequestIDHeaderName)
+		w.Header().Add("Vary", "Origin")
+
+		// Answer the preflight requests
+		if req.Method == http.MethodOptions {
+			w.Header().Set("Access-Control-Allow-Methods", strings.Join(allowedMethods, ","))
+			w.Header().Set("Access-Control-Allow-Headers", strings.Join(corsConf.AllowedHeadersList(), ","))
+			w.Header().Set("Access-Control-Max-Age", "300")
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		h.ServeHTTP(w, req)
+	})
+}
+
+func validMethod(method string) bool {
+	for _, m := range allowedMethods {
+		if m == method {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,95 @@
+package http
+
+import (
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/go-cleanhttp"
+	"github.com/hashicorp/vault/vault"
+)
+
+func TestHandler_CORS(t *testing.T) {
+	core, _, token := vault.TestCoreUnsealed(t)
+	ln, addr := TestServer(t, core)
+	defer ln.Close()
+	TestServerAuth(t, addr, token)
+
+	client := cleanhttp.DefaultClient()
+	do := func(method, origin string) *http.Response {
+		req, err := http.NewRequest(method, addr+"/v1/sys/seal-status", nil)
+		if err != nil {
+			t.Fatalf("err: %s", err)
+		}
+		req.Header.Set("Origin", origin)
+		req.Header.Set("Access-Control-Request-Method", "GET")
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatalf("err: %s", err)
+		}
+		resp.Body.Close()
+		return resp
+	}
+
+	// CORS is disabled by default
+	resp := do("GET", "http://www.example.com")
+	testResponseStatus(t, resp, 200)
+	if v := resp.Header.Get("Access-Control-Allow-Origin"); v != "" {
+		t.Fatalf("bad: %q", v)
+	}
+
+	resp = testHttpPut(t, token, addr+"/v1/sys/config/cors", map[string]interface{}{
+		"allowed_origins": "http://www.example.com",
+		"allowed_headers": "x-custom-header",
+	})
+	testResponseStatus(t, resp, 204)
+
+	resp = testHttpGet(t, token, addr+"/v1/sys/config/cors")
+	var actual map[string]interface{}
+	testResponseStatus(t, resp, 200)
+	testResponseBody(t, resp, &actual)
+	expected := map[string]interface{}{
+		"enabled":         true,
+		"allowed_origins": []interface{}{"http://www.example.com"},
+		"allowed_headers": []interface{}{
+			"Content-Type",
+			"X-Requested-With",
+			"X-Vault-Namespace",
+			"X-Vault-No-Request-Forwarding",
+			"X-Vault-Request-Id",
+			"X-Vault-Token",
+			"X-Vault-Wrap-TTL",
+			"X-Custom-Header",
+		},
+	}
+	if !reflect.DeepEqual(actual["data"], expected) {
+		t.Fatalf("bad: %#v", actual["data"])
+	}
+
+	// Preflight request from an allowed origin
+	resp = do("OPTIONS", "http://www.example.com")
+	testResponseStatus(t, resp, 204)
+	if v := resp.Header.Get("Access-Control-Allow-Origin"); v != "http://www.example.com" {
+		t.Fatalf("bad: %q", v)
+	}
+	if v := resp.Header.Get("Access-Control-Allow-Headers"); !strings.Contains(v, "X-Custom-Header") {
+		t.Fatalf("bad: %q", v)
+	}
+
+	resp = do("GET", "http://www.example.com")
+	testResponseStatus(t, resp, 200)
+	if v := resp.Header.Get("Access-Control-Allow-Origin"); v != "http://www.example.com" {
+		t.Fatalf("bad: %q", v)
+	}
+
+	// Request from another origin
+	resp = do("GET", "http://www.other.com")
+	testResponseStatus(t, resp, 403)
+
+	resp = testHttpDelete(t, token, addr+"/v1/sys/config/cors")
+	testResponseStatus(t, resp, 204)
+
+	resp = do("GET", "http://www.other.com")
+	testResponseStatus(t, resp, 200)
+}
@@ -0,0 +1,52 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+)
+
+// WrapCustomHeadersHandler wraps a handler to add the custom headers of a
+// listener to its responses. The headers are keyed by "default", a class of
+// status codes such as "4xx" or a status code. The headers of a status code
+// take precedence over those of its class, which take precedence over the
+// default ones, and none overrides the headers set by Vault itself.
+func WrapCustomHeadersHandler(h http.Handler, headers map[string]map[string]string) http.Handler {
+	if len(headers) == 0 {
+		return h
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h.ServeHTTP(&customHeadersWriter{ResponseWriter: w, headers: headers}, r)
+	})
+}
+
+// customHeadersWriter adds the custom headers matching the status code of
+// the response when it is written
+type customHeadersWriter struct {
+	http.ResponseWriter
+	headers     map[string]map[string]string
+	wroteHeader bool
+}
+
+func (w *customHeadersWriter) WriteHeader(code int) {
+	if !w.wroteHeader {
+		w.wroteHeader = true
+
+		status := strconv.Itoa(code)
+		for _, class := range []string{status, status[:1] + "xx", "default"} {
+			for name, value := range w.headers[class] {
+				if w.Header().Get(name) == "" {
+					w.Header().Set(name, value)
+				}
+			}
+		}
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *customHeadersWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	return w.ResponseWriter.Write(b)
+}
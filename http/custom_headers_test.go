@@ -0,0 +1,70 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWrapCustomHeadersHandler(t *testing.T) {
+	headers := map[string]map[string]string{
+		"default": {
+			"Strict-Transport-Security": "max-age=31536000",
+			"Cache-Control":             "no-store",
+			"Content-Type":              "text/plain",
+		},
+		"4xx": {
+			"Cache-Control": "no-cache",
+		},
+		"404": {
+			"X-Custom": "not found",
+		},
+	}
+
+	handler := WrapCustomHeadersHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/ok":
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte("{}"))
+		case "/forbidden":
+			respondError(w, http.StatusForbidden, nil)
+		default:
+			respondError(w, http.StatusNotFound, nil)
+		}
+	}), headers)
+
+	cases := []struct {
+		path     string
+		code     int
+		expected map[string]string
+	}{
+		{"/ok", http.StatusOK, map[string]string{
+			"Strict-Transport-Security": "max-age=31536000",
+			"Cache-Control":             "no-store",
+			"Content-Type":              "application/json",
+			"X-Custom":                  "",
+		}},
+		{"/forbidden", http.StatusForbidden, map[string]string{
+			"Strict-Transport-Security": "max-age=31536000",
+			"Cache-Control":             "no-cache",
+			"X-Custom":                  "",
+		}},
+		{"/missing", http.StatusNotFound, map[string]string{
+			"Cache-Control": "no-cache",
+			"X-Custom":      "not found",
+		}},
+	}
+
+	for _, tc := range cases {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("GET", tc.path, nil))
+		if w.Code != tc.code {
+			t.Fatalf("%s: bad code: %d", tc.path, w.Code)
+		}
+		for name, value := range tc.expected {
+			if actual := w.Header().Get(name); actual != value {
+				t.Fatalf("%s: bad %s: %q", tc.path, name, actual)
+			}
+		}
+	}
+}
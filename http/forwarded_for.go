@@ -0,0 +1,78 @@
+package http
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// WrapForwardedForHandler wraps a handler to take the remote address of the
+// requests coming from the authorized proxies from their X-Forwarded-For
+// header. The address used is the last one of the header, unless hopSkips
+// addresses set by further trusted proxies are skipped. Requests without
+// the header, or with it but from unauthorized addresses, are rejected with
+// a 403 if configured to, and keep their remote address otherwise.
+func WrapForwardedForHandler(h http.Handler, authorizedAddrs []*net.IPNet, rejectNotPresent, rejectNotAuthorized bool, hopSkips int) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers, ok := r.Header["X-Forwarded-For"]
+		if !ok {
+			if rejectNotPresent {
+				respondError(w, http.StatusForbidden, fmt.Errorf("missing x-forwarded-for header and configured to reject when not present"))
+				return
+			}
+			h.ServeHTTP(w, r)
+			return
+		}
+
+		host, port, err := net.SplitHostPort(r.RemoteAddr)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, fmt.Errorf("error parsing client hostport: %v", err))
+			return
+		}
+		addr := net.ParseIP(host)
+		if addr == nil {
+			respondError(w, http.StatusBadRequest, fmt.Errorf("error parsing client address %q", host))
+			return
+		}
+
+		var authorized bool
+		for _, authz := range authorizedAddrs {
+			if authz.Contains(addr) {
+				authorized = true
+				break
+			}
+		}
+		if !authorized {
+			if rejectNotAuthorized {
+				respondError(w, http.StatusForbidden, fmt.Errorf("client address not authorized for x-forwarded-for and configured to reject connection"))
+				return
+			}
+			h.ServeHTTP(w, r)
+			return
+		}
+
+		// The header may be set several times, each with a list of addresses
+		var addrs []string
+		for _, header := range headers {
+			for _, v := range strings.Split(header, ",") {
+				if v = strings.TrimSpace(v); v != "" {
+					addrs = append(addrs, v)
+				}
+			}
+		}
+
+		index := len(addrs) - 1 - hopSkips
+		if index < 0 {
+			respondError(w, http.StatusForbidden, fmt.Errorf("malformed x-forwarded-for configuration or request, hops to skip would skip before earliest chain link"))
+			return
+		}
+		if net.ParseIP(addrs[index]) == nil {
+			respondError(w, http.StatusBadRequest, fmt.Errorf("invalid address %q in x-forwarded-for header", addrs[index]))
+			return
+		}
+
+		r.RemoteAddr = net.JoinHostPort(addrs[index], port)
+		h.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,67 @@
+package http
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWrapForwardedForHandler(t *testing.T) {
+	_, authz, err := net.ParseCIDR("127.0.0.1/32")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var remoteAddr string
+	echo := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		remoteAddr = r.RemoteAddr
+	})
+
+	cases := []struct {
+		remoteAddr          string
+		headers             []string
+		rejectNotPresent    bool
+		rejectNotAuthorized bool
+		hopSkips            int
+		code                int
+		expected            string
+	}{
+		// Missing header
+		{"127.0.0.1:1234", nil, true, true, 0, http.StatusForbidden, ""},
+		{"127.0.0.1:1234", nil, false, true, 0, http.StatusOK, "127.0.0.1:1234"},
+
+		// Unauthorized proxy
+		{"10.0.0.1:1234", []string{"1.2.3.4"}, true, true, 0, http.StatusForbidden, ""},
+		{"10.0.0.1:1234", []string{"1.2.3.4"}, true, false, 0, http.StatusOK, "10.0.0.1:1234"},
+
+		// Authorized proxy
+		{"127.0.0.1:1234", []string{"1.2.3.4"}, true, true, 0, http.StatusOK, "1.2.3.4:1234"},
+		{"127.0.0.1:1234", []string{"5.6.7.8, 1.2.3.4"}, true, true, 0, http.StatusOK, "1.2.3.4:1234"},
+		{"127.0.0.1:1234", []string{"5.6.7.8, 1.2.3.4"}, true, true, 1, http.StatusOK, "5.6.7.8:1234"},
+		{"127.0.0.1:1234", []string{"5.6.7.8", "1.2.3.4"}, true, true, 1, http.StatusOK, "5.6.7.8:1234"},
+		{"127.0.0.1:1234", []string{"1.2.3.4"}, true, true, 1, http.StatusForbidden, ""},
+		{"127.0.0.1:1234", []string{"unknown"}, true, true, 0, http.StatusBadRequest, ""},
+	}
+
+	for i, tc := range cases {
+		remoteAddr = ""
+		handler := WrapForwardedForHandler(echo, []*net.IPNet{authz},
+			tc.rejectNotPresent, tc.rejectNotAuthorized, tc.hopSkips)
+
+		req := httptest.NewRequest("GET", "/v1/sys/health", nil)
+		req.RemoteAddr = tc.remoteAddr
+		for _, header := range tc.headers {
+			req.Header.Add("X-Forwarded-For", header)
+		}
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		if w.Code != tc.code {
+			t.Fatalf("%d: bad code: %d", i, w.Code)
+		}
+		if remoteAddr != tc.expected {
+			t.Fatalf("%d: bad remote address: %q", i, remoteAddr)
+		}
+	}
+}
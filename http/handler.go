@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"net/url"
 	"strings"
+	"time"
 
 	"github.com/hashicorp/errwrap"
 	"github.com/hashicorp/vault/helper/duration"
@@ -26,6 +27,22 @@ const (
 	// NoRequestForwardingHeaderName is the name of the header telling Vault
 	// not to use request forwarding
 	NoRequestForwardingHeaderName = "X-Vault-No-Request-Forwarding"
+
+	// RequestIDHeaderName is the name of the header containing the ID of the
+	// request, set by the client or generated by Vault
+	RequestIDHeaderName = "X-Vault-Request-Id"
+
+	// NamespaceHeaderName is the name of the header containing the path of
+	// the namespace of the request
+	NamespaceHeaderName = "X-Vault-Namespace"
+
+	// DefaultMaxRequestSize is the default maximum size of a request body,
+	// 32MB
+	DefaultMaxRequestSize = 32 * 1024 * 1024
+
+	// DefaultMaxRequestDuration is the default maximum time spent handling
+	// a request
+	DefaultMaxRequestDuration = 90 * time.Second
 )
 
 // Handler returns an http.Handler for the API. This can be used on
@@ -55,9 +72,47 @@ func Handler(core *vault.Core) http.Handler {
 	// Wrap the handler in another handler to trigger all help paths.
 	handler := handleHelpHandler(mux, core)
 
+	// Answer the CORS preflight requests before any other handler
+	handler = wrapCORSHandler(handler, core)
+
+	// Identify every request, including the rejected ones
+	handler = wrapRequestIDHandler(handler)
+
 	return handler
 }
 
+// WrapRequestLimits wraps a handler to reject request bodies larger than
+// maxSize bytes and to give up on requests taking longer than maxDuration
+// with a 503. A limit that is zero or negative isn't enforced. The log
+// streams of sys/monitor aren't limited in duration.
+func WrapRequestLimits(handler http.Handler, maxSize int64, maxDuration time.Duration) http.Handler {
+	if maxDuration > 0 {
+		timeoutHandler := http.TimeoutHandler(handler, maxDuration,
+			fmt.Sprintf(`{"errors":["request exceeded the maximum duration of %s"]}`, maxDuration))
+		streamHandler := handler
+		handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == MonitorPath {
+				streamHandler.ServeHTTP(w, r)
+				return
+			}
+			timeoutHandler.ServeHTTP(w, r)
+		})
+	}
+	if maxSize <= 0 {
+		return handler
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.ContentLength > maxSize {
+			respondError(w, http.StatusRequestEntityTooLarge,
+				fmt.Errorf("request body exceeds the maximum size of %d bytes", maxSize))
+			return
+		}
+		r.Body = http.MaxBytesReader(w, r.Body, maxSize)
+		handler.ServeHTTP(w, r)
+	})
+}
+
 // ClientToken is required in the handler of sys/capabilities-self endpoint in
 // system backend. But the ClientToken gets obfuscated before the request gets
 // forwarded to any logical backend. So, setting the ClientToken in the data
@@ -151,6 +206,8 @@ func handleRequestForwarding(core *vault.Core, handler http.Handler) http.Handle
 
 		if header != nil {
 			for k, v := range header {
+				// Replace the headers already set, such as the request ID
+				w.Header().Del(k)
 				for _, j := range v {
 					w.Header().Add(k, j)
 				}
@@ -254,20 +311,29 @@ func requestWrapTTL(r *http.Request, req *logical.Request) (*logical.Request, er
 }
 
 func respondError(w http.ResponseWriter, status int, err error) {
+	respondErrorWarnings(w, status, err, nil)
+}
+
+// respondErrorWarnings responds with the error, along with the warnings of
+// the response of the failed request
+func respondErrorWarnings(w http.ResponseWriter, status int, err error, warnings []string) {
 	// Adjust status code when sealed
 	if errwrap.Contains(err, vault.ErrSealed.Error()) {
 		status = http.StatusServiceUnavailable
 	}
 
 	// Allow HTTPCoded error passthrough to specify a code
-	if t, ok := err.(logical.HTTPCodedError); ok {
-		status = t.Code()
+	if code, ok := codedErrorStatus(err); ok {
+		status = code
 	}
 
 	w.Header().Add("Content-Type", "application/json")
 	w.WriteHeader(status)
 
-	resp := &ErrorResponse{Errors: make([]string, 0, 1)}
+	resp := &ErrorResponse{
+		Errors:   make([]string, 0, 1),
+		Warnings: warnings,
+	}
 	if err != nil {
 		resp.Errors = append(resp.Errors, err.Error())
 	}
@@ -276,6 +342,19 @@ func respondError(w http.ResponseWriter, status int, err error) {
 	enc.Encode(resp)
 }
 
+// codedErrorStatus returns the code of the first logical.HTTPCodedError
+// within the error, which may be wrapped, such as by the core
+func codedErrorStatus(err error) (int, bool) {
+	var code int
+	var ok bool
+	errwrap.Walk(err, func(e error) {
+		if t, isCoded := e.(logical.HTTPCodedError); isCoded && !ok {
+			code, ok = t.Code(), true
+		}
+	})
+	return code, ok
+}
+
 func respondErrorCommon(w http.ResponseWriter, resp *logical.Response, err error) bool {
 	// If there are no errors return
 	if err == nil && (resp == nil || !resp.IsError()) {
@@ -293,7 +372,10 @@ func respondErrorCommon(w http.ResponseWriter, resp *logical.Response, err error
 	// Now, check the error itself; if it has a specific logical error, set the
 	// appropriate code
 	if err != nil {
+		code, coded := codedErrorStatus(err)
 		switch {
+		case coded:
+			statusCode = code
 		case errwrap.ContainsType(err, new(vault.StatusBadRequest)):
 			statusCode = http.StatusBadRequest
 		case errwrap.Contains(err, logical.ErrPermissionDenied.Error()):
@@ -307,11 +389,15 @@ func respondErrorCommon(w http.ResponseWriter, resp *logical.Response, err error
 		}
 	}
 
-	if resp != nil && resp.IsError() {
-		err = fmt.Errorf("%s", resp.Data["error"].(string))
+	var warnings []string
+	if resp != nil {
+		warnings = resp.Warnings
+		if resp.IsError() {
+			err = fmt.Errorf("%s", resp.Data["error"].(string))
+		}
 	}
 
-	respondError(w, statusCode, err)
+	respondErrorWarnings(w, statusCode, err, warnings)
 	return true
 }
 
@@ -328,5 +414,6 @@ func respondOk(w http.ResponseWriter, body interface{}) {
 }
 
 type ErrorResponse struct {
-	Errors []string `json:"errors"`
+	Errors   []string `json:"errors"`
+	Warnings []string `json:"warnings,omitempty"`
 }
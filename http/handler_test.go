@@ -6,9 +6,12 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"reflect"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/hashicorp/go-cleanhttp"
+	"github.com/hashicorp/go-multierror"
 	"github.com/hashicorp/vault/logical"
 	"github.com/hashicorp/vault/vault"
 )
@@ -64,6 +67,14 @@ func TestSysMounts_headerAuth(t *testing.T) {
 					"max_lease_ttl":     json.Number("0"),
 				},
 			},
+			"identity/": map[string]interface{}{
+				"description": "identity store",
+				"type":        "identity",
+				"config": map[string]interface{}{
+					"default_lease_ttl": json.Number("0"),
+					"max_lease_ttl":     json.Number("0"),
+				},
+			},
 		},
 		"secret/": map[string]interface{}{
 			"description": "generic secret storage",
@@ -89,6 +100,14 @@ func TestSysMounts_headerAuth(t *testing.T) {
 				"max_lease_ttl":     json.Number("0"),
 			},
 		},
+		"identity/": map[string]interface{}{
+			"description": "identity store",
+			"type":        "identity",
+			"config": map[string]interface{}{
+				"default_lease_ttl": json.Number("0"),
+				"max_lease_ttl":     json.Number("0"),
+			},
+		},
 	}
 	testResponseStatus(t, resp, 200)
 	testResponseBody(t, resp, &actual)
@@ -142,6 +161,12 @@ func TestSysMounts_headerAuth_Wrapped(t *testing.T) {
 	}
 	expected["wrap_info"].(map[string]interface{})["token"] = actualToken
 
+	actualAccessor, ok := actual["wrap_info"].(map[string]interface{})["accessor"]
+	if !ok || actualAccessor == "" {
+		t.Fatal("accessor missing in wrap info")
+	}
+	expected["wrap_info"].(map[string]interface{})["accessor"] = actualAccessor
+
 	actualCreationTime, ok := actual["wrap_info"].(map[string]interface{})["creation_time"]
 	if !ok || actualCreationTime == "" {
 		t.Fatal("creation_time missing in wrap info")
@@ -197,3 +222,69 @@ func TestHandler_error(t *testing.T) {
 	}
 
 }
+
+func TestHandler_respondErrorCommon(t *testing.T) {
+	// The code of a coded error wrapped by the core is kept
+	resp := logical.ErrorResponse("conflict")
+	resp.AddWarning("deprecated")
+	err := multierror.Append(nil, logical.CodedError(409, "conflict"))
+
+	w := httptest.NewRecorder()
+	if !respondErrorCommon(w, resp, err) {
+		t.Fatal("expected an error response")
+	}
+	if w.Code != 409 {
+		t.Fatalf("expected 409, got %d", w.Code)
+	}
+
+	// The warnings are returned along with the errors
+	var body ErrorResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	expected := ErrorResponse{
+		Errors:   []string{"conflict"},
+		Warnings: []string{"deprecated"},
+	}
+	if !reflect.DeepEqual(body, expected) {
+		t.Fatalf("bad: %#v", body)
+	}
+}
+
+func TestHandler_requestLimits(t *testing.T) {
+	slow := make(chan struct{})
+	defer close(slow)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			<-slow
+			return
+		}
+		var body map[string]interface{}
+		if err := parseRequest(r, &body); err != nil {
+			respondError(w, http.StatusBadRequest, err)
+			return
+		}
+		respondOk(w, nil)
+	})
+	server := httptest.NewServer(WrapRequestLimits(handler, 16, 100*time.Millisecond))
+	defer server.Close()
+
+	client := cleanhttp.DefaultClient()
+	resp, err := client.Post(server.URL+"/fast", "application/json", strings.NewReader(`{"a":"b"}`))
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	testResponseStatus(t, resp, 204)
+
+	resp, err = client.Post(server.URL+"/fast", "application/json", strings.NewReader(`{"a":"bcdefghijklmnopqrstuvwxyz"}`))
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	testResponseStatus(t, resp, http.StatusRequestEntityTooLarge)
+
+	resp, err = client.Get(server.URL + "/slow")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	testResponseStatus(t, resp, http.StatusServiceUnavailable)
+}
@@ -28,9 +28,11 @@ func handleHelp(core *vault.Core, w http.ResponseWriter, req *http.Request) {
 	}
 
 	resp, err := core.HandleRequest(requestAuth(req, &logical.Request{
+		ID:         req.Header.Get(RequestIDHeaderName),
 		Operation:  logical.HelpOperation,
 		Path:       path,
 		Connection: getConnection(req),
+		Namespace:  req.Header.Get(NamespaceHeaderName),
 	}))
 	if err != nil {
 		respondError(w, http.StatusInternalServerError, err)
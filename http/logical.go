@@ -1,9 +1,11 @@
 package http
 
 import (
+	"fmt"
 	"io"
 	"net"
 	"net/http"
+	"net/url"
 	"strconv"
 	"strings"
 
@@ -13,6 +15,10 @@ import (
 	"github.com/hashicorp/vault/vault"
 )
 
+// MergePatchContentType is the content type of the JSON merge patches of the
+// PATCH requests
+const MergePatchContentType = "application/merge-patch+json"
+
 type PrepareRequestFunc func(req *logical.Request) error
 
 func buildLogicalRequest(w http.ResponseWriter, r *http.Request) (*logical.Request, int, error) {
@@ -48,13 +54,24 @@ func buildLogicalRequest(w http.ResponseWriter, r *http.Request) (*logical.Reque
 		op = logical.UpdateOperation
 	case "LIST":
 		op = logical.ListOperation
+	case "PATCH":
+		// The patches are JSON merge patches
+		contentType := r.Header.Get("Content-Type")
+		if contentType != "" && !strings.HasPrefix(contentType, MergePatchContentType) &&
+			!strings.HasPrefix(contentType, "application/json") {
+			return nil, http.StatusUnsupportedMediaType, fmt.Errorf("the content type of patches must be %s", MergePatchContentType)
+		}
+		op = logical.PatchOperation
 	default:
 		return nil, http.StatusMethodNotAllowed, nil
 	}
 
 	// Parse the request if we can
 	var data map[string]interface{}
-	if op == logical.UpdateOperation {
+	if op == logical.ListOperation {
+		data = parseListQuery(r.URL.Query())
+	}
+	if op == logical.UpdateOperation || op == logical.PatchOperation {
 		err := parseRequest(r, &data)
 		if err == io.EOF {
 			data = nil
@@ -65,10 +82,14 @@ func buildLogicalRequest(w http.ResponseWriter, r *http.Request) (*logical.Reque
 		}
 	}
 
+	// The request ID is set by the handler, unless called directly
 	var err error
-	request_id, err := uuid.GenerateUUID()
-	if err != nil {
-		return nil, http.StatusBadRequest, errwrap.Wrapf("failed to generate identifier for the request: {{err}}", err)
+	request_id := r.Header.Get(RequestIDHeaderName)
+	if request_id == "" {
+		request_id, err = uuid.GenerateUUID()
+		if err != nil {
+			return nil, http.StatusBadRequest, errwrap.Wrapf("failed to generate identifier for the request: {{err}}", err)
+		}
 	}
 
 	req := requestAuth(r, &logical.Request{
@@ -77,6 +98,8 @@ func buildLogicalRequest(w http.ResponseWriter, r *http.Request) (*logical.Reque
 		Path:       path,
 		Data:       data,
 		Connection: getConnection(r),
+		Namespace:  r.Header.Get(NamespaceHeaderName),
+		Headers:    r.Header,
 	})
 	req, err = requestWrapTTL(r, req)
 	if err != nil {
@@ -86,6 +109,21 @@ func buildLogicalRequest(w http.ResponseWriter, r *http.Request) (*logical.Reque
 	return req, 0, nil
 }
 
+// parseListQuery returns the data of a list operation, its pagination
+// parameters, from the query of the request
+func parseListQuery(values url.Values) map[string]interface{} {
+	var data map[string]interface{}
+	for _, name := range []string{"after", "limit"} {
+		if v := values.Get(name); v != "" {
+			if data == nil {
+				data = make(map[string]interface{})
+			}
+			data[name] = v
+		}
+	}
+	return data
+}
+
 func handleLogical(core *vault.Core, dataOnly bool, prepareRequestCallback PrepareRequestFunc) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		req, statusCode, err := buildLogicalRequest(w, r)
@@ -168,6 +206,7 @@ func respondLogical(w http.ResponseWriter, r *http.Request, req *logical.Request
 			httpResp = &logical.HTTPResponse{
 				WrapInfo: &logical.HTTPWrapInfo{
 					Token:           resp.WrapInfo.Token,
+					Accessor:        resp.WrapInfo.Accessor,
 					TTL:             int(resp.WrapInfo.TTL.Seconds()),
 					CreationTime:    resp.WrapInfo.CreationTime,
 					WrappedAccessor: resp.WrapInfo.WrappedAccessor,
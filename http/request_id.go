@@ -0,0 +1,54 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/hashicorp/go-uuid"
+)
+
+// maxRequestIDLength is the maximum length of a request ID set by a client
+const maxRequestIDLength = 128
+
+// wrapRequestIDHandler wraps a handler to identify every request with the ID
+// set by the client in the X-Vault-Request-Id header, or with a generated
+// one otherwise. The ID is returned in the same header of the response, is
+// used as the request_id of the response body and is logged by the audit
+// backends, so that errors seen by clients can be found in the logs.
+func wrapRequestIDHandler(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(RequestIDHeaderName)
+		if id == "" {
+			var err error
+			id, err = uuid.GenerateUUID()
+			if err != nil {
+				respondError(w, http.StatusInternalServerError, fmt.Errorf("failed to generate identifier for the request: %v", err))
+				return
+			}
+			r.Header.Set(RequestIDHeaderName, id)
+		} else if !validRequestID(id) {
+			respondError(w, http.StatusBadRequest, fmt.Errorf("invalid %s header: up to %d letters, digits, '-', '_', '.' or ':' are allowed", RequestIDHeaderName, maxRequestIDLength))
+			return
+		}
+
+		w.Header().Set(RequestIDHeaderName, id)
+		h.ServeHTTP(w, r)
+	})
+}
+
+// validRequestID returns whether a request ID set by a client can be used,
+// which keeps it safe to log
+func validRequestID(id string) bool {
+	if len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, c := range id {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case c == '-', c == '_', c == '.', c == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,63 @@
+package http
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/hashicorp/vault/vault"
+)
+
+func TestRequestID(t *testing.T) {
+	core, _, token := vault.TestCoreUnsealed(t)
+	ln, addr := TestServer(t, core)
+	defer ln.Close()
+	TestServerAuth(t, addr, token)
+
+	// Generated ID, returned in the header and the body
+	resp := testHttpGet(t, token, addr+"/v1/secret/foo?list=true")
+	testResponseStatus(t, resp, 404)
+	if resp.Header.Get(RequestIDHeaderName) == "" {
+		t.Fatal("missing request ID")
+	}
+
+	testHttpPut(t, token, addr+"/v1/secret/foo", map[string]interface{}{"data": "bar"})
+	resp = testHttpGet(t, token, addr+"/v1/secret/foo")
+	testResponseStatus(t, resp, 200)
+
+	var actual map[string]interface{}
+	testResponseBody(t, resp, &actual)
+	id := resp.Header.Get(RequestIDHeaderName)
+	if id == "" || actual["request_id"] != id {
+		t.Fatalf("bad: %q %#v", id, actual)
+	}
+
+	// ID set by the client
+	for _, tc := range []struct {
+		id   string
+		code int
+	}{
+		{"client-request.1:2", http.StatusOK},
+		{"bad id", http.StatusBadRequest},
+	} {
+		req, err := http.NewRequest("GET", addr+"/v1/secret/foo", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set(AuthHeaderName, token)
+		req.Header.Set(RequestIDHeaderName, tc.id)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		testResponseStatus(t, resp, tc.code)
+		if tc.code != http.StatusOK {
+			continue
+		}
+
+		actual = nil
+		testResponseBody(t, resp, &actual)
+		if resp.Header.Get(RequestIDHeaderName) != tc.id || actual["request_id"] != tc.id {
+			t.Fatalf("bad: %q %#v", resp.Header.Get(RequestIDHeaderName), actual)
+		}
+	}
+}
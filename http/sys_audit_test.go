@@ -128,3 +128,31 @@ func TestSysAuditHash(t *testing.T) {
 		t.Fatalf("bad: expected:\n%#v\n, got:\n%#v\n", expected, actual)
 	}
 }
+
+func TestSysAuditedHeaders(t *testing.T) {
+	core, _, token := vault.TestCoreUnsealed(t)
+	ln, addr := TestServer(t, core)
+	defer ln.Close()
+	TestServerAuth(t, addr, token)
+
+	resp := testHttpPost(t, token, addr+"/v1/sys/config/auditing/request-headers/X-Forwarded-For", map[string]interface{}{
+		"hmac": true,
+	})
+	testResponseStatus(t, resp, 204)
+
+	resp = testHttpGet(t, token, addr+"/v1/sys/config/auditing/request-headers")
+	testResponseStatus(t, resp, 200)
+
+	var actual map[string]interface{}
+	testResponseBody(t, resp, &actual)
+	expected := map[string]interface{}{
+		"headers": map[string]interface{}{
+			"x-forwarded-for": map[string]interface{}{
+				"hmac": true,
+			},
+		},
+	}
+	if !reflect.DeepEqual(actual["data"], expected) {
+		t.Fatalf("bad: expected:\n%#v actual:\n%#v\n", expected, actual["data"])
+	}
+}
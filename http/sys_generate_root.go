@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"strings"
 
 	"github.com/hashicorp/vault/vault"
 )
@@ -90,6 +91,13 @@ func handleSysGenerateRootAttemptPut(core *vault.Core, w http.ResponseWriter, r
 		return
 	}
 
+	// Keybase handles are resolved by the client, the server doesn't fetch
+	// anything on behalf of this unauthenticated endpoint
+	if strings.HasPrefix(req.PGPKey, "keybase:") {
+		respondError(w, http.StatusBadRequest, fmt.Errorf("keybase handles must be resolved to public keys by the client"))
+		return
+	}
+
 	// Attemptialize the generation
 	err := core.GenerateRootInit(req.OTP, req.PGPKey)
 	if err != nil {
@@ -25,16 +25,35 @@ func handleSysHealth(core *vault.Core) http.Handler {
 }
 
 func fetchStatusCode(r *http.Request, field string) (int, bool, bool) {
-	var err error
-	statusCode := http.StatusOK
-	if statusCodeStr, statusCodeOk := r.URL.Query()[field]; statusCodeOk {
-		statusCode, err = strconv.Atoi(statusCodeStr[0])
-		if err != nil || len(statusCodeStr) < 1 {
-			return http.StatusBadRequest, false, false
-		}
-		return statusCode, true, true
+	statusCodeStr, statusCodeOk := r.URL.Query()[field]
+	if !statusCodeOk {
+		return http.StatusOK, false, true
+	}
+
+	// Only valid status codes can be written to the response
+	statusCode, err := strconv.Atoi(statusCodeStr[0])
+	if err != nil || statusCode < 100 || statusCode > 599 {
+		return http.StatusBadRequest, false, false
+	}
+	return statusCode, true, true
+}
+
+// fetchFlag returns whether the boolean query parameter is set, with either
+// no value or a true one
+func fetchFlag(r *http.Request, field string) (bool, bool) {
+	values, ok := r.URL.Query()[field]
+	if !ok {
+		return false, true
 	}
-	return statusCode, false, true
+	if values[0] == "" {
+		return true, true
+	}
+
+	flag, err := strconv.ParseBool(values[0])
+	if err != nil {
+		return false, false
+	}
+	return flag, true
 }
 
 func handleSysHealthGet(core *vault.Core, w http.ResponseWriter, r *http.Request) {
@@ -71,7 +90,10 @@ func handleSysHealthHead(core *vault.Core, w http.ResponseWriter, r *http.Reques
 
 func getSysHealth(core *vault.Core, r *http.Request) (int, *HealthResponse, error) {
 	// Check if being a standby is allowed for the purpose of a 200 OK
-	_, standbyOK := r.URL.Query()["standbyok"]
+	standbyOK, ok := fetchFlag(r, "standbyok")
+	if !ok {
+		return http.StatusBadRequest, nil, nil
+	}
 
 	uninitCode := http.StatusNotImplemented
 	if code, found, ok := fetchStatusCode(r, "uninitcode"); !ok {
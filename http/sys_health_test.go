@@ -224,6 +224,12 @@ func TestSysHealth_head(t *testing.T) {
 		{"", 200},
 		{"?activecode=503", 503},
 		{"?activecode=notacode", 400},
+		{"?activecode=99", 400},
+		{"?activecode=600", 400},
+		{"?standbyok", 200},
+		{"?standbyok=true&standbycode=200", 200},
+		{"?standbyok=false", 200},
+		{"?standbyok=maybe", 400},
 	}
 
 	for _, tt := range testData {
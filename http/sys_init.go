@@ -58,6 +58,13 @@ func handleSysInitPut(core *vault.Core, w http.ResponseWriter, r *http.Request)
 	}
 
 	if core.SealAccess().StoredKeysSupported() {
+		// The seals storing the master key store it unless the request says
+		// otherwise, so that only the recovery shares have to be given
+		if barrierConfig.StoredShares == 0 && len(barrierConfig.PGPKeys) == 0 {
+			barrierConfig.SecretShares = 1
+			barrierConfig.SecretThreshold = 1
+			barrierConfig.StoredShares = 1
+		}
 		if barrierConfig.SecretShares != 1 {
 			respondError(w, http.StatusBadRequest, fmt.Errorf("secret shares must be 1"))
 			return
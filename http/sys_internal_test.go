@@ -0,0 +1,34 @@
+package http
+
+import (
+	"testing"
+
+	"github.com/hashicorp/vault/vault"
+)
+
+func TestSysInternal_OpenAPI(t *testing.T) {
+	core, _, token := vault.TestCoreUnsealed(t)
+	ln, addr := TestServer(t, core)
+	defer ln.Close()
+	TestServerAuth(t, addr, token)
+
+	resp := testHttpGet(t, token, addr+"/v1/sys/internal/specs/openapi")
+	testResponseStatus(t, resp, 200)
+	if contentType := resp.Header.Get("Content-Type"); contentType != "application/json" {
+		t.Fatalf("bad: %s", contentType)
+	}
+
+	// The document is the body itself rather than the data of a response
+	var actual map[string]interface{}
+	testResponseBody(t, resp, &actual)
+	if actual["openapi"] != "3.0.2" || actual["data"] != nil {
+		t.Fatalf("bad: %#v", actual)
+	}
+	paths := actual["paths"].(map[string]interface{})
+	if paths["/sys/internal/specs/openapi"] == nil {
+		t.Fatalf("bad: %#v", paths)
+	}
+
+	resp = testHttpGet(t, "", addr+"/v1/sys/internal/specs/openapi")
+	testResponseStatus(t, resp, 400)
+}
@@ -1,9 +1,13 @@
 package http
 
 import (
+	"fmt"
 	"testing"
+	"time"
 
 	"github.com/hashicorp/vault/helper/jsonutil"
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
 	"github.com/hashicorp/vault/vault"
 )
 
@@ -52,3 +56,131 @@ func TestSysRevokePrefix(t *testing.T) {
 	resp := testHttpPut(t, token, addr+"/v1/sys/revoke-prefix/secret/foo/1234", nil)
 	testResponseStatus(t, resp, 204)
 }
+
+func TestSysRevokeForce(t *testing.T) {
+	core, _, token := vault.TestCoreUnsealed(t)
+	ln, addr := TestServer(t, core)
+	defer ln.Close()
+	TestServerAuth(t, addr, token)
+
+	resp := testHttpPut(t, token, addr+"/v1/sys/revoke-force/secret/foo/1234", nil)
+	testResponseStatus(t, resp, 204)
+}
+
+// testRevokeFailFactory returns a backend issuing leases whose revocation
+// always fails
+func testRevokeFailFactory(conf *logical.BackendConfig) (logical.Backend, error) {
+	var b *framework.Backend
+	b = &framework.Backend{
+		Paths: []*framework.Path{
+			&framework.Path{
+				Pattern: "creds",
+				Callbacks: map[logical.Operation]framework.OperationFunc{
+					logical.ReadOperation: func(req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+						return b.Secret("creds").Response(map[string]interface{}{"foo": "bar"}, nil), nil
+					},
+				},
+			},
+		},
+		Secrets: []*framework.Secret{
+			&framework.Secret{
+				Type:            "creds",
+				DefaultDuration: time.Hour,
+				Revoke: func(req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+					return nil, fmt.Errorf("revocation failed")
+				},
+			},
+		},
+	}
+	return b.Setup(conf)
+}
+
+func TestSysRevokeForce_backendError(t *testing.T) {
+	if err := vault.AddTestLogicalBackend("revoke-fail", testRevokeFailFactory); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	core, _, token := vault.TestCoreUnsealed(t)
+	ln, addr := TestServer(t, core)
+	defer ln.Close()
+	TestServerAuth(t, addr, token)
+
+	resp := testHttpPost(t, token, addr+"/v1/sys/mounts/fail", map[string]interface{}{
+		"type": "revoke-fail",
+	})
+	testResponseStatus(t, resp, 204)
+
+	resp = testHttpGet(t, token, addr+"/v1/fail/creds")
+	testResponseStatus(t, resp, 200)
+	var result struct {
+		LeaseId string `json:"lease_id"`
+	}
+	if err := jsonutil.DecodeJSONFromReader(resp.Body, &result); err != nil {
+		t.Fatalf("bad: %s", err)
+	}
+
+	lookup := func() int {
+		resp := testHttpPut(t, token, addr+"/v1/sys/leases/lookup", map[string]interface{}{
+			"lease_id": result.LeaseId,
+		})
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	// A normal revocation fails with the backend and keeps the lease
+	resp = testHttpPut(t, token, addr+"/v1/sys/revoke/"+result.LeaseId, nil)
+	if resp.StatusCode < 400 {
+		t.Fatalf("expected revocation to fail, got %d", resp.StatusCode)
+	}
+	if status := lookup(); status != 200 {
+		t.Fatalf("expected lease to be kept, got %d", status)
+	}
+
+	// A forced revocation ignores the error and removes the lease
+	resp = testHttpPut(t, token, addr+"/v1/sys/revoke-force/fail/creds", nil)
+	testResponseStatus(t, resp, 204)
+	if status := lookup(); status == 200 {
+		t.Fatalf("expected lease to be removed")
+	}
+}
+
+func TestSysLeasesLookup(t *testing.T) {
+	core, _, token := vault.TestCoreUnsealed(t)
+	ln, addr := TestServer(t, core)
+	defer ln.Close()
+	TestServerAuth(t, addr, token)
+
+	// write secret
+	resp := testHttpPut(t, token, addr+"/v1/secret/foo", map[string]interface{}{
+		"data":  "bar",
+		"lease": "1h",
+	})
+	testResponseStatus(t, resp, 204)
+
+	// read secret
+	resp = testHttpGet(t, token, addr+"/v1/secret/foo")
+	var result struct {
+		LeaseId string `json:"lease_id"`
+	}
+	if err := jsonutil.DecodeJSONFromReader(resp.Body, &result); err != nil {
+		t.Fatalf("bad: %s", err)
+	}
+
+	resp = testHttpPut(t, token, addr+"/v1/sys/leases/lookup", map[string]interface{}{
+		"lease_id": result.LeaseId,
+	})
+	testResponseStatus(t, resp, 200)
+	var lookup map[string]interface{}
+	testResponseBody(t, resp, &lookup)
+	if data := lookup["data"].(map[string]interface{}); data["id"] != result.LeaseId {
+		t.Fatalf("bad: %#v", lookup)
+	}
+
+	resp = testHttpGet(t, token, addr+"/v1/sys/leases/lookup/secret/?list=true")
+	testResponseStatus(t, resp, 200)
+	var list map[string]interface{}
+	testResponseBody(t, resp, &list)
+	keys := list["data"].(map[string]interface{})["keys"].([]interface{})
+	if len(keys) != 1 || keys[0] != "foo/" {
+		t.Fatalf("bad: %#v", list)
+	}
+}
@@ -0,0 +1,209 @@
+package http
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/armon/go-metrics"
+	"github.com/hashicorp/vault/helper/strutil"
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/vault"
+)
+
+// MetricsPath is the path the telemetry metrics are served at by the
+// listeners with the metrics purpose
+const MetricsPath = "/v1/sys/metrics"
+
+// prometheusContentType is the content type of the Prometheus text format
+const prometheusContentType = "text/plain; version=0.0.4"
+
+// MetricsResponse is the summary of the metrics aggregated during an
+// interval
+type MetricsResponse struct {
+	Timestamp time.Time      `json:"timestamp"`
+	Gauges    []GaugeValue   `json:"gauges"`
+	Counters  []SampledValue `json:"counters"`
+	Samples   []SampledValue `json:"samples"`
+}
+
+// GaugeValue is the last value of a gauge
+type GaugeValue struct {
+	Name  string  `json:"name"`
+	Value float32 `json:"value"`
+}
+
+// SampledValue is the aggregation of the values of a counter or a sample
+type SampledValue struct {
+	Name   string  `json:"name"`
+	Count  int     `json:"count"`
+	Sum    float64 `json:"sum"`
+	Min    float64 `json:"min"`
+	Max    float64 `json:"max"`
+	Mean   float64 `json:"mean"`
+	Stddev float64 `json:"stddev"`
+}
+
+// MetricsHandler returns an http.Handler serving the metrics of the last
+// complete interval of the in-memory sink, as JSON or in the Prometheus text
+// format with format=prometheus. It doesn't require any token, so it is
+// meant to be served on a listener dedicated to metrics, or on a listener
+// allowing unauthenticated access to the metrics.
+func MetricsHandler(sink *metrics.InmemSink) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "GET" {
+			respondError(w, http.StatusMethodNotAllowed, nil)
+			return
+		}
+
+		summary := metricsSummary(sink)
+		switch format := r.URL.Query().Get("format"); format {
+		case "":
+			respondOk(w, summary)
+		case "prometheus":
+			w.Header().Set("Content-Type", prometheusContentType)
+			w.WriteHeader(http.StatusOK)
+			w.Write(summary.prometheus())
+		default:
+			respondError(w, http.StatusBadRequest, fmt.Errorf("unsupported metrics format %q", format))
+		}
+	})
+}
+
+// AuthenticatedMetricsHandler returns an http.Handler serving the metrics
+// like MetricsHandler, to the clients whose token can read sys/metrics. As
+// the token can only be checked by the active node, standbys redirect the
+// clients to it.
+func AuthenticatedMetricsHandler(core *vault.Core, sink *metrics.InmemSink) http.Handler {
+	handler := MetricsHandler(sink)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if sealed, err := core.Sealed(); err != nil {
+			respondError(w, http.StatusInternalServerError, err)
+			return
+		} else if sealed {
+			respondError(w, http.StatusServiceUnavailable, vault.ErrSealed)
+			return
+		}
+		if standby, _ := core.Standby(); standby {
+			respondStandby(core, w, r.URL)
+			return
+		}
+
+		// A missing or invalid token has no capabilities
+		capabilities, err := core.Capabilities(r.Header.Get(AuthHeaderName), "sys/metrics")
+		if err != nil {
+			if _, ok := err.(*vault.StatusBadRequest); !ok {
+				respondError(w, http.StatusInternalServerError, err)
+				return
+			}
+		}
+		if !strutil.StrListContains(capabilities, vault.RootCapability) &&
+			!strutil.StrListContains(capabilities, vault.ReadCapability) {
+			respondError(w, http.StatusForbidden, logical.ErrPermissionDenied)
+			return
+		}
+
+		handler.ServeHTTP(w, r)
+	})
+}
+
+// metricsSummary returns the summary of the last complete interval of the
+// sink, or of the current one if none is complete yet
+func metricsSummary(sink *metrics.InmemSink) *MetricsResponse {
+	data := sink.Data()
+	interval := data[len(data)-1]
+	if len(data) > 1 {
+		interval = data[len(data)-2]
+	}
+
+	interval.RLock()
+	defer interval.RUnlock()
+
+	summary := &MetricsResponse{
+		Timestamp: interval.Interval.UTC(),
+		Gauges:    make([]GaugeValue, 0, len(interval.Gauges)),
+		Counters:  make([]SampledValue, 0, len(interval.Counters)),
+		Samples:   make([]SampledValue, 0, len(interval.Samples)),
+	}
+	for name, value := range interval.Gauges {
+		summary.Gauges = append(summary.Gauges, GaugeValue{Name: name, Value: value})
+	}
+	for name, agg := range interval.Counters {
+		summary.Counters = append(summary.Counters, newSampledValue(name, agg))
+	}
+	for name, agg := range interval.Samples {
+		summary.Samples = append(summary.Samples, newSampledValue(name, agg))
+	}
+
+	sort.Sort(gaugesByName(summary.Gauges))
+	sort.Sort(sampledByName(summary.Counters))
+	sort.Sort(sampledByName(summary.Samples))
+	return summary
+}
+
+func newSampledValue(name string, agg *metrics.AggregateSample) SampledValue {
+	return SampledValue{
+		Name:   name,
+		Count:  agg.Count,
+		Sum:    agg.Sum,
+		Min:    agg.Min,
+		Max:    agg.Max,
+		Mean:   agg.Mean(),
+		Stddev: agg.Stddev(),
+	}
+}
+
+type gaugesByName []GaugeValue
+
+func (g gaugesByName) Len() int           { return len(g) }
+func (g gaugesByName) Less(i, j int) bool { return g[i].Name < g[j].Name }
+func (g gaugesByName) Swap(i, j int)      { g[i], g[j] = g[j], g[i] }
+
+type sampledByName []SampledValue
+
+func (s sampledByName) Len() int           { return len(s) }
+func (s sampledByName) Less(i, j int) bool { return s[i].Name < s[j].Name }
+func (s sampledByName) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+
+// prometheus returns the metrics in the Prometheus text format. The values
+// are those aggregated during the interval rather than running totals, so
+// every metric is exposed as a gauge, and counters and samples are exposed
+// as their count, sum, min, max and mean.
+func (m *MetricsResponse) prometheus() []byte {
+	var buf bytes.Buffer
+	for _, gauge := range m.Gauges {
+		writePrometheusGauge(&buf, prometheusName(gauge.Name), float64(gauge.Value))
+	}
+	for _, values := range [][]SampledValue{m.Counters, m.Samples} {
+		for _, value := range values {
+			name := prometheusName(value.Name)
+			writePrometheusGauge(&buf, name+"_count", float64(value.Count))
+			writePrometheusGauge(&buf, name+"_sum", value.Sum)
+			writePrometheusGauge(&buf, name+"_min", value.Min)
+			writePrometheusGauge(&buf, name+"_max", value.Max)
+			writePrometheusGauge(&buf, name+"_mean", value.Mean)
+		}
+	}
+	return buf.Bytes()
+}
+
+func writePrometheusGauge(buf *bytes.Buffer, name string, value float64) {
+	fmt.Fprintf(buf, "# TYPE %s gauge\n%s %s\n", name, name, strconv.FormatFloat(value, 'g', -1, 64))
+}
+
+// prometheusName turns a metric name into a valid Prometheus one, replacing
+// the dots and any other invalid character with underscores
+func prometheusName(name string) string {
+	b := []byte(name)
+	for i, c := range b {
+		valid := c == '_' || c == ':' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') ||
+			(i > 0 && c >= '0' && c <= '9')
+		if !valid {
+			b[i] = '_'
+		}
+	}
+	return string(b)
+}
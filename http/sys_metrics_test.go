@@ -0,0 +1,106 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/armon/go-metrics"
+	"github.com/hashicorp/vault/helper/jsonutil"
+	"github.com/hashicorp/vault/vault"
+)
+
+func TestMetricsHandler(t *testing.T) {
+	sink := metrics.NewInmemSink(10*time.Second, time.Minute)
+	sink.SetGauge([]string{"vault", "runtime", "num_goroutines"}, 42)
+	sink.IncrCounter([]string{"vault", "cache", "hit"}, 1)
+	sink.IncrCounter([]string{"vault", "cache", "hit"}, 2)
+	sink.AddSample([]string{"vault", "core", "handle_request"}, 5)
+
+	handler := MetricsHandler(sink)
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", MetricsPath, nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("bad: %d", w.Code)
+	}
+
+	var actual MetricsResponse
+	if err := jsonutil.DecodeJSONFromReader(w.Body, &actual); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if len(actual.Gauges) != 1 || actual.Gauges[0].Name != "vault.runtime.num_goroutines" || actual.Gauges[0].Value != 42 {
+		t.Fatalf("bad: %#v", actual.Gauges)
+	}
+	if len(actual.Counters) != 1 || actual.Counters[0].Count != 2 || actual.Counters[0].Sum != 3 {
+		t.Fatalf("bad: %#v", actual.Counters)
+	}
+	if len(actual.Samples) != 1 || actual.Samples[0].Name != "vault.core.handle_request" {
+		t.Fatalf("bad: %#v", actual.Samples)
+	}
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("PUT", MetricsPath, nil))
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("bad: %d", w.Code)
+	}
+}
+
+func TestMetricsHandler_prometheus(t *testing.T) {
+	sink := metrics.NewInmemSink(10*time.Second, time.Minute)
+	sink.SetGauge([]string{"vault", "expire", "num_leases"}, 3)
+	sink.AddSample([]string{"vault", "core", "handle-request"}, 5)
+
+	w := httptest.NewRecorder()
+	MetricsHandler(sink).ServeHTTP(w, httptest.NewRequest("GET", MetricsPath+"?format=prometheus", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("bad: %d", w.Code)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != prometheusContentType {
+		t.Fatalf("bad: %s", ct)
+	}
+
+	body := w.Body.String()
+	for _, expected := range []string{
+		"# TYPE vault_expire_num_leases gauge\nvault_expire_num_leases 3\n",
+		"vault_core_handle_request_count 1\n",
+		"vault_core_handle_request_sum 5\n",
+	} {
+		if !strings.Contains(body, expected) {
+			t.Fatalf("missing %q in:\n%s", expected, body)
+		}
+	}
+
+	w = httptest.NewRecorder()
+	MetricsHandler(sink).ServeHTTP(w, httptest.NewRequest("GET", MetricsPath+"?format=xml", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("bad: %d", w.Code)
+	}
+}
+
+func TestAuthenticatedMetricsHandler(t *testing.T) {
+	core, _, token := vault.TestCoreUnsealed(t)
+	sink := metrics.NewInmemSink(10*time.Second, time.Minute)
+	handler := AuthenticatedMetricsHandler(core, sink)
+
+	for _, tc := range []struct {
+		token string
+		code  int
+	}{
+		{"", http.StatusForbidden},
+		{"invalid", http.StatusForbidden},
+		{token, http.StatusOK},
+	} {
+		req := httptest.NewRequest("GET", MetricsPath, nil)
+		if tc.token != "" {
+			req.Header.Set(AuthHeaderName, tc.token)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		if w.Code != tc.code {
+			t.Fatalf("token %q: bad: %d", tc.token, w.Code)
+		}
+	}
+}
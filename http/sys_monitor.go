@@ -0,0 +1,98 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/hashicorp/vault/helper/logformat"
+	"github.com/hashicorp/vault/helper/strutil"
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/vault"
+)
+
+// MonitorPath is the path the log lines of the server are streamed at
+const MonitorPath = "/v1/sys/monitor"
+
+// monitorBufferSize is the number of log lines buffered for a slow client
+// before they are dropped
+const monitorBufferSize = 512
+
+// MonitorHandler returns an http.Handler streaming the log lines of the
+// server at the log_level of the request, info by default, to the clients
+// whose token has the sudo capability on sys/monitor. The stream lasts until
+// the client disconnects. As the token can only be checked by the active
+// node, standbys redirect the clients to it.
+func MonitorHandler(core *vault.Core, logger *logformat.MonitorLogger) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "GET" {
+			respondError(w, http.StatusMethodNotAllowed, nil)
+			return
+		}
+
+		levelName := r.URL.Query().Get("log_level")
+		if levelName == "" {
+			levelName = "info"
+		}
+		level, err := logformat.ParseLevel(levelName)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		if sealed, err := core.Sealed(); err != nil {
+			respondError(w, http.StatusInternalServerError, err)
+			return
+		} else if sealed {
+			respondError(w, http.StatusServiceUnavailable, vault.ErrSealed)
+			return
+		}
+		if standby, _ := core.Standby(); standby {
+			respondStandby(core, w, r.URL)
+			return
+		}
+
+		// A missing or invalid token has no capabilities
+		capabilities, err := core.Capabilities(r.Header.Get(AuthHeaderName), "sys/monitor")
+		if err != nil {
+			if _, ok := err.(*vault.StatusBadRequest); !ok {
+				respondError(w, http.StatusInternalServerError, err)
+				return
+			}
+		}
+		if !strutil.StrListContains(capabilities, vault.RootCapability) &&
+			!strutil.StrListContains(capabilities, vault.SudoCapability) {
+			respondError(w, http.StatusForbidden, logical.ErrPermissionDenied)
+			return
+		}
+
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			respondError(w, http.StatusInternalServerError, fmt.Errorf("streaming isn't supported"))
+			return
+		}
+
+		mon := logger.Monitor(level, monitorBufferSize)
+		defer logger.StopMonitor(mon)
+
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		flusher.Flush()
+
+		dropped := 0
+		for {
+			select {
+			case line := <-mon.LogCh:
+				if d := mon.Dropped(); d > dropped {
+					fmt.Fprintf(w, "[%d log lines were dropped as the client is too slow]\n", d-dropped)
+					dropped = d
+				}
+				if _, err := fmt.Fprintln(w, line); err != nil {
+					return
+				}
+				flusher.Flush()
+			case <-r.Context().Done():
+				return
+			}
+		}
+	})
+}
@@ -0,0 +1,87 @@
+package http
+
+import (
+	"bufio"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/vault/helper/logformat"
+	"github.com/hashicorp/vault/vault"
+	log "github.com/mgutz/logxi/v1"
+)
+
+func TestMonitorHandler(t *testing.T) {
+	core, _, token := vault.TestCoreUnsealed(t)
+	logger := logformat.NewMonitorLogger(logformat.NewVaultLoggerWithWriter(ioutil.Discard, log.LevelInfo))
+	handler := MonitorHandler(core, logger)
+
+	for _, tc := range []struct {
+		token string
+		query string
+		code  int
+	}{
+		{"", "", http.StatusForbidden},
+		{"invalid", "", http.StatusForbidden},
+		{token, "?log_level=verbose", http.StatusBadRequest},
+	} {
+		req := httptest.NewRequest("GET", MonitorPath+tc.query, nil)
+		if tc.token != "" {
+			req.Header.Set(AuthHeaderName, tc.token)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		if w.Code != tc.code {
+			t.Fatalf("token %q, query %q: bad: %d", tc.token, tc.query, w.Code)
+		}
+	}
+
+	srv := httptest.NewServer(handler)
+	defer srv.Close()
+
+	req, err := http.NewRequest("GET", srv.URL+MonitorPath+"?log_level=debug", nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	req.Header.Set(AuthHeaderName, token)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("bad: %d", resp.StatusCode)
+	}
+
+	// The monitor is registered once the headers are sent, and sees the
+	// debug lines the logger itself doesn't write
+	if logger.IsTrace() || !logger.IsDebug() {
+		t.Fatalf("bad: the monitor should enable the debug level only")
+	}
+	logger.Trace("core: not monitored")
+	logger.Debug("core: monitored", "key", "value")
+
+	scanner := bufio.NewScanner(resp.Body)
+	if !scanner.Scan() {
+		t.Fatalf("err: %v", scanner.Err())
+	}
+	if line := scanner.Text(); !strings.Contains(line, "[DEBUG] core: monitored: key=value") {
+		t.Fatalf("bad: %s", line)
+	}
+}
+
+func TestWrapRequestLimits_monitor(t *testing.T) {
+	handler := WrapRequestLimits(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := w.(http.Flusher); !ok {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}), 0, DefaultMaxRequestDuration)
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", MonitorPath, nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("bad: %d", w.Code)
+	}
+}
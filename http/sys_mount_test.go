@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/fatih/structs"
 	"github.com/hashicorp/vault/vault"
@@ -50,6 +51,14 @@ func TestSysMounts(t *testing.T) {
 					"max_lease_ttl":     json.Number("0"),
 				},
 			},
+			"identity/": map[string]interface{}{
+				"description": "identity store",
+				"type":        "identity",
+				"config": map[string]interface{}{
+					"default_lease_ttl": json.Number("0"),
+					"max_lease_ttl":     json.Number("0"),
+				},
+			},
 		},
 		"secret/": map[string]interface{}{
 			"description": "generic secret storage",
@@ -75,6 +84,14 @@ func TestSysMounts(t *testing.T) {
 				"max_lease_ttl":     json.Number("0"),
 			},
 		},
+		"identity/": map[string]interface{}{
+			"description": "identity store",
+			"type":        "identity",
+			"config": map[string]interface{}{
+				"default_lease_ttl": json.Number("0"),
+				"max_lease_ttl":     json.Number("0"),
+			},
+		},
 	}
 	testResponseStatus(t, resp, 200)
 	testResponseBody(t, resp, &actual)
@@ -139,6 +156,14 @@ func TestSysMount(t *testing.T) {
 					"max_lease_ttl":     json.Number("0"),
 				},
 			},
+			"identity/": map[string]interface{}{
+				"description": "identity store",
+				"type":        "identity",
+				"config": map[string]interface{}{
+					"default_lease_ttl": json.Number("0"),
+					"max_lease_ttl":     json.Number("0"),
+				},
+			},
 		},
 		"foo/": map[string]interface{}{
 			"description": "foo",
@@ -172,6 +197,14 @@ func TestSysMount(t *testing.T) {
 				"max_lease_ttl":     json.Number("0"),
 			},
 		},
+		"identity/": map[string]interface{}{
+			"description": "identity store",
+			"type":        "identity",
+			"config": map[string]interface{}{
+				"default_lease_ttl": json.Number("0"),
+				"max_lease_ttl":     json.Number("0"),
+			},
+		},
 	}
 	testResponseStatus(t, resp, 200)
 	testResponseBody(t, resp, &actual)
@@ -213,7 +246,32 @@ func TestSysRemount(t *testing.T) {
 		"from": "foo",
 		"to":   "bar",
 	})
-	testResponseStatus(t, resp, 204)
+	testResponseStatus(t, resp, 200)
+	var remount map[string]interface{}
+	testResponseBody(t, resp, &remount)
+	migrationID, ok := remount["migration_id"].(string)
+	if !ok || migrationID == "" {
+		t.Fatalf("missing migration ID: %#v", remount)
+	}
+
+	var migrationInfo map[string]interface{}
+	for i := 0; i < 50; i++ {
+		resp = testHttpGet(t, token, addr+"/v1/sys/remount/status/"+migrationID)
+		testResponseStatus(t, resp, 200)
+		var status map[string]interface{}
+		testResponseBody(t, resp, &status)
+		migrationInfo = status["migration_info"].(map[string]interface{})
+		if migrationInfo["status"] != "in-progress" {
+			break
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	if migrationInfo["status"] != "success" || migrationInfo["source_mount"] != "foo/" || migrationInfo["target_mount"] != "bar/" {
+		t.Fatalf("bad: %#v", migrationInfo)
+	}
+
+	resp = testHttpGet(t, token, addr+"/v1/sys/remount/status/unknown")
+	testResponseStatus(t, resp, 404)
 
 	resp = testHttpGet(t, token, addr+"/v1/sys/mounts")
 
@@ -258,6 +316,14 @@ func TestSysRemount(t *testing.T) {
 					"max_lease_ttl":     json.Number("0"),
 				},
 			},
+			"identity/": map[string]interface{}{
+				"description": "identity store",
+				"type":        "identity",
+				"config": map[string]interface{}{
+					"default_lease_ttl": json.Number("0"),
+					"max_lease_ttl":     json.Number("0"),
+				},
+			},
 		},
 		"bar/": map[string]interface{}{
 			"description": "foo",
@@ -291,6 +357,14 @@ func TestSysRemount(t *testing.T) {
 				"max_lease_ttl":     json.Number("0"),
 			},
 		},
+		"identity/": map[string]interface{}{
+			"description": "identity store",
+			"type":        "identity",
+			"config": map[string]interface{}{
+				"default_lease_ttl": json.Number("0"),
+				"max_lease_ttl":     json.Number("0"),
+			},
+		},
 	}
 	testResponseStatus(t, resp, 200)
 	testResponseBody(t, resp, &actual)
@@ -350,6 +424,14 @@ func TestSysUnmount(t *testing.T) {
 					"max_lease_ttl":     json.Number("0"),
 				},
 			},
+			"identity/": map[string]interface{}{
+				"description": "identity store",
+				"type":        "identity",
+				"config": map[string]interface{}{
+					"default_lease_ttl": json.Number("0"),
+					"max_lease_ttl":     json.Number("0"),
+				},
+			},
 		},
 		"secret/": map[string]interface{}{
 			"description": "generic secret storage",
@@ -375,6 +457,14 @@ func TestSysUnmount(t *testing.T) {
 				"max_lease_ttl":     json.Number("0"),
 			},
 		},
+		"identity/": map[string]interface{}{
+			"description": "identity store",
+			"type":        "identity",
+			"config": map[string]interface{}{
+				"default_lease_ttl": json.Number("0"),
+				"max_lease_ttl":     json.Number("0"),
+			},
+		},
 	}
 	testResponseStatus(t, resp, 200)
 	testResponseBody(t, resp, &actual)
@@ -439,6 +529,14 @@ func TestSysTuneMount(t *testing.T) {
 					"max_lease_ttl":     json.Number("0"),
 				},
 			},
+			"identity/": map[string]interface{}{
+				"description": "identity store",
+				"type":        "identity",
+				"config": map[string]interface{}{
+					"default_lease_ttl": json.Number("0"),
+					"max_lease_ttl":     json.Number("0"),
+				},
+			},
 		},
 		"foo/": map[string]interface{}{
 			"description": "foo",
@@ -472,6 +570,14 @@ func TestSysTuneMount(t *testing.T) {
 				"max_lease_ttl":     json.Number("0"),
 			},
 		},
+		"identity/": map[string]interface{}{
+			"description": "identity store",
+			"type":        "identity",
+			"config": map[string]interface{}{
+				"default_lease_ttl": json.Number("0"),
+				"max_lease_ttl":     json.Number("0"),
+			},
+		},
 	}
 	testResponseStatus(t, resp, 200)
 	testResponseBody(t, resp, &actual)
@@ -557,6 +663,14 @@ func TestSysTuneMount(t *testing.T) {
 					"max_lease_ttl":     json.Number("0"),
 				},
 			},
+			"identity/": map[string]interface{}{
+				"description": "identity store",
+				"type":        "identity",
+				"config": map[string]interface{}{
+					"default_lease_ttl": json.Number("0"),
+					"max_lease_ttl":     json.Number("0"),
+				},
+			},
 		},
 		"foo/": map[string]interface{}{
 			"description": "foo",
@@ -590,6 +704,14 @@ func TestSysTuneMount(t *testing.T) {
 				"max_lease_ttl":     json.Number("0"),
 			},
 		},
+		"identity/": map[string]interface{}{
+			"description": "identity store",
+			"type":        "identity",
+			"config": map[string]interface{}{
+				"default_lease_ttl": json.Number("0"),
+				"max_lease_ttl":     json.Number("0"),
+			},
+		},
 	}
 
 	testResponseStatus(t, resp, 200)
@@ -610,9 +732,11 @@ func TestSysTuneMount(t *testing.T) {
 		"warnings":       nil,
 		"auth":           nil,
 		"data": map[string]interface{}{
+			"description":       "foo",
 			"default_lease_ttl": json.Number("259196400"),
 			"max_lease_ttl":     json.Number("259200000"),
 		},
+		"description":       "foo",
 		"default_lease_ttl": json.Number("259196400"),
 		"max_lease_ttl":     json.Number("259200000"),
 	}
@@ -641,9 +765,11 @@ func TestSysTuneMount(t *testing.T) {
 		"warnings":       nil,
 		"auth":           nil,
 		"data": map[string]interface{}{
+			"description":       "generic secret storage",
 			"default_lease_ttl": json.Number("40"),
 			"max_lease_ttl":     json.Number("80"),
 		},
+		"description":       "generic secret storage",
 		"default_lease_ttl": json.Number("40"),
 		"max_lease_ttl":     json.Number("80"),
 	}
@@ -700,3 +826,45 @@ func TestSysTuneMount(t *testing.T) {
 		t.Fatalf("bad:\nExpected: %#v\nActual:%#v", expected, structs.Map(result))
 	}
 }
+
+func TestSysTuneMount_description(t *testing.T) {
+	core, _, token := vault.TestCoreUnsealed(t)
+	ln, addr := TestServer(t, core)
+	defer ln.Close()
+	TestServerAuth(t, addr, token)
+
+	resp := testHttpPost(t, token, addr+"/v1/sys/mounts/secret/tune", map[string]interface{}{
+		"description": "tuned description",
+	})
+	testResponseStatus(t, resp, 204)
+
+	resp = testHttpGet(t, token, addr+"/v1/sys/mounts/secret/tune")
+	testResponseStatus(t, resp, 200)
+	var actual map[string]interface{}
+	testResponseBody(t, resp, &actual)
+	if actual["description"] != "tuned description" {
+		t.Fatalf("bad: %#v", actual)
+	}
+
+	// The mount table is updated as well
+	resp = testHttpGet(t, token, addr+"/v1/sys/mounts")
+	actual = map[string]interface{}{}
+	testResponseBody(t, resp, &actual)
+	secret := actual["secret/"].(map[string]interface{})
+	if secret["description"] != "tuned description" {
+		t.Fatalf("bad: %#v", secret)
+	}
+
+	// The description of auth mounts can be tuned too
+	resp = testHttpPost(t, token, addr+"/v1/sys/auth/token/tune", map[string]interface{}{
+		"description": "tuned token description",
+	})
+	testResponseStatus(t, resp, 204)
+
+	resp = testHttpGet(t, token, addr+"/v1/sys/auth/token/tune")
+	actual = map[string]interface{}{}
+	testResponseBody(t, resp, &actual)
+	if actual["description"] != "tuned token description" {
+		t.Fatalf("bad: %#v", actual)
+	}
+}
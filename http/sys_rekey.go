@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"strings"
 
 	"github.com/hashicorp/vault/helper/pgpkeys"
 	"github.com/hashicorp/vault/vault"
@@ -108,11 +109,20 @@ func handleSysRekeyInitPut(core *vault.Core, recovery bool, w http.ResponseWrite
 	// Right now we don't support this, but the rest of the code is ready for
 	// when we do, hence the check below for this to be false if
 	// StoredShares is greater than zero
-	if core.SealAccess().StoredKeysSupported() {
+	if !recovery && core.SealAccess().StoredKeysSupported() {
 		respondError(w, http.StatusBadRequest, fmt.Errorf("rekeying of barrier not supported when stored key support is available"))
 		return
 	}
 
+	// Keybase handles are resolved by the client, the server doesn't fetch
+	// anything on behalf of this unauthenticated endpoint
+	for _, key := range req.PGPKeys {
+		if strings.HasPrefix(key, "keybase:") {
+			respondError(w, http.StatusBadRequest, fmt.Errorf("keybase handles must be resolved to public keys by the client"))
+			return
+		}
+	}
+
 	// Initialize the rekey
 	err := core.RekeyInit(&vault.SealConfig{
 		SecretShares:    req.SecretShares,
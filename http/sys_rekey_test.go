@@ -7,6 +7,7 @@ import (
 	"reflect"
 	"testing"
 
+	"github.com/hashicorp/vault/helper/pgpkeys"
 	"github.com/hashicorp/vault/vault"
 )
 
@@ -135,6 +136,21 @@ func TestSysRekeyInit_Cancel(t *testing.T) {
 	}
 }
 
+func TestSysRekeyInit_keybase(t *testing.T) {
+	core, _, token := vault.TestCoreUnsealed(t)
+	ln, addr := TestServer(t, core)
+	defer ln.Close()
+	TestServerAuth(t, addr, token)
+
+	// Keybase handles are resolved by the client, not the server
+	resp := testHttpPut(t, token, addr+"/v1/sys/rekey/init", map[string]interface{}{
+		"secret_shares":    2,
+		"secret_threshold": 2,
+		"pgp_keys":         []string{pgpkeys.TestPubKey1, "keybase:jefferai"},
+	})
+	testResponseStatus(t, resp, 400)
+}
+
 func TestSysRekey_badKey(t *testing.T) {
 	core, _, token := vault.TestCoreUnsealed(t)
 	ln, addr := TestServer(t, core)
@@ -219,3 +235,57 @@ func TestSysRekey_ReInitUpdate(t *testing.T) {
 
 	testResponseStatus(t, resp, 400)
 }
+
+// plainKeyWrapper stores keys as-is, for testing auto seals
+type plainKeyWrapper struct{}
+
+func (w *plainKeyWrapper) Type() string                      { return "plain" }
+func (w *plainKeyWrapper) Init() error                       { return nil }
+func (w *plainKeyWrapper) Finalize() error                   { return nil }
+func (w *plainKeyWrapper) Encrypt(in []byte) ([]byte, error) { return in, nil }
+func (w *plainKeyWrapper) Decrypt(in []byte) ([]byte, error) { return in, nil }
+
+func TestSysRekeyInit_RecoveryKeyStoredKeys(t *testing.T) {
+	core := vault.TestCoreWithSeal(t, vault.NewAutoSeal(&plainKeyWrapper{}))
+	result, err := core.Initialize(&vault.InitParams{
+		BarrierConfig: &vault.SealConfig{
+			SecretShares:    1,
+			SecretThreshold: 1,
+			StoredShares:    1,
+		},
+		RecoveryConfig: &vault.SealConfig{
+			SecretShares:    1,
+			SecretThreshold: 1,
+		},
+	})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if err := core.UnsealWithStoredKeys(); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	ln, addr := TestServer(t, core)
+	defer ln.Close()
+	token := result.RootToken
+	TestServerAuth(t, addr, token)
+
+	// The barrier key is stored, so it can't be rekeyed
+	resp := testHttpPut(t, token, addr+"/v1/sys/rekey/init", map[string]interface{}{
+		"secret_shares":    5,
+		"secret_threshold": 3,
+	})
+	testResponseStatus(t, resp, 400)
+
+	// But the recovery key can
+	resp = testHttpPut(t, token, addr+"/v1/sys/rekey-recovery-key/init", map[string]interface{}{
+		"secret_shares":    5,
+		"secret_threshold": 3,
+	})
+	testResponseStatus(t, resp, 200)
+
+	var actual map[string]interface{}
+	testResponseBody(t, resp, &actual)
+	if actual["started"] != true || actual["n"] != json.Number("5") || actual["t"] != json.Number("3") {
+		t.Fatalf("bad: %#v", actual)
+	}
+}
@@ -53,8 +53,12 @@ func handleSysStepDown(core *vault.Core) http.Handler {
 			return
 		}
 
-		// Seal with the token above
+		// Step down with the token above
 		if err := core.StepDown(req); err != nil {
+			if errwrap.Contains(err, logical.ErrPermissionDenied.Error()) {
+				respondError(w, http.StatusForbidden, err)
+				return
+			}
 			respondError(w, http.StatusInternalServerError, err)
 			return
 		}
@@ -115,13 +119,19 @@ func handleSysUnseal(core *vault.Core) http.Handler {
 			}
 
 			// Attempt the unseal
-			if _, err := core.Unseal(key); err != nil {
+			unseal := core.Unseal
+			if req.Migrate {
+				unseal = core.UnsealMigrate
+			}
+			if _, err := unseal(key); err != nil {
 				switch {
 				case errwrap.ContainsType(err, new(vault.ErrInvalidKey)):
 				case errwrap.Contains(err, vault.ErrBarrierInvalidKey.Error()):
 				case errwrap.Contains(err, vault.ErrBarrierNotInit.Error()):
 				case errwrap.Contains(err, vault.ErrBarrierSealed.Error()):
 				case errwrap.Contains(err, vault.ErrStandby.Error()):
+				case err == vault.ErrSealMigrationPending:
+				case err == vault.ErrNoSealMigration:
 				default:
 					respondError(w, http.StatusInternalServerError, err)
 					return
@@ -165,6 +175,17 @@ func handleSysSealStatusRaw(core *vault.Core, w http.ResponseWriter, r *http.Req
 		return
 	}
 
+	// While a seal migration is pending, the keys needed to unseal are the
+	// ones of the migration
+	migration := core.SealMigrationPending()
+	if migration && sealed {
+		sealConfig, err = core.SealMigrationConfig()
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, err)
+			return
+		}
+	}
+
 	// Fetch the local cluster name and identifier
 	var clusterName, clusterID string
 	if !sealed {
@@ -182,7 +203,9 @@ func handleSysSealStatusRaw(core *vault.Core, w http.ResponseWriter, r *http.Req
 	}
 
 	respondOk(w, &SealStatusResponse{
+		Type:        sealConfig.Type,
 		Sealed:      sealed,
+		Migration:   migration,
 		T:           sealConfig.SecretThreshold,
 		N:           sealConfig.SecretShares,
 		Progress:    core.SecretProgress(),
@@ -193,7 +216,9 @@ func handleSysSealStatusRaw(core *vault.Core, w http.ResponseWriter, r *http.Req
 }
 
 type SealStatusResponse struct {
+	Type        string `json:"type"`
 	Sealed      bool   `json:"sealed"`
+	Migration   bool   `json:"migration,omitempty"`
 	T           int    `json:"t"`
 	N           int    `json:"n"`
 	Progress    int    `json:"progress"`
@@ -203,6 +228,7 @@ type SealStatusResponse struct {
 }
 
 type UnsealRequest struct {
-	Key   string
-	Reset bool
+	Key     string
+	Reset   bool
+	Migrate bool
 }
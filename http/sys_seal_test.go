@@ -25,6 +25,7 @@ func TestSysSealStatus(t *testing.T) {
 
 	var actual map[string]interface{}
 	expected := map[string]interface{}{
+		"type":     "shamir",
 		"sealed":   true,
 		"t":        json.Number("1"),
 		"n":        json.Number("1"),
@@ -111,6 +112,7 @@ func TestSysUnseal(t *testing.T) {
 
 	var actual map[string]interface{}
 	expected := map[string]interface{}{
+		"type":     "shamir",
 		"sealed":   false,
 		"t":        json.Number("1"),
 		"n":        json.Number("1"),
@@ -178,6 +180,7 @@ func TestSysUnseal_Reset(t *testing.T) {
 
 		var actual map[string]interface{}
 		expected := map[string]interface{}{
+			"type":     "shamir",
 			"sealed":   true,
 			"t":        json.Number("3"),
 			"n":        json.Number("5"),
@@ -210,6 +213,7 @@ func TestSysUnseal_Reset(t *testing.T) {
 
 	actual = map[string]interface{}{}
 	expected := map[string]interface{}{
+		"type":     "shamir",
 		"sealed":   true,
 		"t":        json.Number("3"),
 		"n":        json.Number("5"),
@@ -360,3 +364,38 @@ func TestSysStepDown(t *testing.T) {
 	resp := testHttpPut(t, token, addr+"/v1/sys/step-down", nil)
 	testResponseStatus(t, resp, 204)
 }
+
+func TestSysStepDown_Permissions(t *testing.T) {
+	core, _, root := vault.TestCoreUnsealed(t)
+	ln, addr := TestServer(t, core)
+	defer ln.Close()
+	TestServerAuth(t, addr, root)
+
+	req := &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "sys/policy/test",
+		Data: map[string]interface{}{
+			"rules": `path "sys/step-down" { capabilities = ["update"] }`,
+		},
+		ClientToken: root,
+	}
+	if _, err := core.HandleRequest(req); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	req.Path = "auth/token/create"
+	req.Data = map[string]interface{}{
+		"id":       "child",
+		"policies": []string{"test"},
+	}
+	if _, err := core.HandleRequest(req); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Stepping down requires sudo, even without HA
+	resp := testHttpPut(t, "child", addr+"/v1/sys/step-down", nil)
+	testResponseStatus(t, resp, 403)
+
+	resp = testHttpPut(t, root, addr+"/v1/sys/step-down", nil)
+	testResponseStatus(t, resp, 204)
+}
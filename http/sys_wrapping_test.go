@@ -0,0 +1,145 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/go-cleanhttp"
+	"github.com/hashicorp/vault/vault"
+)
+
+// testHttpWrap sends a PUT request whose response is wrapped for a minute,
+// and returns the wrapping token
+func testHttpWrap(t *testing.T, token string, addr string, body interface{}) string {
+	buf, err := json.Marshal(body)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	req, err := http.NewRequest("PUT", addr, bytes.NewReader(buf))
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	req.Header.Set(AuthHeaderName, token)
+	req.Header.Set(WrapTTLHeaderName, "60s")
+
+	resp, err := cleanhttp.DefaultClient().Do(req)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	testResponseStatus(t, resp, 200)
+
+	var actual map[string]interface{}
+	testResponseBody(t, resp, &actual)
+	wrapInfo, ok := actual["wrap_info"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("missing wrap info: %#v", actual)
+	}
+	wrapToken, ok := wrapInfo["token"].(string)
+	if !ok || wrapToken == "" {
+		t.Fatalf("missing wrapping token: %#v", wrapInfo)
+	}
+	return wrapToken
+}
+
+func TestSysWrapping(t *testing.T) {
+	core, _, token := vault.TestCoreUnsealed(t)
+	ln, addr := TestServer(t, core)
+	defer ln.Close()
+	TestServerAuth(t, addr, token)
+
+	// Wrapping requires a wrap TTL
+	resp := testHttpPut(t, token, addr+"/v1/sys/wrapping/wrap", map[string]interface{}{
+		"foo": "bar",
+	})
+	testResponseStatus(t, resp, 400)
+
+	wrapToken := testHttpWrap(t, token, addr+"/v1/sys/wrapping/wrap", map[string]interface{}{
+		"foo": "bar",
+	})
+
+	// Look up the token without using it
+	resp = testHttpPut(t, token, addr+"/v1/sys/wrapping/lookup", map[string]interface{}{
+		"token": wrapToken,
+	})
+	testResponseStatus(t, resp, 200)
+	var actual map[string]interface{}
+	testResponseBody(t, resp, &actual)
+	data := actual["data"].(map[string]interface{})
+	if data["creation_ttl"] != json.Number("60") || data["creation_time"] == "" {
+		t.Fatalf("bad: %#v", data)
+	}
+
+	// Rewrapping revokes the original token
+	resp = testHttpPut(t, token, addr+"/v1/sys/wrapping/rewrap", map[string]interface{}{
+		"token": wrapToken,
+	})
+	testResponseStatus(t, resp, 200)
+	actual = nil
+	testResponseBody(t, resp, &actual)
+	wrapInfo := actual["wrap_info"].(map[string]interface{})
+	if wrapInfo["ttl"] != json.Number("60") {
+		t.Fatalf("bad: %#v", wrapInfo)
+	}
+	rewrapToken := wrapInfo["token"].(string)
+	if rewrapToken == "" || rewrapToken == wrapToken {
+		t.Fatalf("bad: %#v", wrapInfo)
+	}
+
+	resp = testHttpPut(t, token, addr+"/v1/sys/wrapping/lookup", map[string]interface{}{
+		"token": wrapToken,
+	})
+	testResponseStatus(t, resp, 400)
+
+	// The wrapping token unwraps itself and is then used up
+	resp = testHttpPut(t, rewrapToken, addr+"/v1/sys/wrapping/unwrap", nil)
+	testResponseStatus(t, resp, 200)
+	actual = nil
+	testResponseBody(t, resp, &actual)
+	expected := map[string]interface{}{
+		"foo": "bar",
+	}
+	if !reflect.DeepEqual(actual["data"], expected) {
+		t.Fatalf("bad: %#v", actual)
+	}
+
+	resp = testHttpPut(t, rewrapToken, addr+"/v1/sys/wrapping/unwrap", nil)
+	testResponseStatus(t, resp, 403)
+}
+
+func TestSysWrapping_thirdPartyUnwrap(t *testing.T) {
+	core, _, token := vault.TestCoreUnsealed(t)
+	ln, addr := TestServer(t, core)
+	defer ln.Close()
+	TestServerAuth(t, addr, token)
+
+	wrapToken := testHttpWrap(t, token, addr+"/v1/sys/wrapping/wrap", map[string]interface{}{
+		"foo": "bar",
+	})
+
+	// Only response-wrapping tokens can be unwrapped
+	resp := testHttpPut(t, token, addr+"/v1/sys/wrapping/unwrap", map[string]interface{}{
+		"token": token,
+	})
+	testResponseStatus(t, resp, 400)
+
+	resp = testHttpPut(t, token, addr+"/v1/sys/wrapping/unwrap", map[string]interface{}{
+		"token": wrapToken,
+	})
+	testResponseStatus(t, resp, 200)
+	var actual map[string]interface{}
+	testResponseBody(t, resp, &actual)
+	expected := map[string]interface{}{
+		"foo": "bar",
+	}
+	if !reflect.DeepEqual(actual["data"], expected) {
+		t.Fatalf("bad: %#v", actual)
+	}
+
+	resp = testHttpPut(t, token, addr+"/v1/sys/wrapping/unwrap", map[string]interface{}{
+		"token": wrapToken,
+	})
+	testResponseStatus(t, resp, 400)
+}
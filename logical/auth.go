@@ -5,6 +5,19 @@ import (
 	"time"
 )
 
+const (
+	// TokenTypeService is the default token type. Service tokens are
+	// persisted in the token store and tracked by the expiration manager,
+	// so they can be renewed, revoked and used to create child tokens.
+	TokenTypeService = "service"
+
+	// TokenTypeBatch is a lightweight token type that is never persisted.
+	// The token ID is an encrypted blob carrying the token's properties, so
+	// batch tokens cannot be renewed, revoked or used to create child tokens
+	// and simply expire at the end of their TTL.
+	TokenTypeBatch = "batch"
+)
+
 // Auth is the resulting authentication information that is part of
 // Response for credential backends.
 type Auth struct {
@@ -48,6 +61,36 @@ type Auth struct {
 	// should never expire. The token should be renewed within the duration
 	// specified by this period.
 	Period time.Duration `json:"period" mapstructure:"period" structs:"period"`
+
+	// ExplicitMaxTTL is the maximum lifetime of the token generated using
+	// this Auth object. If set, it takes the place of the mount/system max
+	// TTL and may exceed it, so backends should only set it when explicitly
+	// configured to, e.g. on a role.
+	ExplicitMaxTTL time.Duration `json:"explicit_max_ttl" mapstructure:"explicit_max_ttl" structs:"explicit_max_ttl"`
+
+	// BoundCIDRs is the list of CIDR blocks from which the token generated
+	// using this Auth object is allowed to be used. If empty, the token can
+	// be used from any network address.
+	BoundCIDRs []string `json:"bound_cidrs" mapstructure:"bound_cidrs" structs:"bound_cidrs"`
+
+	// TokenType is the type of token that should be generated using this
+	// Auth object. If empty, a service token is generated.
+	TokenType string `json:"token_type" mapstructure:"token_type" structs:"token_type"`
+
+	// Alias is the identity of the authenticated client within the
+	// credential backend. If set, core maps it to an identity entity.
+	Alias *Alias `json:"alias" mapstructure:"alias" structs:"alias"`
+
+	// GroupAliases are the groups the authenticated client is a member of
+	// within the credential backend, e.g. LDAP groups or GitHub teams.
+	// Core uses them to maintain the client's membership in external
+	// identity groups. They are ignored if Alias is not set.
+	GroupAliases []*Alias `json:"group_aliases" mapstructure:"group_aliases" structs:"group_aliases"`
+
+	// EntityID is the identifier of the identity entity the token is
+	// associated with. This is filled in by Vault core and setting it
+	// manually will have no effect.
+	EntityID string `json:"entity_id" mapstructure:"entity_id" structs:"entity_id"`
 }
 
 func (a *Auth) GoString() string {
@@ -53,15 +53,23 @@ type Backend struct {
 	// entries in backend's storage, while the backend is still being used.
 	// (Note the different of this action from what `Clean` does, which is
 	// invoked just before the backend is unmounted).
+	//
+	// The periodic_interval of the mount, set when tuning it, spaces out the
+	// invocations, such as to tidy or rotate credentials daily.
 	PeriodicFunc periodicFunc
 
 	// WALRollback is called when a WAL entry (see wal.go) has to be rolled
 	// back. It is called with the data from the entry.
 	//
+	// WALRollbackKinds registers the callbacks rolling back the WAL entries
+	// of each kind, which take precedence over WALRollback. The entries of
+	// a kind without a callback are kept and reported as errors.
+	//
 	// WALRollbackMinAge is the minimum age of a WAL entry before it is attempted
 	// to be rolled back. This should be longer than the maximum time it takes
-	// to successfully create a secret.
+	// to successfully create a secret. It is DefaultWALRollbackMinAge if zero.
 	WALRollback       WALRollbackFunc
+	WALRollbackKinds  map[string]WALRollbackFunc
 	WALRollbackMinAge time.Duration
 
 	// Clean is called on unload to clean up e.g any existing connections
@@ -156,7 +164,7 @@ func (b *Backend) HandleRequest(req *logical.Request) (*logical.Response, error)
 
 	// If the path is empty and it is a help operation, handle that.
 	if req.Path == "" && req.Operation == logical.HelpOperation {
-		return b.handleRootHelp()
+		return b.handleRootHelp(req)
 	}
 
 	// Find the matching route
@@ -183,11 +191,21 @@ func (b *Backend) HandleRequest(req *logical.Request) (*logical.Response, error)
 	}
 	if !ok {
 		if req.Operation == logical.HelpOperation {
-			callback = path.helpCallback
+			callback = path.helpCallback(b)
 			ok = true
 		}
 	}
 	if !ok {
+		// The paths with an existence check which only create, or only
+		// update, their resource reject the writes of the other kind
+		_, create := path.Callbacks[logical.CreateOperation]
+		_, update := path.Callbacks[logical.UpdateOperation]
+		switch {
+		case path.ExistenceCheck != nil && req.Operation == logical.UpdateOperation && create:
+			return logical.ErrorResponse(fmt.Sprintf("%s already exists", req.Path)), logical.ErrInvalidRequest
+		case path.ExistenceCheck != nil && req.Operation == logical.CreateOperation && update:
+			return logical.ErrorResponse(fmt.Sprintf("%s doesn't exist", req.Path)), logical.ErrInvalidRequest
+		}
 		return nil, logical.ErrUnsupportedOperation
 	}
 
@@ -200,10 +218,58 @@ func (b *Backend) HandleRequest(req *logical.Request) (*logical.Response, error)
 		if err != nil {
 			return nil, err
 		}
+
+		write := req.Operation == logical.CreateOperation || req.Operation == logical.UpdateOperation
+		if err := fd.checkFields(write); err != nil {
+			return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+		}
+	}
+
+	// The keys of the lists are paginated, unless the path handles the
+	// pagination itself
+	if req.Operation == logical.ListOperation && !path.paginatesList() {
+		after, limit, err := listPagination(raw)
+		if err != nil {
+			return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+		}
+
+		resp, err := callback(req, &fd)
+		if err != nil {
+			return resp, err
+		}
+		return paginateListResponse(resp, after, limit), nil
 	}
 
 	// Call the callback with the request and the data
-	return callback(req, &fd)
+	resp, err := callback(req, &fd)
+
+	// Warn about the deprecated fields of the request, even on success
+	// without a response
+	if deprecated := deprecatedFields(req.Data, path.Fields); len(deprecated) > 0 {
+		if resp == nil && err == nil {
+			resp = &logical.Response{}
+		}
+		if resp != nil {
+			for _, name := range deprecated {
+				resp.AddWarning(fmt.Sprintf("%q is deprecated", name))
+			}
+		}
+	}
+
+	return resp, err
+}
+
+// deprecatedFields returns the sorted names of the deprecated fields set in
+// the data of a request
+func deprecatedFields(data map[string]interface{}, schema map[string]*FieldSchema) []string {
+	var names []string
+	for name := range data {
+		if s, ok := schema[name]; ok && s.Deprecated {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
 }
 
 // logical.Backend impl.
@@ -343,7 +409,7 @@ func (b *Backend) route(path string) (*Path, map[string]string) {
 	return nil, nil
 }
 
-func (b *Backend) handleRootHelp() (*logical.Response, error) {
+func (b *Backend) handleRootHelp(req *logical.Request) (*logical.Response, error) {
 	// Build a mapping of the paths and get the paths alphabetized to
 	// make the output prettier.
 	pathsMap := make(map[string]*Path)
@@ -372,7 +438,13 @@ func (b *Backend) handleRootHelp() (*logical.Response, error) {
 		return nil, err
 	}
 
-	return logical.HelpResponse(help, nil), nil
+	// Document the paths for sys/internal/specs/openapi
+	doc := NewOASDocument()
+	documentPaths(b, req.MountPoint, doc)
+
+	resp := logical.HelpResponse(help, nil)
+	resp.Data["openapi"] = doc
+	return resp, nil
 }
 
 func (b *Backend) handleRevokeRenew(
@@ -411,11 +483,14 @@ func (b *Backend) handleRevokeRenew(
 	}
 }
 
-// handleRollback invokes the PeriodicFunc set on the backend. It also does a WAL rollback operation.
+// handleRollback invokes the PeriodicFunc set on the backend, unless the
+// periodic interval of the mount hasn't elapsed. It also does a WAL rollback
+// operation.
 func (b *Backend) handleRollback(
 	req *logical.Request) (*logical.Response, error) {
 	// Response is not expected from the periodic operation.
-	if b.PeriodicFunc != nil {
+	skip, _ := req.Data["skip_periodic"].(bool)
+	if b.PeriodicFunc != nil && !skip {
 		if err := b.PeriodicFunc(req); err != nil {
 			return nil, err
 		}
@@ -434,7 +509,7 @@ func (b *Backend) handleAuthRenew(req *logical.Request) (*logical.Response, erro
 
 func (b *Backend) handleWALRollback(
 	req *logical.Request) (*logical.Response, error) {
-	if b.WALRollback == nil {
+	if b.WALRollback == nil && len(b.WALRollbackKinds) == 0 {
 		return nil, logical.ErrUnsupportedOperation
 	}
 
@@ -451,7 +526,7 @@ func (b *Backend) handleWALRollback(
 	// created in order to be rolled back.
 	age := b.WALRollbackMinAge
 	if age == 0 {
-		age = 10 * time.Minute
+		age = DefaultWALRollbackMinAge
 	}
 	minAge := time.Now().Add(-1 * age)
 	if _, ok := req.Data["immediate"]; ok {
@@ -474,7 +549,15 @@ func (b *Backend) handleWALRollback(
 		}
 
 		// Attempt a WAL rollback
-		err = b.WALRollback(req, entry.Kind, entry.Data)
+		rollback, ok := b.WALRollbackKinds[entry.Kind]
+		if !ok {
+			rollback = b.WALRollback
+		}
+		if rollback == nil {
+			err = fmt.Errorf("no rollback callback")
+		} else {
+			err = rollback(req, entry.Kind, entry.Data)
+		}
 		if err != nil {
 			err = fmt.Errorf(
 				"Error rolling back '%s' entry: %s", entry.Kind, err)
@@ -499,6 +582,21 @@ type FieldSchema struct {
 	Type        FieldType
 	Default     interface{}
 	Description string
+
+	// Required makes the field required by the writes, which fail if it is
+	// missing, or empty for a string
+	Required bool
+
+	// AllowedValues, if set, are the values the field can take
+	AllowedValues []interface{}
+
+	// Validate, if set, validates the value of the field when it is set.
+	// The error it returns is returned to the client.
+	Validate func(value interface{}) error
+
+	// Deprecated fields can still be set, but the responses to the
+	// requests setting them carry a warning
+	Deprecated bool
 }
 
 // DefaultOrZero returns the default value if it is set, or otherwise
@@ -523,6 +621,12 @@ func (t FieldType) Zero() interface{} {
 		return map[string]interface{}{}
 	case TypeDurationSecond:
 		return 0
+	case TypeCommaStringSlice:
+		return []string{}
+	case TypeKVPairs:
+		return map[string]string{}
+	case TypeNameString:
+		return ""
 	default:
 		panic("unknown type: " + t.String())
 	}
@@ -1,6 +1,7 @@
 package framework
 
 import (
+	"fmt"
 	"reflect"
 	"sync/atomic"
 	"testing"
@@ -371,6 +372,91 @@ func TestBackendHandleRequest_rollbackMinAge(t *testing.T) {
 	}
 }
 
+func TestBackendHandleRequest_rollbackKinds(t *testing.T) {
+	var called uint32
+	callback := func(req *logical.Request, kind string, data interface{}) error {
+		if kind == "user" && data == "foo" {
+			atomic.AddUint32(&called, 1)
+		}
+
+		return nil
+	}
+
+	b := &Backend{
+		WALRollbackKinds: map[string]WALRollbackFunc{
+			"user": callback,
+		},
+		WALRollbackMinAge: 1 * time.Millisecond,
+	}
+
+	storage := new(logical.InmemStorage)
+	if _, err := PutWAL(storage, "user", "foo"); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	unknown, err := PutWAL(storage, "grant", "bar")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	time.Sleep(10 * time.Millisecond)
+
+	resp, err := b.HandleRequest(&logical.Request{
+		Operation: logical.RollbackOperation,
+		Path:      "",
+		Storage:   storage,
+	})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if resp == nil || !resp.IsError() {
+		t.Fatalf("bad: %#v", resp)
+	}
+	if v := atomic.LoadUint32(&called); v != 1 {
+		t.Fatalf("bad: %#v", v)
+	}
+
+	// The entry of the kind without a callback is kept
+	keys, err := ListWAL(storage)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if !reflect.DeepEqual(keys, []string{unknown}) {
+		t.Fatalf("bad: %#v", keys)
+	}
+}
+
+func TestBackendHandleRequest_rollbackSkipPeriodic(t *testing.T) {
+	var called uint32
+	b := &Backend{
+		PeriodicFunc: func(req *logical.Request) error {
+			atomic.AddUint32(&called, 1)
+			return nil
+		},
+		WALRollback: func(req *logical.Request, kind string, data interface{}) error {
+			return nil
+		},
+	}
+
+	storage := new(logical.InmemStorage)
+	for _, data := range []map[string]interface{}{
+		nil,
+		map[string]interface{}{"skip_periodic": true},
+	} {
+		_, err := b.HandleRequest(&logical.Request{
+			Operation: logical.RollbackOperation,
+			Path:      "",
+			Storage:   storage,
+			Data:      data,
+		})
+		if err != nil {
+			t.Fatalf("err: %s", err)
+		}
+	}
+	if v := atomic.LoadUint32(&called); v != 1 {
+		t.Fatalf("bad: %#v", v)
+	}
+}
+
 func TestBackendHandleRequest_unsupportedOperation(t *testing.T) {
 	callback := func(req *logical.Request, data *FieldData) (*logical.Response, error) {
 		return &logical.Response{
@@ -440,6 +526,232 @@ func TestBackendHandleRequest_urlPriority(t *testing.T) {
 	}
 }
 
+func TestBackendHandleRequest_existenceCheck(t *testing.T) {
+	callback := func(req *logical.Request, data *FieldData) (*logical.Response, error) {
+		entry := &logical.StorageEntry{Key: "role/" + data.Get("name").(string)}
+		return nil, req.Storage.Put(entry)
+	}
+
+	b := &Backend{
+		Paths: []*Path{
+			&Path{
+				Pattern: "roles/" + GenericNameRegex("name"),
+				Fields: map[string]*FieldSchema{
+					"name": &FieldSchema{Type: TypeString},
+				},
+				Callbacks: map[logical.Operation]OperationFunc{
+					logical.CreateOperation: callback,
+				},
+				ExistenceCheck: StorageExistenceCheck("role/", "name"),
+			},
+		},
+	}
+
+	// The operation is chosen with the existence check, as by the core
+	storage := new(logical.InmemStorage)
+	write := func() (*logical.Response, error) {
+		req := &logical.Request{
+			Operation: logical.UpdateOperation,
+			Path:      "roles/foo",
+			Storage:   storage,
+		}
+		checkFound, exists, err := b.HandleExistenceCheck(req)
+		if err != nil || !checkFound {
+			t.Fatalf("bad: %v, %v", checkFound, err)
+		}
+		if !exists {
+			req.Operation = logical.CreateOperation
+		}
+		return b.HandleRequest(req)
+	}
+
+	if _, err := write(); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	// The role isn't overwritten
+	resp, err := write()
+	if err != logical.ErrInvalidRequest || resp.Data["error"] != "roles/foo already exists" {
+		t.Fatalf("bad: %#v, %v", resp, err)
+	}
+}
+
+func TestBackendHandleRequest_fieldValidation(t *testing.T) {
+	callback := func(req *logical.Request, data *FieldData) (*logical.Response, error) {
+		return &logical.Response{
+			Data: map[string]interface{}{
+				"name": data.Get("name"),
+			},
+		}, nil
+	}
+
+	b := &Backend{
+		Paths: []*Path{
+			&Path{
+				Pattern: "foo",
+				Fields: map[string]*FieldSchema{
+					"name": &FieldSchema{
+						Type:     TypeString,
+						Required: true,
+					},
+					"mode": &FieldSchema{
+						Type:          TypeString,
+						AllowedValues: []interface{}{"fast", "slow"},
+					},
+					"count": &FieldSchema{
+						Type: TypeInt,
+						Validate: func(value interface{}) error {
+							if value.(int) > 10 {
+								return fmt.Errorf("must be at most 10")
+							}
+							return nil
+						},
+					},
+				},
+				Callbacks: map[logical.Operation]OperationFunc{
+					logical.ReadOperation:   callback,
+					logical.UpdateOperation: callback,
+				},
+			},
+		},
+	}
+
+	cases := []struct {
+		Operation logical.Operation
+		Data      map[string]interface{}
+		Error     string
+	}{
+		{logical.UpdateOperation, map[string]interface{}{"name": "bar", "mode": "fast", "count": 10}, ""},
+		{logical.UpdateOperation, map[string]interface{}{"mode": "fast"}, `"name" is required`},
+		{logical.UpdateOperation, map[string]interface{}{"name": ""}, `"name" is required`},
+		{logical.UpdateOperation, map[string]interface{}{"name": "bar", "mode": "medium"}, `"mode" must be one of [fast slow]`},
+		{logical.UpdateOperation, map[string]interface{}{"name": "bar", "count": "11"}, `invalid "count": must be at most 10`},
+		{logical.ReadOperation, nil, ""},
+	}
+	for _, tc := range cases {
+		resp, err := b.HandleRequest(&logical.Request{
+			Operation: tc.Operation,
+			Path:      "foo",
+			Data:      tc.Data,
+		})
+		if tc.Error == "" {
+			if err != nil || resp.IsError() {
+				t.Fatalf("%v: bad: %#v, %v", tc.Data, resp, err)
+			}
+			continue
+		}
+		if err != logical.ErrInvalidRequest || resp.Data["error"] != tc.Error {
+			t.Fatalf("%v: bad: %#v, %v", tc.Data, resp, err)
+		}
+	}
+}
+
+func TestBackendHandleRequest_deprecatedFields(t *testing.T) {
+	callback := func(req *logical.Request, data *FieldData) (*logical.Response, error) {
+		return nil, nil
+	}
+
+	b := &Backend{
+		Paths: []*Path{
+			&Path{
+				Pattern: "config",
+				Fields: map[string]*FieldSchema{
+					"url":   &FieldSchema{Type: TypeString},
+					"value": &FieldSchema{Type: TypeString, Deprecated: true},
+				},
+				Callbacks: map[logical.Operation]OperationFunc{
+					logical.UpdateOperation: callback,
+				},
+			},
+		},
+	}
+
+	resp, err := b.HandleRequest(&logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "config",
+		Data:      map[string]interface{}{"url": "foo"},
+	})
+	if err != nil || resp != nil {
+		t.Fatalf("bad: %#v %v", resp, err)
+	}
+
+	resp, err = b.HandleRequest(&logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "config",
+		Data:      map[string]interface{}{"value": "foo"},
+	})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if resp == nil || !reflect.DeepEqual(resp.Warnings, []string{`"value" is deprecated`}) {
+		t.Fatalf("bad: %#v", resp)
+	}
+}
+
+func TestBackendHandleRequest_listPagination(t *testing.T) {
+	callback := func(req *logical.Request, data *FieldData) (*logical.Response, error) {
+		return logical.ListResponse([]string{"foo", "bar", "baz/", "qux"}), nil
+	}
+
+	b := &Backend{
+		Paths: []*Path{
+			&Path{
+				Pattern: "keys/",
+				Callbacks: map[logical.Operation]OperationFunc{
+					logical.ListOperation: callback,
+				},
+			},
+		},
+	}
+
+	cases := []struct {
+		Data     map[string]interface{}
+		Expected interface{}
+	}{
+		{nil, []string{"foo", "bar", "baz/", "qux"}},
+		{map[string]interface{}{"limit": "2"}, []string{"bar", "baz/"}},
+		{map[string]interface{}{"after": "baz/"}, []string{"foo", "qux"}},
+		{map[string]interface{}{"after": "bb", "limit": 1}, []string{"foo"}},
+		{map[string]interface{}{"after": "qux"}, nil},
+	}
+	for _, tc := range cases {
+		resp, err := b.HandleRequest(&logical.Request{
+			Operation: logical.ListOperation,
+			Path:      "keys/",
+			Data:      tc.Data,
+		})
+		if err != nil {
+			t.Fatalf("%v: err: %s", tc.Data, err)
+		}
+		if !reflect.DeepEqual(resp.Data["keys"], tc.Expected) {
+			t.Fatalf("%v: bad: %#v", tc.Data, resp.Data["keys"])
+		}
+	}
+
+	resp, err := b.HandleRequest(&logical.Request{
+		Operation: logical.ListOperation,
+		Path:      "keys/",
+		Data:      map[string]interface{}{"limit": "-1"},
+	})
+	if err != logical.ErrInvalidRequest || !resp.IsError() {
+		t.Fatalf("bad: %#v, %v", resp, err)
+	}
+
+	// The paths with pagination fields paginate their lists themselves
+	b.Paths[0].Fields = ListFields
+	resp, err = b.HandleRequest(&logical.Request{
+		Operation: logical.ListOperation,
+		Path:      "keys/",
+		Data:      map[string]interface{}{"limit": "2"},
+	})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if len(resp.Data["keys"].([]string)) != 4 {
+		t.Fatalf("bad: %#v", resp)
+	}
+}
+
 func TestBackendRoute(t *testing.T) {
 	cases := map[string]struct {
 		Patterns []string
@@ -3,6 +3,10 @@ package framework
 import (
 	"encoding/json"
 	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
 
 	"github.com/hashicorp/vault/helper/duration"
 	"github.com/mitchellh/mapstructure"
@@ -30,7 +34,8 @@ func (d *FieldData) Validate() error {
 		}
 
 		switch schema.Type {
-		case TypeBool, TypeInt, TypeMap, TypeDurationSecond, TypeString:
+		case TypeBool, TypeInt, TypeMap, TypeDurationSecond, TypeString,
+			TypeCommaStringSlice, TypeKVPairs, TypeNameString:
 			_, _, err := d.getPrimitive(field, schema)
 			if err != nil {
 				return fmt.Errorf("Error converting input %v for field %s: %s", value, field, err)
@@ -44,6 +49,52 @@ func (d *FieldData) Validate() error {
 	return nil
 }
 
+// checkFields checks the fields against the Required, AllowedValues and
+// Validate of their schema, the required fields only being required by the
+// writes. It must be called after Validate.
+func (d *FieldData) checkFields(write bool) error {
+	names := make([]string, 0, len(d.Schema))
+	for name := range d.Schema {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		schema := d.Schema[name]
+		value, ok, err := d.GetOkErr(name)
+		if err != nil {
+			return err
+		}
+		if write && schema.Required && (!ok || value == nil || value == "") {
+			return fmt.Errorf("%q is required", name)
+		}
+		if !ok || value == nil {
+			continue
+		}
+
+		if len(schema.AllowedValues) > 0 {
+			allowed := false
+			for _, v := range schema.AllowedValues {
+				if reflect.DeepEqual(v, value) {
+					allowed = true
+					break
+				}
+			}
+			if !allowed {
+				return fmt.Errorf("%q must be one of %v", name, schema.AllowedValues)
+			}
+		}
+
+		if schema.Validate != nil {
+			if err := schema.Validate(value); err != nil {
+				return fmt.Errorf("invalid %q: %v", name, err)
+			}
+		}
+	}
+
+	return nil
+}
+
 // Get gets the value for the given field. If the key is an invalid field,
 // FieldData will panic. If you want a safer version of this method, use
 // GetOk. If the field k is not set, the default value (if set) will be
@@ -105,7 +156,8 @@ func (d *FieldData) GetOkErr(k string) (interface{}, bool, error) {
 	}
 
 	switch schema.Type {
-	case TypeBool, TypeInt, TypeMap, TypeDurationSecond, TypeString:
+	case TypeBool, TypeInt, TypeMap, TypeDurationSecond, TypeString,
+		TypeCommaStringSlice, TypeKVPairs, TypeNameString:
 		return d.getPrimitive(k, schema)
 	default:
 		return nil, false,
@@ -177,7 +229,65 @@ func (d *FieldData) getPrimitive(
 		}
 		return result, true, nil
 
+	case TypeCommaStringSlice:
+		var result []string
+		if inp, ok := raw.(string); ok {
+			result = strings.Split(inp, ",")
+		} else if err := mapstructure.WeakDecode(raw, &result); err != nil {
+			return nil, true, err
+		}
+		return trimStrings(result), true, nil
+
+	case TypeKVPairs:
+		result := make(map[string]string)
+		var pairs []string
+		switch inp := raw.(type) {
+		case string:
+			pairs = trimStrings(strings.Split(inp, ","))
+		case []string, []interface{}:
+			if err := mapstructure.WeakDecode(inp, &pairs); err != nil {
+				return nil, true, err
+			}
+		default:
+			if err := mapstructure.WeakDecode(raw, &result); err != nil {
+				return nil, true, err
+			}
+		}
+		for _, pair := range pairs {
+			kv := strings.SplitN(pair, "=", 2)
+			if len(kv) != 2 || strings.TrimSpace(kv[0]) == "" {
+				return nil, true, fmt.Errorf("invalid key=value pair %q", pair)
+			}
+			result[strings.TrimSpace(kv[0])] = strings.TrimSpace(kv[1])
+		}
+		return result, true, nil
+
+	case TypeNameString:
+		var result string
+		if err := mapstructure.WeakDecode(raw, &result); err != nil {
+			return nil, true, err
+		}
+		result = strings.ToLower(strings.TrimSpace(result))
+		if result != "" && !nameRe.MatchString(result) {
+			return nil, true, fmt.Errorf("invalid name %q", result)
+		}
+		return result, true, nil
+
 	default:
 		panic(fmt.Sprintf("Unknown type: %s", schema.Type))
 	}
 }
+
+// nameRe matches the values of the TypeNameString fields
+var nameRe = regexp.MustCompile(`^\w(([\w-.]+)?\w)?$`)
+
+// trimStrings returns the trimmed strings, without the empty ones
+func trimStrings(values []string) []string {
+	result := make([]string, 0, len(values))
+	for _, v := range values {
+		if v = strings.TrimSpace(v); v != "" {
+			result = append(result, v)
+		}
+	}
+	return result
+}
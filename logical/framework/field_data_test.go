@@ -146,6 +146,81 @@ func TestFieldDataGet(t *testing.T) {
 			"foo",
 			0,
 		},
+
+		"comma string slice type, string value": {
+			map[string]*FieldSchema{
+				"foo": &FieldSchema{Type: TypeCommaStringSlice},
+			},
+			map[string]interface{}{
+				"foo": "a, b,,c ",
+			},
+			"foo",
+			[]string{"a", "b", "c"},
+		},
+
+		"comma string slice type, list value": {
+			map[string]*FieldSchema{
+				"foo": &FieldSchema{Type: TypeCommaStringSlice},
+			},
+			map[string]interface{}{
+				"foo": []interface{}{"a", " b", 42},
+			},
+			"foo",
+			[]string{"a", "b", "42"},
+		},
+
+		"comma string slice type, unset value": {
+			map[string]*FieldSchema{
+				"foo": &FieldSchema{Type: TypeCommaStringSlice},
+			},
+			map[string]interface{}{},
+			"foo",
+			[]string{},
+		},
+
+		"kv pairs type, string value": {
+			map[string]*FieldSchema{
+				"foo": &FieldSchema{Type: TypeKVPairs},
+			},
+			map[string]interface{}{
+				"foo": "a=1, b = 2,c=x=y",
+			},
+			"foo",
+			map[string]string{"a": "1", "b": "2", "c": "x=y"},
+		},
+
+		"kv pairs type, list value": {
+			map[string]*FieldSchema{
+				"foo": &FieldSchema{Type: TypeKVPairs},
+			},
+			map[string]interface{}{
+				"foo": []interface{}{"a=1", "b=2"},
+			},
+			"foo",
+			map[string]string{"a": "1", "b": "2"},
+		},
+
+		"kv pairs type, map value": {
+			map[string]*FieldSchema{
+				"foo": &FieldSchema{Type: TypeKVPairs},
+			},
+			map[string]interface{}{
+				"foo": map[string]interface{}{"a": 1, "b": "2"},
+			},
+			"foo",
+			map[string]string{"a": "1", "b": "2"},
+		},
+
+		"name string type, string value": {
+			map[string]*FieldSchema{
+				"foo": &FieldSchema{Type: TypeNameString},
+			},
+			map[string]interface{}{
+				"foo": " My-Role.1 ",
+			},
+			"foo",
+			"my-role.1",
+		},
 	}
 
 	for name, tc := range cases {
@@ -162,3 +237,25 @@ func TestFieldDataGet(t *testing.T) {
 		}
 	}
 }
+
+func TestFieldDataGet_invalid(t *testing.T) {
+	cases := map[string]struct {
+		Type  FieldType
+		Value interface{}
+	}{
+		"kv pairs type, missing value":    {TypeKVPairs, "a=1,b"},
+		"kv pairs type, missing key":      {TypeKVPairs, []interface{}{"=1"}},
+		"name string type, invalid name":  {TypeNameString, "my role"},
+		"name string type, trailing dash": {TypeNameString, "role-"},
+	}
+
+	for name, tc := range cases {
+		data := &FieldData{
+			Raw:    map[string]interface{}{"foo": tc.Value},
+			Schema: map[string]*FieldSchema{"foo": &FieldSchema{Type: tc.Type}},
+		}
+		if err := data.Validate(); err == nil {
+			t.Fatalf("%s: expected error", name)
+		}
+	}
+}
@@ -13,6 +13,20 @@ const (
 	// TypeDurationSecond represent as seconds, this can be either an
 	// integer or go duration format string (e.g. 24h)
 	TypeDurationSecond
+
+	// TypeCommaStringSlice is a []string, which can be either a list or a
+	// comma-separated string (e.g. "a, b"). The values are trimmed and the
+	// empty ones dropped.
+	TypeCommaStringSlice
+
+	// TypeKVPairs is a map[string]string, which can be either a map, or a
+	// list or comma-separated string of key=value pairs (e.g. "a=1,b=2")
+	TypeKVPairs
+
+	// TypeNameString is a name, such as the name of a role, which is
+	// trimmed and lowercased, and must be made of letters, digits,
+	// underscores, and inner dashes and dots
+	TypeNameString
 )
 
 func (t FieldType) String() string {
@@ -27,6 +41,12 @@ func (t FieldType) String() string {
 		return "map"
 	case TypeDurationSecond:
 		return "duration (sec)"
+	case TypeCommaStringSlice:
+		return "comma-separated strings"
+	case TypeKVPairs:
+		return "key=value pairs"
+	case TypeNameString:
+		return "name"
 	default:
 		return "unknown type"
 	}
@@ -20,6 +20,18 @@ import (
 // systemView is the system view from the calling backend, used to determine
 // and/or correct default/max times.
 func LeaseExtend(backendIncrement, backendMax time.Duration, systemView logical.SystemView) OperationFunc {
+	return leaseExtend(backendIncrement, backendMax, false, systemView)
+}
+
+// LeaseExtendExplicitMax is like LeaseExtend, but explicitMax takes the
+// place of the mount/system max rather than only being able to restrict it.
+// This is used for leases with an explicit max TTL, which is allowed to
+// exceed the mount/system value.
+func LeaseExtendExplicitMax(backendIncrement, explicitMax time.Duration, systemView logical.SystemView) OperationFunc {
+	return leaseExtend(backendIncrement, explicitMax, true, systemView)
+}
+
+func leaseExtend(backendIncrement, backendMax time.Duration, explicitMax bool, systemView logical.SystemView) OperationFunc {
 	return func(req *logical.Request, data *FieldData) (*logical.Response, error) {
 		var leaseOpts *logical.LeaseOptions
 		switch {
@@ -35,7 +47,7 @@ func LeaseExtend(backendIncrement, backendMax time.Duration, systemView logical.
 		// something more restrictive (perhaps from a role configuration
 		// parameter)
 		max := systemView.MaxLeaseTTL()
-		if backendMax > 0 && backendMax < max {
+		if backendMax > 0 && (backendMax < max || explicitMax) {
 			max = backendMax
 		}
 
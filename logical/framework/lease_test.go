@@ -19,6 +19,7 @@ func TestLeaseExtend(t *testing.T) {
 	cases := map[string]struct {
 		BackendDefault time.Duration
 		BackendMax     time.Duration
+		ExplicitMax    bool
 		Increment      time.Duration
 		Result         time.Duration
 		Error          bool
@@ -83,6 +84,27 @@ func TestLeaseExtend(t *testing.T) {
 			Increment: 40 * time.Hour,
 			Result:    30 * time.Hour,
 		},
+
+		"explicit max exceeds the system view": {
+			BackendMax:  50 * time.Hour,
+			ExplicitMax: true,
+			Increment:   40 * time.Hour,
+			Result:      40 * time.Hour,
+		},
+
+		"request outside explicit max": {
+			BackendMax:  45 * time.Hour,
+			ExplicitMax: true,
+			Increment:   50 * time.Hour,
+			Result:      45 * time.Hour,
+		},
+
+		"explicit max within the system view": {
+			BackendMax:  4 * time.Hour,
+			ExplicitMax: true,
+			Increment:   5 * time.Hour,
+			Result:      4 * time.Hour,
+		},
 	}
 
 	for name, tc := range cases {
@@ -97,6 +119,9 @@ func TestLeaseExtend(t *testing.T) {
 		}
 
 		callback := LeaseExtend(tc.BackendDefault, tc.BackendMax, testSysView)
+		if tc.ExplicitMax {
+			callback = LeaseExtendExplicitMax(tc.BackendDefault, tc.BackendMax, testSysView)
+		}
 		resp, err := callback(req, nil)
 		if (err != nil) != tc.Error {
 			t.Fatalf("bad: %s\nerr: %s", name, err)
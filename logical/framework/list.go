@@ -0,0 +1,70 @@
+package framework
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/vault/logical"
+)
+
+// ListFields are the pagination parameters of the list operations, which
+// the framework applies to the keys of their response. A path with one of
+// them in its Fields paginates its lists itself, such as with
+// logical.ListPage, so that it doesn't have to load all its keys.
+var ListFields = map[string]*FieldSchema{
+	"after": &FieldSchema{
+		Type:        TypeString,
+		Description: "Only the keys after this key are listed.",
+	},
+
+	"limit": &FieldSchema{
+		Type:        TypeInt,
+		Description: "Maximum number of keys listed. All the keys are listed if it is zero.",
+	},
+}
+
+// paginatesList returns whether the path paginates its lists itself
+func (p *Path) paginatesList() bool {
+	for name := range ListFields {
+		if _, ok := p.Fields[name]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// listPagination returns the pagination parameters of a list operation
+func listPagination(raw map[string]interface{}) (string, int, error) {
+	fd := &FieldData{
+		Raw:    raw,
+		Schema: ListFields,
+	}
+	if err := fd.Validate(); err != nil {
+		return "", 0, err
+	}
+
+	limit := fd.Get("limit").(int)
+	if limit < 0 {
+		return "", 0, fmt.Errorf("limit can't be negative")
+	}
+	return fd.Get("after").(string), limit, nil
+}
+
+// paginateListResponse replaces the keys of a list response with the keys
+// after the key after, and at most limit of them if it is positive
+func paginateListResponse(resp *logical.Response, after string, limit int) *logical.Response {
+	if resp == nil || resp.Data == nil || (after == "" && limit == 0) {
+		return resp
+	}
+	keys, ok := resp.Data["keys"].([]string)
+	if !ok {
+		return resp
+	}
+
+	keys = logical.PaginateKeys(keys, after, limit)
+	if len(keys) == 0 {
+		delete(resp.Data, "keys")
+	} else {
+		resp.Data["keys"] = keys
+	}
+	return resp
+}
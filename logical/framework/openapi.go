@@ -0,0 +1,377 @@
+package framework
+
+import (
+	"regexp"
+	"regexp/syntax"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/version"
+)
+
+// OASVersion is the version of the OpenAPI specification of the documents
+const OASVersion = "3.0.2"
+
+// maxExpandedPaths bounds the number of paths a pattern is expanded to, the
+// patterns expanding to more paths aren't documented
+const maxExpandedPaths = 64
+
+// pathParamRe matches the parameters of the expanded paths
+var pathParamRe = regexp.MustCompile(`\{(\w+)\}`)
+
+// OASDocument is an OpenAPI document describing the paths of backends
+type OASDocument struct {
+	Version string                  `json:"openapi"`
+	Info    OASInfo                 `json:"info"`
+	Paths   map[string]*OASPathItem `json:"paths"`
+}
+
+type OASInfo struct {
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Version     string `json:"version"`
+}
+
+// OASPathItem describes the operations of a path. The paths requiring a
+// root or sudo token, and the paths which don't require a token, are
+// flagged with the x-vault-sudo and x-vault-unauthenticated extensions.
+type OASPathItem struct {
+	Description     string         `json:"description,omitempty"`
+	Parameters      []OASParameter `json:"parameters,omitempty"`
+	Sudo            bool           `json:"x-vault-sudo,omitempty"`
+	Unauthenticated bool           `json:"x-vault-unauthenticated,omitempty"`
+
+	Get    *OASOperation `json:"get,omitempty"`
+	Post   *OASOperation `json:"post,omitempty"`
+	Patch  *OASOperation `json:"patch,omitempty"`
+	Delete *OASOperation `json:"delete,omitempty"`
+}
+
+type OASOperation struct {
+	Summary     string                  `json:"summary,omitempty"`
+	Description string                  `json:"description,omitempty"`
+	Tags        []string                `json:"tags,omitempty"`
+	Parameters  []OASParameter          `json:"parameters,omitempty"`
+	RequestBody *OASRequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*OASResponse `json:"responses"`
+}
+
+type OASParameter struct {
+	Name        string     `json:"name"`
+	Description string     `json:"description,omitempty"`
+	In          string     `json:"in"`
+	Schema      *OASSchema `json:"schema,omitempty"`
+	Required    bool       `json:"required,omitempty"`
+}
+
+type OASRequestBody struct {
+	Content map[string]*OASMediaType `json:"content"`
+}
+
+type OASMediaType struct {
+	Schema *OASSchema `json:"schema"`
+}
+
+type OASSchema struct {
+	Type        string                `json:"type"`
+	Format      string                `json:"format,omitempty"`
+	Description string                `json:"description,omitempty"`
+	Properties  map[string]*OASSchema `json:"properties,omitempty"`
+	Items       *OASSchema            `json:"items,omitempty"`
+	Required    []string              `json:"required,omitempty"`
+	Default     interface{}           `json:"default,omitempty"`
+	Enum        []interface{}         `json:"enum,omitempty"`
+	Deprecated  bool                  `json:"deprecated,omitempty"`
+}
+
+type OASResponse struct {
+	Description string `json:"description"`
+}
+
+// NewOASDocument returns an OpenAPI document without paths
+func NewOASDocument() *OASDocument {
+	return &OASDocument{
+		Version: OASVersion,
+		Info: OASInfo{
+			Title:       "HashiCorp Vault API",
+			Description: "HTTP API that gives you full access to Vault. All API routes are prefixed with `/v1/`.",
+			Version:     version.GetVersion().Version,
+		},
+		Paths: make(map[string]*OASPathItem),
+	}
+}
+
+// documentPaths adds the paths of the backend mounted at the mount to the
+// document. The patterns which can't be expressed as OpenAPI paths, and the
+// paths without operations, aren't documented.
+func documentPaths(b *Backend, mount string, doc *OASDocument) {
+	b.once.Do(b.init)
+
+	for _, p := range b.Paths {
+		documentPath(b, p, mount, doc)
+	}
+}
+
+func documentPath(b *Backend, p *Path, mount string, doc *OASDocument) {
+	for _, path := range expandPattern(p.Pattern) {
+		// The first path matching a request handles it
+		docPath := "/" + mount + path
+		if _, ok := doc.Paths[docPath]; ok {
+			continue
+		}
+
+		item := &OASPathItem{
+			Description: strings.TrimSpace(p.HelpSynopsis),
+		}
+		if b.PathsSpecial != nil {
+			item.Sudo = matchesSpecialPath(b.PathsSpecial.Root, path)
+			item.Unauthenticated = matchesSpecialPath(b.PathsSpecial.Unauthenticated, path)
+		}
+
+		// The named captures are the parameters of the path, and the
+		// other fields are read from the body of the writes
+		params := make(map[string]bool)
+		for _, match := range pathParamRe.FindAllStringSubmatch(path, -1) {
+			name := match[1]
+			params[name] = true
+
+			param := OASParameter{
+				Name:     name,
+				In:       "path",
+				Required: true,
+				Schema:   &OASSchema{Type: "string"},
+			}
+			if schema, ok := p.Fields[name]; ok {
+				param.Description = strings.TrimSpace(schema.Description)
+				param.Schema = convertFieldSchema(schema)
+				param.Schema.Description = ""
+			}
+			item.Parameters = append(item.Parameters, param)
+		}
+
+		_, read := p.Callbacks[logical.ReadOperation]
+		_, list := p.Callbacks[logical.ListOperation]
+		_, update := p.Callbacks[logical.UpdateOperation]
+		_, create := p.Callbacks[logical.CreateOperation]
+		_, del := p.Callbacks[logical.DeleteOperation]
+		_, patch := p.Callbacks[logical.PatchOperation]
+
+		if read || list {
+			item.Get = newOASOperation(p, "200", "OK")
+			if list {
+				item.Get.Parameters = append(item.Get.Parameters, OASParameter{
+					Name:        "list",
+					Description: "Return a list if `true`",
+					In:          "query",
+					Required:    !read,
+					Schema:      &OASSchema{Type: "string"},
+				})
+				for _, name := range []string{"after", "limit"} {
+					schema := ListFields[name]
+					item.Get.Parameters = append(item.Get.Parameters, OASParameter{
+						Name:        name,
+						Description: schema.Description,
+						In:          "query",
+						Schema:      &OASSchema{Type: convertFieldSchema(schema).Type},
+					})
+				}
+			}
+		}
+
+		properties := make(map[string]*OASSchema)
+		var required []string
+		for name, schema := range p.Fields {
+			if !params[name] {
+				properties[name] = convertFieldSchema(schema)
+				if schema.Required {
+					required = append(required, name)
+				}
+			}
+		}
+		sort.Strings(required)
+
+		if update || create {
+			item.Post = newOASOperation(p, "200", "OK")
+			if len(properties) > 0 {
+				item.Post.RequestBody = newOASRequestBody("application/json", &OASSchema{
+					Type:       "object",
+					Properties: properties,
+					Required:   required,
+				})
+			}
+		}
+
+		// The fields of the patches are optional
+		if patch {
+			item.Patch = newOASOperation(p, "200", "OK")
+			if len(properties) > 0 {
+				item.Patch.RequestBody = newOASRequestBody("application/merge-patch+json", &OASSchema{
+					Type:       "object",
+					Properties: properties,
+				})
+			}
+		}
+
+		if del {
+			item.Delete = newOASOperation(p, "204", "empty body")
+		}
+
+		if item.Get == nil && item.Post == nil && item.Patch == nil && item.Delete == nil {
+			continue
+		}
+		doc.Paths[docPath] = item
+	}
+}
+
+func newOASOperation(p *Path, status, description string) *OASOperation {
+	return &OASOperation{
+		Summary:     strings.TrimSpace(p.HelpSynopsis),
+		Description: strings.TrimSpace(p.HelpDescription),
+		Responses: map[string]*OASResponse{
+			status: &OASResponse{Description: description},
+		},
+	}
+}
+
+func newOASRequestBody(contentType string, schema *OASSchema) *OASRequestBody {
+	return &OASRequestBody{
+		Content: map[string]*OASMediaType{
+			contentType: &OASMediaType{Schema: schema},
+		},
+	}
+}
+
+// convertFieldSchema returns the OpenAPI schema of a field
+func convertFieldSchema(schema *FieldSchema) *OASSchema {
+	s := &OASSchema{
+		Description: strings.TrimSpace(schema.Description),
+		Default:     schema.Default,
+		Enum:        schema.AllowedValues,
+		Deprecated:  schema.Deprecated,
+	}
+	switch schema.Type {
+	case TypeInt:
+		s.Type = "integer"
+	case TypeBool:
+		s.Type = "boolean"
+	case TypeMap, TypeKVPairs:
+		s.Type = "object"
+	case TypeCommaStringSlice:
+		// The lists can also be given as comma-separated strings
+		s.Type = "array"
+		s.Items = &OASSchema{Type: "string"}
+	case TypeDurationSecond:
+		// Durations can also be given as strings such as "24h"
+		s.Type = "integer"
+		s.Format = "seconds"
+	default:
+		s.Type = "string"
+	}
+	return s
+}
+
+// matchesSpecialPath returns whether the path is matched by one of the
+// special paths, which are prefixes when they end with a *
+func matchesSpecialPath(specialPaths []string, path string) bool {
+	for _, specialPath := range specialPaths {
+		if strings.HasSuffix(specialPath, "*") {
+			if strings.HasPrefix(path, strings.TrimSuffix(specialPath, "*")) {
+				return true
+			}
+		} else if path == specialPath {
+			return true
+		}
+	}
+	return false
+}
+
+// expandPattern returns the sorted paths matched by the pattern of a path,
+// with its named captures as {name} parameters, or nil if the pattern can't
+// be expressed as OpenAPI paths. A wildcard which isn't captured, such as
+// the ".*" of the generic backend, is the {path} parameter.
+func expandPattern(pattern string) []string {
+	re, err := syntax.Parse(pattern, syntax.Perl)
+	if err != nil {
+		return nil
+	}
+	paths, ok := expandRegexp(re)
+	if !ok {
+		return nil
+	}
+
+	seen := make(map[string]bool, len(paths))
+	result := make([]string, 0, len(paths))
+	for _, path := range paths {
+		if !seen[path] {
+			seen[path] = true
+			result = append(result, path)
+		}
+	}
+	sort.Strings(result)
+	return result
+}
+
+func expandRegexp(re *syntax.Regexp) ([]string, bool) {
+	switch re.Op {
+	case syntax.OpEmptyMatch, syntax.OpBeginLine, syntax.OpEndLine,
+		syntax.OpBeginText, syntax.OpEndText:
+		return []string{""}, true
+
+	case syntax.OpLiteral:
+		return []string{string(re.Rune)}, true
+
+	case syntax.OpCapture:
+		if re.Name != "" {
+			return []string{"{" + re.Name + "}"}, true
+		}
+		return expandRegexp(re.Sub[0])
+
+	case syntax.OpQuest:
+		paths, ok := expandRegexp(re.Sub[0])
+		return append([]string{""}, paths...), ok
+
+	case syntax.OpStar, syntax.OpPlus:
+		switch re.Sub[0].Op {
+		case syntax.OpAnyChar, syntax.OpAnyCharNotNL:
+			return []string{"{path}"}, true
+		}
+		return nil, false
+
+	case syntax.OpConcat:
+		paths := []string{""}
+		for _, sub := range re.Sub {
+			subPaths, ok := expandRegexp(sub)
+			if !ok {
+				return nil, false
+			}
+			next := make([]string, 0, len(paths)*len(subPaths))
+			for _, path := range paths {
+				for _, subPath := range subPaths {
+					next = append(next, path+subPath)
+				}
+			}
+			if len(next) > maxExpandedPaths {
+				return nil, false
+			}
+			paths = next
+		}
+		return paths, true
+
+	case syntax.OpAlternate:
+		var paths []string
+		for _, sub := range re.Sub {
+			subPaths, ok := expandRegexp(sub)
+			if !ok {
+				return nil, false
+			}
+			paths = append(paths, subPaths...)
+		}
+		if len(paths) > maxExpandedPaths {
+			return nil, false
+		}
+		return paths, true
+	}
+
+	return nil, false
+}
@@ -0,0 +1,242 @@
+package framework
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/vault/logical"
+)
+
+func TestExpandPattern(t *testing.T) {
+	cases := []struct {
+		pattern string
+		paths   []string
+	}{
+		{"^config/lease$", []string{"config/lease"}},
+		{"roles/?$", []string{"roles", "roles/"}},
+		{"roles/" + GenericNameRegex("name"), []string{"roles/{name}"}},
+		{"renew" + OptionalParamRegex("url_lease_id"), []string{"renew", "renew/{url_lease_id}"}},
+		{`ca(/pem)?`, []string{"ca", "ca/pem"}},
+		{"generate-root(/attempt)?$", []string{"generate-root", "generate-root/attempt"}},
+		{"auth/(?P<path>.+?)/tune$", []string{"auth/{path}/tune"}},
+		{"quotas/(?P<type>rate-limit|lease-count)/?$", []string{"quotas/{type}", "quotas/{type}/"}},
+		{"lookup|lookup-self", []string{"lookup", "lookup-self"}},
+		{".*", []string{"{path}"}},
+		{"foo/[a-z]+", nil},
+		{"(", nil},
+	}
+
+	for _, tc := range cases {
+		paths := expandPattern(tc.pattern)
+		if !reflect.DeepEqual(paths, tc.paths) {
+			t.Fatalf("bad: %s\n\nexpected: %#v\nactual: %#v", tc.pattern, tc.paths, paths)
+		}
+	}
+}
+
+func TestDocumentPaths(t *testing.T) {
+	callback := func(*logical.Request, *FieldData) (*logical.Response, error) {
+		return nil, nil
+	}
+	b := &Backend{
+		PathsSpecial: &logical.Paths{
+			Root:            []string{"roles/*"},
+			Unauthenticated: []string{"login"},
+		},
+		Paths: []*Path{
+			&Path{
+				Pattern: "roles/?$",
+				Callbacks: map[logical.Operation]OperationFunc{
+					logical.ListOperation: callback,
+				},
+				HelpSynopsis: "List the roles.",
+			},
+			&Path{
+				Pattern: "roles/" + GenericNameRegex("name"),
+				Fields: map[string]*FieldSchema{
+					"name": &FieldSchema{
+						Type:        TypeString,
+						Description: "Name of the role.",
+					},
+					"ttl": &FieldSchema{
+						Type:    TypeDurationSecond,
+						Default: 3600,
+					},
+					"enabled": &FieldSchema{
+						Type:     TypeBool,
+						Required: true,
+					},
+					"type": &FieldSchema{
+						Type:          TypeString,
+						AllowedValues: []interface{}{"foo", "bar"},
+					},
+				},
+				Callbacks: map[logical.Operation]OperationFunc{
+					logical.ReadOperation:   callback,
+					logical.UpdateOperation: callback,
+					logical.DeleteOperation: callback,
+				},
+				HelpSynopsis:    "Manage a role.",
+				HelpDescription: "Roles are great.",
+			},
+			&Path{
+				Pattern: "login",
+				Callbacks: map[logical.Operation]OperationFunc{
+					logical.UpdateOperation: callback,
+				},
+			},
+			&Path{
+				Pattern:      "help-only",
+				HelpSynopsis: "Not documented without operations.",
+			},
+		},
+	}
+
+	doc := NewOASDocument()
+	documentPaths(b, "", doc)
+
+	if doc.Version != OASVersion || doc.Info.Version == "" {
+		t.Fatalf("bad: %#v", doc)
+	}
+	if len(doc.Paths) != 4 {
+		t.Fatalf("bad: %#v", doc.Paths)
+	}
+
+	list := doc.Paths["/roles/"]
+	if list == nil || list.Get == nil || list.Post != nil || !list.Sudo {
+		t.Fatalf("bad: %#v", list)
+	}
+	expectedList := []OASParameter{
+		{
+			Name:        "list",
+			Description: "Return a list if `true`",
+			In:          "query",
+			Required:    true,
+			Schema:      &OASSchema{Type: "string"},
+		},
+		{
+			Name:        "after",
+			Description: "Only the keys after this key are listed.",
+			In:          "query",
+			Schema:      &OASSchema{Type: "string"},
+		},
+		{
+			Name:        "limit",
+			Description: "Maximum number of keys listed. All the keys are listed if it is zero.",
+			In:          "query",
+			Schema:      &OASSchema{Type: "integer"},
+		},
+	}
+	if !reflect.DeepEqual(list.Get.Parameters, expectedList) {
+		t.Fatalf("bad: %#v", list.Get.Parameters)
+	}
+
+	role := doc.Paths["/roles/{name}"]
+	if role == nil || role.Get == nil || role.Post == nil || role.Delete == nil {
+		t.Fatalf("bad: %#v", role)
+	}
+	if role.Description != "Manage a role." || role.Post.Description != "Roles are great." {
+		t.Fatalf("bad: %#v", role)
+	}
+	expectedParams := []OASParameter{
+		{
+			Name:        "name",
+			Description: "Name of the role.",
+			In:          "path",
+			Required:    true,
+			Schema:      &OASSchema{Type: "string"},
+		},
+	}
+	if !reflect.DeepEqual(role.Parameters, expectedParams) {
+		t.Fatalf("bad: %#v", role.Parameters)
+	}
+	expectedBody := map[string]*OASSchema{
+		"ttl":     &OASSchema{Type: "integer", Format: "seconds", Default: 3600},
+		"enabled": &OASSchema{Type: "boolean"},
+		"type":    &OASSchema{Type: "string", Enum: []interface{}{"foo", "bar"}},
+	}
+	body := role.Post.RequestBody.Content["application/json"].Schema
+	if !reflect.DeepEqual(body.Properties, expectedBody) {
+		t.Fatalf("bad: %#v", role.Post.RequestBody)
+	}
+	if !reflect.DeepEqual(body.Required, []string{"enabled"}) {
+		t.Fatalf("bad: %#v", body.Required)
+	}
+	if _, ok := role.Delete.Responses["204"]; !ok {
+		t.Fatalf("bad: %#v", role.Delete.Responses)
+	}
+
+	login := doc.Paths["/login"]
+	if login == nil || login.Post == nil || login.Post.RequestBody != nil || !login.Unauthenticated || login.Sudo {
+		t.Fatalf("bad: %#v", login)
+	}
+}
+
+func TestBackendHandleRequest_helpRootOpenAPI(t *testing.T) {
+	b := &Backend{
+		Paths: []*Path{
+			&Path{
+				Pattern: "foo",
+				Callbacks: map[logical.Operation]OperationFunc{
+					logical.ReadOperation: nil,
+				},
+			},
+		},
+	}
+
+	resp, err := b.HandleRequest(&logical.Request{
+		Operation: logical.HelpOperation,
+		Path:      "",
+	})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	doc, ok := resp.Data["openapi"].(*OASDocument)
+	if !ok || doc.Paths["/foo"] == nil {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+}
+
+func TestBackendHandleRequest_helpOpenAPI(t *testing.T) {
+	b := &Backend{
+		Paths: []*Path{
+			&Path{
+				Pattern: "foo/" + GenericNameRegex("name"),
+				Fields: map[string]*FieldSchema{
+					"name":  &FieldSchema{Type: TypeString},
+					"value": &FieldSchema{Type: TypeInt},
+				},
+				Callbacks: map[logical.Operation]OperationFunc{
+					logical.UpdateOperation: nil,
+				},
+			},
+			&Path{
+				Pattern: "bar",
+				Callbacks: map[logical.Operation]OperationFunc{
+					logical.ReadOperation: nil,
+				},
+			},
+		},
+	}
+
+	resp, err := b.HandleRequest(&logical.Request{
+		Operation:  logical.HelpOperation,
+		Path:       "foo/baz",
+		MountPoint: "mount/",
+	})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	doc, ok := resp.Data["openapi"].(*OASDocument)
+	if !ok || len(doc.Paths) != 1 {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+	item := doc.Paths["/mount/foo/{name}"]
+	if item == nil || item.Post == nil {
+		t.Fatalf("bad: %#v", doc.Paths)
+	}
+	value := item.Post.RequestBody.Content["application/json"].Schema.Properties["value"]
+	if value == nil || value.Type != "integer" {
+		t.Fatalf("bad: %#v", item.Post.RequestBody)
+	}
+}
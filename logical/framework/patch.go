@@ -0,0 +1,62 @@
+package framework
+
+import (
+	"github.com/hashicorp/vault/helper/jsonutil"
+	"github.com/hashicorp/vault/logical"
+)
+
+// MergePatch applies a JSON merge patch (RFC 7386) to the document and
+// returns the result: the fields of the patch replace those of the document,
+// the objects are patched recursively, and the null fields are removed. The
+// document isn't modified.
+func MergePatch(doc, patch map[string]interface{}) map[string]interface{} {
+	result := make(map[string]interface{}, len(doc)+len(patch))
+	for k, v := range doc {
+		result[k] = v
+	}
+
+	for k, v := range patch {
+		if v == nil {
+			delete(result, k)
+			continue
+		}
+
+		patchValue, ok := v.(map[string]interface{})
+		if !ok {
+			result[k] = v
+			continue
+		}
+		docValue, _ := result[k].(map[string]interface{})
+		result[k] = MergePatch(docValue, patchValue)
+	}
+
+	return result
+}
+
+// PatchStorageEntry applies the JSON merge patch to the JSON storage entry
+// at the key, and decodes the result into out. The entry isn't modified: the
+// backend validates the result before storing it. It returns false if there
+// is no entry at the key.
+func PatchStorageEntry(s logical.Storage, key string, patch map[string]interface{}, out interface{}) (bool, error) {
+	entry, err := s.Get(key)
+	if err != nil {
+		return false, err
+	}
+	if entry == nil {
+		return false, nil
+	}
+
+	var doc map[string]interface{}
+	if err := entry.DecodeJSON(&doc); err != nil {
+		return false, err
+	}
+
+	patched, err := jsonutil.EncodeJSON(MergePatch(doc, patch))
+	if err != nil {
+		return false, err
+	}
+	if err := jsonutil.DecodeJSON(patched, out); err != nil {
+		return false, err
+	}
+	return true, nil
+}
@@ -0,0 +1,92 @@
+package framework
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/vault/logical"
+)
+
+func TestMergePatch(t *testing.T) {
+	doc := map[string]interface{}{
+		"a": "b",
+		"c": map[string]interface{}{
+			"d": "e",
+			"f": "g",
+		},
+		"h": []interface{}{"i"},
+	}
+	patch := map[string]interface{}{
+		"a": "z",
+		"c": map[string]interface{}{
+			"f": nil,
+		},
+		"h": []interface{}{"j", "k"},
+		"l": nil,
+		"m": map[string]interface{}{"n": "o"},
+	}
+	expected := map[string]interface{}{
+		"a": "z",
+		"c": map[string]interface{}{
+			"d": "e",
+		},
+		"h": []interface{}{"j", "k"},
+		"m": map[string]interface{}{"n": "o"},
+	}
+
+	result := MergePatch(doc, patch)
+	if !reflect.DeepEqual(result, expected) {
+		t.Fatalf("bad: %#v", result)
+	}
+
+	// The document isn't modified
+	if doc["a"] != "b" || len(doc["c"].(map[string]interface{})) != 2 {
+		t.Fatalf("bad: %#v", doc)
+	}
+}
+
+func TestPatchStorageEntry(t *testing.T) {
+	type config struct {
+		Hosts   string `json:"hosts"`
+		Port    int    `json:"port"`
+		Enabled bool   `json:"enabled"`
+	}
+
+	storage := new(logical.InmemStorage)
+	var out config
+	found, err := PatchStorageEntry(storage, "config", map[string]interface{}{"port": 42}, &out)
+	if err != nil || found {
+		t.Fatalf("bad: %v, %v", found, err)
+	}
+
+	entry, err := logical.StorageEntryJSON("config", &config{Hosts: "a,b", Port: 1, Enabled: true})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if err := storage.Put(entry); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	patch := map[string]interface{}{
+		"port":    json.Number("42"),
+		"enabled": nil,
+	}
+	found, err = PatchStorageEntry(storage, "config", patch, &out)
+	if err != nil || !found {
+		t.Fatalf("bad: %v, %v", found, err)
+	}
+	if !reflect.DeepEqual(out, config{Hosts: "a,b", Port: 42}) {
+		t.Fatalf("bad: %#v", out)
+	}
+
+	// The entry isn't modified
+	entry, err = storage.Get("config")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	var stored config
+	if err := entry.DecodeJSON(&stored); err != nil || stored.Port != 1 {
+		t.Fatalf("bad: %#v, %v", stored, err)
+	}
+}
@@ -20,6 +20,24 @@ func OptionalParamRegex(name string) string {
 	return fmt.Sprintf("(/(?P<%s>.+))?", name)
 }
 
+// StorageExistenceCheck returns an ExistenceCheck for the paths whose
+// resource is stored at the prefix followed by the value of the field, such
+// as roles stored at "role/<name>", or at the prefix if the field is empty
+func StorageExistenceCheck(prefix, field string) func(*logical.Request, *FieldData) (bool, error) {
+	return func(req *logical.Request, data *FieldData) (bool, error) {
+		key := prefix
+		if field != "" {
+			key += data.Get(field).(string)
+		}
+
+		entry, err := req.Storage.Get(key)
+		if err != nil {
+			return false, fmt.Errorf("existence check failed: %v", err)
+		}
+		return entry != nil, nil
+	}
+}
+
 // PathAppend is a helper for appending lists of paths into a single
 // list.
 func PathAppend(paths ...[]*Path) []*Path {
@@ -46,7 +64,9 @@ type Path struct {
 	// priority.
 	//
 	// Note that only named capture fields are available in every operation,
-	// whereas all fields are available in the Write operation.
+	// whereas all fields are available in the Write operation. The list
+	// operations are paginated by the framework, unless one of the
+	// ListFields is in Fields.
 	Fields map[string]*FieldSchema
 
 	// Callbacks are the set of callbacks that are called for a given
@@ -65,6 +85,11 @@ type Path struct {
 	// is not allowed since the resource must first be created. The reverse is
 	// also true. If not specified, the Update action is forced and the user
 	// must have UpdateCapability on the path.
+	//
+	// A path with an existence check and only a Create callback rejects the
+	// writes to an existing resource, so that it isn't overwritten by
+	// accident, and only an Update callback the writes to a missing one.
+	// StorageExistenceCheck checks the existence of a storage entry.
 	ExistenceCheck func(*logical.Request, *FieldData) (bool, error)
 
 	// Help is text describing how to use this path. This will be used
@@ -82,8 +107,13 @@ type Path struct {
 	HelpDescription string
 }
 
-func (p *Path) helpCallback(
-	req *logical.Request, data *FieldData) (*logical.Response, error) {
+func (p *Path) helpCallback(b *Backend) OperationFunc {
+	return func(req *logical.Request, data *FieldData) (*logical.Response, error) {
+		return p.help(b, req)
+	}
+}
+
+func (p *Path) help(b *Backend, req *logical.Request) (*logical.Response, error) {
 	var tplData pathTemplateData
 	tplData.Request = req.Path
 	tplData.RoutePattern = p.Pattern
@@ -124,7 +154,13 @@ func (p *Path) helpCallback(
 		return nil, fmt.Errorf("error executing template: %s", err)
 	}
 
-	return logical.HelpResponse(help, nil), nil
+	// Document the path for the tools introspecting the API
+	doc := NewOASDocument()
+	documentPath(b, p, req.MountPoint, doc)
+
+	resp := logical.HelpResponse(help, nil)
+	resp.Data["openapi"] = doc
+	return resp, nil
 }
 
 type pathTemplateData struct {
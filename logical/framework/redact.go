@@ -0,0 +1,35 @@
+package framework
+
+import (
+	"strings"
+
+	"github.com/fatih/structs"
+)
+
+// RedactedValue replaces the values of the sensitive fields
+const RedactedValue = "**********"
+
+// RedactedMap returns the fields of a struct as a map keyed by their structs
+// tag, like structs.Map does, with the values of the fields tagged
+// `sensitive:"true"` replaced by RedactedValue when they are set. It is meant
+// for the responses to reads of configurations holding secrets, such as
+// passwords and private keys, which then only reach the audit logs redacted.
+// Only the top-level fields are redacted.
+func RedactedMap(v interface{}) map[string]interface{} {
+	s := structs.New(v)
+	m := s.Map()
+	for _, field := range s.Fields() {
+		if field.Tag("sensitive") != "true" || !field.IsExported() || field.IsZero() {
+			continue
+		}
+
+		name := strings.Split(field.Tag("structs"), ",")[0]
+		if name == "" {
+			name = field.Name()
+		}
+		if _, ok := m[name]; ok {
+			m[name] = RedactedValue
+		}
+	}
+	return m
+}
@@ -0,0 +1,30 @@
+package framework
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRedactedMap(t *testing.T) {
+	type config struct {
+		Username   string `structs:"username"`
+		Password   string `structs:"password" sensitive:"true"`
+		PrivateKey string `structs:"private_key" sensitive:"true"`
+		Token      string `sensitive:"true"`
+	}
+
+	actual := RedactedMap(&config{
+		Username: "foo",
+		Password: "bar",
+		Token:    "baz",
+	})
+	expected := map[string]interface{}{
+		"username":    "foo",
+		"password":    RedactedValue,
+		"private_key": "",
+		"Token":       RedactedValue,
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("bad: %#v", actual)
+	}
+}
@@ -13,6 +13,10 @@ import (
 // WALPrefix is the prefix within Storage where WAL entries will be written.
 const WALPrefix = "wal/"
 
+// DefaultWALRollbackMinAge is the minimum age of the WAL entries before they
+// are rolled back, unless the backend sets WALRollbackMinAge
+const DefaultWALRollbackMinAge = 10 * time.Minute
+
 type WALEntry struct {
 	ID        string      `json:"-"`
 	Kind      string      `json:"type"`
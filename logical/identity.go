@@ -0,0 +1,15 @@
+package logical
+
+// Alias represents the information used by core to map a login to an
+// identity entity or group. Credential backends set this on the Auth they
+// return so that logins from different backends can be linked to the same
+// entity.
+type Alias struct {
+	// Name is the identifier of the authenticated user or machine within
+	// the credential backend, e.g. a username. It must be stable across
+	// logins.
+	Name string `json:"name" mapstructure:"name" structs:"name"`
+
+	// Metadata is attached to the alias when it is first created
+	Metadata map[string]string `json:"metadata" mapstructure:"metadata" structs:"metadata"`
+}
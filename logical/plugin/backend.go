@@ -0,0 +1,282 @@
+package plugin
+
+import (
+	"errors"
+	"time"
+
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/yamux"
+	log "github.com/mgutz/logxi/v1"
+	"google.golang.org/grpc"
+)
+
+const backendService = "plugin.Backend"
+
+type setupArgs struct {
+	Config map[string]string
+}
+
+type setupReply struct {
+	Err *pluginError
+}
+
+// requestArgs holds a request sent to the plugin. The request's storage is
+// replaced by the plugin's, and the lease fields that aren't JSON encoded
+// are sent separately.
+type requestArgs struct {
+	Request *logical.Request
+
+	SecretIncrement time.Duration
+	SecretIssueTime time.Time
+	AuthIncrement   time.Duration
+	AuthIssueTime   time.Time
+}
+
+func newRequestArgs(req *logical.Request) *requestArgs {
+	r := *req
+	r.Storage = nil
+	if r.Connection != nil {
+		r.Connection = &logical.Connection{
+			RemoteAddr: r.Connection.RemoteAddr,
+		}
+	}
+
+	args := &requestArgs{
+		Request: &r,
+	}
+	if r.Secret != nil {
+		args.SecretIncrement = r.Secret.Increment
+		args.SecretIssueTime = r.Secret.IssueTime
+	}
+	if r.Auth != nil {
+		args.AuthIncrement = r.Auth.Increment
+		args.AuthIssueTime = r.Auth.IssueTime
+	}
+	return args
+}
+
+func (a *requestArgs) request(storage logical.Storage) *logical.Request {
+	req := a.Request
+	req.Storage = storage
+	if req.Secret != nil {
+		req.Secret.Increment = a.SecretIncrement
+		req.Secret.IssueTime = a.SecretIssueTime
+	}
+	if req.Auth != nil {
+		req.Auth.Increment = a.AuthIncrement
+		req.Auth.IssueTime = a.AuthIssueTime
+	}
+	return req
+}
+
+type handleRequestReply struct {
+	Response *logical.Response
+	Err      *pluginError
+}
+
+type existenceCheckReply struct {
+	CheckFound bool
+	Exists     bool
+	Err        *pluginError
+}
+
+type specialPathsReply struct {
+	Paths *logical.Paths
+}
+
+// backendServer serves the backend of a plugin, in the plugin process
+type backendServer struct {
+	factory logical.Factory
+	storage logical.Storage
+	system  logical.SystemView
+	logger  log.Logger
+
+	backend logical.Backend
+}
+
+var errNotSetUp = errors.New("plugin backend is not set up")
+
+func (s *backendServer) Setup(args *setupArgs) *setupReply {
+	b, err := s.factory(&logical.BackendConfig{
+		StorageView: s.storage,
+		Logger:      s.logger,
+		System:      s.system,
+		Config:      args.Config,
+	})
+	if err == nil && b == nil {
+		err = errors.New("plugin factory returned no backend")
+	}
+	if err != nil {
+		return &setupReply{Err: wrapError(err)}
+	}
+
+	s.backend = b
+	return &setupReply{}
+}
+
+func (s *backendServer) HandleRequest(args *requestArgs) *handleRequestReply {
+	if s.backend == nil {
+		return &handleRequestReply{Err: wrapError(errNotSetUp)}
+	}
+
+	resp, err := s.backend.HandleRequest(args.request(s.storage))
+	return &handleRequestReply{
+		Response: resp,
+		Err:      wrapError(err),
+	}
+}
+
+func (s *backendServer) HandleExistenceCheck(args *requestArgs) *existenceCheckReply {
+	if s.backend == nil {
+		return &existenceCheckReply{Err: wrapError(errNotSetUp)}
+	}
+
+	checkFound, exists, err := s.backend.HandleExistenceCheck(args.request(s.storage))
+	return &existenceCheckReply{
+		CheckFound: checkFound,
+		Exists:     exists,
+		Err:        wrapError(err),
+	}
+}
+
+func (s *backendServer) SpecialPaths() *specialPathsReply {
+	if s.backend == nil {
+		return &specialPathsReply{}
+	}
+	return &specialPathsReply{Paths: s.backend.SpecialPaths()}
+}
+
+func (s *backendServer) Cleanup() *empty {
+	if s.backend != nil {
+		s.backend.Cleanup()
+	}
+	return &empty{}
+}
+
+var backendServiceDesc = grpc.ServiceDesc{
+	ServiceName: backendService,
+	HandlerType: (*interface{})(nil),
+	Methods: []grpc.MethodDesc{
+		unaryMethod("Setup", func() interface{} { return new(setupArgs) }, func(srv, args interface{}) interface{} {
+			return srv.(*backendServer).Setup(args.(*setupArgs))
+		}),
+		unaryMethod("HandleRequest", func() interface{} { return new(requestArgs) }, func(srv, args interface{}) interface{} {
+			return srv.(*backendServer).HandleRequest(args.(*requestArgs))
+		}),
+		unaryMethod("HandleExistenceCheck", func() interface{} { return new(requestArgs) }, func(srv, args interface{}) interface{} {
+			return srv.(*backendServer).HandleExistenceCheck(args.(*requestArgs))
+		}),
+		unaryMethod("SpecialPaths", func() interface{} { return new(empty) }, func(srv, args interface{}) interface{} {
+			return srv.(*backendServer).SpecialPaths()
+		}),
+		unaryMethod("Cleanup", func() interface{} { return new(empty) }, func(srv, args interface{}) interface{} {
+			return srv.(*backendServer).Cleanup()
+		}),
+	},
+}
+
+// serve serves the backend created by the factory on the session, until
+// the session is closed
+func serve(session *yamux.Session, factory logical.Factory, logger log.Logger) error {
+	conn, err := dial(session)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	server := newServer()
+	server.RegisterService(&backendServiceDesc, &backendServer{
+		factory: factory,
+		storage: &storageClient{conn: conn},
+		system:  &systemViewClient{conn: conn},
+		logger:  logger,
+	})
+	err = server.Serve(sessionListener{session})
+	if session.IsClosed() {
+		return nil
+	}
+	return err
+}
+
+// backendClient is the backend of a plugin, as seen by Vault
+type backendClient struct {
+	conn         *grpc.ClientConn
+	system       logical.SystemView
+	specialPaths *logical.Paths
+	cleanup      func()
+}
+
+// newBackendClient serves storage and the system view to the plugin at the
+// other end of the session, and sets up the plugin's backend
+func newBackendClient(session *yamux.Session, conf *logical.BackendConfig) (*backendClient, error) {
+	server := newServer()
+	server.RegisterService(&storageServiceDesc, &storageServer{storage: conf.StorageView})
+	server.RegisterService(&systemViewServiceDesc, &systemViewServer{system: conf.System})
+	go server.Serve(sessionListener{session})
+
+	conn, err := dial(session)
+	if err != nil {
+		server.Stop()
+		session.Close()
+		return nil, err
+	}
+
+	b := &backendClient{
+		conn:   conn,
+		system: conf.System,
+		cleanup: func() {
+			conn.Close()
+			server.Stop()
+			session.Close()
+		},
+	}
+
+	setup := new(setupReply)
+	if err := invoke(conn, backendService, "Setup", &setupArgs{Config: conf.Config}, setup); err != nil {
+		b.cleanup()
+		return nil, err
+	}
+	if err := setup.Err.unwrap(); err != nil {
+		b.cleanup()
+		return nil, err
+	}
+
+	paths := new(specialPathsReply)
+	if err := invoke(conn, backendService, "SpecialPaths", &empty{}, paths); err != nil {
+		b.cleanup()
+		return nil, err
+	}
+	b.specialPaths = paths.Paths
+
+	return b, nil
+}
+
+func (b *backendClient) HandleRequest(req *logical.Request) (*logical.Response, error) {
+	reply := new(handleRequestReply)
+	if err := invoke(b.conn, backendService, "HandleRequest", newRequestArgs(req), reply); err != nil {
+		return nil, err
+	}
+
+	return reply.Response, reply.Err.unwrap()
+}
+
+func (b *backendClient) HandleExistenceCheck(req *logical.Request) (bool, bool, error) {
+	reply := new(existenceCheckReply)
+	if err := invoke(b.conn, backendService, "HandleExistenceCheck", newRequestArgs(req), reply); err != nil {
+		return false, false, err
+	}
+	return reply.CheckFound, reply.Exists, reply.Err.unwrap()
+}
+
+func (b *backendClient) SpecialPaths() *logical.Paths {
+	return b.specialPaths
+}
+
+func (b *backendClient) System() logical.SystemView {
+	return b.system
+}
+
+func (b *backendClient) Cleanup() {
+	invoke(b.conn, backendService, "Cleanup", &empty{}, &empty{})
+	b.cleanup()
+}
@@ -0,0 +1,131 @@
+package plugin
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net"
+	"time"
+
+	"github.com/hashicorp/vault/helper/jsonutil"
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/yamux"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+)
+
+// jsonCodec encodes gRPC messages as JSON rather than protocol buffers, so
+// that the logical types can be sent as they are. Numbers are decoded as
+// json.Number, as they are in HTTP requests.
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (jsonCodec) Unmarshal(data []byte, v interface{}) error {
+	return jsonutil.DecodeJSONFromReader(bytes.NewReader(data), v)
+}
+
+func (jsonCodec) String() string {
+	return "json"
+}
+
+// unaryMethod describes a gRPC method whose arguments are created by
+// newArgs and which is served by call. Failures are sent in the replies, so
+// the methods themselves never fail.
+func unaryMethod(name string, newArgs func() interface{}, call func(srv, args interface{}) interface{}) grpc.MethodDesc {
+	return grpc.MethodDesc{
+		MethodName: name,
+		Handler: func(srv interface{}, ctx context.Context, dec func(interface{}) error, _ grpc.UnaryServerInterceptor) (interface{}, error) {
+			args := newArgs()
+			if err := dec(args); err != nil {
+				return nil, err
+			}
+			return call(srv, args), nil
+		},
+	}
+}
+
+// invoke calls a method of a service at the other end of the connection
+func invoke(conn *grpc.ClientConn, service, method string, args, reply interface{}) error {
+	return grpc.Invoke(context.Background(), "/"+service+"/"+method, args, reply, conn)
+}
+
+// newServer returns a gRPC server, to serve the streams opened by the other
+// end of a session
+func newServer() *grpc.Server {
+	return grpc.NewServer(grpc.CustomCodec(jsonCodec{}))
+}
+
+// dial returns a gRPC connection over streams opened on the session
+func dial(session *yamux.Session) (*grpc.ClientConn, error) {
+	return grpc.Dial("plugin",
+		grpc.WithInsecure(),
+		grpc.WithCodec(jsonCodec{}),
+		grpc.WithDialer(func(string, time.Duration) (net.Conn, error) {
+			return session.Open()
+		}))
+}
+
+// sessionListener accepts the streams opened by the other end of a yamux
+// session
+type sessionListener struct {
+	*yamux.Session
+}
+
+func (sessionListener) Addr() net.Addr {
+	return pluginAddr{}
+}
+
+type pluginAddr struct{}
+
+func (pluginAddr) Network() string { return "plugin" }
+func (pluginAddr) String() string  { return "plugin" }
+
+// empty is the arguments or reply of methods that have none
+type empty struct{}
+
+// pluginError is an error sent in a reply
+type pluginError struct {
+	Message string
+	Code    int
+}
+
+// knownErrors are the errors callers compare against, which must be the
+// same values on both ends
+var knownErrors = []error{
+	logical.ErrUnsupportedOperation,
+	logical.ErrUnsupportedPath,
+	logical.ErrInvalidRequest,
+	logical.ErrPermissionDenied,
+}
+
+func wrapError(err error) *pluginError {
+	if err == nil {
+		return nil
+	}
+
+	ret := &pluginError{
+		Message: err.Error(),
+	}
+	if coded, ok := err.(logical.HTTPCodedError); ok {
+		ret.Code = coded.Code()
+	}
+	return ret
+}
+
+func (e *pluginError) unwrap() error {
+	if e == nil {
+		return nil
+	}
+	if e.Code != 0 {
+		return logical.CodedError(e.Code, e.Message)
+	}
+	for _, known := range knownErrors {
+		if e.Message == known.Error() {
+			return known
+		}
+	}
+	return errors.New(e.Message)
+}
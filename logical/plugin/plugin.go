@@ -0,0 +1,159 @@
+// Package plugin runs logical backends in separate processes, so that
+// backends can be shipped without recompiling Vault.
+//
+// Vault starts the plugin binary and talks to it over gRPC, multiplexed on
+// the plugin's stdin and stdout. The plugin calls back into Vault over the
+// same connection for storage and for the system view of its mount.
+package plugin
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"time"
+
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/yamux"
+	log "github.com/mgutz/logxi/v1"
+)
+
+const (
+	// MagicCookieKey and MagicCookieValue are set in the environment of
+	// plugins, so that a plugin binary run directly can tell it isn't run
+	// by Vault
+	MagicCookieKey   = "VAULT_BACKEND_PLUGIN"
+	MagicCookieValue = "6669da05-b1c8-4f49-97d9-c8e5bed98e20"
+
+	// MlockEnabledEnv is set to "true" in the environment of plugins when
+	// Vault locks its memory, so that plugins lock theirs too
+	MlockEnabledEnv = "VAULT_PLUGIN_MLOCK_ENABLED"
+
+	// exitTimeout is how long a plugin has to exit once its backend is
+	// cleaned up before it is killed
+	exitTimeout = 2 * time.Second
+)
+
+// Runner holds what is needed to run a plugin: the binary to run, its
+// arguments, and the SHA256 checksum the binary must match.
+type Runner struct {
+	Name    string   `json:"name"`
+	Command string   `json:"command"`
+	Args    []string `json:"args"`
+	Sha256  []byte   `json:"sha256"`
+}
+
+// verify checks that the plugin binary matches its checksum
+func (r *Runner) verify() error {
+	f, err := os.Open(r.Command)
+	if err != nil {
+		return fmt.Errorf("failed to open plugin %q: %v", r.Name, err)
+	}
+	defer f.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, f); err != nil {
+		return fmt.Errorf("failed to read plugin %q: %v", r.Name, err)
+	}
+	if !bytes.Equal(hash.Sum(nil), r.Sha256) {
+		return fmt.Errorf("checksum of plugin %q doesn't match the catalog", r.Name)
+	}
+	return nil
+}
+
+// NewBackend runs the plugin and returns the backend it serves. The binary
+// is checked against its checksum before being run, and the plugin locks
+// its memory if mlock is true. Cleaning up the backend stops the plugin.
+func NewBackend(runner *Runner, mlock bool, conf *logical.BackendConfig) (logical.Backend, error) {
+	if err := runner.verify(); err != nil {
+		return nil, err
+	}
+
+	cmd := exec.Command(runner.Command, runner.Args...)
+	cmd.Env = append(os.Environ(),
+		MagicCookieKey+"="+MagicCookieValue,
+		fmt.Sprintf("%s=%t", MlockEnabledEnv, mlock))
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to run plugin %q: %v", runner.Name, err)
+	}
+	go logOutput(conf.Logger, runner.Name, stderr)
+
+	exited := make(chan struct{})
+	go func() {
+		cmd.Wait()
+		close(exited)
+	}()
+	stop := func() {
+		go func() {
+			select {
+			case <-exited:
+			case <-time.After(exitTimeout):
+				cmd.Process.Kill()
+			}
+		}()
+	}
+
+	session, err := yamux.Client(&stdioConn{
+		Reader: stdout,
+		Writer: stdin,
+		Closer: stdin,
+	}, yamuxConfig())
+	if err != nil {
+		stdin.Close()
+		stop()
+		return nil, err
+	}
+
+	b, err := newBackendClient(session, conf)
+	if err != nil {
+		stop()
+		return nil, fmt.Errorf("failed to set up plugin %q: %v", runner.Name, err)
+	}
+	closeConns := b.cleanup
+	b.cleanup = func() {
+		closeConns()
+		stop()
+	}
+
+	return b, nil
+}
+
+// logOutput logs what the plugin writes to stderr
+func logOutput(logger log.Logger, name string, r io.Reader) {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		if logger != nil {
+			logger.Info("plugin: "+scanner.Text(), "plugin", name)
+		}
+	}
+}
+
+// stdioConn joins the standard input and output of a process into a
+// connection yamux can multiplex
+type stdioConn struct {
+	io.Reader
+	io.Writer
+	io.Closer
+}
+
+func yamuxConfig() *yamux.Config {
+	config := yamux.DefaultConfig()
+	config.LogOutput = ioutil.Discard
+	return config
+}
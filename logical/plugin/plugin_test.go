@@ -0,0 +1,217 @@
+package plugin
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+)
+
+func testFactory(conf *logical.BackendConfig) (logical.Backend, error) {
+	b := &framework.Backend{
+		Paths: []*framework.Path{
+			&framework.Path{
+				Pattern: "kv/" + framework.GenericNameRegex("key"),
+				Fields: map[string]*framework.FieldSchema{
+					"key":   &framework.FieldSchema{Type: framework.TypeString},
+					"value": &framework.FieldSchema{Type: framework.TypeString},
+				},
+				ExistenceCheck: func(req *logical.Request, data *framework.FieldData) (bool, error) {
+					entry, err := req.Storage.Get(data.Get("key").(string))
+					return entry != nil, err
+				},
+				Callbacks: map[logical.Operation]framework.OperationFunc{
+					logical.ReadOperation: func(req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+						entry, err := req.Storage.Get(data.Get("key").(string))
+						if err != nil || entry == nil {
+							return nil, err
+						}
+						return &logical.Response{
+							Data: map[string]interface{}{
+								"value": string(entry.Value),
+							},
+						}, nil
+					},
+					logical.CreateOperation: func(req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+						return nil, req.Storage.Put(&logical.StorageEntry{
+							Key:   data.Get("key").(string),
+							Value: []byte(data.Get("value").(string)),
+						})
+					},
+				},
+			},
+			&framework.Path{
+				Pattern: "config",
+				Callbacks: map[logical.Operation]framework.OperationFunc{
+					logical.ReadOperation: func(req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+						resp := &logical.Response{
+							Data: map[string]interface{}{
+								"default_lease_ttl": int64(conf.System.DefaultLeaseTTL().Seconds()),
+								"foo":               conf.Config["foo"],
+							},
+						}
+						resp.AddWarning("read the config")
+						return resp, nil
+					},
+				},
+			},
+		},
+		PathsSpecial: &logical.Paths{
+			Unauthenticated: []string{"config"},
+		},
+	}
+	return b.Setup(conf)
+}
+
+// TestPlugin_helper isn't a real test: it is the plugin run by the other
+// tests
+func TestPlugin_helper(t *testing.T) {
+	if os.Getenv(MagicCookieKey) == "" {
+		return
+	}
+
+	if err := Serve(testFactory); err != nil {
+		fmt.Fprintf(os.Stderr, "err: %v\n", err)
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
+
+func testRunner(t *testing.T) *Runner {
+	contents, err := ioutil.ReadFile(os.Args[0])
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	sum := sha256.Sum256(contents)
+
+	return &Runner{
+		Name:    "test",
+		Command: os.Args[0],
+		Args:    []string{"-test.run=TestPlugin_helper"},
+		Sha256:  sum[:],
+	}
+}
+
+func TestPlugin(t *testing.T) {
+	storage := &logical.InmemStorage{}
+	b, err := NewBackend(testRunner(t), false, &logical.BackendConfig{
+		StorageView: storage,
+		System: &logical.StaticSystemView{
+			DefaultLeaseTTLVal: time.Hour,
+		},
+		Config: map[string]string{
+			"foo": "bar",
+		},
+	})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	defer b.Cleanup()
+
+	if paths := b.SpecialPaths(); !reflect.DeepEqual(paths.Unauthenticated, []string{"config"}) {
+		t.Fatalf("bad: %#v", paths)
+	}
+
+	req := logical.TestRequest(t, logical.CreateOperation, "kv/foo")
+	req.Storage = storage
+	req.Data["value"] = "bar"
+	checkFound, exists, err := b.HandleExistenceCheck(req)
+	if err != nil || !checkFound || exists {
+		t.Fatalf("bad: %t %t %v", checkFound, exists, err)
+	}
+	if _, err := b.HandleRequest(req); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// The plugin writes to Vault's storage
+	entry, err := storage.Get("foo")
+	if err != nil || entry == nil || string(entry.Value) != "bar" {
+		t.Fatalf("bad: %#v %v", entry, err)
+	}
+
+	req = logical.TestRequest(t, logical.ReadOperation, "kv/foo")
+	req.Storage = storage
+	resp, err := b.HandleRequest(req)
+	if err != nil || resp.Data["value"] != "bar" {
+		t.Fatalf("bad: %#v %v", resp, err)
+	}
+
+	req = logical.TestRequest(t, logical.ReadOperation, "config")
+	req.Storage = storage
+	resp, err = b.HandleRequest(req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if resp.Data["default_lease_ttl"].(json.Number).String() != "3600" || resp.Data["foo"] != "bar" {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+	if !reflect.DeepEqual(resp.Warnings, []string{"read the config"}) {
+		t.Fatalf("bad: %#v", resp.Warnings)
+	}
+
+	// Errors callers compare against are kept
+	req = logical.TestRequest(t, logical.ReadOperation, "nope")
+	req.Storage = storage
+	if _, err := b.HandleRequest(req); err != logical.ErrUnsupportedPath {
+		t.Fatalf("bad: %v", err)
+	}
+}
+
+func TestPlugin_checksum(t *testing.T) {
+	runner := testRunner(t)
+	runner.Sha256[0]++
+
+	_, err := NewBackend(runner, false, &logical.BackendConfig{
+		StorageView: &logical.InmemStorage{},
+		System:      &logical.StaticSystemView{},
+	})
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+}
+
+func TestRequestArgs(t *testing.T) {
+	issued := time.Now().UTC().Truncate(time.Second)
+	req := &logical.Request{
+		Operation: logical.RenewOperation,
+		Path:      "creds",
+		Storage:   &logical.InmemStorage{},
+		Secret: &logical.Secret{
+			LeaseOptions: logical.LeaseOptions{
+				TTL:       time.Hour,
+				Increment: time.Minute,
+				IssueTime: issued,
+			},
+			InternalData: map[string]interface{}{
+				"foo": "bar",
+			},
+		},
+	}
+
+	codec := jsonCodec{}
+	encoded, err := codec.Marshal(newRequestArgs(req))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	args := new(requestArgs)
+	if err := codec.Unmarshal(encoded, args); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	storage := &logical.InmemStorage{}
+	decoded := args.request(storage)
+	if decoded.Storage != storage {
+		t.Fatalf("bad: %#v", decoded.Storage)
+	}
+	decoded.Storage = req.Storage
+	if !reflect.DeepEqual(decoded, req) {
+		t.Fatalf("bad: %#v", decoded.Secret)
+	}
+}
@@ -0,0 +1,45 @@
+package plugin
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/hashicorp/vault/helper/logformat"
+	"github.com/hashicorp/vault/helper/mlock"
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/yamux"
+	log "github.com/mgutz/logxi/v1"
+)
+
+// Serve serves the backend created by the factory to Vault. It is called
+// by the main function of plugin binaries and returns once Vault stops the
+// plugin.
+//
+// Vault talks to the plugin over its stdin and stdout, so once Serve is
+// called os.Stdout is replaced by os.Stderr, whose output Vault logs.
+func Serve(factory logical.Factory) error {
+	if os.Getenv(MagicCookieKey) != MagicCookieValue {
+		return errors.New("this binary is a Vault plugin: it must be registered in the plugin catalog and run by Vault")
+	}
+
+	if os.Getenv(MlockEnabledEnv) == "true" && mlock.Supported() {
+		if err := mlock.LockMemory(); err != nil {
+			return fmt.Errorf("failed to lock memory: %v", err)
+		}
+	}
+
+	stdout := os.Stdout
+	os.Stdout = os.Stderr
+
+	session, err := yamux.Server(&stdioConn{
+		Reader: os.Stdin,
+		Writer: stdout,
+		Closer: stdout,
+	}, yamuxConfig())
+	if err != nil {
+		return err
+	}
+
+	return serve(session, factory, logformat.NewVaultLoggerWithWriter(os.Stderr, log.LevelTrace))
+}
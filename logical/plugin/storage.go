@@ -0,0 +1,115 @@
+package plugin
+
+import (
+	"github.com/hashicorp/vault/logical"
+	"google.golang.org/grpc"
+)
+
+const storageService = "plugin.Storage"
+
+type storageKeyArgs struct {
+	Key string
+}
+
+type storageListReply struct {
+	Keys []string
+	Err  *pluginError
+}
+
+type storageGetReply struct {
+	Entry *logical.StorageEntry
+	Err   *pluginError
+}
+
+type storagePutArgs struct {
+	Entry *logical.StorageEntry
+}
+
+type storageReply struct {
+	Err *pluginError
+}
+
+// storageServer serves the storage of a plugin's mount, in Vault
+type storageServer struct {
+	storage logical.Storage
+}
+
+func (s *storageServer) List(args *storageKeyArgs) *storageListReply {
+	keys, err := s.storage.List(args.Key)
+	return &storageListReply{
+		Keys: keys,
+		Err:  wrapError(err),
+	}
+}
+
+func (s *storageServer) Get(args *storageKeyArgs) *storageGetReply {
+	entry, err := s.storage.Get(args.Key)
+	return &storageGetReply{
+		Entry: entry,
+		Err:   wrapError(err),
+	}
+}
+
+func (s *storageServer) Put(args *storagePutArgs) *storageReply {
+	return &storageReply{Err: wrapError(s.storage.Put(args.Entry))}
+}
+
+func (s *storageServer) Delete(args *storageKeyArgs) *storageReply {
+	return &storageReply{Err: wrapError(s.storage.Delete(args.Key))}
+}
+
+var storageServiceDesc = grpc.ServiceDesc{
+	ServiceName: storageService,
+	HandlerType: (*interface{})(nil),
+	Methods: []grpc.MethodDesc{
+		unaryMethod("List", func() interface{} { return new(storageKeyArgs) }, func(srv, args interface{}) interface{} {
+			return srv.(*storageServer).List(args.(*storageKeyArgs))
+		}),
+		unaryMethod("Get", func() interface{} { return new(storageKeyArgs) }, func(srv, args interface{}) interface{} {
+			return srv.(*storageServer).Get(args.(*storageKeyArgs))
+		}),
+		unaryMethod("Put", func() interface{} { return new(storagePutArgs) }, func(srv, args interface{}) interface{} {
+			return srv.(*storageServer).Put(args.(*storagePutArgs))
+		}),
+		unaryMethod("Delete", func() interface{} { return new(storageKeyArgs) }, func(srv, args interface{}) interface{} {
+			return srv.(*storageServer).Delete(args.(*storageKeyArgs))
+		}),
+	},
+}
+
+// storageClient is the storage of the plugin's mount, as seen by the plugin
+type storageClient struct {
+	conn *grpc.ClientConn
+}
+
+func (s *storageClient) List(prefix string) ([]string, error) {
+	reply := new(storageListReply)
+	if err := invoke(s.conn, storageService, "List", &storageKeyArgs{Key: prefix}, reply); err != nil {
+		return nil, err
+	}
+	return reply.Keys, reply.Err.unwrap()
+}
+
+func (s *storageClient) Get(key string) (*logical.StorageEntry, error) {
+	reply := new(storageGetReply)
+	if err := invoke(s.conn, storageService, "Get", &storageKeyArgs{Key: key}, reply); err != nil {
+		return nil, err
+	}
+	return reply.Entry, reply.Err.unwrap()
+}
+
+func (s *storageClient) Put(entry *logical.StorageEntry) error {
+	reply := new(storageReply)
+	if err := invoke(s.conn, storageService, "Put", &storagePutArgs{Entry: entry}, reply); err != nil {
+		return err
+	}
+	return reply.Err.unwrap()
+}
+
+func (s *storageClient) Delete(key string) error {
+	reply := new(storageReply)
+	if err := invoke(s.conn, storageService, "Delete", &storageKeyArgs{Key: key}, reply); err != nil {
+		return err
+	}
+	return reply.Err.unwrap()
+}
@@ -0,0 +1,107 @@
+package plugin
+
+import (
+	"time"
+
+	"github.com/hashicorp/vault/logical"
+	"google.golang.org/grpc"
+)
+
+const systemViewService = "plugin.SystemView"
+
+type sudoPrivilegeArgs struct {
+	Path  string
+	Token string
+}
+
+type durationReply struct {
+	Duration time.Duration
+}
+
+type boolReply struct {
+	Value bool
+}
+
+// systemViewServer serves the system view of a plugin's mount, in Vault
+type systemViewServer struct {
+	system logical.SystemView
+}
+
+func (s *systemViewServer) DefaultLeaseTTL() *durationReply {
+	return &durationReply{Duration: s.system.DefaultLeaseTTL()}
+}
+
+func (s *systemViewServer) MaxLeaseTTL() *durationReply {
+	return &durationReply{Duration: s.system.MaxLeaseTTL()}
+}
+
+func (s *systemViewServer) SudoPrivilege(args *sudoPrivilegeArgs) *boolReply {
+	return &boolReply{Value: s.system.SudoPrivilege(args.Path, args.Token)}
+}
+
+func (s *systemViewServer) Tainted() *boolReply {
+	return &boolReply{Value: s.system.Tainted()}
+}
+
+func (s *systemViewServer) CachingDisabled() *boolReply {
+	return &boolReply{Value: s.system.CachingDisabled()}
+}
+
+var systemViewServiceDesc = grpc.ServiceDesc{
+	ServiceName: systemViewService,
+	HandlerType: (*interface{})(nil),
+	Methods: []grpc.MethodDesc{
+		unaryMethod("DefaultLeaseTTL", func() interface{} { return new(empty) }, func(srv, args interface{}) interface{} {
+			return srv.(*systemViewServer).DefaultLeaseTTL()
+		}),
+		unaryMethod("MaxLeaseTTL", func() interface{} { return new(empty) }, func(srv, args interface{}) interface{} {
+			return srv.(*systemViewServer).MaxLeaseTTL()
+		}),
+		unaryMethod("SudoPrivilege", func() interface{} { return new(sudoPrivilegeArgs) }, func(srv, args interface{}) interface{} {
+			return srv.(*systemViewServer).SudoPrivilege(args.(*sudoPrivilegeArgs))
+		}),
+		unaryMethod("Tainted", func() interface{} { return new(empty) }, func(srv, args interface{}) interface{} {
+			return srv.(*systemViewServer).Tainted()
+		}),
+		unaryMethod("CachingDisabled", func() interface{} { return new(empty) }, func(srv, args interface{}) interface{} {
+			return srv.(*systemViewServer).CachingDisabled()
+		}),
+	},
+}
+
+// systemViewClient is the system view of the plugin's mount, as seen by the
+// plugin. The system view can't report errors, so when Vault can't be
+// reached the zero values are returned.
+type systemViewClient struct {
+	conn *grpc.ClientConn
+}
+
+func (s *systemViewClient) DefaultLeaseTTL() time.Duration {
+	reply := new(durationReply)
+	invoke(s.conn, systemViewService, "DefaultLeaseTTL", &empty{}, reply)
+	return reply.Duration
+}
+
+func (s *systemViewClient) MaxLeaseTTL() time.Duration {
+	reply := new(durationReply)
+	invoke(s.conn, systemViewService, "MaxLeaseTTL", &empty{}, reply)
+	return reply.Duration
+}
+
+func (s *systemViewClient) SudoPrivilege(path string, token string) bool {
+	reply := new(boolReply)
+	invoke(s.conn, systemViewService, "SudoPrivilege", &sudoPrivilegeArgs{Path: path, Token: token}, reply)
+	return reply.Value
+}
+
+func (s *systemViewClient) Tainted() bool {
+	reply := new(boolReply)
+	invoke(s.conn, systemViewService, "Tainted", &empty{}, reply)
+	return reply.Value
+}
+
+func (s *systemViewClient) CachingDisabled() bool {
+	reply := new(boolReply)
+	invoke(s.conn, systemViewService, "CachingDisabled", &empty{}, reply)
+	return reply.Value
+}
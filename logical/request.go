@@ -60,6 +60,16 @@ type Request struct {
 	// WrapTTL contains the requested TTL of the token used to wrap the
 	// response in a cubbyhole.
 	WrapTTL time.Duration `json:"wrap_ttl" struct:"wrap_ttl" mapstructure:"wrap_ttl"`
+
+	// Namespace is the path of the namespace of the request, such as
+	// "team-a/", or empty for the root namespace. Paths are relative to
+	// the namespace.
+	Namespace string `json:"namespace" structs:"namespace" mapstructure:"namespace"`
+
+	// Headers are the HTTP headers of the request. They are only used to
+	// record the configured headers in audit entries, and are not passed
+	// to the backends.
+	Headers map[string][]string `json:"headers" structs:"headers" mapstructure:"headers"`
 }
 
 // Get returns a data field and guards for nil Data
@@ -136,10 +146,19 @@ const (
 	ListOperation             = "list"
 	HelpOperation             = "help"
 
+	// PatchOperation partially updates a resource with a JSON merge patch
+	// (RFC 7386), whose null fields reset their value
+	PatchOperation = "patch"
+
 	// The operations below are called globally, the path is less relevant.
 	RevokeOperation   Operation = "revoke"
 	RenewOperation              = "renew"
 	RollbackOperation           = "rollback"
+
+	// AliasLookaheadOperation is sent by core to login paths ahead of a
+	// login request. Backends that support it return an Auth containing
+	// only the Alias the login would be for, without authenticating it.
+	AliasLookaheadOperation = "alias-lookahead"
 )
 
 var (
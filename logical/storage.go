@@ -2,6 +2,7 @@ package logical
 
 import (
 	"fmt"
+	"sort"
 
 	"github.com/hashicorp/vault/helper/jsonutil"
 )
@@ -25,6 +26,34 @@ func (e *StorageEntry) DecodeJSON(out interface{}) error {
 	return jsonutil.DecodeJSON(e.Value, out)
 }
 
+// ListPage lists the keys of the storage with the prefix, returning the sorted
+// keys after the key after, and at most limit of them if it is positive.
+func ListPage(s Storage, prefix, after string, limit int) ([]string, error) {
+	keys, err := s.List(prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	return PaginateKeys(keys, after, limit), nil
+}
+
+// PaginateKeys returns the sorted keys after the key after, and at most limit
+// of them if it is positive. The keys aren't modified.
+func PaginateKeys(keys []string, after string, limit int) []string {
+	sorted := make([]string, len(keys))
+	copy(sorted, keys)
+	sort.Strings(sorted)
+
+	i := sort.Search(len(sorted), func(i int) bool {
+		return sorted[i] > after
+	})
+	sorted = sorted[i:]
+	if limit > 0 && len(sorted) > limit {
+		sorted = sorted[:limit]
+	}
+	return sorted
+}
+
 // StorageEntryJSON creates a StorageEntry with a JSON-encoded value.
 func StorageEntryJSON(k string, v interface{}) (*StorageEntry, error) {
 	encodedBytes, err := jsonutil.EncodeJSON(v)
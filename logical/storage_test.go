@@ -0,0 +1,37 @@
+package logical
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestListPage(t *testing.T) {
+	s := new(InmemStorage)
+	for _, key := range []string{"foo/c", "foo/a", "foo/b/d", "foo/e"} {
+		if err := s.Put(&StorageEntry{Key: key}); err != nil {
+			t.Fatalf("err: %s", err)
+		}
+	}
+
+	cases := []struct {
+		After    string
+		Limit    int
+		Expected []string
+	}{
+		{"", 0, []string{"a", "b/", "c", "e"}},
+		{"", 2, []string{"a", "b/"}},
+		{"b/", 0, []string{"c", "e"}},
+		{"b/", 1, []string{"c"}},
+		{"d", 5, []string{"e"}},
+		{"e", 0, []string{}},
+	}
+	for _, tc := range cases {
+		keys, err := ListPage(s, "foo/", tc.After, tc.Limit)
+		if err != nil {
+			t.Fatalf("err: %s", err)
+		}
+		if !reflect.DeepEqual(keys, tc.Expected) {
+			t.Fatalf("%q, %d: bad: %#v", tc.After, tc.Limit, keys)
+		}
+	}
+}
@@ -40,6 +40,7 @@ type Meta struct {
 	flagClientCert string
 	flagClientKey  string
 	flagWrapTTL    string
+	flagNamespace  string
 	flagInsecure   bool
 
 	// Queried if no token can be found
@@ -91,6 +92,10 @@ func (m *Meta) Client() (*api.Client, error) {
 
 	client.SetWrappingLookupFunc(m.DefaultWrappingLookupFunc)
 
+	if m.flagNamespace != "" {
+		client.SetNamespace(m.flagNamespace)
+	}
+
 	// If we have a token directly, then set that
 	token := m.ClientToken
 
@@ -138,6 +143,7 @@ func (m *Meta) FlagSet(n string, fs FlagSetFlags) *flag.FlagSet {
 		f.StringVar(&m.flagClientCert, "client-cert", "", "")
 		f.StringVar(&m.flagClientKey, "client-key", "", "")
 		f.StringVar(&m.flagWrapTTL, "wrap-ttl", "", "")
+		f.StringVar(&m.flagNamespace, "namespace", "", "")
 		f.BoolVar(&m.flagInsecure, "insecure", false, "")
 		f.BoolVar(&m.flagInsecure, "tls-skip-verify", false, "")
 	}
@@ -187,6 +193,10 @@ func GeneralOptionsUsage() string {
   -tls-skip-verify        Do not verify TLS certificate. This is highly
                           not recommended. Verification will also be skipped
                           if VAULT_SKIP_VERIFY is set.
+
+  -namespace=path         The namespace the request is made in, such as
+                          "team-a/dev". Overrides the VAULT_NAMESPACE
+                          environment variable if set.
 `
 
 	general += AdditionalOptionsUsage()
@@ -2,8 +2,11 @@ package physical
 
 import (
 	"encoding/base64"
+	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"net/http"
+	"net/url"
 	"os"
 	"sort"
 	"strconv"
@@ -31,7 +34,8 @@ type AzureBackend struct {
 
 // newAzureBackend constructs an Azure backend using a pre-existing
 // bucket. Credentials can be provided to the backend, sourced
-// from the environment, AWS credential files or by IAM role.
+// from the environment, or listed with the managed identity of the
+// virtual machine.
 func newAzureBackend(conf map[string]string, logger log.Logger) (Backend, error) {
 
 	container := os.Getenv("AZURE_BLOB_CONTAINER")
@@ -50,15 +54,50 @@ func newAzureBackend(conf map[string]string, logger log.Logger) (Backend, error)
 		}
 	}
 
+	environmentName := os.Getenv("AZURE_ENVIRONMENT")
+	if environmentName == "" {
+		environmentName = conf["environment"]
+		if environmentName == "" {
+			environmentName = "AzurePublicCloud"
+		}
+	}
+	environment, ok := azureEnvironments[environmentName]
+	if !ok {
+		return nil, fmt.Errorf("unknown Azure environment %q", environmentName)
+	}
+
 	accountKey := os.Getenv("AZURE_ACCOUNT_KEY")
 	if accountKey == "" {
 		accountKey = conf["accountKey"]
-		if accountKey == "" {
-			return nil, fmt.Errorf("'accountKey' must be set")
+	}
+
+	// Without an account key, the key is listed with the managed identity
+	// of the virtual machine
+	if accountKey == "" {
+		subscriptionID := os.Getenv("AZURE_SUBSCRIPTION_ID")
+		if subscriptionID == "" {
+			subscriptionID = conf["subscription_id"]
+		}
+		resourceGroup := os.Getenv("AZURE_RESOURCE_GROUP")
+		if resourceGroup == "" {
+			resourceGroup = conf["resource_group"]
+		}
+		if subscriptionID == "" || resourceGroup == "" {
+			return nil, fmt.Errorf("'accountKey', or 'subscription_id' and 'resource_group' to use a managed identity, must be set")
+		}
+		clientID := os.Getenv("AZURE_CLIENT_ID")
+		if clientID == "" {
+			clientID = conf["msi_client_id"]
+		}
+
+		var err error
+		accountKey, err = azureMSIAccountKey(environment, subscriptionID, resourceGroup, accountName, clientID)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to get the account key with the managed identity: %v", err)
 		}
 	}
 
-	client, err := storage.NewBasicClient(accountName, accountKey)
+	client, err := storage.NewClient(accountName, accountKey, environment.storageSuffix, storage.DefaultAPIVersion, true)
 
 	if err != nil {
 		return nil, fmt.Errorf("Failed to create Azure client: %v", err)
@@ -102,10 +141,11 @@ func (a *AzureBackend) Put(entry *Entry) error {
 	a.permitPool.Acquire()
 	defer a.permitPool.Release()
 
-	err := a.client.PutBlock(a.container, entry.Key, blockID, entry.Value)
+	if err := a.client.PutBlock(a.container, entry.Key, blockID, entry.Value); err != nil {
+		return err
+	}
 
-	err = a.client.PutBlockList(a.container, entry.Key, blocks)
-	return err
+	return a.client.PutBlockList(a.container, entry.Key, blocks)
 }
 
 // Get is used to fetch an entry
@@ -176,3 +216,92 @@ func (a *AzureBackend) List(prefix string) ([]string, error) {
 	sort.Strings(keys)
 	return keys, nil
 }
+
+// azureEnvironment holds the endpoints of an Azure cloud
+type azureEnvironment struct {
+	storageSuffix      string
+	resourceManagerURL string
+}
+
+var azureEnvironments = map[string]azureEnvironment{
+	"AzurePublicCloud": {
+		storageSuffix:      "core.windows.net",
+		resourceManagerURL: "https://management.azure.com/",
+	},
+	"AzureChinaCloud": {
+		storageSuffix:      "core.chinacloudapi.cn",
+		resourceManagerURL: "https://management.chinacloudapi.cn/",
+	},
+	"AzureUSGovernmentCloud": {
+		storageSuffix:      "core.usgovcloudapi.net",
+		resourceManagerURL: "https://management.usgovcloudapi.net/",
+	},
+	"AzureGermanCloud": {
+		storageSuffix:      "core.cloudapi.de",
+		resourceManagerURL: "https://management.microsoftazure.de/",
+	},
+}
+
+// azureMSIEndpoint is the endpoint of the instance metadata service issuing
+// tokens for the managed identities of virtual machines
+var azureMSIEndpoint = "http://169.254.169.254/metadata/identity/oauth2/token"
+
+// azureMSIAccountKey gets a token for the resource manager with the managed
+// identity of the virtual machine, and uses it to list the keys of the
+// storage account. The identity needs the permission to list the keys. A
+// clientID selects a user-assigned identity.
+func azureMSIAccountKey(environment azureEnvironment, subscriptionID, resourceGroup, accountName, clientID string) (string, error) {
+	params := url.Values{}
+	params.Set("api-version", "2018-02-01")
+	params.Set("resource", environment.resourceManagerURL)
+	if clientID != "" {
+		params.Set("client_id", clientID)
+	}
+	req, err := http.NewRequest("GET", azureMSIEndpoint+"?"+params.Encode(), nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Metadata", "true")
+
+	var token struct {
+		AccessToken string `json:"access_token"`
+	}
+	if err := azureJSONRequest(req, &token); err != nil {
+		return "", fmt.Errorf("failed to get a token: %v", err)
+	}
+
+	req, err = http.NewRequest("POST", fmt.Sprintf(
+		"%ssubscriptions/%s/resourceGroups/%s/providers/Microsoft.Storage/storageAccounts/%s/listKeys?api-version=2016-12-01",
+		environment.resourceManagerURL, url.QueryEscape(subscriptionID), url.QueryEscape(resourceGroup), url.QueryEscape(accountName)), nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Authorization", "Bearer "+token.AccessToken)
+
+	var keys struct {
+		Keys []struct {
+			Value string `json:"value"`
+		} `json:"keys"`
+	}
+	if err := azureJSONRequest(req, &keys); err != nil {
+		return "", fmt.Errorf("failed to list the keys of the storage account: %v", err)
+	}
+	if len(keys.Keys) == 0 {
+		return "", fmt.Errorf("the storage account has no keys")
+	}
+	return keys.Keys[0].Value, nil
+}
+
+// azureJSONRequest sends a request and decodes its JSON response
+func azureJSONRequest(req *http.Request, out interface{}) error {
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("unexpected response code %d: %s", resp.StatusCode, body)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
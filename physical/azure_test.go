@@ -2,6 +2,8 @@ package physical
 
 import (
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"testing"
 	"time"
@@ -45,3 +47,38 @@ func TestAzureBackend(t *testing.T) {
 	testBackend(t, backend)
 	testBackend_ListPrefix(t, backend)
 }
+
+func TestAzureMSIAccountKey(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/metadata/identity/oauth2/token":
+			if r.Header.Get("Metadata") != "true" || r.URL.Query().Get("client_id") != "client" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			fmt.Fprintf(w, `{"access_token": "token-for-%s"}`, r.URL.Query().Get("resource"))
+		case "/subscriptions/sub/resourceGroups/group/providers/Microsoft.Storage/storageAccounts/account/listKeys":
+			if r.Method != "POST" || r.Header.Get("Authorization") != "Bearer token-for-http://"+r.Host+"/" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			fmt.Fprint(w, `{"keys": [{"keyName": "key1", "value": "secret"}, {"keyName": "key2", "value": "other"}]}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	defer func(endpoint string) { azureMSIEndpoint = endpoint }(azureMSIEndpoint)
+	azureMSIEndpoint = ts.URL + "/metadata/identity/oauth2/token"
+
+	environment := azureEnvironment{resourceManagerURL: ts.URL + "/"}
+	key, err := azureMSIAccountKey(environment, "sub", "group", "account", "client")
+	if err != nil || key != "secret" {
+		t.Fatalf("bad: %q %v", key, err)
+	}
+
+	if _, err := azureMSIAccountKey(environment, "sub", "other", "account", "client"); err == nil {
+		t.Fatal("expected an error")
+	}
+}
@@ -3,6 +3,7 @@ package physical
 import (
 	"strings"
 
+	"github.com/armon/go-metrics"
 	"github.com/hashicorp/golang-lru"
 	log "github.com/mgutz/logxi/v1"
 )
@@ -17,27 +18,41 @@ const (
 // Vault are for policy objects so there is a large read reduction
 // by using a simple write-through cache.
 type Cache struct {
-	backend Backend
-	lru     *lru.TwoQueueCache
+	backend    Backend
+	lru        *lru.TwoQueueCache
+	exceptions []string
 }
 
-// NewCache returns a physical cache of the given size.
-// If no size is provided, the default size is used.
-func NewCache(b Backend, size int, logger log.Logger) *Cache {
+// NewCache returns a physical cache of the given size, in number of
+// entries. If no size is provided, the default size is used. The keys
+// under the exception prefixes are never cached, and always read from the
+// backend.
+func NewCache(b Backend, size int, exceptions []string, logger log.Logger) *Cache {
 	if size <= 0 {
 		size = DefaultCacheSize
 	}
 	if logger.IsTrace() {
-		logger.Trace("physical/cache: creating LRU cache", "size", size)
+		logger.Trace("physical/cache: creating LRU cache", "size", size, "exceptions", exceptions)
 	}
 	cache, _ := lru.New2Q(size)
 	c := &Cache{
-		backend: b,
-		lru:     cache,
+		backend:    b,
+		lru:        cache,
+		exceptions: exceptions,
 	}
 	return c
 }
 
+// cacheable returns whether the key can be cached
+func (c *Cache) cacheable(key string) bool {
+	for _, prefix := range c.exceptions {
+		if strings.HasPrefix(key, prefix) {
+			return false
+		}
+	}
+	return true
+}
+
 // Purge is used to clear the cache
 func (c *Cache) Purge() {
 	c.lru.Purge()
@@ -45,19 +60,27 @@ func (c *Cache) Purge() {
 
 func (c *Cache) Put(entry *Entry) error {
 	err := c.backend.Put(entry)
-	c.lru.Add(entry.Key, entry)
+	if c.cacheable(entry.Key) {
+		c.lru.Add(entry.Key, entry)
+	}
 	return err
 }
 
 func (c *Cache) Get(key string) (*Entry, error) {
+	if !c.cacheable(key) {
+		return c.backend.Get(key)
+	}
+
 	// Check the LRU first
 	if raw, ok := c.lru.Get(key); ok {
+		metrics.IncrCounter([]string{"cache", "hit"}, 1)
 		if raw == nil {
 			return nil, nil
 		} else {
 			return raw.(*Entry), nil
 		}
 	}
+	metrics.IncrCounter([]string{"cache", "miss"}, 1)
 
 	// Read from the underlying backend
 	ent, err := c.backend.Get(key)
@@ -82,6 +105,25 @@ func (c *Cache) Delete(key string) error {
 	return err
 }
 
+// Transaction applies the operations to the underlying backend, atomically
+// if it is Transactional
+func (c *Cache) Transaction(txns []*TxnEntry) error {
+	err := ApplyTransaction(c.backend, txns)
+	for _, txn := range txns {
+		if txn.Entry == nil {
+			continue
+		}
+		// Operations of a failed transaction may have been partially
+		// applied and rolled back, so their keys aren't cached
+		if err == nil && txn.Operation == PutOperation && c.cacheable(txn.Entry.Key) {
+			c.lru.Add(txn.Entry.Key, txn.Entry)
+		} else {
+			c.lru.Remove(txn.Entry.Key)
+		}
+	}
+	return err
+}
+
 func (c *Cache) List(prefix string) ([]string, error) {
 	// Always pass-through as this would be difficult to cache.
 	return c.backend.List(prefix)
@@ -11,7 +11,7 @@ func TestCache(t *testing.T) {
 	logger := logformat.NewVaultLogger(log.LevelTrace)
 
 	inm := NewInmem(logger)
-	cache := NewCache(inm, 0, logger)
+	cache := NewCache(inm, 0, nil, logger)
 	testBackend(t, cache)
 	testBackend_ListPrefix(t, cache)
 }
@@ -20,7 +20,7 @@ func TestCache_Purge(t *testing.T) {
 	logger := logformat.NewVaultLogger(log.LevelTrace)
 
 	inm := NewInmem(logger)
-	cache := NewCache(inm, 0, logger)
+	cache := NewCache(inm, 0, nil, logger)
 
 	ent := &Entry{
 		Key:   "foo",
@@ -55,3 +55,37 @@ func TestCache_Purge(t *testing.T) {
 		t.Fatalf("should not have key")
 	}
 }
+
+func TestCache_Exceptions(t *testing.T) {
+	logger := logformat.NewVaultLogger(log.LevelTrace)
+
+	inm := NewInmem(logger)
+	cache := NewCache(inm, 0, []string{"core/audit"}, logger)
+
+	for _, key := range []string{"foo", "core/audit"} {
+		if err := cache.Put(&Entry{Key: key, Value: []byte("bar")}); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if err := inm.Put(&Entry{Key: key, Value: []byte("baz")}); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+	}
+
+	// Cached keys are read from the cache
+	out, err := cache.Get("foo")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out == nil || string(out.Value) != "bar" {
+		t.Fatalf("bad: %#v", out)
+	}
+
+	// Exceptions are always read from the backend
+	out, err = cache.Get("core/audit")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out == nil || string(out.Value) != "baz" {
+		t.Fatalf("bad: %#v", out)
+	}
+}
@@ -0,0 +1,252 @@
+package physical
+
+import (
+	"crypto/tls"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/mgutz/logxi/v1"
+
+	"github.com/armon/go-metrics"
+	"github.com/gocql/gocql"
+	"github.com/hashicorp/vault/helper/certutil"
+	"github.com/hashicorp/vault/helper/tlsutil"
+)
+
+// CassandraBackend is a physical backend that stores data in a Cassandra
+// table. Entries are stored in the partition of their parent path, along
+// with empty rows in the partitions of all the ancestors of their parent
+// path, so that listing a prefix reads a single partition.
+type CassandraBackend struct {
+	session *gocql.Session
+	table   string
+	logger  log.Logger
+}
+
+// newCassandraBackend constructs a Cassandra backend using a pre-existing
+// keyspace and table.
+func newCassandraBackend(conf map[string]string, logger log.Logger) (Backend, error) {
+	splitArray := func(v string) []string {
+		return strings.FieldsFunc(v, func(r rune) bool {
+			return r == ','
+		})
+	}
+
+	var (
+		hosts        = splitArray(conf["hosts"])
+		port         = 9042
+		keyspace     = conf["keyspace"]
+		table        = conf["table"]
+		consistency  = gocql.LocalQuorum
+		protoVersion = 2
+	)
+
+	if len(hosts) == 0 {
+		hosts = []string{"localhost"}
+	}
+	if keyspace == "" {
+		keyspace = "vault"
+	}
+	if table == "" {
+		table = "entries"
+	}
+	if cs, ok := conf["consistency"]; ok {
+		var err error
+		consistency, err = gocql.ParseConsistencyWrapper(cs)
+		if err != nil {
+			return nil, fmt.Errorf("invalid consistency: %s", cs)
+		}
+	}
+	if portStr, ok := conf["port"]; ok {
+		var err error
+		port, err = strconv.Atoi(portStr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid port: %s", portStr)
+		}
+	}
+	if versionStr, ok := conf["protocol_version"]; ok {
+		var err error
+		protoVersion, err = strconv.Atoi(versionStr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid protocol_version: %s", versionStr)
+		}
+	}
+
+	cluster := gocql.NewCluster(hosts...)
+	cluster.Port = port
+	cluster.Keyspace = keyspace
+	cluster.Consistency = consistency
+	cluster.ProtoVersion = protoVersion
+
+	if timeoutStr, ok := conf["connection_timeout"]; ok {
+		timeout, err := strconv.Atoi(timeoutStr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid connection_timeout: %s", timeoutStr)
+		}
+		cluster.Timeout = time.Duration(timeout) * time.Second
+	}
+
+	if username, ok := conf["username"]; ok {
+		cluster.Authenticator = gocql.PasswordAuthenticator{
+			Username: username,
+			Password: conf["password"],
+		}
+	}
+
+	if tlsEnabled, _ := strconv.ParseBool(conf["tls"]); tlsEnabled {
+		sslOpts, err := cassandraSSLOptions(conf)
+		if err != nil {
+			return nil, err
+		}
+		cluster.SslOpts = sslOpts
+	}
+
+	session, err := cluster.CreateSession()
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to cassandra: %v", err)
+	}
+
+	return &CassandraBackend{
+		session: session,
+		table:   table,
+		logger:  logger,
+	}, nil
+}
+
+// cassandraSSLOptions returns the TLS options of the connections, with the
+// certificates of a PEM bundle file
+func cassandraSSLOptions(conf map[string]string) (*gocql.SslOptions, error) {
+	skipVerify, _ := strconv.ParseBool(conf["tls_skip_verify"])
+
+	// gocql disables the verification of certificates unless host
+	// verification is enabled
+	sslOpts := &gocql.SslOptions{
+		EnableHostVerification: !skipVerify,
+	}
+	sslOpts.MinVersion = tls.VersionTLS12
+
+	if pemBundleFile := conf["pem_bundle_file"]; pemBundleFile != "" {
+		pemBundle, err := ioutil.ReadFile(pemBundleFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read pem_bundle_file: %v", err)
+		}
+
+		parsedCertBundle, err := certutil.ParsePEMBundle(string(pemBundle))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse pem_bundle_file: %v", err)
+		}
+
+		tlsConfig, err := parsedCertBundle.GetTLSConfig(certutil.TLSClient)
+		if err != nil || tlsConfig == nil {
+			return nil, fmt.Errorf("failed to get TLS configuration: tlsConfig:%#v err:%v", tlsConfig, err)
+		}
+		sslOpts.Certificates = tlsConfig.Certificates
+		sslOpts.RootCAs = tlsConfig.RootCAs
+	}
+
+	if tlsMinVersion := conf["tls_min_version"]; tlsMinVersion != "" {
+		var ok bool
+		sslOpts.MinVersion, ok = tlsutil.TLSLookup[tlsMinVersion]
+		if !ok {
+			return nil, fmt.Errorf("invalid 'tls_min_version' in config")
+		}
+	}
+	return sslOpts, nil
+}
+
+// cassandraBuckets returns the partitions in which a key is stored, the
+// first one being its parent path
+func cassandraBuckets(key string) []string {
+	var buckets []string
+	for i := strings.LastIndex(key, "/"); i != -1; i = strings.LastIndex(key[:i], "/") {
+		buckets = append(buckets, key[:i+1])
+	}
+	return append(buckets, "")
+}
+
+// Put is used to insert or update an entry
+func (c *CassandraBackend) Put(entry *Entry) error {
+	defer metrics.MeasureSince([]string{"cassandra", "put"}, time.Now())
+
+	// Write the entry and its ancestors atomically
+	batch := c.session.NewBatch(gocql.LoggedBatch)
+	for i, bucket := range cassandraBuckets(entry.Key) {
+		var value []byte
+		if i == 0 {
+			value = entry.Value
+		}
+		batch.Query("INSERT INTO "+c.table+" (bucket, key, value) VALUES (?, ?, ?)", bucket, entry.Key, value)
+	}
+	return c.session.ExecuteBatch(batch)
+}
+
+// Get is used to fetch an entry
+func (c *CassandraBackend) Get(key string) (*Entry, error) {
+	defer metrics.MeasureSince([]string{"cassandra", "get"}, time.Now())
+
+	var value []byte
+	err := c.session.Query("SELECT value FROM "+c.table+" WHERE bucket = ? AND key = ? LIMIT 1",
+		cassandraBuckets(key)[0], key).Scan(&value)
+	if err == gocql.ErrNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &Entry{
+		Key:   key,
+		Value: value,
+	}, nil
+}
+
+// Delete is used to permanently delete an entry
+func (c *CassandraBackend) Delete(key string) error {
+	defer metrics.MeasureSince([]string{"cassandra", "delete"}, time.Now())
+
+	batch := c.session.NewBatch(gocql.LoggedBatch)
+	for _, bucket := range cassandraBuckets(key) {
+		batch.Query("DELETE FROM "+c.table+" WHERE bucket = ? AND key = ?", bucket, key)
+	}
+	return c.session.ExecuteBatch(batch)
+}
+
+// List is used to list all the keys under a given
+// prefix, up to the next prefix.
+func (c *CassandraBackend) List(prefix string) ([]string, error) {
+	defer metrics.MeasureSince([]string{"cassandra", "list"}, time.Now())
+
+	// Only whole path segments are buckets: keys listed under a partial
+	// segment are filtered
+	bucket := prefix
+	if i := strings.LastIndex(prefix, "/"); i != len(prefix)-1 {
+		bucket = prefix[:i+1]
+	}
+
+	iter := c.session.Query("SELECT key FROM "+c.table+" WHERE bucket = ?", bucket).Iter()
+	keys := []string{}
+	var key string
+	for iter.Scan(&key) {
+		if !strings.HasPrefix(key, prefix) {
+			continue
+		}
+		key = strings.TrimPrefix(key, prefix)
+		if i := strings.Index(key, "/"); i == -1 {
+			// Add objects only from the current 'folder'
+			keys = append(keys, key)
+		} else {
+			// Add truncated 'folder' paths
+			keys = appendIfMissing(keys, key[:i+1])
+		}
+	}
+	if err := iter.Close(); err != nil {
+		return nil, err
+	}
+
+	sort.Strings(keys)
+	return keys, nil
+}
@@ -0,0 +1,79 @@
+package physical
+
+import (
+	"crypto/tls"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/vault/helper/logformat"
+	log "github.com/mgutz/logxi/v1"
+)
+
+func TestCassandraBackend(t *testing.T) {
+	hosts := os.Getenv("CASSANDRA_HOSTS")
+	if hosts == "" {
+		t.SkipNow()
+	}
+
+	// Run vault tests
+	logger := logformat.NewVaultLogger(log.LevelTrace)
+
+	b, err := NewBackend("cassandra", logger, map[string]string{
+		"hosts":       hosts,
+		"consistency": "ONE",
+	})
+	if err != nil {
+		t.Fatalf("Failed to create new backend: %v", err)
+	}
+
+	defer func() {
+		c := b.(*CassandraBackend)
+		if err := c.session.Query("TRUNCATE " + c.table).Exec(); err != nil {
+			t.Fatalf("Failed to truncate table: %v", err)
+		}
+	}()
+
+	testBackend(t, b)
+	testBackend_ListPrefix(t, b)
+}
+
+func TestCassandraBuckets(t *testing.T) {
+	expected := map[string][]string{
+		"":        {""},
+		"a":       {""},
+		"a/b":     {"a/", ""},
+		"a/b/c/d": {"a/b/c/", "a/b/", "a/", ""},
+		"a/b/":    {"a/b/", "a/", ""},
+	}
+	for key, buckets := range expected {
+		if actual := cassandraBuckets(key); !reflect.DeepEqual(actual, buckets) {
+			t.Fatalf("bad: %q: %#v", key, actual)
+		}
+	}
+}
+
+func TestCassandraSSLOptions(t *testing.T) {
+	sslOpts, err := cassandraSSLOptions(map[string]string{})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !sslOpts.EnableHostVerification || sslOpts.MinVersion != tls.VersionTLS12 {
+		t.Fatalf("bad: %#v", sslOpts)
+	}
+
+	sslOpts, err = cassandraSSLOptions(map[string]string{
+		"tls_skip_verify": "true",
+		"tls_min_version": "tls11",
+	})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if sslOpts.EnableHostVerification || sslOpts.MinVersion != tls.VersionTLS11 {
+		t.Fatalf("bad: %#v", sslOpts)
+	}
+
+	if _, err := cassandraSSLOptions(map[string]string{"tls_min_version": "ssl3"}); err == nil {
+		t.Fatal("expected error")
+	}
+}
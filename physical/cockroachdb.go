@@ -0,0 +1,257 @@
+package physical
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/mgutz/logxi/v1"
+
+	"github.com/armon/go-metrics"
+	"github.com/hashicorp/errwrap"
+	"github.com/lib/pq"
+)
+
+// cockroachDBRetryError is the SQLSTATE code of the errors of transactions
+// that CockroachDB couldn't serialize and that should be retried
+const cockroachDBRetryError = "40001"
+
+// CockroachDBBackend is a physical backend that stores data
+// within a CockroachDB database.
+type CockroachDBBackend struct {
+	table      string
+	client     *sql.DB
+	statements map[string]*sql.Stmt
+	permitPool *PermitPool
+	logger     log.Logger
+}
+
+// newCockroachDBBackend constructs a CockroachDB backend using the given
+// connection URL, creating its table if it doesn't exist.
+func newCockroachDBBackend(conf map[string]string, logger log.Logger) (Backend, error) {
+	connURL, ok := conf["connection_url"]
+	if !ok || connURL == "" {
+		return nil, fmt.Errorf("missing connection_url")
+	}
+
+	dbTable, ok := conf["table"]
+	if !ok {
+		dbTable = "vault_kv_store"
+	}
+	quotedTable := pq.QuoteIdentifier(dbTable)
+
+	maxParStr, ok := conf["max_parallel"]
+	var maxParInt int
+	if ok {
+		var err error
+		maxParInt, err = strconv.Atoi(maxParStr)
+		if err != nil {
+			return nil, errwrap.Wrapf("failed parsing max_parallel parameter: {{err}}", err)
+		}
+		if logger.IsDebug() {
+			logger.Debug("physical/cockroachdb: max_parallel set", "max_parallel", maxParInt)
+		}
+	}
+
+	// CockroachDB speaks the PostgreSQL wire protocol
+	db, err := sql.Open("postgres", connURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to cockroachdb: %v", err)
+	}
+
+	// Create the required table if it doesn't exists.
+	createQuery := "CREATE TABLE IF NOT EXISTS " + quotedTable +
+		" (path STRING, value BYTES, PRIMARY KEY (path))"
+	if _, err := db.Exec(createQuery); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create cockroachdb table: %v", err)
+	}
+
+	c := &CockroachDBBackend{
+		table:      quotedTable,
+		client:     db,
+		statements: make(map[string]*sql.Stmt),
+		permitPool: NewPermitPool(maxParInt),
+		logger:     logger,
+	}
+
+	// Prepare all the statements required
+	statements := map[string]string{
+		"put":    "UPSERT INTO " + quotedTable + " VALUES($1, $2)",
+		"get":    "SELECT value FROM " + quotedTable + " WHERE path = $1",
+		"delete": "DELETE FROM " + quotedTable + " WHERE path = $1",
+		"list":   "SELECT path FROM " + quotedTable + " WHERE path LIKE $1",
+	}
+	for name, query := range statements {
+		if err := c.prepare(name, query); err != nil {
+			db.Close()
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// prepare is a helper to prepare a query for future execution
+func (c *CockroachDBBackend) prepare(name, query string) error {
+	stmt, err := c.client.Prepare(query)
+	if err != nil {
+		return fmt.Errorf("failed to prepare '%s': %v", name, err)
+	}
+	c.statements[name] = stmt
+	return nil
+}
+
+// Put is used to insert or update an entry.
+func (c *CockroachDBBackend) Put(entry *Entry) error {
+	defer metrics.MeasureSince([]string{"cockroachdb", "put"}, time.Now())
+
+	c.permitPool.Acquire()
+	defer c.permitPool.Release()
+
+	return cockroachDBExecuteTx(c.client, func(tx *sql.Tx) error {
+		_, err := tx.Stmt(c.statements["put"]).Exec(entry.Key, entry.Value)
+		return err
+	})
+}
+
+// Get is used to fetch and entry.
+func (c *CockroachDBBackend) Get(key string) (*Entry, error) {
+	defer metrics.MeasureSince([]string{"cockroachdb", "get"}, time.Now())
+
+	c.permitPool.Acquire()
+	defer c.permitPool.Release()
+
+	var result []byte
+	err := c.statements["get"].QueryRow(key).Scan(&result)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	ent := &Entry{
+		Key:   key,
+		Value: result,
+	}
+	return ent, nil
+}
+
+// Delete is used to permanently delete an entry
+func (c *CockroachDBBackend) Delete(key string) error {
+	defer metrics.MeasureSince([]string{"cockroachdb", "delete"}, time.Now())
+
+	c.permitPool.Acquire()
+	defer c.permitPool.Release()
+
+	return cockroachDBExecuteTx(c.client, func(tx *sql.Tx) error {
+		_, err := tx.Stmt(c.statements["delete"]).Exec(key)
+		return err
+	})
+}
+
+// Transaction is used to apply several operations atomically, in a single
+// SQL transaction
+func (c *CockroachDBBackend) Transaction(txns []*TxnEntry) error {
+	defer metrics.MeasureSince([]string{"cockroachdb", "transaction"}, time.Now())
+	if err := validateTransaction(txns); err != nil {
+		return err
+	}
+
+	c.permitPool.Acquire()
+	defer c.permitPool.Release()
+
+	return cockroachDBExecuteTx(c.client, func(tx *sql.Tx) error {
+		for _, txn := range txns {
+			var err error
+			switch txn.Operation {
+			case PutOperation:
+				_, err = tx.Stmt(c.statements["put"]).Exec(txn.Entry.Key, txn.Entry.Value)
+			case DeleteOperation:
+				_, err = tx.Stmt(c.statements["delete"]).Exec(txn.Entry.Key)
+			}
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// List is used to list all the keys under a given
+// prefix, up to the next prefix.
+func (c *CockroachDBBackend) List(prefix string) ([]string, error) {
+	defer metrics.MeasureSince([]string{"cockroachdb", "list"}, time.Now())
+
+	c.permitPool.Acquire()
+	defer c.permitPool.Release()
+
+	// Escape the wildcards of LIKE in the prefix
+	likePrefix := strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(prefix) + "%"
+	rows, err := c.statements["list"].Query(likePrefix)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keys []string
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err != nil {
+			return nil, fmt.Errorf("failed to scan rows: %v", err)
+		}
+		key = strings.TrimPrefix(key, prefix)
+		if i := strings.Index(key, "/"); i == -1 {
+			// Add objects only from the current 'folder'
+			keys = append(keys, key)
+		} else {
+			// Add truncated 'folder' paths
+			keys = appendIfMissing(keys, key[:i+1])
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.Strings(keys)
+	return keys, nil
+}
+
+// cockroachDBExecuteTx runs fn in a transaction, retrying it when
+// CockroachDB asks to, as described in
+// https://www.cockroachlabs.com/docs/stable/transactions.html#client-side-transaction-retries
+func cockroachDBExecuteTx(db *sql.DB, fn func(*sql.Tx) error) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+
+	// The savepoint tells CockroachDB that the transaction is retried by
+	// the client, rolling back to it keeps the priority of the transaction
+	if _, err := tx.Exec("SAVEPOINT cockroach_restart"); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	for {
+		err = fn(tx)
+		if err == nil {
+			// RELEASE acts like COMMIT, but can fail with a retryable error
+			if _, err = tx.Exec("RELEASE SAVEPOINT cockroach_restart"); err == nil {
+				return tx.Commit()
+			}
+		}
+
+		if pqErr, ok := err.(*pq.Error); !ok || pqErr.Code != cockroachDBRetryError {
+			tx.Rollback()
+			return err
+		}
+		if _, err := tx.Exec("ROLLBACK TO SAVEPOINT cockroach_restart"); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+}
@@ -0,0 +1,167 @@
+package physical
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/vault/helper/logformat"
+	log "github.com/mgutz/logxi/v1"
+
+	"github.com/lib/pq"
+)
+
+func TestCockroachDBBackend(t *testing.T) {
+	connURL := os.Getenv("CR_URL")
+	if connURL == "" {
+		t.SkipNow()
+	}
+
+	table := os.Getenv("CR_TABLE")
+	if table == "" {
+		table = "vault_kv_store"
+	}
+
+	// Run vault tests
+	logger := logformat.NewVaultLogger(log.LevelTrace)
+
+	b, err := NewBackend("cockroachdb", logger, map[string]string{
+		"connection_url": connURL,
+		"table":          table,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create new backend: %v", err)
+	}
+
+	defer func() {
+		cr := b.(*CockroachDBBackend)
+		_, err := cr.client.Exec("TRUNCATE TABLE " + cr.table)
+		if err != nil {
+			t.Fatalf("Failed to drop table: %v", err)
+		}
+	}()
+
+	testBackend(t, b)
+	testBackend_ListPrefix(t, b)
+	testTransactionalBackend(t, b)
+}
+
+// testCockroachDBDriver is a database driver recording the statements it
+// runs, failing those listed in errors once
+type testCockroachDBDriver struct {
+	statements []string
+	errors     map[string]error
+}
+
+func (d *testCockroachDBDriver) Open(name string) (driver.Conn, error) {
+	return &testCockroachDBConn{d}, nil
+}
+
+type testCockroachDBConn struct {
+	driver *testCockroachDBDriver
+}
+
+func (c *testCockroachDBConn) Prepare(query string) (driver.Stmt, error) {
+	return &testCockroachDBStmt{c.driver, query}, nil
+}
+
+func (c *testCockroachDBConn) Close() error { return nil }
+
+func (c *testCockroachDBConn) Begin() (driver.Tx, error) {
+	c.driver.statements = append(c.driver.statements, "BEGIN")
+	return c, nil
+}
+
+func (c *testCockroachDBConn) Commit() error {
+	c.driver.statements = append(c.driver.statements, "COMMIT")
+	return nil
+}
+
+func (c *testCockroachDBConn) Rollback() error {
+	c.driver.statements = append(c.driver.statements, "ROLLBACK")
+	return nil
+}
+
+type testCockroachDBStmt struct {
+	driver *testCockroachDBDriver
+	query  string
+}
+
+func (s *testCockroachDBStmt) Close() error  { return nil }
+func (s *testCockroachDBStmt) NumInput() int { return -1 }
+
+func (s *testCockroachDBStmt) Exec(args []driver.Value) (driver.Result, error) {
+	s.driver.statements = append(s.driver.statements, s.query)
+	if err, ok := s.driver.errors[s.query]; ok {
+		delete(s.driver.errors, s.query)
+		return nil, err
+	}
+	return driver.RowsAffected(1), nil
+}
+
+func (s *testCockroachDBStmt) Query(args []driver.Value) (driver.Rows, error) {
+	return nil, errors.New("not supported")
+}
+
+func TestCockroachDBExecuteTx(t *testing.T) {
+	d := &testCockroachDBDriver{}
+	sql.Register("cockroachdb-test", d)
+	db, err := sql.Open("cockroachdb-test", "")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	defer db.Close()
+
+	run := func(errs map[string]error) error {
+		d.statements = nil
+		d.errors = errs
+		return cockroachDBExecuteTx(db, func(tx *sql.Tx) error {
+			_, err := tx.Exec("UPSERT")
+			return err
+		})
+	}
+
+	// Transactions that can't be serialized are retried, whether the
+	// statement or the release of the savepoint fails
+	retry := &pq.Error{Code: cockroachDBRetryError}
+	err = run(map[string]error{
+		"UPSERT":                              retry,
+		"RELEASE SAVEPOINT cockroach_restart": retry,
+	})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	expected := []string{
+		"BEGIN",
+		"SAVEPOINT cockroach_restart",
+		"UPSERT",
+		"ROLLBACK TO SAVEPOINT cockroach_restart",
+		"UPSERT",
+		"RELEASE SAVEPOINT cockroach_restart",
+		"ROLLBACK TO SAVEPOINT cockroach_restart",
+		"UPSERT",
+		"RELEASE SAVEPOINT cockroach_restart",
+		"COMMIT",
+	}
+	if !reflect.DeepEqual(d.statements, expected) {
+		t.Fatalf("bad: %#v", d.statements)
+	}
+
+	// Other errors abort the transaction
+	failure := &pq.Error{Code: "23505"}
+	if err := run(map[string]error{"UPSERT": failure}); err != failure {
+		t.Fatalf("bad: %v", err)
+	}
+	expected = []string{
+		"BEGIN",
+		"SAVEPOINT cockroach_restart",
+		"UPSERT",
+		"ROLLBACK",
+	}
+	if !reflect.DeepEqual(d.statements, expected) {
+		t.Fatalf("bad: %#v", d.statements)
+	}
+}
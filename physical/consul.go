@@ -21,6 +21,7 @@ import (
 	"github.com/hashicorp/consul/lib"
 	"github.com/hashicorp/errwrap"
 	"github.com/hashicorp/go-cleanhttp"
+	"github.com/hashicorp/go-multierror"
 	"github.com/hashicorp/vault/helper/strutil"
 	"github.com/hashicorp/vault/helper/tlsutil"
 )
@@ -47,6 +48,18 @@ const (
 	// reconcileTimeout is how often Vault should query Consul to detect
 	// and fix any state drift.
 	reconcileTimeout = 60 * time.Second
+
+	// ConsulMaxTransactionOps is the maximum number of operations of a
+	// Consul transaction
+	ConsulMaxTransactionOps = 64
+
+	// consulMinSessionTTL is the minimum TTL of a session accepted by Consul
+	consulMinSessionTTL = 10 * time.Second
+
+	// consulConsistencyDefault and consulConsistencyStrong are the
+	// consistency modes of the reads
+	consulConsistencyDefault = "default"
+	consulConsistencyStrong  = "strong"
 )
 
 type notifyEvent struct{}
@@ -65,8 +78,12 @@ type ConsulBackend struct {
 	redirectPort        int64
 	serviceName         string
 	serviceTags         []string
+	serviceAddress      *string
 	disableRegistration bool
 	checkTimeout        time.Duration
+	consistencyMode     string
+	sessionTTL          string
+	lockWaitTime        time.Duration
 
 	notifyActiveCh chan notifyEvent
 	notifySealedCh chan notifyEvent
@@ -124,6 +141,16 @@ func newConsulBackend(conf map[string]string, logger log.Logger) (Backend, error
 		logger.Debug("physical/consul: config service_tags set", "service_tags", tags)
 	}
 
+	// Get the address to register the service with, which defaults to the
+	// redirect address. An empty address lets Consul use the agent's one.
+	var serviceAddr *string
+	if serviceAddrStr, ok := conf["service_address"]; ok {
+		serviceAddr = &serviceAddrStr
+		if logger.IsDebug() {
+			logger.Debug("physical/consul: config service_address set", "service_address", serviceAddrStr)
+		}
+	}
+
 	checkTimeout := defaultCheckTimeout
 	checkTimeoutStr, ok := conf["check_timeout"]
 	if ok {
@@ -143,6 +170,49 @@ func newConsulBackend(conf map[string]string, logger log.Logger) (Backend, error
 		}
 	}
 
+	consistencyMode, ok := conf["consistency_mode"]
+	if ok {
+		switch consistencyMode {
+		case consulConsistencyDefault, consulConsistencyStrong:
+		default:
+			return nil, fmt.Errorf("invalid consistency_mode value: %q", consistencyMode)
+		}
+		if logger.IsDebug() {
+			logger.Debug("physical/consul: config consistency_mode set", "consistency_mode", consistencyMode)
+		}
+	} else {
+		consistencyMode = consulConsistencyDefault
+	}
+
+	sessionTTL := api.DefaultLockSessionTTL
+	if sessionTTLStr, ok := conf["session_ttl"]; ok {
+		d, err := time.ParseDuration(sessionTTLStr)
+		if err != nil {
+			return nil, errwrap.Wrapf("failed parsing session_ttl parameter: {{err}}", err)
+		}
+		if d < consulMinSessionTTL {
+			return nil, fmt.Errorf("Consul session_ttl must be at least %v", consulMinSessionTTL)
+		}
+
+		sessionTTL = d.String()
+		if logger.IsDebug() {
+			logger.Debug("physical/consul: config session_ttl set", "session_ttl", sessionTTL)
+		}
+	}
+
+	lockWaitTime := api.DefaultLockWaitTime
+	if lockWaitTimeStr, ok := conf["lock_wait_time"]; ok {
+		d, err := time.ParseDuration(lockWaitTimeStr)
+		if err != nil {
+			return nil, errwrap.Wrapf("failed parsing lock_wait_time parameter: {{err}}", err)
+		}
+
+		lockWaitTime = d
+		if logger.IsDebug() {
+			logger.Debug("physical/consul: config lock_wait_time set", "lock_wait_time", lockWaitTime)
+		}
+	}
+
 	// Configure the client
 	consulConf := api.DefaultConfig()
 
@@ -202,8 +272,12 @@ func newConsulBackend(conf map[string]string, logger log.Logger) (Backend, error
 		permitPool:          NewPermitPool(maxParInt),
 		serviceName:         service,
 		serviceTags:         strutil.ParseDedupAndSortStrings(tags, ","),
+		serviceAddress:      serviceAddr,
 		checkTimeout:        checkTimeout,
 		disableRegistration: disableRegistration,
+		consistencyMode:     consistencyMode,
+		sessionTTL:          sessionTTL,
+		lockWaitTime:        lockWaitTime,
 	}
 	return c, nil
 }
@@ -285,7 +359,7 @@ func (c *ConsulBackend) Get(key string) (*Entry, error) {
 	c.permitPool.Acquire()
 	defer c.permitPool.Release()
 
-	pair, _, err := c.kv.Get(c.path+key, nil)
+	pair, _, err := c.kv.Get(c.path+key, c.queryOptions())
 	if err != nil {
 		return nil, err
 	}
@@ -299,6 +373,15 @@ func (c *ConsulBackend) Get(key string) (*Entry, error) {
 	return ent, nil
 }
 
+// queryOptions returns the options of the reads, according to the
+// consistency mode
+func (c *ConsulBackend) queryOptions() *api.QueryOptions {
+	if c.consistencyMode == consulConsistencyStrong {
+		return &api.QueryOptions{RequireConsistent: true}
+	}
+	return nil
+}
+
 // Delete is used to permanently delete an entry
 func (c *ConsulBackend) Delete(key string) error {
 	defer metrics.MeasureSince([]string{"consul", "delete"}, time.Now())
@@ -310,6 +393,49 @@ func (c *ConsulBackend) Delete(key string) error {
 	return err
 }
 
+// Transaction is used to apply several operations atomically, in a single
+// Consul transaction
+func (c *ConsulBackend) Transaction(txns []*TxnEntry) error {
+	defer metrics.MeasureSince([]string{"consul", "transaction"}, time.Now())
+	if err := validateTransaction(txns); err != nil {
+		return err
+	}
+	if len(txns) > ConsulMaxTransactionOps {
+		return fmt.Errorf("transactions are limited to %d operations in Consul", ConsulMaxTransactionOps)
+	}
+
+	ops := make(api.KVTxnOps, 0, len(txns))
+	for _, txn := range txns {
+		op := &api.KVTxnOp{
+			Key: c.path + txn.Entry.Key,
+		}
+		switch txn.Operation {
+		case PutOperation:
+			op.Verb = string(api.KVSet)
+			op.Value = txn.Entry.Value
+		case DeleteOperation:
+			op.Verb = api.KVDelete
+		}
+		ops = append(ops, op)
+	}
+
+	c.permitPool.Acquire()
+	defer c.permitPool.Release()
+
+	ok, resp, _, err := c.kv.Txn(ops, nil)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		var retErr error
+		for _, txnErr := range resp.Errors {
+			retErr = multierror.Append(retErr, fmt.Errorf("operation %d: %s", txnErr.OpIndex, txnErr.What))
+		}
+		return errwrap.Wrapf("failed to apply transaction: {{err}}", retErr)
+	}
+	return nil
+}
+
 // List is used to list all the keys under a given
 // prefix, up to the next prefix.
 func (c *ConsulBackend) List(prefix string) ([]string, error) {
@@ -326,7 +452,7 @@ func (c *ConsulBackend) List(prefix string) ([]string, error) {
 	c.permitPool.Acquire()
 	defer c.permitPool.Release()
 
-	out, _, err := c.kv.Keys(scan, "/", nil)
+	out, _, err := c.kv.Keys(scan, "/", c.queryOptions())
 	for idx, val := range out {
 		out[idx] = strings.TrimPrefix(val, scan)
 	}
@@ -341,7 +467,9 @@ func (c *ConsulBackend) LockWith(key, value string) (Lock, error) {
 		Key:            c.path + key,
 		Value:          []byte(value),
 		SessionName:    "Vault Lock",
+		SessionTTL:     c.sessionTTL,
 		MonitorRetries: 5,
+		LockWaitTime:   c.lockWaitTime,
 	}
 	lock, err := c.client.LockOpts(opts)
 	if err != nil {
@@ -604,12 +732,17 @@ func (c *ConsulBackend) reconcileConsul(registeredServiceID string, activeFunc a
 		return serviceID, nil
 	}
 
+	serviceAddress := c.redirectHost
+	if c.serviceAddress != nil {
+		serviceAddress = *c.serviceAddress
+	}
+
 	service := &api.AgentServiceRegistration{
 		ID:                serviceID,
 		Name:              c.serviceName,
 		Tags:              tags,
 		Port:              int(c.redirectPort),
-		Address:           c.redirectHost,
+		Address:           serviceAddress,
 		EnableTagOverride: false,
 	}
 
@@ -130,6 +130,9 @@ func TestConsul_newConsulBackend(t *testing.T) {
 		token        string
 		max_parallel int
 		disableReg   bool
+		consistency  string
+		sessionTTL   string
+		lockWaitTime time.Duration
 	}{
 		{
 			name:         "Valid default config",
@@ -143,6 +146,9 @@ func TestConsul_newConsulBackend(t *testing.T) {
 			token:        "",
 			max_parallel: 4,
 			disableReg:   false,
+			consistency:  "default",
+			sessionTTL:   "15s",
+			lockWaitTime: 15 * time.Second,
 		},
 		{
 			name: "Valid modified config",
@@ -156,6 +162,9 @@ func TestConsul_newConsulBackend(t *testing.T) {
 				"token":                "deadbeef-cafeefac-deadc0de-feedface",
 				"max_parallel":         "4",
 				"disable_registration": "false",
+				"consistency_mode":     "strong",
+				"session_ttl":          "1m",
+				"lock_wait_time":       "20s",
 			},
 			checkTimeout: 6 * time.Second,
 			path:         "seaTech/",
@@ -165,6 +174,9 @@ func TestConsul_newConsulBackend(t *testing.T) {
 			scheme:       "https",
 			token:        "deadbeef-cafeefac-deadc0de-feedface",
 			max_parallel: 4,
+			consistency:  "strong",
+			sessionTTL:   "1m0s",
+			lockWaitTime: 20 * time.Second,
 		},
 		{
 			name: "check timeout too short",
@@ -173,6 +185,27 @@ func TestConsul_newConsulBackend(t *testing.T) {
 				"check_timeout": "99ms",
 			},
 		},
+		{
+			name: "invalid consistency mode",
+			fail: true,
+			consulConfig: map[string]string{
+				"consistency_mode": "stale",
+			},
+		},
+		{
+			name: "session TTL too short",
+			fail: true,
+			consulConfig: map[string]string{
+				"session_ttl": "5s",
+			},
+		},
+		{
+			name: "invalid lock wait time",
+			fail: true,
+			consulConfig: map[string]string{
+				"lock_wait_time": "soon",
+			},
+		},
 	}
 
 	for _, test := range tests {
@@ -220,6 +253,18 @@ func TestConsul_newConsulBackend(t *testing.T) {
 			t.Errorf("bad: %v != %v", test.service, c.serviceName)
 		}
 
+		if test.consistency != c.consistencyMode {
+			t.Errorf("bad: %v != %v", test.consistency, c.consistencyMode)
+		}
+
+		if test.sessionTTL != c.sessionTTL {
+			t.Errorf("bad: %v != %v", test.sessionTTL, c.sessionTTL)
+		}
+
+		if test.lockWaitTime != c.lockWaitTime {
+			t.Errorf("bad: %v != %v", test.lockWaitTime, c.lockWaitTime)
+		}
+
 		// FIXME(sean@): Unable to test max_parallel
 		// if test.max_parallel != cap(c.permitPool) {
 		// 	t.Errorf("bad: %v != %v", test.max_parallel, cap(c.permitPool))
@@ -415,6 +460,7 @@ func TestConsulBackend(t *testing.T) {
 
 	testBackend(t, b)
 	testBackend_ListPrefix(t, b)
+	testTransactionalBackend(t, b)
 }
 
 func TestConsulHABackend(t *testing.T) {
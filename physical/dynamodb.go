@@ -19,6 +19,7 @@ import (
 	"github.com/aws/aws-sdk-go/aws/credentials"
 	"github.com/aws/aws-sdk-go/aws/credentials/ec2rolecreds"
 	"github.com/aws/aws-sdk-go/aws/ec2metadata"
+	"github.com/aws/aws-sdk-go/aws/request"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/dynamodb"
 	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
@@ -51,6 +52,13 @@ const (
 	// DynamoDBLockRetryInterval is the amount of time to wait
 	// if a lock fails before trying again.
 	DynamoDBLockRetryInterval = time.Second
+	// DynamoDBLockTTL is the default amount of time a lock record
+	// is valid for if it isn't renewed, so that the lock of a
+	// crashed leader is eventually released.
+	DynamoDBLockTTL = 15 * time.Second
+	// DynamoDBLockRenewInterval is the default amount of time to
+	// wait between renewals of a held lock record.
+	DynamoDBLockRenewInterval = 5 * time.Second
 	// DynamoDBWatchRetryMax is the number of times to re-try a
 	// failed watch before signaling that leadership is lost.
 	DynamoDBWatchRetryMax = 5
@@ -69,6 +77,9 @@ type DynamoDBBackend struct {
 	logger     log.Logger
 	haEnabled  bool
 	permitPool *PermitPool
+
+	lockTTL           time.Duration
+	lockRenewInterval time.Duration
 }
 
 // DynamoDBRecord is the representation of a vault entry in
@@ -80,6 +91,18 @@ type DynamoDBRecord struct {
 	Value []byte
 }
 
+// DynamoDBLockRecord is the representation of a lock in
+// DynamoDB. Expires is the time, in nanoseconds since the
+// epoch, after which the lock can be taken over by another
+// node. Lock records written by previous versions of Vault
+// don't have it, and never expire.
+type DynamoDBLockRecord struct {
+	Path    string
+	Key     string
+	Value   []byte
+	Expires int64
+}
+
 // DynamoDBLock implements a lock using an DynamoDB client.
 type DynamoDBLock struct {
 	backend    *DynamoDBBackend
@@ -87,6 +110,12 @@ type DynamoDBLock struct {
 	held       bool
 	lock       sync.Mutex
 	recovery   bool
+
+	// ttl is the validity of the lock record, renewed every
+	// renewInterval while the lock is held
+	ttl           time.Duration
+	renewInterval time.Duration
+	stopRenew     chan struct{}
 }
 
 // newDynamoDBBackend constructs a DynamoDB backend. If the
@@ -171,7 +200,27 @@ func newDynamoDBBackend(conf map[string]string, logger log.Logger) (Backend, err
 		WithEndpoint(endpoint)
 	client := dynamodb.New(session.New(awsConf))
 
-	if err := ensureTableExists(client, table, readCapacity, writeCapacity); err != nil {
+	sseString := os.Getenv("AWS_DYNAMODB_SERVER_SIDE_ENCRYPTION")
+	if sseString == "" {
+		sseString = conf["server_side_encryption"]
+	}
+	var sse *dynamoDBSSESpecification
+	if sseString != "" {
+		enabled, err := strconv.ParseBool(sseString)
+		if err != nil {
+			return nil, fmt.Errorf("invalid server_side_encryption: %s", sseString)
+		}
+		sse = &dynamoDBSSESpecification{Enabled: aws.Bool(enabled)}
+	}
+	if kmsKeyID := conf["kms_key_id"]; kmsKeyID != "" {
+		if sse == nil || !*sse.Enabled {
+			return nil, fmt.Errorf("kms_key_id requires server_side_encryption to be enabled")
+		}
+		sse.SSEType = aws.String("KMS")
+		sse.KMSMasterKeyId = aws.String(kmsKeyID)
+	}
+
+	if err := ensureTableExists(client, table, readCapacity, writeCapacity, sse); err != nil {
 		return nil, err
 	}
 
@@ -187,6 +236,11 @@ func newDynamoDBBackend(conf map[string]string, logger log.Logger) (Backend, err
 	}
 	recoveryModeBool, _ := strconv.ParseBool(recoveryMode)
 
+	lockTTL, lockRenewInterval, err := parseHALockTimings(conf, DynamoDBLockTTL, DynamoDBLockRenewInterval)
+	if err != nil {
+		return nil, err
+	}
+
 	maxParStr, ok := conf["max_parallel"]
 	var maxParInt int
 	if ok {
@@ -206,6 +260,9 @@ func newDynamoDBBackend(conf map[string]string, logger log.Logger) (Backend, err
 		recovery:   recoveryModeBool,
 		haEnabled:  haEnabledBool,
 		logger:     logger,
+
+		lockTTL:           lockTTL,
+		lockRenewInterval: lockRenewInterval,
 	}, nil
 }
 
@@ -361,10 +418,12 @@ func (d *DynamoDBBackend) List(prefix string) ([]string, error) {
 // LockWith is used for mutual exclusion based on the given key.
 func (d *DynamoDBBackend) LockWith(key, value string) (Lock, error) {
 	return &DynamoDBLock{
-		backend:  d,
-		key:      filepath.Join(filepath.Dir(key), DynamoDBLockPrefix+filepath.Base(key)),
-		value:    value,
-		recovery: d.recovery,
+		backend:       d,
+		key:           filepath.Join(filepath.Dir(key), DynamoDBLockPrefix+filepath.Base(key)),
+		value:         value,
+		recovery:      d.recovery,
+		ttl:           d.lockTTL,
+		renewInterval: d.lockRenewInterval,
 	}, nil
 }
 
@@ -426,9 +485,11 @@ func (l *DynamoDBLock) Lock(stopCh <-chan struct{}) (doneCh <-chan struct{}, ret
 	select {
 	case <-success:
 		l.held = true
-		// after acquiring it successfully, we must watch
-		// the lock in order to close the leader channel
-		// once it is lost.
+		// after acquiring it successfully, we must renew the
+		// lock record before it expires, and watch the lock in
+		// order to close the leader channel once it is lost.
+		l.stopRenew = make(chan struct{})
+		go l.periodicallyRenewLock(l.stopRenew)
 		go l.watch(leader)
 	case retErr = <-errors:
 		close(stop)
@@ -451,6 +512,7 @@ func (l *DynamoDBLock) Unlock() error {
 	}
 
 	l.held = false
+	close(l.stopRenew)
 	if err := l.backend.Delete(l.key); err != nil {
 		return err
 	}
@@ -460,15 +522,99 @@ func (l *DynamoDBLock) Unlock() error {
 // Value checks whether or not the lock is held by any instance of DynamoDBLock,
 // including this one, and returns the current value.
 func (l *DynamoDBLock) Value() (bool, string, error) {
-	entry, err := l.backend.Get(l.key)
+	record, err := l.getLockRecord()
 	if err != nil {
 		return false, "", err
 	}
-	if entry == nil {
+	if record == nil || record.expired() {
 		return false, "", nil
 	}
 
-	return true, string(entry.Value), nil
+	return true, string(record.Value), nil
+}
+
+// getLockRecord fetches the lock record from DynamoDB, or nil if
+// there is none.
+func (l *DynamoDBLock) getLockRecord() (*DynamoDBLockRecord, error) {
+	l.backend.permitPool.Acquire()
+	defer l.backend.permitPool.Release()
+
+	resp, err := l.backend.client.GetItem(&dynamodb.GetItemInput{
+		TableName:      aws.String(l.backend.table),
+		ConsistentRead: aws.Bool(true),
+		Key: map[string]*dynamodb.AttributeValue{
+			"Path": {S: aws.String(recordPathForVaultKey(l.key))},
+			"Key":  {S: aws.String(recordKeyForVaultKey(l.key))},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if resp.Item == nil {
+		return nil, nil
+	}
+
+	record := &DynamoDBLockRecord{}
+	if err := dynamodbattribute.ConvertFromMap(resp.Item, record); err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+// expired returns whether the lock record can be taken over by
+// another node. Records without an expiration never expire.
+func (r *DynamoDBLockRecord) expired() bool {
+	return r.Expires != 0 && r.Expires < time.Now().UnixNano()
+}
+
+// writeLockRecord writes the lock record with a new expiration,
+// if the given condition holds.
+func (l *DynamoDBLock) writeLockRecord(condition string, names map[string]*string, values map[string]*dynamodb.AttributeValue) error {
+	record := DynamoDBLockRecord{
+		Path:    recordPathForVaultKey(l.key),
+		Key:     recordKeyForVaultKey(l.key),
+		Value:   []byte(l.value),
+		Expires: time.Now().Add(l.ttl).UnixNano(),
+	}
+	item, err := dynamodbattribute.ConvertToMap(record)
+	if err != nil {
+		return err
+	}
+
+	_, err = l.backend.client.PutItem(&dynamodb.PutItemInput{
+		TableName:                 aws.String(l.backend.table),
+		Item:                      item,
+		ConditionExpression:       aws.String(condition),
+		ExpressionAttributeNames:  names,
+		ExpressionAttributeValues: values,
+	})
+	return err
+}
+
+// periodicallyRenewLock extends the expiration of the lock record
+// every renewInterval, as long as it still holds our value, until
+// the stop channel is closed.
+func (l *DynamoDBLock) periodicallyRenewLock(stop chan struct{}) {
+	ticker := time.NewTicker(l.renewInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			err := l.writeLockRecord("#v = :v",
+				map[string]*string{
+					"#v": aws.String("Value"),
+				},
+				map[string]*dynamodb.AttributeValue{
+					":v": {B: []byte(l.value)},
+				})
+			if err != nil && l.backend.logger.IsWarn() {
+				l.backend.logger.Warn("physical/dynamodb: failed to renew lock", "error", err)
+			}
+		case <-stop:
+			return
+		}
+	}
 }
 
 // tryToLock tries to create a new item in DynamoDB
@@ -481,31 +627,22 @@ func (l *DynamoDBLock) Value() (bool, string, error) {
 func (l *DynamoDBLock) tryToLock(stop, success chan struct{}, errors chan error) {
 	ticker := time.NewTicker(DynamoDBLockRetryInterval)
 
-	record := DynamoDBRecord{
-		Path:  recordPathForVaultKey(l.key),
-		Key:   recordKeyForVaultKey(l.key),
-		Value: []byte(l.value),
-	}
-	item, err := dynamodbattribute.ConvertToMap(record)
-	if err != nil {
-		errors <- err
-		return
-	}
-
 	for {
 		select {
 		case <-stop:
 			ticker.Stop()
 		case <-ticker.C:
-			_, err := l.backend.client.PutItem(&dynamodb.PutItemInput{
-				TableName:           aws.String(l.backend.table),
-				Item:                item,
-				ConditionExpression: aws.String("attribute_not_exists(#p) or attribute_not_exists(#k)"),
-				ExpressionAttributeNames: map[string]*string{
+			// The lock can be taken if there is no lock record, or
+			// if the one there has expired
+			err := l.writeLockRecord("attribute_not_exists(#p) or attribute_not_exists(#k) or #e < :now",
+				map[string]*string{
 					"#p": aws.String("Path"),
 					"#k": aws.String("Key"),
+					"#e": aws.String("Expires"),
 				},
-			})
+				map[string]*dynamodb.AttributeValue{
+					":now": {N: aws.String(strconv.FormatInt(time.Now().UnixNano(), 10))},
+				})
 			if err != nil {
 				if err, ok := err.(awserr.Error); ok && err.Code() != "ConditionalCheckFailedException" {
 					errors <- err
@@ -514,8 +651,8 @@ func (l *DynamoDBLock) tryToLock(stop, success chan struct{}, errors chan error)
 					_, err := l.backend.client.DeleteItem(&dynamodb.DeleteItemInput{
 						TableName: aws.String(l.backend.table),
 						Key: map[string]*dynamodb.AttributeValue{
-							"Path": {S: aws.String(record.Path)},
-							"Key":  {S: aws.String(record.Key)},
+							"Path": {S: aws.String(recordPathForVaultKey(l.key))},
+							"Key":  {S: aws.String(recordKeyForVaultKey(l.key))},
 						},
 					})
 					if err != nil {
@@ -532,7 +669,7 @@ func (l *DynamoDBLock) tryToLock(stop, success chan struct{}, errors chan error)
 	}
 }
 
-// watch checks whether the lock has changed in the
+// watch checks whether the lock has changed or expired in the
 // DynamoDB table and closes the leader channel if so.
 // The interval is set by `DynamoDBWatchRetryInterval`.
 // If an error occurs during the check, watch will retry
@@ -546,7 +683,7 @@ WatchLoop:
 	for {
 		select {
 		case <-ticker.C:
-			item, err := l.backend.Get(l.key)
+			record, err := l.getLockRecord()
 			if err != nil {
 				retries--
 				if retries == 0 {
@@ -555,7 +692,7 @@ WatchLoop:
 				continue
 			}
 
-			if item == nil || string(item.Value) != l.value {
+			if record == nil || string(record.Value) != l.value || record.expired() {
 				break WatchLoop
 			}
 		}
@@ -567,33 +704,13 @@ WatchLoop:
 // ensureTableExists creates a DynamoDB table with a given
 // DynamoDB client. If the table already exists, it is not
 // being reconfigured.
-func ensureTableExists(client *dynamodb.DynamoDB, table string, readCapacity, writeCapacity int) error {
+func ensureTableExists(client *dynamodb.DynamoDB, table string, readCapacity, writeCapacity int, sse *dynamoDBSSESpecification) error {
 	_, err := client.DescribeTable(&dynamodb.DescribeTableInput{
 		TableName: aws.String(table),
 	})
 	if awserr, ok := err.(awserr.Error); ok {
 		if awserr.Code() == "ResourceNotFoundException" {
-			_, err = client.CreateTable(&dynamodb.CreateTableInput{
-				TableName: aws.String(table),
-				ProvisionedThroughput: &dynamodb.ProvisionedThroughput{
-					ReadCapacityUnits:  aws.Int64(int64(readCapacity)),
-					WriteCapacityUnits: aws.Int64(int64(writeCapacity)),
-				},
-				KeySchema: []*dynamodb.KeySchemaElement{{
-					AttributeName: aws.String("Path"),
-					KeyType:       aws.String("HASH"),
-				}, {
-					AttributeName: aws.String("Key"),
-					KeyType:       aws.String("RANGE"),
-				}},
-				AttributeDefinitions: []*dynamodb.AttributeDefinition{{
-					AttributeName: aws.String("Path"),
-					AttributeType: aws.String("S"),
-				}, {
-					AttributeName: aws.String("Key"),
-					AttributeType: aws.String("S"),
-				}},
-			})
+			err = createTableRequest(client, table, readCapacity, writeCapacity, sse).Send()
 			if err != nil {
 				return err
 			}
@@ -612,6 +729,67 @@ func ensureTableExists(client *dynamodb.DynamoDB, table string, readCapacity, wr
 	return nil
 }
 
+// dynamoDBSSESpecification is the server-side encryption setting of a
+// table, which the vendored SDK doesn't know about
+type dynamoDBSSESpecification struct {
+	_ struct{} `type:"structure"`
+
+	Enabled        *bool   `type:"boolean"`
+	SSEType        *string `type:"string"`
+	KMSMasterKeyId *string `type:"string"`
+}
+
+// dynamoDBCreateTableInput is dynamodb.CreateTableInput along with the
+// server-side encryption setting
+type dynamoDBCreateTableInput struct {
+	_ struct{} `type:"structure"`
+
+	AttributeDefinitions  []*dynamodb.AttributeDefinition `type:"list"`
+	KeySchema             []*dynamodb.KeySchemaElement    `min:"1" type:"list"`
+	ProvisionedThroughput *dynamodb.ProvisionedThroughput `type:"structure"`
+	SSESpecification      *dynamoDBSSESpecification       `type:"structure"`
+	TableName             *string                         `min:"3" type:"string"`
+}
+
+// createTableRequest returns the request creating the table of the backend
+func createTableRequest(client *dynamodb.DynamoDB, table string, readCapacity, writeCapacity int, sse *dynamoDBSSESpecification) *request.Request {
+	input := &dynamodb.CreateTableInput{
+		TableName: aws.String(table),
+		ProvisionedThroughput: &dynamodb.ProvisionedThroughput{
+			ReadCapacityUnits:  aws.Int64(int64(readCapacity)),
+			WriteCapacityUnits: aws.Int64(int64(writeCapacity)),
+		},
+		KeySchema: []*dynamodb.KeySchemaElement{{
+			AttributeName: aws.String("Path"),
+			KeyType:       aws.String("HASH"),
+		}, {
+			AttributeName: aws.String("Key"),
+			KeyType:       aws.String("RANGE"),
+		}},
+		AttributeDefinitions: []*dynamodb.AttributeDefinition{{
+			AttributeName: aws.String("Path"),
+			AttributeType: aws.String("S"),
+		}, {
+			AttributeName: aws.String("Key"),
+			AttributeType: aws.String("S"),
+		}},
+	}
+	req, _ := client.CreateTableRequest(input)
+
+	// The SDK builds the request body from its parameters, so swap them for
+	// ones carrying the encryption setting
+	if sse != nil {
+		req.Params = &dynamoDBCreateTableInput{
+			AttributeDefinitions:  input.AttributeDefinitions,
+			KeySchema:             input.KeySchema,
+			ProvisionedThroughput: input.ProvisionedThroughput,
+			SSESpecification:      sse,
+			TableName:             input.TableName,
+		}
+	}
+	return req
+}
+
 // recordPathForVaultKey transforms a vault key into
 // a value suitable for the `DynamoDBRecord`'s `Path`
 // property. This path equals the the vault key without
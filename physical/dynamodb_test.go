@@ -1,9 +1,11 @@
 package physical
 
 import (
+	"encoding/json"
 	"fmt"
 	"math/rand"
 	"os"
+	"reflect"
 	"testing"
 	"time"
 
@@ -116,4 +118,91 @@ func TestDynamoDBHABackend(t *testing.T) {
 		t.Fatalf("dynamodb does not implement HABackend")
 	}
 	testHABackend(t, ha, ha)
+
+	// A lock that isn't renewed anymore, as when its holder crashed, can be
+	// taken over once it expires
+	b, err = NewBackend("dynamodb", logger, map[string]string{
+		"access_key":             creds.AccessKeyID,
+		"secret_key":             creds.SecretAccessKey,
+		"session_token":          creds.SessionToken,
+		"table":                  table,
+		"ha_lock_ttl":            "3s",
+		"ha_lock_renew_interval": "1s",
+	})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	ha = b.(HABackend)
+
+	lock, err := ha.LockWith("expire", "bar")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	leaderCh, err := lock.Lock(nil)
+	if err != nil || leaderCh == nil {
+		t.Fatalf("failed to get leader ch: %v", err)
+	}
+	close(lock.(*DynamoDBLock).stopRenew)
+
+	lock2, err := ha.LockWith("expire", "baz")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	stopCh := make(chan struct{})
+	time.AfterFunc(15*time.Second, func() { close(stopCh) })
+	leaderCh2, err := lock2.Lock(stopCh)
+	if err != nil || leaderCh2 == nil {
+		t.Fatalf("expected the expired lock to be taken over: %v", err)
+	}
+
+	select {
+	case <-leaderCh:
+	case <-time.After(15 * time.Second):
+		t.Fatalf("expected the expired lock to be lost")
+	}
+}
+
+func TestDynamoDBBackend_createTableRequest(t *testing.T) {
+	client := dynamodb.New(session.New(&aws.Config{
+		Credentials: credentials.NewStaticCredentials("foo", "bar", ""),
+		Region:      aws.String("us-east-1"),
+	}))
+
+	body := func(sse *dynamoDBSSESpecification) map[string]interface{} {
+		req := createTableRequest(client, "vault", 5, 10, sse)
+		if err := req.Build(); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		var out map[string]interface{}
+		if err := json.NewDecoder(req.Body).Decode(&out); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		return out
+	}
+
+	out := body(nil)
+	if _, ok := out["SSESpecification"]; ok || out["TableName"] != "vault" {
+		t.Fatalf("bad: %#v", out)
+	}
+
+	out = body(&dynamoDBSSESpecification{
+		Enabled:        aws.Bool(true),
+		SSEType:        aws.String("KMS"),
+		KMSMasterKeyId: aws.String("alias/vault"),
+	})
+	expected := map[string]interface{}{
+		"Enabled":        true,
+		"SSEType":        "KMS",
+		"KMSMasterKeyId": "alias/vault",
+	}
+	if !reflect.DeepEqual(out["SSESpecification"], expected) {
+		t.Fatalf("bad: %#v", out)
+	}
+	throughput := out["ProvisionedThroughput"].(map[string]interface{})
+	if out["TableName"] != "vault" || throughput["ReadCapacityUnits"] != 5.0 || throughput["WriteCapacityUnits"] != 10.0 {
+		t.Fatalf("bad: %#v", out)
+	}
+	if len(out["KeySchema"].([]interface{})) != 2 {
+		t.Fatalf("bad: %#v", out)
+	}
 }
@@ -32,10 +32,11 @@ const (
 	// The delimiter is the same as the `-C` flag of etcdctl.
 	EtcdMachineDelimiter = ","
 
-	// The lock TTL matches the default that Consul API uses, 15 seconds.
+	// The default lock TTL matches the default that Consul API uses, 15
+	// seconds.
 	EtcdLockTTL = 15 * time.Second
 
-	// The amount of time to wait between the semaphore key renewals
+	// The default amount of time to wait between the semaphore key renewals
 	EtcdLockRenewInterval = 5 * time.Second
 
 	// The amount of time to wait if a watch fails before trying again.
@@ -70,10 +71,22 @@ type EtcdBackend struct {
 	kAPI       client.KeysAPI
 	permitPool *PermitPool
 	logger     log.Logger
+
+	lockTTL           time.Duration
+	lockRenewInterval time.Duration
 }
 
 // newEtcdBackend constructs a etcd backend using a given machine address.
 func newEtcdBackend(conf map[string]string, logger log.Logger) (Backend, error) {
+	// The v2 API is used unless the v3 API is requested
+	switch conf["etcd_api"] {
+	case "", "2", "v2":
+	case "3", "v3":
+		return newEtcd3Backend(conf, logger)
+	default:
+		return nil, fmt.Errorf("unsupported etcd_api: %q", conf["etcd_api"])
+	}
+
 	// Get the etcd path form the configuration.
 	path, ok := conf["path"]
 	if !ok {
@@ -173,14 +186,21 @@ func newEtcdBackend(conf map[string]string, logger log.Logger) (Backend, error)
 		return nil, fmt.Errorf("value of 'sync' could not be understood")
 	}
 
+	lockTTL, lockRenewInterval, err := parseHALockTimings(conf, EtcdLockTTL, EtcdLockRenewInterval)
+	if err != nil {
+		return nil, err
+	}
+
 	kAPI := client.NewKeysAPI(c)
 
 	// Setup the backend.
 	return &EtcdBackend{
-		path:       path,
-		kAPI:       kAPI,
-		permitPool: NewPermitPool(DefaultParallelOperations),
-		logger:     logger,
+		path:              path,
+		kAPI:              kAPI,
+		permitPool:        NewPermitPool(DefaultParallelOperations),
+		logger:            logger,
+		lockTTL:           lockTTL,
+		lockRenewInterval: lockRenewInterval,
 	}, nil
 }
 
@@ -311,6 +331,8 @@ func (c *EtcdBackend) LockWith(key, value string) (Lock, error) {
 		kAPI:            c.kAPI,
 		value:           value,
 		semaphoreDirKey: c.nodePathLock(key),
+		ttl:             c.lockTTL,
+		renewInterval:   c.lockRenewInterval,
 	}, nil
 }
 
@@ -325,6 +347,10 @@ type EtcdLock struct {
 	kAPI                                 client.KeysAPI
 	value, semaphoreDirKey, semaphoreKey string
 	lock                                 sync.Mutex
+
+	// ttl is the TTL of the semaphore key, renewed every renewInterval
+	ttl           time.Duration
+	renewInterval time.Duration
 }
 
 // addSemaphoreKey acquires a new ordered semaphore key.
@@ -334,7 +360,7 @@ func (c *EtcdLock) addSemaphoreKey() (string, uint64, error) {
 	// resulting key as a "semaphore key".
 	// https://coreos.com/etcd/docs/2.0.8/api.html#atomically-creating-in-order-keys
 	opts := &client.CreateInOrderOptions{
-		TTL: EtcdLockTTL,
+		TTL: c.ttl,
 	}
 	response, err := c.kAPI.CreateInOrder(context.Background(), c.semaphoreDirKey, c.value, opts)
 	if err != nil {
@@ -346,7 +372,7 @@ func (c *EtcdLock) addSemaphoreKey() (string, uint64, error) {
 // renewSemaphoreKey renews an existing semaphore key.
 func (c *EtcdLock) renewSemaphoreKey() (string, uint64, error) {
 	setOpts := &client.SetOptions{
-		TTL:       EtcdLockTTL,
+		TTL:       c.ttl,
 		PrevExist: client.PrevExist,
 	}
 	response, err := c.kAPI.Set(context.Background(), c.semaphoreKey, c.value, setOpts)
@@ -424,7 +450,7 @@ func (c *EtcdLock) assertNotHeld() error {
 func (c *EtcdLock) periodicallyRenewSemaphoreKey(stopCh chan struct{}) {
 	for {
 		select {
-		case <-time.After(EtcdLockRenewInterval):
+		case <-time.After(c.renewInterval):
 			c.renewSemaphoreKey()
 		case <-stopCh:
 			return
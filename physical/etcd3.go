@@ -0,0 +1,487 @@
+package physical
+
+import (
+	"errors"
+	"net/url"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/mgutz/logxi/v1"
+
+	"github.com/armon/go-metrics"
+	"github.com/coreos/etcd/clientv3"
+	"github.com/coreos/etcd/etcdserver/api/v3rpc/rpctypes"
+	"github.com/coreos/etcd/pkg/transport"
+	"golang.org/x/net/context"
+)
+
+const (
+	// Etcd3RequestTimeout is the timeout of the requests made to etcd
+	Etcd3RequestTimeout = 5 * time.Second
+
+	// Etcd3LockRetryInterval is the interval between attempts to acquire
+	// a lock held by another node
+	Etcd3LockRetryInterval = time.Second
+
+	// Etcd3LockSuffix is appended to the path of the backend to form the
+	// path of the locks, so that they aren't listed with the data
+	Etcd3LockSuffix = ".locks"
+)
+
+var (
+	Etcd3LeaseExpiredError = errors.New("lock lease expired")
+)
+
+// Etcd3Backend is a physical backend that stores data at a specific prefix
+// within etcd, using the v3 API. Locks are keys attached to an etcd lease,
+// so that they are released when the holder stops renewing the lease.
+type Etcd3Backend struct {
+	path       string
+	config     clientv3.Config
+	etcd       *clientv3.Client
+	etcdLock   sync.RWMutex
+	permitPool *PermitPool
+	logger     log.Logger
+
+	lockTTL           time.Duration
+	lockRenewInterval time.Duration
+}
+
+// newEtcd3Backend constructs an etcd backend using the v3 API
+func newEtcd3Backend(conf map[string]string, logger log.Logger) (Backend, error) {
+	// Get the etcd path form the configuration.
+	prefix, ok := conf["path"]
+	if !ok {
+		prefix = "/vault"
+	}
+
+	// Ensure path is prefixed and ends with a slash, so that the keys of
+	// the backend don't match other prefixes
+	if !strings.HasPrefix(prefix, "/") {
+		prefix = "/" + prefix
+	}
+	if !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+
+	machines := "http://127.0.0.1:2379"
+	if address, ok := conf["address"]; ok {
+		machines = address
+	}
+	machinesEnv := os.Getenv("ETCD_ADDR")
+	if machinesEnv != "" {
+		machines = machinesEnv
+	}
+
+	// The scheme of the endpoints tells the client whether to use TLS
+	endpoints := strings.Split(machines, EtcdMachineDelimiter)
+	for _, endpoint := range endpoints {
+		u, err := url.Parse(endpoint)
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			return nil, EtcdAddressError
+		}
+	}
+
+	config := clientv3.Config{
+		Endpoints:   endpoints,
+		DialTimeout: Etcd3RequestTimeout,
+	}
+
+	cert, hasCert := conf["tls_cert_file"]
+	key, hasKey := conf["tls_key_file"]
+	ca, hasCa := conf["tls_ca_file"]
+	if (hasCert && hasKey) || hasCa {
+		tlsConfig, err := transport.TLSInfo{
+			CAFile:   ca,
+			CertFile: cert,
+			KeyFile:  key,
+		}.ClientConfig()
+		if err != nil {
+			return nil, err
+		}
+		config.TLS = tlsConfig
+	}
+
+	// Set credentials.
+	config.Username = os.Getenv("ETCD_USERNAME")
+	if config.Username == "" {
+		config.Username, _ = conf["username"]
+	}
+
+	config.Password = os.Getenv("ETCD_PASSWORD")
+	if config.Password == "" {
+		config.Password, _ = conf["password"]
+	}
+
+	lockTTL, lockRenewInterval, err := parseHALockTimings(conf, EtcdLockTTL, EtcdLockRenewInterval)
+	if err != nil {
+		return nil, err
+	}
+
+	etcd, err := clientv3.New(config)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Etcd3Backend{
+		path:              prefix,
+		config:            config,
+		etcd:              etcd,
+		permitPool:        NewPermitPool(DefaultParallelOperations),
+		logger:            logger,
+		lockTTL:           lockTTL,
+		lockRenewInterval: lockRenewInterval,
+	}, nil
+}
+
+// call runs fn with the etcd client and a context limited to the request
+// timeout. The client authenticates when it connects, so it's replaced when
+// its token expired, and fn is run again.
+func (c *Etcd3Backend) call(fn func(ctx context.Context, etcd *clientv3.Client) error) error {
+	for refresh := false; ; refresh = true {
+		c.etcdLock.RLock()
+		etcd := c.etcd
+		c.etcdLock.RUnlock()
+
+		ctx, cancel := context.WithTimeout(context.Background(), Etcd3RequestTimeout)
+		err := fn(ctx, etcd)
+		cancel()
+		if err != nil && !refresh && c.config.Username != "" &&
+			strings.Contains(err.Error(), "invalid auth token") {
+			if err := c.reconnect(etcd); err != nil {
+				return err
+			}
+			continue
+		}
+		return err
+	}
+}
+
+// reconnect replaces the given etcd client, unless another request already
+// did
+func (c *Etcd3Backend) reconnect(old *clientv3.Client) error {
+	c.etcdLock.Lock()
+	defer c.etcdLock.Unlock()
+
+	if c.etcd != old {
+		return nil
+	}
+	etcd, err := clientv3.New(c.config)
+	if err != nil {
+		return err
+	}
+	c.etcd = etcd
+	old.Close()
+	return nil
+}
+
+// key returns the etcd key of a backend key
+func (c *Etcd3Backend) key(key string) string {
+	return c.path + key
+}
+
+// Put is used to insert or update an entry.
+func (c *Etcd3Backend) Put(entry *Entry) error {
+	defer metrics.MeasureSince([]string{"etcd", "put"}, time.Now())
+
+	c.permitPool.Acquire()
+	defer c.permitPool.Release()
+
+	return c.call(func(ctx context.Context, etcd *clientv3.Client) error {
+		_, err := etcd.Put(ctx, c.key(entry.Key), string(entry.Value))
+		return err
+	})
+}
+
+// Get is used to fetch an entry.
+func (c *Etcd3Backend) Get(key string) (*Entry, error) {
+	defer metrics.MeasureSince([]string{"etcd", "get"}, time.Now())
+
+	c.permitPool.Acquire()
+	defer c.permitPool.Release()
+
+	var resp *clientv3.GetResponse
+	if err := c.call(func(ctx context.Context, etcd *clientv3.Client) (err error) {
+		resp, err = etcd.Get(ctx, c.key(key))
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	if len(resp.Kvs) == 0 {
+		return nil, nil
+	}
+
+	return &Entry{
+		Key:   key,
+		Value: resp.Kvs[0].Value,
+	}, nil
+}
+
+// Delete is used to permanently delete an entry.
+func (c *Etcd3Backend) Delete(key string) error {
+	defer metrics.MeasureSince([]string{"etcd", "delete"}, time.Now())
+
+	c.permitPool.Acquire()
+	defer c.permitPool.Release()
+
+	return c.call(func(ctx context.Context, etcd *clientv3.Client) error {
+		_, err := etcd.Delete(ctx, c.key(key))
+		return err
+	})
+}
+
+// Transaction is used to apply several operations atomically, in a single
+// etcd transaction.
+func (c *Etcd3Backend) Transaction(txns []*TxnEntry) error {
+	defer metrics.MeasureSince([]string{"etcd", "transaction"}, time.Now())
+	if err := validateTransaction(txns); err != nil {
+		return err
+	}
+
+	// etcd rejects transactions with several operations on the same key,
+	// and only the last one matters since they are applied in order
+	last := make(map[string]int, len(txns))
+	for i, txn := range txns {
+		last[txn.Entry.Key] = i
+	}
+
+	ops := make([]clientv3.Op, 0, len(last))
+	for i, txn := range txns {
+		if last[txn.Entry.Key] != i {
+			continue
+		}
+		switch txn.Operation {
+		case PutOperation:
+			ops = append(ops, clientv3.OpPut(c.key(txn.Entry.Key), string(txn.Entry.Value)))
+		case DeleteOperation:
+			ops = append(ops, clientv3.OpDelete(c.key(txn.Entry.Key)))
+		}
+	}
+
+	c.permitPool.Acquire()
+	defer c.permitPool.Release()
+
+	// Without comparisons, the operations are always applied
+	return c.call(func(ctx context.Context, etcd *clientv3.Client) error {
+		_, err := etcd.Txn(ctx).Then(ops...).Commit()
+		return err
+	})
+}
+
+// List is used to list all the keys under a given prefix, up to the next
+// prefix.
+func (c *Etcd3Backend) List(prefix string) ([]string, error) {
+	defer metrics.MeasureSince([]string{"etcd", "list"}, time.Now())
+
+	c.permitPool.Acquire()
+	defer c.permitPool.Release()
+
+	// etcd has no directories: fetch the keys with the prefix and keep the
+	// first segment of each
+	start := c.key(prefix)
+	var resp *clientv3.GetResponse
+	if err := c.call(func(ctx context.Context, etcd *clientv3.Client) (err error) {
+		resp, err = etcd.Get(ctx, start, clientv3.WithPrefix(), clientv3.WithKeysOnly())
+		return err
+	}); err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	out := []string{}
+	for _, kv := range resp.Kvs {
+		name := strings.TrimPrefix(string(kv.Key), start)
+		if i := strings.Index(name, "/"); i != -1 {
+			name = name[:i+1]
+		}
+		if !seen[name] {
+			seen[name] = true
+			out = append(out, name)
+		}
+	}
+	sort.Strings(out)
+	return out, nil
+}
+
+// LockWith is used for mutual exclusion based on the given key.
+func (c *Etcd3Backend) LockWith(key, value string) (Lock, error) {
+	return &Etcd3Lock{
+		backend:       c,
+		key:           path.Join(strings.TrimSuffix(c.path, "/")+Etcd3LockSuffix, key),
+		value:         value,
+		ttl:           c.lockTTL,
+		renewInterval: c.lockRenewInterval,
+	}, nil
+}
+
+// HAEnabled indicates whether the HA functionality should be exposed.
+// Currently always returns true.
+func (c *Etcd3Backend) HAEnabled() bool {
+	return true
+}
+
+// Etcd3Lock implements a lock using an etcd key attached to a lease. The key
+// is created only if it doesn't exist, and deleted by etcd when the lease
+// expires.
+type Etcd3Lock struct {
+	backend *Etcd3Backend
+	key     string
+	value   string
+	lock    sync.Mutex
+
+	// leaseID is the lease of the key while the lock is held
+	leaseID clientv3.LeaseID
+	stopCh  chan struct{}
+
+	// ttl is the TTL of the lease, renewed every renewInterval
+	ttl           time.Duration
+	renewInterval time.Duration
+}
+
+// Lock attempts to acquire the lock, retrying until it is successful or the
+// provided channel is closed. The returned channel will be closed when the
+// lock is lost, either by an explicit call to Unlock or by the lease of the
+// key expiring.
+//
+// If the lock is currently held by this instance of Etcd3Lock, Lock will
+// return an EtcdLockHeldError error.
+func (c *Etcd3Lock) Lock(stopCh <-chan struct{}) (<-chan struct{}, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.leaseID != 0 {
+		return nil, EtcdLockHeldError
+	}
+
+	for {
+		acquired, err := c.tryLock()
+		if err != nil {
+			return nil, err
+		}
+		if acquired {
+			break
+		}
+
+		select {
+		case <-stopCh:
+			return nil, nil
+		case <-time.After(Etcd3LockRetryInterval):
+		}
+	}
+
+	c.stopCh = make(chan struct{})
+	doneCh := make(chan struct{})
+	go c.renew(c.leaseID, c.stopCh, doneCh)
+	return doneCh, nil
+}
+
+// tryLock creates the key of the lock if it doesn't exist
+func (c *Etcd3Lock) tryLock() (bool, error) {
+	held, _, err := c.Value()
+	if err != nil || held {
+		return false, err
+	}
+
+	var grant *clientv3.LeaseGrantResponse
+	if err := c.backend.call(func(ctx context.Context, etcd *clientv3.Client) (err error) {
+		grant, err = etcd.Grant(ctx, int64(c.ttl.Seconds()))
+		return err
+	}); err != nil {
+		return false, err
+	}
+
+	var txn *clientv3.TxnResponse
+	if err := c.backend.call(func(ctx context.Context, etcd *clientv3.Client) (err error) {
+		txn, err = etcd.Txn(ctx).
+			If(clientv3.Compare(clientv3.CreateRevision(c.key), "=", 0)).
+			Then(clientv3.OpPut(c.key, c.value, clientv3.WithLease(grant.ID))).
+			Commit()
+		return err
+	}); err != nil || !txn.Succeeded {
+		c.revoke(grant.ID)
+		return false, err
+	}
+
+	c.leaseID = grant.ID
+	return true, nil
+}
+
+// renew periodically renews the lease of the lock until stopCh is closed,
+// and closes doneCh once the lock is lost. Renewals that fail are retried
+// until the lease would have expired.
+func (c *Etcd3Lock) renew(leaseID clientv3.LeaseID, stopCh, doneCh chan struct{}) {
+	defer close(doneCh)
+
+	renewed := time.Now()
+	for {
+		select {
+		case <-stopCh:
+			return
+		case <-time.After(c.renewInterval):
+		}
+
+		err := c.backend.call(func(ctx context.Context, etcd *clientv3.Client) error {
+			_, err := etcd.KeepAliveOnce(ctx, leaseID)
+			return err
+		})
+		switch {
+		case err == rpctypes.ErrLeaseNotFound:
+			c.backend.logger.Error("physical/etcd: lock lost", "error", Etcd3LeaseExpiredError)
+			return
+		case err != nil:
+			c.backend.logger.Warn("physical/etcd: failed to renew lock lease", "error", err)
+			if time.Since(renewed) >= c.ttl {
+				c.backend.logger.Error("physical/etcd: lock lease expired", "error", err)
+				return
+			}
+		default:
+			renewed = time.Now()
+		}
+	}
+}
+
+// revoke revokes a lease, deleting the keys attached to it
+func (c *Etcd3Lock) revoke(leaseID clientv3.LeaseID) error {
+	return c.backend.call(func(ctx context.Context, etcd *clientv3.Client) error {
+		_, err := etcd.Revoke(ctx, leaseID)
+		return err
+	})
+}
+
+// Unlock releases the lock by revoking the lease of its key.
+//
+// If the lock is not currently held by this instance of Etcd3Lock, Unlock
+// will return an EtcdLockNotHeldError error.
+func (c *Etcd3Lock) Unlock() error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.leaseID == 0 {
+		return EtcdLockNotHeldError
+	}
+
+	close(c.stopCh)
+	leaseID := c.leaseID
+	c.leaseID = 0
+	return c.revoke(leaseID)
+}
+
+// Value checks whether or not the lock is held by any instance of
+// Etcd3Lock, including this one, and returns the current value.
+func (c *Etcd3Lock) Value() (bool, string, error) {
+	var resp *clientv3.GetResponse
+	if err := c.backend.call(func(ctx context.Context, etcd *clientv3.Client) (err error) {
+		resp, err = etcd.Get(ctx, c.key)
+		return err
+	}); err != nil {
+		return false, "", err
+	}
+	if len(resp.Kvs) == 0 {
+		return false, "", nil
+	}
+	return true, string(resp.Kvs[0].Value), nil
+}
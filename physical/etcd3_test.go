@@ -0,0 +1,100 @@
+package physical
+
+import (
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/helper/logformat"
+	log "github.com/mgutz/logxi/v1"
+
+	"github.com/coreos/etcd/clientv3"
+	"golang.org/x/net/context"
+)
+
+// testEtcd3Backend returns a backend storing its data under a random path of
+// the etcd cluster at ETCD_ADDR, and a function deleting the data
+func testEtcd3Backend(t *testing.T, conf map[string]string) (Backend, func()) {
+	addr := os.Getenv("ETCD_ADDR")
+	if addr == "" {
+		t.SkipNow()
+	}
+
+	randPath := fmt.Sprintf("/vault-%d", time.Now().UnixNano())
+	conf["path"] = randPath
+	conf["etcd_api"] = "v3"
+
+	// The etcd address is read from ETCD_ADDR.
+	logger := logformat.NewVaultLogger(log.LevelTrace)
+	b, err := NewBackend("etcd", logger, conf)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	cleanup := func() {
+		etcd := b.(*Etcd3Backend).etcd
+		for _, prefix := range []string{randPath + "/", randPath + Etcd3LockSuffix + "/"} {
+			if _, err := etcd.Delete(context.Background(), prefix, clientv3.WithPrefix()); err != nil {
+				t.Fatalf("err: %v", err)
+			}
+		}
+	}
+	return b, cleanup
+}
+
+func TestEtcd3Backend(t *testing.T) {
+	b, cleanup := testEtcd3Backend(t, map[string]string{})
+	defer cleanup()
+
+	testBackend(t, b)
+	testBackend_ListPrefix(t, b)
+	testTransactionalBackend(t, b)
+
+	ha, ok := b.(HABackend)
+	if !ok {
+		t.Fatalf("etcd does not implement HABackend")
+	}
+	testHABackend(t, ha, ha)
+}
+
+func TestEtcd3Backend_lockLost(t *testing.T) {
+	b, cleanup := testEtcd3Backend(t, map[string]string{
+		"ha_lock_ttl":            "5s",
+		"ha_lock_renew_interval": "10ms",
+	})
+	defer cleanup()
+
+	lock, err := b.(HABackend).LockWith("foo", "bar")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	leaderCh, err := lock.Lock(nil)
+	if err != nil || leaderCh == nil {
+		t.Fatalf("bad: %v %v", leaderCh, err)
+	}
+
+	// The lock is lost when its lease is revoked
+	etcd := b.(*Etcd3Backend).etcd
+	if _, err := etcd.Revoke(context.Background(), lock.(*Etcd3Lock).leaseID); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	select {
+	case <-leaderCh:
+	case <-time.After(time.Second):
+		t.Fatal("lock should have been lost")
+	}
+	if held, _, err := lock.Value(); err != nil || held {
+		t.Fatalf("bad: %t %v", held, err)
+	}
+}
+
+func TestEtcd3Backend_api(t *testing.T) {
+	logger := logformat.NewVaultLogger(log.LevelTrace)
+	_, err := NewBackend("etcd", logger, map[string]string{
+		"etcd_api": "v4",
+	})
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+}
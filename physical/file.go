@@ -6,6 +6,7 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"strconv"
 	"sync"
 
 	log "github.com/mgutz/logxi/v1"
@@ -24,8 +25,16 @@ type FileBackend struct {
 	Path   string
 	l      sync.Mutex
 	logger log.Logger
+
+	// fsync makes the writes durable before they return, by syncing the
+	// entries and the directories containing them
+	fsync bool
 }
 
+// fileTempSuffix is the suffix of the temporary files entries are written
+// to before being renamed
+const fileTempSuffix = ".tmp"
+
 // newFileBackend constructs a Filebackend using the given directory
 func newFileBackend(conf map[string]string, logger log.Logger) (Backend, error) {
 	path, ok := conf["path"]
@@ -33,9 +42,22 @@ func newFileBackend(conf map[string]string, logger log.Logger) (Backend, error)
 		return nil, fmt.Errorf("'path' must be set")
 	}
 
+	fsync := true
+	if fsyncStr, ok := conf["fsync"]; ok {
+		var err error
+		fsync, err = strconv.ParseBool(fsyncStr)
+		if err != nil {
+			return nil, fmt.Errorf("failed parsing fsync parameter: %v", err)
+		}
+		if logger.IsDebug() {
+			logger.Debug("physical/file: fsync set", "fsync", fsync)
+		}
+	}
+
 	return &FileBackend{
 		Path:   path,
 		logger: logger,
+		fsync:  fsync,
 	}, nil
 }
 
@@ -57,6 +79,9 @@ func (b *FileBackend) Delete(k string) error {
 			return err
 		}
 	}
+	if err := b.syncDir(path); err != nil {
+		return err
+	}
 
 	// Check for the directory being empty and remove if so, with another
 	// additional guard for the path not existing
@@ -122,17 +147,55 @@ func (b *FileBackend) Put(entry *Entry) error {
 		return err
 	}
 
-	// JSON encode the entry and write it
-	f, err := os.OpenFile(
-		filepath.Join(path, key),
-		os.O_CREATE|os.O_TRUNC|os.O_WRONLY,
-		0600)
+	// JSON encode the entry and write it to a temporary file, renamed once
+	// complete, so that a crash never leaves a truncated entry behind
+	fullPath := filepath.Join(path, key)
+	tmpPath := filepath.Join(path, "."+key+fileTempSuffix)
+	if err := b.writeFile(tmpPath, entry); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := os.Rename(tmpPath, fullPath); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	return b.syncDir(path)
+}
+
+// writeFile writes the JSON encoded entry to the file at the given path
+func (b *FileBackend) writeFile(path string, entry *Entry) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
 	if err != nil {
 		return err
 	}
-	defer f.Close()
+
 	enc := json.NewEncoder(f)
-	return enc.Encode(entry)
+	if err := enc.Encode(entry); err != nil {
+		f.Close()
+		return err
+	}
+	if b.fsync {
+		if err := f.Sync(); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	return f.Close()
+}
+
+// syncDir syncs the directory at the given path, making the creation,
+// renaming and removal of its files durable
+func (b *FileBackend) syncDir(path string) error {
+	if !b.fsync {
+		return nil
+	}
+
+	dir, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer dir.Close()
+	return dir.Sync()
 }
 
 func (b *FileBackend) List(prefix string) ([]string, error) {
@@ -155,16 +218,21 @@ func (b *FileBackend) List(prefix string) ([]string, error) {
 	}
 	defer f.Close()
 
-	names, err := f.Readdirnames(-1)
+	infos, err := f.Readdir(-1)
 	if err != nil {
 		return nil, err
 	}
 
-	for i, name := range names {
-		if name[0] == '_' {
-			names[i] = name[1:]
-		} else {
-			names[i] = name + "/"
+	// Other files, such as the temporary files left by an interrupted
+	// write, aren't entries
+	names := make([]string, 0, len(infos))
+	for _, info := range infos {
+		name := info.Name()
+		switch {
+		case name[0] == '_':
+			names = append(names, name[1:])
+		case info.IsDir():
+			names = append(names, name+"/")
 		}
 	}
 
@@ -3,6 +3,8 @@ package physical
 import (
 	"io/ioutil"
 	"os"
+	"path/filepath"
+	"reflect"
 	"testing"
 
 	"github.com/hashicorp/vault/helper/logformat"
@@ -28,3 +30,54 @@ func TestFileBackend(t *testing.T) {
 	testBackend(t, b)
 	testBackend_ListPrefix(t, b)
 }
+
+func TestFileBackend_tempFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "vault")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	logger := logformat.NewVaultLogger(log.LevelTrace)
+
+	b, err := NewBackend("file", logger, map[string]string{
+		"path":  dir,
+		"fsync": "false",
+	})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if err := b.Put(&Entry{Key: "foo/bar", Value: []byte("baz")}); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	// Simulate a write interrupted before the rename
+	tmpPath := filepath.Join(dir, "foo", "._zip"+fileTempSuffix)
+	if err := ioutil.WriteFile(tmpPath, []byte(`{"Key":"foo/zi`), 0600); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	keys, err := b.List("foo/")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if !reflect.DeepEqual(keys, []string{"bar"}) {
+		t.Fatalf("bad: %v", keys)
+	}
+
+	// The next write of the key replaces the temporary file
+	if err := b.Put(&Entry{Key: "foo/zip", Value: []byte("zap")}); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if _, err := os.Stat(tmpPath); !os.IsNotExist(err) {
+		t.Fatalf("expected the temporary file to be renamed: %v", err)
+	}
+	entry, err := b.Get("foo/zip")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if entry == nil || string(entry.Value) != "zap" {
+		t.Fatalf("bad: %#v", entry)
+	}
+}
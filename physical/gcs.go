@@ -0,0 +1,513 @@
+package physical
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/mgutz/logxi/v1"
+
+	"cloud.google.com/go/storage"
+	"github.com/armon/go-metrics"
+	"github.com/hashicorp/errwrap"
+	"golang.org/x/net/context"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/api/iterator"
+	"google.golang.org/api/option"
+)
+
+const (
+	// DefaultGCSChunkSize is the size above which values are uploaded in
+	// chunks, in kilobytes
+	DefaultGCSChunkSize = 8192
+
+	// GCSLockPrefix is the prefix of the objects holding the locks, so that
+	// they aren't mixed with the data
+	GCSLockPrefix = ".locks/"
+
+	// GCSLockTTL is the default validity of a lock record, which the leader
+	// renews every GCSLockRenewInterval
+	GCSLockTTL           = 15 * time.Second
+	GCSLockRenewInterval = 5 * time.Second
+
+	// GCSLockRetryInterval is the interval between attempts to acquire a
+	// lock held by another node
+	GCSLockRetryInterval = time.Second
+)
+
+var (
+	GCSLockHeldError    = errors.New("lock already held")
+	GCSLockNotHeldError = errors.New("lock not held")
+)
+
+// GCSBackend is a physical backend that stores data within a Google Cloud
+// Storage bucket. HA is optional, with locks whose objects are updated only
+// if their generation didn't change.
+type GCSBackend struct {
+	bucket     *storage.BucketHandle
+	bucketName string
+	chunkSize  int
+	permitPool *PermitPool
+	logger     log.Logger
+	haEnabled  bool
+
+	lockTTL           time.Duration
+	lockRenewInterval time.Duration
+}
+
+// newGCSBackend constructs a Google Cloud Storage backend using a
+// pre-existing bucket. Credentials are read from a service account key
+// file, or else are the application default credentials, such as those of
+// the Google Compute Engine instance.
+func newGCSBackend(conf map[string]string, logger log.Logger) (Backend, error) {
+	credentialsFile := os.Getenv("GOOGLE_APPLICATION_CREDENTIALS")
+	if credentialsFile == "" {
+		credentialsFile = conf["credentials_file"]
+	}
+	opts := []option.ClientOption{
+		option.WithScopes(storage.ScopeReadWrite),
+	}
+	if credentialsFile != "" {
+		opts = append(opts, option.WithServiceAccountFile(credentialsFile))
+	}
+
+	return newGCSBackendWithOptions(conf, logger, opts...)
+}
+
+// newGCSBackendWithOptions constructs a Google Cloud Storage backend whose
+// client is created with the given options
+func newGCSBackendWithOptions(conf map[string]string, logger log.Logger, opts ...option.ClientOption) (Backend, error) {
+	bucketName := os.Getenv("GOOGLE_STORAGE_BUCKET")
+	if bucketName == "" {
+		bucketName = conf["bucket"]
+		if bucketName == "" {
+			return nil, fmt.Errorf("'bucket' must be set")
+		}
+	}
+
+	chunkSize := DefaultGCSChunkSize
+	if chunkSizeStr, ok := conf["chunk_size"]; ok {
+		var err error
+		chunkSize, err = strconv.Atoi(chunkSizeStr)
+		if err != nil || chunkSize <= 0 {
+			return nil, fmt.Errorf("invalid chunk_size: %s", chunkSizeStr)
+		}
+	}
+
+	maxParStr, ok := conf["max_parallel"]
+	var maxParInt int
+	if ok {
+		var err error
+		maxParInt, err = strconv.Atoi(maxParStr)
+		if err != nil {
+			return nil, errwrap.Wrapf("failed parsing max_parallel parameter: {{err}}", err)
+		}
+		if logger.IsDebug() {
+			logger.Debug("physical/gcs: max_parallel set", "max_parallel", maxParInt)
+		}
+	}
+
+	haEnabled := os.Getenv("GOOGLE_STORAGE_HA_ENABLED")
+	if haEnabled == "" {
+		haEnabled = conf["ha_enabled"]
+	}
+	haEnabledBool, _ := strconv.ParseBool(haEnabled)
+
+	lockTTL, lockRenewInterval, err := parseHALockTimings(conf, GCSLockTTL, GCSLockRenewInterval)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := storage.NewClient(context.Background(), opts...)
+	if err != nil {
+		return nil, fmt.Errorf("error establishing storage client: %v", err)
+	}
+
+	// Check that the bucket exists and is accessible
+	bucket := client.Bucket(bucketName)
+	if _, err := bucket.Attrs(context.Background()); err != nil {
+		return nil, fmt.Errorf("unable to access bucket '%s': %v", bucketName, err)
+	}
+
+	g := &GCSBackend{
+		bucket:            bucket,
+		bucketName:        bucketName,
+		chunkSize:         chunkSize * 1024,
+		permitPool:        NewPermitPool(maxParInt),
+		logger:            logger,
+		haEnabled:         haEnabledBool,
+		lockTTL:           lockTTL,
+		lockRenewInterval: lockRenewInterval,
+	}
+	return g, nil
+}
+
+// isGCSPreconditionFailed checks whether an error is caused by the
+// generation of an object not matching the expected one
+func isGCSPreconditionFailed(err error) bool {
+	apiErr, ok := err.(*googleapi.Error)
+	return ok && apiErr.Code == http.StatusPreconditionFailed
+}
+
+// upload writes an object, uploading it in chunks if it is larger than the
+// chunk size. If generation is not nil, the object is only written if its
+// generation matches, zero meaning that it doesn't exist. The generation
+// of the new object is returned.
+func (g *GCSBackend) upload(key string, value []byte, generation *int64) (int64, error) {
+	object := g.bucket.Object(key)
+	switch {
+	case generation == nil:
+	case *generation == 0:
+		object = object.If(storage.Conditions{DoesNotExist: true})
+	default:
+		object = object.If(storage.Conditions{GenerationMatch: *generation})
+	}
+
+	w := object.NewWriter(context.Background())
+	w.ChunkSize = g.chunkSize
+	if _, err := w.Write(value); err != nil {
+		w.CloseWithError(err)
+		return 0, err
+	}
+	if err := w.Close(); err != nil {
+		return 0, err
+	}
+	return w.Attrs().Generation, nil
+}
+
+// download reads an object and its generation, returning a nil value if it
+// doesn't exist
+func (g *GCSBackend) download(key string) ([]byte, int64, error) {
+	attrs, err := g.bucket.Object(key).Attrs(context.Background())
+	if err == storage.ErrObjectNotExist {
+		return nil, 0, nil
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// Read the generation whose attributes were returned, which is gone if
+	// the object was written since
+	r, err := g.bucket.Object(key).Generation(attrs.Generation).NewReader(context.Background())
+	if err == storage.ErrObjectNotExist {
+		return nil, 0, nil
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+	defer r.Close()
+
+	value, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, 0, fmt.Errorf("error reading object '%s': %v", key, err)
+	}
+	return value, attrs.Generation, nil
+}
+
+// remove deletes an object, if its generation matches when generation is
+// not zero. Objects that don't exist are ignored.
+func (g *GCSBackend) remove(key string, generation int64) error {
+	object := g.bucket.Object(key)
+	if generation != 0 {
+		object = object.If(storage.Conditions{GenerationMatch: generation})
+	}
+	err := object.Delete(context.Background())
+	if err == storage.ErrObjectNotExist {
+		return nil
+	}
+	return err
+}
+
+// Put is used to insert or update an entry
+func (g *GCSBackend) Put(entry *Entry) error {
+	defer metrics.MeasureSince([]string{"gcs", "put"}, time.Now())
+
+	g.permitPool.Acquire()
+	defer g.permitPool.Release()
+
+	_, err := g.upload(entry.Key, entry.Value, nil)
+	return err
+}
+
+// Get is used to fetch an entry
+func (g *GCSBackend) Get(key string) (*Entry, error) {
+	defer metrics.MeasureSince([]string{"gcs", "get"}, time.Now())
+
+	g.permitPool.Acquire()
+	defer g.permitPool.Release()
+
+	r, err := g.bucket.Object(key).NewReader(context.Background())
+	if err == storage.ErrObjectNotExist {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	value, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("error reading object '%s': %v", key, err)
+	}
+
+	return &Entry{
+		Key:   key,
+		Value: value,
+	}, nil
+}
+
+// Delete is used to permanently delete an entry
+func (g *GCSBackend) Delete(key string) error {
+	defer metrics.MeasureSince([]string{"gcs", "delete"}, time.Now())
+
+	g.permitPool.Acquire()
+	defer g.permitPool.Release()
+
+	return g.remove(key, 0)
+}
+
+// List is used to list all the keys under a given
+// prefix, up to the next prefix.
+func (g *GCSBackend) List(prefix string) ([]string, error) {
+	defer metrics.MeasureSince([]string{"gcs", "list"}, time.Now())
+
+	g.permitPool.Acquire()
+	defer g.permitPool.Release()
+
+	it := g.bucket.Objects(context.Background(), &storage.Query{
+		Prefix:    prefix,
+		Delimiter: "/",
+	})
+
+	keys := []string{}
+	for {
+		attrs, err := it.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error listing bucket '%s': %v", g.bucketName, err)
+		}
+
+		if attrs.Prefix == "" {
+			keys = append(keys, strings.TrimPrefix(attrs.Name, prefix))
+			continue
+		}
+		if prefix == "" && attrs.Prefix == GCSLockPrefix {
+			continue
+		}
+		keys = append(keys, strings.TrimPrefix(attrs.Prefix, prefix))
+	}
+
+	sort.Strings(keys)
+	return keys, nil
+}
+
+// LockWith is used for mutual exclusion based on the given key.
+func (g *GCSBackend) LockWith(key, value string) (Lock, error) {
+	return &GCSLock{
+		backend:       g,
+		key:           GCSLockPrefix + key,
+		value:         value,
+		ttl:           g.lockTTL,
+		renewInterval: g.lockRenewInterval,
+	}, nil
+}
+
+// HAEnabled indicates whether the HA functionality should be exposed.
+func (g *GCSBackend) HAEnabled() bool {
+	return g.haEnabled
+}
+
+// GCSLock implements a lock using an object whose updates are conditioned
+// on its generation, so that only one node can take or renew it. The lock
+// record expires unless renewed by its holder.
+type GCSLock struct {
+	backend *GCSBackend
+	key     string
+	value   string
+	lock    sync.Mutex
+
+	// generation is the generation of the lock object written by this
+	// instance, while the lock is held
+	generation int64
+	held       bool
+	stopRenew  chan struct{}
+	renewDone  chan struct{}
+
+	ttl           time.Duration
+	renewInterval time.Duration
+}
+
+// gcsLockRecord is the content of a lock object
+type gcsLockRecord struct {
+	Value   string    `json:"value"`
+	Expires time.Time `json:"expires"`
+}
+
+// Lock attempts to acquire the lock, retrying until it is successful or the
+// provided channel is closed. The returned channel will be closed when the
+// lock is lost, either by an explicit call to Unlock or by failing to renew
+// it.
+func (l *GCSLock) Lock(stopCh <-chan struct{}) (<-chan struct{}, error) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if l.held {
+		return nil, GCSLockHeldError
+	}
+
+	for {
+		acquired, err := l.tryLock()
+		if err != nil {
+			return nil, err
+		}
+		if acquired {
+			break
+		}
+
+		select {
+		case <-stopCh:
+			return nil, nil
+		case <-time.After(GCSLockRetryInterval):
+		}
+	}
+
+	l.held = true
+	l.stopRenew = make(chan struct{})
+	l.renewDone = make(chan struct{})
+	leader := make(chan struct{})
+	go l.renew(l.stopRenew, l.renewDone, leader)
+	return leader, nil
+}
+
+// read returns the lock record and the generation of its object, or a nil
+// record if there is none
+func (l *GCSLock) read() (*gcsLockRecord, int64, error) {
+	l.backend.permitPool.Acquire()
+	defer l.backend.permitPool.Release()
+
+	value, generation, err := l.backend.download(l.key)
+	if err != nil || value == nil {
+		return nil, 0, err
+	}
+
+	record := new(gcsLockRecord)
+	if err := json.Unmarshal(value, record); err != nil {
+		return nil, 0, fmt.Errorf("failed to decode lock record: %v", err)
+	}
+	return record, generation, nil
+}
+
+// write writes the lock record if the generation of its object matches,
+// returning the new generation
+func (l *GCSLock) write(generation int64) (int64, error) {
+	value, err := json.Marshal(&gcsLockRecord{
+		Value:   l.value,
+		Expires: time.Now().Add(l.ttl),
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	l.backend.permitPool.Acquire()
+	defer l.backend.permitPool.Release()
+
+	return l.backend.upload(l.key, value, &generation)
+}
+
+// tryLock writes the lock record if there is none or it expired
+func (l *GCSLock) tryLock() (bool, error) {
+	record, generation, err := l.read()
+	if err != nil {
+		return false, err
+	}
+	if record != nil && time.Now().Before(record.Expires) {
+		return false, nil
+	}
+
+	l.generation, err = l.write(generation)
+	if isGCSPreconditionFailed(err) {
+		// Another node took the lock first
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// renew periodically renews the lock record until stopCh is closed, and
+// closes leader once the lock is lost. Renewals that fail are retried until
+// the record would have expired.
+func (l *GCSLock) renew(stopCh, doneCh, leader chan struct{}) {
+	defer close(doneCh)
+	defer close(leader)
+
+	renewed := time.Now()
+	for {
+		select {
+		case <-stopCh:
+			return
+		case <-time.After(l.renewInterval):
+		}
+
+		generation, err := l.write(l.generation)
+		switch {
+		case isGCSPreconditionFailed(err):
+			l.backend.logger.Error("physical/gcs: lock lost, taken over by another node")
+			return
+		case err != nil:
+			l.backend.logger.Warn("physical/gcs: failed to renew lock", "error", err)
+			if time.Since(renewed) >= l.ttl {
+				l.backend.logger.Error("physical/gcs: lock expired", "error", err)
+				return
+			}
+		default:
+			l.generation = generation
+			renewed = time.Now()
+		}
+	}
+}
+
+// Unlock releases the lock by deleting its object, unless another node
+// took it over.
+func (l *GCSLock) Unlock() error {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if !l.held {
+		return GCSLockNotHeldError
+	}
+
+	l.held = false
+	close(l.stopRenew)
+	<-l.renewDone
+
+	l.backend.permitPool.Acquire()
+	defer l.backend.permitPool.Release()
+
+	err := l.backend.remove(l.key, l.generation)
+	if isGCSPreconditionFailed(err) {
+		return nil
+	}
+	return err
+}
+
+// Value checks whether or not the lock is held by any instance of GCSLock,
+// including this one, and returns the current value.
+func (l *GCSLock) Value() (bool, string, error) {
+	record, _, err := l.read()
+	if err != nil {
+		return false, "", err
+	}
+	if record == nil || !time.Now().Before(record.Expires) {
+		return false, "", nil
+	}
+	return true, record.Value, nil
+}
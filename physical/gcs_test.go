@@ -0,0 +1,358 @@
+package physical
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/helper/logformat"
+	log "github.com/mgutz/logxi/v1"
+
+	"google.golang.org/api/option"
+)
+
+type testGCSObject struct {
+	value      []byte
+	generation int64
+}
+
+type testGCSUpload struct {
+	name       string
+	generation string
+	value      []byte
+}
+
+// testGCSServer is an in-memory implementation of the parts of the Google
+// Cloud Storage JSON and XML APIs used by the backend. It serves a single
+// bucket named vault, listing two objects per page.
+type testGCSServer struct {
+	sync.Mutex
+	url        string
+	objects    map[string]*testGCSObject
+	uploads    map[string]*testGCSUpload
+	generation int64
+	chunks     int
+}
+
+func (s *testGCSServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.Lock()
+	defer s.Unlock()
+
+	const objects = "/storage/v1/b/vault/o"
+	query := r.URL.Query()
+	switch {
+	case r.URL.Path == "/storage/v1/b/vault":
+		fmt.Fprint(w, `{"name": "vault"}`)
+	case r.URL.Path == objects && r.Method == "GET":
+		s.serveList(w, r)
+	case strings.HasPrefix(r.URL.Path, objects+"/") && r.Method == "GET":
+		name := strings.TrimPrefix(r.URL.Path, objects+"/")
+		object, ok := s.objects[name]
+		if !ok {
+			s.error(w, http.StatusNotFound)
+			return
+		}
+		fmt.Fprintf(w, `{"name": %q, "generation": "%d"}`, name, object.generation)
+	case strings.HasPrefix(r.URL.Path, "/vault/") && r.Method == "GET":
+		object, ok := s.objects[strings.TrimPrefix(r.URL.Path, "/vault/")]
+		if !ok || (query.Get("generation") != "" && query.Get("generation") != strconv.FormatInt(object.generation, 10)) {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write(object.value)
+	case strings.HasPrefix(r.URL.Path, objects+"/") && r.Method == "DELETE":
+		name := strings.TrimPrefix(r.URL.Path, objects+"/")
+		_, ok := s.objects[name]
+		switch {
+		case !ok:
+			s.error(w, http.StatusNotFound)
+		case !s.matches(name, query.Get("ifGenerationMatch")):
+			s.error(w, http.StatusPreconditionFailed)
+		default:
+			delete(s.objects, name)
+			w.WriteHeader(http.StatusNoContent)
+		}
+	case r.URL.Path == "/upload"+objects && r.Method == "POST" && query.Get("uploadType") == "resumable":
+		var object struct {
+			Name string `json:"name"`
+		}
+		json.NewDecoder(r.Body).Decode(&object)
+		id := strconv.Itoa(len(s.uploads))
+		s.uploads[id] = &testGCSUpload{
+			name:       object.Name,
+			generation: query.Get("ifGenerationMatch"),
+		}
+		w.Header().Set("Location", s.url+"/upload/session/"+id)
+	case r.URL.Path == "/upload"+objects && r.Method == "POST" && query.Get("uploadType") == "multipart":
+		name, value, err := readTestGCSMultipart(r)
+		if err != nil {
+			s.error(w, http.StatusBadRequest)
+			return
+		}
+		s.write(w, name, query.Get("ifGenerationMatch"), value)
+	case strings.HasPrefix(r.URL.Path, "/upload/session/") && r.Method == "POST":
+		upload := s.uploads[strings.TrimPrefix(r.URL.Path, "/upload/session/")]
+		var start, end int
+		var total string
+		fmt.Sscanf(r.Header.Get("Content-Range"), "bytes %d-%d/%s", &start, &end, &total)
+		chunk, _ := ioutil.ReadAll(r.Body)
+		if upload == nil || start != len(upload.value) || end-start+1 != len(chunk) {
+			s.error(w, http.StatusBadRequest)
+			return
+		}
+		s.chunks++
+		upload.value = append(upload.value, chunk...)
+		if total == "*" {
+			// Signals that the upload is incomplete
+			w.Header().Set("X-Http-Status-Code-Override", "308")
+			return
+		}
+		s.write(w, upload.name, upload.generation, upload.value)
+	default:
+		s.error(w, http.StatusNotFound)
+	}
+}
+
+func (s *testGCSServer) error(w http.ResponseWriter, code int) {
+	w.WriteHeader(code)
+	fmt.Fprintf(w, `{"error": {"code": %d, "message": %q}}`, code, http.StatusText(code))
+}
+
+// readTestGCSMultipart reads the name and the value of an object uploaded
+// with its metadata in a single request
+func readTestGCSMultipart(r *http.Request) (string, []byte, error) {
+	_, params, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		return "", nil, err
+	}
+	mr := multipart.NewReader(r.Body, params["boundary"])
+
+	part, err := mr.NextPart()
+	if err != nil {
+		return "", nil, err
+	}
+	var object struct {
+		Name string `json:"name"`
+	}
+	if err := json.NewDecoder(part).Decode(&object); err != nil {
+		return "", nil, err
+	}
+
+	part, err = mr.NextPart()
+	if err != nil {
+		return "", nil, err
+	}
+	value, err := ioutil.ReadAll(part)
+	return object.Name, value, err
+}
+
+func (s *testGCSServer) serveList(w http.ResponseWriter, r *http.Request) {
+	prefix := r.URL.Query().Get("prefix")
+	if r.URL.Query().Get("delimiter") != "/" {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	// Objects and prefixes are listed in order, from the page token
+	var entries []string
+	seen := make(map[string]bool)
+	for name := range s.objects {
+		if !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		entry := name
+		if i := strings.Index(name[len(prefix):], "/"); i != -1 {
+			entry = name[:len(prefix)+i+1]
+		}
+		if !seen[entry] && entry > r.URL.Query().Get("pageToken") {
+			seen[entry] = true
+			entries = append(entries, entry)
+		}
+	}
+	sort.Strings(entries)
+
+	var page struct {
+		Items []struct {
+			Name string `json:"name"`
+		} `json:"items,omitempty"`
+		Prefixes      []string `json:"prefixes,omitempty"`
+		NextPageToken string   `json:"nextPageToken,omitempty"`
+	}
+	if len(entries) > 2 {
+		entries = entries[:2]
+		page.NextPageToken = entries[1]
+	}
+	for _, entry := range entries {
+		if strings.HasSuffix(entry, "/") {
+			page.Prefixes = append(page.Prefixes, entry)
+		} else {
+			page.Items = append(page.Items, struct {
+				Name string `json:"name"`
+			}{entry})
+		}
+	}
+	json.NewEncoder(w).Encode(&page)
+}
+
+// matches checks the generation precondition of a request
+func (s *testGCSServer) matches(name, generation string) bool {
+	if generation == "" {
+		return true
+	}
+	var current int64
+	if object, ok := s.objects[name]; ok {
+		current = object.generation
+	}
+	return generation == strconv.FormatInt(current, 10)
+}
+
+func (s *testGCSServer) write(w http.ResponseWriter, name, generation string, value []byte) {
+	if !s.matches(name, generation) {
+		s.error(w, http.StatusPreconditionFailed)
+		return
+	}
+	s.generation++
+	s.objects[name] = &testGCSObject{
+		value:      value,
+		generation: s.generation,
+	}
+	fmt.Fprintf(w, `{"name": %q, "generation": "%d"}`, name, s.generation)
+}
+
+// testGCSTransport sends the requests meant for Google Cloud Storage to the
+// in-memory server
+type testGCSTransport struct {
+	url *url.URL
+}
+
+func (t *testGCSTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	r.URL.Scheme = t.url.Scheme
+	r.URL.Host = t.url.Host
+	return http.DefaultTransport.RoundTrip(r)
+}
+
+// testGCSBackend starts an in-memory Google Cloud Storage server, and
+// returns a backend using it
+func testGCSBackend(t *testing.T, conf map[string]string) (*testGCSServer, Backend, func()) {
+	s := &testGCSServer{
+		objects: make(map[string]*testGCSObject),
+		uploads: make(map[string]*testGCSUpload),
+	}
+	ts := httptest.NewServer(s)
+	s.url = ts.URL
+	u, _ := url.Parse(ts.URL)
+
+	conf["bucket"] = "vault"
+	logger := logformat.NewVaultLogger(log.LevelTrace)
+	b, err := newGCSBackendWithOptions(conf, logger, option.WithHTTPClient(&http.Client{
+		Transport: &testGCSTransport{url: u},
+	}))
+	if err != nil {
+		ts.Close()
+		t.Fatalf("err: %s", err)
+	}
+	return s, b, ts.Close
+}
+
+func TestGCSBackend(t *testing.T) {
+	s, b, cleanup := testGCSBackend(t, map[string]string{
+		"chunk_size": "256",
+	})
+	defer cleanup()
+
+	testBackend(t, b)
+	testBackend_ListPrefix(t, b)
+
+	// Values larger than the chunk size are uploaded in chunks
+	value := bytes.Repeat([]byte("a"), 600*1024)
+	if err := b.Put(&Entry{Key: "large", Value: value}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	entry, err := b.Get("large")
+	if err != nil || entry == nil || !bytes.Equal(entry.Value, value) {
+		t.Fatalf("bad: %v", err)
+	}
+	if s.chunks != 3 {
+		t.Fatalf("bad: %d", s.chunks)
+	}
+
+	if ha, ok := b.(HABackend); !ok || ha.HAEnabled() {
+		t.Fatal("HA should be disabled")
+	}
+}
+
+func TestGCSHABackend(t *testing.T) {
+	s, b, cleanup := testGCSBackend(t, map[string]string{
+		"ha_enabled":             "true",
+		"ha_lock_ttl":            "1s",
+		"ha_lock_renew_interval": "50ms",
+	})
+	defer cleanup()
+
+	ha, ok := b.(HABackend)
+	if !ok || !ha.HAEnabled() {
+		t.Fatal("HA should be enabled")
+	}
+	testHABackend(t, ha, ha)
+
+	// Locks aren't listed with the data
+	keys, err := b.List("")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	for _, key := range keys {
+		if key == GCSLockPrefix {
+			t.Fatalf("bad: %#v", keys)
+		}
+	}
+
+	// The lock is lost when another node takes it over
+	lock, err := ha.LockWith("foo", "bar")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	leaderCh, err := lock.Lock(nil)
+	if err != nil || leaderCh == nil {
+		t.Fatalf("bad: %v %v", leaderCh, err)
+	}
+	s.Lock()
+	s.generation++
+	s.objects[GCSLockPrefix+"foo"] = &testGCSObject{
+		value:      []byte(fmt.Sprintf(`{"value": "baz", "expires": %q}`, time.Now().Add(time.Hour).Format(time.RFC3339))),
+		generation: s.generation,
+	}
+	s.Unlock()
+	select {
+	case <-leaderCh:
+	case <-time.After(time.Second):
+		t.Fatal("lock should have been lost")
+	}
+	if held, value, err := lock.Value(); err != nil || !held || value != "baz" {
+		t.Fatalf("bad: %t %q %v", held, value, err)
+	}
+	if err := lock.Unlock(); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Expired locks are taken over
+	s.Lock()
+	s.objects[GCSLockPrefix+"foo"].value = []byte(fmt.Sprintf(`{"value": "baz", "expires": %q}`, time.Now().Add(-time.Second).Format(time.RFC3339)))
+	s.Unlock()
+	leaderCh, err = lock.Lock(nil)
+	if err != nil || leaderCh == nil {
+		t.Fatalf("bad: %v %v", leaderCh, err)
+	}
+	lock.Unlock()
+}
@@ -3,6 +3,7 @@ package physical
 import (
 	"fmt"
 	"sync"
+	"time"
 
 	log "github.com/mgutz/logxi/v1"
 )
@@ -118,16 +119,19 @@ var builtinBackends = map[string]Factory{
 	"inmem_ha": func(_ map[string]string, logger log.Logger) (Backend, error) {
 		return NewInmemHA(logger), nil
 	},
-	"consul":     newConsulBackend,
-	"zookeeper":  newZookeeperBackend,
-	"file":       newFileBackend,
-	"s3":         newS3Backend,
-	"azure":      newAzureBackend,
-	"dynamodb":   newDynamoDBBackend,
-	"etcd":       newEtcdBackend,
-	"mysql":      newMySQLBackend,
-	"postgresql": newPostgreSQLBackend,
-	"swift":      newSwiftBackend,
+	"consul":      newConsulBackend,
+	"zookeeper":   newZookeeperBackend,
+	"file":        newFileBackend,
+	"s3":          newS3Backend,
+	"azure":       newAzureBackend,
+	"gcs":         newGCSBackend,
+	"dynamodb":    newDynamoDBBackend,
+	"etcd":        newEtcdBackend,
+	"mysql":       newMySQLBackend,
+	"postgresql":  newPostgreSQLBackend,
+	"cockroachdb": newCockroachDBBackend,
+	"cassandra":   newCassandraBackend,
+	"swift":       newSwiftBackend,
 }
 
 // PermitPool is a wrapper around a semaphore library to keep things
@@ -156,3 +160,33 @@ func (c *PermitPool) Acquire() {
 func (c *PermitPool) Release() {
 	<-c.sem
 }
+
+// parseHALockTimings parses the `ha_lock_ttl` and `ha_lock_renew_interval`
+// options of HA backends, falling back to the given defaults. The renewal
+// interval must be shorter than the TTL, otherwise the lock would expire
+// before being renewed.
+func parseHALockTimings(conf map[string]string, defaultTTL, defaultRenewInterval time.Duration) (time.Duration, time.Duration, error) {
+	ttl := defaultTTL
+	if raw, ok := conf["ha_lock_ttl"]; ok {
+		var err error
+		ttl, err = time.ParseDuration(raw)
+		if err != nil {
+			return 0, 0, fmt.Errorf("failed parsing ha_lock_ttl parameter: %v", err)
+		}
+	}
+
+	renewInterval := defaultRenewInterval
+	if raw, ok := conf["ha_lock_renew_interval"]; ok {
+		var err error
+		renewInterval, err = time.ParseDuration(raw)
+		if err != nil {
+			return 0, 0, fmt.Errorf("failed parsing ha_lock_renew_interval parameter: %v", err)
+		}
+	}
+
+	if renewInterval <= 0 || renewInterval >= ttl {
+		return 0, 0, fmt.Errorf("ha_lock_renew_interval must be positive and shorter than ha_lock_ttl")
+	}
+
+	return ttl, renewInterval, nil
+}
@@ -255,6 +255,76 @@ func testBackend_ListPrefix(t *testing.T, b Backend) {
 
 }
 
+func testTransactionalBackend(t *testing.T, b Backend) {
+	tb, ok := b.(Transactional)
+	if !ok {
+		t.Fatal("backend is not Transactional")
+	}
+
+	if err := b.Put(&Entry{Key: "txn/delete", Value: []byte("test")}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	txns := []*TxnEntry{
+		&TxnEntry{
+			Operation: PutOperation,
+			Entry:     &Entry{Key: "txn/put", Value: []byte("test")},
+		},
+		&TxnEntry{
+			Operation: DeleteOperation,
+			Entry:     &Entry{Key: "txn/delete"},
+		},
+		&TxnEntry{
+			Operation: PutOperation,
+			Entry:     &Entry{Key: "txn/put", Value: []byte("updated")},
+		},
+	}
+	if err := tb.Transaction(txns); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	out, err := b.Get("txn/put")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out == nil || string(out.Value) != "updated" {
+		t.Fatalf("bad: %#v", out)
+	}
+	out, err = b.Get("txn/delete")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out != nil {
+		t.Fatalf("bad: %#v", out)
+	}
+
+	// Invalid operations fail the whole transaction
+	txns = []*TxnEntry{
+		&TxnEntry{
+			Operation: DeleteOperation,
+			Entry:     &Entry{Key: "txn/put"},
+		},
+		&TxnEntry{
+			Operation: "bad",
+			Entry:     &Entry{Key: "txn/bad"},
+		},
+	}
+	if err := tb.Transaction(txns); err == nil {
+		t.Fatal("expected error")
+	}
+	out, err = b.Get("txn/put")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out == nil {
+		t.Fatal("transaction should not be applied")
+	}
+
+	if err := b.Delete("txn/put"); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+}
+
 func testHABackend(t *testing.T, b HABackend, b2 HABackend) {
 	// Get the lock
 	lock, err := b.LockWith("foo", "bar")
@@ -330,3 +400,36 @@ func testHABackend(t *testing.T, b HABackend, b2 HABackend) {
 	// Cleanup
 	lock2.Unlock()
 }
+
+func TestParseHALockTimings(t *testing.T) {
+	ttl, renew, err := parseHALockTimings(map[string]string{}, 15*time.Second, 5*time.Second)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if ttl != 15*time.Second || renew != 5*time.Second {
+		t.Fatalf("bad: %v %v", ttl, renew)
+	}
+
+	ttl, renew, err = parseHALockTimings(map[string]string{
+		"ha_lock_ttl":            "1m",
+		"ha_lock_renew_interval": "10s",
+	}, 15*time.Second, 5*time.Second)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if ttl != time.Minute || renew != 10*time.Second {
+		t.Fatalf("bad: %v %v", ttl, renew)
+	}
+
+	badConfs := []map[string]string{
+		{"ha_lock_ttl": "foo"},
+		{"ha_lock_renew_interval": "foo"},
+		{"ha_lock_ttl": "5s"},
+		{"ha_lock_renew_interval": "0s"},
+	}
+	for _, conf := range badConfs {
+		if _, _, err := parseHALockTimings(conf, 15*time.Second, 5*time.Second); err == nil {
+			t.Fatalf("expected error for %v", conf)
+		}
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"io"
 	"os"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
 
@@ -22,9 +23,10 @@ import (
 // S3Backend is a physical backend that stores data
 // within an S3 bucket.
 type S3Backend struct {
-	bucket string
-	client *s3.S3
-	logger log.Logger
+	bucket   string
+	client   *s3.S3
+	logger   log.Logger
+	kmsKeyID string
 }
 
 // newS3Backend constructs a S3 backend using a pre-existing
@@ -64,6 +66,26 @@ func newS3Backend(conf map[string]string, logger log.Logger) (Backend, error) {
 		}
 	}
 
+	// S3-compatible stores often don't support virtual-hosted-style
+	// addressing, where the bucket is part of the host name
+	forcePathStyle := os.Getenv("AWS_S3_FORCE_PATH_STYLE")
+	if forcePathStyle == "" {
+		forcePathStyle = conf["s3_force_path_style"]
+	}
+	forcePathStyleBool := false
+	if forcePathStyle != "" {
+		var err error
+		forcePathStyleBool, err = strconv.ParseBool(forcePathStyle)
+		if err != nil {
+			return nil, fmt.Errorf("invalid s3_force_path_style: %s", forcePathStyle)
+		}
+	}
+
+	kmsKeyID := os.Getenv("AWS_S3_KMS_KEY_ID")
+	if kmsKeyID == "" {
+		kmsKeyID = conf["kms_key_id"]
+	}
+
 	credsConfig := &awsutil.CredentialsConfig{
 		AccessKey:    accessKey,
 		SecretKey:    secretKey,
@@ -75,9 +97,10 @@ func newS3Backend(conf map[string]string, logger log.Logger) (Backend, error) {
 	}
 
 	s3conn := s3.New(session.New(&aws.Config{
-		Credentials: creds,
-		Endpoint:    aws.String(endpoint),
-		Region:      aws.String(region),
+		Credentials:      creds,
+		Endpoint:         aws.String(endpoint),
+		Region:           aws.String(region),
+		S3ForcePathStyle: aws.Bool(forcePathStyleBool),
 	}))
 
 	_, err = s3conn.HeadBucket(&s3.HeadBucketInput{Bucket: &bucket})
@@ -86,9 +109,10 @@ func newS3Backend(conf map[string]string, logger log.Logger) (Backend, error) {
 	}
 
 	s := &S3Backend{
-		client: s3conn,
-		bucket: bucket,
-		logger: logger,
+		client:   s3conn,
+		bucket:   bucket,
+		logger:   logger,
+		kmsKeyID: kmsKeyID,
 	}
 	return s, nil
 }
@@ -97,11 +121,20 @@ func newS3Backend(conf map[string]string, logger log.Logger) (Backend, error) {
 func (s *S3Backend) Put(entry *Entry) error {
 	defer metrics.MeasureSince([]string{"s3", "put"}, time.Now())
 
-	_, err := s.client.PutObject(&s3.PutObjectInput{
+	input := &s3.PutObjectInput{
 		Bucket: aws.String(s.bucket),
 		Key:    aws.String(entry.Key),
 		Body:   bytes.NewReader(entry.Value),
-	})
+	}
+
+	// Objects are encrypted with the KMS key if one is set, and with the
+	// default encryption of the bucket otherwise
+	if s.kmsKeyID != "" {
+		input.ServerSideEncryption = aws.String(s3.ServerSideEncryptionAwsKms)
+		input.SSEKMSKeyId = aws.String(s.kmsKeyID)
+	}
+
+	_, err := s.client.PutObject(input)
 
 	if err != nil {
 		return err
@@ -168,29 +201,28 @@ func (s *S3Backend) Delete(key string) error {
 func (s *S3Backend) List(prefix string) ([]string, error) {
 	defer metrics.MeasureSince([]string{"s3", "list"}, time.Now())
 
-	resp, err := s.client.ListObjects(&s3.ListObjectsInput{
+	// S3 returns at most 1000 objects per request
+	keys := []string{}
+	err := s.client.ListObjectsPages(&s3.ListObjectsInput{
 		Bucket: aws.String(s.bucket),
 		Prefix: aws.String(prefix),
+	}, func(page *s3.ListObjectsOutput, lastPage bool) bool {
+		for _, key := range page.Contents {
+			key := strings.TrimPrefix(*key.Key, prefix)
+
+			if i := strings.Index(key, "/"); i == -1 {
+				// Add objects only from the current 'folder'
+				keys = append(keys, key)
+			} else if i != -1 {
+				// Add truncated 'folder' paths
+				keys = appendIfMissing(keys, key[:i+1])
+			}
+		}
+		return true
 	})
 	if err != nil {
 		return nil, err
 	}
-	if resp == nil {
-		return nil, fmt.Errorf("nil response from S3 but no error")
-	}
-
-	keys := []string{}
-	for _, key := range resp.Contents {
-		key := strings.TrimPrefix(*key.Key, prefix)
-
-		if i := strings.Index(key, "/"); i == -1 {
-			// Add objects only from the current 'folder'
-			keys = append(keys, key)
-		} else if i != -1 {
-			// Add truncated 'folder' paths
-			keys = appendIfMissing(keys, key[:i+1])
-		}
-	}
 
 	sort.Strings(keys)
 
@@ -2,8 +2,16 @@ package physical
 
 import (
 	"fmt"
+	"io/ioutil"
 	"math/rand"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -91,3 +99,105 @@ func TestS3Backend(t *testing.T) {
 	testBackend_ListPrefix(t, b)
 
 }
+
+// testS3Server is an in-memory S3 server only serving path-style requests
+// for a single bucket, and listing two objects per page
+type testS3Server struct {
+	sync.Mutex
+	objects map[string][]byte
+	headers map[string]http.Header
+}
+
+func (s *testS3Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.Lock()
+	defer s.Unlock()
+
+	if !strings.HasPrefix(r.URL.Path, "/vault") {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	key := strings.TrimPrefix(strings.TrimPrefix(r.URL.Path, "/vault"), "/")
+
+	switch {
+	case r.Method == "HEAD" && key == "":
+	case r.Method == "GET" && key == "":
+		var keys []string
+		for k := range s.objects {
+			if strings.HasPrefix(k, r.URL.Query().Get("prefix")) && k > r.URL.Query().Get("marker") {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		truncated := len(keys) > 2
+		if truncated {
+			keys = keys[:2]
+		}
+		fmt.Fprintf(w, "<ListBucketResult><IsTruncated>%t</IsTruncated>", truncated)
+		for _, k := range keys {
+			fmt.Fprintf(w, "<Contents><Key>%s</Key></Contents>", k)
+		}
+		fmt.Fprint(w, "</ListBucketResult>")
+	case r.Method == "PUT":
+		value, _ := ioutil.ReadAll(r.Body)
+		s.objects[key] = value
+		s.headers[key] = r.Header
+	case r.Method == "GET":
+		value, ok := s.objects[key]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Length", strconv.Itoa(len(value)))
+		w.Write(value)
+	case r.Method == "DELETE":
+		delete(s.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func TestS3Backend_pathStyle(t *testing.T) {
+	server := &testS3Server{
+		objects: make(map[string][]byte),
+		headers: make(map[string]http.Header),
+	}
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	logger := logformat.NewVaultLogger(log.LevelTrace)
+	b, err := NewBackend("s3", logger, map[string]string{
+		"access_key":          "foo",
+		"secret_key":          "bar",
+		"bucket":              "vault",
+		"endpoint":            ts.URL,
+		"s3_force_path_style": "true",
+		"kms_key_id":          "alias/vault",
+	})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	testBackend(t, b)
+	testBackend_ListPrefix(t, b)
+
+	// Keys are listed across pages
+	for i := 0; i < 5; i++ {
+		if err := b.Put(&Entry{Key: fmt.Sprintf("page/%d", i), Value: []byte("bar")}); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+	}
+	keys, err := b.List("page/")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !reflect.DeepEqual(keys, []string{"0", "1", "2", "3", "4"}) {
+		t.Fatalf("bad: %#v", keys)
+	}
+
+	// Objects are encrypted with the KMS key
+	header := server.headers["page/0"]
+	if header.Get("X-Amz-Server-Side-Encryption") != "aws:kms" || header.Get("X-Amz-Server-Side-Encryption-Aws-Kms-Key-Id") != "alias/vault" {
+		t.Fatalf("bad: %#v", header)
+	}
+}
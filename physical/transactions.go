@@ -0,0 +1,114 @@
+package physical
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/go-multierror"
+)
+
+// Operation is the type of an operation of a transaction
+type Operation string
+
+const (
+	PutOperation    Operation = "put"
+	DeleteOperation Operation = "delete"
+)
+
+// TxnEntry is an operation of a transaction. The entry of a delete
+// operation only needs its key.
+type TxnEntry struct {
+	Operation Operation
+	Entry     *Entry
+}
+
+// Transactional is an optional interface for backends that can apply
+// several operations atomically: either all the operations are applied,
+// or none are.
+type Transactional interface {
+	// Transaction applies the operations in order, atomically
+	Transaction([]*TxnEntry) error
+}
+
+// ApplyTransaction applies the operations of a transaction atomically if
+// the backend is Transactional. Otherwise, the operations are applied one
+// at a time, and those already applied are rolled back if one fails.
+func ApplyTransaction(b Backend, txns []*TxnEntry) error {
+	if t, ok := b.(Transactional); ok {
+		return t.Transaction(txns)
+	}
+	return genericTransaction(b, txns)
+}
+
+// genericTransaction applies the operations one at a time, restoring the
+// previous values of the keys on failure
+func genericTransaction(b Backend, txns []*TxnEntry) error {
+	if err := validateTransaction(txns); err != nil {
+		return err
+	}
+
+	// Read the previous values to roll back to
+	previous := make([]*Entry, len(txns))
+	for i, txn := range txns {
+		entry, err := b.Get(txn.Entry.Key)
+		if err != nil {
+			return err
+		}
+		previous[i] = entry
+	}
+
+	for i, txn := range txns {
+		err := applyTxnEntry(b, txn)
+		if err == nil {
+			continue
+		}
+
+		// Roll back in the reverse order, so that keys modified several
+		// times get their first value back
+		var retErr error
+		retErr = multierror.Append(retErr, err)
+		for j := i - 1; j >= 0; j-- {
+			rollback := &TxnEntry{
+				Operation: DeleteOperation,
+				Entry:     txns[j].Entry,
+			}
+			if previous[j] != nil {
+				rollback = &TxnEntry{
+					Operation: PutOperation,
+					Entry:     previous[j],
+				}
+			}
+			if err := applyTxnEntry(b, rollback); err != nil {
+				retErr = multierror.Append(retErr, fmt.Errorf("failed to roll back %q: %v", txns[j].Entry.Key, err))
+			}
+		}
+		return retErr
+	}
+	return nil
+}
+
+func applyTxnEntry(b Backend, txn *TxnEntry) error {
+	switch txn.Operation {
+	case PutOperation:
+		return b.Put(txn.Entry)
+	case DeleteOperation:
+		return b.Delete(txn.Entry.Key)
+	default:
+		return fmt.Errorf("unsupported transaction operation: %q", txn.Operation)
+	}
+}
+
+// validateTransaction checks the operations of a transaction before they
+// are applied
+func validateTransaction(txns []*TxnEntry) error {
+	for _, txn := range txns {
+		if txn.Entry == nil {
+			return fmt.Errorf("missing transaction entry")
+		}
+		switch txn.Operation {
+		case PutOperation, DeleteOperation:
+		default:
+			return fmt.Errorf("unsupported transaction operation: %q", txn.Operation)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+package physical
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/vault/helper/logformat"
+	log "github.com/mgutz/logxi/v1"
+)
+
+// testFailingBackend is a backend failing to write a key. Embedding the
+// interface hides the Transaction method of the underlying backend.
+type testFailingBackend struct {
+	Backend
+	key string
+}
+
+func (b *testFailingBackend) Put(entry *Entry) error {
+	if entry.Key == b.key {
+		return fmt.Errorf("failure")
+	}
+	return b.Backend.Put(entry)
+}
+
+func TestApplyTransaction_rollback(t *testing.T) {
+	logger := logformat.NewVaultLogger(log.LevelTrace)
+	b := &testFailingBackend{
+		Backend: NewInmem(logger),
+		key:     "fail",
+	}
+	b.Put(&Entry{Key: "foo", Value: []byte("previous")})
+	b.Put(&Entry{Key: "bar", Value: []byte("previous")})
+
+	// The generic handler applies the operations one at a time
+	txns := []*TxnEntry{
+		&TxnEntry{
+			Operation: PutOperation,
+			Entry:     &Entry{Key: "foo", Value: []byte("test")},
+		},
+		&TxnEntry{
+			Operation: DeleteOperation,
+			Entry:     &Entry{Key: "bar"},
+		},
+		&TxnEntry{
+			Operation: PutOperation,
+			Entry:     &Entry{Key: "baz", Value: []byte("test")},
+		},
+	}
+	if err := ApplyTransaction(b, txns); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	for key, value := range map[string]string{"foo": "test", "baz": "test"} {
+		out, err := b.Get(key)
+		if err != nil || out == nil || string(out.Value) != value {
+			t.Fatalf("bad: %s: %#v %v", key, out, err)
+		}
+	}
+	if out, err := b.Get("bar"); err != nil || out != nil {
+		t.Fatalf("bad: %#v %v", out, err)
+	}
+
+	// The operations applied before a failure are rolled back
+	txns = []*TxnEntry{
+		&TxnEntry{
+			Operation: PutOperation,
+			Entry:     &Entry{Key: "foo", Value: []byte("first")},
+		},
+		&TxnEntry{
+			Operation: PutOperation,
+			Entry:     &Entry{Key: "foo", Value: []byte("second")},
+		},
+		&TxnEntry{
+			Operation: DeleteOperation,
+			Entry:     &Entry{Key: "baz"},
+		},
+		&TxnEntry{
+			Operation: PutOperation,
+			Entry:     &Entry{Key: "bar", Value: []byte("test")},
+		},
+		&TxnEntry{
+			Operation: PutOperation,
+			Entry:     &Entry{Key: "fail", Value: []byte("test")},
+		},
+	}
+	if err := ApplyTransaction(b, txns); err == nil {
+		t.Fatal("expected error")
+	}
+	for key, value := range map[string]string{"foo": "test", "baz": "test"} {
+		out, err := b.Get(key)
+		if err != nil || out == nil || string(out.Value) != value {
+			t.Fatalf("bad: %s: %#v %v", key, out, err)
+		}
+	}
+	if out, err := b.Get("bar"); err != nil || out != nil {
+		t.Fatalf("bad: %#v %v", out, err)
+	}
+}
+
+func TestCache_Transaction(t *testing.T) {
+	logger := logformat.NewVaultLogger(log.LevelTrace)
+	inm := NewInmem(logger)
+	cache := NewCache(inm, 0, nil, logger)
+	testTransactionalBackend(t, cache)
+
+	// Keys of failed transactions aren't cached
+	cache.Put(&Entry{Key: "foo", Value: []byte("test")})
+	inm.Put(&Entry{Key: "foo", Value: []byte("updated")})
+	txns := []*TxnEntry{
+		&TxnEntry{
+			Operation: PutOperation,
+			Entry:     &Entry{Key: "foo", Value: []byte("test")},
+		},
+		&TxnEntry{
+			Operation: "bad",
+			Entry:     &Entry{Key: "bar"},
+		},
+	}
+	if err := cache.Transaction(txns); err == nil {
+		t.Fatal("expected error")
+	}
+	out, err := cache.Get("foo")
+	if err != nil || out == nil || string(out.Value) != "updated" {
+		t.Fatalf("bad: %#v %v", out, err)
+	}
+}
@@ -1,9 +1,12 @@
 package physical
 
 import (
+	"crypto/tls"
 	"fmt"
+	"net"
 	"path/filepath"
 	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -104,8 +107,32 @@ func newZookeeperBackend(conf map[string]string, logger log.Logger) (Backend, er
 		}
 	}
 
+	// Nodes created by an authenticated client are only accessible to the
+	// identities it authenticated with, unless an owner is set
+	if _, ok := conf["znode_owner"]; !ok && useAddAuth {
+		acl = zk.AuthACL(zk.PermAll)
+	}
+
+	// Additional ACLs, in the zkCli format
+	if aclsStr, ok := conf["znode_acls"]; ok {
+		acls, err := parseZookeeperACLs(aclsStr)
+		if err != nil {
+			return nil, err
+		}
+		acl = append(acl, acls...)
+	}
+
+	dialer := zk.Dialer(net.DialTimeout)
+	if tlsEnabled, _ := strconv.ParseBool(conf["tls_enabled"]); tlsEnabled {
+		tlsConfig, err := setupTLSConfig(conf)
+		if err != nil {
+			return nil, err
+		}
+		dialer = zookeeperTLSDialer(tlsConfig)
+	}
+
 	// We have all of the configuration in hand - let's try and connect to ZK
-	client, _, err := zk.Connect(strings.Split(machines, ","), time.Second)
+	client, _, err := zk.Connect(strings.Split(machines, ","), time.Second, zk.WithDialer(dialer))
 	if err != nil {
 		return nil, fmt.Errorf("client setup failed: %v", err)
 	}
@@ -128,6 +155,65 @@ func newZookeeperBackend(conf map[string]string, logger log.Logger) (Backend, er
 	return c, nil
 }
 
+// zookeeperPerms maps the letters of the zkCli permissions to their value
+var zookeeperPerms = map[rune]int32{
+	'c': zk.PermCreate,
+	'r': zk.PermRead,
+	'w': zk.PermWrite,
+	'd': zk.PermDelete,
+	'a': zk.PermAdmin,
+}
+
+// parseZookeeperACLs parses a comma-separated list of ACLs in the zkCli
+// format, 'schema:id:perms', where perms are letters among 'crwda'
+func parseZookeeperACLs(aclsStr string) ([]zk.ACL, error) {
+	var acls []zk.ACL
+	for _, aclStr := range strings.Split(aclsStr, ",") {
+		aclStr = strings.TrimSpace(aclStr)
+		i := strings.Index(aclStr, ":")
+		j := strings.LastIndex(aclStr, ":")
+		if i <= 0 || j <= i+1 || j == len(aclStr)-1 {
+			return nil, fmt.Errorf("znode_acls expected format is 'schema:id:perms'")
+		}
+
+		var perms int32
+		for _, p := range aclStr[j+1:] {
+			perm, ok := zookeeperPerms[p]
+			if !ok {
+				return nil, fmt.Errorf("invalid permission %q in znode_acls, expected letters among 'crwda'", p)
+			}
+			perms |= perm
+		}
+
+		acls = append(acls, zk.ACL{
+			Perms:  perms,
+			Scheme: aclStr[:i],
+			ID:     aclStr[i+1 : j],
+		})
+	}
+	return acls, nil
+}
+
+// zookeeperTLSDialer returns a dialer connecting to the Zookeeper servers
+// over TLS, verifying the certificate of each server against its host name
+func zookeeperTLSDialer(tlsConfig *tls.Config) zk.Dialer {
+	return func(network, address string, timeout time.Duration) (net.Conn, error) {
+		host, _, err := net.SplitHostPort(address)
+		if err != nil {
+			return nil, err
+		}
+
+		dialer := &net.Dialer{Timeout: timeout}
+		return tls.DialWithDialer(dialer, network, address, &tls.Config{
+			Certificates:       tlsConfig.Certificates,
+			RootCAs:            tlsConfig.RootCAs,
+			MinVersion:         tlsConfig.MinVersion,
+			InsecureSkipVerify: tlsConfig.InsecureSkipVerify,
+			ServerName:         host,
+		})
+	}
+}
+
 // ensurePath is used to create each node in the path hierarchy.
 // We avoid calling this optimistically, and invoke it when we get
 // an error during an operation
@@ -3,6 +3,7 @@ package physical
 import (
 	"fmt"
 	"os"
+	"reflect"
 	"testing"
 	"time"
 
@@ -96,3 +97,24 @@ func TestZookeeperHABackend(t *testing.T) {
 	}
 	testHABackend(t, ha, ha)
 }
+
+func TestZookeeperACLs(t *testing.T) {
+	acls, err := parseZookeeperACLs("digest:vault:c2FsdA==:rw, ip:10.0.0.0/8:crwda,world:anyone:r")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	expected := []zk.ACL{
+		{Perms: zk.PermRead | zk.PermWrite, Scheme: "digest", ID: "vault:c2FsdA=="},
+		{Perms: zk.PermAll, Scheme: "ip", ID: "10.0.0.0/8"},
+		{Perms: zk.PermRead, Scheme: "world", ID: "anyone"},
+	}
+	if !reflect.DeepEqual(acls, expected) {
+		t.Fatalf("bad: %#v", acls)
+	}
+
+	for _, invalid := range []string{"", "digest:vault", ":vault:r", "digest::r", "digest:vault:", "world:anyone:rx"} {
+		if _, err := parseZookeeperACLs(invalid); err == nil {
+			t.Fatalf("expected error for %q", invalid)
+		}
+	}
+}
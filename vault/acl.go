@@ -1,7 +1,11 @@
 package vault
 
 import (
+	"fmt"
+	"strings"
+
 	"github.com/armon/go-radix"
+	"github.com/hashicorp/vault/helper/strutil"
 	"github.com/hashicorp/vault/logical"
 )
 
@@ -14,17 +18,32 @@ type ACL struct {
 	// globRules contains the path policies that glob
 	globRules *radix.Tree
 
+	// segmentWildcardRules contains the path policies with '+' segment
+	// wildcards, keyed by their path including the trailing '*' of globs
+	segmentWildcardRules *radix.Tree
+
 	// root is enabled if the "root" named policy is present.
 	root bool
 }
 
+// aclPermissions are the permissions granted on a path by the combined
+// policies of an ACL
+type aclPermissions struct {
+	CapabilitiesBitmap uint32
+	ControlGroup       *ControlGroup
+	AllowedParameters  map[string][]interface{}
+	DeniedParameters   map[string][]interface{}
+	RequiredParameters []string
+}
+
 // New is used to construct a policy based ACL from a set of policies.
 func NewACL(policies []*Policy) (*ACL, error) {
 	// Initialize
 	a := &ACL{
-		exactRules: radix.New(),
-		globRules:  radix.New(),
-		root:       false,
+		exactRules:           radix.New(),
+		globRules:            radix.New(),
+		segmentWildcardRules: radix.New(),
+		root:                 false,
 	}
 
 	// Inject each policy
@@ -40,60 +59,213 @@ func NewACL(policies []*Policy) (*ACL, error) {
 		for _, pc := range policy.Paths {
 			// Check which tree to use
 			tree := a.exactRules
-			if pc.Glob {
+			key := pc.Prefix
+			switch {
+			case hasSegmentWildcard(pc.Prefix):
+				tree = a.segmentWildcardRules
+				if pc.Glob {
+					key += "*"
+				}
+			case pc.Glob:
 				tree = a.globRules
 			}
 
 			// Check for an existing policy
-			raw, ok := tree.Get(pc.Prefix)
+			raw, ok := tree.Get(key)
 			if !ok {
-				tree.Insert(pc.Prefix, pc.CapabilitiesBitmap)
+				perms := &aclPermissions{
+					CapabilitiesBitmap: pc.CapabilitiesBitmap,
+					RequiredParameters: append([]string{}, pc.RequiredParameters...),
+				}
+				perms.mergeControlGroup(pc.ControlGroup)
+				perms.AllowedParameters = mergeParameters(nil, pc.AllowedParameters)
+				perms.DeniedParameters = mergeParameters(nil, pc.DeniedParameters)
+				tree.Insert(key, perms)
 				continue
 			}
-			existing := raw.(uint32)
+			existing := raw.(*aclPermissions)
 
 			switch {
-			case existing&DenyCapabilityInt > 0:
+			case existing.CapabilitiesBitmap&DenyCapabilityInt > 0:
 				// If we are explicitly denied in the existing capability set,
 				// don't save anything else
+				continue
 
 			case pc.CapabilitiesBitmap&DenyCapabilityInt > 0:
 				// If this new policy explicitly denies, only save the deny value
-				tree.Insert(pc.Prefix, DenyCapabilityInt)
+				tree.Insert(key, &aclPermissions{
+					CapabilitiesBitmap: DenyCapabilityInt,
+				})
+				continue
 
 			default:
 				// Insert the capabilities in this new policy into the existing
 				// value
-				tree.Insert(pc.Prefix, existing|pc.CapabilitiesBitmap)
+				existing.CapabilitiesBitmap |= pc.CapabilitiesBitmap
+			}
+
+			existing.mergeControlGroup(pc.ControlGroup)
+			existing.AllowedParameters = mergeParameters(existing.AllowedParameters, pc.AllowedParameters)
+			existing.DeniedParameters = mergeParameters(existing.DeniedParameters, pc.DeniedParameters)
+			for _, param := range pc.RequiredParameters {
+				if !strutil.StrListContains(existing.RequiredParameters, param) {
+					existing.RequiredParameters = append(existing.RequiredParameters, param)
+				}
 			}
 		}
 	}
 	return a, nil
 }
 
+// mergeControlGroup combines the control group with the existing one, so
+// that every factor has to be satisfied
+func (p *aclPermissions) mergeControlGroup(cg *ControlGroup) {
+	if cg == nil {
+		return
+	}
+	if p.ControlGroup == nil {
+		p.ControlGroup = &ControlGroup{
+			TTL:     cg.TTL,
+			Factors: cg.Factors,
+		}
+		return
+	}
+
+	merged := &ControlGroup{
+		TTL:     cg.TTL,
+		Factors: append(append([]*ControlGroupFactor{}, p.ControlGroup.Factors...), cg.Factors...),
+	}
+	if p.ControlGroup.TTL != 0 && (merged.TTL == 0 || p.ControlGroup.TTL < merged.TTL) {
+		merged.TTL = p.ControlGroup.TTL
+	}
+	p.ControlGroup = merged
+}
+
+// mergeParameters combines allowed or denied parameters. An empty list of
+// values, which stands for any value, takes precedence over listed values.
+func mergeParameters(existing, params map[string][]interface{}) map[string][]interface{} {
+	if len(params) == 0 {
+		return existing
+	}
+
+	merged := make(map[string][]interface{}, len(existing)+len(params))
+	for key, values := range existing {
+		merged[key] = values
+	}
+	for key, values := range params {
+		current, ok := merged[key]
+		switch {
+		case !ok:
+			merged[key] = values
+		case len(current) == 0 || len(values) == 0:
+			merged[key] = []interface{}{}
+		default:
+			merged[key] = append(append([]interface{}{}, current...), values...)
+		}
+	}
+	return merged
+}
+
+// permissions returns the permissions on the given path: those of an exact
+// matching rule, or else of the most specific matching glob or '+' segment
+// wildcard rule
+func (a *ACL) permissions(path string) *aclPermissions {
+	if raw, ok := a.exactRules.Get(path); ok {
+		return raw.(*aclPermissions)
+	}
+
+	var pattern string
+	var perms *aclPermissions
+	if prefix, raw, ok := a.globRules.LongestPrefix(path); ok {
+		pattern = prefix + "*"
+		perms = raw.(*aclPermissions)
+	}
+
+	a.segmentWildcardRules.Walk(func(candidate string, raw interface{}) bool {
+		if segmentWildcardMatch(candidate, path) && (perms == nil || morePrecisePattern(candidate, pattern)) {
+			pattern = candidate
+			perms = raw.(*aclPermissions)
+		}
+		return false
+	})
+
+	return perms
+}
+
+// hasSegmentWildcard returns whether a policy path has a '+' segment
+func hasSegmentWildcard(path string) bool {
+	for _, segment := range strings.Split(path, "/") {
+		if segment == "+" {
+			return true
+		}
+	}
+	return false
+}
+
+// segmentWildcardMatch returns whether the path matches the pattern, in
+// which a '+' segment matches any single segment and a trailing '*' any
+// suffix
+func segmentWildcardMatch(pattern, path string) bool {
+	glob := strings.HasSuffix(pattern, "*")
+	patternSegments := strings.Split(strings.TrimSuffix(pattern, "*"), "/")
+	pathSegments := strings.Split(path, "/")
+
+	if len(pathSegments) < len(patternSegments) ||
+		(!glob && len(pathSegments) != len(patternSegments)) {
+		return false
+	}
+
+	last := len(patternSegments) - 1
+	for i, segment := range patternSegments {
+		switch {
+		case segment == "+":
+		case glob && i == last:
+			if !strings.HasPrefix(pathSegments[i], segment) {
+				return false
+			}
+		case segment != pathSegments[i]:
+			return false
+		}
+	}
+	return true
+}
+
+// morePrecisePattern returns whether the policy path a takes precedence over
+// b when both match a path. The path whose first wildcard comes later wins,
+// then the one that isn't a glob, then the one with fewer '+' segments, and
+// finally the longer one.
+func morePrecisePattern(a, b string) bool {
+	aWildcard, bWildcard := strings.IndexAny(a, "+*"), strings.IndexAny(b, "+*")
+	if aWildcard != bWildcard {
+		return aWildcard > bWildcard
+	}
+
+	aGlob, bGlob := strings.HasSuffix(a, "*"), strings.HasSuffix(b, "*")
+	if aGlob != bGlob {
+		return bGlob
+	}
+
+	aSegments, bSegments := strings.Count(a, "+"), strings.Count(b, "+")
+	if aSegments != bSegments {
+		return aSegments < bSegments
+	}
+
+	return len(a) > len(b)
+}
+
 func (a *ACL) Capabilities(path string) (pathCapabilities []string) {
 	// Fast-path root
 	if a.root {
 		return []string{RootCapability}
 	}
 
-	// Find an exact matching rule, look for glob if no match
-	var capabilities uint32
-	raw, ok := a.exactRules.Get(path)
-	if ok {
-		capabilities = raw.(uint32)
-		goto CHECK
-	}
-
-	// Find a glob rule, default deny if no match
-	_, raw, ok = a.globRules.LongestPrefix(path)
-	if !ok {
+	// Find the matching rule, default deny if no match
+	perms := a.permissions(path)
+	if perms == nil {
 		return []string{DenyCapability}
-	} else {
-		capabilities = raw.(uint32)
 	}
+	capabilities := perms.CapabilitiesBitmap
 
-CHECK:
 	if capabilities&SudoCapabilityInt > 0 {
 		pathCapabilities = append(pathCapabilities, SudoCapability)
 	}
@@ -135,23 +307,13 @@ func (a *ACL) AllowOperation(op logical.Operation, path string) (allowed bool, s
 		return true, false
 	}
 
-	// Find an exact matching rule, look for glob if no match
-	var capabilities uint32
-	raw, ok := a.exactRules.Get(path)
-	if ok {
-		capabilities = raw.(uint32)
-		goto CHECK
-	}
-
-	// Find a glob rule, default deny if no match
-	_, raw, ok = a.globRules.LongestPrefix(path)
-	if !ok {
+	// Find the matching rule, default deny if no match
+	perms := a.permissions(path)
+	if perms == nil {
 		return false, false
-	} else {
-		capabilities = raw.(uint32)
 	}
+	capabilities := perms.CapabilitiesBitmap
 
-CHECK:
 	// Check if the minimum permissions are met
 	// If "deny" has been explicitly set, only deny will be in the map, so we
 	// only need to check for the existence of other values
@@ -161,7 +323,7 @@ CHECK:
 		allowed = capabilities&ReadCapabilityInt > 0
 	case logical.ListOperation:
 		allowed = capabilities&ListCapabilityInt > 0
-	case logical.UpdateOperation:
+	case logical.UpdateOperation, logical.PatchOperation:
 		allowed = capabilities&UpdateCapabilityInt > 0
 	case logical.DeleteOperation:
 		allowed = capabilities&DeleteCapabilityInt > 0
@@ -177,3 +339,94 @@ CHECK:
 	}
 	return
 }
+
+// ControlGroup returns the control group that applies to the given path, or
+// nil if requests to the path don't require any approval
+func (a *ACL) ControlGroup(path string) *ControlGroup {
+	if a.root {
+		return nil
+	}
+
+	perms := a.permissions(path)
+	if perms == nil {
+		return nil
+	}
+	return perms.ControlGroup
+}
+
+// AllowParameters checks the data of a request to the given path against the
+// allowed, denied and required parameters of the policies. Values are
+// compared by their string representation, and a value ending with '*'
+// matches any value with that prefix.
+func (a *ACL) AllowParameters(path string, data map[string]interface{}) error {
+	if a.root {
+		return nil
+	}
+
+	perms := a.permissions(path)
+	if perms == nil {
+		return nil
+	}
+
+	for _, key := range perms.RequiredParameters {
+		if _, ok := data[key]; !ok {
+			return fmt.Errorf("missing required parameter %q", key)
+		}
+	}
+
+	for key, value := range data {
+		if denied, ok := parameterValues(perms.DeniedParameters, key); ok {
+			if len(denied) == 0 || valueInParameterList(value, denied, false) {
+				return fmt.Errorf("parameter %q is denied", key)
+			}
+		}
+
+		if len(perms.AllowedParameters) == 0 {
+			continue
+		}
+		allowed, ok := parameterValues(perms.AllowedParameters, key)
+		if !ok {
+			return fmt.Errorf("parameter %q is not allowed", key)
+		}
+		if len(allowed) > 0 && !valueInParameterList(value, allowed, true) {
+			return fmt.Errorf("value of parameter %q is not allowed", key)
+		}
+	}
+
+	return nil
+}
+
+// parameterValues returns the values listed for the parameter, falling back
+// to those of the '*' parameter
+func parameterValues(params map[string][]interface{}, key string) ([]interface{}, bool) {
+	if values, ok := params[key]; ok {
+		return values, true
+	}
+	values, ok := params["*"]
+	return values, ok
+}
+
+// valueInParameterList returns whether the value is in the list. For a list
+// value, all must be in the list if all is true, and any otherwise.
+func valueInParameterList(value interface{}, list []interface{}, all bool) bool {
+	if values, ok := value.([]interface{}); ok {
+		for _, v := range values {
+			if valueInParameterList(v, list, all) != all {
+				return !all
+			}
+		}
+		return all
+	}
+
+	str := fmt.Sprintf("%v", value)
+	for _, item := range list {
+		pattern := fmt.Sprintf("%v", item)
+		if pattern == str {
+			return true
+		}
+		if strings.HasSuffix(pattern, "*") && strings.HasPrefix(str, strings.TrimSuffix(pattern, "*")) {
+			return true
+		}
+	}
+	return false
+}
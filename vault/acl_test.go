@@ -3,6 +3,7 @@ package vault
 import (
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/hashicorp/vault/logical"
 )
@@ -98,6 +99,7 @@ func TestACL_Single(t *testing.T) {
 
 		{logical.ReadOperation, "dev/foo", true, true},
 		{logical.UpdateOperation, "dev/foo", true, true},
+		{logical.PatchOperation, "dev/foo", true, true},
 
 		{logical.DeleteOperation, "stage/foo", true, false},
 		{logical.ListOperation, "stage/aws/foo", true, true},
@@ -106,6 +108,7 @@ func TestACL_Single(t *testing.T) {
 
 		{logical.DeleteOperation, "prod/foo", false, false},
 		{logical.UpdateOperation, "prod/foo", false, false},
+		{logical.PatchOperation, "prod/foo", false, false},
 		{logical.ReadOperation, "prod/foo", true, false},
 		{logical.ListOperation, "prod/foo", true, false},
 		{logical.ReadOperation, "prod/aws/foo", false, false},
@@ -255,3 +258,199 @@ path "foo/bar" {
 	capabilities = ["deny"]
 }
 `
+
+func TestACL_ControlGroup(t *testing.T) {
+	policy1, err := Parse(`
+path "secret/*" {
+	capabilities = ["read"]
+	control_group = {
+		ttl = "1h"
+		factor "managers" {
+			identity {
+				group_names = ["managers"]
+			}
+		}
+	}
+}
+
+path "secret/public" {
+	capabilities = ["read"]
+}
+`)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	policy2, err := Parse(`
+path "secret/*" {
+	capabilities = ["list"]
+	control_group = {
+		ttl = "30m"
+		factor "security" {
+			identity {
+				group_names = ["security"]
+			}
+		}
+	}
+}
+`)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	acl, err := NewACL([]*Policy{policy1, policy2})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Control groups on the same path are combined
+	cg := acl.ControlGroup("secret/foo")
+	if cg == nil || cg.TTL != 30*time.Minute || len(cg.Factors) != 2 {
+		t.Fatalf("bad: %#v", cg)
+	}
+
+	// An exact match without a control group takes precedence over a glob
+	if cg := acl.ControlGroup("secret/public"); cg != nil {
+		t.Fatalf("bad: %#v", cg)
+	}
+	if cg := acl.ControlGroup("other/foo"); cg != nil {
+		t.Fatalf("bad: %#v", cg)
+	}
+}
+
+func TestACL_AllowParameters(t *testing.T) {
+	policy1, err := Parse(`
+path "database/config/*" {
+	capabilities = ["update"]
+	allowed_parameters = {
+		"connection_url" = []
+		"insecure_tls" = []
+		"plugin_name" = ["mysql-*"]
+		"tags" = ["a", "b"]
+	}
+	denied_parameters = {
+		"insecure_tls" = ["true"]
+	}
+	required_parameters = ["connection_url"]
+}
+
+path "secret/*" {
+	capabilities = ["update"]
+	denied_parameters = {
+		"*" = []
+	}
+}
+`)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	policy2, err := Parse(`
+path "database/config/*" {
+	capabilities = ["update"]
+	allowed_parameters = {
+		"plugin_name" = []
+		"verify_connection" = []
+	}
+}
+`)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	acl, err := NewACL([]*Policy{policy1, policy2})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	type tcase struct {
+		path    string
+		data    map[string]interface{}
+		allowed bool
+	}
+	tcases := []tcase{
+		{"database/config/foo", map[string]interface{}{"connection_url": "foo"}, true},
+		{"database/config/foo", map[string]interface{}{"connection_url": "foo", "insecure_tls": false}, true},
+		{"database/config/foo", map[string]interface{}{"connection_url": "foo", "insecure_tls": true}, false},
+		{"database/config/foo", map[string]interface{}{"connection_url": "foo", "insecure_tls": "true"}, false},
+		{"database/config/foo", map[string]interface{}{"insecure_tls": false}, false},
+		{"database/config/foo", map[string]interface{}{"connection_url": "foo", "username": "foo"}, false},
+		{"database/config/foo", map[string]interface{}{"connection_url": "foo", "tags": []interface{}{"a", "b"}}, true},
+		{"database/config/foo", map[string]interface{}{"connection_url": "foo", "tags": []interface{}{"a", "c"}}, false},
+
+		// An empty list of values in another policy allows any value
+		{"database/config/foo", map[string]interface{}{"connection_url": "foo", "plugin_name": "postgres"}, true},
+		{"database/config/foo", map[string]interface{}{"connection_url": "foo", "verify_connection": true}, true},
+
+		{"secret/foo", map[string]interface{}{}, true},
+		{"secret/foo", map[string]interface{}{"foo": "bar"}, false},
+		{"other/foo", map[string]interface{}{"foo": "bar"}, true},
+	}
+
+	for _, tc := range tcases {
+		err := acl.AllowParameters(tc.path, tc.data)
+		if (err == nil) != tc.allowed {
+			t.Fatalf("bad: case %#v: %v", tc, err)
+		}
+	}
+}
+
+func TestACL_SegmentWildcard(t *testing.T) {
+	policy, err := Parse(`
+path "secret/prod/*" {
+	capabilities = ["list"]
+}
+
+path "secret/+/team-a/*" {
+	capabilities = ["read"]
+}
+
+path "secret/+/team-a/admin" {
+	capabilities = ["deny"]
+}
+
+path "secret/prod/team-a/*" {
+	capabilities = ["update"]
+}
+
+path "secret/+/+/config" {
+	capabilities = ["delete"]
+}
+
+path "secret/+/team-b/config" {
+	capabilities = ["create"]
+}
+`)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	acl, err := NewACL([]*Policy{policy})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	type tcase struct {
+		path     string
+		expected []string
+	}
+	tcases := []tcase{
+		{"secret/dev/team-a/foo", []string{"read"}},
+		{"secret/dev/team-a/foo/bar", []string{"read"}},
+		{"secret/dev/team-a", []string{"deny"}},
+		{"secret/dev/team-a/admin", []string{"deny"}},
+		{"secret/prod/team-a/foo", []string{"update"}},
+		{"secret/dev/team-c/config", []string{"delete"}},
+		{"secret/dev/team-b/config", []string{"create"}},
+		{"secret/dev/team-b/config/foo", []string{"deny"}},
+
+		// The rule whose first wildcard comes later takes precedence
+		{"secret/prod/team-b/config", []string{"list"}},
+		{"other/dev/team-a/foo", []string{"deny"}},
+	}
+
+	for _, tc := range tcases {
+		actual := acl.Capabilities(tc.path)
+		if !reflect.DeepEqual(actual, tc.expected) {
+			t.Fatalf("bad: path %q: %v", tc.path, actual)
+		}
+	}
+}
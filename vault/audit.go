@@ -278,6 +278,26 @@ func (c *Core) newAuditBackend(t string, view logical.Storage, conf map[string]s
 	})
 }
 
+// nonHMACDataKeys returns the keys of the request and response data whose
+// values the mount serving the given path logs without HMACing them
+func (c *Core) nonHMACDataKeys(path string) ([]string, []string) {
+	entry := c.router.MatchingMountEntry(path)
+	if entry == nil {
+		return nil, nil
+	}
+
+	// The keys are tuned under the lock of the mount's table
+	lock := &c.mountsLock
+	if strings.HasPrefix(path, credentialRoutePrefix) {
+		lock = &c.authLock
+	}
+	lock.RLock()
+	defer lock.RUnlock()
+
+	return append([]string(nil), entry.Config.AuditNonHMACRequestKeys...),
+		append([]string(nil), entry.Config.AuditNonHMACResponseKeys...)
+}
+
 // defaultAuditTable creates a default audit table
 func defaultAuditTable() *MountTable {
 	table := &MountTable{
@@ -346,14 +366,16 @@ func (a *AuditBroker) GetHash(name string, input string) (string, error) {
 }
 
 // LogRequest is used to ensure all the audit backends have an opportunity to
-// log the given request and that *at least one* succeeds.
-func (a *AuditBroker) LogRequest(auth *logical.Auth, req *logical.Request, outerErr error) (retErr error) {
+// log the given request and that *at least one* succeeds. The request headers
+// configured in headersConfig are recorded, hashed with the salt of each
+// backend where required.
+func (a *AuditBroker) LogRequest(in *audit.LogInput, headersConfig *AuditedHeadersConfig) (retErr error) {
 	defer metrics.MeasureSince([]string{"audit", "log_request"}, time.Now())
 	a.l.RLock()
 	defer a.l.RUnlock()
 	defer func() {
 		if r := recover(); r != nil {
-			a.logger.Error("audit: panic during logging", "request_path", req.Path, "error", r)
+			a.logger.Error("audit: panic during logging", "request_path", in.Request.Path, "error", r)
 			retErr = multierror.Append(retErr, fmt.Errorf("panic generating audit log"))
 		}
 	}()
@@ -365,11 +387,22 @@ func (a *AuditBroker) LogRequest(auth *logical.Auth, req *logical.Request, outer
 	//	return
 	//}
 
+	// Only the configured headers are recorded, restore all of them once done
+	headers := in.Request.Headers
+	defer func() {
+		in.Request.Headers = headers
+	}()
+
 	// Ensure at least one backend logs
 	anyLogged := false
 	for name, be := range a.backends {
+		in.Request.Headers = nil
+		if headersConfig != nil {
+			in.Request.Headers = headersConfig.ApplyConfig(headers, be.backend.GetHash)
+		}
+
 		start := time.Now()
-		err := be.backend.LogRequest(auth, req, outerErr)
+		err := be.backend.LogRequest(in)
 		metrics.MeasureSince([]string{"audit", name, "log_request"}, start)
 		if err != nil {
 			a.logger.Error("audit: backend failed to log request", "backend", name, "error", err)
@@ -385,24 +418,35 @@ func (a *AuditBroker) LogRequest(auth *logical.Auth, req *logical.Request, outer
 }
 
 // LogResponse is used to ensure all the audit backends have an opportunity to
-// log the given response and that *at least one* succeeds.
-func (a *AuditBroker) LogResponse(auth *logical.Auth, req *logical.Request,
-	resp *logical.Response, err error) (reterr error) {
+// log the given response and that *at least one* succeeds. Request headers
+// are recorded as in LogRequest.
+func (a *AuditBroker) LogResponse(in *audit.LogInput, headersConfig *AuditedHeadersConfig) (reterr error) {
 	defer metrics.MeasureSince([]string{"audit", "log_response"}, time.Now())
 	a.l.RLock()
 	defer a.l.RUnlock()
 	defer func() {
 		if r := recover(); r != nil {
-			a.logger.Error("audit: panic during logging", "request_path", req.Path, "error", r)
+			a.logger.Error("audit: panic during logging", "request_path", in.Request.Path, "error", r)
 			reterr = fmt.Errorf("panic generating audit log")
 		}
 	}()
 
+	// Only the configured headers are recorded, restore all of them once done
+	headers := in.Request.Headers
+	defer func() {
+		in.Request.Headers = headers
+	}()
+
 	// Ensure at least one backend logs
 	anyLogged := false
 	for name, be := range a.backends {
+		in.Request.Headers = nil
+		if headersConfig != nil {
+			in.Request.Headers = headersConfig.ApplyConfig(headers, be.backend.GetHash)
+		}
+
 		start := time.Now()
-		err := be.backend.LogResponse(auth, req, resp, err)
+		err := be.backend.LogResponse(in)
 		metrics.MeasureSince([]string{"audit", name, "log_response"}, start)
 		if err != nil {
 			a.logger.Error("audit: backend failed to log response", "backend", name, "error", err)
@@ -23,25 +23,33 @@ type NoopAudit struct {
 	Req     []*logical.Request
 	ReqErrs []error
 
+	ReqHeaders []map[string][]string
+
 	RespErr  error
 	RespAuth []*logical.Auth
 	RespReq  []*logical.Request
 	Resp     []*logical.Response
 	RespErrs []error
+
+	RespNonHMACReqDataKeys  [][]string
+	RespNonHMACRespDataKeys [][]string
 }
 
-func (n *NoopAudit) LogRequest(a *logical.Auth, r *logical.Request, err error) error {
-	n.ReqAuth = append(n.ReqAuth, a)
-	n.Req = append(n.Req, r)
-	n.ReqErrs = append(n.ReqErrs, err)
+func (n *NoopAudit) LogRequest(in *audit.LogInput) error {
+	n.ReqAuth = append(n.ReqAuth, in.Auth)
+	n.Req = append(n.Req, in.Request)
+	n.ReqErrs = append(n.ReqErrs, in.OuterErr)
+	n.ReqHeaders = append(n.ReqHeaders, in.Request.Headers)
 	return n.ReqErr
 }
 
-func (n *NoopAudit) LogResponse(a *logical.Auth, r *logical.Request, re *logical.Response, err error) error {
-	n.RespAuth = append(n.RespAuth, a)
-	n.RespReq = append(n.RespReq, r)
-	n.Resp = append(n.Resp, re)
-	n.RespErrs = append(n.RespErrs, err)
+func (n *NoopAudit) LogResponse(in *audit.LogInput) error {
+	n.RespAuth = append(n.RespAuth, in.Auth)
+	n.RespReq = append(n.RespReq, in.Request)
+	n.Resp = append(n.Resp, in.Response)
+	n.RespErrs = append(n.RespErrs, in.OuterErr)
+	n.RespNonHMACReqDataKeys = append(n.RespNonHMACReqDataKeys, in.NonHMACReqDataKeys)
+	n.RespNonHMACRespDataKeys = append(n.RespNonHMACRespDataKeys, in.NonHMACRespDataKeys)
 	return n.RespErr
 }
 
@@ -235,7 +243,11 @@ func TestAuditBroker_LogRequest(t *testing.T) {
 
 	reqErrs := errors.New("errs")
 
-	err = b.LogRequest(auth, req, reqErrs)
+	err = b.LogRequest(&audit.LogInput{
+		Auth:     auth,
+		Request:  req,
+		OuterErr: reqErrs,
+	}, nil)
 	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
@@ -254,13 +266,13 @@ func TestAuditBroker_LogRequest(t *testing.T) {
 
 	// Should still work with one failing backend
 	a1.ReqErr = fmt.Errorf("failed")
-	if err := b.LogRequest(auth, req, nil); err != nil {
+	if err := b.LogRequest(&audit.LogInput{Auth: auth, Request: req}, nil); err != nil {
 		t.Fatalf("err: %v", err)
 	}
 
 	// Should FAIL work with both failing backends
 	a2.ReqErr = fmt.Errorf("failed")
-	if err := b.LogRequest(auth, req, nil); !errwrap.Contains(err, "no audit backend succeeded in logging the request") {
+	if err := b.LogRequest(&audit.LogInput{Auth: auth, Request: req}, nil); !errwrap.Contains(err, "no audit backend succeeded in logging the request") {
 		t.Fatalf("err: %v", err)
 	}
 }
@@ -297,8 +309,14 @@ func TestAuditBroker_LogResponse(t *testing.T) {
 		},
 	}
 	respErr := fmt.Errorf("permission denied")
+	logInput := &audit.LogInput{
+		Auth:     auth,
+		Request:  req,
+		Response: resp,
+		OuterErr: respErr,
+	}
 
-	err := b.LogResponse(auth, req, resp, respErr)
+	err := b.LogResponse(logInput, nil)
 	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
@@ -320,15 +338,94 @@ func TestAuditBroker_LogResponse(t *testing.T) {
 
 	// Should still work with one failing backend
 	a1.RespErr = fmt.Errorf("failed")
-	err = b.LogResponse(auth, req, resp, respErr)
+	err = b.LogResponse(logInput, nil)
 	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
 
 	// Should FAIL work with both failing backends
 	a2.RespErr = fmt.Errorf("failed")
-	err = b.LogResponse(auth, req, resp, respErr)
+	err = b.LogResponse(logInput, nil)
 	if err.Error() != "no audit backend succeeded in logging the response" {
 		t.Fatalf("err: %v", err)
 	}
 }
+
+func TestAuditBroker_AuditHeaders(t *testing.T) {
+	l := logformat.NewVaultLogger(log.LevelTrace)
+	b := NewAuditBroker(l)
+	a1 := &NoopAudit{}
+	a2 := &NoopAudit{}
+	b.Register("foo", a1, nil)
+	b.Register("bar", a2, nil)
+
+	_, barrier, _ := mockBarrier(t)
+	view := NewBarrierView(barrier, "headers/")
+	headersConf := &AuditedHeadersConfig{
+		Headers: make(map[string]*auditedHeaderSettings),
+		view:    view,
+	}
+	if err := headersConf.add("X-Test-Header", false); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	reqHeaders := map[string][]string{
+		"X-Test-Header":  []string{"foo"},
+		"X-Vault-Token":  []string{"token"},
+		"Content-Length": []string{"0"},
+	}
+	req := &logical.Request{
+		Operation: logical.ReadOperation,
+		Path:      "sys/mounts",
+		Headers:   reqHeaders,
+	}
+
+	if err := b.LogRequest(&audit.LogInput{Request: req}, headersConf); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	expected := map[string][]string{
+		"x-test-header": []string{"foo"},
+	}
+	for _, a := range []*NoopAudit{a1, a2} {
+		if !reflect.DeepEqual(a.ReqHeaders[0], expected) {
+			t.Fatalf("Bad: %#v", a.ReqHeaders[0])
+		}
+	}
+
+	// The request headers are left untouched
+	if !reflect.DeepEqual(req.Headers, reqHeaders) {
+		t.Fatalf("Bad: %#v", req.Headers)
+	}
+}
+
+func TestCore_nonHMACDataKeys_tune(t *testing.T) {
+	c, _, root := TestCoreUnsealed(t)
+
+	// Looking up the keys while the mount is tuned must not race
+	doneCh := make(chan struct{})
+	go func() {
+		defer close(doneCh)
+		for i := 0; i < 20; i++ {
+			req := logical.TestRequest(t, logical.UpdateOperation, "sys/mounts/secret/tune")
+			req.Data["audit_non_hmac_request_keys"] = fmt.Sprintf("foo%d", i)
+			req.ClientToken = root
+			if _, err := c.HandleRequest(req); err != nil {
+				t.Errorf("err: %v", err)
+				return
+			}
+		}
+	}()
+	for {
+		select {
+		case <-doneCh:
+			reqKeys, _ := c.nonHMACDataKeys("secret/test")
+			if !reflect.DeepEqual(reqKeys, []string{"foo19"}) {
+				t.Fatalf("bad: %#v", reqKeys)
+			}
+			return
+		default:
+			c.nonHMACDataKeys("secret/test")
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package vault
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/hashicorp/vault/helper/jsonutil"
+	"github.com/hashicorp/vault/logical"
+)
+
+const (
+	// auditedHeadersSubPath is the sub-path used for the audited headers
+	// configuration
+	auditedHeadersSubPath = "audited-headers-config/"
+
+	// auditedHeadersEntry is the key the audited headers configuration is
+	// stored under
+	auditedHeadersEntry = "audited-headers"
+)
+
+// auditedHeaderSettings is the configuration of an audited request header
+type auditedHeaderSettings struct {
+	HMAC bool `json:"hmac"`
+}
+
+// AuditedHeadersConfig is the set of request headers recorded in audit
+// entries. Header names are case-insensitive and kept lowercased.
+type AuditedHeadersConfig struct {
+	Headers map[string]*auditedHeaderSettings
+
+	view *BarrierView
+	sync.RWMutex
+}
+
+// add adds or updates the header to audit and persists the configuration
+func (a *AuditedHeadersConfig) add(header string, hmac bool) error {
+	if header == "" {
+		return fmt.Errorf("header value cannot be empty")
+	}
+
+	a.Lock()
+	defer a.Unlock()
+
+	if a.Headers == nil {
+		a.Headers = make(map[string]*auditedHeaderSettings)
+	}
+	header = strings.ToLower(header)
+	orig, existed := a.Headers[header]
+	a.Headers[header] = &auditedHeaderSettings{HMAC: hmac}
+
+	if err := a.persist(); err != nil {
+		if existed {
+			a.Headers[header] = orig
+		} else {
+			delete(a.Headers, header)
+		}
+		return err
+	}
+
+	return nil
+}
+
+// remove stops auditing the header and persists the configuration
+func (a *AuditedHeadersConfig) remove(header string) error {
+	if header == "" {
+		return fmt.Errorf("header value cannot be empty")
+	}
+
+	a.Lock()
+	defer a.Unlock()
+
+	header = strings.ToLower(header)
+	orig, ok := a.Headers[header]
+	if !ok {
+		return nil
+	}
+	delete(a.Headers, header)
+
+	if err := a.persist(); err != nil {
+		a.Headers[header] = orig
+		return err
+	}
+
+	return nil
+}
+
+// persist writes the configuration to storage. It must be called with the
+// lock held.
+func (a *AuditedHeadersConfig) persist() error {
+	entry, err := logical.StorageEntryJSON(auditedHeadersEntry, a.Headers)
+	if err != nil {
+		return fmt.Errorf("failed to encode audited headers config: %v", err)
+	}
+	if err := a.view.Put(entry); err != nil {
+		return fmt.Errorf("failed to persist audited headers config: %v", err)
+	}
+	return nil
+}
+
+// ApplyConfig returns the headers to record in an audit entry: those of the
+// given headers that are configured to be audited, with their values HMAC'd
+// using hashFunc where configured. The given headers are not modified.
+func (a *AuditedHeadersConfig) ApplyConfig(headers map[string][]string, hashFunc func(string) string) map[string][]string {
+	a.RLock()
+	defer a.RUnlock()
+
+	result := make(map[string][]string)
+	for key, values := range headers {
+		lowerKey := strings.ToLower(key)
+		settings, ok := a.Headers[lowerKey]
+		if !ok {
+			continue
+		}
+
+		hVals := make([]string, len(values))
+		copy(hVals, values)
+		if settings.HMAC {
+			for i, v := range hVals {
+				hVals[i] = hashFunc(v)
+			}
+		}
+		result[lowerKey] = append(result[lowerKey], hVals...)
+	}
+
+	return result
+}
+
+// setupAuditedHeadersConfig loads the audited headers configuration
+func (c *Core) setupAuditedHeadersConfig() error {
+	view := c.systemBarrierView.SubView(auditedHeadersSubPath)
+
+	out, err := view.Get(auditedHeadersEntry)
+	if err != nil {
+		return fmt.Errorf("failed to read audited headers config: %v", err)
+	}
+
+	headers := make(map[string]*auditedHeaderSettings)
+	if out != nil {
+		if err := jsonutil.DecodeJSON(out.Value, &headers); err != nil {
+			return fmt.Errorf("failed to decode audited headers config: %v", err)
+		}
+	}
+
+	c.auditedHeaders = &AuditedHeadersConfig{
+		Headers: headers,
+		view:    view,
+	}
+
+	return nil
+}
@@ -0,0 +1,71 @@
+package vault
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestAuditedHeadersConfig_CRUD(t *testing.T) {
+	c, _, _ := TestCoreUnsealed(t)
+	conf := c.auditedHeaders
+
+	if err := conf.add("X-Test-Header", false); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if err := conf.add("X-Vault-HeAdEr", true); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	expected := map[string]*auditedHeaderSettings{
+		"x-test-header":  &auditedHeaderSettings{HMAC: false},
+		"x-vault-header": &auditedHeaderSettings{HMAC: true},
+	}
+	if !reflect.DeepEqual(conf.Headers, expected) {
+		t.Fatalf("bad: %#v", conf.Headers)
+	}
+
+	if err := conf.remove("x-test-HEADER"); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	delete(expected, "x-test-header")
+	if !reflect.DeepEqual(conf.Headers, expected) {
+		t.Fatalf("bad: %#v", conf.Headers)
+	}
+
+	// The configuration is persisted
+	if err := c.setupAuditedHeadersConfig(); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !reflect.DeepEqual(c.auditedHeaders.Headers, expected) {
+		t.Fatalf("bad: %#v", c.auditedHeaders.Headers)
+	}
+}
+
+func TestAuditedHeadersConfig_ApplyConfig(t *testing.T) {
+	c, _, _ := TestCoreUnsealed(t)
+	conf := c.auditedHeaders
+
+	conf.add("X-TesT-Header", false)
+	conf.add("X-Vault-HeAdEr", true)
+
+	reqHeaders := map[string][]string{
+		"X-Test-Header":  []string{"foo"},
+		"X-Vault-Header": []string{"bar", "bar"},
+		"Content-Type":   []string{"json"},
+	}
+	hashFunc := func(s string) string { return "hashed" }
+
+	result := conf.ApplyConfig(reqHeaders, hashFunc)
+	expected := map[string][]string{
+		"x-test-header":  []string{"foo"},
+		"x-vault-header": []string{"hashed", "hashed"},
+	}
+	if !reflect.DeepEqual(result, expected) {
+		t.Fatalf("bad: %#v", result)
+	}
+
+	// The original headers are not modified
+	if reqHeaders["X-Vault-Header"][0] != "bar" {
+		t.Fatalf("bad: %#v", reqHeaders)
+	}
+}
@@ -46,6 +46,20 @@ func (c *Core) enableCredential(entry *MountEntry) error {
 		return fmt.Errorf("backend path must be specified")
 	}
 
+	// The auth mounts of a namespace are prefixed with its path
+	ns := c.namespaceByID(entry.NamespaceID)
+	if ns == nil {
+		return logical.CodedError(404, "namespace not found")
+	}
+	if ns.ID != "" && namespaceSharedPath(credentialRoutePrefix+entry.Path) {
+		return logical.CodedError(409, fmt.Sprintf("path '%s' is reserved", entry.Path))
+	}
+	nsPath, err := c.namespaceMountPath(ns, entry.Path)
+	if err != nil {
+		return err
+	}
+	entry.Path = nsPath
+
 	c.authLock.Lock()
 	defer c.authLock.Unlock()
 
@@ -66,16 +80,20 @@ func (c *Core) enableCredential(entry *MountEntry) error {
 		return fmt.Errorf("token credential backend cannot be instantiated")
 	}
 
+	if entry.SealWrap && c.sealWrapper() == nil {
+		return logical.CodedError(400, ErrSealWrapUnavailable.Error())
+	}
+
 	// Generate a new UUID and view
 	entryUUID, err := uuid.GenerateUUID()
 	if err != nil {
 		return err
 	}
 	entry.UUID = entryUUID
-	view := NewBarrierView(c.barrier, credentialBarrierPrefix+entry.UUID+"/")
+	view := c.mountEntryView(entry, credentialBarrierPrefix)
 
 	// Create the new backend
-	backend, err := c.newCredentialBackend(entry.Type, c.mountEntrySysView(entry), view, nil)
+	backend, err := c.newCredentialBackend(entry.Type, c.mountEntrySysView(entry), view, entry.backendConfig())
 	if err != nil {
 		return err
 	}
@@ -153,12 +171,67 @@ func (c *Core) disableCredential(path string) error {
 	if err := c.removeCredEntry(path); err != nil {
 		return err
 	}
+	c.rollback.forget(fullPath)
 	if c.logger.IsInfo() {
 		c.logger.Info("core: disabled credential backend", "path", path)
 	}
 	return nil
 }
 
+// convertCredential is used to change the type of an existing credential
+// backend in place, keeping its storage and the tokens it issued. Only the
+// conversion of app-id backends to AppRole is supported, which migrates the
+// app-id mappings to roles and SecretIDs as clients log in.
+func (c *Core) convertCredential(path, newType string) error {
+	// Ensure we end the path in a slash
+	if !strings.HasSuffix(path, "/") {
+		path += "/"
+	}
+
+	c.authLock.Lock()
+	defer c.authLock.Unlock()
+
+	fullPath := credentialRoutePrefix + path
+	entry := c.router.MatchingMountEntry(fullPath)
+	if entry == nil || entry.Path != path {
+		return fmt.Errorf("no matching backend")
+	}
+
+	if entry.Type != "app-id" || newType != "approle" {
+		return fmt.Errorf("cannot convert backend of type %s to %s", entry.Type, newType)
+	}
+
+	// Create the new backend on top of the existing storage
+	view := c.router.MatchingStorageView(fullPath)
+	if view == nil {
+		return fmt.Errorf("no matching backend")
+	}
+	backend, err := c.newCredentialBackend(newType, c.mountEntrySysView(entry), view, nil)
+	if err != nil {
+		return err
+	}
+
+	// Update the auth table
+	oldType := entry.Type
+	entry.Type = newType
+	if err := c.persistAuth(c.auth); err != nil {
+		entry.Type = oldType
+		return errors.New("failed to update auth table")
+	}
+
+	// Swap the backend
+	if err := c.router.Unmount(fullPath); err != nil {
+		return err
+	}
+	if err := c.router.Mount(backend, fullPath, entry, view); err != nil {
+		return err
+	}
+	if c.logger.IsInfo() {
+		c.logger.Info("core: converted credential backend", "path", path, "from", oldType, "to", newType)
+	}
+	return nil
+}
+
 // removeCredEntry is used to remove an entry in the auth table
 func (c *Core) removeCredEntry(path string) error {
 	// Taint the entry from the auth table
@@ -304,12 +377,16 @@ func (c *Core) setupCredentials() error {
 		}
 
 		// Create a barrier view using the UUID
-		view = NewBarrierView(c.barrier, credentialBarrierPrefix+entry.UUID+"/")
+		view = c.mountEntryView(entry, credentialBarrierPrefix)
 
 		// Initialize the backend
-		backend, err = c.newCredentialBackend(entry.Type, c.mountEntrySysView(entry), view, nil)
+		backend, err = c.newCredentialBackend(entry.Type, c.mountEntrySysView(entry), view, entry.backendConfig())
 		if err != nil {
 			c.logger.Error("core: failed to create credential entry", "path", entry.Path, "error", err)
+			if entry.Type == pluginBackendType {
+				// A broken plugin must not keep Vault from unsealing
+				continue
+			}
 			return errLoadAuthFailed
 		}
 
@@ -344,11 +421,21 @@ func (c *Core) setupCredentials() error {
 }
 
 // teardownCredentials is used before we seal the vault to reset the credential
-// backends to their unloaded state. This is reversed by loadCredentials.
+// backends to their unloaded state, calling their Cleanup. This is reversed by
+// loadCredentials.
 func (c *Core) teardownCredentials() error {
 	c.authLock.Lock()
 	defer c.authLock.Unlock()
 
+	if c.auth != nil {
+		for _, e := range c.auth.Entries {
+			b, ok := c.router.root.Get(credentialRoutePrefix + e.Path)
+			if ok {
+				b.(*routeEntry).backend.Cleanup()
+			}
+		}
+	}
+
 	c.auth = nil
 	c.tokenStore = nil
 	return nil
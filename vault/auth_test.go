@@ -4,6 +4,8 @@ import (
 	"reflect"
 	"testing"
 
+	credAppId "github.com/hashicorp/vault/builtin/credential/app-id"
+	credAppRole "github.com/hashicorp/vault/builtin/credential/approle"
 	"github.com/hashicorp/vault/logical"
 )
 
@@ -174,6 +176,100 @@ func TestCore_DisableCredential(t *testing.T) {
 	}
 }
 
+func TestCore_ConvertCredential_AppID(t *testing.T) {
+	c, _, root := TestCoreUnsealed(t)
+	c.credentialBackends["app-id"] = credAppId.Factory
+	c.credentialBackends["approle"] = credAppRole.Factory
+
+	err := c.enableCredential(&MountEntry{
+		Table: credentialTableType,
+		Path:  "app-id",
+		Type:  "app-id",
+	})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	for path, data := range map[string]map[string]interface{}{
+		"auth/app-id/map/app-id/foo":  {"value": "foo-policy"},
+		"auth/app-id/map/user-id/bar": {"value": "foo"},
+		"auth/app-id/map/user-id/baz": {"value": "foo", "cidr_block": "127.0.0.1/32"},
+	} {
+		req := logical.TestRequest(t, logical.UpdateOperation, path)
+		req.ClientToken = root
+		req.Data = data
+		if resp, err := c.HandleRequest(req); err != nil || (resp != nil && resp.IsError()) {
+			t.Fatalf("err: %v\nresp: %#v", err, resp)
+		}
+	}
+
+	req := logical.TestRequest(t, logical.UpdateOperation, "auth/app-id/login")
+	req.Data["app_id"] = "foo"
+	req.Data["user_id"] = "bar"
+	resp, err := c.HandleRequest(req)
+	if err != nil || resp == nil || resp.Auth == nil {
+		t.Fatalf("err: %v\nresp: %#v", err, resp)
+	}
+	appIDToken := resp.Auth.ClientToken
+
+	// Only app-id backends can be converted, and only to AppRole
+	if err := c.convertCredential("app-id", "userpass"); err == nil {
+		t.Fatalf("expected error")
+	}
+
+	req = logical.TestRequest(t, logical.UpdateOperation, "sys/auth/app-id/convert")
+	req.ClientToken = root
+	req.Data["type"] = "approle"
+	resp, err = c.HandleRequest(req)
+	if err != nil || resp != nil {
+		t.Fatalf("err: %v\nresp: %#v", err, resp)
+	}
+	if entry := c.router.MatchingMountEntry("auth/app-id/"); entry == nil || entry.Type != "approle" {
+		t.Fatalf("bad: %#v", entry)
+	}
+
+	login := func(roleID, secretID string) (*logical.Response, error) {
+		req := logical.TestRequest(t, logical.UpdateOperation, "auth/app-id/login")
+		req.Data["role_id"] = roleID
+		req.Data["secret_id"] = secretID
+		return c.HandleRequest(req)
+	}
+
+	// The app ID and user ID log in as RoleID and SecretID
+	for i := 0; i < 2; i++ {
+		resp, err = login("foo", "bar")
+		if err != nil || resp == nil || resp.Auth == nil {
+			t.Fatalf("%d: err: %v\nresp: %#v", i, err, resp)
+		}
+		if !reflect.DeepEqual(resp.Auth.Policies, []string{"default", "foo-policy"}) {
+			t.Fatalf("%d: bad: %#v", i, resp.Auth.Policies)
+		}
+	}
+
+	// Unmapped pairs and user IDs restricted to a CIDR block are not migrated
+	for _, secretID := range []string{"foo", "baz"} {
+		resp, err = login("foo", secretID)
+		if err != nil || resp == nil || !resp.IsError() {
+			t.Fatalf("expected error response for %s, got err: %v\nresp: %#v", secretID, err, resp)
+		}
+	}
+
+	// Tokens issued by app-id before the conversion can still be renewed
+	req = logical.TestRequest(t, logical.UpdateOperation, "auth/token/renew-self")
+	req.ClientToken = appIDToken
+	resp, err = c.HandleRequest(req)
+	if err != nil || resp == nil || resp.Auth == nil {
+		t.Fatalf("err: %v\nresp: %#v", err, resp)
+	}
+
+	req = logical.TestRequest(t, logical.ReadOperation, "auth/app-id/role/foo/role-id")
+	req.ClientToken = root
+	resp, err = c.HandleRequest(req)
+	if err != nil || resp == nil || resp.Data["role_id"] != "foo" {
+		t.Fatalf("err: %v\nresp: %#v", err, resp)
+	}
+}
+
 func TestCore_DisableCredential_Protected(t *testing.T) {
 	c, _, _ := TestCoreUnsealed(t)
 	err := c.disableCredential("token")
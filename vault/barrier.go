@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/physical"
 )
 
 var (
@@ -121,6 +122,9 @@ type SecurityBarrier interface {
 
 	// SecurityBarrier must provide the storage APIs
 	BarrierStorage
+
+	// SecurityBarrier must provide the encryption APIs
+	BarrierEncryptor
 }
 
 // BarrierStorage is the storage only interface required for a Barrier.
@@ -139,12 +143,41 @@ type BarrierStorage interface {
 	List(prefix string) ([]string, error)
 }
 
+// TransactionalStorage is implemented by the barrier storages that can
+// apply several operations atomically
+type TransactionalStorage interface {
+	// Transaction applies the operations in order, atomically if the
+	// physical backend supports transactions
+	Transaction(txns []*TxnEntry) error
+}
+
+// BarrierEncryptor is the in-memory only interface that does not actually
+// use the underlying barrier. It is used for lower level modules like the
+// token store to encrypt values that are handed out to clients rather than
+// persisted.
+type BarrierEncryptor interface {
+	// Encrypt is used to encrypt a value using the active key. The key
+	// is used as additional authenticated data.
+	Encrypt(key string, plaintext []byte) ([]byte, error)
+
+	// Decrypt is used to decrypt a value previously returned by Encrypt
+	// for the same key
+	Decrypt(key string, ciphertext []byte) ([]byte, error)
+}
+
 // Entry is used to represent data stored by the security barrier
 type Entry struct {
 	Key   string
 	Value []byte
 }
 
+// TxnEntry is an operation of a transaction on the barrier. The entry of a
+// delete operation only needs its key.
+type TxnEntry struct {
+	Operation physical.Operation
+	Entry     *Entry
+}
+
 // Logical turns the Entry into a logical storage entry.
 func (e *Entry) Logical() *logical.StorageEntry {
 	return &logical.StorageEntry{
@@ -665,6 +665,41 @@ func (b *AESGCMBarrier) Delete(key string) error {
 	return b.backend.Delete(key)
 }
 
+// Transaction is used to apply several operations atomically if the
+// physical backend is transactional, one at a time otherwise
+func (b *AESGCMBarrier) Transaction(txns []*TxnEntry) error {
+	defer metrics.MeasureSince([]string{"barrier", "transaction"}, time.Now())
+	b.l.RLock()
+	defer b.l.RUnlock()
+	if b.sealed {
+		return ErrBarrierSealed
+	}
+
+	term := b.keyring.ActiveTerm()
+	primary, err := b.aeadForTerm(term)
+	if err != nil {
+		return err
+	}
+
+	ptxns := make([]*physical.TxnEntry, 0, len(txns))
+	for _, txn := range txns {
+		if txn.Entry == nil {
+			return fmt.Errorf("missing transaction entry")
+		}
+		pe := &physical.Entry{
+			Key: txn.Entry.Key,
+		}
+		if txn.Operation == physical.PutOperation {
+			pe.Value = b.encrypt(txn.Entry.Key, term, primary, txn.Entry.Value)
+		}
+		ptxns = append(ptxns, &physical.TxnEntry{
+			Operation: txn.Operation,
+			Entry:     pe,
+		})
+	}
+	return physical.ApplyTransaction(b.backend, ptxns)
+}
+
 // List is used ot list all the keys under a given
 // prefix, up to the next prefix.
 func (b *AESGCMBarrier) List(prefix string) ([]string, error) {
@@ -678,6 +713,40 @@ func (b *AESGCMBarrier) List(prefix string) ([]string, error) {
 	return b.backend.List(prefix)
 }
 
+// Encrypt is used to encrypt in-memory for the BarrierEncryptor interface
+func (b *AESGCMBarrier) Encrypt(key string, plaintext []byte) ([]byte, error) {
+	b.l.RLock()
+	defer b.l.RUnlock()
+	if b.sealed {
+		return nil, ErrBarrierSealed
+	}
+
+	term := b.keyring.ActiveTerm()
+	primary, err := b.aeadForTerm(term)
+	if err != nil {
+		return nil, err
+	}
+
+	return b.encrypt(key, term, primary, plaintext), nil
+}
+
+// Decrypt is used to decrypt in-memory for the BarrierEncryptor interface
+func (b *AESGCMBarrier) Decrypt(key string, ciphertext []byte) ([]byte, error) {
+	b.l.RLock()
+	defer b.l.RUnlock()
+	if b.sealed {
+		return nil, ErrBarrierSealed
+	}
+
+	// Ensure the term and version byte are present so that
+	// decryptKeyring can safely slice the value
+	if len(ciphertext) <= termSize+1 {
+		return nil, fmt.Errorf("invalid ciphertext")
+	}
+
+	return b.decryptKeyring(key, ciphertext)
+}
+
 // aeadForTerm returns the AES-GCM AEAD for the given term
 func (b *AESGCMBarrier) aeadForTerm(term uint32) (cipher.AEAD, error) {
 	// Check for the keyring
@@ -800,6 +869,10 @@ func (b *AESGCMBarrier) decryptKeyring(path string, cipher []byte) ([]byte, erro
 		return nil, fmt.Errorf("no decryption key available for term %d", term)
 	}
 
+	if len(cipher) < 5+gcm.NonceSize()+gcm.Overhead() {
+		return nil, fmt.Errorf("invalid ciphertext length")
+	}
+
 	nonce := cipher[5 : 5+gcm.NonceSize()]
 	raw := cipher[5+gcm.NonceSize():]
 	out := make([]byte, 0, len(raw)-gcm.NonceSize())
@@ -403,6 +403,46 @@ func TestEncrypt_Unique(t *testing.T) {
 	}
 }
 
+func TestAESGCMBarrier_EncryptDecrypt(t *testing.T) {
+	inm := physical.NewInmem(logger)
+	b, err := NewAESGCMBarrier(inm)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	key, _ := b.GenerateKey()
+	b.Initialize(key)
+	b.Unseal(key)
+
+	ciphertext, err := b.Encrypt("foo", []byte("quick brown fox"))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	plaintext, err := b.Decrypt("foo", ciphertext)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if string(plaintext) != "quick brown fox" {
+		t.Fatalf("bad: %s", plaintext)
+	}
+
+	// The key is authenticated
+	if _, err := b.Decrypt("bar", ciphertext); err == nil {
+		t.Fatalf("expected error decrypting with the wrong key")
+	}
+
+	// Truncated values are rejected rather than panicking
+	if _, err := b.Decrypt("foo", ciphertext[:6]); err == nil {
+		t.Fatalf("expected error decrypting a truncated value")
+	}
+
+	b.Seal()
+	if _, err := b.Encrypt("foo", []byte("quick brown fox")); err != ErrBarrierSealed {
+		t.Fatalf("err: %v", err)
+	}
+}
+
 func TestInitialize_KeyLength(t *testing.T) {
 
 	inm := physical.NewInmem(logger)
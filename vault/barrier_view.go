@@ -5,6 +5,7 @@ import (
 	"strings"
 
 	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/physical"
 )
 
 // BarrierView wraps a SecurityBarrier and ensures all access is automatically
@@ -86,6 +87,47 @@ func (v *BarrierView) Delete(key string) error {
 	return v.barrier.Delete(v.expandKey(key))
 }
 
+// Transaction applies several operations, whose keys are relative to the
+// view. They are applied atomically if the barrier supports transactions,
+// one at a time otherwise.
+func (v *BarrierView) Transaction(txns []*TxnEntry) error {
+	nested := make([]*TxnEntry, 0, len(txns))
+	for _, txn := range txns {
+		if txn.Entry == nil {
+			return fmt.Errorf("missing transaction entry")
+		}
+		if err := v.sanityCheck(txn.Entry.Key); err != nil {
+			return err
+		}
+		nested = append(nested, &TxnEntry{
+			Operation: txn.Operation,
+			Entry: &Entry{
+				Key:   v.expandKey(txn.Entry.Key),
+				Value: txn.Entry.Value,
+			},
+		})
+	}
+
+	if t, ok := v.barrier.(TransactionalStorage); ok {
+		return t.Transaction(nested)
+	}
+	for _, txn := range nested {
+		var err error
+		switch txn.Operation {
+		case physical.PutOperation:
+			err = v.barrier.Put(txn.Entry)
+		case physical.DeleteOperation:
+			err = v.barrier.Delete(txn.Entry.Key)
+		default:
+			err = fmt.Errorf("unsupported transaction operation: %q", txn.Operation)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // SubView constructs a nested sub-view using the given prefix
 func (v *BarrierView) SubView(prefix string) *BarrierView {
 	sub := v.expandKey(prefix)
@@ -6,6 +6,7 @@ import (
 	"testing"
 
 	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/physical"
 )
 
 func TestBarrierView_impl(t *testing.T) {
@@ -282,3 +283,72 @@ func TestBarrierView_ClearView(t *testing.T) {
 		t.Fatalf("have keys: %#v", out)
 	}
 }
+
+func TestBarrierView_Transaction(t *testing.T) {
+	inm, barrier, _ := mockBarrier(t)
+	view := NewBarrierView(barrier, "foo/")
+
+	if err := view.Put(&logical.StorageEntry{Key: "delete", Value: []byte("test")}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	txns := []*TxnEntry{
+		&TxnEntry{
+			Operation: physical.PutOperation,
+			Entry:     &Entry{Key: "put", Value: []byte("test")},
+		},
+		&TxnEntry{
+			Operation: physical.DeleteOperation,
+			Entry:     &Entry{Key: "delete"},
+		},
+	}
+	if err := view.Transaction(txns); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	out, err := view.Get("put")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out == nil || string(out.Value) != "test" {
+		t.Fatalf("bad: %#v", out)
+	}
+	out, err = view.Get("delete")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out != nil {
+		t.Fatalf("bad: %#v", out)
+	}
+
+	// The values are encrypted by the barrier
+	pe, err := inm.Get("foo/put")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if pe == nil || string(pe.Value) == "test" {
+		t.Fatalf("bad: %#v", pe)
+	}
+
+	// Keys outside of the view are rejected before any operation is applied
+	txns = []*TxnEntry{
+		&TxnEntry{
+			Operation: physical.DeleteOperation,
+			Entry:     &Entry{Key: "put"},
+		},
+		&TxnEntry{
+			Operation: physical.PutOperation,
+			Entry:     &Entry{Key: "../bar", Value: []byte("test")},
+		},
+	}
+	if err := view.Transaction(txns); err == nil {
+		t.Fatal("expected error")
+	}
+	out, err = view.Get("put")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out == nil {
+		t.Fatal("transaction should not be applied")
+	}
+}
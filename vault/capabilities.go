@@ -1,6 +1,9 @@
 package vault
 
-import "sort"
+import (
+	"sort"
+	"strings"
+)
 
 // Struct to identify user input errors.
 // This is helpful in responding the appropriate status codes to clients
@@ -14,7 +17,9 @@ func (s *StatusBadRequest) Error() string {
 	return s.Err
 }
 
-// Capabilities is used to fetch the capabilities of the given token on the given path
+// Capabilities is used to fetch the capabilities of the given token on the given path.
+// The path is relative to the root namespace, so paths in namespaces are
+// prefixed with the path of their namespace.
 func (c *Core) Capabilities(token, path string) ([]string, error) {
 	if path == "" {
 		return nil, &StatusBadRequest{Err: "missing path"}
@@ -32,13 +37,30 @@ func (c *Core) Capabilities(token, path string) ([]string, error) {
 		return nil, &StatusBadRequest{Err: "invalid token"}
 	}
 
-	if te.Policies == nil {
+	// The token has no capabilities outside of its namespace, and the
+	// paths of its policies are relative to its namespace
+	ns := c.namespaceByID(te.NamespaceID)
+	if ns == nil || !strings.HasPrefix(path, ns.Path) {
+		return []string{DenyCapability}, nil
+	}
+	ps := c.namespacePolicyStore(ns)
+	if ps == nil {
+		return []string{DenyCapability}, nil
+	}
+	path = strings.TrimPrefix(path, ns.Path)
+
+	tePolicies, err := c.tokenPolicies(te)
+	if err != nil {
+		return nil, err
+	}
+
+	if tePolicies == nil {
 		return []string{DenyCapability}, nil
 	}
 
 	var policies []*Policy
-	for _, tePolicy := range te.Policies {
-		policy, err := c.policyStore.GetPolicy(tePolicy)
+	for _, tePolicy := range tePolicies {
+		policy, err := ps.GetPolicy(tePolicy)
 		if err != nil {
 			return nil, err
 		}
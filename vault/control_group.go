@@ -0,0 +1,219 @@
+package vault
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/vault/helper/jsonutil"
+	"github.com/hashicorp/vault/helper/strutil"
+	"github.com/hashicorp/vault/logical"
+)
+
+const (
+	// controlGroupSubPath is the sub-path used for the requests subject to
+	// a control group, keyed by the accessor of their wrapping token
+	controlGroupSubPath = "control-group/"
+
+	// defaultControlGroupTTL is the TTL of the wrapping token of a request
+	// subject to a control group that doesn't specify one
+	defaultControlGroupTTL = 24 * time.Hour
+)
+
+// ErrControlGroupPending is returned when using the wrapping token of a
+// request that hasn't been authorized yet
+var ErrControlGroupPending = errors.New("request needs further approval")
+
+// ControlGroupRequest is a request subject to a control group whose response
+// is held in a wrapping token until the request is authorized
+type ControlGroupRequest struct {
+	Accessor        string                       `json:"accessor"`
+	RequestPath     string                       `json:"request_path"`
+	RequestEntityID string                       `json:"request_entity_id"`
+	Factors         []*ControlGroupFactor        `json:"factors"`
+	Authorizations  []*ControlGroupAuthorization `json:"authorizations"`
+	CreationTime    time.Time                    `json:"creation_time"`
+	ExpireTime      time.Time                    `json:"expire_time"`
+}
+
+// ControlGroupAuthorization records the authorization of a request by an
+// entity, and the factors it counts towards
+type ControlGroupAuthorization struct {
+	EntityID   string    `json:"entity_id"`
+	EntityName string    `json:"entity_name"`
+	Factors    []string  `json:"factors"`
+	Time       time.Time `json:"time"`
+}
+
+// wrapTTL returns the TTL of the wrapping token holding the response of a
+// request subject to the control group
+func (cg *ControlGroup) wrapTTL(requested time.Duration) time.Duration {
+	switch {
+	case cg.TTL != 0:
+		return cg.TTL
+	case requested != 0:
+		return requested
+	default:
+		return defaultControlGroupTTL
+	}
+}
+
+// Approved returns whether every factor of the control group has been
+// satisfied
+func (r *ControlGroupRequest) Approved() bool {
+	for _, factor := range r.Factors {
+		approvals := 0
+		for _, authz := range r.Authorizations {
+			if strutil.StrListContains(authz.Factors, factor.Name) {
+				approvals++
+			}
+		}
+		if approvals < factor.Approvals {
+			return false
+		}
+	}
+	return true
+}
+
+// registerControlGroupRequest records that the response wrapped in the given
+// wrapping token must be authorized before it can be unwrapped
+func (c *Core) registerControlGroupRequest(req *logical.Request, auth *logical.Auth, cg *ControlGroup, wrapInfo *logical.WrapInfo) error {
+	cgReq := &ControlGroupRequest{
+		Accessor:     wrapInfo.Accessor,
+		RequestPath:  req.Path,
+		Factors:      cg.Factors,
+		CreationTime: wrapInfo.CreationTime,
+		ExpireTime:   wrapInfo.CreationTime.Add(wrapInfo.TTL),
+	}
+	if auth != nil {
+		cgReq.RequestEntityID = auth.EntityID
+	}
+	return c.persistControlGroupRequest(cgReq)
+}
+
+func (c *Core) persistControlGroupRequest(cgReq *ControlGroupRequest) error {
+	entry, err := logical.StorageEntryJSON(cgReq.Accessor, cgReq)
+	if err != nil {
+		return fmt.Errorf("failed to encode control group request: %v", err)
+	}
+	if err := c.systemBarrierView.SubView(controlGroupSubPath).Put(entry); err != nil {
+		return fmt.Errorf("failed to persist control group request: %v", err)
+	}
+	return nil
+}
+
+// ControlGroupRequest returns the request subject to a control group whose
+// response is wrapped in the token with the given accessor, or nil if there
+// is none. Requests whose wrapping token has expired are removed.
+func (c *Core) ControlGroupRequest(accessor string) (*ControlGroupRequest, error) {
+	if accessor == "" {
+		return nil, nil
+	}
+
+	view := c.systemBarrierView.SubView(controlGroupSubPath)
+	out, err := view.Get(accessor)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read control group request: %v", err)
+	}
+	if out == nil {
+		return nil, nil
+	}
+
+	cgReq := new(ControlGroupRequest)
+	if err := jsonutil.DecodeJSON(out.Value, cgReq); err != nil {
+		return nil, fmt.Errorf("failed to decode control group request: %v", err)
+	}
+
+	if time.Now().After(cgReq.ExpireTime) {
+		if err := view.Delete(accessor); err != nil {
+			return nil, fmt.Errorf("failed to delete control group request: %v", err)
+		}
+		return nil, nil
+	}
+
+	return cgReq, nil
+}
+
+// AuthorizeControlGroupRequest records the authorization by the given entity
+// of the request whose response is wrapped in the token with the given
+// accessor. The entity must be a member of a group of one of the factors,
+// and can't authorize its own request.
+func (c *Core) AuthorizeControlGroupRequest(accessor, entityID string) (*ControlGroupRequest, error) {
+	c.controlGroupLock.Lock()
+	defer c.controlGroupLock.Unlock()
+
+	cgReq, err := c.ControlGroupRequest(accessor)
+	if err != nil {
+		return nil, err
+	}
+	if cgReq == nil {
+		return nil, logical.CodedError(400, "control group request not found")
+	}
+
+	if entityID == "" {
+		return nil, logical.CodedError(400, "authorizing a request requires a token with an entity")
+	}
+	if entityID == cgReq.RequestEntityID {
+		return nil, logical.CodedError(403, "requesters can't authorize their own request")
+	}
+	for _, authz := range cgReq.Authorizations {
+		if authz.EntityID == entityID {
+			return cgReq, nil
+		}
+	}
+
+	entity, err := c.identityStore.entityByID(entityID)
+	if err != nil {
+		return nil, err
+	}
+	if entity == nil {
+		return nil, logical.CodedError(400, "entity not found")
+	}
+	groupNames, err := c.identityStore.GroupNames(entityID)
+	if err != nil {
+		return nil, err
+	}
+
+	authz := &ControlGroupAuthorization{
+		EntityID:   entity.ID,
+		EntityName: entity.Name,
+		Time:       time.Now(),
+	}
+	for _, factor := range cgReq.Factors {
+		for _, name := range factor.GroupNames {
+			if strutil.StrListContains(groupNames, name) {
+				authz.Factors = append(authz.Factors, factor.Name)
+				break
+			}
+		}
+	}
+	if len(authz.Factors) == 0 {
+		return nil, logical.CodedError(403, "entity is not a member of an authorizing group")
+	}
+
+	cgReq.Authorizations = append(cgReq.Authorizations, authz)
+	if err := c.persistControlGroupRequest(cgReq); err != nil {
+		return nil, err
+	}
+
+	return cgReq, nil
+}
+
+// checkControlGroupApproval returns ErrControlGroupPending if the token is
+// the wrapping token of a request subject to a control group that hasn't
+// been authorized yet
+func (c *Core) checkControlGroupApproval(te *TokenEntry) error {
+	if te == nil || len(te.Policies) != 1 || te.Policies[0] != cubbyholeResponseWrappingPolicyName {
+		return nil
+	}
+
+	cgReq, err := c.ControlGroupRequest(te.Accessor)
+	if err != nil {
+		c.logger.Error("core: failed to look up control group request", "error", err)
+		return ErrInternalError
+	}
+	if cgReq != nil && !cgReq.Approved() {
+		return ErrControlGroupPending
+	}
+	return nil
+}
@@ -0,0 +1,149 @@
+package vault
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/vault/logical"
+)
+
+func TestCore_ControlGroup(t *testing.T) {
+	c, _, root := TestCoreUnsealed(t)
+
+	policy, err := Parse(`
+path "secret/foo" {
+	capabilities = ["read"]
+	control_group = {
+		factor "managers" {
+			identity {
+				group_names = ["managers"]
+			}
+		}
+	}
+}
+
+path "sys/control-group/*" {
+	capabilities = ["update"]
+}
+`)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	policy.Name = "control-group"
+	if err := c.policyStore.SetPolicy(policy); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	request := func(op logical.Operation, path, token string, data map[string]interface{}) (*logical.Response, error) {
+		req := logical.TestRequest(t, op, path)
+		req.ClientToken = token
+		req.Data = data
+		return c.HandleRequest(req)
+	}
+	mustRequest := func(op logical.Operation, path, token string, data map[string]interface{}) *logical.Response {
+		resp, err := request(op, path, token, data)
+		if err != nil || (resp != nil && resp.IsError()) {
+			t.Fatalf("err: %v resp: %#v", err, resp)
+		}
+		return resp
+	}
+
+	// Create a token for an entity, optionally member of the managers group
+	var managers []string
+	makeToken := func(name string, manager bool) string {
+		resp := mustRequest(logical.UpdateOperation, "identity/entity", root, map[string]interface{}{
+			"name": name,
+		})
+		entityID := resp.Data["id"].(string)
+		if manager {
+			managers = append(managers, entityID)
+		}
+
+		te := &TokenEntry{
+			Path:     "auth/token/create",
+			Policies: []string{"control-group"},
+			EntityID: entityID,
+		}
+		if err := c.tokenStore.create(te); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		return te.ID
+	}
+	requester := makeToken("requester", false)
+	other := makeToken("other", false)
+	manager := makeToken("manager", true)
+	mustRequest(logical.UpdateOperation, "identity/group", root, map[string]interface{}{
+		"name":              "managers",
+		"member_entity_ids": strings.Join(managers, ","),
+	})
+
+	mustRequest(logical.UpdateOperation, "secret/foo", root, map[string]interface{}{
+		"foo": "bar",
+	})
+
+	// The response is wrapped even though no wrapping was requested
+	resp := mustRequest(logical.ReadOperation, "secret/foo", requester, nil)
+	if resp.WrapInfo == nil || resp.WrapInfo.Token == "" || resp.WrapInfo.Accessor == "" {
+		t.Fatalf("expected a wrapped response: %#v", resp)
+	}
+	if resp.WrapInfo.TTL != defaultControlGroupTTL {
+		t.Fatalf("bad: %#v", resp.WrapInfo)
+	}
+	if resp.Data != nil {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+	wrapToken, accessor := resp.WrapInfo.Token, resp.WrapInfo.Accessor
+
+	// The wrapping token can't be used until the request is authorized, and
+	// isn't used up by trying
+	for i := 0; i < 2; i++ {
+		resp, err = request(logical.UpdateOperation, "sys/wrapping/unwrap", wrapToken, nil)
+		if err == nil || resp == nil || resp.Data["error"] != ErrControlGroupPending.Error() {
+			t.Fatalf("expected the request to need approval: err: %v resp: %#v", err, resp)
+		}
+	}
+
+	// Neither the requester nor entities outside of the authorizing groups
+	// can authorize the request
+	for _, token := range []string{requester, other} {
+		resp, err = request(logical.UpdateOperation, "sys/control-group/authorize", token, map[string]interface{}{
+			"accessor": accessor,
+		})
+		if err == nil {
+			t.Fatalf("expected an error: %#v", resp)
+		}
+	}
+
+	resp = mustRequest(logical.UpdateOperation, "sys/control-group/request", requester, map[string]interface{}{
+		"accessor": accessor,
+	})
+	if resp.Data["approved"] != false || resp.Data["request_path"] != "secret/foo" {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+
+	resp = mustRequest(logical.UpdateOperation, "sys/control-group/authorize", manager, map[string]interface{}{
+		"accessor": accessor,
+	})
+	if resp.Data["approved"] != true {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+
+	resp = mustRequest(logical.UpdateOperation, "sys/control-group/request", requester, map[string]interface{}{
+		"accessor": accessor,
+	})
+	authorizations := resp.Data["authorizations"].([]map[string]interface{})
+	if resp.Data["approved"] != true || len(authorizations) != 1 || authorizations[0]["entity_name"] != "manager" {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+
+	// Once authorized, the response can be unwrapped
+	resp = mustRequest(logical.UpdateOperation, "sys/wrapping/unwrap", wrapToken, nil)
+	var unwrapped map[string]interface{}
+	if err := json.Unmarshal(resp.Data[logical.HTTPRawBody].([]byte), &unwrapped); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if data := unwrapped["data"].(map[string]interface{}); data["foo"] != "bar" {
+		t.Fatalf("bad: %#v", unwrapped)
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"net"
 	"net/http"
 	"net/url"
+	"strings"
 	"sync"
 	"time"
 
@@ -23,6 +24,7 @@ import (
 	"github.com/hashicorp/go-multierror"
 	"github.com/hashicorp/go-uuid"
 	"github.com/hashicorp/vault/audit"
+	"github.com/hashicorp/vault/helper/cidrutil"
 	"github.com/hashicorp/vault/helper/errutil"
 	"github.com/hashicorp/vault/helper/jsonutil"
 	"github.com/hashicorp/vault/helper/logformat"
@@ -58,6 +60,15 @@ const (
 	leaderPrefixCleanDelay = 200 * time.Millisecond
 )
 
+// cacheExceptionPaths are the prefixes of the keys which are never cached
+// by the physical cache: the audit table, and the storage of the audit
+// backends, are always read from the backend so that auditing never relies
+// on stale data.
+var cacheExceptionPaths = []string{
+	coreAuditConfigPath,
+	auditBarrierPrefix,
+}
+
 var (
 	// ErrSealed is returned if an operation is performed on
 	// a sealed barrier. No operation is expected to succeed before unsealing
@@ -83,6 +94,14 @@ var (
 	// in an HA setting
 	ErrHANotEnabled = errors.New("Vault is not configured for highly-available mode")
 
+	// ErrSealMigrationPending is returned if Vault is unsealed normally
+	// while a seal migration is pending
+	ErrSealMigrationPending = errors.New("seal migration is pending; unseal with the migrate flag set")
+
+	// ErrNoSealMigration is returned if Vault is unsealed with the migrate
+	// flag while no seal migration is pending
+	ErrNoSealMigration = errors.New("no seal migration is pending")
+
 	// manualStepDownSleepPeriod is how long to sleep after a user-initiated
 	// step down of the active node, to prevent instantly regrabbing the lock.
 	// It's var not const so that tests can manipulate it.
@@ -140,6 +159,14 @@ type Core struct {
 	// Our Seal, for seal configuration information
 	seal Seal
 
+	// migrationSeal is the seal to migrate to when Vault is unsealed with
+	// the migrate flag. It replaces seal once the migration is done.
+	migrationSeal Seal
+
+	// migrationKeyConf caches the configuration of the keys needed to
+	// migrate the seal
+	migrationKeyConf *SealConfig
+
 	// barrier is the security barrier wrapping the physical backend
 	barrier SecurityBarrier
 
@@ -211,6 +238,28 @@ type Core struct {
 	// out into the configured audit backends
 	auditBroker *AuditBroker
 
+	// auditedHeaders is the configuration of the request headers recorded
+	// in audit entries
+	auditedHeaders *AuditedHeadersConfig
+
+	// corsConfig is the configuration of the Cross-Origin Resource Sharing
+	// of the API
+	corsConfig *CORSConfig
+
+	// quotaManager holds the rate limit and lease count quotas
+	quotaManager *QuotaManager
+
+	// pluginCatalog holds the plugins that can be mounted
+	pluginCatalog *PluginCatalog
+
+	// enableMlock is whether Vault locks its memory, which plugins then do
+	// too
+	enableMlock bool
+
+	// controlGroupLock serializes the authorizations of requests subject to
+	// a control group
+	controlGroupLock sync.Mutex
+
 	// systemBarrierView is the barrier view for the system backend
 	systemBarrierView *BarrierView
 
@@ -224,9 +273,22 @@ type Core struct {
 	// policy store is used to manage named ACL policies
 	policyStore *PolicyStore
 
+	// namespaces holds the namespaces other than the root namespace
+	namespaces *namespaceStore
+
 	// token store is used to manage authentication tokens
 	tokenStore *TokenStore
 
+	// identity store is used to manage identity entities and their aliases
+	identityStore *IdentityStore
+
+	// loginLockout tracks failed logins to lock out users of auth mounts
+	// that have a lockout threshold configured
+	loginLockout *loginLockout
+
+	// mountMigrations tracks the status of remounts
+	mountMigrations *mountMigrations
+
 	// metricsCh is used to stop the metrics streaming
 	metricsCh chan struct{}
 
@@ -300,6 +362,11 @@ type CoreConfig struct {
 
 	Seal Seal `json:"seal" structs:"seal" mapstructure:"seal"`
 
+	// MigrationSeal, if set, is the seal to migrate to from Seal. Vault must
+	// then be unsealed with the migrate flag, using the unseal keys of Seal,
+	// or its recovery keys if it is an auto-unseal seal.
+	MigrationSeal Seal `json:"migration_seal" structs:"migration_seal" mapstructure:"migration_seal"`
+
 	Logger log.Logger `json:"logger" structs:"logger" mapstructure:"logger"`
 
 	// Disables the LRU cache on the physical backend
@@ -322,6 +389,9 @@ type CoreConfig struct {
 	MaxLeaseTTL time.Duration `json:"max_lease_ttl" structs:"max_lease_ttl" mapstructure:"max_lease_ttl"`
 
 	ClusterName string `json:"cluster_name" structs:"cluster_name" mapstructure:"cluster_name"`
+
+	// PluginDirectory is the directory holding the binaries of plugins
+	PluginDirectory string `json:"plugin_directory" structs:"plugin_directory" mapstructure:"plugin_directory"`
 }
 
 // NewCore is used to construct a new core
@@ -364,7 +434,7 @@ func NewCore(conf *CoreConfig) (*Core, error) {
 		_, isCache := conf.Physical.(*physical.Cache)
 		_, isInmem := conf.Physical.(*physical.InmemBackend)
 		if !isCache && !isInmem {
-			cache := physical.NewCache(conf.Physical, conf.CacheSize, conf.Logger)
+			cache := physical.NewCache(conf.Physical, conf.CacheSize, cacheExceptionPaths, conf.Logger)
 			conf.Physical = cache
 		}
 	}
@@ -409,6 +479,15 @@ func NewCore(conf *CoreConfig) (*Core, error) {
 		localClusterCertPool:             x509.NewCertPool(),
 		clusterListenerShutdownCh:        make(chan struct{}),
 		clusterListenerShutdownSuccessCh: make(chan struct{}),
+		loginLockout:                     newLoginLockout(),
+		mountMigrations:                  newMountMigrations(),
+		enableMlock:                      !conf.DisableMlock,
+		corsConfig:                       &CORSConfig{},
+	}
+
+	c.pluginCatalog = &PluginCatalog{
+		directory: conf.PluginDirectory,
+		view:      NewBarrierView(barrier, pluginCatalogPath),
 	}
 
 	if conf.HAPhysical != nil && conf.HAPhysical.HAEnabled() {
@@ -428,6 +507,10 @@ func NewCore(conf *CoreConfig) (*Core, error) {
 	logicalBackends["system"] = func(config *logical.BackendConfig) (logical.Backend, error) {
 		return NewSystemBackend(c, config), nil
 	}
+	logicalBackends["identity"] = func(config *logical.BackendConfig) (logical.Backend, error) {
+		return NewIdentityStore(c, config)
+	}
+	logicalBackends[pluginBackendType] = c.pluginBackendFactory
 	c.logicalBackends = logicalBackends
 
 	credentialBackends := make(map[string]logical.Factory)
@@ -437,6 +520,7 @@ func NewCore(conf *CoreConfig) (*Core, error) {
 	credentialBackends["token"] = func(config *logical.BackendConfig) (logical.Backend, error) {
 		return NewTokenStore(c, config)
 	}
+	credentialBackends[pluginBackendType] = c.pluginBackendFactory
 	c.credentialBackends = credentialBackends
 
 	auditBackends := make(map[string]audit.Factory)
@@ -450,6 +534,42 @@ func NewCore(conf *CoreConfig) (*Core, error) {
 	}
 	c.seal.SetCore(c)
 
+	migrationSeal := conf.MigrationSeal
+	if migrationSeal != nil && !c.seal.StoredKeysSupported() && !migrationSeal.StoredKeysSupported() {
+		return nil, fmt.Errorf("cannot migrate between two Shamir seals")
+	}
+
+	if migrationSeal != nil || c.seal.StoredKeysSupported() {
+		sealType, err := c.storedBarrierSealType()
+		if err != nil {
+			return nil, err
+		}
+
+		switch {
+		case sealType == "" && migrationSeal != nil:
+			// There is nothing to migrate until Vault is initialized
+			c.seal = migrationSeal
+			c.seal.SetCore(c)
+			migrationSeal = nil
+
+		case migrationSeal == nil && sealType == "shamir" && c.seal.BarrierType() != "shamir":
+			// An auto-unseal seal configured on a Vault initialized with
+			// Shamir can only be adopted through a seal migration
+			c.logger.Warn("core: Vault was initialized with Shamir, unseal with the migrate flag to migrate to the configured seal", "seal_type", c.seal.BarrierType())
+			migrationSeal = c.seal
+			c.seal = &DefaultSeal{}
+			c.seal.SetCore(c)
+		}
+	}
+
+	if migrationSeal != nil {
+		c.migrationSeal = migrationSeal
+		c.migrationSeal.SetCore(c)
+
+		// Unsealing must go through the migration
+		return c, nil
+	}
+
 	// Attempt unsealing with stored keys; if there are no stored keys this
 	// returns nil, otherwise returns nil or an error
 	storedKeyErr := c.UnsealWithStoredKeys()
@@ -497,8 +617,25 @@ func (c *Core) fetchACLandTokenEntry(req *logical.Request) (*ACL, *TokenEntry, e
 		return nil, nil, logical.ErrPermissionDenied
 	}
 
+	// The policies of the token are the ones of its namespace
+	ns, err := c.tokenNamespace(te, req.Namespace)
+	if err != nil {
+		return nil, nil, err
+	}
+	ps := c.namespacePolicyStore(ns)
+	if ps == nil {
+		return nil, nil, logical.ErrPermissionDenied
+	}
+
+	// Include the policies of the token's entity, if any
+	policies, err := c.tokenPolicies(te)
+	if err != nil {
+		c.logger.Error("core: failed to fetch entity policies", "error", err)
+		return nil, nil, ErrInternalError
+	}
+
 	// Construct the corresponding ACL object
-	acl, err := c.policyStore.ACL(te.Policies...)
+	acl, err := ps.ACL(policies...)
 	if err != nil {
 		c.logger.Error("core: failed to construct ACL", "error", err)
 		return nil, nil, ErrInternalError
@@ -507,17 +644,46 @@ func (c *Core) fetchACLandTokenEntry(req *logical.Request) (*ACL, *TokenEntry, e
 	return acl, te, nil
 }
 
-func (c *Core) checkToken(req *logical.Request) (*logical.Auth, *TokenEntry, error) {
+func (c *Core) checkToken(req *logical.Request) (*logical.Auth, *TokenEntry, *ControlGroup, error) {
 	defer metrics.MeasureSince([]string{"core", "check_token"}, time.Now())
 
 	acl, te, err := c.fetchACLandTokenEntry(req)
 	if err != nil {
-		return nil, te, err
+		return nil, te, nil, err
+	}
+
+	// The response-wrapping token of a request subject to a control group
+	// can't be used until the request has been authorized. The token entry
+	// isn't returned so that the wrapping token isn't used up.
+	if err := c.checkControlGroupApproval(te); err != nil {
+		return nil, nil, nil, err
+	}
+
+	// If the token is bound to specific networks, ensure the request
+	// originates from one of them. The token entry is returned so that the
+	// use count is still decremented.
+	if len(te.BoundCIDRs) > 0 {
+		if req.Connection == nil || !cidrutil.RemoteAddrIsOk(req.Connection.RemoteAddr, te.BoundCIDRs) {
+			return nil, te, nil, logical.ErrPermissionDenied
+		}
+	}
+
+	// Batch tokens are not persisted, so there is nothing to tie the
+	// lifetime of a cubbyhole to
+	if te.Type == logical.TokenTypeBatch && strings.HasPrefix(req.Path, "cubbyhole/") {
+		return nil, te, nil, logical.ErrPermissionDenied
 	}
 
 	// Check if this is a root protected path
 	rootPath := c.router.RootPath(req.Path)
 
+	// The paths of the policies of the token are relative to its namespace
+	tokenNS, err := c.tokenNamespace(te, req.Namespace)
+	if err != nil {
+		return nil, te, nil, err
+	}
+	aclPath := c.namespaceACLPath(req, tokenNS)
+
 	// When we receive a write of either type, rather than require clients to
 	// PUT/POST and trust the operation, we ask the backend to give us the real
 	// skinny -- if the backend implements an existence check, it can tell us
@@ -534,9 +700,9 @@ func (c *Core) checkToken(req *logical.Request) (*logical.Auth, *TokenEntry, err
 		default:
 			c.logger.Error("core: failed to run existence check", "error", err)
 			if _, ok := err.(errutil.UserError); ok {
-				return nil, nil, err
+				return nil, nil, nil, err
 			} else {
-				return nil, nil, ErrInternalError
+				return nil, nil, nil, ErrInternalError
 			}
 		}
 
@@ -557,12 +723,22 @@ func (c *Core) checkToken(req *logical.Request) (*logical.Auth, *TokenEntry, err
 
 	// Check the standard non-root ACLs. Return the token entry if it's not
 	// allowed so we can decrement the use count.
-	allowed, rootPrivs := acl.AllowOperation(req.Operation, req.Path)
+	allowed, rootPrivs := acl.AllowOperation(req.Operation, aclPath)
 	if !allowed {
-		return nil, te, logical.ErrPermissionDenied
+		return nil, te, nil, logical.ErrPermissionDenied
 	}
 	if rootPath && !rootPrivs {
-		return nil, te, logical.ErrPermissionDenied
+		return nil, te, nil, logical.ErrPermissionDenied
+	}
+
+	// Check the data of writes against the parameter constraints of the
+	// policies
+	if req.Operation == logical.CreateOperation || req.Operation == logical.UpdateOperation ||
+		req.Operation == logical.PatchOperation {
+		if err := acl.AllowParameters(aclPath, req.Data); err != nil {
+			c.logger.Trace("core: request data not allowed by policy", "request_path", req.Path, "error", err)
+			return nil, te, nil, logical.ErrPermissionDenied
+		}
 	}
 
 	// Create the auth response
@@ -571,8 +747,9 @@ func (c *Core) checkToken(req *logical.Request) (*logical.Auth, *TokenEntry, err
 		Policies:    te.Policies,
 		Metadata:    te.Meta,
 		DisplayName: te.DisplayName,
+		EntityID:    te.EntityID,
 	}
-	return auth, te, nil
+	return auth, te, acl.ControlGroup(aclPath), nil
 }
 
 // Sealed checks if the Vault is current sealed
@@ -737,6 +914,10 @@ func (c *Core) Unseal(key []byte) (bool, error) {
 		return true, nil
 	}
 
+	if c.migrationSeal != nil {
+		return false, ErrSealMigrationPending
+	}
+
 	// Check if we already have this piece
 	for _, existing := range c.unlockParts {
 		if bytes.Equal(existing, key) {
@@ -769,6 +950,13 @@ func (c *Core) Unseal(key []byte) (bool, error) {
 	}
 	defer memzero(masterKey)
 
+	return c.unsealInternal(masterKey)
+}
+
+// unsealInternal unseals the barrier with the given master key and
+// performs the post-unseal setup, or enters standby mode if HA is enabled.
+// It must be called with the state lock held.
+func (c *Core) unsealInternal(masterKey []byte) (bool, error) {
 	// Attempt to unlock
 	if err := c.barrier.Unseal(masterKey); err != nil {
 		return false, err
@@ -889,7 +1077,11 @@ func (c *Core) sealInitCommon(req *logical.Request) (retErr error) {
 		DisplayName: te.DisplayName,
 	}
 
-	if err := c.auditBroker.LogRequest(auth, req, nil); err != nil {
+	logInput := &audit.LogInput{
+		Auth:    auth,
+		Request: req,
+	}
+	if err := c.auditBroker.LogRequest(logInput, c.auditedHeaders); err != nil {
 		c.logger.Error("core: failed to audit request", "request_path", req.Path, "error", err)
 		retErr = multierror.Append(retErr, errors.New("failed to audit request, cannot continue"))
 		return retErr
@@ -957,7 +1149,7 @@ func (c *Core) StepDown(req *logical.Request) (retErr error) {
 	if c.sealed {
 		return nil
 	}
-	if c.ha == nil || c.standby {
+	if c.standby {
 		return nil
 	}
 
@@ -975,7 +1167,11 @@ func (c *Core) StepDown(req *logical.Request) (retErr error) {
 		DisplayName: te.DisplayName,
 	}
 
-	if err := c.auditBroker.LogRequest(auth, req, nil); err != nil {
+	logInput := &audit.LogInput{
+		Auth:    auth,
+		Request: req,
+	}
+	if err := c.auditBroker.LogRequest(logInput, c.auditedHeaders); err != nil {
 		c.logger.Error("core: failed to audit request", "request_path", req.Path, "error", err)
 		retErr = multierror.Append(retErr, errors.New("failed to audit request, cannot continue"))
 		return retErr
@@ -1019,6 +1215,11 @@ func (c *Core) StepDown(req *logical.Request) (retErr error) {
 		return retErr
 	}
 
+	// Without HA there is no lock to give up
+	if c.ha == nil {
+		return retErr
+	}
+
 	select {
 	case c.manualStepDownCh <- struct{}{}:
 	default:
@@ -1111,6 +1312,9 @@ func (c *Core) postUnseal() (retErr error) {
 	if err := c.setupPolicyStore(); err != nil {
 		return err
 	}
+	if err := c.setupNamespaces(); err != nil {
+		return err
+	}
 	if err := c.loadCredentials(); err != nil {
 		return err
 	}
@@ -1120,12 +1324,24 @@ func (c *Core) postUnseal() (retErr error) {
 	if err := c.setupExpiration(); err != nil {
 		return err
 	}
+	if err := c.loadMountMigrations(); err != nil {
+		return err
+	}
 	if err := c.loadAudits(); err != nil {
 		return err
 	}
 	if err := c.setupAudits(); err != nil {
 		return err
 	}
+	if err := c.setupAuditedHeadersConfig(); err != nil {
+		return err
+	}
+	if err := c.setupCORSConfig(); err != nil {
+		return err
+	}
+	if err := c.setupQuotas(); err != nil {
+		return err
+	}
 	if c.ha != nil {
 		if err := c.startClusterListener(); err != nil {
 			return err
@@ -1167,6 +1383,9 @@ func (c *Core) preSeal() error {
 	if err := c.teardownCredentials(); err != nil {
 		result = multierror.Append(result, errwrap.Wrapf("error tearing down credentials: {{err}}", err))
 	}
+	if err := c.teardownNamespaces(); err != nil {
+		result = multierror.Append(result, errwrap.Wrapf("error tearing down namespaces: {{err}}", err))
+	}
 	if err := c.teardownPolicyStore(); err != nil {
 		result = multierror.Append(result, errwrap.Wrapf("error tearing down policy store: {{err}}", err))
 	}
@@ -556,6 +556,56 @@ func TestCore_HandleRequest_PermissionAllowed(t *testing.T) {
 	}
 }
 
+// Check that the parameter constraints of policies are enforced
+func TestCore_HandleRequest_PermissionParameters(t *testing.T) {
+	c, _, root := TestCoreUnsealed(t)
+	testCoreMakeToken(t, c, root, "child", "", []string{"test"})
+
+	req := &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "sys/policy/test",
+		Data: map[string]interface{}{
+			"rules": `path "secret/*" {
+	capabilities = ["create", "update"]
+	denied_parameters = {
+		"insecure_tls" = ["true"]
+	}
+}`,
+		},
+		ClientToken: root,
+	}
+	resp, err := c.HandleRequest(req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if resp != nil {
+		t.Fatalf("bad: %#v", resp)
+	}
+
+	req = &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "secret/test",
+		Data: map[string]interface{}{
+			"foo":          "bar",
+			"insecure_tls": true,
+		},
+		ClientToken: "child",
+	}
+	resp, err = c.HandleRequest(req)
+	if err == nil || !errwrap.Contains(err, logical.ErrPermissionDenied.Error()) {
+		t.Fatalf("err: %v, resp: %v", err, resp)
+	}
+
+	req.Data["insecure_tls"] = false
+	resp, err = c.HandleRequest(req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if resp != nil {
+		t.Fatalf("bad: %#v", resp)
+	}
+}
+
 func TestCore_HandleRequest_NoClientToken(t *testing.T) {
 	noop := &NoopBackend{
 		Response: &logical.Response{},
@@ -694,6 +744,63 @@ func TestCore_HandleLogin_Token(t *testing.T) {
 	}
 }
 
+func TestCore_HandleLogin_ExplicitMaxTTL(t *testing.T) {
+	noop := &NoopBackend{
+		Login: []string{"login"},
+		Response: &logical.Response{
+			Auth: &logical.Auth{
+				Policies: []string{"foo"},
+				LeaseOptions: logical.LeaseOptions{
+					TTL: 1000 * time.Hour,
+				},
+				ExplicitMaxTTL: 2000 * time.Hour,
+			},
+		},
+	}
+	c, _, root := TestCoreUnsealed(t)
+	c.credentialBackends["noop"] = func(conf *logical.BackendConfig) (logical.Backend, error) {
+		return noop, nil
+	}
+
+	req := logical.TestRequest(t, logical.UpdateOperation, "sys/auth/foo")
+	req.Data["type"] = "noop"
+	req.ClientToken = root
+	if _, err := c.HandleRequest(req); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// The explicit max TTL takes the place of the system max
+	lresp, err := c.HandleRequest(&logical.Request{
+		Path: "auth/foo/login",
+	})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if c.maxLeaseTTL >= 1000*time.Hour || lresp.Auth.TTL != 1000*time.Hour {
+		t.Fatalf("bad: %v", lresp.Auth.TTL)
+	}
+
+	te, err := c.tokenStore.Lookup(lresp.Auth.ClientToken)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if te.ExplicitMaxTTL != 2000*time.Hour {
+		t.Fatalf("bad: %#v", te)
+	}
+
+	// The TTL is limited by the explicit max TTL
+	noop.Response.Auth.ExplicitMaxTTL = 10 * time.Hour
+	lresp, err = c.HandleRequest(&logical.Request{
+		Path: "auth/foo/login",
+	})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if lresp.Auth.TTL != 10*time.Hour {
+		t.Fatalf("bad: %v", lresp.Auth.TTL)
+	}
+}
+
 func TestCore_HandleRequest_AuditTrail(t *testing.T) {
 	// Create a noop audit backend
 	noop := &NoopAudit{}
@@ -758,6 +865,67 @@ func TestCore_HandleRequest_AuditTrail(t *testing.T) {
 	}
 }
 
+func TestCore_HandleRequest_AuditTrail_NonHMACKeys(t *testing.T) {
+	noop := &NoopAudit{}
+	c, _, root := TestCoreUnsealed(t)
+	c.auditBackends["noop"] = func(config *audit.BackendConfig) (audit.Backend, error) {
+		noop = &NoopAudit{
+			Config: config,
+		}
+		return noop, nil
+	}
+
+	req := logical.TestRequest(t, logical.UpdateOperation, "sys/audit/noop")
+	req.Data["type"] = "noop"
+	req.ClientToken = root
+	if _, err := c.HandleRequest(req); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Exempt some keys of the generic backend from HMACing
+	req = logical.TestRequest(t, logical.UpdateOperation, "sys/mounts/secret/tune")
+	req.Data["audit_non_hmac_request_keys"] = "foo, bar,foo"
+	req.Data["audit_non_hmac_response_keys"] = "baz"
+	req.ClientToken = root
+	if _, err := c.HandleRequest(req); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	req = &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "secret/test",
+		Data: map[string]interface{}{
+			"foo": "bar",
+		},
+		ClientToken: root,
+	}
+	if _, err := c.HandleRequest(req); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	last := len(noop.RespNonHMACReqDataKeys) - 1
+	if last < 0 {
+		t.Fatalf("bad: %#v", noop)
+	}
+	if !reflect.DeepEqual(noop.RespNonHMACReqDataKeys[last], []string{"foo", "bar"}) {
+		t.Fatalf("bad: %#v", noop.RespNonHMACReqDataKeys[last])
+	}
+	if !reflect.DeepEqual(noop.RespNonHMACRespDataKeys[last], []string{"baz"}) {
+		t.Fatalf("bad: %#v", noop.RespNonHMACRespDataKeys[last])
+	}
+
+	// Requests to other mounts are not affected
+	req = logical.TestRequest(t, logical.ReadOperation, "sys/mounts")
+	req.ClientToken = root
+	if _, err := c.HandleRequest(req); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	last = len(noop.RespNonHMACReqDataKeys) - 1
+	if noop.RespNonHMACReqDataKeys[last] != nil || noop.RespNonHMACRespDataKeys[last] != nil {
+		t.Fatalf("bad: %#v", noop)
+	}
+}
+
 // Ensure we get a client token
 func TestCore_HandleLogin_AuditTrail(t *testing.T) {
 	// Create a badass credential backend that always logs in as armon
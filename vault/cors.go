@@ -0,0 +1,154 @@
+package vault
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/hashicorp/vault/helper/jsonutil"
+	"github.com/hashicorp/vault/helper/strutil"
+	"github.com/hashicorp/vault/logical"
+)
+
+// corsConfigPath is the key the CORS configuration is stored under in the
+// system view
+const corsConfigPath = "config/cors"
+
+// StdAllowedHeaders are the request headers always allowed by CORS
+var StdAllowedHeaders = []string{
+	"Content-Type",
+	"X-Requested-With",
+	"X-Vault-Namespace",
+	"X-Vault-No-Request-Forwarding",
+	"X-Vault-Request-Id",
+	"X-Vault-Token",
+	"X-Vault-Wrap-TTL",
+}
+
+// CORSConfig is the configuration of the Cross-Origin Resource Sharing of
+// the API, allowing browsers to call it from the allowed origins
+type CORSConfig struct {
+	Enabled        bool     `json:"enabled"`
+	AllowedOrigins []string `json:"allowed_origins"`
+	AllowedHeaders []string `json:"allowed_headers"`
+
+	view *BarrierView
+	sync.RWMutex
+}
+
+// CORSConfig returns the CORS configuration of the API
+func (c *Core) CORSConfig() *CORSConfig {
+	return c.corsConfig
+}
+
+// enable enables CORS for the given origins, allowing the standard headers
+// and the given ones, and persists the configuration
+func (cc *CORSConfig) enable(origins, headers []string) error {
+	allowedHeaders := append([]string{}, StdAllowedHeaders...)
+	for _, header := range headers {
+		header = http.CanonicalHeaderKey(strings.TrimSpace(header))
+		if header != "" && !strutil.StrListContains(allowedHeaders, header) {
+			allowedHeaders = append(allowedHeaders, header)
+		}
+	}
+
+	cc.Lock()
+	defer cc.Unlock()
+
+	origEnabled, origOrigins, origHeaders := cc.Enabled, cc.AllowedOrigins, cc.AllowedHeaders
+	cc.Enabled = true
+	cc.AllowedOrigins = origins
+	cc.AllowedHeaders = allowedHeaders
+
+	if err := cc.persist(); err != nil {
+		cc.Enabled, cc.AllowedOrigins, cc.AllowedHeaders = origEnabled, origOrigins, origHeaders
+		return err
+	}
+	return nil
+}
+
+// disable disables CORS and removes its configuration
+func (cc *CORSConfig) disable() error {
+	cc.Lock()
+	defer cc.Unlock()
+
+	if err := cc.view.Delete(corsConfigPath); err != nil {
+		return fmt.Errorf("failed to delete CORS config: %v", err)
+	}
+
+	cc.Enabled = false
+	cc.AllowedOrigins = nil
+	cc.AllowedHeaders = nil
+	return nil
+}
+
+// persist writes the configuration to storage. It must be called with the
+// lock held.
+func (cc *CORSConfig) persist() error {
+	entry, err := logical.StorageEntryJSON(corsConfigPath, cc)
+	if err != nil {
+		return fmt.Errorf("failed to encode CORS config: %v", err)
+	}
+	if err := cc.view.Put(entry); err != nil {
+		return fmt.Errorf("failed to persist CORS config: %v", err)
+	}
+	return nil
+}
+
+// IsEnabled returns whether CORS is enabled
+func (cc *CORSConfig) IsEnabled() bool {
+	cc.RLock()
+	defer cc.RUnlock()
+	return cc.Enabled
+}
+
+// IsValidOrigin returns whether requests from the origin are allowed
+func (cc *CORSConfig) IsValidOrigin(origin string) bool {
+	cc.RLock()
+	defer cc.RUnlock()
+
+	if origin == "" {
+		return false
+	}
+	if len(cc.AllowedOrigins) == 1 && cc.AllowedOrigins[0] == "*" {
+		return true
+	}
+	return strutil.StrListContains(cc.AllowedOrigins, strings.ToLower(origin))
+}
+
+// AllowedHeadersList returns the request headers allowed by CORS
+func (cc *CORSConfig) AllowedHeadersList() []string {
+	cc.RLock()
+	defer cc.RUnlock()
+	return append([]string{}, cc.AllowedHeaders...)
+}
+
+// setupCORSConfig loads the CORS configuration
+func (c *Core) setupCORSConfig() error {
+	view := c.systemBarrierView
+
+	out, err := view.Get(corsConfigPath)
+	if err != nil {
+		return fmt.Errorf("failed to read CORS config: %v", err)
+	}
+
+	var config CORSConfig
+	if out != nil {
+		if err := jsonutil.DecodeJSON(out.Value, &config); err != nil {
+			return fmt.Errorf("failed to decode CORS config: %v", err)
+		}
+	}
+
+	// The configuration is updated in place, since the HTTP handlers hold
+	// a reference to it
+	cc := c.corsConfig
+	cc.Lock()
+	defer cc.Unlock()
+	cc.Enabled = config.Enabled
+	cc.AllowedOrigins = config.AllowedOrigins
+	cc.AllowedHeaders = config.AllowedHeaders
+	cc.view = view
+
+	return nil
+}
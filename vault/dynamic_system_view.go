@@ -1,6 +1,7 @@
 package vault
 
 import (
+	"strings"
 	"time"
 
 	"github.com/hashicorp/vault/logical"
@@ -21,6 +22,9 @@ func (d dynamicSystemView) MaxLeaseTTL() time.Duration {
 	return max
 }
 
+// SudoPrivilege returns whether the token has sudo privileges on the path.
+// The path is relative to the root namespace, like the paths given to
+// Capabilities.
 func (d dynamicSystemView) SudoPrivilege(path string, token string) bool {
 	// Resolve the token policy
 	te, err := d.core.tokenStore.Lookup(token)
@@ -35,10 +39,28 @@ func (d dynamicSystemView) SudoPrivilege(path string, token string) bool {
 		return false
 	}
 
+	// The paths of the policies of the token are relative to its namespace
+	ns := d.core.namespaceByID(te.NamespaceID)
+	if ns == nil || !strings.HasPrefix(path, ns.Path) {
+		return false
+	}
+	ps := d.core.namespacePolicyStore(ns)
+	if ps == nil {
+		return false
+	}
+	path = strings.TrimPrefix(path, ns.Path)
+
+	// Include the policies of the token's entity, if any
+	policies, err := d.core.tokenPolicies(te)
+	if err != nil {
+		d.core.logger.Error("core: failed to fetch entity policies", "error", err)
+		return false
+	}
+
 	// Construct the corresponding ACL object
-	acl, err := d.core.policyStore.ACL(te.Policies...)
+	acl, err := ps.ACL(policies...)
 	if err != nil {
-		d.core.logger.Error("failed to retrieve ACL for token's policies", "token_policies", te.Policies, "error", err)
+		d.core.logger.Error("failed to retrieve ACL for token's policies", "token_policies", policies, "error", err)
 		return false
 	}
 
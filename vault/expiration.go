@@ -14,6 +14,7 @@ import (
 	"github.com/hashicorp/go-uuid"
 	"github.com/hashicorp/vault/helper/jsonutil"
 	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/physical"
 )
 
 const (
@@ -33,6 +34,13 @@ const (
 	// revokeRetryBase is a baseline retry time
 	revokeRetryBase = 10 * time.Second
 
+	// revokeWorkers is the number of expired leases revoked concurrently
+	revokeWorkers = 200
+
+	// restoreWorkers is the number of leases loaded concurrently when
+	// restoring
+	restoreWorkers = 64
+
 	// minRevokeDelay is used to prevent an instant revoke on restore
 	minRevokeDelay = 5 * time.Second
 
@@ -49,6 +57,7 @@ const (
 // the ExpirationManager will handle doing automatic revocation.
 type ExpirationManager struct {
 	router     *Router
+	view       *BarrierView
 	idView     *BarrierView
 	tokenView  *BarrierView
 	tokenStore *TokenStore
@@ -56,6 +65,114 @@ type ExpirationManager struct {
 
 	pending     map[string]*time.Timer
 	pendingLock sync.Mutex
+
+	// Expired leases are revoked by a pool of workers started with the
+	// first expiration. Stop replaces the queue, which stops the workers.
+	revokeQueue          *revocationQueue
+	revokeWorkersStarted bool
+
+	// quitCh is closed when the manager is stopped, which aborts the
+	// restore running in the background
+	quitCh    chan struct{}
+	restoreWG sync.WaitGroup
+
+	// leaseCounts holds the leases under the prefixes of the lease count
+	// quotas. The lock is held for reading while leases are stored or
+	// deleted, and for writing while a prefix is scanned.
+	leaseCounts    map[string]*leaseCounter
+	leaseCountLock sync.RWMutex
+}
+
+// leaseCounter holds the IDs of the leases under a prefix tracked for the
+// lease count quotas. refs is the number of quotas using the prefix.
+type leaseCounter struct {
+	l    sync.Mutex
+	ids  map[string]struct{}
+	refs int
+}
+
+// trackLeaseCount starts counting the leases, including those of tokens,
+// under the given prefix. The leases are counted from storage, so the count
+// is right while the leases are still being restored.
+func (m *ExpirationManager) trackLeaseCount(prefix string) error {
+	// Holding the lock for writing waits for the leases being stored or
+	// deleted, so none is missed by the scan below
+	m.leaseCountLock.Lock()
+	defer m.leaseCountLock.Unlock()
+
+	if counter, ok := m.leaseCounts[prefix]; ok {
+		counter.refs++
+		return nil
+	}
+
+	// Physical backends list directories, so scan the one containing the
+	// prefix and filter the keys
+	dir := prefix[:strings.LastIndex(prefix, "/")+1]
+	existing, err := CollectKeys(m.idView.SubView(dir))
+	if err != nil {
+		return fmt.Errorf("failed to scan for leases: %v", err)
+	}
+
+	counter := &leaseCounter{
+		ids:  make(map[string]struct{}),
+		refs: 1,
+	}
+	for _, suffix := range existing {
+		if leaseID := dir + suffix; strings.HasPrefix(leaseID, prefix) {
+			counter.ids[leaseID] = struct{}{}
+		}
+	}
+	m.leaseCounts[prefix] = counter
+	return nil
+}
+
+// untrackLeaseCount stops counting the leases under the given prefix once no
+// quota uses it anymore
+func (m *ExpirationManager) untrackLeaseCount(prefix string) {
+	m.leaseCountLock.Lock()
+	defer m.leaseCountLock.Unlock()
+
+	counter, ok := m.leaseCounts[prefix]
+	if !ok {
+		return
+	}
+	counter.refs--
+	if counter.refs <= 0 {
+		delete(m.leaseCounts, prefix)
+	}
+}
+
+// leaseCount returns the number of leases, including those of tokens, under
+// the given prefix. The prefix must be tracked with trackLeaseCount.
+func (m *ExpirationManager) leaseCount(prefix string) int {
+	m.leaseCountLock.RLock()
+	defer m.leaseCountLock.RUnlock()
+
+	counter, ok := m.leaseCounts[prefix]
+	if !ok {
+		return 0
+	}
+	counter.l.Lock()
+	defer counter.l.Unlock()
+	return len(counter.ids)
+}
+
+// updateLeaseCounts adds or removes a lease from the counts of the tracked
+// prefixes containing it. It must be called with the lease count lock held
+// for reading, across the storage operation.
+func (m *ExpirationManager) updateLeaseCounts(leaseID string, add bool) {
+	for prefix, counter := range m.leaseCounts {
+		if !strings.HasPrefix(leaseID, prefix) {
+			continue
+		}
+		counter.l.Lock()
+		if add {
+			counter.ids[leaseID] = struct{}{}
+		} else {
+			delete(counter.ids, leaseID)
+		}
+		counter.l.Unlock()
+	}
 }
 
 // NewExpirationManager creates a new ExpirationManager that is backed
@@ -66,12 +183,17 @@ func NewExpirationManager(router *Router, view *BarrierView, ts *TokenStore, log
 
 	}
 	exp := &ExpirationManager{
-		router:     router,
-		idView:     view.SubView(leaseViewPrefix),
-		tokenView:  view.SubView(tokenViewPrefix),
-		tokenStore: ts,
-		logger:     logger,
-		pending:    make(map[string]*time.Timer),
+		router:      router,
+		view:        view,
+		idView:      view.SubView(leaseViewPrefix),
+		tokenView:   view.SubView(tokenViewPrefix),
+		tokenStore:  ts,
+		logger:      logger,
+		pending:     make(map[string]*time.Timer),
+		leaseCounts: make(map[string]*leaseCounter),
+
+		revokeQueue: newRevocationQueue(),
+		quitCh:      make(chan struct{}),
 	}
 	return exp
 }
@@ -91,10 +213,16 @@ func (c *Core) setupExpiration() error {
 	// Link the token store to this
 	c.tokenStore.SetExpirationManager(mgr)
 
-	// Restore the existing state
-	if err := c.expiration.Restore(); err != nil {
-		return fmt.Errorf("expiration state restore failed: %v", err)
-	}
+	// Restore the existing state in the background, so that Vault is
+	// available without waiting for every lease to be loaded. Leases not
+	// restored yet are still read from storage when renewed or revoked.
+	mgr.restoreWG.Add(1)
+	go func() {
+		defer mgr.restoreWG.Done()
+		if err := mgr.Restore(); err != nil {
+			mgr.logger.Error("expire: expiration state restore failed, leases not restored will not expire until the next unseal", "error", err)
+		}
+	}()
 	return nil
 }
 
@@ -113,10 +241,12 @@ func (c *Core) stopExpiration() error {
 }
 
 // Restore is used to recover the lease states when starting.
-// This is used after starting the vault.
+// This is used after starting the vault. Leases are loaded concurrently,
+// and the restore is aborted when the manager is stopped.
 func (m *ExpirationManager) Restore() error {
 	m.pendingLock.Lock()
-	defer m.pendingLock.Unlock()
+	quitCh := m.quitCh
+	m.pendingLock.Unlock()
 
 	// Accumulate existing leases
 	existing, err := CollectKeys(m.idView)
@@ -124,52 +254,128 @@ func (m *ExpirationManager) Restore() error {
 		return fmt.Errorf("failed to scan for leases: %v", err)
 	}
 
-	// Restore each key
-	for _, leaseID := range existing {
-		// Load the entry
-		le, err := m.loadEntry(leaseID)
-		if err != nil {
-			return err
-		}
+	var errLock sync.Mutex
+	var restoreErr error
+	var restored int
 
-		// If there is no entry, nothing to restore
-		if le == nil {
-			continue
-		}
+	leaseCh := make(chan string)
+	var wg sync.WaitGroup
+	for i := 0; i < restoreWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for leaseID := range leaseCh {
+				ok, err := m.restoreEntry(leaseID, quitCh)
 
-		// If there is no expiry time, don't do anything
-		if le.ExpireTime.IsZero() {
-			continue
+				errLock.Lock()
+				if err != nil && restoreErr == nil {
+					restoreErr = err
+				}
+				if ok {
+					restored++
+				}
+				errLock.Unlock()
+			}
+		}()
+	}
+
+	// Restore each key
+LOOP:
+	for _, leaseID := range existing {
+		errLock.Lock()
+		failed := restoreErr != nil
+		errLock.Unlock()
+		if failed {
+			break
 		}
 
-		// Determine the remaining time to expiration
-		expires := le.ExpireTime.Sub(time.Now())
-		if expires <= 0 {
-			expires = minRevokeDelay
+		select {
+		case <-quitCh:
+			break LOOP
+		case leaseCh <- leaseID:
 		}
+	}
+	close(leaseCh)
+	wg.Wait()
 
-		// Setup revocation timer
-		m.pending[le.LeaseID] = time.AfterFunc(expires, func() {
-			m.expireID(le.LeaseID)
-		})
+	if restoreErr != nil {
+		return restoreErr
 	}
-	if len(m.pending) > 0 {
+	if restored > 0 {
 		if m.logger.IsInfo() {
-			m.logger.Info("expire: leases restored", "restored_lease_count", len(m.pending))
+			m.logger.Info("expire: leases restored", "restored_lease_count", restored)
 		}
 	}
 	return nil
 }
 
+// restoreEntry sets up the revocation timer of a lease being restored. It
+// returns whether a timer was set up.
+func (m *ExpirationManager) restoreEntry(leaseID string, quitCh chan struct{}) (bool, error) {
+	// Load the entry
+	le, err := m.loadEntry(leaseID)
+	if err != nil {
+		return false, err
+	}
+
+	// If there is no entry, nothing to restore
+	if le == nil {
+		return false, nil
+	}
+
+	// If there is no expiry time, don't do anything
+	if le.ExpireTime.IsZero() {
+		return false, nil
+	}
+
+	// Determine the remaining time to expiration
+	expires := le.ExpireTime.Sub(time.Now())
+	if expires <= 0 {
+		expires = minRevokeDelay
+	}
+
+	m.pendingLock.Lock()
+	defer m.pendingLock.Unlock()
+
+	// Don't set up timers once stopped, and keep the timers of leases
+	// renewed since the restore started
+	select {
+	case <-quitCh:
+		return false, nil
+	default:
+	}
+	if _, ok := m.pending[le.LeaseID]; ok {
+		return false, nil
+	}
+
+	// Setup revocation timer
+	m.pending[le.LeaseID] = time.AfterFunc(expires, func() {
+		m.expireID(le.LeaseID)
+	})
+	return true, nil
+}
+
 // Stop is used to prevent further automatic revocations.
 // This must be called before sealing the view.
 func (m *ExpirationManager) Stop() error {
+	// Abort the restore and wait for it to return
+	m.pendingLock.Lock()
+	close(m.quitCh)
+	m.pendingLock.Unlock()
+	m.restoreWG.Wait()
+
 	// Stop all the pending expiration timers
 	m.pendingLock.Lock()
 	for _, timer := range m.pending {
 		timer.Stop()
 	}
 	m.pending = make(map[string]*time.Timer)
+
+	// Drop the queued revocations and stop the workers
+	m.revokeQueue.close()
+	m.revokeQueue = newRevocationQueue()
+	m.revokeWorkersStarted = false
+	m.quitCh = make(chan struct{})
 	m.pendingLock.Unlock()
 	return nil
 }
@@ -209,14 +415,22 @@ func (m *ExpirationManager) revokeCommon(leaseID string, force, skipToken bool)
 		}
 	}
 
-	// Delete the entry
-	if err := m.deleteEntry(leaseID); err != nil {
-		return err
+	// Delete the entry and the secondary index
+	txns := []*TxnEntry{
+		&TxnEntry{
+			Operation: physical.DeleteOperation,
+			Entry:     &Entry{Key: leaseViewPrefix + leaseID},
+		},
+		m.removeIndexByTokenTxn(le.ClientToken, le.LeaseID),
 	}
-
-	// Delete the secondary index
-	if err := m.removeIndexByToken(le.ClientToken, le.LeaseID); err != nil {
-		return err
+	m.leaseCountLock.RLock()
+	err = m.view.Transaction(txns)
+	if err == nil {
+		m.updateLeaseCounts(leaseID, false)
+	}
+	m.leaseCountLock.RUnlock()
+	if err != nil {
+		return fmt.Errorf("failed to delete lease entry: %v", err)
 	}
 
 	// Clear the expiration handler
@@ -302,6 +516,103 @@ func (m *ExpirationManager) revokePrefixCommon(prefix string, force bool) error
 	return nil
 }
 
+// MovePrefix is used to move all the leases with a given prefix to a new
+// prefix, when the mount they were issued by is remounted. Each lease is
+// moved along with its secondary index in a single transaction, but the
+// prefix as a whole isn't moved atomically: if moving fails midway, every
+// lease is found under one of the prefixes, and the caller moves them back.
+func (m *ExpirationManager) MovePrefix(src, dst string) error {
+	defer metrics.MeasureSince([]string{"expire", "move-prefix"}, time.Now())
+
+	// Ensure there is a trailing slash
+	if !strings.HasSuffix(src, "/") {
+		src = src + "/"
+	}
+	if !strings.HasSuffix(dst, "/") {
+		dst = dst + "/"
+	}
+
+	// Accumulate existing leases
+	sub := m.idView.SubView(src)
+	existing, err := CollectKeys(sub)
+	if err != nil {
+		return fmt.Errorf("failed to scan for leases: %v", err)
+	}
+
+	// Move all the keys
+	for idx, suffix := range existing {
+		leaseID := src + suffix
+		if err := m.moveEntry(leaseID, src, dst); err != nil {
+			return fmt.Errorf("failed to move '%s' (%d / %d): %v",
+				leaseID, idx+1, len(existing), err)
+		}
+	}
+	return nil
+}
+
+// moveEntry moves a lease from the src prefix to the dst prefix, along with
+// its secondary index and expiration timer
+func (m *ExpirationManager) moveEntry(leaseID, src, dst string) error {
+	le, err := m.loadEntry(leaseID)
+	if err != nil {
+		return err
+	}
+	if le == nil {
+		return nil
+	}
+
+	newLeaseID := dst + strings.TrimPrefix(leaseID, src)
+	le.LeaseID = newLeaseID
+	le.Path = dst + strings.TrimPrefix(le.Path, src)
+	if le.Secret != nil && le.Secret.LeaseID != "" {
+		le.Secret.LeaseID = newLeaseID
+	}
+
+	// Store the lease under its new ID first, and move its secondary index
+	// in the same transaction
+	txn, err := m.persistEntryTxn(le)
+	if err != nil {
+		return err
+	}
+	txns := []*TxnEntry{txn}
+	if le.ClientToken != "" {
+		txns = append(txns,
+			m.createIndexByTokenTxn(le.ClientToken, newLeaseID),
+			m.removeIndexByTokenTxn(le.ClientToken, leaseID))
+	}
+	txns = append(txns, &TxnEntry{
+		Operation: physical.DeleteOperation,
+		Entry:     &Entry{Key: leaseViewPrefix + leaseID},
+	})
+	m.leaseCountLock.RLock()
+	err = m.view.Transaction(txns)
+	if err == nil {
+		m.updateLeaseCounts(leaseID, false)
+		m.updateLeaseCounts(newLeaseID, true)
+	}
+	m.leaseCountLock.RUnlock()
+	if err != nil {
+		return fmt.Errorf("failed to move lease entry: %v", err)
+	}
+
+	// Move the expiration handler
+	m.pendingLock.Lock()
+	if timer, ok := m.pending[leaseID]; ok {
+		timer.Stop()
+		delete(m.pending, leaseID)
+	}
+	m.pendingLock.Unlock()
+	if !le.ExpireTime.IsZero() {
+		expires := le.ExpireTime.Sub(time.Now())
+		if expires <= 0 {
+			expires = minRevokeDelay
+		}
+		m.updatePending(le, expires)
+	}
+
+	return nil
+}
+
 // Renew is used to renew a secret using the given leaseID
 // and a renew interval. The increment may be ignored.
 func (m *ExpirationManager) Renew(leaseID string, increment time.Duration) (*logical.Response, error) {
@@ -443,14 +754,24 @@ func (m *ExpirationManager) Register(req *logical.Request, resp *logical.Respons
 		ExpireTime:  resp.Secret.ExpirationTime(),
 	}
 
-	// Encode the entry
-	if err := m.persistEntry(&le); err != nil {
+	// Encode the entry, and maintain the secondary index by token in the
+	// same transaction
+	txn, err := m.persistEntryTxn(&le)
+	if err != nil {
 		return "", err
 	}
-
-	// Maintain secondary index by token
-	if err := m.createIndexByToken(le.ClientToken, le.LeaseID); err != nil {
-		return "", err
+	txns := []*TxnEntry{
+		txn,
+		m.createIndexByTokenTxn(le.ClientToken, le.LeaseID),
+	}
+	m.leaseCountLock.RLock()
+	err = m.view.Transaction(txns)
+	if err == nil {
+		m.updateLeaseCounts(le.LeaseID, true)
+	}
+	m.leaseCountLock.RUnlock()
+	if err != nil {
+		return "", fmt.Errorf("failed to persist lease entry: %v", err)
 	}
 
 	// Setup revocation timer if there is a lease
@@ -477,7 +798,13 @@ func (m *ExpirationManager) RegisterAuth(source string, auth *logical.Auth) erro
 	}
 
 	// Encode the entry
-	if err := m.persistEntry(&le); err != nil {
+	m.leaseCountLock.RLock()
+	err := m.persistEntry(&le)
+	if err == nil {
+		m.updateLeaseCounts(le.LeaseID, true)
+	}
+	m.leaseCountLock.RUnlock()
+	if err != nil {
 		return err
 	}
 
@@ -530,6 +857,23 @@ func (m *ExpirationManager) FetchLeaseTimes(leaseID string) (*leaseEntry, error)
 	return ret, nil
 }
 
+// ListLeases is used to list the lease IDs and sub-prefixes directly under
+// the given prefix. Sub-prefixes end with a slash.
+func (m *ExpirationManager) ListLeases(prefix string) ([]string, error) {
+	defer metrics.MeasureSince([]string{"expire", "list-leases"}, time.Now())
+
+	// Ensure there is a trailing slash
+	if prefix != "" && !strings.HasSuffix(prefix, "/") {
+		prefix = prefix + "/"
+	}
+
+	keys, err := m.idView.List(prefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list leases: %v", err)
+	}
+	return keys, nil
+}
+
 // updatePending is used to update a pending invocation for a lease
 func (m *ExpirationManager) updatePending(le *leaseEntry, leaseTotal time.Duration) {
 	m.pendingLock.Lock()
@@ -567,18 +911,54 @@ func (m *ExpirationManager) expireID(leaseID string) {
 	delete(m.pending, leaseID)
 	m.pendingLock.Unlock()
 
-	for attempt := uint(0); attempt < maxRevokeAttempts; attempt++ {
-		err := m.Revoke(leaseID)
+	m.queueRevocation(&revocationJob{leaseID: leaseID})
+}
+
+// queueRevocation queues an expired lease for revocation by the workers,
+// under the mount of the lease
+func (m *ExpirationManager) queueRevocation(job *revocationJob) {
+	m.pendingLock.Lock()
+	q := m.revokeQueue
+	if !m.revokeWorkersStarted {
+		m.revokeWorkersStarted = true
+		for i := 0; i < revokeWorkers; i++ {
+			go m.revokeWorker(q)
+		}
+	}
+	m.pendingLock.Unlock()
+
+	q.push(m.router.MatchingMount(job.leaseID), job)
+}
+
+// revokeWorker revokes the leases of the queue until it is closed, when the
+// expiration manager is stopped
+func (m *ExpirationManager) revokeWorker(q *revocationQueue) {
+	for {
+		job, ok := q.pop()
+		if !ok {
+			return
+		}
+
+		err := m.Revoke(job.leaseID)
 		if err == nil {
 			if m.logger.IsInfo() {
-				m.logger.Info("expire: revoked lease", "lease_id", leaseID)
+				m.logger.Info("expire: revoked lease", "lease_id", job.leaseID)
 			}
-			return
+			continue
+		}
+		m.logger.Error("expire: failed to revoke lease", "lease_id", job.leaseID, "error", err)
+
+		// Retry later rather than holding a worker
+		delay := (1 << job.attempt) * revokeRetryBase
+		job.attempt++
+		if job.attempt >= maxRevokeAttempts {
+			m.logger.Error("expire: maximum revoke attempts reached", "lease_id", job.leaseID)
+			continue
 		}
-		m.logger.Error("expire: failed to revoke lease", "lease_id", leaseID, "error", err)
-		time.Sleep((1 << attempt) * revokeRetryBase)
+		time.AfterFunc(delay, func() {
+			q.push(m.router.MatchingMount(job.leaseID), job)
+		})
 	}
-	m.logger.Error("expire: maximum revoke attempts reached", "lease_id", leaseID)
 }
 
 // revokeEntry is used to attempt revocation of an internal entry
@@ -654,6 +1034,19 @@ func (m *ExpirationManager) loadEntry(leaseID string) (*leaseEntry, error) {
 	return le, nil
 }
 
+// persistEntryTxn returns the operation persisting a lease entry, relative
+// to the view of the expiration manager
+func (m *ExpirationManager) persistEntryTxn(le *leaseEntry) (*TxnEntry, error) {
+	buf, err := le.encode()
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode lease entry: %v", err)
+	}
+	return &TxnEntry{
+		Operation: physical.PutOperation,
+		Entry:     &Entry{Key: leaseViewPrefix + le.LeaseID, Value: buf},
+	}, nil
+}
+
 // persistEntry is used to persist a lease entry
 func (m *ExpirationManager) persistEntry(le *leaseEntry) error {
 	// Encode the entry
@@ -675,7 +1068,13 @@ func (m *ExpirationManager) persistEntry(le *leaseEntry) error {
 
 // deleteEntry is used to delete a lease entry
 func (m *ExpirationManager) deleteEntry(leaseID string) error {
-	if err := m.idView.Delete(leaseID); err != nil {
+	m.leaseCountLock.RLock()
+	err := m.idView.Delete(leaseID)
+	if err == nil {
+		m.updateLeaseCounts(leaseID, false)
+	}
+	m.leaseCountLock.RUnlock()
+	if err != nil {
 		return fmt.Errorf("failed to delete lease entry: %v", err)
 	}
 	return nil
@@ -693,6 +1092,19 @@ func (m *ExpirationManager) createIndexByToken(token, leaseID string) error {
 	return nil
 }
 
+// createIndexByTokenTxn returns the operation creating the secondary index
+// from the token to a lease entry, relative to the view of the expiration
+// manager
+func (m *ExpirationManager) createIndexByTokenTxn(token, leaseID string) *TxnEntry {
+	return &TxnEntry{
+		Operation: physical.PutOperation,
+		Entry: &Entry{
+			Key:   tokenViewPrefix + m.tokenStore.SaltID(token) + "/" + m.tokenStore.SaltID(leaseID),
+			Value: []byte(leaseID),
+		},
+	}
+}
+
 // indexByToken looks up the secondary index from the token to a lease entry
 func (m *ExpirationManager) indexByToken(token, leaseID string) (*logical.StorageEntry, error) {
 	key := m.tokenStore.SaltID(token) + "/" + m.tokenStore.SaltID(leaseID)
@@ -712,6 +1124,16 @@ func (m *ExpirationManager) removeIndexByToken(token, leaseID string) error {
 	return nil
 }
 
+// removeIndexByTokenTxn returns the operation removing the secondary index
+// from the token to a lease entry, relative to the view of the expiration
+// manager
+func (m *ExpirationManager) removeIndexByTokenTxn(token, leaseID string) *TxnEntry {
+	return &TxnEntry{
+		Operation: physical.DeleteOperation,
+		Entry:     &Entry{Key: tokenViewPrefix + m.tokenStore.SaltID(token) + "/" + m.tokenStore.SaltID(leaseID)},
+	}
+}
+
 // lookupByToken is used to lookup all the leaseID's via the
 func (m *ExpirationManager) lookupByToken(token string) ([]string, error) {
 	// Scan via the index for sub-leases
@@ -740,8 +1162,17 @@ func (m *ExpirationManager) lookupByToken(token string) ([]string, error) {
 func (m *ExpirationManager) emitMetrics() {
 	m.pendingLock.Lock()
 	num := len(m.pending)
+	var numTokens int
+	for leaseID := range m.pending {
+		if strings.HasPrefix(leaseID, "auth/") {
+			numTokens++
+		}
+	}
+	q := m.revokeQueue
 	m.pendingLock.Unlock()
 	metrics.SetGauge([]string{"expire", "num_leases"}, float32(num))
+	metrics.SetGauge([]string{"expire", "num_token_leases"}, float32(numTokens))
+	metrics.SetGauge([]string{"expire", "revocation_queue"}, float32(q.len()))
 }
 
 // leaseEntry is used to structure the values the expiration
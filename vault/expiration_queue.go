@@ -0,0 +1,100 @@
+package vault
+
+import (
+	"sync"
+)
+
+// revocationJob is a lease queued for revocation, along with the number of
+// revocations of it that already failed
+type revocationJob struct {
+	leaseID string
+	attempt uint
+}
+
+// revocationQueue holds the leases waiting to be revoked by the workers of
+// the expiration manager. Leases are queued per mount and the mounts are
+// served in turn, so that a mount with many expired leases, or whose
+// revocations are slow, doesn't hold back the revocations of other mounts.
+type revocationQueue struct {
+	lock   sync.Mutex
+	cond   *sync.Cond
+	queues map[string][]*revocationJob
+	order  []string
+	size   int
+	closed bool
+}
+
+func newRevocationQueue() *revocationQueue {
+	q := &revocationQueue{
+		queues: make(map[string][]*revocationJob),
+	}
+	q.cond = sync.NewCond(&q.lock)
+	return q
+}
+
+// push queues a job under the given mount. It returns false if the queue
+// is closed.
+func (q *revocationQueue) push(mount string, job *revocationJob) bool {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	if q.closed {
+		return false
+	}
+
+	if len(q.queues[mount]) == 0 {
+		q.order = append(q.order, mount)
+	}
+	q.queues[mount] = append(q.queues[mount], job)
+	q.size++
+	q.cond.Signal()
+	return true
+}
+
+// pop blocks until a job is queued and returns the next job of the next
+// mount in turn. It returns false once the queue is closed.
+func (q *revocationQueue) pop() (*revocationJob, bool) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	for len(q.order) == 0 && !q.closed {
+		q.cond.Wait()
+	}
+	if q.closed {
+		return nil, false
+	}
+
+	mount := q.order[0]
+	q.order = q.order[1:]
+
+	jobs := q.queues[mount]
+	job := jobs[0]
+	jobs[0] = nil
+	if len(jobs) == 1 {
+		delete(q.queues, mount)
+	} else {
+		q.queues[mount] = jobs[1:]
+		q.order = append(q.order, mount)
+	}
+	q.size--
+	return job, true
+}
+
+// len returns the number of queued jobs
+func (q *revocationQueue) len() int {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	return q.size
+}
+
+// close drops the queued jobs and wakes up the workers waiting for jobs
+func (q *revocationQueue) close() {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	q.closed = true
+	q.queues = nil
+	q.order = nil
+	q.size = 0
+	q.cond.Broadcast()
+}
@@ -0,0 +1,55 @@
+package vault
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestRevocationQueue(t *testing.T) {
+	q := newRevocationQueue()
+
+	for _, leaseID := range []string{"a/1", "a/2", "a/3", "a/4"} {
+		q.push("a/", &revocationJob{leaseID: leaseID})
+	}
+	q.push("b/", &revocationJob{leaseID: "b/1"})
+	q.push("c/", &revocationJob{leaseID: "c/1"})
+	q.push("b/", &revocationJob{leaseID: "b/2"})
+	if q.len() != 7 {
+		t.Fatalf("bad: %d", q.len())
+	}
+
+	// Mounts are served in turn
+	var popped []string
+	for q.len() > 0 {
+		job, ok := q.pop()
+		if !ok {
+			t.Fatal("queue closed")
+		}
+		popped = append(popped, job.leaseID)
+	}
+	expected := []string{"a/1", "b/1", "c/1", "a/2", "b/2", "a/3", "a/4"}
+	if !reflect.DeepEqual(popped, expected) {
+		t.Fatalf("bad: %#v", popped)
+	}
+
+	// Closing wakes up waiting workers
+	done := make(chan bool)
+	go func() {
+		_, ok := q.pop()
+		done <- ok
+	}()
+	q.close()
+	select {
+	case ok := <-done:
+		if ok {
+			t.Fatal("expected the queue to be closed")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("pop did not return")
+	}
+
+	if q.push("a/", &revocationJob{leaseID: "a/5"}) {
+		t.Fatal("expected push to fail")
+	}
+}
@@ -19,6 +19,20 @@ func mockExpiration(t *testing.T) *ExpirationManager {
 	return ts.expiration
 }
 
+// pendingCount returns the number of lease timers under the given prefix
+func pendingCount(m *ExpirationManager, prefix string) int {
+	m.pendingLock.Lock()
+	defer m.pendingLock.Unlock()
+
+	count := 0
+	for leaseID := range m.pending {
+		if strings.HasPrefix(leaseID, prefix) {
+			count++
+		}
+	}
+	return count
+}
+
 func TestExpiration_Restore(t *testing.T) {
 	exp := mockExpiration(t)
 	noop := &NoopBackend{}
@@ -89,6 +103,90 @@ func TestExpiration_Restore(t *testing.T) {
 	}
 }
 
+func TestExpiration_Restore_renewed(t *testing.T) {
+	exp := mockExpiration(t)
+	noop := &NoopBackend{}
+	_, barrier, _ := mockBarrier(t)
+	view := NewBarrierView(barrier, "logical/")
+	meUUID, err := uuid.GenerateUUID()
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp.router.Mount(noop, "prod/aws/", &MountEntry{UUID: meUUID}, view)
+
+	leaseIDs := make([]string, 2)
+	for i := range leaseIDs {
+		leaseIDs[i], err = exp.Register(&logical.Request{
+			Operation: logical.ReadOperation,
+			Path:      fmt.Sprintf("prod/aws/foo%d", i),
+		}, &logical.Response{
+			Secret: &logical.Secret{
+				LeaseOptions: logical.LeaseOptions{
+					TTL: time.Hour,
+				},
+			},
+		})
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+	}
+
+	if err := exp.Stop(); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// A lease renewed before the restore reaches it keeps its timer
+	le, err := exp.loadEntry(leaseIDs[0])
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	exp.updatePending(le, 2*time.Hour)
+	exp.pendingLock.Lock()
+	renewed := exp.pending[leaseIDs[0]]
+	exp.pendingLock.Unlock()
+
+	if err := exp.Restore(); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	exp.pendingLock.Lock()
+	defer exp.pendingLock.Unlock()
+	if len(exp.pending) != 2 {
+		t.Fatalf("bad: %#v", exp.pending)
+	}
+	if exp.pending[leaseIDs[0]] != renewed {
+		t.Fatal("timer of the renewed lease was replaced")
+	}
+}
+
+func TestCore_RestoreLeases(t *testing.T) {
+	c, key, root := TestCoreUnsealed(t)
+
+	req := logical.TestRequest(t, logical.UpdateOperation, "auth/token/create")
+	req.ClientToken = root
+	req.Data["ttl"] = "1h"
+	resp, err := c.HandleRequest(req)
+	if err != nil || resp == nil || resp.Auth == nil {
+		t.Fatalf("bad: %#v %v", resp, err)
+	}
+
+	if err := c.Seal(root); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if _, err := TestCoreUnseal(c, TestKeyCopy(key)); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Leases are restored in the background
+	start := time.Now()
+	for pendingCount(c.expiration, "auth/token/create/") != 1 {
+		if time.Now().Sub(start) > 5*time.Second {
+			t.Fatal("lease not restored")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
 func TestExpiration_Register(t *testing.T) {
 	exp := mockExpiration(t)
 	req := &logical.Request{
@@ -141,6 +239,90 @@ func TestExpiration_RegisterAuth(t *testing.T) {
 	}
 }
 
+func TestExpiration_leaseCount(t *testing.T) {
+	exp := mockExpiration(t)
+	noop := &NoopBackend{}
+	_, barrier, _ := mockBarrier(t)
+	view := NewBarrierView(barrier, "logical/")
+	meUUID, err := uuid.GenerateUUID()
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp.router.Mount(noop, "prod/aws/", &MountEntry{UUID: meUUID}, view)
+
+	register := func(path string) string {
+		req := &logical.Request{
+			Operation: logical.ReadOperation,
+			Path:      path,
+		}
+		resp := &logical.Response{
+			Secret: &logical.Secret{
+				LeaseOptions: logical.LeaseOptions{
+					TTL: time.Hour,
+				},
+			},
+		}
+		leaseID, err := exp.Register(req, resp)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		return leaseID
+	}
+
+	// Leases stored before the prefix is tracked are counted from storage
+	register("prod/aws/foo")
+	register("prod/aws/sub/bar")
+	register("prod/awsfoo/baz")
+	if err := exp.trackLeaseCount("prod/aws/"); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if err := exp.trackLeaseCount("prod/aws/f"); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if n := exp.leaseCount("prod/aws/"); n != 2 {
+		t.Fatalf("bad: %d", n)
+	}
+	if n := exp.leaseCount("prod/aws/f"); n != 1 {
+		t.Fatalf("bad: %d", n)
+	}
+
+	// Registered and revoked leases update the counts
+	leaseID := register("prod/aws/foo")
+	if n := exp.leaseCount("prod/aws/"); n != 3 {
+		t.Fatalf("bad: %d", n)
+	}
+	if err := exp.Revoke(leaseID); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if err := exp.Revoke(leaseID); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if n := exp.leaseCount("prod/aws/"); n != 2 {
+		t.Fatalf("bad: %d", n)
+	}
+
+	// Moved leases are counted under their new prefix
+	if err := exp.MovePrefix("prod/aws/sub/", "prod/awsfoo/sub/"); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if n := exp.leaseCount("prod/aws/"); n != 1 {
+		t.Fatalf("bad: %d", n)
+	}
+
+	// Prefixes are counted until no quota uses them
+	if err := exp.trackLeaseCount("prod/aws/"); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	exp.untrackLeaseCount("prod/aws/")
+	if n := exp.leaseCount("prod/aws/"); n != 1 {
+		t.Fatalf("bad: %d", n)
+	}
+	exp.untrackLeaseCount("prod/aws/")
+	if n := exp.leaseCount("prod/aws/"); n != 0 {
+		t.Fatalf("bad: %d", n)
+	}
+}
+
 func TestExpiration_RegisterAuth_NoLease(t *testing.T) {
 	exp := mockExpiration(t)
 	root, err := exp.tokenStore.rootToken()
@@ -274,6 +456,63 @@ func TestExpiration_RevokeOnExpire(t *testing.T) {
 	}
 }
 
+func TestExpiration_RevokeOnExpire_many(t *testing.T) {
+	exp := mockExpiration(t)
+	_, barrier, _ := mockBarrier(t)
+	view := NewBarrierView(barrier, "logical/")
+
+	var backends []*NoopBackend
+	for _, path := range []string{"prod/aws/", "prod/db/"} {
+		noop := &NoopBackend{}
+		meUUID, err := uuid.GenerateUUID()
+		if err != nil {
+			t.Fatal(err)
+		}
+		exp.router.Mount(noop, path, &MountEntry{UUID: meUUID}, view)
+		backends = append(backends, noop)
+
+		for i := 0; i < 500; i++ {
+			req := &logical.Request{
+				Operation: logical.ReadOperation,
+				Path:      fmt.Sprintf("%sfoo%d", path, i),
+			}
+			resp := &logical.Response{
+				Secret: &logical.Secret{
+					LeaseOptions: logical.LeaseOptions{
+						TTL: 20 * time.Millisecond,
+					},
+				},
+				Data: map[string]interface{}{
+					"access_key": "xyz",
+				},
+			}
+			if _, err := exp.Register(req, resp); err != nil {
+				t.Fatalf("err: %v", err)
+			}
+		}
+	}
+
+	start := time.Now()
+	for {
+		revoked := 0
+		for _, noop := range backends {
+			noop.Lock()
+			revoked += len(noop.Requests)
+			noop.Unlock()
+		}
+		if revoked == 1000 {
+			break
+		}
+		if time.Now().Sub(start) > 10*time.Second {
+			t.Fatalf("only %d leases revoked", revoked)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if n := pendingCount(exp, ""); n != 0 {
+		t.Fatalf("bad: %d pending leases", n)
+	}
+}
+
 func TestExpiration_RevokePrefix(t *testing.T) {
 	exp := mockExpiration(t)
 	noop := &NoopBackend{}
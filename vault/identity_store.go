@@ -0,0 +1,488 @@
+package vault
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/go-uuid"
+	"github.com/hashicorp/vault/helper/jsonutil"
+	"github.com/hashicorp/vault/helper/salt"
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+)
+
+const (
+	// entityIDPrefix is the prefix used to store entities by their ID
+	entityIDPrefix = "entity/id/"
+
+	// entityNamePrefix is the prefix used to store the index from
+	// entity name to entity ID
+	entityNamePrefix = "entity/name/"
+
+	// aliasIDPrefix is the prefix used to store the index from alias ID
+	// to the ID of the entity holding it
+	aliasIDPrefix = "alias/id/"
+
+	// aliasLookupPrefix is the prefix used to store the index from the
+	// salted mount path and alias name to the alias ID
+	aliasLookupPrefix = "alias/lookup/"
+
+	// groupIDPrefix is the prefix used to store groups by their ID
+	groupIDPrefix = "group/id/"
+
+	// groupNamePrefix is the prefix used to store the index from group
+	// name to group ID
+	groupNamePrefix = "group/name/"
+
+	// groupEntityMemberPrefix is the prefix used to store the index from
+	// an entity ID to the IDs of the groups the entity is a member of
+	groupEntityMemberPrefix = "group/member/entity/"
+
+	// groupGroupMemberPrefix is the prefix used to store the index from a
+	// group ID to the IDs of the groups the group is a member of
+	groupGroupMemberPrefix = "group/member/group/"
+
+	// groupAliasIDPrefix is the prefix used to store the index from group
+	// alias ID to the ID of the group holding it
+	groupAliasIDPrefix = "group-alias/id/"
+
+	// groupAliasLookupPrefix is the prefix used to store the index from the
+	// salted mount path and group alias name to the group ID
+	groupAliasLookupPrefix = "group-alias/lookup/"
+)
+
+const (
+	// groupTypeInternal is the type of groups whose members are managed
+	// via the identity store
+	groupTypeInternal = "internal"
+
+	// groupTypeExternal is the type of groups whose member entities are
+	// managed by a credential backend, via the group's alias
+	groupTypeExternal = "external"
+)
+
+// IdentityStore is used to manage identity entities and their aliases.
+// An entity represents a single client, such as a person or a machine,
+// and each alias maps an identity within a credential backend to the
+// entity. This allows logins via different backends to share one
+// identity, along with its policies and metadata.
+type IdentityStore struct {
+	*framework.Backend
+
+	core *Core
+	view logical.Storage
+	salt *salt.Salt
+
+	// lock guards all modifications of entities and their indexes
+	lock sync.RWMutex
+}
+
+// Entity represents a single client across all credential backends
+type Entity struct {
+	ID             string            `json:"id"`
+	Name           string            `json:"name"`
+	Policies       []string          `json:"policies"`
+	Metadata       map[string]string `json:"metadata"`
+	Aliases        []*EntityAlias    `json:"aliases"`
+	CreationTime   time.Time         `json:"creation_time"`
+	LastUpdateTime time.Time         `json:"last_update_time"`
+}
+
+// EntityAlias maps an identity within a credential backend to an entity
+type EntityAlias struct {
+	ID             string            `json:"id"`
+	EntityID       string            `json:"entity_id"`
+	MountPath      string            `json:"mount_path"`
+	Name           string            `json:"name"`
+	Metadata       map[string]string `json:"metadata"`
+	CreationTime   time.Time         `json:"creation_time"`
+	LastUpdateTime time.Time         `json:"last_update_time"`
+}
+
+// Group aggregates entities and other groups so that policies can be
+// attached to all of them at once
+type Group struct {
+	ID              string            `json:"id"`
+	Name            string            `json:"name"`
+	Type            string            `json:"type"`
+	Policies        []string          `json:"policies"`
+	Metadata        map[string]string `json:"metadata"`
+	MemberEntityIDs []string          `json:"member_entity_ids"`
+	MemberGroupIDs  []string          `json:"member_group_ids"`
+	Alias           *GroupAlias       `json:"alias"`
+	CreationTime    time.Time         `json:"creation_time"`
+	LastUpdateTime  time.Time         `json:"last_update_time"`
+}
+
+// GroupAlias maps a group within a credential backend, e.g. an LDAP group
+// or a GitHub team, to an external group
+type GroupAlias struct {
+	ID             string    `json:"id"`
+	GroupID        string    `json:"group_id"`
+	MountPath      string    `json:"mount_path"`
+	Name           string    `json:"name"`
+	CreationTime   time.Time `json:"creation_time"`
+	LastUpdateTime time.Time `json:"last_update_time"`
+}
+
+// aliasIndexEntry is the value stored in the alias indexes
+type aliasIndexEntry struct {
+	AliasID  string `json:"alias_id"`
+	EntityID string `json:"entity_id"`
+}
+
+// NewIdentityStore is used to construct the identity store, backed by
+// the storage view of its mount
+func NewIdentityStore(c *Core, config *logical.BackendConfig) (*IdentityStore, error) {
+	if config == nil || config.StorageView == nil {
+		return nil, fmt.Errorf("identity store requires a storage view")
+	}
+
+	i := &IdentityStore{
+		core: c,
+		view: config.StorageView,
+	}
+
+	salt, err := salt.NewSalt(i.view, &salt.Config{
+		HashFunc: salt.SHA256Hash,
+	})
+	if err != nil {
+		return nil, err
+	}
+	i.salt = salt
+
+	i.Backend = &framework.Backend{
+		Help: strings.TrimSpace(identityStoreHelp),
+		Paths: framework.PathAppend(
+			entityPaths(i),
+			aliasPaths(i),
+			groupPaths(i),
+			groupAliasPaths(i),
+		),
+	}
+
+	i.Backend.Setup(config)
+
+	return i, nil
+}
+
+// entityByID fetches the entity with the given ID, or nil if it does not
+// exist. The caller must hold the lock.
+func (i *IdentityStore) entityByID(id string) (*Entity, error) {
+	if id == "" {
+		return nil, nil
+	}
+
+	raw, err := i.view.Get(entityIDPrefix + id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read entity: %v", err)
+	}
+	if raw == nil {
+		return nil, nil
+	}
+
+	entity := new(Entity)
+	if err := jsonutil.DecodeJSON(raw.Value, entity); err != nil {
+		return nil, fmt.Errorf("failed to decode entity: %v", err)
+	}
+	return entity, nil
+}
+
+// entityByName fetches the entity with the given name, or nil if it does
+// not exist. The caller must hold the lock.
+func (i *IdentityStore) entityByName(name string) (*Entity, error) {
+	raw, err := i.view.Get(entityNamePrefix + strings.ToLower(name))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read entity name index: %v", err)
+	}
+	if raw == nil {
+		return nil, nil
+	}
+
+	return i.entityByID(string(raw.Value))
+}
+
+// persistEntity writes the entity and its indexes. If the name of the
+// entity changed, the index for the previous name is removed. The caller
+// must hold the lock.
+func (i *IdentityStore) persistEntity(entity *Entity, previousName string) error {
+	entry, err := logical.StorageEntryJSON(entityIDPrefix+entity.ID, entity)
+	if err != nil {
+		return err
+	}
+	if err := i.view.Put(entry); err != nil {
+		return fmt.Errorf("failed to persist entity: %v", err)
+	}
+
+	if previousName != "" && !strings.EqualFold(previousName, entity.Name) {
+		if err := i.view.Delete(entityNamePrefix + strings.ToLower(previousName)); err != nil {
+			return fmt.Errorf("failed to delete entity name index: %v", err)
+		}
+	}
+
+	nameEntry := &logical.StorageEntry{
+		Key:   entityNamePrefix + strings.ToLower(entity.Name),
+		Value: []byte(entity.ID),
+	}
+	if err := i.view.Put(nameEntry); err != nil {
+		return fmt.Errorf("failed to persist entity name index: %v", err)
+	}
+
+	return nil
+}
+
+// deleteEntity removes the entity, its aliases and all of their indexes,
+// along with its group memberships. The caller must hold the lock.
+func (i *IdentityStore) deleteEntity(entity *Entity) error {
+	if err := i.removeEntityMemberships(entity.ID); err != nil {
+		return err
+	}
+
+	for _, alias := range entity.Aliases {
+		if err := i.deleteAliasIndexes(alias); err != nil {
+			return err
+		}
+	}
+
+	if err := i.view.Delete(entityNamePrefix + strings.ToLower(entity.Name)); err != nil {
+		return fmt.Errorf("failed to delete entity name index: %v", err)
+	}
+	if err := i.view.Delete(entityIDPrefix + entity.ID); err != nil {
+		return fmt.Errorf("failed to delete entity: %v", err)
+	}
+	return nil
+}
+
+// aliasLookupKey returns the storage key of the index from mount path and
+// alias name to the alias
+func (i *IdentityStore) aliasLookupKey(mountPath, name string) string {
+	return aliasLookupPrefix + i.salt.SaltID(mountPath+name)
+}
+
+// aliasIndex fetches the alias index entry for the given mount path and
+// alias name, or nil if there is none. The caller must hold the lock.
+func (i *IdentityStore) aliasIndex(mountPath, name string) (*aliasIndexEntry, error) {
+	raw, err := i.view.Get(i.aliasLookupKey(mountPath, name))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read alias index: %v", err)
+	}
+	if raw == nil {
+		return nil, nil
+	}
+
+	index := new(aliasIndexEntry)
+	if err := jsonutil.DecodeJSON(raw.Value, index); err != nil {
+		return nil, fmt.Errorf("failed to decode alias index: %v", err)
+	}
+	return index, nil
+}
+
+// aliasByID fetches the alias with the given ID along with the entity
+// holding it. Both are nil if the alias does not exist. The caller must
+// hold the lock.
+func (i *IdentityStore) aliasByID(id string) (*EntityAlias, *Entity, error) {
+	if id == "" {
+		return nil, nil, nil
+	}
+
+	raw, err := i.view.Get(aliasIDPrefix + id)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read alias index: %v", err)
+	}
+	if raw == nil {
+		return nil, nil, nil
+	}
+
+	index := new(aliasIndexEntry)
+	if err := jsonutil.DecodeJSON(raw.Value, index); err != nil {
+		return nil, nil, fmt.Errorf("failed to decode alias index: %v", err)
+	}
+
+	entity, err := i.entityByID(index.EntityID)
+	if err != nil || entity == nil {
+		return nil, nil, err
+	}
+
+	for _, alias := range entity.Aliases {
+		if alias.ID == id {
+			return alias, entity, nil
+		}
+	}
+	return nil, nil, nil
+}
+
+// persistAliasIndexes writes the indexes pointing to the given alias. The
+// caller must hold the lock.
+func (i *IdentityStore) persistAliasIndexes(alias *EntityAlias) error {
+	index := &aliasIndexEntry{
+		AliasID:  alias.ID,
+		EntityID: alias.EntityID,
+	}
+
+	entry, err := logical.StorageEntryJSON(aliasIDPrefix+alias.ID, index)
+	if err != nil {
+		return err
+	}
+	if err := i.view.Put(entry); err != nil {
+		return fmt.Errorf("failed to persist alias index: %v", err)
+	}
+
+	entry, err = logical.StorageEntryJSON(i.aliasLookupKey(alias.MountPath, alias.Name), index)
+	if err != nil {
+		return err
+	}
+	if err := i.view.Put(entry); err != nil {
+		return fmt.Errorf("failed to persist alias index: %v", err)
+	}
+
+	return nil
+}
+
+// deleteAliasIndexes removes the indexes pointing to the given alias. The
+// caller must hold the lock.
+func (i *IdentityStore) deleteAliasIndexes(alias *EntityAlias) error {
+	if err := i.view.Delete(i.aliasLookupKey(alias.MountPath, alias.Name)); err != nil {
+		return fmt.Errorf("failed to delete alias index: %v", err)
+	}
+	if err := i.view.Delete(aliasIDPrefix + alias.ID); err != nil {
+		return fmt.Errorf("failed to delete alias index: %v", err)
+	}
+	return nil
+}
+
+// newEntity returns an entity with a generated ID and, if no name is
+// given, a generated name
+func newEntity(name string) (*Entity, error) {
+	entityID, err := uuid.GenerateUUID()
+	if err != nil {
+		return nil, err
+	}
+	if name == "" {
+		name = "entity_" + entityID[:8]
+	}
+
+	now := time.Now()
+	return &Entity{
+		ID:             entityID,
+		Name:           name,
+		CreationTime:   now,
+		LastUpdateTime: now,
+	}, nil
+}
+
+// CreateOrFetchEntity returns the entity the given alias of the credential
+// backend mounted at mountPath belongs to. If the alias is not known yet, a
+// new entity holding the alias is created.
+func (i *IdentityStore) CreateOrFetchEntity(mountPath string, alias *logical.Alias) (*Entity, error) {
+	if alias == nil || alias.Name == "" {
+		return nil, fmt.Errorf("missing alias name")
+	}
+
+	i.lock.Lock()
+	defer i.lock.Unlock()
+
+	index, err := i.aliasIndex(mountPath, alias.Name)
+	if err != nil {
+		return nil, err
+	}
+	if index != nil {
+		entity, err := i.entityByID(index.EntityID)
+		if err != nil {
+			return nil, err
+		}
+		if entity != nil {
+			return entity, nil
+		}
+	}
+
+	entity, err := newEntity("")
+	if err != nil {
+		return nil, err
+	}
+
+	aliasID, err := uuid.GenerateUUID()
+	if err != nil {
+		return nil, err
+	}
+	entity.Aliases = []*EntityAlias{
+		&EntityAlias{
+			ID:             aliasID,
+			EntityID:       entity.ID,
+			MountPath:      mountPath,
+			Name:           alias.Name,
+			Metadata:       alias.Metadata,
+			CreationTime:   entity.CreationTime,
+			LastUpdateTime: entity.CreationTime,
+		},
+	}
+
+	if err := i.persistEntity(entity, ""); err != nil {
+		return nil, err
+	}
+	if err := i.persistAliasIndexes(entity.Aliases[0]); err != nil {
+		return nil, err
+	}
+
+	return entity, nil
+}
+
+// EntityPolicies returns the policies attached to the entity with the
+// given ID. If the entity no longer exists, no policies are returned.
+func (i *IdentityStore) EntityPolicies(entityID string) ([]string, error) {
+	i.lock.RLock()
+	defer i.lock.RUnlock()
+
+	entity, err := i.entityByID(entityID)
+	if err != nil || entity == nil {
+		return nil, err
+	}
+	return entity.Policies, nil
+}
+
+// tokenPolicies returns the policies that apply to requests made with the
+// given token: those of the token itself and those of its entity and the
+// entity's groups, if any.
+func (c *Core) tokenPolicies(te *TokenEntry) ([]string, error) {
+	if te.EntityID == "" || c.identityStore == nil {
+		return te.Policies, nil
+	}
+
+	entityPolicies, err := c.identityStore.EntityPolicies(te.EntityID)
+	if err != nil {
+		return nil, err
+	}
+	groupPolicies, err := c.identityStore.GroupPolicies(te.EntityID)
+	if err != nil {
+		return nil, err
+	}
+	if len(entityPolicies) == 0 && len(groupPolicies) == 0 {
+		return te.Policies, nil
+	}
+
+	policies := make([]string, 0, len(te.Policies)+len(entityPolicies)+len(groupPolicies))
+	policies = append(policies, te.Policies...)
+	policies = append(policies, entityPolicies...)
+	policies = append(policies, groupPolicies...)
+	return policies, nil
+}
+
+const identityStoreHelp = `
+The identity backend is used to manage identity entities and their aliases.
+
+An entity represents a single client, such as a person or a machine, across
+all credential backends. Each alias of an entity maps the identity of the
+client within a particular credential backend, e.g. a GitHub username, to the
+entity. Logins via any of an entity's aliases produce tokens that carry the
+entity's policies in addition to their own.
+
+Logins via a credential backend that supports aliases automatically create
+an entity if the alias is not already known.
+
+Groups aggregate entities and other groups, and their policies are granted
+to all of their members. Internal groups have their members managed
+explicitly. External groups are mapped via an alias to a group within a
+credential backend, such as an LDAP group or a GitHub team, and their member
+entities are updated whenever one of the backend's users logs in.
+`
@@ -0,0 +1,334 @@
+package vault
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/go-uuid"
+	"github.com/hashicorp/vault/helper/strutil"
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+)
+
+// aliasPaths returns the API endpoints supported to operate on entity
+// aliases.
+//
+// Paths returned:
+// entity-alias - To create aliases
+// entity-alias/id/ - To list aliases
+// entity-alias/id/<id> - To read, update or delete an alias using its ID
+func aliasPaths(i *IdentityStore) []*framework.Path {
+	aliasFields := map[string]*framework.FieldSchema{
+		"name": &framework.FieldSchema{
+			Type:        framework.TypeString,
+			Description: "Name of the alias; the identifier of the client within the credential backend, e.g. a username.",
+		},
+		"mount_path": &framework.FieldSchema{
+			Type:        framework.TypeString,
+			Description: "Mount path of the credential backend the alias belongs to, e.g. 'auth/github/'.",
+		},
+		"entity_id": &framework.FieldSchema{
+			Type:        framework.TypeString,
+			Description: "ID of the entity the alias belongs to.",
+		},
+		"metadata": &framework.FieldSchema{
+			Type: framework.TypeString,
+			Description: `Metadata to be associated with the alias. This can be a JSON
+object or a comma separated list of key=value pairs.`,
+		},
+	}
+
+	aliasIDFields := map[string]*framework.FieldSchema{
+		"id": &framework.FieldSchema{
+			Type:        framework.TypeString,
+			Description: "ID of the alias.",
+		},
+	}
+	for k, v := range aliasFields {
+		aliasIDFields[k] = v
+	}
+
+	return []*framework.Path{
+		&framework.Path{
+			Pattern: "entity-alias$",
+			Fields:  aliasFields,
+			Callbacks: map[logical.Operation]framework.OperationFunc{
+				logical.UpdateOperation: i.pathAliasCreate,
+			},
+
+			HelpSynopsis:    strings.TrimSpace(aliasHelp["entity-alias"][0]),
+			HelpDescription: strings.TrimSpace(aliasHelp["entity-alias"][1]),
+		},
+		&framework.Path{
+			Pattern: "entity-alias/id/?$",
+			Callbacks: map[logical.Operation]framework.OperationFunc{
+				logical.ListOperation: i.pathAliasIDList,
+			},
+
+			HelpSynopsis:    strings.TrimSpace(aliasHelp["entity-alias-id-list"][0]),
+			HelpDescription: strings.TrimSpace(aliasHelp["entity-alias-id-list"][1]),
+		},
+		&framework.Path{
+			Pattern: "entity-alias/id/" + framework.GenericNameRegex("id"),
+			Fields:  aliasIDFields,
+			Callbacks: map[logical.Operation]framework.OperationFunc{
+				logical.ReadOperation:   i.pathAliasIDRead,
+				logical.UpdateOperation: i.pathAliasIDUpdate,
+				logical.DeleteOperation: i.pathAliasIDDelete,
+			},
+
+			HelpSynopsis:    strings.TrimSpace(aliasHelp["entity-alias-id"][0]),
+			HelpDescription: strings.TrimSpace(aliasHelp["entity-alias-id"][1]),
+		},
+	}
+}
+
+// parseMountPath normalizes the given mount path of a credential backend
+// and ensures that a credential backend is mounted there
+func (i *IdentityStore) parseMountPath(mountPath string) (string, error) {
+	mountPath = strings.Trim(mountPath, "/")
+	if mountPath == "" {
+		return "", fmt.Errorf("missing mount_path")
+	}
+	if !strings.HasPrefix(mountPath+"/", credentialRoutePrefix) {
+		mountPath = credentialRoutePrefix + mountPath
+	}
+	mountPath += "/"
+
+	if i.core.router.MatchingMount(mountPath) != mountPath {
+		return "", fmt.Errorf("no credential backend is mounted at %q", mountPath)
+	}
+	return mountPath, nil
+}
+
+// pathAliasCreate creates a new alias for an existing entity
+func (i *IdentityStore) pathAliasCreate(req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+
+	aliasID, err := uuid.GenerateUUID()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	alias := &EntityAlias{
+		ID:             aliasID,
+		CreationTime:   now,
+		LastUpdateTime: now,
+	}
+
+	return i.handleAliasUpdateCommon(alias, nil, d)
+}
+
+// pathAliasIDUpdate updates an existing alias, possibly moving it to a
+// different entity
+func (i *IdentityStore) pathAliasIDUpdate(req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+
+	alias, entity, err := i.aliasByID(d.Get("id").(string))
+	if err != nil {
+		return nil, err
+	}
+	if alias == nil {
+		return logical.ErrorResponse("alias not found"), nil
+	}
+
+	// Work on a copy so that a failed update leaves the stored alias intact
+	updated := *alias
+	updated.LastUpdateTime = time.Now()
+
+	return i.handleAliasUpdateCommon(&updated, entity, d)
+}
+
+// handleAliasUpdateCommon applies the supplied fields to the alias and
+// persists it with the entity it belongs to. If the alias already exists,
+// previousEntity is the entity currently holding it. The caller must hold
+// the lock.
+func (i *IdentityStore) handleAliasUpdateCommon(alias *EntityAlias, previousEntity *Entity, d *framework.FieldData) (*logical.Response, error) {
+	previousMountPath, previousName := alias.MountPath, alias.Name
+
+	if nameRaw, ok := d.GetOk("name"); ok {
+		alias.Name = nameRaw.(string)
+	}
+	if alias.Name == "" {
+		return logical.ErrorResponse("missing name"), nil
+	}
+
+	if mountPathRaw, ok := d.GetOk("mount_path"); ok {
+		mountPath, err := i.parseMountPath(mountPathRaw.(string))
+		if err != nil {
+			return logical.ErrorResponse(err.Error()), nil
+		}
+		alias.MountPath = mountPath
+	}
+	if alias.MountPath == "" {
+		return logical.ErrorResponse("missing mount_path"), nil
+	}
+
+	if entityIDRaw, ok := d.GetOk("entity_id"); ok {
+		alias.EntityID = entityIDRaw.(string)
+	}
+	if alias.EntityID == "" {
+		return logical.ErrorResponse("missing entity_id"), nil
+	}
+
+	if metadataRaw, ok := d.GetOk("metadata"); ok {
+		metadata := make(map[string]string)
+		if err := strutil.ParseArbitraryKeyValues(metadataRaw.(string), metadata, ","); err != nil {
+			return logical.ErrorResponse(fmt.Sprintf("failed to parse metadata: %v", err)), nil
+		}
+		alias.Metadata = metadata
+	}
+
+	// An identity within a credential backend can only belong to one entity
+	index, err := i.aliasIndex(alias.MountPath, alias.Name)
+	if err != nil {
+		return nil, err
+	}
+	if index != nil && index.AliasID != alias.ID {
+		return logical.ErrorResponse(fmt.Sprintf(
+			"alias %q of mount path %q already exists", alias.Name, alias.MountPath)), nil
+	}
+
+	entity, err := i.entityByID(alias.EntityID)
+	if err != nil {
+		return nil, err
+	}
+	if entity == nil {
+		return logical.ErrorResponse("entity not found"), nil
+	}
+
+	// Detach the alias from the entity previously holding it
+	if previousEntity != nil {
+		if err := i.view.Delete(i.aliasLookupKey(previousMountPath, previousName)); err != nil {
+			return nil, fmt.Errorf("failed to delete alias index: %v", err)
+		}
+
+		removeAlias(previousEntity, alias.ID)
+		if previousEntity.ID == entity.ID {
+			entity = previousEntity
+		} else {
+			previousEntity.LastUpdateTime = time.Now()
+			if err := i.persistEntity(previousEntity, ""); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	entity.Aliases = append(entity.Aliases, alias)
+	entity.LastUpdateTime = time.Now()
+	if err := i.persistEntity(entity, ""); err != nil {
+		return nil, err
+	}
+	if err := i.persistAliasIndexes(alias); err != nil {
+		return nil, err
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"id":        alias.ID,
+			"entity_id": alias.EntityID,
+		},
+	}, nil
+}
+
+// pathAliasIDRead returns the properties of an alias
+func (i *IdentityStore) pathAliasIDRead(req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	i.lock.RLock()
+	defer i.lock.RUnlock()
+
+	alias, _, err := i.aliasByID(d.Get("id").(string))
+	if err != nil {
+		return nil, err
+	}
+	if alias == nil {
+		return nil, nil
+	}
+
+	return &logical.Response{
+		Data: aliasResponseData(alias),
+	}, nil
+}
+
+// pathAliasIDDelete deletes an alias. The entity it belonged to is kept.
+func (i *IdentityStore) pathAliasIDDelete(req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+
+	alias, entity, err := i.aliasByID(d.Get("id").(string))
+	if err != nil {
+		return nil, err
+	}
+	if alias == nil {
+		return nil, nil
+	}
+
+	removeAlias(entity, alias.ID)
+	entity.LastUpdateTime = time.Now()
+	if err := i.persistEntity(entity, ""); err != nil {
+		return nil, err
+	}
+
+	return nil, i.deleteAliasIndexes(alias)
+}
+
+// pathAliasIDList lists the IDs of all the aliases
+func (i *IdentityStore) pathAliasIDList(req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	i.lock.RLock()
+	defer i.lock.RUnlock()
+
+	aliasIDs, err := i.view.List(aliasIDPrefix)
+	if err != nil {
+		return nil, err
+	}
+	return logical.ListResponse(aliasIDs), nil
+}
+
+// removeAlias removes the alias with the given ID from the entity
+func removeAlias(entity *Entity, aliasID string) {
+	aliases := make([]*EntityAlias, 0, len(entity.Aliases))
+	for _, alias := range entity.Aliases {
+		if alias.ID != aliasID {
+			aliases = append(aliases, alias)
+		}
+	}
+	entity.Aliases = aliases
+}
+
+// aliasResponseData converts an alias into response data
+func aliasResponseData(alias *EntityAlias) map[string]interface{} {
+	return map[string]interface{}{
+		"id":               alias.ID,
+		"entity_id":        alias.EntityID,
+		"mount_path":       alias.MountPath,
+		"name":             alias.Name,
+		"metadata":         alias.Metadata,
+		"creation_time":    alias.CreationTime,
+		"last_update_time": alias.LastUpdateTime,
+	}
+}
+
+var aliasHelp = map[string][2]string{
+	"entity-alias": {
+		"Create a new alias for an entity",
+		`
+An alias maps the identity of a client within a credential backend, e.g. a
+GitHub username, to an entity. Logins via the credential backend using that
+identity then produce tokens associated with the entity. An identity within a
+credential backend can only be mapped to a single entity.`,
+	},
+	"entity-alias-id-list": {
+		"List the IDs of all the aliases",
+		"",
+	},
+	"entity-alias-id": {
+		"Read, update or delete an alias using its ID",
+		`
+Updating the 'entity_id' of an alias moves it to a different entity, which
+can be used to merge the identities of a client that were automatically
+created by logins via different credential backends.`,
+	},
+}
@@ -0,0 +1,298 @@
+package vault
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/vault/helper/policyutil"
+	"github.com/hashicorp/vault/helper/strutil"
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+)
+
+// entityPaths returns the API endpoints supported to operate on entities.
+//
+// Paths returned:
+// entity - To create entities
+// entity/id/ - To list entities
+// entity/id/<id> - To read, update or delete an entity using its ID
+// entity/name/<name> - To read an entity using its name
+func entityPaths(i *IdentityStore) []*framework.Path {
+	return []*framework.Path{
+		&framework.Path{
+			Pattern: "entity$",
+			Fields: map[string]*framework.FieldSchema{
+				"name": &framework.FieldSchema{
+					Type:        framework.TypeString,
+					Description: "Name of the entity. Defaults to a generated name.",
+				},
+				"policies": &framework.FieldSchema{
+					Type:        framework.TypeString,
+					Description: "Comma separated list of policies to be attached to the entity.",
+				},
+				"metadata": &framework.FieldSchema{
+					Type: framework.TypeString,
+					Description: `Metadata to be associated with the entity. This can be a JSON
+object or a comma separated list of key=value pairs.`,
+				},
+			},
+			Callbacks: map[logical.Operation]framework.OperationFunc{
+				logical.UpdateOperation: i.pathEntityCreate,
+			},
+
+			HelpSynopsis:    strings.TrimSpace(entityHelp["entity"][0]),
+			HelpDescription: strings.TrimSpace(entityHelp["entity"][1]),
+		},
+		&framework.Path{
+			Pattern: "entity/id/?$",
+			Callbacks: map[logical.Operation]framework.OperationFunc{
+				logical.ListOperation: i.pathEntityIDList,
+			},
+
+			HelpSynopsis:    strings.TrimSpace(entityHelp["entity-id-list"][0]),
+			HelpDescription: strings.TrimSpace(entityHelp["entity-id-list"][1]),
+		},
+		&framework.Path{
+			Pattern: "entity/id/" + framework.GenericNameRegex("id"),
+			Fields: map[string]*framework.FieldSchema{
+				"id": &framework.FieldSchema{
+					Type:        framework.TypeString,
+					Description: "ID of the entity.",
+				},
+				"name": &framework.FieldSchema{
+					Type:        framework.TypeString,
+					Description: "Name of the entity.",
+				},
+				"policies": &framework.FieldSchema{
+					Type:        framework.TypeString,
+					Description: "Comma separated list of policies to be attached to the entity.",
+				},
+				"metadata": &framework.FieldSchema{
+					Type: framework.TypeString,
+					Description: `Metadata to be associated with the entity. This can be a JSON
+object or a comma separated list of key=value pairs.`,
+				},
+			},
+			Callbacks: map[logical.Operation]framework.OperationFunc{
+				logical.ReadOperation:   i.pathEntityIDRead,
+				logical.UpdateOperation: i.pathEntityIDUpdate,
+				logical.DeleteOperation: i.pathEntityIDDelete,
+			},
+
+			HelpSynopsis:    strings.TrimSpace(entityHelp["entity-id"][0]),
+			HelpDescription: strings.TrimSpace(entityHelp["entity-id"][1]),
+		},
+		&framework.Path{
+			Pattern: "entity/name/" + framework.GenericNameRegex("name"),
+			Fields: map[string]*framework.FieldSchema{
+				"name": &framework.FieldSchema{
+					Type:        framework.TypeString,
+					Description: "Name of the entity.",
+				},
+			},
+			Callbacks: map[logical.Operation]framework.OperationFunc{
+				logical.ReadOperation: i.pathEntityNameRead,
+			},
+
+			HelpSynopsis:    strings.TrimSpace(entityHelp["entity-name"][0]),
+			HelpDescription: strings.TrimSpace(entityHelp["entity-name"][1]),
+		},
+	}
+}
+
+// pathEntityCreate creates a new entity
+func (i *IdentityStore) pathEntityCreate(req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+
+	entity, err := newEntity(d.Get("name").(string))
+	if err != nil {
+		return nil, err
+	}
+
+	if resp, err := i.updateEntityFromData(entity, d); resp != nil || err != nil {
+		return resp, err
+	}
+
+	if err := i.persistEntity(entity, ""); err != nil {
+		return nil, err
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"id":   entity.ID,
+			"name": entity.Name,
+		},
+	}, nil
+}
+
+// pathEntityIDUpdate updates the name, policies or metadata of an entity
+func (i *IdentityStore) pathEntityIDUpdate(req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+
+	entity, err := i.entityByID(d.Get("id").(string))
+	if err != nil {
+		return nil, err
+	}
+	if entity == nil {
+		return logical.ErrorResponse("entity not found"), nil
+	}
+
+	previousName := entity.Name
+	if resp, err := i.updateEntityFromData(entity, d); resp != nil || err != nil {
+		return resp, err
+	}
+	entity.LastUpdateTime = time.Now()
+
+	if err := i.persistEntity(entity, previousName); err != nil {
+		return nil, err
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"id":   entity.ID,
+			"name": entity.Name,
+		},
+	}, nil
+}
+
+// updateEntityFromData applies the supplied fields to the entity. A non-nil
+// response indicates invalid input. The caller must hold the lock.
+func (i *IdentityStore) updateEntityFromData(entity *Entity, d *framework.FieldData) (*logical.Response, error) {
+	if nameRaw, ok := d.GetOk("name"); ok && nameRaw.(string) != "" {
+		entity.Name = nameRaw.(string)
+	}
+
+	// Entity names must be unique
+	existing, err := i.entityByName(entity.Name)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil && existing.ID != entity.ID {
+		return logical.ErrorResponse(fmt.Sprintf("entity name %q is already in use", entity.Name)), nil
+	}
+
+	if policiesRaw, ok := d.GetOk("policies"); ok {
+		entity.Policies = policyutil.SanitizePolicies(strings.Split(policiesRaw.(string), ","), false)
+		if strutil.StrListContains(entity.Policies, "root") {
+			return logical.ErrorResponse("the root policy cannot be attached to an entity"), nil
+		}
+	}
+
+	if metadataRaw, ok := d.GetOk("metadata"); ok {
+		metadata := make(map[string]string)
+		if err := strutil.ParseArbitraryKeyValues(metadataRaw.(string), metadata, ","); err != nil {
+			return logical.ErrorResponse(fmt.Sprintf("failed to parse metadata: %v", err)), nil
+		}
+		entity.Metadata = metadata
+	}
+
+	return nil, nil
+}
+
+// pathEntityIDRead returns the properties of an entity
+func (i *IdentityStore) pathEntityIDRead(req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	i.lock.RLock()
+	defer i.lock.RUnlock()
+
+	entity, err := i.entityByID(d.Get("id").(string))
+	if err != nil {
+		return nil, err
+	}
+	if entity == nil {
+		return nil, nil
+	}
+
+	return entityResponse(entity), nil
+}
+
+// pathEntityNameRead returns the properties of an entity given its name
+func (i *IdentityStore) pathEntityNameRead(req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	i.lock.RLock()
+	defer i.lock.RUnlock()
+
+	entity, err := i.entityByName(d.Get("name").(string))
+	if err != nil {
+		return nil, err
+	}
+	if entity == nil {
+		return nil, nil
+	}
+
+	return entityResponse(entity), nil
+}
+
+// pathEntityIDDelete deletes an entity along with all of its aliases
+func (i *IdentityStore) pathEntityIDDelete(req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+
+	entity, err := i.entityByID(d.Get("id").(string))
+	if err != nil {
+		return nil, err
+	}
+	if entity == nil {
+		return nil, nil
+	}
+
+	return nil, i.deleteEntity(entity)
+}
+
+// pathEntityIDList lists the IDs of all the entities
+func (i *IdentityStore) pathEntityIDList(req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	i.lock.RLock()
+	defer i.lock.RUnlock()
+
+	entityIDs, err := i.view.List(entityIDPrefix)
+	if err != nil {
+		return nil, err
+	}
+	return logical.ListResponse(entityIDs), nil
+}
+
+// entityResponse converts an entity into a response
+func entityResponse(entity *Entity) *logical.Response {
+	aliases := make([]map[string]interface{}, 0, len(entity.Aliases))
+	for _, alias := range entity.Aliases {
+		aliases = append(aliases, aliasResponseData(alias))
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"id":               entity.ID,
+			"name":             entity.Name,
+			"policies":         entity.Policies,
+			"metadata":         entity.Metadata,
+			"aliases":          aliases,
+			"creation_time":    entity.CreationTime,
+			"last_update_time": entity.LastUpdateTime,
+		},
+	}
+}
+
+var entityHelp = map[string][2]string{
+	"entity": {
+		"Create a new entity",
+		`
+An entity represents a single client, such as a person or a machine, across
+all credential backends. The policies attached to an entity are granted to
+every token issued via one of its aliases, in addition to the token's own
+policies.`,
+	},
+	"entity-id-list": {
+		"List the IDs of all the entities",
+		"",
+	},
+	"entity-id": {
+		"Read, update or delete an entity using its ID",
+		`
+Deleting an entity also deletes all of its aliases. Tokens previously issued
+via one of its aliases no longer receive the entity's policies.`,
+	},
+	"entity-name": {
+		"Read an entity using its name",
+		"",
+	},
+}
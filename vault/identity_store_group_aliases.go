@@ -0,0 +1,376 @@
+package vault
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/go-uuid"
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+)
+
+// groupAliasPaths returns the API endpoints supported to operate on the
+// aliases of external groups.
+//
+// Paths returned:
+// group-alias - To create group aliases
+// group-alias/id/ - To list group aliases
+// group-alias/id/<id> - To read, update or delete a group alias using its ID
+func groupAliasPaths(i *IdentityStore) []*framework.Path {
+	groupAliasFields := map[string]*framework.FieldSchema{
+		"name": &framework.FieldSchema{
+			Type:        framework.TypeString,
+			Description: "Name of the group within the credential backend, e.g. an LDAP group or a GitHub team.",
+		},
+		"mount_path": &framework.FieldSchema{
+			Type:        framework.TypeString,
+			Description: "Mount path of the credential backend the alias belongs to, e.g. 'auth/ldap/'.",
+		},
+		"group_id": &framework.FieldSchema{
+			Type:        framework.TypeString,
+			Description: "ID of the external group the alias belongs to.",
+		},
+	}
+
+	groupAliasIDFields := map[string]*framework.FieldSchema{
+		"id": &framework.FieldSchema{
+			Type:        framework.TypeString,
+			Description: "ID of the group alias.",
+		},
+	}
+	for k, v := range groupAliasFields {
+		groupAliasIDFields[k] = v
+	}
+
+	return []*framework.Path{
+		&framework.Path{
+			Pattern: "group-alias$",
+			Fields:  groupAliasFields,
+			Callbacks: map[logical.Operation]framework.OperationFunc{
+				logical.UpdateOperation: i.pathGroupAliasCreate,
+			},
+
+			HelpSynopsis:    strings.TrimSpace(groupAliasHelp["group-alias"][0]),
+			HelpDescription: strings.TrimSpace(groupAliasHelp["group-alias"][1]),
+		},
+		&framework.Path{
+			Pattern: "group-alias/id/?$",
+			Callbacks: map[logical.Operation]framework.OperationFunc{
+				logical.ListOperation: i.pathGroupAliasIDList,
+			},
+
+			HelpSynopsis:    strings.TrimSpace(groupAliasHelp["group-alias-id-list"][0]),
+			HelpDescription: strings.TrimSpace(groupAliasHelp["group-alias-id-list"][1]),
+		},
+		&framework.Path{
+			Pattern: "group-alias/id/" + framework.GenericNameRegex("id"),
+			Fields:  groupAliasIDFields,
+			Callbacks: map[logical.Operation]framework.OperationFunc{
+				logical.ReadOperation:   i.pathGroupAliasIDRead,
+				logical.UpdateOperation: i.pathGroupAliasIDUpdate,
+				logical.DeleteOperation: i.pathGroupAliasIDDelete,
+			},
+
+			HelpSynopsis:    strings.TrimSpace(groupAliasHelp["group-alias-id"][0]),
+			HelpDescription: strings.TrimSpace(groupAliasHelp["group-alias-id"][1]),
+		},
+	}
+}
+
+// groupAliasLookupKey returns the storage key of the index from mount path
+// and group alias name to the group
+func (i *IdentityStore) groupAliasLookupKey(mountPath, name string) string {
+	return groupAliasLookupPrefix + i.salt.SaltID(mountPath+name)
+}
+
+// groupAliasByID fetches the group alias with the given ID along with the
+// group holding it. Both are nil if the alias does not exist. The caller
+// must hold the lock.
+func (i *IdentityStore) groupAliasByID(id string) (*GroupAlias, *Group, error) {
+	if id == "" {
+		return nil, nil, nil
+	}
+
+	raw, err := i.view.Get(groupAliasIDPrefix + id)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read group alias index: %v", err)
+	}
+	if raw == nil {
+		return nil, nil, nil
+	}
+
+	group, err := i.groupByID(string(raw.Value))
+	if err != nil || group == nil {
+		return nil, nil, err
+	}
+	if group.Alias == nil || group.Alias.ID != id {
+		return nil, nil, nil
+	}
+	return group.Alias, group, nil
+}
+
+// persistGroupAliasIndexes writes the indexes pointing to the group holding
+// the given alias. The caller must hold the lock.
+func (i *IdentityStore) persistGroupAliasIndexes(alias *GroupAlias) error {
+	entries := []*logical.StorageEntry{
+		&logical.StorageEntry{
+			Key:   groupAliasIDPrefix + alias.ID,
+			Value: []byte(alias.GroupID),
+		},
+		&logical.StorageEntry{
+			Key:   i.groupAliasLookupKey(alias.MountPath, alias.Name),
+			Value: []byte(alias.GroupID),
+		},
+	}
+	for _, entry := range entries {
+		if err := i.view.Put(entry); err != nil {
+			return fmt.Errorf("failed to persist group alias index: %v", err)
+		}
+	}
+	return nil
+}
+
+// deleteGroupAliasIndexes removes the indexes pointing to the group holding
+// the given alias. The caller must hold the lock.
+func (i *IdentityStore) deleteGroupAliasIndexes(alias *GroupAlias) error {
+	if err := i.view.Delete(i.groupAliasLookupKey(alias.MountPath, alias.Name)); err != nil {
+		return fmt.Errorf("failed to delete group alias index: %v", err)
+	}
+	if err := i.view.Delete(groupAliasIDPrefix + alias.ID); err != nil {
+		return fmt.Errorf("failed to delete group alias index: %v", err)
+	}
+	return nil
+}
+
+// detachGroupAlias removes the alias from the group holding it. As the
+// member entities of the group were derived from the alias, they are
+// removed as well. The group is not persisted. The caller must hold the
+// lock.
+func (i *IdentityStore) detachGroupAlias(group *Group) error {
+	if err := i.deleteGroupAliasIndexes(group.Alias); err != nil {
+		return err
+	}
+	if err := i.setGroupMembers(group, nil, group.MemberGroupIDs); err != nil {
+		return err
+	}
+	group.Alias = nil
+	group.LastUpdateTime = time.Now()
+	return nil
+}
+
+// pathGroupAliasCreate creates a new alias for an external group
+func (i *IdentityStore) pathGroupAliasCreate(req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+
+	aliasID, err := uuid.GenerateUUID()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	alias := &GroupAlias{
+		ID:             aliasID,
+		CreationTime:   now,
+		LastUpdateTime: now,
+	}
+
+	return i.handleGroupAliasUpdateCommon(alias, nil, d)
+}
+
+// pathGroupAliasIDUpdate updates an existing group alias, possibly moving
+// it to a different group
+func (i *IdentityStore) pathGroupAliasIDUpdate(req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+
+	alias, group, err := i.groupAliasByID(d.Get("id").(string))
+	if err != nil {
+		return nil, err
+	}
+	if alias == nil {
+		return logical.ErrorResponse("group alias not found"), nil
+	}
+
+	// Work on a copy so that a failed update leaves the stored alias intact
+	updated := *alias
+	updated.LastUpdateTime = time.Now()
+
+	return i.handleGroupAliasUpdateCommon(&updated, group, d)
+}
+
+// handleGroupAliasUpdateCommon applies the supplied fields to the group
+// alias and persists it with the group it belongs to. If the alias already
+// exists, previousGroup is the group currently holding it. The caller must
+// hold the lock.
+func (i *IdentityStore) handleGroupAliasUpdateCommon(alias *GroupAlias, previousGroup *Group, d *framework.FieldData) (*logical.Response, error) {
+	if nameRaw, ok := d.GetOk("name"); ok {
+		alias.Name = nameRaw.(string)
+	}
+	if alias.Name == "" {
+		return logical.ErrorResponse("missing name"), nil
+	}
+
+	if mountPathRaw, ok := d.GetOk("mount_path"); ok {
+		mountPath, err := i.parseMountPath(mountPathRaw.(string))
+		if err != nil {
+			return logical.ErrorResponse(err.Error()), nil
+		}
+		alias.MountPath = mountPath
+	}
+	if alias.MountPath == "" {
+		return logical.ErrorResponse("missing mount_path"), nil
+	}
+
+	if groupIDRaw, ok := d.GetOk("group_id"); ok {
+		alias.GroupID = groupIDRaw.(string)
+	}
+	if alias.GroupID == "" {
+		return logical.ErrorResponse("missing group_id"), nil
+	}
+
+	// A group within a credential backend can only be mapped to one group
+	raw, err := i.view.Get(i.groupAliasLookupKey(alias.MountPath, alias.Name))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read group alias index: %v", err)
+	}
+	if raw != nil && (previousGroup == nil || string(raw.Value) != previousGroup.ID ||
+		alias.MountPath != previousGroup.Alias.MountPath || alias.Name != previousGroup.Alias.Name) {
+		return logical.ErrorResponse(fmt.Sprintf(
+			"group alias %q of mount path %q already exists", alias.Name, alias.MountPath)), nil
+	}
+
+	group, err := i.groupByID(alias.GroupID)
+	if err != nil {
+		return nil, err
+	}
+	if group == nil {
+		return logical.ErrorResponse("group not found"), nil
+	}
+	if group.Type != groupTypeExternal {
+		return logical.ErrorResponse("aliases can only be attached to external groups"), nil
+	}
+	if group.Alias != nil && group.Alias.ID != alias.ID {
+		return logical.ErrorResponse("group already has an alias"), nil
+	}
+
+	if previousGroup != nil {
+		// The memberships derived from the alias remain valid as long as it
+		// keeps referring to the same group within the credential backend
+		unchanged := previousGroup.ID == group.ID &&
+			previousGroup.Alias.MountPath == alias.MountPath &&
+			previousGroup.Alias.Name == alias.Name
+		if !unchanged {
+			if err := i.detachGroupAlias(previousGroup); err != nil {
+				return nil, err
+			}
+			if previousGroup.ID == group.ID {
+				group = previousGroup
+			} else if err := i.persistGroup(previousGroup, ""); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	group.Alias = alias
+	group.LastUpdateTime = time.Now()
+	if err := i.persistGroup(group, ""); err != nil {
+		return nil, err
+	}
+	if err := i.persistGroupAliasIndexes(alias); err != nil {
+		return nil, err
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"id":       alias.ID,
+			"group_id": alias.GroupID,
+		},
+	}, nil
+}
+
+// pathGroupAliasIDRead returns the properties of a group alias
+func (i *IdentityStore) pathGroupAliasIDRead(req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	i.lock.RLock()
+	defer i.lock.RUnlock()
+
+	alias, _, err := i.groupAliasByID(d.Get("id").(string))
+	if err != nil {
+		return nil, err
+	}
+	if alias == nil {
+		return nil, nil
+	}
+
+	return &logical.Response{
+		Data: groupAliasResponseData(alias),
+	}, nil
+}
+
+// pathGroupAliasIDDelete deletes a group alias. The group it belonged to is
+// kept, but loses its member entities.
+func (i *IdentityStore) pathGroupAliasIDDelete(req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+
+	alias, group, err := i.groupAliasByID(d.Get("id").(string))
+	if err != nil {
+		return nil, err
+	}
+	if alias == nil {
+		return nil, nil
+	}
+
+	if err := i.detachGroupAlias(group); err != nil {
+		return nil, err
+	}
+	return nil, i.persistGroup(group, "")
+}
+
+// pathGroupAliasIDList lists the IDs of all the group aliases
+func (i *IdentityStore) pathGroupAliasIDList(req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	i.lock.RLock()
+	defer i.lock.RUnlock()
+
+	aliasIDs, err := i.view.List(groupAliasIDPrefix)
+	if err != nil {
+		return nil, err
+	}
+	return logical.ListResponse(aliasIDs), nil
+}
+
+// groupAliasResponseData converts a group alias into response data
+func groupAliasResponseData(alias *GroupAlias) map[string]interface{} {
+	return map[string]interface{}{
+		"id":               alias.ID,
+		"group_id":         alias.GroupID,
+		"mount_path":       alias.MountPath,
+		"name":             alias.Name,
+		"creation_time":    alias.CreationTime,
+		"last_update_time": alias.LastUpdateTime,
+	}
+}
+
+var groupAliasHelp = map[string][2]string{
+	"group-alias": {
+		"Create a new alias for an external group",
+		`
+A group alias maps a group within a credential backend, such as an LDAP group
+or a GitHub team, to an external group. Whenever a client logs in via the
+credential backend, the client's entity is added to or removed from the
+external group based on whether the backend reports the client as a member of
+the aliased group. Each external group can have a single alias.`,
+	},
+	"group-alias-id-list": {
+		"List the IDs of all the group aliases",
+		"",
+	},
+	"group-alias-id": {
+		"Read, update or delete a group alias using its ID",
+		`
+Changing the name or mount path of a group alias, moving it to a different
+group or deleting it removes all the member entities of the group previously
+holding it, as those were derived from the alias.`,
+	},
+}
@@ -0,0 +1,718 @@
+package vault
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/go-uuid"
+	"github.com/hashicorp/vault/helper/jsonutil"
+	"github.com/hashicorp/vault/helper/policyutil"
+	"github.com/hashicorp/vault/helper/strutil"
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+)
+
+// groupPaths returns the API endpoints supported to operate on groups.
+//
+// Paths returned:
+// group - To create groups
+// group/id/ - To list groups
+// group/id/<id> - To read, update or delete a group using its ID
+// group/name/<name> - To read a group using its name
+func groupPaths(i *IdentityStore) []*framework.Path {
+	groupFields := map[string]*framework.FieldSchema{
+		"name": &framework.FieldSchema{
+			Type:        framework.TypeString,
+			Description: "Name of the group. Defaults to a generated name.",
+		},
+		"type": &framework.FieldSchema{
+			Type: framework.TypeString,
+			Description: `Type of the group, 'internal' or 'external'. The member
+entities of external groups are managed by a credential backend via the
+group's alias. Defaults to 'internal'.`,
+		},
+		"policies": &framework.FieldSchema{
+			Type:        framework.TypeString,
+			Description: "Comma separated list of policies to be attached to the group.",
+		},
+		"metadata": &framework.FieldSchema{
+			Type: framework.TypeString,
+			Description: `Metadata to be associated with the group. This can be a JSON
+object or a comma separated list of key=value pairs.`,
+		},
+		"member_entity_ids": &framework.FieldSchema{
+			Type:        framework.TypeString,
+			Description: "Comma separated list of the IDs of the entities that are members of the group.",
+		},
+		"member_group_ids": &framework.FieldSchema{
+			Type:        framework.TypeString,
+			Description: "Comma separated list of the IDs of the groups that are members of the group.",
+		},
+	}
+
+	groupIDFields := map[string]*framework.FieldSchema{
+		"id": &framework.FieldSchema{
+			Type:        framework.TypeString,
+			Description: "ID of the group.",
+		},
+	}
+	for k, v := range groupFields {
+		groupIDFields[k] = v
+	}
+
+	return []*framework.Path{
+		&framework.Path{
+			Pattern: "group$",
+			Fields:  groupFields,
+			Callbacks: map[logical.Operation]framework.OperationFunc{
+				logical.UpdateOperation: i.pathGroupCreate,
+			},
+
+			HelpSynopsis:    strings.TrimSpace(groupHelp["group"][0]),
+			HelpDescription: strings.TrimSpace(groupHelp["group"][1]),
+		},
+		&framework.Path{
+			Pattern: "group/id/?$",
+			Callbacks: map[logical.Operation]framework.OperationFunc{
+				logical.ListOperation: i.pathGroupIDList,
+			},
+
+			HelpSynopsis:    strings.TrimSpace(groupHelp["group-id-list"][0]),
+			HelpDescription: strings.TrimSpace(groupHelp["group-id-list"][1]),
+		},
+		&framework.Path{
+			Pattern: "group/id/" + framework.GenericNameRegex("id"),
+			Fields:  groupIDFields,
+			Callbacks: map[logical.Operation]framework.OperationFunc{
+				logical.ReadOperation:   i.pathGroupIDRead,
+				logical.UpdateOperation: i.pathGroupIDUpdate,
+				logical.DeleteOperation: i.pathGroupIDDelete,
+			},
+
+			HelpSynopsis:    strings.TrimSpace(groupHelp["group-id"][0]),
+			HelpDescription: strings.TrimSpace(groupHelp["group-id"][1]),
+		},
+		&framework.Path{
+			Pattern: "group/name/" + framework.GenericNameRegex("name"),
+			Fields: map[string]*framework.FieldSchema{
+				"name": &framework.FieldSchema{
+					Type:        framework.TypeString,
+					Description: "Name of the group.",
+				},
+			},
+			Callbacks: map[logical.Operation]framework.OperationFunc{
+				logical.ReadOperation: i.pathGroupNameRead,
+			},
+
+			HelpSynopsis:    strings.TrimSpace(groupHelp["group-name"][0]),
+			HelpDescription: strings.TrimSpace(groupHelp["group-name"][1]),
+		},
+	}
+}
+
+// pathGroupCreate creates a new group
+func (i *IdentityStore) pathGroupCreate(req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+
+	groupID, err := uuid.GenerateUUID()
+	if err != nil {
+		return nil, err
+	}
+
+	name := d.Get("name").(string)
+	if name == "" {
+		name = "group_" + groupID[:8]
+	}
+
+	groupType := d.Get("type").(string)
+	switch groupType {
+	case "":
+		groupType = groupTypeInternal
+	case groupTypeInternal, groupTypeExternal:
+	default:
+		return logical.ErrorResponse(fmt.Sprintf("invalid group type %q", groupType)), nil
+	}
+
+	now := time.Now()
+	group := &Group{
+		ID:             groupID,
+		Name:           name,
+		Type:           groupType,
+		CreationTime:   now,
+		LastUpdateTime: now,
+	}
+
+	return i.handleGroupUpdateCommon(group, "", d)
+}
+
+// pathGroupIDUpdate updates the properties and members of a group
+func (i *IdentityStore) pathGroupIDUpdate(req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+
+	group, err := i.groupByID(d.Get("id").(string))
+	if err != nil {
+		return nil, err
+	}
+	if group == nil {
+		return logical.ErrorResponse("group not found"), nil
+	}
+
+	if groupTypeRaw, ok := d.GetOk("type"); ok && groupTypeRaw.(string) != group.Type {
+		return logical.ErrorResponse("the type of a group cannot be changed"), nil
+	}
+	group.LastUpdateTime = time.Now()
+
+	return i.handleGroupUpdateCommon(group, group.Name, d)
+}
+
+// handleGroupUpdateCommon applies the supplied fields to the group and
+// persists it along with its membership indexes. The caller must hold the
+// lock.
+func (i *IdentityStore) handleGroupUpdateCommon(group *Group, previousName string, d *framework.FieldData) (*logical.Response, error) {
+	if nameRaw, ok := d.GetOk("name"); ok && nameRaw.(string) != "" {
+		group.Name = nameRaw.(string)
+	}
+
+	// Group names must be unique
+	existing, err := i.groupByName(group.Name)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil && existing.ID != group.ID {
+		return logical.ErrorResponse(fmt.Sprintf("group name %q is already in use", group.Name)), nil
+	}
+
+	if policiesRaw, ok := d.GetOk("policies"); ok {
+		group.Policies = policyutil.SanitizePolicies(strings.Split(policiesRaw.(string), ","), false)
+		if strutil.StrListContains(group.Policies, "root") {
+			return logical.ErrorResponse("the root policy cannot be attached to a group"), nil
+		}
+	}
+
+	if metadataRaw, ok := d.GetOk("metadata"); ok {
+		metadata := make(map[string]string)
+		if err := strutil.ParseArbitraryKeyValues(metadataRaw.(string), metadata, ","); err != nil {
+			return logical.ErrorResponse(fmt.Sprintf("failed to parse metadata: %v", err)), nil
+		}
+		group.Metadata = metadata
+	}
+
+	memberEntityIDs := group.MemberEntityIDs
+	if memberEntityIDsRaw, ok := d.GetOk("member_entity_ids"); ok {
+		if group.Type == groupTypeExternal {
+			return logical.ErrorResponse("the member entities of external groups are managed via the group's alias"), nil
+		}
+
+		memberEntityIDs = strutil.ParseDedupAndSortStrings(memberEntityIDsRaw.(string), ",")
+		for _, entityID := range memberEntityIDs {
+			entity, err := i.entityByID(entityID)
+			if err != nil {
+				return nil, err
+			}
+			if entity == nil {
+				return logical.ErrorResponse(fmt.Sprintf("entity %q not found", entityID)), nil
+			}
+		}
+	}
+
+	memberGroupIDs := group.MemberGroupIDs
+	if memberGroupIDsRaw, ok := d.GetOk("member_group_ids"); ok {
+		memberGroupIDs = strutil.ParseDedupAndSortStrings(memberGroupIDsRaw.(string), ",")
+
+		// A group cannot be a member of itself, directly or via other groups
+		ancestors, err := i.ancestorGroupIDs([]string{group.ID})
+		if err != nil {
+			return nil, err
+		}
+		for _, memberGroupID := range memberGroupIDs {
+			if _, ok := ancestors[memberGroupID]; ok {
+				return logical.ErrorResponse(fmt.Sprintf(
+					"group %q cannot be a member of group %q as it would create a cycle", memberGroupID, group.ID)), nil
+			}
+
+			memberGroup, err := i.groupByID(memberGroupID)
+			if err != nil {
+				return nil, err
+			}
+			if memberGroup == nil {
+				return logical.ErrorResponse(fmt.Sprintf("group %q not found", memberGroupID)), nil
+			}
+		}
+	}
+
+	if err := i.setGroupMembers(group, memberEntityIDs, memberGroupIDs); err != nil {
+		return nil, err
+	}
+	if err := i.persistGroup(group, previousName); err != nil {
+		return nil, err
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"id":   group.ID,
+			"name": group.Name,
+		},
+	}, nil
+}
+
+// pathGroupIDRead returns the properties of a group
+func (i *IdentityStore) pathGroupIDRead(req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	i.lock.RLock()
+	defer i.lock.RUnlock()
+
+	group, err := i.groupByID(d.Get("id").(string))
+	if err != nil {
+		return nil, err
+	}
+	if group == nil {
+		return nil, nil
+	}
+
+	return groupResponse(group), nil
+}
+
+// pathGroupNameRead returns the properties of a group given its name
+func (i *IdentityStore) pathGroupNameRead(req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	i.lock.RLock()
+	defer i.lock.RUnlock()
+
+	group, err := i.groupByName(d.Get("name").(string))
+	if err != nil {
+		return nil, err
+	}
+	if group == nil {
+		return nil, nil
+	}
+
+	return groupResponse(group), nil
+}
+
+// pathGroupIDDelete deletes a group along with its alias. The members of
+// the group are kept.
+func (i *IdentityStore) pathGroupIDDelete(req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+
+	group, err := i.groupByID(d.Get("id").(string))
+	if err != nil {
+		return nil, err
+	}
+	if group == nil {
+		return nil, nil
+	}
+
+	return nil, i.deleteGroup(group)
+}
+
+// pathGroupIDList lists the IDs of all the groups
+func (i *IdentityStore) pathGroupIDList(req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	i.lock.RLock()
+	defer i.lock.RUnlock()
+
+	groupIDs, err := i.view.List(groupIDPrefix)
+	if err != nil {
+		return nil, err
+	}
+	return logical.ListResponse(groupIDs), nil
+}
+
+// groupResponse converts a group into a response
+func groupResponse(group *Group) *logical.Response {
+	var alias map[string]interface{}
+	if group.Alias != nil {
+		alias = groupAliasResponseData(group.Alias)
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"id":                group.ID,
+			"name":              group.Name,
+			"type":              group.Type,
+			"policies":          group.Policies,
+			"metadata":          group.Metadata,
+			"member_entity_ids": group.MemberEntityIDs,
+			"member_group_ids":  group.MemberGroupIDs,
+			"alias":             alias,
+			"creation_time":     group.CreationTime,
+			"last_update_time":  group.LastUpdateTime,
+		},
+	}
+}
+
+// groupByID fetches the group with the given ID, or nil if it does not
+// exist. The caller must hold the lock.
+func (i *IdentityStore) groupByID(id string) (*Group, error) {
+	if id == "" {
+		return nil, nil
+	}
+
+	raw, err := i.view.Get(groupIDPrefix + id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read group: %v", err)
+	}
+	if raw == nil {
+		return nil, nil
+	}
+
+	group := new(Group)
+	if err := jsonutil.DecodeJSON(raw.Value, group); err != nil {
+		return nil, fmt.Errorf("failed to decode group: %v", err)
+	}
+	return group, nil
+}
+
+// groupByName fetches the group with the given name, or nil if it does not
+// exist. The caller must hold the lock.
+func (i *IdentityStore) groupByName(name string) (*Group, error) {
+	raw, err := i.view.Get(groupNamePrefix + strings.ToLower(name))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read group name index: %v", err)
+	}
+	if raw == nil {
+		return nil, nil
+	}
+
+	return i.groupByID(string(raw.Value))
+}
+
+// persistGroup writes the group and its name index. If the name of the
+// group changed, the index for the previous name is removed. The caller
+// must hold the lock.
+func (i *IdentityStore) persistGroup(group *Group, previousName string) error {
+	entry, err := logical.StorageEntryJSON(groupIDPrefix+group.ID, group)
+	if err != nil {
+		return err
+	}
+	if err := i.view.Put(entry); err != nil {
+		return fmt.Errorf("failed to persist group: %v", err)
+	}
+
+	if previousName != "" && !strings.EqualFold(previousName, group.Name) {
+		if err := i.view.Delete(groupNamePrefix + strings.ToLower(previousName)); err != nil {
+			return fmt.Errorf("failed to delete group name index: %v", err)
+		}
+	}
+
+	nameEntry := &logical.StorageEntry{
+		Key:   groupNamePrefix + strings.ToLower(group.Name),
+		Value: []byte(group.ID),
+	}
+	if err := i.view.Put(nameEntry); err != nil {
+		return fmt.Errorf("failed to persist group name index: %v", err)
+	}
+
+	return nil
+}
+
+// setGroupMembers replaces the members of the group, updating the
+// membership indexes accordingly. The group itself is not persisted. The
+// caller must hold the lock.
+func (i *IdentityStore) setGroupMembers(group *Group, entityIDs, groupIDs []string) error {
+	for _, entityID := range group.MemberEntityIDs {
+		if !strutil.StrListContains(entityIDs, entityID) {
+			if err := i.view.Delete(groupEntityMemberPrefix + entityID + "/" + group.ID); err != nil {
+				return fmt.Errorf("failed to delete group membership index: %v", err)
+			}
+		}
+	}
+	for _, groupID := range group.MemberGroupIDs {
+		if !strutil.StrListContains(groupIDs, groupID) {
+			if err := i.view.Delete(groupGroupMemberPrefix + groupID + "/" + group.ID); err != nil {
+				return fmt.Errorf("failed to delete group membership index: %v", err)
+			}
+		}
+	}
+
+	for _, entityID := range entityIDs {
+		entry := &logical.StorageEntry{
+			Key:   groupEntityMemberPrefix + entityID + "/" + group.ID,
+			Value: []byte(group.ID),
+		}
+		if err := i.view.Put(entry); err != nil {
+			return fmt.Errorf("failed to persist group membership index: %v", err)
+		}
+	}
+	for _, groupID := range groupIDs {
+		entry := &logical.StorageEntry{
+			Key:   groupGroupMemberPrefix + groupID + "/" + group.ID,
+			Value: []byte(group.ID),
+		}
+		if err := i.view.Put(entry); err != nil {
+			return fmt.Errorf("failed to persist group membership index: %v", err)
+		}
+	}
+
+	group.MemberEntityIDs = entityIDs
+	group.MemberGroupIDs = groupIDs
+	return nil
+}
+
+// deleteGroup removes the group, its alias and its memberships along with
+// all of their indexes. The caller must hold the lock.
+func (i *IdentityStore) deleteGroup(group *Group) error {
+	if err := i.setGroupMembers(group, nil, nil); err != nil {
+		return err
+	}
+
+	// Remove the group from the groups it is a member of
+	parentGroupIDs, err := i.view.List(groupGroupMemberPrefix + group.ID + "/")
+	if err != nil {
+		return fmt.Errorf("failed to list group memberships: %v", err)
+	}
+	for _, parentGroupID := range parentGroupIDs {
+		parent, err := i.groupByID(parentGroupID)
+		if err != nil {
+			return err
+		}
+		if parent == nil {
+			continue
+		}
+
+		memberGroupIDs := strutil.StrListDelete(parent.MemberGroupIDs, group.ID)
+		if err := i.setGroupMembers(parent, parent.MemberEntityIDs, memberGroupIDs); err != nil {
+			return err
+		}
+		parent.LastUpdateTime = time.Now()
+		if err := i.persistGroup(parent, ""); err != nil {
+			return err
+		}
+	}
+
+	if group.Alias != nil {
+		if err := i.deleteGroupAliasIndexes(group.Alias); err != nil {
+			return err
+		}
+	}
+
+	if err := i.view.Delete(groupNamePrefix + strings.ToLower(group.Name)); err != nil {
+		return fmt.Errorf("failed to delete group name index: %v", err)
+	}
+	if err := i.view.Delete(groupIDPrefix + group.ID); err != nil {
+		return fmt.Errorf("failed to delete group: %v", err)
+	}
+	return nil
+}
+
+// removeEntityMemberships removes the entity from all the groups it is a
+// member of. The caller must hold the lock.
+func (i *IdentityStore) removeEntityMemberships(entityID string) error {
+	groupIDs, err := i.view.List(groupEntityMemberPrefix + entityID + "/")
+	if err != nil {
+		return fmt.Errorf("failed to list group memberships: %v", err)
+	}
+
+	for _, groupID := range groupIDs {
+		group, err := i.groupByID(groupID)
+		if err != nil {
+			return err
+		}
+		if group == nil {
+			continue
+		}
+
+		memberEntityIDs := strutil.StrListDelete(group.MemberEntityIDs, entityID)
+		if err := i.setGroupMembers(group, memberEntityIDs, group.MemberGroupIDs); err != nil {
+			return err
+		}
+		group.LastUpdateTime = time.Now()
+		if err := i.persistGroup(group, ""); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ancestorGroupIDs returns the IDs of the given groups along with those of
+// all the groups they are direct or indirect members of. The caller must
+// hold the lock.
+func (i *IdentityStore) ancestorGroupIDs(groupIDs []string) (map[string]struct{}, error) {
+	ancestors := make(map[string]struct{})
+	for len(groupIDs) > 0 {
+		groupID := groupIDs[0]
+		groupIDs = groupIDs[1:]
+		if _, ok := ancestors[groupID]; ok {
+			continue
+		}
+		ancestors[groupID] = struct{}{}
+
+		parentGroupIDs, err := i.view.List(groupGroupMemberPrefix + groupID + "/")
+		if err != nil {
+			return nil, fmt.Errorf("failed to list group memberships: %v", err)
+		}
+		groupIDs = append(groupIDs, parentGroupIDs...)
+	}
+	return ancestors, nil
+}
+
+// GroupPolicies returns the policies attached to the groups the entity
+// with the given ID is a direct or indirect member of
+func (i *IdentityStore) GroupPolicies(entityID string) ([]string, error) {
+	groups, err := i.entityGroups(entityID)
+	if err != nil {
+		return nil, err
+	}
+
+	var policies []string
+	for _, group := range groups {
+		policies = append(policies, group.Policies...)
+	}
+	return policies, nil
+}
+
+// GroupNames returns the names of the groups the entity with the given ID
+// is a direct or indirect member of
+func (i *IdentityStore) GroupNames(entityID string) ([]string, error) {
+	groups, err := i.entityGroups(entityID)
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, group := range groups {
+		names = append(names, group.Name)
+	}
+	return names, nil
+}
+
+// entityGroups returns the groups the entity with the given ID is a direct
+// or indirect member of
+func (i *IdentityStore) entityGroups(entityID string) ([]*Group, error) {
+	i.lock.RLock()
+	defer i.lock.RUnlock()
+
+	groupIDs, err := i.view.List(groupEntityMemberPrefix + entityID + "/")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list group memberships: %v", err)
+	}
+	if len(groupIDs) == 0 {
+		return nil, nil
+	}
+
+	ancestors, err := i.ancestorGroupIDs(groupIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	var groups []*Group
+	for groupID := range ancestors {
+		group, err := i.groupByID(groupID)
+		if err != nil {
+			return nil, err
+		}
+		if group != nil {
+			groups = append(groups, group)
+		}
+	}
+	return groups, nil
+}
+
+// UpdateExternalGroupMemberships makes the entity with the given ID a
+// member of exactly those external groups whose alias on the credential
+// backend mounted at mountPath is among the given group aliases
+func (i *IdentityStore) UpdateExternalGroupMemberships(entityID, mountPath string, groupAliases []*logical.Alias) error {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+
+	desired := make(map[string]struct{})
+	for _, groupAlias := range groupAliases {
+		if groupAlias == nil || groupAlias.Name == "" {
+			continue
+		}
+
+		raw, err := i.view.Get(i.groupAliasLookupKey(mountPath, groupAlias.Name))
+		if err != nil {
+			return fmt.Errorf("failed to read group alias index: %v", err)
+		}
+		if raw != nil {
+			desired[string(raw.Value)] = struct{}{}
+		}
+	}
+
+	current, err := i.view.List(groupEntityMemberPrefix + entityID + "/")
+	if err != nil {
+		return fmt.Errorf("failed to list group memberships: %v", err)
+	}
+
+	// Leave the external groups of this mount the entity no longer belongs to
+	for _, groupID := range current {
+		if _, ok := desired[groupID]; ok {
+			delete(desired, groupID)
+			continue
+		}
+
+		group, err := i.groupByID(groupID)
+		if err != nil {
+			return err
+		}
+		if group == nil || group.Alias == nil || group.Alias.MountPath != mountPath {
+			continue
+		}
+
+		memberEntityIDs := strutil.StrListDelete(group.MemberEntityIDs, entityID)
+		if err := i.setGroupMembers(group, memberEntityIDs, group.MemberGroupIDs); err != nil {
+			return err
+		}
+		group.LastUpdateTime = time.Now()
+		if err := i.persistGroup(group, ""); err != nil {
+			return err
+		}
+	}
+
+	// Join the external groups the entity is not yet a member of
+	for groupID := range desired {
+		group, err := i.groupByID(groupID)
+		if err != nil {
+			return err
+		}
+		if group == nil {
+			continue
+		}
+
+		memberEntityIDs := append(group.MemberEntityIDs, entityID)
+		if err := i.setGroupMembers(group, memberEntityIDs, group.MemberGroupIDs); err != nil {
+			return err
+		}
+		group.LastUpdateTime = time.Now()
+		if err := i.persistGroup(group, ""); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+var groupHelp = map[string][2]string{
+	"group": {
+		"Create a new group",
+		`
+A group aggregates entities and other groups. The policies attached to a
+group are granted to every token associated with one of its member entities,
+including the members of its member groups, in addition to the token's own
+policies.
+
+The members of internal groups are set via 'member_entity_ids' and
+'member_group_ids'. The member entities of external groups are instead
+managed by a credential backend: once the group is given an alias via the
+'group-alias' endpoint, logins via that backend add or remove the logged in
+entity from the group based on the groups reported by the backend, such as
+LDAP groups or GitHub teams.`,
+	},
+	"group-id-list": {
+		"List the IDs of all the groups",
+		"",
+	},
+	"group-id": {
+		"Read, update or delete a group using its ID",
+		`
+Deleting a group also deletes its alias and removes it from the groups it is a
+member of. The members of the group are not affected otherwise.`,
+	},
+	"group-name": {
+		"Read a group using its name",
+		"",
+	},
+}
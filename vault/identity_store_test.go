@@ -0,0 +1,530 @@
+package vault
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/hashicorp/vault/logical"
+)
+
+func TestIdentityStore_EntityCRUD(t *testing.T) {
+	c, _, root := TestCoreUnsealed(t)
+
+	req := logical.TestRequest(t, logical.UpdateOperation, "identity/entity")
+	req.ClientToken = root
+	req.Data = map[string]interface{}{
+		"name":     "armon",
+		"policies": "foo,bar",
+		"metadata": "team=core,location=remote",
+	}
+	resp, err := c.HandleRequest(req)
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("err: %v resp: %#v", err, resp)
+	}
+	entityID := resp.Data["id"].(string)
+	if entityID == "" || resp.Data["name"] != "armon" {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+
+	// Names must be unique
+	resp, err = c.HandleRequest(req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if resp == nil || !resp.IsError() {
+		t.Fatalf("expected an error response for a duplicate name")
+	}
+
+	// The root policy cannot be attached
+	req.Data = map[string]interface{}{
+		"policies": "root",
+	}
+	resp, err = c.HandleRequest(req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if resp == nil || !resp.IsError() {
+		t.Fatalf("expected an error response for the root policy")
+	}
+
+	req = logical.TestRequest(t, logical.ReadOperation, "identity/entity/id/"+entityID)
+	req.ClientToken = root
+	resp, err = c.HandleRequest(req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !reflect.DeepEqual(resp.Data["policies"], []string{"bar", "foo"}) {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+	expectedMetadata := map[string]string{
+		"team":     "core",
+		"location": "remote",
+	}
+	if !reflect.DeepEqual(resp.Data["metadata"], expectedMetadata) {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+
+	// Rename the entity
+	req.Operation = logical.UpdateOperation
+	req.Data = map[string]interface{}{
+		"name": "armon-dadgar",
+	}
+	resp, err = c.HandleRequest(req)
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("err: %v resp: %#v", err, resp)
+	}
+
+	req = logical.TestRequest(t, logical.ReadOperation, "identity/entity/name/armon-dadgar")
+	req.ClientToken = root
+	resp, err = c.HandleRequest(req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if resp == nil || resp.Data["id"] != entityID {
+		t.Fatalf("bad: %#v", resp)
+	}
+
+	req.Path = "identity/entity/name/armon"
+	resp, err = c.HandleRequest(req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if resp != nil {
+		t.Fatalf("expected the previous name to be released: %#v", resp)
+	}
+
+	req = logical.TestRequest(t, logical.ListOperation, "identity/entity/id/")
+	req.ClientToken = root
+	resp, err = c.HandleRequest(req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !reflect.DeepEqual(resp.Data["keys"], []string{entityID}) {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+
+	req = logical.TestRequest(t, logical.DeleteOperation, "identity/entity/id/"+entityID)
+	req.ClientToken = root
+	if _, err := c.HandleRequest(req); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	req.Operation = logical.ReadOperation
+	resp, err = c.HandleRequest(req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if resp != nil {
+		t.Fatalf("expected the entity to be deleted: %#v", resp)
+	}
+}
+
+func TestIdentityStore_LoginAliases(t *testing.T) {
+	c, _, root := TestCoreUnsealed(t)
+
+	// Mount two credential backends that authenticate the same user
+	for _, path := range []string{"github", "ldap"} {
+		noop := &NoopBackend{
+			Login: []string{"login"},
+			Response: &logical.Response{
+				Auth: &logical.Auth{
+					Policies: []string{"default"},
+					Alias: &logical.Alias{
+						Name: "armon",
+					},
+				},
+			},
+		}
+		c.credentialBackends["noop-"+path] = func(conf *logical.BackendConfig) (logical.Backend, error) {
+			return noop, nil
+		}
+
+		req := logical.TestRequest(t, logical.UpdateOperation, "sys/auth/"+path)
+		req.Data["type"] = "noop-" + path
+		req.ClientToken = root
+		if _, err := c.HandleRequest(req); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+	}
+
+	login := func(path string) *logical.Auth {
+		resp, err := c.HandleRequest(&logical.Request{
+			Path: "auth/" + path + "/login",
+		})
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if resp.Auth.EntityID == "" {
+			t.Fatalf("expected an entity ID: %#v", resp.Auth)
+		}
+		return resp.Auth
+	}
+
+	// Logins via the same backend map to the same entity
+	githubAuth := login("github")
+	if login("github").EntityID != githubAuth.EntityID {
+		t.Fatalf("expected logins to map to the same entity")
+	}
+
+	// Logins via a different backend create a separate entity
+	ldapAuth := login("ldap")
+	if ldapAuth.EntityID == githubAuth.EntityID {
+		t.Fatalf("expected logins via different backends to map to different entities")
+	}
+
+	// Merge the identities by moving the LDAP alias to the GitHub entity
+	req := logical.TestRequest(t, logical.ReadOperation, "identity/entity/id/"+ldapAuth.EntityID)
+	req.ClientToken = root
+	resp, err := c.HandleRequest(req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	aliases := resp.Data["aliases"].([]map[string]interface{})
+	if len(aliases) != 1 || aliases[0]["mount_path"] != "auth/ldap/" || aliases[0]["name"] != "armon" {
+		t.Fatalf("bad: %#v", aliases)
+	}
+
+	req = logical.TestRequest(t, logical.UpdateOperation, "identity/entity-alias/id/"+aliases[0]["id"].(string))
+	req.ClientToken = root
+	req.Data = map[string]interface{}{
+		"entity_id": githubAuth.EntityID,
+	}
+	resp, err = c.HandleRequest(req)
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("err: %v resp: %#v", err, resp)
+	}
+
+	if login("ldap").EntityID != githubAuth.EntityID {
+		t.Fatalf("expected the LDAP login to map to the merged entity")
+	}
+
+	// A second alias for the same identity cannot be created
+	req = logical.TestRequest(t, logical.UpdateOperation, "identity/entity-alias")
+	req.ClientToken = root
+	req.Data = map[string]interface{}{
+		"name":       "armon",
+		"mount_path": "ldap",
+		"entity_id":  ldapAuth.EntityID,
+	}
+	resp, err = c.HandleRequest(req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if resp == nil || !resp.IsError() {
+		t.Fatalf("expected an error response for a duplicate alias")
+	}
+
+	// Nor can aliases be created for backends that are not mounted
+	req.Data["mount_path"] = "userpass"
+	resp, err = c.HandleRequest(req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if resp == nil || !resp.IsError() {
+		t.Fatalf("expected an error response for an unknown mount path")
+	}
+
+	// Entity policies apply to the tokens of the entity, including those
+	// issued before the policies were attached
+	req = logical.TestRequest(t, logical.UpdateOperation, "sys/policy/secret-reader")
+	req.ClientToken = root
+	req.Data["rules"] = `path "secret/*" { policy = "read" }`
+	if _, err := c.HandleRequest(req); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	capabilities, err := c.Capabilities(githubAuth.ClientToken, "secret/foo")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !reflect.DeepEqual(capabilities, []string{"deny"}) {
+		t.Fatalf("bad: %#v", capabilities)
+	}
+
+	req = logical.TestRequest(t, logical.UpdateOperation, "identity/entity/id/"+githubAuth.EntityID)
+	req.ClientToken = root
+	req.Data = map[string]interface{}{
+		"policies": "secret-reader",
+	}
+	resp, err = c.HandleRequest(req)
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("err: %v resp: %#v", err, resp)
+	}
+
+	capabilities, err = c.Capabilities(githubAuth.ClientToken, "secret/foo")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !reflect.DeepEqual(capabilities, []string{"list", "read"}) {
+		t.Fatalf("bad: %#v", capabilities)
+	}
+
+	req = logical.TestRequest(t, logical.ReadOperation, "secret/foo")
+	req.ClientToken = githubAuth.ClientToken
+	if _, err := c.HandleRequest(req); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+}
+
+func TestIdentityStore_Groups(t *testing.T) {
+	c, _, root := TestCoreUnsealed(t)
+
+	write := func(path string, data map[string]interface{}) *logical.Response {
+		req := logical.TestRequest(t, logical.UpdateOperation, path)
+		req.ClientToken = root
+		req.Data = data
+		resp, err := c.HandleRequest(req)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		return resp
+	}
+
+	resp := write("identity/entity", map[string]interface{}{
+		"name": "armon",
+	})
+	entityID := resp.Data["id"].(string)
+
+	resp = write("identity/group", map[string]interface{}{
+		"name":              "engineering",
+		"policies":          "engineering",
+		"member_entity_ids": entityID,
+	})
+	if resp.IsError() {
+		t.Fatalf("bad: %#v", resp)
+	}
+	engineeringID := resp.Data["id"].(string)
+
+	resp = write("identity/group", map[string]interface{}{
+		"name":             "employees",
+		"policies":         "employees",
+		"member_group_ids": engineeringID,
+	})
+	if resp.IsError() {
+		t.Fatalf("bad: %#v", resp)
+	}
+	employeesID := resp.Data["id"].(string)
+
+	// Group memberships cannot be cyclic
+	resp = write("identity/group/id/"+engineeringID, map[string]interface{}{
+		"member_group_ids": employeesID,
+	})
+	if resp == nil || !resp.IsError() {
+		t.Fatalf("expected an error response for a cyclic membership")
+	}
+
+	// Members must exist
+	resp = write("identity/group/id/"+engineeringID, map[string]interface{}{
+		"member_entity_ids": "nonexistent",
+	})
+	if resp == nil || !resp.IsError() {
+		t.Fatalf("expected an error response for an unknown member entity")
+	}
+
+	req := logical.TestRequest(t, logical.ReadOperation, "identity/group/name/employees")
+	req.ClientToken = root
+	resp, err := c.HandleRequest(req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if resp.Data["id"] != employeesID || resp.Data["type"] != "internal" ||
+		!reflect.DeepEqual(resp.Data["member_group_ids"], []string{engineeringID}) {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+
+	// Policies are inherited from the groups the entity is a direct or
+	// indirect member of
+	policies, err := c.identityStore.GroupPolicies(entityID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	sort.Strings(policies)
+	if !reflect.DeepEqual(policies, []string{"employees", "engineering"}) {
+		t.Fatalf("bad: %#v", policies)
+	}
+
+	// Deleting a group removes it from the groups it is a member of
+	req = logical.TestRequest(t, logical.DeleteOperation, "identity/group/id/"+engineeringID)
+	req.ClientToken = root
+	if _, err := c.HandleRequest(req); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	req = logical.TestRequest(t, logical.ReadOperation, "identity/group/id/"+employeesID)
+	req.ClientToken = root
+	resp, err = c.HandleRequest(req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(resp.Data["member_group_ids"].([]string)) != 0 {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+
+	policies, err = c.identityStore.GroupPolicies(entityID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(policies) != 0 {
+		t.Fatalf("bad: %#v", policies)
+	}
+
+	// Deleting an entity removes it from its groups
+	write("identity/group/id/"+employeesID, map[string]interface{}{
+		"member_entity_ids": entityID,
+	})
+	req = logical.TestRequest(t, logical.DeleteOperation, "identity/entity/id/"+entityID)
+	req.ClientToken = root
+	if _, err := c.HandleRequest(req); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	req = logical.TestRequest(t, logical.ReadOperation, "identity/group/id/"+employeesID)
+	req.ClientToken = root
+	resp, err = c.HandleRequest(req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(resp.Data["member_entity_ids"].([]string)) != 0 {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+}
+
+func TestIdentityStore_ExternalGroups(t *testing.T) {
+	c, _, root := TestCoreUnsealed(t)
+
+	auth := &logical.Auth{
+		Policies: []string{"default"},
+		Alias: &logical.Alias{
+			Name: "armon",
+		},
+		GroupAliases: []*logical.Alias{
+			&logical.Alias{
+				Name: "admins",
+			},
+		},
+	}
+	noop := &NoopBackend{
+		Login: []string{"login"},
+		Response: &logical.Response{
+			Auth: auth,
+		},
+	}
+	c.credentialBackends["noop"] = func(conf *logical.BackendConfig) (logical.Backend, error) {
+		return noop, nil
+	}
+
+	req := logical.TestRequest(t, logical.UpdateOperation, "sys/auth/ldap")
+	req.Data["type"] = "noop"
+	req.ClientToken = root
+	if _, err := c.HandleRequest(req); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	req = logical.TestRequest(t, logical.UpdateOperation, "identity/group")
+	req.ClientToken = root
+	req.Data = map[string]interface{}{
+		"name":     "admins",
+		"type":     "external",
+		"policies": "admins",
+	}
+	resp, err := c.HandleRequest(req)
+	if err != nil || resp.IsError() {
+		t.Fatalf("err: %v resp: %#v", err, resp)
+	}
+	groupID := resp.Data["id"].(string)
+
+	// The members of external groups cannot be managed directly
+	req = logical.TestRequest(t, logical.UpdateOperation, "identity/group/id/"+groupID)
+	req.ClientToken = root
+	req.Data = map[string]interface{}{
+		"member_entity_ids": "foo",
+	}
+	resp, err = c.HandleRequest(req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if resp == nil || !resp.IsError() {
+		t.Fatalf("expected an error response")
+	}
+
+	req = logical.TestRequest(t, logical.UpdateOperation, "identity/group-alias")
+	req.ClientToken = root
+	req.Data = map[string]interface{}{
+		"name":       "admins",
+		"mount_path": "ldap",
+		"group_id":   groupID,
+	}
+	resp, err = c.HandleRequest(req)
+	if err != nil || resp.IsError() {
+		t.Fatalf("err: %v resp: %#v", err, resp)
+	}
+	aliasID := resp.Data["id"].(string)
+
+	// An external group can only have a single alias
+	req.Data["name"] = "operators"
+	resp, err = c.HandleRequest(req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if resp == nil || !resp.IsError() {
+		t.Fatalf("expected an error response")
+	}
+
+	readMembers := func() []string {
+		req := logical.TestRequest(t, logical.ReadOperation, "identity/group/id/"+groupID)
+		req.ClientToken = root
+		resp, err := c.HandleRequest(req)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		return resp.Data["member_entity_ids"].([]string)
+	}
+
+	// Logging in as a member of the aliased group joins the external group
+	resp, err = c.HandleRequest(&logical.Request{
+		Path: "auth/ldap/login",
+	})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	entityID := resp.Auth.EntityID
+	if !reflect.DeepEqual(readMembers(), []string{entityID}) {
+		t.Fatalf("bad: %#v", readMembers())
+	}
+
+	policies, err := c.identityStore.GroupPolicies(entityID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !reflect.DeepEqual(policies, []string{"admins"}) {
+		t.Fatalf("bad: %#v", policies)
+	}
+
+	// Logging in after leaving the aliased group leaves the external group
+	auth.GroupAliases = nil
+	if _, err := c.HandleRequest(&logical.Request{Path: "auth/ldap/login"}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(readMembers()) != 0 {
+		t.Fatalf("bad: %#v", readMembers())
+	}
+
+	// Deleting the alias removes the members derived from it
+	auth.GroupAliases = []*logical.Alias{&logical.Alias{Name: "admins"}}
+	if _, err := c.HandleRequest(&logical.Request{Path: "auth/ldap/login"}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(readMembers()) != 1 {
+		t.Fatalf("bad: %#v", readMembers())
+	}
+
+	req = logical.TestRequest(t, logical.DeleteOperation, "identity/group-alias/id/"+aliasID)
+	req.ClientToken = root
+	if _, err := c.HandleRequest(req); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(readMembers()) != 0 {
+		t.Fatalf("bad: %#v", readMembers())
+	}
+}
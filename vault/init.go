@@ -127,6 +127,10 @@ func (c *Core) Initialize(initParams *InitParams) (*InitResult, error) {
 		return nil, ErrAlreadyInit
 	}
 
+	if c.migrationSeal != nil {
+		return nil, fmt.Errorf("cannot initialize Vault while a seal migration is pending")
+	}
+
 	err = c.seal.Init()
 	if err != nil {
 		c.logger.Error("core: failed to initialize seal", "error", err)
@@ -250,7 +254,7 @@ func (c *Core) Initialize(initParams *InitParams) (*InitResult, error) {
 }
 
 func (c *Core) UnsealWithStoredKeys() error {
-	if !c.seal.StoredKeysSupported() {
+	if !c.seal.StoredKeysSupported() || c.SealMigrationPending() {
 		return nil
 	}
 
@@ -39,6 +39,7 @@ func LeaseSwitchedPassthroughBackend(conf *logical.BackendConfig, leases bool) (
 					logical.ReadOperation:   b.handleRead,
 					logical.CreateOperation: b.handleWrite,
 					logical.UpdateOperation: b.handleWrite,
+					logical.PatchOperation:  b.handlePatch,
 					logical.DeleteOperation: b.handleDelete,
 					logical.ListOperation:   b.handleList,
 				},
@@ -173,6 +174,37 @@ func (b *PassthroughBackend) handleWrite(
 	return nil, nil
 }
 
+func (b *PassthroughBackend) handlePatch(
+	req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	var patched map[string]interface{}
+	found, err := framework.PatchStorageEntry(req.Storage, req.Path, req.Data, &patched)
+	if err != nil {
+		return nil, fmt.Errorf("patch failed: %v", err)
+	}
+	if !found {
+		return logical.ErrorResponse("no data found at the path"), logical.ErrInvalidRequest
+	}
+	if len(patched) == 0 {
+		return logical.ErrorResponse("missing data fields"), nil
+	}
+
+	// JSON encode the data
+	buf, err := json.Marshal(patched)
+	if err != nil {
+		return nil, fmt.Errorf("json encoding failed: %v", err)
+	}
+
+	entry := &logical.StorageEntry{
+		Key:   req.Path,
+		Value: buf,
+	}
+	if err := req.Storage.Put(entry); err != nil {
+		return nil, fmt.Errorf("failed to write: %v", err)
+	}
+
+	return nil, nil
+}
+
 func (b *PassthroughBackend) handleDelete(
 	req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
 	// Delete the key at the request path
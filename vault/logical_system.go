@@ -3,12 +3,14 @@ package vault
 import (
 	"encoding/base64"
 	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/hashicorp/vault/helper/duration"
+	"github.com/hashicorp/vault/helper/strutil"
 	"github.com/hashicorp/vault/logical"
 	"github.com/hashicorp/vault/logical/framework"
 	"github.com/mitchellh/mapstructure"
@@ -35,10 +37,16 @@ func NewSystemBackend(core *Core, config *logical.BackendConfig) logical.Backend
 				"auth/*",
 				"remount",
 				"revoke-prefix/*",
+				"revoke-force/*",
+				"leases/lookup/*",
 				"audit",
 				"audit/*",
+				"config/auditing/*",
+				"config/cors",
 				"raw/*",
 				"rotate",
+				"plugins/catalog/*",
+				"plugins/reload/backend",
 			},
 		},
 
@@ -164,6 +172,34 @@ func NewSystemBackend(core *Core, config *logical.BackendConfig) logical.Backend
 						Type:        framework.TypeString,
 						Description: strings.TrimSpace(sysHelp["tune_max_lease_ttl"][0]),
 					},
+					"description": &framework.FieldSchema{
+						Type:        framework.TypeString,
+						Description: strings.TrimSpace(sysHelp["tune_description"][0]),
+					},
+					"audit_non_hmac_request_keys": &framework.FieldSchema{
+						Type:        framework.TypeString,
+						Description: strings.TrimSpace(sysHelp["tune_audit_non_hmac_request_keys"][0]),
+					},
+					"audit_non_hmac_response_keys": &framework.FieldSchema{
+						Type:        framework.TypeString,
+						Description: strings.TrimSpace(sysHelp["tune_audit_non_hmac_response_keys"][0]),
+					},
+					"periodic_interval": &framework.FieldSchema{
+						Type:        framework.TypeString,
+						Description: strings.TrimSpace(sysHelp["tune_periodic_interval"][0]),
+					},
+					"lockout_threshold": &framework.FieldSchema{
+						Type:        framework.TypeInt,
+						Description: strings.TrimSpace(sysHelp["tune_lockout_threshold"][0]),
+					},
+					"lockout_duration": &framework.FieldSchema{
+						Type:        framework.TypeString,
+						Description: strings.TrimSpace(sysHelp["tune_lockout_duration"][0]),
+					},
+					"lockout_counter_reset": &framework.FieldSchema{
+						Type:        framework.TypeString,
+						Description: strings.TrimSpace(sysHelp["tune_lockout_counter_reset"][0]),
+					},
 				},
 				Callbacks: map[logical.Operation]framework.OperationFunc{
 					logical.ReadOperation:   b.handleAuthTuneRead,
@@ -173,6 +209,25 @@ func NewSystemBackend(core *Core, config *logical.BackendConfig) logical.Backend
 				HelpDescription: strings.TrimSpace(sysHelp["auth_tune"][1]),
 			},
 
+			&framework.Path{
+				Pattern: "auth/(?P<path>.+?)/convert$",
+				Fields: map[string]*framework.FieldSchema{
+					"path": &framework.FieldSchema{
+						Type:        framework.TypeString,
+						Description: strings.TrimSpace(sysHelp["auth_convert"][0]),
+					},
+					"type": &framework.FieldSchema{
+						Type:        framework.TypeString,
+						Description: strings.TrimSpace(sysHelp["auth_convert_type"][0]),
+					},
+				},
+				Callbacks: map[logical.Operation]framework.OperationFunc{
+					logical.UpdateOperation: b.handleAuthConvert,
+				},
+				HelpSynopsis:    strings.TrimSpace(sysHelp["auth_convert"][0]),
+				HelpDescription: strings.TrimSpace(sysHelp["auth_convert"][1]),
+			},
+
 			&framework.Path{
 				Pattern: "mounts/(?P<path>.+?)/tune$",
 
@@ -189,6 +244,22 @@ func NewSystemBackend(core *Core, config *logical.BackendConfig) logical.Backend
 						Type:        framework.TypeString,
 						Description: strings.TrimSpace(sysHelp["tune_max_lease_ttl"][0]),
 					},
+					"description": &framework.FieldSchema{
+						Type:        framework.TypeString,
+						Description: strings.TrimSpace(sysHelp["tune_description"][0]),
+					},
+					"audit_non_hmac_request_keys": &framework.FieldSchema{
+						Type:        framework.TypeString,
+						Description: strings.TrimSpace(sysHelp["tune_audit_non_hmac_request_keys"][0]),
+					},
+					"audit_non_hmac_response_keys": &framework.FieldSchema{
+						Type:        framework.TypeString,
+						Description: strings.TrimSpace(sysHelp["tune_audit_non_hmac_response_keys"][0]),
+					},
+					"periodic_interval": &framework.FieldSchema{
+						Type:        framework.TypeString,
+						Description: strings.TrimSpace(sysHelp["tune_periodic_interval"][0]),
+					},
 				},
 
 				Callbacks: map[logical.Operation]framework.OperationFunc{
@@ -220,6 +291,10 @@ func NewSystemBackend(core *Core, config *logical.BackendConfig) logical.Backend
 						Type:        framework.TypeMap,
 						Description: strings.TrimSpace(sysHelp["mount_config"][0]),
 					},
+					"seal_wrap": &framework.FieldSchema{
+						Type:        framework.TypeBool,
+						Description: strings.TrimSpace(sysHelp["seal_wrap"][0]),
+					},
 				},
 
 				Callbacks: map[logical.Operation]framework.OperationFunc{
@@ -264,6 +339,24 @@ func NewSystemBackend(core *Core, config *logical.BackendConfig) logical.Backend
 				HelpDescription: strings.TrimSpace(sysHelp["remount"][1]),
 			},
 
+			&framework.Path{
+				Pattern: "remount/status/(?P<migration_id>.+?)$",
+
+				Fields: map[string]*framework.FieldSchema{
+					"migration_id": &framework.FieldSchema{
+						Type:        framework.TypeString,
+						Description: strings.TrimSpace(sysHelp["remount_migration_id"][0]),
+					},
+				},
+
+				Callbacks: map[logical.Operation]framework.OperationFunc{
+					logical.ReadOperation: b.handleRemountStatus,
+				},
+
+				HelpSynopsis:    strings.TrimSpace(sysHelp["remount_status"][0]),
+				HelpDescription: strings.TrimSpace(sysHelp["remount_status"][1]),
+			},
+
 			&framework.Path{
 				Pattern: "renew" + framework.OptionalParamRegex("url_lease_id"),
 
@@ -290,6 +383,42 @@ func NewSystemBackend(core *Core, config *logical.BackendConfig) logical.Backend
 				HelpDescription: strings.TrimSpace(sysHelp["renew"][1]),
 			},
 
+			&framework.Path{
+				Pattern: "leases/lookup/(?P<prefix>.*)$",
+
+				Fields: map[string]*framework.FieldSchema{
+					"prefix": &framework.FieldSchema{
+						Type:        framework.TypeString,
+						Description: strings.TrimSpace(sysHelp["leases-list-prefix"][0]),
+					},
+				},
+
+				Callbacks: map[logical.Operation]framework.OperationFunc{
+					logical.ListOperation: b.handleLeaseLookupList,
+				},
+
+				HelpSynopsis:    strings.TrimSpace(sysHelp["leases-list"][0]),
+				HelpDescription: strings.TrimSpace(sysHelp["leases-list"][1]),
+			},
+
+			&framework.Path{
+				Pattern: "leases/lookup$",
+
+				Fields: map[string]*framework.FieldSchema{
+					"lease_id": &framework.FieldSchema{
+						Type:        framework.TypeString,
+						Description: strings.TrimSpace(sysHelp["lease_id"][0]),
+					},
+				},
+
+				Callbacks: map[logical.Operation]framework.OperationFunc{
+					logical.UpdateOperation: b.handleLeaseLookup,
+				},
+
+				HelpSynopsis:    strings.TrimSpace(sysHelp["leases"][0]),
+				HelpDescription: strings.TrimSpace(sysHelp["leases"][1]),
+			},
+
 			&framework.Path{
 				Pattern: "revoke/(?P<lease_id>.+)",
 
@@ -371,6 +500,14 @@ func NewSystemBackend(core *Core, config *logical.BackendConfig) logical.Backend
 						Type:        framework.TypeString,
 						Description: strings.TrimSpace(sysHelp["auth_desc"][0]),
 					},
+					"config": &framework.FieldSchema{
+						Type:        framework.TypeMap,
+						Description: strings.TrimSpace(sysHelp["auth_config"][0]),
+					},
+					"seal_wrap": &framework.FieldSchema{
+						Type:        framework.TypeBool,
+						Description: strings.TrimSpace(sysHelp["seal_wrap"][0]),
+					},
 				},
 
 				Callbacks: map[logical.Operation]framework.OperationFunc{
@@ -418,6 +555,37 @@ func NewSystemBackend(core *Core, config *logical.BackendConfig) logical.Backend
 				HelpDescription: strings.TrimSpace(sysHelp["policy"][1]),
 			},
 
+			&framework.Path{
+				Pattern: "namespaces/?$",
+
+				Callbacks: map[logical.Operation]framework.OperationFunc{
+					logical.ListOperation: b.handleNamespacesList,
+				},
+
+				HelpSynopsis:    strings.TrimSpace(sysHelp["namespaces-list"][0]),
+				HelpDescription: strings.TrimSpace(sysHelp["namespaces-list"][1]),
+			},
+
+			&framework.Path{
+				Pattern: "namespaces/(?P<path>.+)",
+
+				Fields: map[string]*framework.FieldSchema{
+					"path": &framework.FieldSchema{
+						Type:        framework.TypeString,
+						Description: strings.TrimSpace(sysHelp["namespace-path"][0]),
+					},
+				},
+
+				Callbacks: map[logical.Operation]framework.OperationFunc{
+					logical.ReadOperation:   b.handleNamespacesRead,
+					logical.UpdateOperation: b.handleNamespacesSet,
+					logical.DeleteOperation: b.handleNamespacesDelete,
+				},
+
+				HelpSynopsis:    strings.TrimSpace(sysHelp["namespaces"][0]),
+				HelpDescription: strings.TrimSpace(sysHelp["namespaces"][1]),
+			},
+
 			&framework.Path{
 				Pattern:         "seal-status$",
 				HelpSynopsis:    strings.TrimSpace(sysHelp["seal-status"][0]),
@@ -500,6 +668,192 @@ func NewSystemBackend(core *Core, config *logical.BackendConfig) logical.Backend
 				HelpDescription: strings.TrimSpace(sysHelp["audit"][1]),
 			},
 
+			&framework.Path{
+				Pattern: "config/auditing/request-headers/(?P<header>.+)",
+
+				Fields: map[string]*framework.FieldSchema{
+					"header": &framework.FieldSchema{
+						Type:        framework.TypeString,
+						Description: strings.TrimSpace(sysHelp["audited-headers-header"][0]),
+					},
+					"hmac": &framework.FieldSchema{
+						Type:        framework.TypeBool,
+						Description: strings.TrimSpace(sysHelp["audited-headers-hmac"][0]),
+					},
+				},
+
+				Callbacks: map[logical.Operation]framework.OperationFunc{
+					logical.UpdateOperation: b.handleAuditedHeaderUpdate,
+					logical.DeleteOperation: b.handleAuditedHeaderDelete,
+					logical.ReadOperation:   b.handleAuditedHeaderRead,
+				},
+
+				HelpSynopsis:    strings.TrimSpace(sysHelp["audited-headers-name"][0]),
+				HelpDescription: strings.TrimSpace(sysHelp["audited-headers-name"][1]),
+			},
+
+			&framework.Path{
+				Pattern: "plugins/catalog/?$",
+
+				Callbacks: map[logical.Operation]framework.OperationFunc{
+					logical.ListOperation: b.handlePluginCatalogList,
+				},
+
+				HelpSynopsis:    strings.TrimSpace(sysHelp["plugin-catalog"][0]),
+				HelpDescription: strings.TrimSpace(sysHelp["plugin-catalog"][1]),
+			},
+
+			&framework.Path{
+				Pattern: "plugins/catalog/(?P<name>.+)",
+
+				Fields: map[string]*framework.FieldSchema{
+					"name": &framework.FieldSchema{
+						Type:        framework.TypeString,
+						Description: strings.TrimSpace(sysHelp["plugin-catalog_name"][0]),
+					},
+					"sha256": &framework.FieldSchema{
+						Type:        framework.TypeString,
+						Description: strings.TrimSpace(sysHelp["plugin-catalog_sha256"][0]),
+					},
+					"command": &framework.FieldSchema{
+						Type:        framework.TypeString,
+						Description: strings.TrimSpace(sysHelp["plugin-catalog_command"][0]),
+					},
+					"args": &framework.FieldSchema{
+						Type:        framework.TypeString,
+						Description: strings.TrimSpace(sysHelp["plugin-catalog_args"][0]),
+					},
+				},
+
+				Callbacks: map[logical.Operation]framework.OperationFunc{
+					logical.UpdateOperation: b.handlePluginCatalogUpdate,
+					logical.DeleteOperation: b.handlePluginCatalogDelete,
+					logical.ReadOperation:   b.handlePluginCatalogRead,
+				},
+
+				HelpSynopsis:    strings.TrimSpace(sysHelp["plugin-catalog"][0]),
+				HelpDescription: strings.TrimSpace(sysHelp["plugin-catalog"][1]),
+			},
+
+			&framework.Path{
+				Pattern: "plugins/reload/backend$",
+
+				Fields: map[string]*framework.FieldSchema{
+					"plugin": &framework.FieldSchema{
+						Type:        framework.TypeString,
+						Description: strings.TrimSpace(sysHelp["plugin-reload_plugin"][0]),
+					},
+					"mounts": &framework.FieldSchema{
+						Type:        framework.TypeString,
+						Description: strings.TrimSpace(sysHelp["plugin-reload_mounts"][0]),
+					},
+				},
+
+				Callbacks: map[logical.Operation]framework.OperationFunc{
+					logical.UpdateOperation: b.handlePluginReloadUpdate,
+				},
+
+				HelpSynopsis:    strings.TrimSpace(sysHelp["plugin-reload"][0]),
+				HelpDescription: strings.TrimSpace(sysHelp["plugin-reload"][1]),
+			},
+
+			&framework.Path{
+				Pattern: "quotas/(?P<type>rate-limit|lease-count)/(?P<name>.+)",
+
+				Fields: map[string]*framework.FieldSchema{
+					"type": &framework.FieldSchema{
+						Type:        framework.TypeString,
+						Description: strings.TrimSpace(sysHelp["quota_type"][0]),
+					},
+					"name": &framework.FieldSchema{
+						Type:        framework.TypeString,
+						Description: strings.TrimSpace(sysHelp["quota_name"][0]),
+					},
+					"path": &framework.FieldSchema{
+						Type:        framework.TypeString,
+						Description: strings.TrimSpace(sysHelp["quota_path"][0]),
+					},
+					"namespace": &framework.FieldSchema{
+						Type:        framework.TypeString,
+						Description: strings.TrimSpace(sysHelp["quota_namespace"][0]),
+					},
+					"rate": &framework.FieldSchema{
+						Type:        framework.TypeInt,
+						Description: strings.TrimSpace(sysHelp["quota_rate"][0]),
+					},
+					"burst": &framework.FieldSchema{
+						Type:        framework.TypeInt,
+						Description: strings.TrimSpace(sysHelp["quota_burst"][0]),
+					},
+					"max_leases": &framework.FieldSchema{
+						Type:        framework.TypeInt,
+						Description: strings.TrimSpace(sysHelp["quota_max_leases"][0]),
+					},
+				},
+
+				Callbacks: map[logical.Operation]framework.OperationFunc{
+					logical.UpdateOperation: b.handleQuotaUpdate,
+					logical.DeleteOperation: b.handleQuotaDelete,
+					logical.ReadOperation:   b.handleQuotaRead,
+				},
+
+				HelpSynopsis:    strings.TrimSpace(sysHelp["quotas"][0]),
+				HelpDescription: strings.TrimSpace(sysHelp["quotas"][1]),
+			},
+
+			&framework.Path{
+				Pattern: "quotas/(?P<type>rate-limit|lease-count)/?$",
+
+				Fields: map[string]*framework.FieldSchema{
+					"type": &framework.FieldSchema{
+						Type:        framework.TypeString,
+						Description: strings.TrimSpace(sysHelp["quota_type"][0]),
+					},
+				},
+
+				Callbacks: map[logical.Operation]framework.OperationFunc{
+					logical.ListOperation: b.handleQuotaList,
+				},
+
+				HelpSynopsis:    strings.TrimSpace(sysHelp["quotas"][0]),
+				HelpDescription: strings.TrimSpace(sysHelp["quotas"][1]),
+			},
+
+			&framework.Path{
+				Pattern: "config/cors$",
+
+				Fields: map[string]*framework.FieldSchema{
+					"allowed_origins": &framework.FieldSchema{
+						Type:        framework.TypeString,
+						Description: strings.TrimSpace(sysHelp["cors-allowed-origins"][0]),
+					},
+					"allowed_headers": &framework.FieldSchema{
+						Type:        framework.TypeString,
+						Description: strings.TrimSpace(sysHelp["cors-allowed-headers"][0]),
+					},
+				},
+
+				Callbacks: map[logical.Operation]framework.OperationFunc{
+					logical.ReadOperation:   b.handleCORSRead,
+					logical.UpdateOperation: b.handleCORSUpdate,
+					logical.DeleteOperation: b.handleCORSDelete,
+				},
+
+				HelpSynopsis:    strings.TrimSpace(sysHelp["config/cors"][0]),
+				HelpDescription: strings.TrimSpace(sysHelp["config/cors"][1]),
+			},
+
+			&framework.Path{
+				Pattern: "config/auditing/request-headers$",
+
+				Callbacks: map[logical.Operation]framework.OperationFunc{
+					logical.ReadOperation: b.handleAuditedHeadersRead,
+				},
+
+				HelpSynopsis:    strings.TrimSpace(sysHelp["audited-headers"][0]),
+				HelpDescription: strings.TrimSpace(sysHelp["audited-headers"][1]),
+			},
+
 			&framework.Path{
 				Pattern: "raw/(?P<path>.+)",
 
@@ -540,50 +894,180 @@ func NewSystemBackend(core *Core, config *logical.BackendConfig) logical.Backend
 				HelpSynopsis:    strings.TrimSpace(sysHelp["rotate"][0]),
 				HelpDescription: strings.TrimSpace(sysHelp["rotate"][1]),
 			},
-		},
-	}
 
-	b.Backend.Setup(config)
+			&framework.Path{
+				Pattern: "wrapping/wrap$",
 
-	return b.Backend
-}
+				Callbacks: map[logical.Operation]framework.OperationFunc{
+					logical.UpdateOperation: b.handleWrappingWrap,
+				},
 
-// SystemBackend implements logical.Backend and is used to interact with
-// the core of the system. This backend is hardcoded to exist at the "sys"
-// prefix. Conceptually it is similar to procfs on Linux.
-type SystemBackend struct {
-	Core    *Core
-	Backend *framework.Backend
-}
+				HelpSynopsis:    strings.TrimSpace(sysHelp["wrap"][0]),
+				HelpDescription: strings.TrimSpace(sysHelp["wrap"][1]),
+			},
 
-// handleCapabilitiesreturns the ACL capabilities of the token for a given path
-func (b *SystemBackend) handleCapabilities(req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
-	capabilities, err := b.Core.Capabilities(d.Get("token").(string), d.Get("path").(string))
-	if err != nil {
-		return nil, err
-	}
+			&framework.Path{
+				Pattern: "wrapping/unwrap$",
 
-	return &logical.Response{
-		Data: map[string]interface{}{
-			"capabilities": capabilities,
-		},
-	}, nil
-}
+				Fields: map[string]*framework.FieldSchema{
+					"token": &framework.FieldSchema{
+						Type:        framework.TypeString,
+						Description: "The response-wrapping token to unwrap. Defaults to the client token.",
+					},
+				},
 
-// handleCapabilitiesAccessor returns the ACL capabilities of the token associted
-// with the given accessor for a given path.
-func (b *SystemBackend) handleCapabilitiesAccessor(req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
-	accessor := d.Get("accessor").(string)
-	if accessor == "" {
-		return logical.ErrorResponse("missing accessor"), nil
-	}
+				Callbacks: map[logical.Operation]framework.OperationFunc{
+					logical.UpdateOperation: b.handleWrappingUnwrap,
+				},
 
-	aEntry, err := b.Core.tokenStore.lookupByAccessor(accessor)
-	if err != nil {
-		return nil, err
+				HelpSynopsis:    strings.TrimSpace(sysHelp["unwrap"][0]),
+				HelpDescription: strings.TrimSpace(sysHelp["unwrap"][1]),
+			},
+
+			&framework.Path{
+				Pattern: "wrapping/lookup$",
+
+				Fields: map[string]*framework.FieldSchema{
+					"token": &framework.FieldSchema{
+						Type:        framework.TypeString,
+						Description: "The response-wrapping token to look up.",
+					},
+				},
+
+				Callbacks: map[logical.Operation]framework.OperationFunc{
+					logical.UpdateOperation: b.handleWrappingLookup,
+				},
+
+				HelpSynopsis:    strings.TrimSpace(sysHelp["wraplookup"][0]),
+				HelpDescription: strings.TrimSpace(sysHelp["wraplookup"][1]),
+			},
+
+			&framework.Path{
+				Pattern: "wrapping/rewrap$",
+
+				Fields: map[string]*framework.FieldSchema{
+					"token": &framework.FieldSchema{
+						Type:        framework.TypeString,
+						Description: "The response-wrapping token to rewrap. Defaults to the client token.",
+					},
+				},
+
+				Callbacks: map[logical.Operation]framework.OperationFunc{
+					logical.UpdateOperation: b.handleWrappingRewrap,
+				},
+
+				HelpSynopsis:    strings.TrimSpace(sysHelp["rewrap"][0]),
+				HelpDescription: strings.TrimSpace(sysHelp["rewrap"][1]),
+			},
+
+			&framework.Path{
+				Pattern: "control-group/authorize$",
+
+				Fields: map[string]*framework.FieldSchema{
+					"accessor": &framework.FieldSchema{
+						Type:        framework.TypeString,
+						Description: strings.TrimSpace(sysHelp["control_group_accessor"][0]),
+					},
+				},
+
+				Callbacks: map[logical.Operation]framework.OperationFunc{
+					logical.UpdateOperation: b.handleControlGroupAuthorize,
+				},
+
+				HelpSynopsis:    strings.TrimSpace(sysHelp["control_group_authorize"][0]),
+				HelpDescription: strings.TrimSpace(sysHelp["control_group_authorize"][1]),
+			},
+
+			&framework.Path{
+				Pattern: "control-group/request$",
+
+				Fields: map[string]*framework.FieldSchema{
+					"accessor": &framework.FieldSchema{
+						Type:        framework.TypeString,
+						Description: strings.TrimSpace(sysHelp["control_group_accessor"][0]),
+					},
+				},
+
+				Callbacks: map[logical.Operation]framework.OperationFunc{
+					logical.UpdateOperation: b.handleControlGroupRequest,
+				},
+
+				HelpSynopsis:    strings.TrimSpace(sysHelp["control_group_request"][0]),
+				HelpDescription: strings.TrimSpace(sysHelp["control_group_request"][1]),
+			},
+
+			&framework.Path{
+				Pattern: "internal/specs/openapi$",
+
+				Callbacks: map[logical.Operation]framework.OperationFunc{
+					logical.ReadOperation: b.handleOpenAPI,
+				},
+
+				HelpSynopsis:    strings.TrimSpace(sysHelp["openapi"][0]),
+				HelpDescription: strings.TrimSpace(sysHelp["openapi"][1]),
+			},
+
+			&framework.Path{
+				Pattern: "internal/ui/mounts/(?P<path>.+)",
+
+				Fields: map[string]*framework.FieldSchema{
+					"path": &framework.FieldSchema{
+						Type:        framework.TypeString,
+						Description: strings.TrimSpace(sysHelp["internal_ui_mount_path"][0]),
+					},
+				},
+
+				Callbacks: map[logical.Operation]framework.OperationFunc{
+					logical.ReadOperation: b.handleInternalUIMountRead,
+				},
+
+				HelpSynopsis:    strings.TrimSpace(sysHelp["internal_ui_mount"][0]),
+				HelpDescription: strings.TrimSpace(sysHelp["internal_ui_mount"][1]),
+			},
+		},
+	}
+
+	b.Backend.Setup(config)
+
+	return b.Backend
+}
+
+// SystemBackend implements logical.Backend and is used to interact with
+// the core of the system. This backend is hardcoded to exist at the "sys"
+// prefix. Conceptually it is similar to procfs on Linux.
+type SystemBackend struct {
+	Core    *Core
+	Backend *framework.Backend
+}
+
+// handleCapabilitiesreturns the ACL capabilities of the token for a given path
+func (b *SystemBackend) handleCapabilities(req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	capabilities, err := b.Core.Capabilities(d.Get("token").(string), req.Namespace+d.Get("path").(string))
+	if err != nil {
+		return nil, err
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"capabilities": capabilities,
+		},
+	}, nil
+}
+
+// handleCapabilitiesAccessor returns the ACL capabilities of the token associted
+// with the given accessor for a given path.
+func (b *SystemBackend) handleCapabilitiesAccessor(req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	accessor := d.Get("accessor").(string)
+	if accessor == "" {
+		return logical.ErrorResponse("missing accessor"), nil
+	}
+
+	aEntry, err := b.Core.tokenStore.lookupByAccessor(accessor)
+	if err != nil {
+		return nil, err
 	}
 
-	capabilities, err := b.Core.Capabilities(aEntry.TokenID, d.Get("path").(string))
+	capabilities, err := b.Core.Capabilities(aEntry.TokenID, req.Namespace+d.Get("path").(string))
 	if err != nil {
 		return nil, err
 	}
@@ -673,6 +1157,11 @@ func (b *SystemBackend) handleRekeyDeleteRecovery(
 // handleMountTable handles the "mounts" endpoint to provide the mount table
 func (b *SystemBackend) handleMountTable(
 	req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	ns, err := b.Core.requestNamespace(req)
+	if err != nil {
+		return handleError(err)
+	}
+
 	b.Core.mountsLock.RLock()
 	defer b.Core.mountsLock.RUnlock()
 
@@ -681,6 +1170,10 @@ func (b *SystemBackend) handleMountTable(
 	}
 
 	for _, entry := range b.Core.mounts.Entries {
+		path, ok := namespaceEntryPath(ns, entry, "")
+		if !ok {
+			continue
+		}
 		info := map[string]interface{}{
 			"type":        entry.Type,
 			"description": entry.Description,
@@ -689,8 +1182,14 @@ func (b *SystemBackend) handleMountTable(
 				"max_lease_ttl":     int64(entry.Config.MaxLeaseTTL.Seconds()),
 			},
 		}
+		if entry.Config.PluginName != "" {
+			info["config"].(map[string]interface{})["plugin_name"] = entry.Config.PluginName
+		}
+		if entry.SealWrap {
+			info["seal_wrap"] = true
+		}
 
-		resp.Data[entry.Path] = info
+		resp.Data[path] = info
 	}
 
 	return resp, nil
@@ -699,6 +1198,11 @@ func (b *SystemBackend) handleMountTable(
 // handleMount is used to mount a new path
 func (b *SystemBackend) handleMount(
 	req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	ns, err := b.Core.requestNamespace(req)
+	if err != nil {
+		return handleError(err)
+	}
+
 	// Get all the options
 	path := data.Get("path").(string)
 	logicalType := data.Get("type").(string)
@@ -711,6 +1215,7 @@ func (b *SystemBackend) handleMount(
 	var apiConfig struct {
 		DefaultLeaseTTL string `json:"default_lease_ttl" structs:"default_lease_ttl" mapstructure:"default_lease_ttl"`
 		MaxLeaseTTL     string `json:"max_lease_ttl" structs:"max_lease_ttl" mapstructure:"max_lease_ttl"`
+		PluginName      string `json:"plugin_name" structs:"plugin_name" mapstructure:"plugin_name"`
 	}
 	configMap := data.Get("config").(map[string]interface{})
 	if configMap != nil && len(configMap) != 0 {
@@ -766,6 +1271,13 @@ func (b *SystemBackend) handleMount(
 			logical.ErrInvalidRequest
 	}
 
+	config.PluginName = apiConfig.PluginName
+	if (logicalType == pluginBackendType) != (config.PluginName != "") {
+		return logical.ErrorResponse(fmt.Sprintf(
+				"plugin_name must be set for, and only for, %s backends", pluginBackendType)),
+			logical.ErrInvalidRequest
+	}
+
 	// Create the mount entry
 	me := &MountEntry{
 		Table:       mountTableType,
@@ -773,6 +1285,8 @@ func (b *SystemBackend) handleMount(
 		Type:        logicalType,
 		Description: description,
 		Config:      config,
+		SealWrap:    data.Get("seal_wrap").(bool),
+		NamespaceID: ns.ID,
 	}
 
 	// Attempt mount
@@ -805,6 +1319,16 @@ func (b *SystemBackend) handleUnmount(
 
 	suffix = sanitizeMountPath(suffix)
 
+	// The mounts of a namespace are prefixed with its path
+	ns, err := b.Core.requestNamespace(req)
+	if err != nil {
+		return handleError(err)
+	}
+	suffix, err = b.Core.namespaceMountPath(ns, suffix)
+	if err != nil {
+		return handleError(err)
+	}
+
 	// Attempt unmount
 	if err := b.Core.unmount(suffix); err != nil {
 		b.Backend.Logger().Error("sys: unmount failed", "path", suffix, "error", err)
@@ -829,13 +1353,71 @@ func (b *SystemBackend) handleRemount(
 	fromPath = sanitizeMountPath(fromPath)
 	toPath = sanitizeMountPath(toPath)
 
-	// Attempt remount
-	if err := b.Core.remount(fromPath, toPath); err != nil {
+	// The mounts of a namespace are prefixed with its path, so the paths
+	// protected from remounting are checked before they are
+	ns, err := b.Core.requestNamespace(req)
+	if err != nil {
+		return handleError(err)
+	}
+	if ns.ID != "" {
+		for _, p := range protectedMounts {
+			if strings.HasPrefix(fromPath, p) || strings.HasPrefix(toPath, p) {
+				return logical.ErrorResponse(fmt.Sprintf("cannot remount '%s' to '%s'", fromPath, toPath)), logical.ErrInvalidRequest
+			}
+		}
+	}
+	if fromPath, err = b.Core.namespaceMountPath(ns, fromPath); err != nil {
+		return handleError(err)
+	}
+	if toPath, err = b.Core.namespaceMountPath(ns, toPath); err != nil {
+		return handleError(err)
+	}
+
+	// Start the remount
+	migrationID, err := b.Core.startRemount(fromPath, toPath)
+	if err != nil {
 		b.Backend.Logger().Error("sys: remount failed", "from_path", fromPath, "to_path", toPath, "error", err)
 		return handleError(err)
 	}
 
-	return nil, nil
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"migration_id": migrationID,
+		},
+	}, nil
+}
+
+// handleRemountStatus is used to look up the status of a remount
+func (b *SystemBackend) handleRemountStatus(
+	req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	migrationID := data.Get("migration_id").(string)
+	if migrationID == "" {
+		return logical.ErrorResponse("migration_id must be specified"), logical.ErrInvalidRequest
+	}
+
+	info := b.Core.MountMigrationStatus(migrationID)
+	if info == nil {
+		return nil, nil
+	}
+
+	// The end time is empty while the remount is in progress
+	endTime := ""
+	if !info.EndTime.IsZero() {
+		endTime = info.EndTime.Format(time.RFC3339Nano)
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"migration_id": info.ID,
+			"migration_info": map[string]interface{}{
+				"source_mount": info.SourceMount,
+				"target_mount": info.TargetMount,
+				"status":       info.Status,
+				"start_time":   info.StartTime.Format(time.RFC3339Nano),
+				"end_time":     endTime,
+			},
+		},
+	}, nil
 }
 
 // handleAuthTuneRead is used to get config settings on a auth path
@@ -847,7 +1429,7 @@ func (b *SystemBackend) handleAuthTuneRead(
 				"path must be specified as a string"),
 			logical.ErrInvalidRequest
 	}
-	return b.handleTuneReadCommon("auth/" + path)
+	return b.handleTuneReadCommon(req, "auth/"+path)
 }
 
 // handleMountTuneRead is used to get config settings on a backend
@@ -863,12 +1445,17 @@ func (b *SystemBackend) handleMountTuneRead(
 	// This call will read both logical backend's configuration as well as auth backends'.
 	// Retaining this behavior for backward compatibility. If this behavior is not desired,
 	// an error can be returned if path has a prefix of "auth/".
-	return b.handleTuneReadCommon(path)
+	return b.handleTuneReadCommon(req, path)
 }
 
-// handleTuneReadCommon returns the config settings of a path
-func (b *SystemBackend) handleTuneReadCommon(path string) (*logical.Response, error) {
-	path = sanitizeMountPath(path)
+// handleTuneReadCommon returns the config settings of a path relative to
+// the namespace of the request
+func (b *SystemBackend) handleTuneReadCommon(req *logical.Request, path string) (*logical.Response, error) {
+	ns, err := b.Core.requestNamespace(req)
+	if err != nil {
+		return handleError(err)
+	}
+	path = namespaceRoutePath(ns, sanitizeMountPath(path))
 
 	sysView := b.Core.router.MatchingSystemView(path)
 	if sysView == nil {
@@ -876,6 +1463,11 @@ func (b *SystemBackend) handleTuneReadCommon(path string) (*logical.Response, er
 		return handleError(fmt.Errorf("sys: cannot fetch sysview for path %s", path))
 	}
 
+	mountEntry := b.Core.router.MatchingMountEntry(path)
+	if mountEntry == nil || mountEntry.NamespaceID != ns.ID {
+		return handleError(fmt.Errorf("sys: no mount entry found for path %s", path))
+	}
+
 	resp := &logical.Response{
 		Data: map[string]interface{}{
 			"default_lease_ttl": int(sysView.DefaultLeaseTTL().Seconds()),
@@ -883,9 +1475,45 @@ func (b *SystemBackend) handleTuneReadCommon(path string) (*logical.Response, er
 		},
 	}
 
+	if strings.HasPrefix(path, "auth/") {
+		b.Core.authLock.RLock()
+		resp.Data["description"] = mountEntry.Description
+		addAuditKeys(resp, mountEntry.Config)
+		addPeriodicInterval(resp, mountEntry.Config)
+		resp.Data["lockout_threshold"] = mountEntry.Config.LockoutThreshold
+		resp.Data["lockout_duration"] = int(mountEntry.Config.LockoutDuration.Seconds())
+		resp.Data["lockout_counter_reset"] = int(mountEntry.Config.LockoutCounterReset.Seconds())
+		b.Core.authLock.RUnlock()
+	} else {
+		b.Core.mountsLock.RLock()
+		resp.Data["description"] = mountEntry.Description
+		addAuditKeys(resp, mountEntry.Config)
+		addPeriodicInterval(resp, mountEntry.Config)
+		b.Core.mountsLock.RUnlock()
+	}
+
 	return resp, nil
 }
 
+// addAuditKeys adds the audit keys configured on a mount to a tune response,
+// if there are any
+func addAuditKeys(resp *logical.Response, config MountConfig) {
+	if len(config.AuditNonHMACRequestKeys) > 0 {
+		resp.Data["audit_non_hmac_request_keys"] = config.AuditNonHMACRequestKeys
+	}
+	if len(config.AuditNonHMACResponseKeys) > 0 {
+		resp.Data["audit_non_hmac_response_keys"] = config.AuditNonHMACResponseKeys
+	}
+}
+
+// addPeriodicInterval adds the periodic interval configured on a mount to a
+// tune response, if there is one
+func addPeriodicInterval(resp *logical.Response, config MountConfig) {
+	if config.PeriodicInterval > 0 {
+		resp.Data["periodic_interval"] = int(config.PeriodicInterval.Seconds())
+	}
+}
+
 // handleAuthTuneWrite is used to set config settings on an auth path
 func (b *SystemBackend) handleAuthTuneWrite(
 	req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
@@ -894,7 +1522,7 @@ func (b *SystemBackend) handleAuthTuneWrite(
 		return logical.ErrorResponse("path must be specified as a string"),
 			logical.ErrInvalidRequest
 	}
-	return b.handleTuneWriteCommon("auth/"+path, data)
+	return b.handleTuneWriteCommon(req, "auth/"+path, data)
 }
 
 // handleMountTuneWrite is used to set config settings on a backend
@@ -908,13 +1536,18 @@ func (b *SystemBackend) handleMountTuneWrite(
 	// This call will write both logical backend's configuration as well as auth backends'.
 	// Retaining this behavior for backward compatibility. If this behavior is not desired,
 	// an error can be returned if path has a prefix of "auth/".
-	return b.handleTuneWriteCommon(path, data)
+	return b.handleTuneWriteCommon(req, path, data)
 }
 
-// handleTuneWriteCommon is used to set config settings on a path
+// handleTuneWriteCommon is used to set config settings on a path relative
+// to the namespace of the request
 func (b *SystemBackend) handleTuneWriteCommon(
-	path string, data *framework.FieldData) (*logical.Response, error) {
-	path = sanitizeMountPath(path)
+	req *logical.Request, path string, data *framework.FieldData) (*logical.Response, error) {
+	ns, err := b.Core.requestNamespace(req)
+	if err != nil {
+		return handleError(err)
+	}
+	path = namespaceRoutePath(ns, sanitizeMountPath(path))
 
 	// Prevent protected paths from being changed
 	for _, p := range untunableMounts {
@@ -925,7 +1558,7 @@ func (b *SystemBackend) handleTuneWriteCommon(
 	}
 
 	mountEntry := b.Core.router.MatchingMountEntry(path)
-	if mountEntry == nil {
+	if mountEntry == nil || mountEntry.NamespaceID != ns.ID {
 		b.Backend.Logger().Error("sys: tune failed: no mount entry found", "path", path)
 		return handleError(fmt.Errorf("sys: tune of path '%s' failed: no mount entry found", path))
 	}
@@ -971,9 +1604,105 @@ func (b *SystemBackend) handleTuneWriteCommon(
 
 		if newDefault != nil || newMax != nil {
 			lock.Lock()
-			defer lock.Unlock()
+			err := b.tuneMountTTLs(path, &mountEntry.Config, newDefault, newMax)
+			lock.Unlock()
+			if err != nil {
+				b.Backend.Logger().Error("sys: tuning failed", "path", path, "error", err)
+				return handleError(err)
+			}
+		}
+	}
+
+	// Description of the mount
+	if descRaw, ok := data.GetOk("description"); ok {
+		lock.Lock()
+		err := b.tuneMountDescription(path, mountEntry, descRaw.(string))
+		lock.Unlock()
+		if err != nil {
+			b.Backend.Logger().Error("sys: tuning failed", "path", path, "error", err)
+			return handleError(err)
+		}
+	}
+
+	// Audit configuration parameters
+	{
+		var newReqKeys, newRespKeys *[]string
+		if rawVal, ok := data.GetOk("audit_non_hmac_request_keys"); ok {
+			keys := parseAuditKeys(rawVal.(string))
+			newReqKeys = &keys
+		}
+		if rawVal, ok := data.GetOk("audit_non_hmac_response_keys"); ok {
+			keys := parseAuditKeys(rawVal.(string))
+			newRespKeys = &keys
+		}
+
+		if newReqKeys != nil || newRespKeys != nil {
+			lock.Lock()
+			err := b.tuneMountAuditKeys(path, &mountEntry.Config, newReqKeys, newRespKeys)
+			lock.Unlock()
+			if err != nil {
+				b.Backend.Logger().Error("sys: tuning failed", "path", path, "error", err)
+				return handleError(err)
+			}
+		}
+	}
+
+	// Interval of the periodic function of the backend
+	if intervalRaw, ok := data.GetOk("periodic_interval"); ok {
+		interval, err := duration.ParseDurationSecond(intervalRaw.(string))
+		if err != nil {
+			return handleError(err)
+		}
+		if interval < 0 {
+			return logical.ErrorResponse("periodic_interval cannot be negative"), logical.ErrInvalidRequest
+		}
+
+		lock.Lock()
+		err = b.tuneMountPeriodicInterval(path, &mountEntry.Config, interval)
+		lock.Unlock()
+		if err != nil {
+			b.Backend.Logger().Error("sys: tuning failed", "path", path, "error", err)
+			return handleError(err)
+		}
+	}
+
+	// Login lockout configuration parameters
+	{
+		var newThreshold *int
+		var newDuration, newCounterReset *time.Duration
+		if thresholdRaw, ok := data.GetOk("lockout_threshold"); ok {
+			threshold := thresholdRaw.(int)
+			if threshold < 0 {
+				return logical.ErrorResponse("lockout_threshold cannot be negative"), logical.ErrInvalidRequest
+			}
+			newThreshold = &threshold
+		}
 
-			if err := b.tuneMountTTLs(path, &mountEntry.Config, newDefault, newMax); err != nil {
+		if durationRaw, ok := data.GetOk("lockout_duration"); ok {
+			tmpDuration, err := duration.ParseDurationSecond(durationRaw.(string))
+			if err != nil {
+				return handleError(err)
+			}
+			newDuration = &tmpDuration
+		}
+
+		if resetRaw, ok := data.GetOk("lockout_counter_reset"); ok {
+			tmpReset, err := duration.ParseDurationSecond(resetRaw.(string))
+			if err != nil {
+				return handleError(err)
+			}
+			newCounterReset = &tmpReset
+		}
+
+		if newThreshold != nil || newDuration != nil || newCounterReset != nil {
+			if !strings.HasPrefix(path, "auth/") {
+				return logical.ErrorResponse("login lockout can only be configured on auth mounts"), logical.ErrInvalidRequest
+			}
+
+			lock.Lock()
+			err := b.tuneMountLockout(path, &mountEntry.Config, newThreshold, newDuration, newCounterReset)
+			lock.Unlock()
+			if err != nil {
 				b.Backend.Logger().Error("sys: tuning failed", "path", path, "error", err)
 				return handleError(err)
 			}
@@ -996,6 +1725,10 @@ func (b *SystemBackend) handleRenew(
 	// Convert the increment
 	increment := time.Duration(incrementRaw) * time.Second
 
+	if err := b.Core.checkLeaseNamespace(req, leaseID); err != nil {
+		return handleError(err)
+	}
+
 	// Invoke the expiration manager directly
 	resp, err := b.Core.expiration.Renew(leaseID, increment)
 	if err != nil {
@@ -1005,24 +1738,93 @@ func (b *SystemBackend) handleRenew(
 	return resp, err
 }
 
-// handleRevoke is used to revoke a given LeaseID
-func (b *SystemBackend) handleRevoke(
+// handleLeaseLookup is used to view the metadata of a given LeaseID
+func (b *SystemBackend) handleLeaseLookup(
 	req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
-	// Get all the options
 	leaseID := data.Get("lease_id").(string)
+	if leaseID == "" {
+		return logical.ErrorResponse("lease_id must be specified"),
+			logical.ErrInvalidRequest
+	}
 
-	// Invoke the expiration manager directly
-	if err := b.Core.expiration.Revoke(leaseID); err != nil {
-		b.Backend.Logger().Error("sys: lease revocation failed", "lease_id", leaseID, "error", err)
+	if err := b.Core.checkLeaseNamespace(req, leaseID); err != nil {
 		return handleError(err)
 	}
-	return nil, nil
-}
 
-// handleRevokePrefix is used to revoke a prefix with many LeaseIDs
-func (b *SystemBackend) handleRevokePrefix(
-	req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
-	return b.handleRevokePrefixCommon(req, data, false)
+	leaseTimes, err := b.Core.expiration.FetchLeaseTimes(leaseID)
+	if err != nil {
+		b.Backend.Logger().Error("sys: error retrieving lease", "lease_id", leaseID, "error", err)
+		return handleError(err)
+	}
+	if leaseTimes == nil {
+		return logical.ErrorResponse("invalid lease"), logical.ErrInvalidRequest
+	}
+
+	resp := &logical.Response{
+		Data: map[string]interface{}{
+			"id":           leaseID,
+			"issue_time":   leaseTimes.IssueTime,
+			"expire_time":  nil,
+			"last_renewal": nil,
+			"renewable":    leaseTimes.renewable() == nil,
+			"ttl":          int64(0),
+		},
+	}
+	if !leaseTimes.LastRenewalTime.IsZero() {
+		resp.Data["last_renewal"] = leaseTimes.LastRenewalTime
+	}
+	if !leaseTimes.ExpireTime.IsZero() {
+		resp.Data["expire_time"] = leaseTimes.ExpireTime
+		if ttl := leaseTimes.ExpireTime.Sub(time.Now()); ttl > 0 {
+			resp.Data["ttl"] = int64(ttl.Seconds())
+		}
+	}
+	return resp, nil
+}
+
+// handleLeaseLookupList is used to list the leases and sub-prefixes under a
+// given prefix
+func (b *SystemBackend) handleLeaseLookupList(
+	req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	prefix, err := b.Core.namespaceLeasePrefix(req, data.Get("prefix").(string))
+	if err != nil {
+		return handleError(err)
+	}
+
+	keys, err := b.Core.expiration.ListLeases(prefix)
+	if err != nil {
+		b.Backend.Logger().Error("sys: error listing leases", "prefix", prefix, "error", err)
+		return handleError(err)
+	}
+	if len(keys) == 0 {
+		return logical.ErrorResponse("prefix not found"), logical.ErrInvalidRequest
+	}
+
+	return logical.ListResponse(keys), nil
+}
+
+// handleRevoke is used to revoke a given LeaseID
+func (b *SystemBackend) handleRevoke(
+	req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	// Get all the options
+	leaseID := data.Get("lease_id").(string)
+
+	if err := b.Core.checkLeaseNamespace(req, leaseID); err != nil {
+		return handleError(err)
+	}
+
+	// Invoke the expiration manager directly
+	if err := b.Core.expiration.Revoke(leaseID); err != nil {
+		b.Backend.Logger().Error("sys: lease revocation failed", "lease_id", leaseID, "error", err)
+		return handleError(err)
+	}
+	return nil, nil
+}
+
+// handleRevokePrefix is used to revoke a prefix with many LeaseIDs
+func (b *SystemBackend) handleRevokePrefix(
+	req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	return b.handleRevokePrefixCommon(req, data, false)
 }
 
 // handleRevokeForce is used to revoke a prefix with many LeaseIDs, ignoring errors
@@ -1035,10 +1837,12 @@ func (b *SystemBackend) handleRevokeForce(
 func (b *SystemBackend) handleRevokePrefixCommon(
 	req *logical.Request, data *framework.FieldData, force bool) (*logical.Response, error) {
 	// Get all the options
-	prefix := data.Get("prefix").(string)
+	prefix, err := b.Core.namespaceLeasePrefix(req, data.Get("prefix").(string))
+	if err != nil {
+		return handleError(err)
+	}
 
 	// Invoke the expiration manager directly
-	var err error
 	if force {
 		err = b.Core.expiration.RevokeForce(prefix)
 	} else {
@@ -1054,6 +1858,11 @@ func (b *SystemBackend) handleRevokePrefixCommon(
 // handleAuthTable handles the "auth" endpoint to provide the auth table
 func (b *SystemBackend) handleAuthTable(
 	req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	ns, err := b.Core.requestNamespace(req)
+	if err != nil {
+		return handleError(err)
+	}
+
 	b.Core.authLock.RLock()
 	defer b.Core.authLock.RUnlock()
 
@@ -1061,6 +1870,10 @@ func (b *SystemBackend) handleAuthTable(
 		Data: make(map[string]interface{}),
 	}
 	for _, entry := range b.Core.auth.Entries {
+		path, ok := namespaceEntryPath(ns, entry, credentialRoutePrefix)
+		if !ok {
+			continue
+		}
 		info := map[string]interface{}{
 			"type":        entry.Type,
 			"description": entry.Description,
@@ -1069,7 +1882,13 @@ func (b *SystemBackend) handleAuthTable(
 				"max_lease_ttl":     int64(entry.Config.MaxLeaseTTL.Seconds()),
 			},
 		}
-		resp.Data[entry.Path] = info
+		if entry.Config.PluginName != "" {
+			info["config"].(map[string]interface{})["plugin_name"] = entry.Config.PluginName
+		}
+		if entry.SealWrap {
+			info["seal_wrap"] = true
+		}
+		resp.Data[path] = info
 	}
 	return resp, nil
 }
@@ -1077,6 +1896,11 @@ func (b *SystemBackend) handleAuthTable(
 // handleEnableAuth is used to enable a new credential backend
 func (b *SystemBackend) handleEnableAuth(
 	req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	ns, err := b.Core.requestNamespace(req)
+	if err != nil {
+		return handleError(err)
+	}
+
 	// Get all the options
 	path := data.Get("path").(string)
 	logicalType := data.Get("type").(string)
@@ -1090,12 +1914,36 @@ func (b *SystemBackend) handleEnableAuth(
 
 	path = sanitizeMountPath(path)
 
+	var apiConfig struct {
+		PluginName string `json:"plugin_name" structs:"plugin_name" mapstructure:"plugin_name"`
+	}
+	configMap := data.Get("config").(map[string]interface{})
+	if configMap != nil && len(configMap) != 0 {
+		err := mapstructure.Decode(configMap, &apiConfig)
+		if err != nil {
+			return logical.ErrorResponse(
+					"unable to convert given auth config information"),
+				logical.ErrInvalidRequest
+		}
+	}
+
+	if (logicalType == pluginBackendType) != (apiConfig.PluginName != "") {
+		return logical.ErrorResponse(fmt.Sprintf(
+				"plugin_name must be set for, and only for, %s backends", pluginBackendType)),
+			logical.ErrInvalidRequest
+	}
+
 	// Create the mount entry
 	me := &MountEntry{
 		Table:       credentialTableType,
 		Path:        path,
 		Type:        logicalType,
 		Description: description,
+		Config: MountConfig{
+			PluginName: apiConfig.PluginName,
+		},
+		SealWrap:    data.Get("seal_wrap").(bool),
+		NamespaceID: ns.ID,
 	}
 
 	// Attempt enabling
@@ -1116,6 +1964,16 @@ func (b *SystemBackend) handleDisableAuth(
 
 	suffix = sanitizeMountPath(suffix)
 
+	// The auth mounts of a namespace are prefixed with its path
+	ns, err := b.Core.requestNamespace(req)
+	if err != nil {
+		return handleError(err)
+	}
+	suffix, err = b.Core.namespaceMountPath(ns, suffix)
+	if err != nil {
+		return handleError(err)
+	}
+
 	// Attempt disable
 	if err := b.Core.disableCredential(suffix); err != nil {
 		b.Backend.Logger().Error("sys: disable auth mount failed", "path", suffix, "error", err)
@@ -1124,11 +1982,49 @@ func (b *SystemBackend) handleDisableAuth(
 	return nil, nil
 }
 
+// handleAuthConvert is used to change the type of an auth mount in place
+func (b *SystemBackend) handleAuthConvert(
+	req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	path := data.Get("path").(string)
+	if path == "" {
+		return logical.ErrorResponse("path must be specified as a string"),
+			logical.ErrInvalidRequest
+	}
+	newType := data.Get("type").(string)
+	if newType == "" {
+		return logical.ErrorResponse("type must be specified as a string"),
+			logical.ErrInvalidRequest
+	}
+
+	path = sanitizeMountPath(path)
+
+	// The auth mounts of a namespace are prefixed with its path
+	ns, err := b.Core.requestNamespace(req)
+	if err != nil {
+		return handleError(err)
+	}
+	path, err = b.Core.namespaceMountPath(ns, path)
+	if err != nil {
+		return handleError(err)
+	}
+
+	if err := b.Core.convertCredential(path, newType); err != nil {
+		b.Backend.Logger().Error("sys: convert auth mount failed", "path", path, "error", err)
+		return handleError(err)
+	}
+	return nil, nil
+}
+
 // handlePolicyList handles the "policy" endpoint to provide the enabled policies
 func (b *SystemBackend) handlePolicyList(
 	req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	ps, err := b.Core.requestPolicyStore(req)
+	if err != nil {
+		return handleError(err)
+	}
+
 	// Get all the configured policies
-	policies, err := b.Core.policyStore.ListPolicies()
+	policies, err := ps.ListPolicies()
 
 	// Add the special "root" policy
 	policies = append(policies, "root")
@@ -1145,7 +2041,12 @@ func (b *SystemBackend) handlePolicyRead(
 	req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
 	name := data.Get("name").(string)
 
-	policy, err := b.Core.policyStore.GetPolicy(name)
+	ps, err := b.Core.requestPolicyStore(req)
+	if err != nil {
+		return handleError(err)
+	}
+
+	policy, err := ps.GetPolicy(name)
 	if err != nil {
 		return handleError(err)
 	}
@@ -1177,8 +2078,13 @@ func (b *SystemBackend) handlePolicySet(
 	// Override the name
 	parse.Name = strings.ToLower(name)
 
+	ps, err := b.Core.requestPolicyStore(req)
+	if err != nil {
+		return handleError(err)
+	}
+
 	// Update the policy
-	if err := b.Core.policyStore.SetPolicy(parse); err != nil {
+	if err := ps.SetPolicy(parse); err != nil {
 		return handleError(err)
 	}
 	return nil, nil
@@ -1189,12 +2095,115 @@ func (b *SystemBackend) handlePolicyDelete(
 	req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
 	name := data.Get("name").(string)
 
-	if err := b.Core.policyStore.DeletePolicy(name); err != nil {
+	ps, err := b.Core.requestPolicyStore(req)
+	if err != nil {
+		return handleError(err)
+	}
+
+	if err := ps.DeletePolicy(name); err != nil {
 		return handleError(err)
 	}
 	return nil, nil
 }
 
+// handleNamespacesList handles the "namespaces" endpoint to list the
+// namespaces directly beneath the namespace of the request
+func (b *SystemBackend) handleNamespacesList(
+	req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	ns, err := b.Core.requestNamespace(req)
+	if err != nil {
+		return handleError(err)
+	}
+	return logical.ListResponse(b.Core.listNamespaces(ns)), nil
+}
+
+// handleNamespacesRead handles the "namespaces/<path>" endpoint to read a
+// namespace beneath the namespace of the request
+func (b *SystemBackend) handleNamespacesRead(
+	req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	parent, err := b.Core.requestNamespace(req)
+	if err != nil {
+		return handleError(err)
+	}
+	path := canonicalNamespacePath(data.Get("path").(string))
+	if path == "" {
+		return logical.ErrorResponse("path must be specified"), logical.ErrInvalidRequest
+	}
+
+	ns := b.Core.namespaceByPath(parent.Path + path)
+	if ns == nil {
+		return nil, nil
+	}
+	return namespaceResponse(parent, ns), nil
+}
+
+// handleNamespacesSet handles the "namespaces/<path>" endpoint to create a
+// namespace beneath the namespace of the request. The parent of the
+// namespace must exist.
+func (b *SystemBackend) handleNamespacesSet(
+	req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	ns, err := b.Core.requestNamespace(req)
+	if err != nil {
+		return handleError(err)
+	}
+	path := strings.TrimSuffix(canonicalNamespacePath(data.Get("path").(string)), "/")
+	if path == "" {
+		return logical.ErrorResponse("path must be specified"), logical.ErrInvalidRequest
+	}
+
+	name := path
+	parent := ns
+	if i := strings.LastIndex(path, "/"); i != -1 {
+		name = path[i+1:]
+		parent = b.Core.namespaceByPath(ns.Path + path[:i+1])
+		if parent == nil {
+			return logical.ErrorResponse(fmt.Sprintf("parent namespace %s not found", path[:i+1])), logical.ErrInvalidRequest
+		}
+	}
+
+	created, err := b.Core.createNamespace(parent, name)
+	if err != nil {
+		b.Backend.Logger().Error("sys: create namespace failed", "path", path, "error", err)
+		return handleError(err)
+	}
+	return namespaceResponse(ns, created), nil
+}
+
+// handleNamespacesDelete handles the "namespaces/<path>" endpoint to delete
+// a namespace beneath the namespace of the request
+func (b *SystemBackend) handleNamespacesDelete(
+	req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	parent, err := b.Core.requestNamespace(req)
+	if err != nil {
+		return handleError(err)
+	}
+	path := canonicalNamespacePath(data.Get("path").(string))
+	if path == "" {
+		return logical.ErrorResponse("path must be specified"), logical.ErrInvalidRequest
+	}
+
+	ns := b.Core.namespaceByPath(parent.Path + path)
+	if ns == nil {
+		return nil, nil
+	}
+	if err := b.Core.deleteNamespace(ns); err != nil {
+		b.Backend.Logger().Error("sys: delete namespace failed", "path", ns.Path, "error", err)
+		return handleError(err)
+	}
+	return nil, nil
+}
+
+// namespaceResponse returns the response describing a namespace, whose path
+// is relative to the namespace of the request
+func namespaceResponse(parent, ns *Namespace) *logical.Response {
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"id":   ns.ID,
+			"path": strings.TrimPrefix(ns.Path, parent.Path),
+		},
+	}
+}
+
 // handleAuditTable handles the "audit" endpoint to provide the audit table
 func (b *SystemBackend) handleAuditTable(
 	req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
@@ -1240,6 +2249,310 @@ func (b *SystemBackend) handleAuditHash(
 	}, nil
 }
 
+// handleAuditedHeaderUpdate adds or updates a request header to record in
+// audit entries
+func (b *SystemBackend) handleAuditedHeaderUpdate(
+	req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	header := data.Get("header").(string)
+	hmac := data.Get("hmac").(bool)
+	if header == "" {
+		return logical.ErrorResponse("missing header name"), nil
+	}
+
+	if err := b.Core.auditedHeaders.add(header, hmac); err != nil {
+		return nil, err
+	}
+
+	return nil, nil
+}
+
+// handleAuditedHeaderDelete stops recording a request header in audit
+// entries
+func (b *SystemBackend) handleAuditedHeaderDelete(
+	req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	header := data.Get("header").(string)
+	if header == "" {
+		return logical.ErrorResponse("missing header name"), nil
+	}
+
+	if err := b.Core.auditedHeaders.remove(header); err != nil {
+		return nil, err
+	}
+
+	return nil, nil
+}
+
+// handleAuditedHeaderRead returns the configuration of an audited request
+// header
+func (b *SystemBackend) handleAuditedHeaderRead(
+	req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	header := data.Get("header").(string)
+	if header == "" {
+		return logical.ErrorResponse("missing header name"), nil
+	}
+
+	headersConfig := b.Core.auditedHeaders
+	headersConfig.RLock()
+	defer headersConfig.RUnlock()
+
+	header = strings.ToLower(header)
+	settings, ok := headersConfig.Headers[header]
+	if !ok {
+		return logical.ErrorResponse("could not find header in config"), nil
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			header: settings,
+		},
+	}, nil
+}
+
+// handlePluginCatalogList lists the plugins in the catalog
+func (b *SystemBackend) handlePluginCatalogList(
+	req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	names, err := b.Core.pluginCatalog.List()
+	if err != nil {
+		return handleError(err)
+	}
+	return logical.ListResponse(names), nil
+}
+
+// handlePluginCatalogRead returns a plugin of the catalog
+func (b *SystemBackend) handlePluginCatalogRead(
+	req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	runner, err := b.Core.pluginCatalog.Get(data.Get("name").(string))
+	if err != nil {
+		return handleError(err)
+	}
+	if runner == nil {
+		return nil, nil
+	}
+
+	args := runner.Args
+	if args == nil {
+		args = []string{}
+	}
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"name":    runner.Name,
+			"command": runner.Command,
+			"args":    args,
+			"sha256":  hex.EncodeToString(runner.Sha256),
+		},
+	}, nil
+}
+
+// handlePluginCatalogUpdate registers a plugin in the catalog
+func (b *SystemBackend) handlePluginCatalogUpdate(
+	req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	command := data.Get("command").(string)
+	if command == "" {
+		return logical.ErrorResponse("missing command"), logical.ErrInvalidRequest
+	}
+
+	sha256, err := hex.DecodeString(data.Get("sha256").(string))
+	if err != nil || len(sha256) != 32 {
+		return logical.ErrorResponse("sha256 must be the hex-encoded SHA256 checksum of the plugin binary"), logical.ErrInvalidRequest
+	}
+
+	var args []string
+	if argsRaw := data.Get("args").(string); argsRaw != "" {
+		args = strings.Split(argsRaw, ",")
+	}
+
+	if err := b.Core.pluginCatalog.Set(data.Get("name").(string), command, args, sha256); err != nil {
+		return handleError(err)
+	}
+	return nil, nil
+}
+
+// handlePluginCatalogDelete removes a plugin from the catalog
+func (b *SystemBackend) handlePluginCatalogDelete(
+	req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	if err := b.Core.pluginCatalog.Delete(data.Get("name").(string)); err != nil {
+		return handleError(err)
+	}
+	return nil, nil
+}
+
+// handlePluginReloadUpdate restarts the backends of plugin mounts
+func (b *SystemBackend) handlePluginReloadUpdate(
+	req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	var mounts []string
+	if mountsRaw := data.Get("mounts").(string); mountsRaw != "" {
+		mounts = strings.Split(mountsRaw, ",")
+	}
+
+	if err := b.Core.reloadPluginMounts(data.Get("plugin").(string), mounts); err != nil {
+		return handleError(err)
+	}
+	return nil, nil
+}
+
+// handleQuotaRead returns a resource quota
+func (b *SystemBackend) handleQuotaRead(
+	req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	q := b.Core.quotaManager.Get(data.Get("type").(string), data.Get("name").(string))
+	if q == nil {
+		return nil, nil
+	}
+
+	resp := &logical.Response{
+		Data: map[string]interface{}{
+			"name":      q.Name,
+			"type":      q.Type,
+			"path":      q.Path,
+			"namespace": q.Namespace,
+		},
+	}
+	switch q.Type {
+	case QuotaTypeRateLimit:
+		resp.Data["rate"] = q.Rate
+		resp.Data["burst"] = q.Burst
+	case QuotaTypeLeaseCount:
+		resp.Data["max_leases"] = q.MaxLeases
+	}
+	return resp, nil
+}
+
+// handleQuotaUpdate creates or updates a resource quota
+func (b *SystemBackend) handleQuotaUpdate(
+	req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	quotaType := data.Get("type").(string)
+	name := data.Get("name").(string)
+
+	q := b.Core.quotaManager.Get(quotaType, name)
+	if q == nil {
+		q = &Quota{
+			Name: name,
+			Type: quotaType,
+		}
+	}
+
+	if pathRaw, ok := data.GetOk("path"); ok {
+		q.Path = strings.TrimPrefix(pathRaw.(string), "/")
+	}
+	if namespaceRaw, ok := data.GetOk("namespace"); ok {
+		q.Namespace = canonicalNamespacePath(namespaceRaw.(string))
+	}
+	if b.Core.namespaceByPath(q.Namespace) == nil {
+		return logical.ErrorResponse(fmt.Sprintf("namespace %q not found", q.Namespace)), logical.ErrInvalidRequest
+	}
+	if q.Namespace != "" && namespaceSharedPath(q.Path) {
+		return logical.ErrorResponse(fmt.Sprintf("path %q is shared by all the namespaces", q.Path)), logical.ErrInvalidRequest
+	}
+	if q.Path != "" && b.Core.router.MatchingMount(q.routePrefix()) == "" {
+		return logical.ErrorResponse(fmt.Sprintf("path %q does not match a mount", q.Path)), logical.ErrInvalidRequest
+	}
+
+	switch quotaType {
+	case QuotaTypeRateLimit:
+		if rateRaw, ok := data.GetOk("rate"); ok {
+			q.Rate = rateRaw.(int)
+		}
+		if burstRaw, ok := data.GetOk("burst"); ok {
+			q.Burst = burstRaw.(int)
+		}
+		if q.Rate <= 0 {
+			return logical.ErrorResponse("rate must be positive"), logical.ErrInvalidRequest
+		}
+		if q.Burst < 0 {
+			return logical.ErrorResponse("burst cannot be negative"), logical.ErrInvalidRequest
+		}
+	case QuotaTypeLeaseCount:
+		if maxLeasesRaw, ok := data.GetOk("max_leases"); ok {
+			q.MaxLeases = maxLeasesRaw.(int)
+		}
+		if q.MaxLeases <= 0 {
+			return logical.ErrorResponse("max_leases must be positive"), logical.ErrInvalidRequest
+		}
+	}
+
+	if err := b.Core.quotaManager.Set(q); err != nil {
+		return handleError(err)
+	}
+	return nil, nil
+}
+
+// handleQuotaDelete removes a resource quota
+func (b *SystemBackend) handleQuotaDelete(
+	req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	if err := b.Core.quotaManager.Delete(data.Get("type").(string), data.Get("name").(string)); err != nil {
+		return handleError(err)
+	}
+	return nil, nil
+}
+
+// handleQuotaList lists the resource quotas of a type
+func (b *SystemBackend) handleQuotaList(
+	req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	return logical.ListResponse(b.Core.quotaManager.List(data.Get("type").(string))), nil
+}
+
+// handleAuditedHeadersRead returns the request headers recorded in audit
+// entries
+func (b *SystemBackend) handleAuditedHeadersRead(
+	req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	headersConfig := b.Core.auditedHeaders
+	headersConfig.RLock()
+	defer headersConfig.RUnlock()
+
+	headers := make(map[string]interface{}, len(headersConfig.Headers))
+	for header, settings := range headersConfig.Headers {
+		headers[header] = settings
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"headers": headers,
+		},
+	}, nil
+}
+
+// handleCORSRead returns the CORS configuration
+func (b *SystemBackend) handleCORSRead(
+	req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	corsConfig := b.Core.corsConfig
+	corsConfig.RLock()
+	defer corsConfig.RUnlock()
+
+	resp := &logical.Response{
+		Data: map[string]interface{}{
+			"enabled": corsConfig.Enabled,
+		},
+	}
+	if corsConfig.Enabled {
+		resp.Data["allowed_origins"] = corsConfig.AllowedOrigins
+		resp.Data["allowed_headers"] = corsConfig.AllowedHeaders
+	}
+	return resp, nil
+}
+
+// handleCORSUpdate enables CORS for the given origins and headers
+func (b *SystemBackend) handleCORSUpdate(
+	req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	origins := strutil.ParseDedupAndSortStrings(data.Get("allowed_origins").(string), ",")
+	headers := strutil.ParseStringSlice(data.Get("allowed_headers").(string), ",")
+	if len(origins) == 0 {
+		return logical.ErrorResponse("missing allowed_origins"), nil
+	}
+	if strutil.StrListContains(origins, "*") && len(origins) > 1 {
+		return logical.ErrorResponse("to allow all origins the '*' must be the only value for allowed_origins"), nil
+	}
+
+	if err := b.Core.corsConfig.enable(origins, headers); err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
+
+// handleCORSDelete disables CORS
+func (b *SystemBackend) handleCORSDelete(
+	req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	return nil, b.Core.corsConfig.disable()
+}
+
 // handleEnableAudit is used to enable a new audit backend
 func (b *SystemBackend) handleEnableAudit(
 	req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
@@ -1354,56 +2667,417 @@ func (b *SystemBackend) handleRawDelete(
 		}
 	}
 
-	if err := b.Core.barrier.Delete(path); err != nil {
-		return handleError(err)
+	if err := b.Core.barrier.Delete(path); err != nil {
+		return handleError(err)
+	}
+	return nil, nil
+}
+
+// handleKeyStatus returns status information about the backend key
+func (b *SystemBackend) handleKeyStatus(
+	req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	// Get the key info
+	info, err := b.Core.barrier.ActiveKeyInfo()
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &logical.Response{
+		Data: map[string]interface{}{
+			"term":         info.Term,
+			"install_time": info.InstallTime.Format(time.RFC3339Nano),
+		},
+	}
+	return resp, nil
+}
+
+// handleRotate is used to trigger a key rotation
+func (b *SystemBackend) handleRotate(
+	req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	// Rotate to the new term
+	newTerm, err := b.Core.barrier.Rotate()
+	if err != nil {
+		b.Backend.Logger().Error("sys: failed to create new encryption key", "error", err)
+		return handleError(err)
+	}
+	b.Backend.Logger().Info("sys: installed new encryption key")
+
+	// In HA mode, we need to an upgrade path for the standby instances
+	if b.Core.ha != nil {
+		// Create the upgrade path to the new term
+		if err := b.Core.barrier.CreateUpgrade(newTerm); err != nil {
+			b.Backend.Logger().Error("sys: failed to create new upgrade", "term", newTerm, "error", err)
+		}
+
+		// Schedule the destroy of the upgrade path
+		time.AfterFunc(keyRotateGracePeriod, func() {
+			if err := b.Core.barrier.DestroyUpgrade(newTerm); err != nil {
+				b.Backend.Logger().Error("sys: failed to destroy upgrade", "term", newTerm, "error", err)
+			}
+		})
+	}
+	return nil, nil
+}
+
+// wrappingToken returns the response-wrapping token a wrapping request
+// operates on: the given token if any, or the client token otherwise. When
+// the token is given by a third party it is used up here and the returned
+// function revokes it once the request is done, like the client token would
+// be.
+func (b *SystemBackend) wrappingToken(req *logical.Request, data *framework.FieldData) (string, func(), error) {
+	token := data.Get("token").(string)
+	if token == "" {
+		return req.ClientToken, func() {}, nil
+	}
+
+	te, err := b.Core.tokenStore.Lookup(token)
+	if err != nil {
+		return "", nil, err
+	}
+	if te == nil || len(te.Policies) != 1 || te.Policies[0] != cubbyholeResponseWrappingPolicyName {
+		return "", nil, fmt.Errorf("wrapping token is not valid or does not exist")
+	}
+	if err := b.Core.checkControlGroupApproval(te); err != nil {
+		return "", nil, err
+	}
+	if _, err := b.Core.tokenStore.UseToken(te); err != nil {
+		return "", nil, fmt.Errorf("error decrementing wrapping token's use-count: %v", err)
+	}
+
+	return token, func() {
+		if err := b.Core.tokenStore.Revoke(token); err != nil {
+			b.Backend.Logger().Error("sys: failed to revoke wrapping token", "error", err)
+		}
+	}, nil
+}
+
+// readWrappingCubbyhole reads a key of the cubbyhole of a response-wrapping
+// token
+func (b *SystemBackend) readWrappingCubbyhole(token, key string) (*logical.Response, error) {
+	cubbyResp, err := b.Core.router.Route(&logical.Request{
+		Operation:   logical.ReadOperation,
+		Path:        "cubbyhole/" + key,
+		ClientToken: token,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error looking up wrapping information: %v", err)
+	}
+	if cubbyResp == nil || cubbyResp.Data == nil {
+		return logical.ErrorResponse("no information found; wrapping token may be from a previous Vault version"), nil
+	}
+	return cubbyResp, nil
+}
+
+// handleWrappingWrap wraps the given data in a response-wrapping token
+func (b *SystemBackend) handleWrappingWrap(
+	req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	if req.WrapTTL == 0 {
+		return logical.ErrorResponse("endpoint requires response wrapping to be used"), logical.ErrInvalidRequest
+	}
+
+	return &logical.Response{
+		Data: data.Raw,
+	}, nil
+}
+
+// handleWrappingUnwrap returns the response wrapped in a response-wrapping
+// token
+func (b *SystemBackend) handleWrappingUnwrap(
+	req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	token, done, err := b.wrappingToken(req, data)
+	if err != nil {
+		return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+	}
+	defer done()
+
+	cubbyResp, err := b.readWrappingCubbyhole(token, "response")
+	if err != nil || cubbyResp.IsError() {
+		return cubbyResp, err
+	}
+
+	response, ok := cubbyResp.Data["response"].(string)
+	if !ok {
+		return nil, fmt.Errorf("could not decode response inside the cubbyhole")
+	}
+
+	// The response was marshaled when it was wrapped, so it is returned as
+	// is
+	resp := &logical.Response{
+		Data: map[string]interface{}{},
+	}
+	if len(response) == 0 {
+		resp.Data[logical.HTTPStatusCode] = 204
+		resp.Data[logical.HTTPRawBody] = []byte{}
+	} else {
+		resp.Data[logical.HTTPStatusCode] = 200
+		resp.Data[logical.HTTPRawBody] = []byte(response)
+	}
+	resp.Data[logical.HTTPContentType] = "application/json"
+
+	return resp, nil
+}
+
+// handleWrappingLookup returns the creation information of a
+// response-wrapping token without using it up
+func (b *SystemBackend) handleWrappingLookup(
+	req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	token := data.Get("token").(string)
+	if token == "" {
+		return logical.ErrorResponse("missing \"token\" value in input"), logical.ErrInvalidRequest
+	}
+
+	te, err := b.Core.tokenStore.Lookup(token)
+	if err != nil {
+		return nil, err
+	}
+	if te == nil || len(te.Policies) != 1 || te.Policies[0] != cubbyholeResponseWrappingPolicyName {
+		return logical.ErrorResponse("wrapping token is not valid or does not exist"), logical.ErrInvalidRequest
+	}
+
+	cubbyResp, err := b.readWrappingCubbyhole(token, "wrapinfo")
+	if err != nil || cubbyResp.IsError() {
+		return cubbyResp, err
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"creation_ttl":  cubbyResp.Data["creation_ttl"],
+			"creation_time": cubbyResp.Data["creation_time"],
+		},
+	}, nil
+}
+
+// handleWrappingRewrap moves the response wrapped in a response-wrapping
+// token to a new token with the same TTL, revoking the old one
+func (b *SystemBackend) handleWrappingRewrap(
+	req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	token, done, err := b.wrappingToken(req, data)
+	if err != nil {
+		return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+	}
+	defer done()
+
+	cubbyResp, err := b.readWrappingCubbyhole(token, "wrapinfo")
+	if err != nil || cubbyResp.IsError() {
+		return cubbyResp, err
+	}
+	creationTTLRaw, ok := cubbyResp.Data["creation_ttl"].(json.Number)
+	if !ok {
+		return nil, fmt.Errorf("could not decode creation TTL of the wrapping token")
+	}
+	creationTTL, err := creationTTLRaw.Int64()
+	if err != nil {
+		return nil, fmt.Errorf("error reading creation TTL of the wrapping token: %v", err)
+	}
+
+	cubbyResp, err = b.readWrappingCubbyhole(token, "response")
+	if err != nil || cubbyResp.IsError() {
+		return cubbyResp, err
+	}
+	response, ok := cubbyResp.Data["response"].(string)
+	if !ok {
+		return nil, fmt.Errorf("could not decode response inside the cubbyhole")
+	}
+
+	// The response is stored as is in the new token's cubbyhole instead of
+	// being wrapped again
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"response": response,
+		},
+		WrapInfo: &logical.WrapInfo{
+			TTL: time.Duration(creationTTL) * time.Second,
+		},
+	}, nil
+}
+
+// handleControlGroupAuthorize records the authorization of a request subject
+// to a control group by the entity of the client token
+func (b *SystemBackend) handleControlGroupAuthorize(
+	req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	accessor := data.Get("accessor").(string)
+	if accessor == "" {
+		return logical.ErrorResponse("missing \"accessor\" value in input"), logical.ErrInvalidRequest
+	}
+
+	te, err := b.Core.tokenStore.Lookup(req.ClientToken)
+	if err != nil {
+		return nil, err
+	}
+	if te == nil {
+		return nil, logical.ErrPermissionDenied
+	}
+
+	cgReq, err := b.Core.AuthorizeControlGroupRequest(accessor, te.EntityID)
+	if err != nil {
+		return handleError(err)
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"approved": cgReq.Approved(),
+		},
+	}, nil
+}
+
+// handleControlGroupRequest returns the status of a request subject to a
+// control group
+func (b *SystemBackend) handleControlGroupRequest(
+	req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	accessor := data.Get("accessor").(string)
+	if accessor == "" {
+		return logical.ErrorResponse("missing \"accessor\" value in input"), logical.ErrInvalidRequest
+	}
+
+	cgReq, err := b.Core.ControlGroupRequest(accessor)
+	if err != nil {
+		return nil, err
+	}
+	if cgReq == nil {
+		return logical.ErrorResponse("control group request not found"), logical.ErrInvalidRequest
+	}
+
+	authorizations := make([]map[string]interface{}, 0, len(cgReq.Authorizations))
+	for _, authz := range cgReq.Authorizations {
+		authorizations = append(authorizations, map[string]interface{}{
+			"entity_id":   authz.EntityID,
+			"entity_name": authz.EntityName,
+			"factors":     authz.Factors,
+		})
 	}
-	return nil, nil
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"approved":          cgReq.Approved(),
+			"request_path":      cgReq.RequestPath,
+			"request_entity_id": cgReq.RequestEntityID,
+			"authorizations":    authorizations,
+			"creation_time":     cgReq.CreationTime,
+			"expire_time":       cgReq.ExpireTime,
+		},
+	}, nil
 }
 
-// handleKeyStatus returns status information about the backend key
-func (b *SystemBackend) handleKeyStatus(
+// handleInternalUIMountRead returns the secret mount serving a path, so
+// that clients such as the kv commands can adapt to the mount
+func (b *SystemBackend) handleInternalUIMountRead(
 	req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
-	// Get the key info
-	info, err := b.Core.barrier.ActiveKeyInfo()
+	path := strings.TrimPrefix(data.Get("path").(string), "/")
+	ns, err := b.Core.requestNamespace(req)
 	if err != nil {
-		return nil, err
+		return handleError(err)
 	}
 
-	resp := &logical.Response{
+	// The mount is only reported to tokens with access to the path
+	capabilities, err := b.Core.Capabilities(req.ClientToken, ns.Path+path)
+	if err != nil {
+		return handleError(err)
+	}
+	if len(capabilities) == 0 || (len(capabilities) == 1 && capabilities[0] == DenyCapability) {
+		return nil, logical.ErrPermissionDenied
+	}
+
+	b.Core.mountsLock.RLock()
+	defer b.Core.mountsLock.RUnlock()
+
+	entry := b.Core.router.MatchingMountEntry(namespaceRoutePath(ns, path))
+	if entry == nil || entry.Table != mountTableType {
+		return logical.ErrorResponse(fmt.Sprintf("no secret mount found for path %q", path)), logical.ErrInvalidRequest
+	}
+	mountPath, ok := namespaceEntryPath(ns, entry, "")
+	if !ok {
+		return logical.ErrorResponse(fmt.Sprintf("no secret mount found for path %q", path)), logical.ErrInvalidRequest
+	}
+
+	options := make(map[string]string, len(entry.Options))
+	for k, v := range entry.Options {
+		options[k] = v
+	}
+	return &logical.Response{
 		Data: map[string]interface{}{
-			"term":         info.Term,
-			"install_time": info.InstallTime.Format(time.RFC3339Nano),
+			"path":        mountPath,
+			"type":        entry.Type,
+			"description": entry.Description,
+			"options":     options,
 		},
-	}
-	return resp, nil
+	}, nil
 }
 
-// handleRotate is used to trigger a key rotation
-func (b *SystemBackend) handleRotate(
+// handleOpenAPI returns the OpenAPI document of the paths of the mounted
+// backends, which document their paths under their mount in their root help
+func (b *SystemBackend) handleOpenAPI(
 	req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
-	// Rotate to the new term
-	newTerm, err := b.Core.barrier.Rotate()
-	if err != nil {
-		b.Backend.Logger().Error("sys: failed to create new encryption key", "error", err)
-		return handleError(err)
+	type mount struct {
+		path string
+		tag  string
 	}
-	b.Backend.Logger().Info("sys: installed new encryption key")
+	var mounts []mount
 
-	// In HA mode, we need to an upgrade path for the standby instances
-	if b.Core.ha != nil {
-		// Create the upgrade path to the new term
-		if err := b.Core.barrier.CreateUpgrade(newTerm); err != nil {
-			b.Backend.Logger().Error("sys: failed to create new upgrade", "term", newTerm, "error", err)
+	b.Core.mountsLock.RLock()
+	for _, entry := range b.Core.mounts.Entries {
+		tag := "secrets"
+		if entry.Type == "system" {
+			tag = "system"
 		}
+		mounts = append(mounts, mount{path: entry.Path, tag: tag})
+	}
+	b.Core.mountsLock.RUnlock()
 
-		// Schedule the destroy of the upgrade path
-		time.AfterFunc(keyRotateGracePeriod, func() {
-			if err := b.Core.barrier.DestroyUpgrade(newTerm); err != nil {
-				b.Backend.Logger().Error("sys: failed to destroy upgrade", "term", newTerm, "error", err)
-			}
+	b.Core.authLock.RLock()
+	for _, entry := range b.Core.auth.Entries {
+		mounts = append(mounts, mount{path: credentialRoutePrefix + entry.Path, tag: "auth"})
+	}
+	b.Core.authLock.RUnlock()
+
+	doc := framework.NewOASDocument()
+	for _, m := range mounts {
+		resp, err := b.Core.router.Route(&logical.Request{
+			Operation: logical.HelpOperation,
+			Path:      m.path,
 		})
+		if err != nil || resp == nil {
+			b.Backend.Logger().Warn("sys: failed to document the paths of a mount", "path", m.path, "error", err)
+			continue
+		}
+
+		// The document of the plugins is decoded from JSON, so the documents
+		// are all round-tripped through JSON
+		docRaw, ok := resp.Data["openapi"]
+		if !ok {
+			continue
+		}
+		docJSON, err := json.Marshal(docRaw)
+		if err != nil {
+			return nil, err
+		}
+		var backendDoc framework.OASDocument
+		if err := json.Unmarshal(docJSON, &backendDoc); err != nil {
+			b.Backend.Logger().Warn("sys: invalid document of the paths of a mount", "path", m.path, "error", err)
+			continue
+		}
+
+		for path, item := range backendDoc.Paths {
+			for _, op := range []*framework.OASOperation{item.Get, item.Post, item.Delete} {
+				if op != nil {
+					op.Tags = []string{m.tag}
+				}
+			}
+			doc.Paths[path] = item
+		}
 	}
-	return nil, nil
+
+	buf, err := json.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			logical.HTTPStatusCode:  200,
+			logical.HTTPRawBody:     buf,
+			logical.HTTPContentType: "application/json",
+		},
+	}, nil
 }
 
 func sanitizeMountPath(path string) string {
@@ -1547,26 +3221,95 @@ and max_lease_ttl.`,
 		`The max lease TTL for this mount.`,
 	},
 
+	"tune_description": {
+		`The human-friendly description of this mount.`,
+	},
+
+	"tune_audit_non_hmac_request_keys": {
+		`Comma-separated list of keys of the request data whose values are not HMAC'd by audit backends.`,
+	},
+
+	"tune_audit_non_hmac_response_keys": {
+		`Comma-separated list of keys of the response data whose values are not HMAC'd by audit backends.`,
+	},
+
+	"tune_periodic_interval": {
+		`The minimum interval between the invocations of the periodic function of the backend, such as "24h". The periodic function runs with the rollbacks, every minute, if zero.`,
+	},
+
 	"remount": {
 		"Move the mount point of an already-mounted backend.",
 		`
 This path responds to the following HTTP methods.
 
     POST /sys/remount
-        Changes the mount point of an already-mounted backend.
+        Starts changing the mount point of an already-mounted backend,
+        moving its leases to the new mount point. Returns the ID of the
+        migration to look its status up with.
+		`,
+	},
+
+	"remount_status": {
+		"Check the status of a remount.",
+		`
+This path responds to the following HTTP methods.
+
+    GET /sys/remount/status/<migration_id>
+        Returns the source and target mount points of the remount and
+        whether it is in progress, succeeded or failed.
 		`,
 	},
 
+	"remount_migration_id": {
+		`The ID of the migration returned when starting the remount.`,
+	},
+
+	"tune_lockout_threshold": {
+		`The number of failed logins after which a user is locked out of the auth mount. Zero disables the lockout.`,
+	},
+
+	"tune_lockout_duration": {
+		`The amount of time a user is locked out for. Defaults to 15 minutes.`,
+	},
+
+	"tune_lockout_counter_reset": {
+		`The amount of time after the last failed login after which the failed login count is reset. Defaults to 15 minutes.`,
+	},
+
 	"auth_tune": {
 		"Tune the configuration parameters for an auth path.",
-		`Read and write the 'default-lease-ttl' and 'max-lease-ttl' values of
-the auth path.`,
+		`Read and write the 'default-lease-ttl', 'max-lease-ttl' and 'description'
+values of the auth path, the keys of the request and response data the
+audit backends log without HMACing them, as well as its login lockout settings: after
+'lockout-threshold' failed logins within 'lockout-counter-reset' of each
+other, a user is locked out of the auth path for 'lockout-duration'.`,
+	},
+
+	"auth_convert": {
+		"Convert an auth path to a different backend type.",
+		`
+This path responds to the following HTTP methods.
+
+    POST /sys/auth/<path>/convert
+        Converts the backend mounted at the path to the given type,
+        keeping its data and the tokens it issued.
+
+Only the conversion of app-id backends to the approle type is supported.
+The app-id mappings are migrated to roles and SecretIDs as clients log in:
+each app ID becomes a role named after it with the app ID as its RoleID,
+and each user ID mapped to it becomes one of its SecretIDs.
+		`,
+	},
+
+	"auth_convert_type": {
+		`The type to convert the backend to. Only "approle" is supported.`,
 	},
 
 	"mount_tune": {
 		"Tune backend configuration parameters for this mount.",
-		`Read and write the 'default-lease-ttl' and 'max-lease-ttl' values of
-the mount.`,
+		`Read and write the 'default-lease-ttl', 'max-lease-ttl' and 'description'
+values of the mount, and the keys of the request and response data the audit
+backends log without HMACing them.`,
 	},
 
 	"renew": {
@@ -1579,6 +3322,28 @@ lease and to prevent an automatic revocation.
 		`,
 	},
 
+	"leases": {
+		"View the metadata of a lease",
+		`
+Returns the issue time, expiration time, last renewal time, remaining TTL
+and renewability of the given lease.
+		`,
+	},
+
+	"leases-list": {
+		"List the leases under a prefix",
+		`
+Lists the lease IDs directly under the given prefix, such as the mount path
+or the path of a role. Keys ending with a slash are prefixes that can be
+listed in turn.
+		`,
+	},
+
+	"leases-list-prefix": {
+		`The path to list leases under. Example: "aws/creds/deploy"`,
+		"",
+	},
+
 	"lease_id": {
 		"The lease identifier to renew. This is included with a lease.",
 		"",
@@ -1675,6 +3440,11 @@ Example: you might have an OAuth backend for GitHub, and one for Google Apps.
 		"",
 	},
 
+	"auth_config": {
+		`Configuration for this credential backend, such as plugin_name for
+plugin backends.`,
+	},
+
 	"policy-list": {
 		`List the configured access control policies.`,
 		`
@@ -1707,6 +3477,41 @@ or delete a policy.
 		"",
 	},
 
+	"namespaces-list": {
+		`List the namespaces.`,
+		`
+This path responds to the following HTTP methods.
+
+    LIST /
+        List the namespaces directly beneath the namespace of the request.
+
+    GET /<path>
+        Retrieve the ID of the namespace with the given path.
+
+    PUT /<path>
+        Create a namespace.
+
+    DELETE /<path>
+        Delete the namespace with the given path.
+		`,
+	},
+
+	"namespaces": {
+		`Read, Create, or Delete a namespace.`,
+		`
+Namespaces are isolated tenants with their own mounts, auth mounts,
+policies and tokens. Requests are made in a namespace with the
+X-Vault-Namespace header, and the paths of the namespace are relative to it.
+A namespace can only be deleted once its child namespaces, mounts and auth
+mounts have been removed.
+		`,
+	},
+
+	"namespace-path": {
+		`The path of the namespace, relative to the namespace of the request. Example: "team-a/dev"`,
+		"",
+	},
+
 	"policy-rules": {
 		`The rules of the policy. Either given in HCL or JSON format.`,
 		"",
@@ -1733,6 +3538,176 @@ This path responds to the following HTTP methods.
 		`,
 	},
 
+	"config/cors": {
+		"Configures the Cross-Origin Resource Sharing of the API.",
+		`
+This path responds to the following HTTP methods.
+
+    GET /
+        Read the CORS configuration.
+
+    PUT /
+        Enable CORS for the given origins and request headers.
+
+    DELETE /
+        Disable CORS.
+		`,
+	},
+
+	"cors-allowed-origins": {
+		`Comma-separated list of the origins allowed to make cross-origin
+requests, or '*' to allow all origins.`,
+		"",
+	},
+
+	"cors-allowed-headers": {
+		`Comma-separated list of the request headers allowed in cross-origin
+requests, in addition to the standard headers used by Vault.`,
+		"",
+	},
+
+	"audited-headers": {
+		"List the request headers recorded in audit entries.",
+		`
+This path responds to the following HTTP methods.
+
+    GET /
+        List the request headers recorded in audit entries.
+
+    GET /<header>
+        Read the configuration of a request header recorded in audit entries.
+
+    PUT /<header>
+        Record the given request header in audit entries.
+
+    DELETE /<header>
+        Stop recording the given request header in audit entries.
+		`,
+	},
+
+	"plugin-catalog": {
+		"Configures the plugins that can be mounted.",
+		`
+Plugins are backends run by Vault in separate processes. The binaries of
+plugins must be in the plugin directory set in the server configuration, and
+are checked against the SHA256 checksum registered here before being run.
+Plugins are mounted with the "plugin" backend type and their name as the
+plugin_name config.
+		`,
+	},
+
+	"plugin-catalog_name": {
+		`The name of the plugin.`,
+		"",
+	},
+
+	"plugin-catalog_sha256": {
+		`The hex-encoded SHA256 checksum of the plugin binary.`,
+		"",
+	},
+
+	"plugin-catalog_command": {
+		`The plugin binary, relative to the plugin directory.`,
+		"",
+	},
+
+	"plugin-catalog_args": {
+		`Comma-separated arguments given to the plugin binary.`,
+		"",
+	},
+
+	"seal_wrap": {
+		`Whether the values written by the backend are also encrypted by the seal. This requires an auto seal.`,
+		"",
+	},
+
+	"plugin-reload": {
+		"Reloads the backends of plugin mounts.",
+		`
+Restarts the plugin backends of all the mounts of a plugin, or of the given
+mounts, without unmounting them: their leases are kept. This is used to run
+a new plugin binary after updating it in the catalog. Mounts whose plugin
+failed to start are mounted again.
+		`,
+	},
+
+	"plugin-reload_plugin": {
+		`The name of the plugin whose mounts are reloaded.`,
+		"",
+	},
+
+	"plugin-reload_mounts": {
+		`Comma-separated paths of the mounts to reload. Auth mounts start with "auth/".`,
+		"",
+	},
+
+	"quotas": {
+		"Configures resource quotas.",
+		`
+Rate limit quotas ("rate-limit") limit the number of requests per second to a
+path, and lease count quotas ("lease-count") limit the number of leases under
+a path. The path must be within a mount. Quotas with a namespace but no path
+apply to the requests in the namespace and the namespaces beneath it that no
+quota with a path matches, and quotas with neither apply to all requests not
+matched by another quota of the same type. Requests over a quota are
+rejected with a 429 status code.
+		`,
+	},
+
+	"quota_type": {
+		`The type of the quota, either "rate-limit" or "lease-count".`,
+		"",
+	},
+
+	"quota_name": {
+		`The name of the quota.`,
+		"",
+	},
+
+	"quota_path": {
+		`The path the quota applies to, e.g. a mount path such as "secret/". Defaults to all paths.`,
+		"",
+	},
+
+	"quota_namespace": {
+		`The path of the namespace the quota applies to, e.g. "team-a/". The path of the quota is relative to it. Defaults to the root namespace.`,
+		"",
+	},
+
+	"quota_rate": {
+		`The number of requests per second allowed by a rate limit quota.`,
+		"",
+	},
+
+	"quota_burst": {
+		`The number of requests allowed in a burst by a rate limit quota. Defaults to the rate.`,
+		"",
+	},
+
+	"quota_max_leases": {
+		`The maximum number of leases allowed by a lease count quota.`,
+		"",
+	},
+
+	"audited-headers-name": {
+		"Configures a request header recorded in audit entries.",
+		`
+Request headers listed here are recorded in the request section of audit
+entries, optionally HMAC'd with the salt of each audit backend. Header names
+are case-insensitive.
+		`,
+	},
+
+	"audited-headers-header": {
+		`The name of the request header. Example: "X-Forwarded-For"`,
+		"",
+	},
+
+	"audited-headers-hmac": {
+		`Whether the values of the header are HMAC'd in audit entries. Defaults to false.`,
+		"",
+	},
+
 	"audit_path": {
 		`The name of the backend. Cannot be delimited. Example: "mysql"`,
 		"",
@@ -1798,4 +3773,71 @@ Enable a new audit backend or disable an existing backend.
 		`When there is no access to the token, token accessor can be used to fetch the token's capabilities
 		on a given path.`,
 	},
+
+	"wrap": {
+		"Response-wraps an arbitrary JSON object.",
+		`Round trips the given input data into a response-wrapped token.`,
+	},
+
+	"unwrap": {
+		"Unwraps a response-wrapped token.",
+		`Unwraps a response-wrapped token. Unlike simply reading from
+		cubbyhole/response, this provides additional validation on the token,
+		and rather than a JSON-escaped string, the returned response is the
+		exact same as the contained wrapped response.`,
+	},
+
+	"wraplookup": {
+		"Looks up the properties of a response-wrapped token.",
+		`Returns the creation TTL and creation time of a response-wrapped
+		token without using it.`,
+	},
+
+	"rewrap": {
+		"Rotates a response-wrapped token.",
+		`Rotates a response-wrapped token; the output is a new token with the
+		same response wrapped inside and the same creation TTL. The original
+		token is revoked.`,
+	},
+
+	"control_group_accessor": {
+		"The accessor of the wrapping token of the request.",
+		"",
+	},
+
+	"control_group_authorize": {
+		"Authorizes a request subject to a control group.",
+		`Records the authorization of a request subject to a control group by
+		the entity of the client token, which must be a member of one of the
+		authorizing groups. Once every factor of the control group is
+		satisfied, the response can be unwrapped by the requester.`,
+	},
+
+	"control_group_request": {
+		"Looks up the status of a request subject to a control group.",
+		`Returns the path of a request subject to a control group, the
+		authorizations it received and whether it is approved.`,
+	},
+
+	"internal_ui_mount": {
+		"Looks up the secret mount serving a path.",
+		`Returns the path, type, description and options of the secret mount
+		serving the given path, to tokens that have any capability on the
+		path. Clients use it to adapt to the mount, as the kv commands do for
+		versioned mounts.`,
+	},
+
+	"internal_ui_mount_path": {
+		"The path to look up the mount of.",
+		"",
+	},
+
+	"openapi": {
+		"Generates an OpenAPI document of the paths of the mounted backends.",
+		`Returns an OpenAPI v3 document describing the paths of the mounted
+		backends, with their operations, their parameters and their help, so
+		that client libraries and documentation can be generated from it.
+		The paths whose patterns can't be expressed in OpenAPI aren't
+		documented.`,
+	},
 }
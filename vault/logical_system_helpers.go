@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"strings"
 	"time"
+
+	"github.com/hashicorp/vault/helper/strutil"
 )
 
 // tuneMount is used to set config on a mount point
@@ -81,3 +83,147 @@ func (b *SystemBackend) tuneMountTTLs(path string, meConfig *MountConfig, newDef
 
 	return nil
 }
+
+// tuneMountLockout is used to set the login lockout settings of an auth mount
+func (b *SystemBackend) tuneMountLockout(path string, meConfig *MountConfig, newThreshold *int, newDuration, newCounterReset *time.Duration) error {
+	if newDuration != nil && *newDuration < 0 {
+		return fmt.Errorf("lockout duration cannot be negative")
+	}
+	if newCounterReset != nil && *newCounterReset < 0 {
+		return fmt.Errorf("lockout counter reset cannot be negative")
+	}
+
+	origThreshold := meConfig.LockoutThreshold
+	origDuration := meConfig.LockoutDuration
+	origCounterReset := meConfig.LockoutCounterReset
+
+	if newThreshold != nil {
+		meConfig.LockoutThreshold = *newThreshold
+	}
+	if newDuration != nil {
+		meConfig.LockoutDuration = *newDuration
+	}
+	if newCounterReset != nil {
+		meConfig.LockoutCounterReset = *newCounterReset
+	}
+
+	if err := b.Core.persistAuth(b.Core.auth); err != nil {
+		meConfig.LockoutThreshold = origThreshold
+		meConfig.LockoutDuration = origDuration
+		meConfig.LockoutCounterReset = origCounterReset
+		return fmt.Errorf("failed to update mount table, rolling back lockout changes")
+	}
+
+	if b.Core.logger.IsInfo() {
+		b.Core.logger.Info("core: mount tuning successful", "path", path)
+	}
+
+	return nil
+}
+
+// tuneMountDescription is used to set the description of a mount point
+func (b *SystemBackend) tuneMountDescription(path string, me *MountEntry, newDescription string) error {
+	if newDescription == me.Description {
+		return nil
+	}
+
+	origDescription := me.Description
+	me.Description = newDescription
+
+	// Update the mount table
+	var err error
+	switch {
+	case strings.HasPrefix(path, "auth/"):
+		err = b.Core.persistAuth(b.Core.auth)
+	default:
+		err = b.Core.persistMounts(b.Core.mounts)
+	}
+	if err != nil {
+		me.Description = origDescription
+		return fmt.Errorf("failed to update mount table, rolling back description change")
+	}
+
+	if b.Core.logger.IsInfo() {
+		b.Core.logger.Info("core: mount tuning successful", "path", path)
+	}
+
+	return nil
+}
+
+// tuneMountAuditKeys is used to set the keys of the request and response data
+// of a mount point that are not HMAC'd in audit entries
+func (b *SystemBackend) tuneMountAuditKeys(path string, meConfig *MountConfig, newReqKeys, newRespKeys *[]string) error {
+	origReqKeys := meConfig.AuditNonHMACRequestKeys
+	origRespKeys := meConfig.AuditNonHMACResponseKeys
+
+	if newReqKeys != nil {
+		meConfig.AuditNonHMACRequestKeys = *newReqKeys
+	}
+	if newRespKeys != nil {
+		meConfig.AuditNonHMACResponseKeys = *newRespKeys
+	}
+
+	// Update the mount table
+	var err error
+	switch {
+	case strings.HasPrefix(path, "auth/"):
+		err = b.Core.persistAuth(b.Core.auth)
+	default:
+		err = b.Core.persistMounts(b.Core.mounts)
+	}
+	if err != nil {
+		meConfig.AuditNonHMACRequestKeys = origReqKeys
+		meConfig.AuditNonHMACResponseKeys = origRespKeys
+		return fmt.Errorf("failed to update mount table, rolling back audit changes")
+	}
+
+	if b.Core.logger.IsInfo() {
+		b.Core.logger.Info("core: mount tuning successful", "path", path)
+	}
+
+	return nil
+}
+
+// tuneMountPeriodicInterval is used to set the minimum interval between the
+// invocations of the periodic function of the backend of a mount point
+func (b *SystemBackend) tuneMountPeriodicInterval(path string, meConfig *MountConfig, newInterval time.Duration) error {
+	if newInterval == meConfig.PeriodicInterval {
+		return nil
+	}
+
+	origInterval := meConfig.PeriodicInterval
+	meConfig.PeriodicInterval = newInterval
+
+	// Update the mount table
+	var err error
+	switch {
+	case strings.HasPrefix(path, "auth/"):
+		err = b.Core.persistAuth(b.Core.auth)
+	default:
+		err = b.Core.persistMounts(b.Core.mounts)
+	}
+	if err != nil {
+		meConfig.PeriodicInterval = origInterval
+		return fmt.Errorf("failed to update mount table, rolling back periodic interval change")
+	}
+
+	if b.Core.logger.IsInfo() {
+		b.Core.logger.Info("core: mount tuning successful", "path", path)
+	}
+
+	return nil
+}
+
+// parseAuditKeys parses a comma-separated list of data keys, dropping empty
+// and duplicate keys. Keys are case-sensitive.
+func parseAuditKeys(input string) []string {
+	var keys []string
+	for _, key := range strings.Split(input, ",") {
+		key = strings.TrimSpace(key)
+		if key == "" || strutil.StrListContains(keys, key) {
+			continue
+		}
+		keys = append(keys, key)
+	}
+	return keys
+}
@@ -2,6 +2,7 @@ package vault
 
 import (
 	"crypto/sha256"
+	"encoding/json"
 	"reflect"
 	"strings"
 	"testing"
@@ -11,6 +12,7 @@ import (
 	"github.com/hashicorp/vault/audit"
 	"github.com/hashicorp/vault/helper/salt"
 	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
 )
 
 func TestSystemBackend_RootPaths(t *testing.T) {
@@ -18,10 +20,16 @@ func TestSystemBackend_RootPaths(t *testing.T) {
 		"auth/*",
 		"remount",
 		"revoke-prefix/*",
+		"revoke-force/*",
+		"leases/lookup/*",
 		"audit",
 		"audit/*",
+		"config/auditing/*",
+		"config/cors",
 		"raw/*",
 		"rotate",
+		"plugins/catalog/*",
+		"plugins/reload/backend",
 	}
 
 	b := testSystemBackend(t)
@@ -66,6 +74,14 @@ func TestSystemBackend_mounts(t *testing.T) {
 				"max_lease_ttl":     resp.Data["cubbyhole/"].(map[string]interface{})["config"].(map[string]interface{})["max_lease_ttl"].(int64),
 			},
 		},
+		"identity/": map[string]interface{}{
+			"description": "identity store",
+			"type":        "identity",
+			"config": map[string]interface{}{
+				"default_lease_ttl": resp.Data["identity/"].(map[string]interface{})["config"].(map[string]interface{})["default_lease_ttl"].(int64),
+				"max_lease_ttl":     resp.Data["identity/"].(map[string]interface{})["config"].(map[string]interface{})["max_lease_ttl"].(int64),
+			},
+		},
 	}
 	if !reflect.DeepEqual(resp.Data, exp) {
 		t.Fatalf("Got:\n%#v\nExpected:\n%#v", resp.Data, exp)
@@ -257,6 +273,48 @@ func TestSystemBackend_remount(t *testing.T) {
 	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
+	migrationID, ok := resp.Data["migration_id"].(string)
+	if !ok || migrationID == "" {
+		t.Fatalf("bad: %v", resp)
+	}
+
+	var info map[string]interface{}
+	for i := 0; i < 50; i++ {
+		req = logical.TestRequest(t, logical.ReadOperation, "remount/status/"+migrationID)
+		resp, err = b.HandleRequest(req)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if resp.Data["migration_id"] != migrationID {
+			t.Fatalf("bad: %v", resp)
+		}
+		info = resp.Data["migration_info"].(map[string]interface{})
+		if info["status"] != MountMigrationInProgress {
+			break
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	if info["status"] != MountMigrationSuccess || info["source_mount"] != "secret/" || info["target_mount"] != "foo/" {
+		t.Fatalf("bad: %v", info)
+	}
+	startTime, err := time.Parse(time.RFC3339Nano, info["start_time"].(string))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	endTime, err := time.Parse(time.RFC3339Nano, info["end_time"].(string))
+	if err != nil || endTime.Before(startTime) {
+		t.Fatalf("bad: %v %v", info, err)
+	}
+}
+
+func TestSystemBackend_remount_status_unknown(t *testing.T) {
+	b := testSystemBackend(t)
+
+	req := logical.TestRequest(t, logical.ReadOperation, "remount/status/unknown")
+	resp, err := b.HandleRequest(req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
 	if resp != nil {
 		t.Fatalf("bad: %v", resp)
 	}
@@ -293,6 +351,110 @@ func TestSystemBackend_remount_system(t *testing.T) {
 	}
 }
 
+func TestSystemBackend_tunePeriodicInterval(t *testing.T) {
+	b := testSystemBackend(t)
+
+	req := logical.TestRequest(t, logical.UpdateOperation, "mounts/secret/tune")
+	req.Data["periodic_interval"] = "24h"
+	resp, err := b.HandleRequest(req)
+	if err != nil {
+		t.Fatalf("err: %v %#v", err, resp)
+	}
+
+	req = logical.TestRequest(t, logical.ReadOperation, "mounts/secret/tune")
+	resp, err = b.HandleRequest(req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if resp.Data["periodic_interval"] != 86400 {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+
+	req = logical.TestRequest(t, logical.UpdateOperation, "mounts/secret/tune")
+	req.Data["periodic_interval"] = "-1h"
+	resp, err = b.HandleRequest(req)
+	if err != logical.ErrInvalidRequest {
+		t.Fatalf("err: %v %#v", err, resp)
+	}
+}
+
+func TestSystemBackend_leases(t *testing.T) {
+	core, b, root := testCoreSystemBackend(t)
+
+	// Create a key with a lease
+	req := logical.TestRequest(t, logical.UpdateOperation, "secret/foo")
+	req.Data["foo"] = "bar"
+	req.Data["ttl"] = "180s"
+	req.ClientToken = root
+	if _, err := core.HandleRequest(req); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Read a key with a LeaseID
+	req = logical.TestRequest(t, logical.ReadOperation, "secret/foo")
+	req.ClientToken = root
+	resp, err := core.HandleRequest(req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if resp == nil || resp.Secret == nil || resp.Secret.LeaseID == "" {
+		t.Fatalf("bad: %#v", resp)
+	}
+	leaseID := resp.Secret.LeaseID
+
+	// Lookup the lease
+	req = logical.TestRequest(t, logical.UpdateOperation, "leases/lookup")
+	req.Data["lease_id"] = leaseID
+	resp, err = b.HandleRequest(req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if resp.Data["id"] != leaseID || resp.Data["renewable"] != true {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+	if resp.Data["last_renewal"] != nil || resp.Data["expire_time"] == nil {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+	if ttl := resp.Data["ttl"].(int64); ttl <= 0 || ttl > 180 {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+
+	// Lookup an unknown lease
+	req = logical.TestRequest(t, logical.UpdateOperation, "leases/lookup")
+	req.Data["lease_id"] = "secret/foo/unknown"
+	resp, err = b.HandleRequest(req)
+	if err != logical.ErrInvalidRequest || !resp.IsError() {
+		t.Fatalf("bad: %#v %v", resp, err)
+	}
+
+	// List the leases of the mount, then of the path
+	req = logical.TestRequest(t, logical.ListOperation, "leases/lookup/secret/")
+	resp, err = b.HandleRequest(req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if keys := resp.Data["keys"].([]string); !reflect.DeepEqual(keys, []string{"foo/"}) {
+		t.Fatalf("bad: %#v", keys)
+	}
+
+	req = logical.TestRequest(t, logical.ListOperation, "leases/lookup/secret/foo")
+	resp, err = b.HandleRequest(req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	expected := []string{strings.TrimPrefix(leaseID, "secret/foo/")}
+	if keys := resp.Data["keys"].([]string); !reflect.DeepEqual(keys, expected) {
+		t.Fatalf("bad: %#v", keys)
+	}
+
+	// Listing an unknown prefix is an error
+	req = logical.TestRequest(t, logical.ListOperation, "leases/lookup/unknown/")
+	resp, err = b.HandleRequest(req)
+	if err != logical.ErrInvalidRequest || !resp.IsError() {
+		t.Fatalf("bad: %#v %v", resp, err)
+	}
+}
+
 func TestSystemBackend_renew(t *testing.T) {
 	core, b, root := testCoreSystemBackend(t)
 
@@ -786,6 +948,66 @@ func TestSystemBackend_enableAudit(t *testing.T) {
 	}
 }
 
+func TestSystemBackend_auditedHeaders(t *testing.T) {
+	b := testSystemBackend(t)
+
+	req := logical.TestRequest(t, logical.UpdateOperation, "config/auditing/request-headers/X-Forwarded-For")
+	req.Data["hmac"] = true
+	resp, err := b.HandleRequest(req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if resp != nil {
+		t.Fatalf("bad: %#v", resp)
+	}
+
+	req = logical.TestRequest(t, logical.UpdateOperation, "config/auditing/request-headers/X-Request-Id")
+	if _, err := b.HandleRequest(req); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	req = logical.TestRequest(t, logical.ReadOperation, "config/auditing/request-headers/X-FORWARDED-FOR")
+	resp, err = b.HandleRequest(req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	expected := map[string]interface{}{
+		"x-forwarded-for": &auditedHeaderSettings{HMAC: true},
+	}
+	if !reflect.DeepEqual(resp.Data, expected) {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+
+	req = logical.TestRequest(t, logical.DeleteOperation, "config/auditing/request-headers/X-Forwarded-For")
+	if _, err := b.HandleRequest(req); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	req = logical.TestRequest(t, logical.ReadOperation, "config/auditing/request-headers")
+	resp, err = b.HandleRequest(req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	expected = map[string]interface{}{
+		"headers": map[string]interface{}{
+			"x-request-id": &auditedHeaderSettings{HMAC: false},
+		},
+	}
+	if !reflect.DeepEqual(resp.Data, expected) {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+
+	// Reading a header that isn't audited is an error
+	req = logical.TestRequest(t, logical.ReadOperation, "config/auditing/request-headers/X-Forwarded-For")
+	resp, err = b.HandleRequest(req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !resp.IsError() {
+		t.Fatalf("bad: %#v", resp)
+	}
+}
+
 func TestSystemBackend_auditHash(t *testing.T) {
 	c, b, _ := testCoreSystemBackend(t)
 	c.auditBackends["noop"] = func(config *audit.BackendConfig) (audit.Backend, error) {
@@ -1091,3 +1313,80 @@ func testCoreSystemBackend(t *testing.T) (*Core, logical.Backend, string) {
 	}
 	return c, NewSystemBackend(c, bc), root
 }
+
+func TestSystemBackend_internalUIMount(t *testing.T) {
+	c, _, root := TestCoreUnsealed(t)
+
+	req := logical.TestRequest(t, logical.UpdateOperation, "sys/policy/foo")
+	req.ClientToken = root
+	req.Data["rules"] = `path "secret/foo" { capabilities = ["read"] }`
+	if _, err := c.HandleRequest(req); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	testCoreMakeToken(t, c, root, "client", "", []string{"foo"})
+
+	req = logical.TestRequest(t, logical.ReadOperation, "sys/internal/ui/mounts/secret/foo")
+	req.ClientToken = "client"
+	resp, err := c.HandleRequest(req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	expected := map[string]interface{}{
+		"path":        "secret/",
+		"type":        "generic",
+		"description": "generic secret storage",
+		"options":     map[string]string{},
+	}
+	if !reflect.DeepEqual(resp.Data, expected) {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+
+	// The mount isn't reported for paths the token has no access to
+	req = logical.TestRequest(t, logical.ReadOperation, "sys/internal/ui/mounts/secret/bar")
+	req.ClientToken = "client"
+	if _, err := c.HandleRequest(req); err == nil || !strings.Contains(err.Error(), logical.ErrPermissionDenied.Error()) {
+		t.Fatalf("expected permission denied, got: %v", err)
+	}
+}
+
+func TestSystemBackend_OpenAPI(t *testing.T) {
+	b := testSystemBackend(t)
+	req := logical.TestRequest(t, logical.ReadOperation, "internal/specs/openapi")
+	resp, err := b.HandleRequest(req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if resp.Data[logical.HTTPContentType] != "application/json" {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+
+	var doc framework.OASDocument
+	if err := json.Unmarshal(resp.Data[logical.HTTPRawBody].([]byte), &doc); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if doc.Version != framework.OASVersion {
+		t.Fatalf("bad: %#v", doc)
+	}
+
+	// The paths of the system backend, the generic backend and the token
+	// store are documented under their mount
+	mounts := doc.Paths["/sys/mounts"]
+	if mounts == nil || mounts.Get == nil || mounts.Get.Tags[0] != "system" {
+		t.Fatalf("bad: %#v", mounts)
+	}
+	secret := doc.Paths["/secret/{path}"]
+	if secret == nil || secret.Get == nil || secret.Post == nil || secret.Delete == nil || secret.Get.Tags[0] != "secrets" {
+		t.Fatalf("bad: %#v", secret)
+	}
+	create := doc.Paths["/auth/token/create"]
+	if create == nil || create.Post == nil || create.Post.Tags[0] != "auth" {
+		t.Fatalf("bad: %#v", create)
+	}
+	renew := doc.Paths["/auth/token/renew-accessor/{urlaccessor}"]
+	if renew == nil || renew.Post == nil || renew.Post.RequestBody.Content["application/json"].Schema.Properties["increment"] == nil {
+		t.Fatalf("bad: %#v", renew)
+	}
+	if doc.Paths["/sys/raw/{path}"] == nil || !doc.Paths["/sys/raw/{path}"].Sudo {
+		t.Fatalf("bad: %#v", doc.Paths["/sys/raw/{path}"])
+	}
+}
@@ -0,0 +1,165 @@
+package vault
+
+import (
+	"sync"
+	"time"
+
+	"github.com/hashicorp/vault/logical"
+)
+
+const (
+	// defaultLockoutDuration is how long a user is locked out for if the
+	// mount does not configure a duration
+	defaultLockoutDuration = 15 * time.Minute
+
+	// defaultLockoutCounterReset is how long after the last failed login
+	// the failure count is reset if the mount does not configure it
+	defaultLockoutCounterReset = 15 * time.Minute
+
+	// lockoutPruneInterval is how often the entries which no longer count
+	// towards a lockout are removed
+	lockoutPruneInterval = time.Minute
+)
+
+// lockoutEntry tracks the failed logins of a single user of a mount
+type lockoutEntry struct {
+	failedCount int
+	lastFailed  time.Time
+	lockedUntil time.Time
+
+	// expires is when the entry no longer counts towards a lockout: the end
+	// of the lockout, or the reset of the failure count
+	expires time.Time
+}
+
+// loginLockout tracks failed logins per mount and user. State is kept in
+// memory only, so lockouts do not survive a restart or leader election.
+// Logins are unauthenticated, so expired entries are pruned to keep failed
+// logins for arbitrary usernames from growing the map without bound.
+type loginLockout struct {
+	l         sync.Mutex
+	entries   map[string]*lockoutEntry
+	lastPrune time.Time
+}
+
+func newLoginLockout() *loginLockout {
+	return &loginLockout{
+		entries: make(map[string]*lockoutEntry),
+	}
+}
+
+// lockedOut returns whether the user identified by key is currently
+// locked out
+func (l *loginLockout) lockedOut(key string) bool {
+	l.l.Lock()
+	defer l.l.Unlock()
+
+	l.prune(time.Now())
+
+	entry, ok := l.entries[key]
+	if !ok || entry.lockedUntil.IsZero() {
+		return false
+	}
+	if time.Now().Before(entry.lockedUntil) {
+		return true
+	}
+
+	// The lockout has expired, start over
+	delete(l.entries, key)
+	return false
+}
+
+// recordFailure records a failed login for the user identified by key,
+// locking the user out once the threshold of the config is reached. It
+// returns whether the user got locked out.
+func (l *loginLockout) recordFailure(key string, config MountConfig) bool {
+	l.l.Lock()
+	defer l.l.Unlock()
+
+	counterReset := config.LockoutCounterReset
+	if counterReset == 0 {
+		counterReset = defaultLockoutCounterReset
+	}
+	duration := config.LockoutDuration
+	if duration == 0 {
+		duration = defaultLockoutDuration
+	}
+
+	now := time.Now()
+	l.prune(now)
+
+	entry, ok := l.entries[key]
+	if !ok || now.Sub(entry.lastFailed) > counterReset {
+		entry = &lockoutEntry{}
+		l.entries[key] = entry
+	}
+
+	entry.failedCount++
+	entry.lastFailed = now
+	entry.expires = now.Add(counterReset)
+	if entry.failedCount >= config.LockoutThreshold {
+		entry.lockedUntil = now.Add(duration)
+		if entry.lockedUntil.After(entry.expires) {
+			entry.expires = entry.lockedUntil
+		}
+		return true
+	}
+	return false
+}
+
+// prune removes the expired entries, at most once per prune interval. The
+// lock must be held.
+func (l *loginLockout) prune(now time.Time) {
+	if now.Sub(l.lastPrune) < lockoutPruneInterval {
+		return
+	}
+	l.lastPrune = now
+
+	for key, entry := range l.entries {
+		if now.After(entry.expires) {
+			delete(l.entries, key)
+		}
+	}
+}
+
+// reset clears the failed logins of the user identified by key
+func (l *loginLockout) reset(key string) {
+	l.l.Lock()
+	defer l.l.Unlock()
+
+	delete(l.entries, key)
+}
+
+// loginLockoutKey returns the key used to track failed logins for the
+// given login request along with the lockout config of its mount. The key
+// is empty if the mount has no lockout configured or if the backend can't
+// tell which user the login is for.
+func (c *Core) loginLockoutKey(req *logical.Request) (string, MountConfig) {
+	me := c.router.MatchingMountEntry(req.Path)
+	if me == nil {
+		return "", MountConfig{}
+	}
+
+	c.authLock.RLock()
+	config := me.Config
+	uuid := me.UUID
+	c.authLock.RUnlock()
+
+	if config.LockoutThreshold <= 0 {
+		return "", MountConfig{}
+	}
+
+	// Ask the backend which alias the login is for
+	lookahead := &logical.Request{
+		Operation:  logical.AliasLookaheadOperation,
+		Path:       req.Path,
+		Data:       req.Data,
+		Connection: req.Connection,
+	}
+	resp, err := c.router.Route(lookahead)
+	if err != nil || resp == nil || resp.Auth == nil || resp.Auth.Alias == nil || resp.Auth.Alias.Name == "" {
+		return "", MountConfig{}
+	}
+
+	return uuid + "/" + resp.Auth.Alias.Name, config
+}
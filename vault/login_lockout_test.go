@@ -0,0 +1,38 @@
+package vault
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLoginLockout_prune(t *testing.T) {
+	l := newLoginLockout()
+	config := MountConfig{
+		LockoutThreshold:    2,
+		LockoutDuration:     time.Hour,
+		LockoutCounterReset: time.Second,
+	}
+
+	l.recordFailure("mount/failed", config)
+	l.recordFailure("mount/locked", config)
+	if !l.recordFailure("mount/locked", config) {
+		t.Fatalf("expected lockout")
+	}
+	if len(l.entries) != 2 {
+		t.Fatalf("bad: %#v", l.entries)
+	}
+
+	// Entries past the counter reset are pruned, locked out users aren't
+	l.prune(time.Now().Add(lockoutPruneInterval))
+	if _, ok := l.entries["mount/failed"]; ok {
+		t.Fatalf("expected failed entry to be pruned")
+	}
+	if !l.lockedOut("mount/locked") {
+		t.Fatalf("expected lockout")
+	}
+
+	l.prune(time.Now().Add(2 * time.Hour))
+	if len(l.entries) != 0 {
+		t.Fatalf("bad: %#v", l.entries)
+	}
+}
@@ -42,12 +42,14 @@ var (
 		"auth/",
 		"sys/",
 		"cubbyhole/",
+		"identity/",
 	}
 
 	untunableMounts = []string{
 		"cubbyhole/",
 		"sys/",
 		"audit/",
+		"identity/",
 	}
 
 	// singletonMounts can only exist in one location and are
@@ -55,6 +57,7 @@ var (
 	singletonMounts = []string{
 		"cubbyhole",
 		"system",
+		"identity",
 	}
 )
 
@@ -116,20 +119,51 @@ func (t *MountTable) remove(path string) bool {
 
 // MountEntry is used to represent a mount table entry
 type MountEntry struct {
-	Table       string            `json:"table"`             // The table it belongs to
-	Path        string            `json:"path"`              // Mount Path
-	Type        string            `json:"type"`              // Logical backend Type
-	Description string            `json:"description"`       // User-provided description
-	UUID        string            `json:"uuid"`              // Barrier view UUID
-	Config      MountConfig       `json:"config"`            // Configuration related to this mount (but not backend-derived)
-	Options     map[string]string `json:"options"`           // Backend options
-	Tainted     bool              `json:"tainted,omitempty"` // Set as a Write-Ahead flag for unmount/remount
+	Table       string            `json:"table"`                  // The table it belongs to
+	Path        string            `json:"path"`                   // Mount Path
+	Type        string            `json:"type"`                   // Logical backend Type
+	Description string            `json:"description"`            // User-provided description
+	UUID        string            `json:"uuid"`                   // Barrier view UUID
+	Config      MountConfig       `json:"config"`                 // Configuration related to this mount (but not backend-derived)
+	Options     map[string]string `json:"options"`                // Backend options
+	Tainted     bool              `json:"tainted,omitempty"`      // Set as a Write-Ahead flag for unmount/remount
+	SealWrap    bool              `json:"seal_wrap,omitempty"`    // Values are also encrypted by the seal
+	NamespaceID string            `json:"namespace_id,omitempty"` // The namespace of the mount, whose path prefixes Path
 }
 
 // MountConfig is used to hold settable options
 type MountConfig struct {
 	DefaultLeaseTTL time.Duration `json:"default_lease_ttl" structs:"default_lease_ttl" mapstructure:"default_lease_ttl"` // Override for global default
 	MaxLeaseTTL     time.Duration `json:"max_lease_ttl" structs:"max_lease_ttl" mapstructure:"max_lease_ttl"`             // Override for global default
+
+	// Login lockout settings, only used for auth mounts. A threshold of
+	// zero disables the lockout.
+	LockoutThreshold    int           `json:"lockout_threshold,omitempty" structs:"lockout_threshold" mapstructure:"lockout_threshold"`
+	LockoutDuration     time.Duration `json:"lockout_duration,omitempty" structs:"lockout_duration" mapstructure:"lockout_duration"`
+	LockoutCounterReset time.Duration `json:"lockout_counter_reset,omitempty" structs:"lockout_counter_reset" mapstructure:"lockout_counter_reset"`
+
+	// Keys of the request and response data whose values are written to
+	// the audit log without being HMAC'd
+	AuditNonHMACRequestKeys  []string `json:"audit_non_hmac_request_keys,omitempty" structs:"audit_non_hmac_request_keys" mapstructure:"audit_non_hmac_request_keys"`
+	AuditNonHMACResponseKeys []string `json:"audit_non_hmac_response_keys,omitempty" structs:"audit_non_hmac_response_keys" mapstructure:"audit_non_hmac_response_keys"`
+
+	// The minimum interval between the invocations of the periodic function
+	// of the backend, which is invoked with each rollback when zero
+	PeriodicInterval time.Duration `json:"periodic_interval,omitempty" structs:"periodic_interval" mapstructure:"periodic_interval"`
+
+	// The plugin run by mounts of plugin backends
+	PluginName string `json:"plugin_name,omitempty" structs:"plugin_name" mapstructure:"plugin_name"`
+}
+
+// backendConfig returns the configuration given to the factory of the
+// mount's backend
+func (e *MountEntry) backendConfig() map[string]string {
+	if e.Config.PluginName == "" {
+		return nil
+	}
+	return map[string]string{
+		"plugin_name": e.Config.PluginName,
+	}
 }
 
 // Returns a deep copy of the mount entry
@@ -146,10 +180,13 @@ func (e *MountEntry) Clone() *MountEntry {
 		UUID:        e.UUID,
 		Config:      e.Config,
 		Options:     optClone,
+		SealWrap:    e.SealWrap,
+		NamespaceID: e.NamespaceID,
 	}
 }
 
-// Mount is used to mount a new backend to the mount table.
+// Mount is used to mount a new backend to the mount table. The path of
+// the entry is relative to its namespace.
 func (c *Core) mount(me *MountEntry) error {
 	// Ensure we end the path in a slash
 	if !strings.HasSuffix(me.Path, "/") {
@@ -170,20 +207,35 @@ func (c *Core) mount(me *MountEntry) error {
 		}
 	}
 
+	// The mounts of a namespace are prefixed with its path
+	ns := c.namespaceByID(me.NamespaceID)
+	if ns == nil {
+		return logical.CodedError(404, "namespace not found")
+	}
+	path, err := c.namespaceMountPath(ns, me.Path)
+	if err != nil {
+		return err
+	}
+	me.Path = path
+
 	// Verify there is no conflicting mount
 	if match := c.router.MatchingMount(me.Path); match != "" {
 		return logical.CodedError(409, fmt.Sprintf("existing mount at %s", match))
 	}
 
+	if me.SealWrap && c.sealWrapper() == nil {
+		return logical.CodedError(400, ErrSealWrapUnavailable.Error())
+	}
+
 	// Generate a new UUID and view
 	meUUID, err := uuid.GenerateUUID()
 	if err != nil {
 		return err
 	}
 	me.UUID = meUUID
-	view := NewBarrierView(c.barrier, backendBarrierPrefix+me.UUID+"/")
+	view := c.mountEntryView(me, backendBarrierPrefix)
 
-	backend, err := c.newLogicalBackend(me.Type, c.mountEntrySysView(me), view, nil)
+	backend, err := c.newLogicalBackend(me.Type, c.mountEntrySysView(me), view, me.backendConfig())
 	if err != nil {
 		return err
 	}
@@ -267,6 +319,7 @@ func (c *Core) unmount(path string) error {
 	if err := c.removeMountEntry(path); err != nil {
 		return err
 	}
+	c.rollback.forget(path)
 	if c.logger.IsInfo() {
 		c.logger.Info("core: successful unmounted", "path", path)
 	}
@@ -308,8 +361,9 @@ func (c *Core) taintMountEntry(path string) error {
 	return nil
 }
 
-// Remount is used to remount a path at a new mount point.
-func (c *Core) remount(src, dst string) error {
+// checkRemount validates a remount of src to dst, returning both paths
+// ending in a slash
+func (c *Core) checkRemount(src, dst string) (string, string, error) {
 	// Ensure we end the path in a slash
 	if !strings.HasSuffix(src, "/") {
 		src += "/"
@@ -321,18 +375,32 @@ func (c *Core) remount(src, dst string) error {
 	// Prevent protected paths from being remounted
 	for _, p := range protectedMounts {
 		if strings.HasPrefix(src, p) {
-			return fmt.Errorf("cannot remount '%s'", src)
+			return "", "", fmt.Errorf("cannot remount '%s'", src)
 		}
 	}
 
 	// Verify exact match of the route
 	match := c.router.MatchingMount(src)
 	if match == "" || src != match {
-		return fmt.Errorf("no matching mount at '%s'", src)
+		return "", "", fmt.Errorf("no matching mount at '%s'", src)
 	}
 
 	if match := c.router.MatchingMount(dst); match != "" {
-		return fmt.Errorf("existing mount at '%s'", match)
+		return "", "", fmt.Errorf("existing mount at '%s'", match)
+	}
+
+	return src, dst, nil
+}
+
+// Remount is used to remount a path at a new mount point. The storage of
+// a backend is keyed by the UUID of its mount so it stays in place, but
+// the leases issued by the backend are moved to the new path so that they
+// can still be renewed and revoked. If moving the leases or updating the
+// mount table fails, the backend is left at its original path.
+func (c *Core) remount(src, dst string) error {
+	src, dst, err := c.checkRemount(src, dst)
+	if err != nil {
+		return err
 	}
 
 	// Mark the entry as tainted
@@ -345,13 +413,31 @@ func (c *Core) remount(src, dst string) error {
 		return err
 	}
 
+	// abort restores the original mount if the remount fails
+	abort := func() {
+		c.mountsLock.Lock()
+		c.mounts.setTaint(src, false)
+		if err := c.persistMounts(c.mounts); err != nil {
+			c.logger.Error("core: failed to untaint mount entry", "path", src, "error", err)
+		}
+		c.mountsLock.Unlock()
+		if err := c.router.Untaint(src); err != nil {
+			c.logger.Error("core: failed to untaint route", "path", src, "error", err)
+		}
+	}
+
 	// Invoke the rollback manager a final time
 	if err := c.rollback.Rollback(src); err != nil {
+		abort()
 		return err
 	}
 
-	// Revoke all the dynamic keys
-	if err := c.expiration.RevokePrefix(src); err != nil {
+	// Move the leases to the new path
+	if err := c.expiration.MovePrefix(src, dst); err != nil {
+		if moveErr := c.expiration.MovePrefix(dst, src); moveErr != nil {
+			c.logger.Error("core: failed to move leases back", "old_path", src, "new_path", dst, "error", moveErr)
+		}
+		abort()
 		return err
 	}
 
@@ -370,6 +456,10 @@ func (c *Core) remount(src, dst string) error {
 		ent.Path = src
 		ent.Tainted = true
 		c.mountsLock.Unlock()
+		if moveErr := c.expiration.MovePrefix(dst, src); moveErr != nil {
+			c.logger.Error("core: failed to move leases back", "old_path", src, "new_path", dst, "error", moveErr)
+		}
+		abort()
 		return logical.CodedError(500, "failed to update mount table")
 	}
 	c.mountsLock.Unlock()
@@ -378,6 +468,7 @@ func (c *Core) remount(src, dst string) error {
 	if err := c.router.Remount(src, dst); err != nil {
 		return err
 	}
+	c.rollback.forget(src)
 
 	// Un-taint the path
 	if err := c.router.Untaint(dst); err != nil {
@@ -511,20 +602,21 @@ func (c *Core) setupMounts() error {
 	var err error
 
 	for _, entry := range c.mounts.Entries {
-		// Initialize the backend, special casing for system
-		barrierPath := backendBarrierPrefix + entry.UUID + "/"
+		// Create a barrier view using the UUID, special casing for system
+		view = c.mountEntryView(entry, backendBarrierPrefix)
 		if entry.Type == "system" {
-			barrierPath = systemBarrierPrefix
+			view = NewBarrierView(c.barrier, systemBarrierPrefix)
 		}
 
-		// Create a barrier view using the UUID
-		view = NewBarrierView(c.barrier, barrierPath)
-
 		// Initialize the backend
 		// Create the new backend
-		backend, err = c.newLogicalBackend(entry.Type, c.mountEntrySysView(entry), view, nil)
+		backend, err = c.newLogicalBackend(entry.Type, c.mountEntrySysView(entry), view, entry.backendConfig())
 		if err != nil {
 			c.logger.Error("core: failed to create mount entry", "path", entry.Path, "error", err)
+			if entry.Type == pluginBackendType {
+				// A broken plugin must not keep Vault from unsealing
+				continue
+			}
 			return errLoadMountsFailed
 		}
 
@@ -535,6 +627,8 @@ func (c *Core) setupMounts() error {
 			ch := backend.(*CubbyholeBackend)
 			ch.saltUUID = entry.UUID
 			ch.storageView = view
+		case "identity":
+			c.identityStore = backend.(*IdentityStore)
 		}
 
 		// Mount the backend
@@ -576,6 +670,7 @@ func (c *Core) unloadMounts() error {
 	c.mounts = nil
 	c.router = NewRouter()
 	c.systemBarrierView = nil
+	c.identityStore = nil
 	return nil
 }
 
@@ -660,7 +755,20 @@ func requiredMountTable() *MountTable {
 		Description: "system endpoints used for control, policy and debugging",
 		UUID:        sysUUID,
 	}
+	identityUUID, err := uuid.GenerateUUID()
+	if err != nil {
+		panic(fmt.Sprintf("could not create identity UUID: %v", err))
+	}
+	identityMount := &MountEntry{
+		Table:       mountTableType,
+		Path:        "identity/",
+		Type:        "identity",
+		Description: "identity store",
+		UUID:        identityUUID,
+	}
+
 	table.Entries = append(table.Entries, cubbyholeMount)
 	table.Entries = append(table.Entries, sysMount)
+	table.Entries = append(table.Entries, identityMount)
 	return table
 }
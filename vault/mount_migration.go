@@ -0,0 +1,274 @@
+package vault
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/go-uuid"
+	"github.com/hashicorp/vault/helper/jsonutil"
+	"github.com/hashicorp/vault/logical"
+)
+
+const (
+	// MountMigrationInProgress, MountMigrationSuccess and
+	// MountMigrationFailure are the statuses of a remount
+	MountMigrationInProgress = "in-progress"
+	MountMigrationSuccess    = "success"
+	MountMigrationFailure    = "failure"
+
+	// mountMigrationRetention is how long the status of a finished remount
+	// is kept around
+	mountMigrationRetention = 24 * time.Hour
+
+	// coreMountMigrationPath is the prefix the statuses of the remounts are
+	// stored under, so that they survive restarts and leader elections
+	coreMountMigrationPath = "core/mount-migrations/"
+)
+
+// MountMigrationInfo is the status of a remount
+type MountMigrationInfo struct {
+	ID          string    `json:"id"`
+	SourceMount string    `json:"source_mount"`
+	TargetMount string    `json:"target_mount"`
+	Status      string    `json:"status"`
+	StartTime   time.Time `json:"start_time"`
+	EndTime     time.Time `json:"end_time"`
+}
+
+// mountMigrations tracks the remounts started through sys/remount. The
+// statuses are also stored by the core, and loaded back on unseal.
+type mountMigrations struct {
+	l       sync.Mutex
+	entries map[string]*MountMigrationInfo
+}
+
+func newMountMigrations() *mountMigrations {
+	return &mountMigrations{
+		entries: make(map[string]*MountMigrationInfo),
+	}
+}
+
+// start registers a remount from src to dst, unless either path is already
+// part of a remount in progress
+func (m *mountMigrations) start(src, dst string) (*MountMigrationInfo, error) {
+	m.l.Lock()
+	defer m.l.Unlock()
+
+	now := time.Now()
+	for id, info := range m.entries {
+		if info.Status != MountMigrationInProgress {
+			if now.Sub(info.EndTime) > mountMigrationRetention {
+				delete(m.entries, id)
+			}
+			continue
+		}
+		for _, path := range []string{info.SourceMount, info.TargetMount} {
+			if path == src || path == dst {
+				return nil, logical.CodedError(400, fmt.Sprintf("remount of '%s' already in progress", path))
+			}
+		}
+	}
+
+	id, err := uuid.GenerateUUID()
+	if err != nil {
+		return nil, err
+	}
+	info := &MountMigrationInfo{
+		ID:          id,
+		SourceMount: src,
+		TargetMount: dst,
+		Status:      MountMigrationInProgress,
+		StartTime:   now,
+	}
+	m.entries[id] = info
+	return info, nil
+}
+
+// finish records the outcome of a remount, returning a copy of its status
+// or nil if it is unknown
+func (m *mountMigrations) finish(id string, err error) *MountMigrationInfo {
+	m.l.Lock()
+	defer m.l.Unlock()
+
+	info, ok := m.entries[id]
+	if !ok {
+		return nil
+	}
+	info.Status = MountMigrationSuccess
+	if err != nil {
+		info.Status = MountMigrationFailure
+	}
+	info.EndTime = time.Now()
+	ret := *info
+	return &ret
+}
+
+// add tracks a remount loaded from storage
+func (m *mountMigrations) add(info *MountMigrationInfo) {
+	m.l.Lock()
+	defer m.l.Unlock()
+
+	m.entries[info.ID] = info
+}
+
+// get returns a copy of the status of a remount, or nil if it is unknown
+func (m *mountMigrations) get(id string) *MountMigrationInfo {
+	m.l.Lock()
+	defer m.l.Unlock()
+
+	info, ok := m.entries[id]
+	if !ok {
+		return nil
+	}
+	ret := *info
+	return &ret
+}
+
+// startRemount validates a remount of src to dst and runs it in the
+// background, returning the ID its status can be looked up with
+func (c *Core) startRemount(src, dst string) (string, error) {
+	src, dst, err := c.checkRemount(src, dst)
+	if err != nil {
+		return "", err
+	}
+
+	info, err := c.mountMigrations.start(src, dst)
+	if err != nil {
+		return "", err
+	}
+	if err := c.persistMountMigration(info); err != nil {
+		c.mountMigrations.finish(info.ID, err)
+		return "", err
+	}
+
+	go func() {
+		c.stateLock.RLock()
+		defer c.stateLock.RUnlock()
+
+		var err error
+		if c.sealed {
+			err = ErrSealed
+		} else {
+			err = c.remount(src, dst)
+		}
+		if err != nil {
+			c.logger.Error("core: remount failed", "migration_id", info.ID, "from_path", src, "to_path", dst, "error", err)
+		}
+		if finished := c.mountMigrations.finish(info.ID, err); finished != nil && !c.sealed {
+			c.persistMountMigration(finished)
+		}
+	}()
+
+	return info.ID, nil
+}
+
+// persistMountMigration stores the status of a remount
+func (c *Core) persistMountMigration(info *MountMigrationInfo) error {
+	buf, err := jsonutil.EncodeJSON(info)
+	if err != nil {
+		return err
+	}
+	if err := c.barrier.Put(&Entry{
+		Key:   coreMountMigrationPath + info.ID,
+		Value: buf,
+	}); err != nil {
+		c.logger.Error("core: failed to persist remount status", "migration_id", info.ID, "error", err)
+		return err
+	}
+	return nil
+}
+
+// loadMountMigrations is invoked as part of postUnseal to load the statuses
+// of the remounts, once the mounts and the expiration manager are set up.
+// Remounts still in progress were interrupted by a seal, a restart or a
+// leader election, and are finished by recoverRemount.
+func (c *Core) loadMountMigrations() error {
+	c.mountMigrations = newMountMigrations()
+
+	ids, err := c.barrier.List(coreMountMigrationPath)
+	if err != nil {
+		c.logger.Error("core: failed to list remount statuses", "error", err)
+		return err
+	}
+	for _, id := range ids {
+		entry, err := c.barrier.Get(coreMountMigrationPath + id)
+		if err != nil {
+			c.logger.Error("core: failed to read remount status", "migration_id", id, "error", err)
+			return err
+		}
+		if entry == nil {
+			continue
+		}
+
+		info := &MountMigrationInfo{}
+		if err := jsonutil.DecodeJSON(entry.Value, info); err != nil {
+			c.logger.Error("core: failed to decode remount status", "migration_id", id, "error", err)
+			return err
+		}
+
+		switch {
+		case info.Status == MountMigrationInProgress:
+			c.recoverRemount(info)
+			if err := c.persistMountMigration(info); err != nil {
+				return err
+			}
+		case time.Now().Sub(info.EndTime) > mountMigrationRetention:
+			if err := c.barrier.Delete(coreMountMigrationPath + id); err != nil {
+				c.logger.Error("core: failed to delete remount status", "migration_id", id, "error", err)
+				return err
+			}
+			continue
+		}
+		c.mountMigrations.add(info)
+	}
+	return nil
+}
+
+// recoverRemount finishes an interrupted remount. Leases are moved one at a
+// time, each atomically with its secondary index, but moving a whole prefix
+// isn't atomic. The mount table is only updated once every lease is moved,
+// so a mount found at the target path was remounted. Otherwise, the leases
+// already moved are moved back and the mount is untainted at its source
+// path.
+func (c *Core) recoverRemount(info *MountMigrationInfo) {
+	info.EndTime = time.Now()
+
+	var remounted bool
+	c.mountsLock.RLock()
+	for _, entry := range c.mounts.Entries {
+		if entry.Path == info.TargetMount {
+			remounted = true
+		}
+	}
+	c.mountsLock.RUnlock()
+	if remounted {
+		info.Status = MountMigrationSuccess
+		return
+	}
+
+	info.Status = MountMigrationFailure
+	if err := c.expiration.MovePrefix(info.TargetMount, info.SourceMount); err != nil {
+		c.logger.Error("core: failed to move leases back after interrupted remount", "migration_id", info.ID, "old_path", info.SourceMount, "new_path", info.TargetMount, "error", err)
+	}
+
+	c.mountsLock.Lock()
+	found := c.mounts.setTaint(info.SourceMount, false)
+	if found {
+		if err := c.persistMounts(c.mounts); err != nil {
+			c.logger.Error("core: failed to untaint mount entry", "path", info.SourceMount, "error", err)
+		}
+	}
+	c.mountsLock.Unlock()
+	if found {
+		c.router.Untaint(info.SourceMount)
+	}
+
+	c.logger.Warn("core: recovered interrupted remount", "migration_id", info.ID, "from_path", info.SourceMount, "to_path", info.TargetMount)
+}
+
+// MountMigrationStatus returns the status of the remount with the given ID,
+// or nil if it is unknown
+func (c *Core) MountMigrationStatus(id string) *MountMigrationInfo {
+	return c.mountMigrations.get(id)
+}
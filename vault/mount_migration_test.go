@@ -0,0 +1,129 @@
+package vault
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/logical"
+)
+
+func TestMountMigrations(t *testing.T) {
+	m := newMountMigrations()
+
+	info, err := m.start("foo/", "bar/")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if info.ID == "" || info.Status != MountMigrationInProgress {
+		t.Fatalf("bad: %#v", info)
+	}
+
+	// Paths of a remount in progress can't be remounted
+	if _, err := m.start("bar/", "baz/"); err == nil {
+		t.Fatal("expected error remounting the target of a remount in progress")
+	}
+	if _, err := m.start("baz/", "foo/"); err == nil {
+		t.Fatal("expected error remounting to the source of a remount in progress")
+	}
+
+	m.finish(info.ID, fmt.Errorf("failed"))
+	status := m.get(info.ID)
+	if status == nil || status.Status != MountMigrationFailure {
+		t.Fatalf("bad: %#v", status)
+	}
+
+	// Finished remounts don't block others, and are eventually forgotten
+	m.entries[info.ID].EndTime = time.Now().Add(-2 * mountMigrationRetention)
+	other, err := m.start("foo/", "bar/")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if m.get(info.ID) != nil {
+		t.Fatal("expected the finished remount to be forgotten")
+	}
+
+	m.finish(other.ID, nil)
+	if status := m.get(other.ID); status == nil || status.Status != MountMigrationSuccess {
+		t.Fatalf("bad: %#v", status)
+	}
+}
+
+func TestCore_loadMountMigrations(t *testing.T) {
+	c, key, root := TestCoreUnsealed(t)
+
+	req := logical.TestRequest(t, logical.UpdateOperation, "secret/foo")
+	req.ClientToken = root
+	req.Data["lease"] = "1h"
+	if _, err := c.HandleRequest(req); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	req = logical.TestRequest(t, logical.ReadOperation, "secret/foo")
+	req.ClientToken = root
+	resp, err := c.HandleRequest(req)
+	if err != nil || resp == nil || resp.Secret == nil || resp.Secret.LeaseID == "" {
+		t.Fatalf("err: %v\nresp: %#v", err, resp)
+	}
+	leaseID := resp.Secret.LeaseID
+
+	// Interrupt a remount after the leases are moved, before the mount
+	// table is updated
+	info, err := c.mountMigrations.start("secret/", "new/")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if err := c.persistMountMigration(info); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if err := c.taintMountEntry("secret/"); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if err := c.expiration.MovePrefix("secret/", "new/"); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// The statuses of remounts finished long ago are forgotten
+	expired := &MountMigrationInfo{
+		ID:      "expired",
+		Status:  MountMigrationSuccess,
+		EndTime: time.Now().Add(-2 * mountMigrationRetention),
+	}
+	if err := c.persistMountMigration(expired); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	c2, err := NewCore(&CoreConfig{
+		Physical:     c.physical,
+		DisableMlock: true,
+		LogicalBackends: map[string]logical.Factory{
+			"generic": LeasedPassthroughBackendFactory,
+		},
+	})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if unseal, err := TestCoreUnseal(c2, key); err != nil || !unseal {
+		t.Fatalf("err: %v", err)
+	}
+
+	status := c2.MountMigrationStatus(info.ID)
+	if status == nil || status.Status != MountMigrationFailure || status.EndTime.IsZero() {
+		t.Fatalf("bad: %#v", status)
+	}
+	if status := c2.MountMigrationStatus("expired"); status != nil {
+		t.Fatalf("bad: %#v", status)
+	}
+	if entry, err := c2.barrier.Get(coreMountMigrationPath + "expired"); err != nil || entry != nil {
+		t.Fatalf("err: %v\nentry: %#v", err, entry)
+	}
+
+	// The lease is back under the source mount, which is usable again
+	if le, err := c2.expiration.loadEntry(leaseID); err != nil || le == nil {
+		t.Fatalf("err: %v\nlease: %#v", err, le)
+	}
+	req = logical.TestRequest(t, logical.ReadOperation, "secret/foo")
+	req.ClientToken = root
+	if resp, err := c2.HandleRequest(req); err != nil || resp == nil || resp.Data["lease"] != "1h" {
+		t.Fatalf("err: %v\nresp: %#v", err, resp)
+	}
+}
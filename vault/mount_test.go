@@ -90,6 +90,14 @@ func TestCore_Unmount(t *testing.T) {
 		t.Fatalf("backend present")
 	}
 
+	// The rollback manager doesn't keep the state of the path
+	c.rollback.inflightLock.RLock()
+	_, ok := c.rollback.lastPeriodic["secret/"]
+	c.rollback.inflightLock.RUnlock()
+	if ok {
+		t.Fatalf("rollback state present")
+	}
+
 	conf := &CoreConfig{
 		Physical:     c.physical,
 		DisableMlock: true,
@@ -208,6 +216,13 @@ func TestCore_Remount(t *testing.T) {
 		t.Fatalf("failed remount")
 	}
 
+	c.rollback.inflightLock.RLock()
+	_, ok := c.rollback.lastPeriodic["secret/"]
+	c.rollback.inflightLock.RUnlock()
+	if ok {
+		t.Fatalf("rollback state present")
+	}
+
 	conf := &CoreConfig{
 		Physical:     c.physical,
 		DisableMlock: true,
@@ -230,7 +245,7 @@ func TestCore_Remount(t *testing.T) {
 	}
 }
 
-func TestCore_Remount_Cleanup(t *testing.T) {
+func TestCore_Remount_Leases(t *testing.T) {
 	noop := &NoopBackend{}
 	c, _, root := TestCoreUnsealed(t)
 	c.logicalBackends["noop"] = func(*logical.BackendConfig) (logical.Backend, error) {
@@ -286,17 +301,46 @@ func TestCore_Remount_Cleanup(t *testing.T) {
 		t.Fatalf("bad: %#v", resp)
 	}
 
-	// Remount, this should cleanup
+	leaseID := resp.Secret.LeaseID
+
+	// Remount, this should move the lease
 	if err := c.remount("test/", "new/"); err != nil {
 		t.Fatalf("err: %v", err)
 	}
 
-	// Rollback should be invoked
-	if noop.Requests[1].Operation != logical.RollbackOperation {
+	// Rollback should be invoked, but not revoke
+	if len(noop.Requests) != 2 || noop.Requests[1].Operation != logical.RollbackOperation {
 		t.Fatalf("bad: %#v", noop.Requests)
 	}
 
-	// Revoke should be invoked
+	// The lease should be moved to the new path
+	le, err := c.expiration.loadEntry(leaseID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if le != nil {
+		t.Fatalf("lease should have moved: %#v", le)
+	}
+	newLeaseID := "new/" + strings.TrimPrefix(leaseID, "test/")
+	le, err = c.expiration.loadEntry(newLeaseID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if le == nil || le.Path != "new/foo" {
+		t.Fatalf("bad: %#v", le)
+	}
+	leases, err := c.expiration.lookupByToken(root)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(leases) != 1 || leases[0] != newLeaseID {
+		t.Fatalf("bad: %#v", leases)
+	}
+
+	// Revoking the moved lease should reach the backend at its new path
+	if err := c.expiration.Revoke(newLeaseID); err != nil {
+		t.Fatalf("err: %v", err)
+	}
 	if noop.Requests[2].Operation != logical.RevokeOperation {
 		t.Fatalf("bad: %#v", noop.Requests)
 	}
@@ -475,7 +519,7 @@ func testCore_MountTable_UpgradeToTyped_Common(
 }
 
 func verifyDefaultTable(t *testing.T, table *MountTable) {
-	if len(table.Entries) != 3 {
+	if len(table.Entries) != 4 {
 		t.Fatalf("bad: %v", table.Entries)
 	}
 	for idx, entry := range table.Entries {
@@ -501,6 +545,13 @@ func verifyDefaultTable(t *testing.T, table *MountTable) {
 			if entry.Type != "system" {
 				t.Fatalf("bad: %v", entry)
 			}
+		case 3:
+			if entry.Path != "identity/" {
+				t.Fatalf("bad: %v", entry)
+			}
+			if entry.Type != "identity" {
+				t.Fatalf("bad: %v", entry)
+			}
 		}
 		if entry.Table != mountTableType {
 			t.Fatalf("bad: %v", entry)
@@ -0,0 +1,610 @@
+package vault
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/hashicorp/go-uuid"
+	"github.com/hashicorp/vault/helper/jsonutil"
+	"github.com/hashicorp/vault/logical"
+)
+
+const (
+	// coreNamespacesPath is used to store the namespaces
+	coreNamespacesPath = "core/namespaces"
+
+	// namespaceSubPath is the sub-path of the system view under which the
+	// data of the namespaces, such as their policies, is stored
+	namespaceSubPath = "namespaces/"
+)
+
+var (
+	// namespaceNameRegex matches the valid names of namespaces
+	namespaceNameRegex = regexp.MustCompile(`^[\w-]+$`)
+
+	// namespaceSharedPaths are routed the same in every namespace; the
+	// backends serving them scope the requests to their namespace
+	namespaceSharedPaths = []string{
+		"sys/",
+		"auth/token/",
+		"cubbyhole/",
+	}
+
+	// namespaceSysPaths are the paths of the system backend that are
+	// available in the namespaces other than the root namespace
+	namespaceSysPaths = []string{
+		"sys/auth",
+		"sys/capabilities",
+		"sys/internal/ui/mounts/",
+		"sys/leases/",
+		"sys/mounts",
+		"sys/namespaces",
+		"sys/policy",
+		"sys/remount",
+		"sys/renew",
+		"sys/revoke",
+		"sys/wrapping/",
+	}
+)
+
+// Namespace is an isolated tenant of Vault with its own mounts, policies
+// and tokens. The path of a namespace ends in a slash, such as "team-a/" or
+// "team-a/dev/"; the root namespace has an empty path and ID.
+type Namespace struct {
+	ID   string `json:"id"`
+	Path string `json:"path"`
+}
+
+// rootNamespace is the namespace of the requests made without a namespace
+var rootNamespace = &Namespace{}
+
+// contains returns whether other is the namespace or one beneath it
+func (ns *Namespace) contains(other *Namespace) bool {
+	return strings.HasPrefix(other.Path, ns.Path)
+}
+
+// namespaceTable is used to persist the namespaces
+type namespaceTable struct {
+	Entries []*Namespace `json:"entries"`
+}
+
+// namespaceStore holds the namespaces other than the root namespace, and
+// their policy stores
+type namespaceStore struct {
+	byPath       map[string]*Namespace
+	byID         map[string]*Namespace
+	policyStores map[string]*PolicyStore
+	sync.RWMutex
+}
+
+// ofPath returns the namespace owning a path relative to the root
+// namespace, the one with the longest path the path starts with. It must
+// be called with the lock held.
+func (s *namespaceStore) ofPath(path string) *Namespace {
+	match := rootNamespace
+	for nsPath, ns := range s.byPath {
+		if strings.HasPrefix(path, nsPath) && len(nsPath) > len(match.Path) {
+			match = ns
+		}
+	}
+	return match
+}
+
+// canonicalNamespacePath returns the path of a namespace ending in a slash
+// and without a leading slash
+func canonicalNamespacePath(path string) string {
+	path = strings.Trim(path, "/")
+	if path == "" {
+		return ""
+	}
+	return path + "/"
+}
+
+// namespaceRoutePath returns the path a request to the path made in the
+// namespace is routed to. The mounts of a namespace are prefixed with its
+// path, and its auth mounts are under auth/ followed by its path.
+func namespaceRoutePath(ns *Namespace, path string) string {
+	if ns.Path == "" || namespaceSharedPath(path) {
+		return path
+	}
+	if strings.HasPrefix(path, credentialRoutePrefix) {
+		return credentialRoutePrefix + ns.Path + strings.TrimPrefix(path, credentialRoutePrefix)
+	}
+	return ns.Path + path
+}
+
+// namespaceRequestPath is the inverse of namespaceRoutePath, returning the
+// path relative to the namespace of a path requests are routed to
+func namespaceRequestPath(ns *Namespace, routePath string) string {
+	if ns.Path == "" {
+		return routePath
+	}
+	if prefix := credentialRoutePrefix + ns.Path; strings.HasPrefix(routePath, prefix) {
+		return credentialRoutePrefix + strings.TrimPrefix(routePath, prefix)
+	}
+	return strings.TrimPrefix(routePath, ns.Path)
+}
+
+// namespaceSharedPath returns whether the path is routed the same in every
+// namespace
+func namespaceSharedPath(path string) bool {
+	for _, shared := range namespaceSharedPaths {
+		if strings.HasPrefix(path, shared) {
+			return true
+		}
+	}
+	return false
+}
+
+// namespaceSysPath returns whether the path of the system backend is
+// available in the namespaces other than the root namespace
+func namespaceSysPath(path string) bool {
+	for _, p := range namespaceSysPaths {
+		if strings.HasPrefix(path, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// setupNamespaces loads the namespaces and creates their policy stores
+func (c *Core) setupNamespaces() error {
+	s := &namespaceStore{
+		byPath:       make(map[string]*Namespace),
+		byID:         make(map[string]*Namespace),
+		policyStores: make(map[string]*PolicyStore),
+	}
+
+	raw, err := c.barrier.Get(coreNamespacesPath)
+	if err != nil {
+		c.logger.Error("core: failed to read namespaces", "error", err)
+		return err
+	}
+	if raw != nil {
+		table := &namespaceTable{}
+		if err := jsonutil.DecodeJSON(raw.Value, table); err != nil {
+			c.logger.Error("core: failed to decode namespaces", "error", err)
+			return err
+		}
+		for _, ns := range table.Entries {
+			ps, err := c.newNamespacePolicyStore(ns)
+			if err != nil {
+				return err
+			}
+			s.byPath[ns.Path] = ns
+			s.byID[ns.ID] = ns
+			s.policyStores[ns.ID] = ps
+		}
+	}
+
+	c.namespaces = s
+	return nil
+}
+
+// teardownNamespaces is used to reverse setupNamespaces when the vault is
+// being sealed
+func (c *Core) teardownNamespaces() error {
+	c.namespaces = nil
+	return nil
+}
+
+// persistNamespaces stores the namespaces. It must be called with the lock
+// of the namespaces held.
+func (c *Core) persistNamespaces() error {
+	table := &namespaceTable{
+		Entries: make([]*Namespace, 0, len(c.namespaces.byPath)),
+	}
+	for _, ns := range c.namespaces.byPath {
+		table.Entries = append(table.Entries, ns)
+	}
+	sort.Slice(table.Entries, func(i, j int) bool {
+		return table.Entries[i].Path < table.Entries[j].Path
+	})
+
+	buf, err := jsonutil.EncodeJSON(table)
+	if err != nil {
+		c.logger.Error("core: failed to encode namespaces", "error", err)
+		return err
+	}
+	if err := c.barrier.Put(&Entry{Key: coreNamespacesPath, Value: buf}); err != nil {
+		c.logger.Error("core: failed to persist namespaces", "error", err)
+		return err
+	}
+	return nil
+}
+
+// namespaceView returns the view holding the data of a namespace
+func (c *Core) namespaceView(ns *Namespace) *BarrierView {
+	return c.systemBarrierView.SubView(namespaceSubPath + ns.ID + "/")
+}
+
+// newNamespacePolicyStore creates the policy store of a namespace, creating
+// its default and response-wrapping policies if they don't exist
+func (c *Core) newNamespacePolicyStore(ns *Namespace) (*PolicyStore, error) {
+	ps := NewPolicyStore(c.namespaceView(ns).SubView(policySubPath), &dynamicSystemView{core: c})
+
+	policy, err := ps.GetPolicy("default")
+	if err != nil {
+		return nil, fmt.Errorf("error fetching default policy of namespace %s: %v", ns.Path, err)
+	}
+	if policy == nil {
+		if err := ps.createDefaultPolicy(); err != nil {
+			return nil, err
+		}
+	}
+
+	policy, err = ps.GetPolicy(cubbyholeResponseWrappingPolicyName)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching response-wrapping policy of namespace %s: %v", ns.Path, err)
+	}
+	if policy == nil || policy.Raw != cubbyholeResponseWrappingPolicy {
+		if err := ps.createCubbyholeResponseWrappingPolicy(); err != nil {
+			return nil, err
+		}
+	}
+	return ps, nil
+}
+
+// namespaceByPath returns the namespace with the given path, or nil if it
+// doesn't exist
+func (c *Core) namespaceByPath(path string) *Namespace {
+	path = canonicalNamespacePath(path)
+	if path == "" {
+		return rootNamespace
+	}
+	if c.namespaces == nil {
+		return nil
+	}
+
+	c.namespaces.RLock()
+	defer c.namespaces.RUnlock()
+	return c.namespaces.byPath[path]
+}
+
+// namespaceByID returns the namespace with the given ID, or nil if it
+// doesn't exist
+func (c *Core) namespaceByID(id string) *Namespace {
+	if id == "" {
+		return rootNamespace
+	}
+	if c.namespaces == nil {
+		return nil
+	}
+
+	c.namespaces.RLock()
+	defer c.namespaces.RUnlock()
+	return c.namespaces.byID[id]
+}
+
+// namespacePolicyStore returns the policy store of a namespace, or nil if
+// the namespace doesn't exist
+func (c *Core) namespacePolicyStore(ns *Namespace) *PolicyStore {
+	if ns.ID == "" {
+		return c.policyStore
+	}
+	if c.namespaces == nil {
+		return nil
+	}
+
+	c.namespaces.RLock()
+	defer c.namespaces.RUnlock()
+	return c.namespaces.policyStores[ns.ID]
+}
+
+// routeNamespace resolves the namespace of a request and rewrites its path
+// to the path the request is routed to. The namespace of a request is the
+// one of its namespace header, or a namespace beneath it when the path of
+// the request starts with the path of the namespace relative to it, so
+// that "team-a/secret/foo" in the root namespace is the same request as
+// "secret/foo" in the team-a/ namespace.
+func (c *Core) routeNamespace(req *logical.Request) error {
+	ns := c.namespaceByPath(req.Namespace)
+	if ns == nil {
+		return fmt.Errorf("namespace %q not found", req.Namespace)
+	}
+
+	path := req.Path
+	if c.namespaces != nil {
+		c.namespaces.RLock()
+		switch {
+		case namespaceSharedPath(path):
+		case strings.HasPrefix(path, credentialRoutePrefix):
+			full := ns.Path + strings.TrimPrefix(path, credentialRoutePrefix)
+			ns = c.namespaces.ofPath(full)
+			path = credentialRoutePrefix + strings.TrimPrefix(full, ns.Path)
+		default:
+			full := ns.Path + path
+			ns = c.namespaces.ofPath(full)
+			path = strings.TrimPrefix(full, ns.Path)
+		}
+		c.namespaces.RUnlock()
+	}
+
+	if ns.Path != "" && strings.HasPrefix(path, "sys/") && !namespaceSysPath(path) {
+		return fmt.Errorf("path %q is only available in the root namespace", path)
+	}
+
+	req.Namespace = ns.Path
+	req.Path = namespaceRoutePath(ns, path)
+	return nil
+}
+
+// requestNamespace returns the namespace of a request routed by
+// routeNamespace
+func (c *Core) requestNamespace(req *logical.Request) (*Namespace, error) {
+	ns := c.namespaceByPath(req.Namespace)
+	if ns == nil {
+		return nil, logical.CodedError(404, fmt.Sprintf("namespace %q not found", req.Namespace))
+	}
+	return ns, nil
+}
+
+// requestPolicyStore returns the policy store of the namespace of a request
+func (c *Core) requestPolicyStore(req *logical.Request) (*PolicyStore, error) {
+	ns, err := c.requestNamespace(req)
+	if err != nil {
+		return nil, err
+	}
+	ps := c.namespacePolicyStore(ns)
+	if ps == nil {
+		return nil, logical.CodedError(404, fmt.Sprintf("namespace %q not found", req.Namespace))
+	}
+	return ps, nil
+}
+
+// checkLeaseNamespace returns an error unless the lease belongs to the
+// namespace of the request or a namespace beneath it. The leases of tokens
+// belong to the namespace of their token, and the other leases to the
+// namespace owning their path.
+func (c *Core) checkLeaseNamespace(req *logical.Request, leaseID string) error {
+	if req.Namespace == "" {
+		return nil
+	}
+
+	le, err := c.expiration.loadEntry(leaseID)
+	if err != nil {
+		return err
+	}
+	if le == nil {
+		return fmt.Errorf("lease not found")
+	}
+
+	var ns *Namespace
+	if le.Auth != nil {
+		te, err := c.tokenStore.Lookup(le.ClientToken)
+		if err != nil {
+			return err
+		}
+		if te != nil {
+			ns = c.namespaceByID(te.NamespaceID)
+		}
+	} else if c.namespaces != nil {
+		c.namespaces.RLock()
+		ns = c.namespaces.ofPath(strings.TrimPrefix(le.Path, credentialRoutePrefix))
+		c.namespaces.RUnlock()
+	}
+	if ns == nil || !strings.HasPrefix(ns.Path, req.Namespace) {
+		return fmt.Errorf("lease not found")
+	}
+	return nil
+}
+
+// namespaceLeasePrefix returns the prefix of the lease IDs of a lease
+// prefix relative to the namespace of a request. The leases under the
+// shared paths can only be managed by prefix in the root namespace.
+func (c *Core) namespaceLeasePrefix(req *logical.Request, prefix string) (string, error) {
+	ns, err := c.requestNamespace(req)
+	if err != nil {
+		return "", err
+	}
+	if ns.ID != "" && namespaceSharedPath(prefix) {
+		return "", fmt.Errorf("prefix %q is only available in the root namespace", prefix)
+	}
+	return namespaceRoutePath(ns, prefix), nil
+}
+
+// namespaceEntryPath returns the path relative to a namespace of a mount or
+// auth mount, and whether the mount is visible in the namespace. The shared
+// mounts of the root namespace are visible in every namespace; routePrefix
+// is the prefix the table of the entry is routed under.
+func namespaceEntryPath(ns *Namespace, entry *MountEntry, routePrefix string) (string, bool) {
+	switch {
+	case entry.NamespaceID == ns.ID:
+		return strings.TrimPrefix(entry.Path, ns.Path), true
+	case entry.NamespaceID == "" && namespaceSharedPath(routePrefix+entry.Path):
+		return entry.Path, true
+	default:
+		return "", false
+	}
+}
+
+// tokenNamespace returns the namespace of a token used for a request. A
+// token can only be used in its namespace and the namespaces beneath it.
+func (c *Core) tokenNamespace(te *TokenEntry, reqNamespace string) (*Namespace, error) {
+	ns := c.namespaceByID(te.NamespaceID)
+	if ns == nil || !strings.HasPrefix(reqNamespace, ns.Path) {
+		return nil, logical.ErrPermissionDenied
+	}
+	return ns, nil
+}
+
+// namespaceACLPath returns the path a request is checked against the
+// policies of its token with: the path relative to the namespace of the
+// token
+func (c *Core) namespaceACLPath(req *logical.Request, tokenNS *Namespace) string {
+	reqNS := &Namespace{Path: req.Namespace}
+	return strings.TrimPrefix(req.Namespace, tokenNS.Path) + namespaceRequestPath(reqNS, req.Path)
+}
+
+// namespaceMountPath returns the path relative to the root namespace of a
+// mount path relative to a namespace, checking that the mount belongs to
+// the namespace rather than to a namespace beneath it
+func (c *Core) namespaceMountPath(ns *Namespace, path string) (string, error) {
+	full := ns.Path + path
+	if c.namespaces == nil {
+		return full, nil
+	}
+
+	c.namespaces.RLock()
+	defer c.namespaces.RUnlock()
+	if owner := c.namespaces.ofPath(full); owner != ns {
+		return "", logical.CodedError(409, fmt.Sprintf("path '%s' is in use by namespace %s", path, strings.TrimPrefix(owner.Path, ns.Path)))
+	}
+	return full, nil
+}
+
+// listNamespaces returns the paths relative to the namespace of the
+// namespaces directly beneath it
+func (c *Core) listNamespaces(ns *Namespace) []string {
+	var keys []string
+	if c.namespaces == nil {
+		return keys
+	}
+
+	c.namespaces.RLock()
+	defer c.namespaces.RUnlock()
+	for path := range c.namespaces.byPath {
+		if !strings.HasPrefix(path, ns.Path) || path == ns.Path {
+			continue
+		}
+		rel := strings.TrimPrefix(path, ns.Path)
+		if strings.Index(rel, "/") == len(rel)-1 {
+			keys = append(keys, rel)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// createNamespace creates a namespace directly beneath the parent
+// namespace. The path of the namespace must not overlap the path of a mount
+// or auth mount.
+func (c *Core) createNamespace(parent *Namespace, name string) (*Namespace, error) {
+	if !namespaceNameRegex.MatchString(name) {
+		return nil, logical.CodedError(400, fmt.Sprintf("invalid namespace name '%s'", name))
+	}
+	for _, p := range protectedMounts {
+		if name+"/" == p {
+			return nil, logical.CodedError(400, fmt.Sprintf("cannot create namespace '%s'", name))
+		}
+	}
+
+	c.namespaces.Lock()
+	defer c.namespaces.Unlock()
+
+	if parent.ID != "" && c.namespaces.byID[parent.ID] == nil {
+		return nil, logical.CodedError(404, "parent namespace not found")
+	}
+	path := parent.Path + name + "/"
+	if c.namespaces.byPath[path] != nil {
+		return nil, logical.CodedError(409, fmt.Sprintf("namespace %s already exists", path))
+	}
+
+	overlaps := func(mountPath string) bool {
+		return strings.HasPrefix(mountPath, path) || strings.HasPrefix(path, mountPath)
+	}
+	c.mountsLock.RLock()
+	for _, entry := range c.mounts.Entries {
+		if overlaps(entry.Path) {
+			c.mountsLock.RUnlock()
+			return nil, logical.CodedError(409, fmt.Sprintf("existing mount at %s", entry.Path))
+		}
+	}
+	c.mountsLock.RUnlock()
+	c.authLock.RLock()
+	for _, entry := range c.auth.Entries {
+		if overlaps(entry.Path) {
+			c.authLock.RUnlock()
+			return nil, logical.CodedError(409, fmt.Sprintf("existing auth mount at %s", entry.Path))
+		}
+	}
+	c.authLock.RUnlock()
+
+	id, err := uuid.GenerateUUID()
+	if err != nil {
+		return nil, err
+	}
+	ns := &Namespace{
+		ID:   id,
+		Path: path,
+	}
+	ps, err := c.newNamespacePolicyStore(ns)
+	if err != nil {
+		return nil, err
+	}
+
+	c.namespaces.byPath[ns.Path] = ns
+	c.namespaces.byID[ns.ID] = ns
+	if err := c.persistNamespaces(); err != nil {
+		delete(c.namespaces.byPath, ns.Path)
+		delete(c.namespaces.byID, ns.ID)
+		ClearView(c.namespaceView(ns))
+		return nil, logical.CodedError(500, "failed to update namespaces")
+	}
+	c.namespaces.policyStores[ns.ID] = ps
+
+	if c.logger.IsInfo() {
+		c.logger.Info("core: created namespace", "path", ns.Path)
+	}
+	return ns, nil
+}
+
+// deleteNamespace deletes a namespace along with its policies and token
+// roles. The namespaces beneath it, its mounts and its auth mounts must be
+// removed first. The tokens of the namespace can no longer be used.
+func (c *Core) deleteNamespace(ns *Namespace) error {
+	c.namespaces.Lock()
+	defer c.namespaces.Unlock()
+
+	if c.namespaces.byID[ns.ID] == nil {
+		return nil
+	}
+	for path := range c.namespaces.byPath {
+		if path != ns.Path && strings.HasPrefix(path, ns.Path) {
+			return logical.CodedError(400, fmt.Sprintf("namespace %s has child namespaces", ns.Path))
+		}
+	}
+
+	c.mountsLock.RLock()
+	for _, entry := range c.mounts.Entries {
+		if entry.NamespaceID == ns.ID {
+			c.mountsLock.RUnlock()
+			return logical.CodedError(400, fmt.Sprintf("namespace %s has mounts", ns.Path))
+		}
+	}
+	c.mountsLock.RUnlock()
+	c.authLock.RLock()
+	for _, entry := range c.auth.Entries {
+		if entry.NamespaceID == ns.ID {
+			c.authLock.RUnlock()
+			return logical.CodedError(400, fmt.Sprintf("namespace %s has auth mounts", ns.Path))
+		}
+	}
+	c.authLock.RUnlock()
+
+	delete(c.namespaces.byPath, ns.Path)
+	delete(c.namespaces.byID, ns.ID)
+	if err := c.persistNamespaces(); err != nil {
+		c.namespaces.byPath[ns.Path] = ns
+		c.namespaces.byID[ns.ID] = ns
+		return logical.CodedError(500, "failed to update namespaces")
+	}
+	delete(c.namespaces.policyStores, ns.ID)
+
+	if err := ClearView(c.namespaceView(ns)); err != nil {
+		c.logger.Error("core: failed to clear the data of the namespace", "path", ns.Path, "error", err)
+	}
+	if c.tokenStore != nil {
+		if err := c.tokenStore.clearNamespaceRoles(ns.ID); err != nil {
+			c.logger.Error("core: failed to clear the token roles of the namespace", "path", ns.Path, "error", err)
+		}
+	}
+
+	if c.logger.IsInfo() {
+		c.logger.Info("core: deleted namespace", "path", ns.Path)
+	}
+	return nil
+}
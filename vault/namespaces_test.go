@@ -0,0 +1,367 @@
+package vault
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/vault/logical"
+)
+
+// testNamespaceRequest makes a request in a namespace with the given token
+func testNamespaceRequest(t *testing.T, c *Core, namespace, token string, op logical.Operation, path string, data map[string]interface{}) (*logical.Response, error) {
+	req := logical.TestRequest(t, op, path)
+	req.Namespace = namespace
+	req.ClientToken = token
+	if data != nil {
+		req.Data = data
+	}
+	return c.HandleRequest(req)
+}
+
+func TestCore_Namespaces_CRUD(t *testing.T) {
+	c, key, root := TestCoreUnsealed(t)
+
+	for _, path := range []string{"team-a", "team-a/dev", "team-b"} {
+		resp, err := testNamespaceRequest(t, c, "", root, logical.UpdateOperation, "sys/namespaces/"+path, nil)
+		if err != nil {
+			t.Fatalf("err: %v %#v", err, resp)
+		}
+		if resp.Data["path"] != path+"/" || resp.Data["id"] == "" {
+			t.Fatalf("bad: %#v", resp)
+		}
+	}
+
+	// The parent of a namespace must exist
+	resp, err := testNamespaceRequest(t, c, "", root, logical.UpdateOperation, "sys/namespaces/team-c/dev", nil)
+	if err == nil || !errwrap.Contains(err, logical.ErrInvalidRequest.Error()) {
+		t.Fatalf("expected invalid request, got: %v %#v", err, resp)
+	}
+
+	resp, err = testNamespaceRequest(t, c, "", root, logical.ListOperation, "sys/namespaces", nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if expected := []string{"team-a/", "team-b/"}; !reflect.DeepEqual(resp.Data["keys"], expected) {
+		t.Fatalf("bad: %#v", resp.Data["keys"])
+	}
+
+	// The paths are relative to the namespace of the request
+	resp, err = testNamespaceRequest(t, c, "team-a", root, logical.ListOperation, "sys/namespaces", nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if expected := []string{"dev/"}; !reflect.DeepEqual(resp.Data["keys"], expected) {
+		t.Fatalf("bad: %#v", resp.Data["keys"])
+	}
+	resp, err = testNamespaceRequest(t, c, "team-a", root, logical.ReadOperation, "sys/namespaces/dev", nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if resp.Data["path"] != "dev/" {
+		t.Fatalf("bad: %#v", resp)
+	}
+
+	// A namespace with child namespaces cannot be deleted
+	resp, err = testNamespaceRequest(t, c, "", root, logical.DeleteOperation, "sys/namespaces/team-a", nil)
+	if err == nil {
+		t.Fatalf("expected error, got: %#v", resp)
+	}
+
+	// The namespaces are persisted
+	c2, err := NewCore(&CoreConfig{
+		Physical:     c.physical,
+		DisableMlock: true,
+	})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if unseal, err := TestCoreUnseal(c2, key); err != nil || !unseal {
+		t.Fatalf("err: %v", err)
+	}
+	if ns := c2.namespaceByPath("team-a/dev"); ns == nil || ns.ID != c.namespaceByPath("team-a/dev").ID {
+		t.Fatalf("bad: %#v", ns)
+	}
+
+	for _, path := range []string{"team-a/dev", "team-a"} {
+		if _, err := testNamespaceRequest(t, c, "", root, logical.DeleteOperation, "sys/namespaces/"+path, nil); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+	}
+	if ns := c.namespaceByPath("team-a"); ns != nil {
+		t.Fatalf("bad: %#v", ns)
+	}
+
+	// Requests cannot be made in a namespace that doesn't exist
+	resp, err = testNamespaceRequest(t, c, "team-a", root, logical.ListOperation, "sys/namespaces", nil)
+	if err == nil || !errwrap.Contains(err, logical.ErrInvalidRequest.Error()) {
+		t.Fatalf("expected invalid request, got: %v %#v", err, resp)
+	}
+}
+
+func TestCore_Namespaces_Mounts(t *testing.T) {
+	c, _, root := TestCoreUnsealed(t)
+
+	if _, err := testNamespaceRequest(t, c, "", root, logical.UpdateOperation, "sys/namespaces/team-a", nil); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if _, err := testNamespaceRequest(t, c, "team-a", root, logical.UpdateOperation, "sys/mounts/secret", map[string]interface{}{
+		"type": "generic",
+	}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if match := c.router.MatchingMount("team-a/secret/foo"); match != "team-a/secret/" {
+		t.Fatalf("bad: %q", match)
+	}
+
+	// The mount of the namespace is separate from the one of the root
+	// namespace
+	if _, err := testNamespaceRequest(t, c, "team-a", root, logical.UpdateOperation, "secret/foo", map[string]interface{}{
+		"value": "a",
+	}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	resp, err := testNamespaceRequest(t, c, "", root, logical.ReadOperation, "secret/foo", nil)
+	if err != nil || resp != nil {
+		t.Fatalf("bad: %v %#v", err, resp)
+	}
+
+	// The paths of a namespace can be requested from its parent with its
+	// path as prefix
+	resp, err = testNamespaceRequest(t, c, "", root, logical.ReadOperation, "team-a/secret/foo", nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if resp == nil || resp.Data["value"] != "a" {
+		t.Fatalf("bad: %#v", resp)
+	}
+
+	// Only the mounts of the namespace and the shared mounts are listed
+	resp, err = testNamespaceRequest(t, c, "team-a", root, logical.ReadOperation, "sys/mounts", nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	for _, path := range []string{"secret/", "sys/", "cubbyhole/"} {
+		if _, ok := resp.Data[path]; !ok {
+			t.Fatalf("missing %s: %#v", path, resp.Data)
+		}
+	}
+	if len(resp.Data) != 3 {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+	resp, err = testNamespaceRequest(t, c, "", root, logical.ReadOperation, "sys/mounts", nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if _, ok := resp.Data["team-a/secret/"]; ok {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+
+	// A namespace cannot be created over a mount, nor a mount over a
+	// namespace
+	resp, err = testNamespaceRequest(t, c, "", root, logical.UpdateOperation, "sys/namespaces/secret", nil)
+	if err == nil {
+		t.Fatalf("expected error, got: %#v", resp)
+	}
+	resp, err = testNamespaceRequest(t, c, "", root, logical.UpdateOperation, "sys/mounts/team-a/other", map[string]interface{}{
+		"type": "generic",
+	})
+	if err == nil {
+		t.Fatalf("expected error, got: %#v", resp)
+	}
+
+	// The paths of the system backend managing the core are only available
+	// in the root namespace
+	resp, err = testNamespaceRequest(t, c, "team-a", root, logical.ReadOperation, "sys/audit", nil)
+	if err == nil || !errwrap.Contains(err, logical.ErrInvalidRequest.Error()) {
+		t.Fatalf("expected invalid request, got: %v %#v", err, resp)
+	}
+
+	// A namespace with mounts cannot be deleted
+	resp, err = testNamespaceRequest(t, c, "", root, logical.DeleteOperation, "sys/namespaces/team-a", nil)
+	if err == nil {
+		t.Fatalf("expected error, got: %#v", resp)
+	}
+	if _, err := testNamespaceRequest(t, c, "team-a", root, logical.DeleteOperation, "sys/mounts/secret", nil); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if _, err := testNamespaceRequest(t, c, "", root, logical.DeleteOperation, "sys/namespaces/team-a", nil); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+}
+
+func TestCore_Namespaces_PoliciesAndTokens(t *testing.T) {
+	c, _, root := TestCoreUnsealed(t)
+
+	if _, err := testNamespaceRequest(t, c, "", root, logical.UpdateOperation, "sys/namespaces/team-a", nil); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if _, err := testNamespaceRequest(t, c, "team-a", root, logical.UpdateOperation, "sys/mounts/secret", map[string]interface{}{
+		"type": "generic",
+	}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if _, err := testNamespaceRequest(t, c, "team-a", root, logical.UpdateOperation, "secret/foo", map[string]interface{}{
+		"value": "a",
+	}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// The policies of the namespace are separate from the ones of the root
+	// namespace, and their paths are relative to the namespace
+	if _, err := testNamespaceRequest(t, c, "team-a", root, logical.UpdateOperation, "sys/policy/reader", map[string]interface{}{
+		"rules": `path "secret/*" { capabilities = ["read"] }`,
+	}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	resp, err := testNamespaceRequest(t, c, "", root, logical.ReadOperation, "sys/policy/reader", nil)
+	if err != nil || resp != nil {
+		t.Fatalf("bad: %v %#v", err, resp)
+	}
+
+	// Create a token in the namespace
+	resp, err = testNamespaceRequest(t, c, "team-a", root, logical.UpdateOperation, "auth/token/create", map[string]interface{}{
+		"policies": []string{"reader"},
+	})
+	if err != nil {
+		t.Fatalf("err: %v %#v", err, resp)
+	}
+	if len(resp.Warnings) != 0 {
+		t.Fatalf("bad: %#v", resp.Warnings)
+	}
+	token := resp.Auth.ClientToken
+	te, err := c.tokenStore.Lookup(token)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if te.NamespaceID != c.namespaceByPath("team-a").ID {
+		t.Fatalf("bad: %#v", te)
+	}
+
+	resp, err = testNamespaceRequest(t, c, "team-a", token, logical.ReadOperation, "secret/foo", nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if resp == nil || resp.Data["value"] != "a" {
+		t.Fatalf("bad: %#v", resp)
+	}
+	resp, err = testNamespaceRequest(t, c, "team-a", token, logical.UpdateOperation, "secret/foo", map[string]interface{}{
+		"value": "b",
+	})
+	if err == nil || !errwrap.Contains(err, logical.ErrPermissionDenied.Error()) {
+		t.Fatalf("expected permission denied, got: %v %#v", err, resp)
+	}
+
+	// The token cannot be used outside of its namespace, while requests
+	// prefixed with the path of the namespace are made in the namespace
+	resp, err = testNamespaceRequest(t, c, "", token, logical.ReadOperation, "secret/foo", nil)
+	if err == nil || !errwrap.Contains(err, logical.ErrPermissionDenied.Error()) {
+		t.Fatalf("expected permission denied, got: %v %#v", err, resp)
+	}
+	resp, err = testNamespaceRequest(t, c, "", token, logical.ReadOperation, "team-a/secret/foo", nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if resp == nil || resp.Data["value"] != "a" {
+		t.Fatalf("bad: %#v", resp)
+	}
+
+	capabilities, err := c.Capabilities(token, "team-a/secret/foo")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !reflect.DeepEqual(capabilities, []string{ReadCapability}) {
+		t.Fatalf("bad: %#v", capabilities)
+	}
+	capabilities, err = c.Capabilities(token, "secret/foo")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !reflect.DeepEqual(capabilities, []string{DenyCapability}) {
+		t.Fatalf("bad: %#v", capabilities)
+	}
+
+	// The tokens of a namespace cannot be looked up from another namespace
+	if _, err := testNamespaceRequest(t, c, "", root, logical.UpdateOperation, "sys/namespaces/team-b", nil); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	resp, err = testNamespaceRequest(t, c, "team-b", root, logical.UpdateOperation, "auth/token/lookup", map[string]interface{}{
+		"token": token,
+	})
+	if err == nil {
+		t.Fatalf("expected error, got: %#v", resp)
+	}
+	resp, err = testNamespaceRequest(t, c, "", root, logical.UpdateOperation, "auth/token/lookup", map[string]interface{}{
+		"token": token,
+	})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if resp.Data["namespace_path"] != "team-a/" {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+}
+
+func TestCore_Namespaces_Login(t *testing.T) {
+	noop := &NoopBackend{
+		Login: []string{"login"},
+		Response: &logical.Response{
+			Auth: &logical.Auth{
+				Policies:    []string{"foo"},
+				DisplayName: "armon",
+			},
+		},
+	}
+	c, _, root := TestCoreUnsealed(t)
+	c.credentialBackends["noop"] = func(conf *logical.BackendConfig) (logical.Backend, error) {
+		return noop, nil
+	}
+
+	if _, err := testNamespaceRequest(t, c, "", root, logical.UpdateOperation, "sys/namespaces/team-a", nil); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if _, err := testNamespaceRequest(t, c, "team-a", root, logical.UpdateOperation, "sys/auth/foo", map[string]interface{}{
+		"type": "noop",
+	}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Logging in through the path of the namespace relative to the root
+	// namespace issues a token of the namespace
+	nsID := c.namespaceByPath("team-a").ID
+	for _, tc := range []struct {
+		namespace string
+		path      string
+	}{
+		{"team-a", "auth/foo/login"},
+		{"", "auth/team-a/foo/login"},
+	} {
+		resp, err := testNamespaceRequest(t, c, tc.namespace, "", logical.UpdateOperation, tc.path, nil)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		te, err := c.tokenStore.Lookup(resp.Auth.ClientToken)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if te.NamespaceID != nsID || te.Path != "auth/team-a/foo/login" {
+			t.Fatalf("bad: %#v", te)
+		}
+	}
+
+	// The auth mount of the namespace is only listed in it
+	resp, err := testNamespaceRequest(t, c, "team-a", root, logical.ReadOperation, "sys/auth", nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if _, ok := resp.Data["foo/"]; !ok {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+	resp, err = testNamespaceRequest(t, c, "", root, logical.ReadOperation, "sys/auth", nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if _, ok := resp.Data["team-a/foo/"]; ok {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+}
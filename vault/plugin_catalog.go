@@ -0,0 +1,226 @@
+package vault
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/hashicorp/vault/helper/jsonutil"
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/plugin"
+)
+
+const (
+	// pluginCatalogPath is the path of the plugin catalog in the barrier
+	pluginCatalogPath = "core/plugin-catalog/"
+
+	// pluginBackendType is the type of the mounts of plugin backends, whose
+	// plugin is given by the plugin_name config
+	pluginBackendType = "plugin"
+)
+
+// ErrPluginDirectoryNotSet is returned when registering or running a plugin
+// without a plugin directory
+var ErrPluginDirectoryNotSet = errors.New("plugin directory is not configured")
+
+// PluginCatalog holds the plugins that can be mounted. Plugin commands are
+// relative to the plugin directory, and only binaries in that directory can
+// be run.
+type PluginCatalog struct {
+	directory string
+	view      *BarrierView
+	lock      sync.RWMutex
+}
+
+// Get returns the runner of the plugin with the given name, or nil if
+// there is none. The command of the runner is relative to the plugin
+// directory.
+func (p *PluginCatalog) Get(name string) (*plugin.Runner, error) {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	out, err := p.view.Get(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read plugin: %v", err)
+	}
+	if out == nil {
+		return nil, nil
+	}
+
+	runner := new(plugin.Runner)
+	if err := jsonutil.DecodeJSON(out.Value, runner); err != nil {
+		return nil, fmt.Errorf("failed to decode plugin: %v", err)
+	}
+	return runner, nil
+}
+
+// Set registers a plugin. The command must be a path within the plugin
+// directory.
+func (p *PluginCatalog) Set(name, command string, args []string, sha256 []byte) error {
+	if p.directory == "" {
+		return ErrPluginDirectoryNotSet
+	}
+
+	command, err := filepath.Rel(p.directory, filepath.Join(p.directory, command))
+	if err != nil || command == "." || command == ".." || strings.HasPrefix(command, ".."+string(filepath.Separator)) {
+		return logical.CodedError(400, "plugin command must be within the plugin directory")
+	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	entry, err := logical.StorageEntryJSON(name, &plugin.Runner{
+		Name:    name,
+		Command: command,
+		Args:    args,
+		Sha256:  sha256,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode plugin: %v", err)
+	}
+	if err := p.view.Put(entry); err != nil {
+		return fmt.Errorf("failed to persist plugin: %v", err)
+	}
+	return nil
+}
+
+// Delete removes a plugin from the catalog
+func (p *PluginCatalog) Delete(name string) error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	return p.view.Delete(name)
+}
+
+// List returns the names of the plugins in the catalog
+func (p *PluginCatalog) List() ([]string, error) {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	names, err := CollectKeys(p.view)
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// pluginBackendFactory returns the factory of plugin backends, which runs
+// the plugin named by the plugin_name config
+func (c *Core) pluginBackendFactory(config *logical.BackendConfig) (logical.Backend, error) {
+	name := config.Config["plugin_name"]
+	if name == "" {
+		return nil, fmt.Errorf("plugin_name must be set for %s backends", pluginBackendType)
+	}
+	if c.pluginCatalog.directory == "" {
+		return nil, ErrPluginDirectoryNotSet
+	}
+
+	runner, err := c.pluginCatalog.Get(name)
+	if err != nil {
+		return nil, err
+	}
+	if runner == nil {
+		return nil, fmt.Errorf("plugin %q not found in the catalog", name)
+	}
+	runner.Command = filepath.Join(c.pluginCatalog.directory, runner.Command)
+
+	return plugin.NewBackend(runner, c.enableMlock, config)
+}
+
+// reloadPluginMounts restarts the plugin backends of the mounts running the
+// named plugin, or of the given mount paths, without unmounting them, so that
+// their leases are kept. Auth mounts are given with their auth/ prefix.
+// Mounts whose plugin failed to start when unsealing are mounted again.
+func (c *Core) reloadPluginMounts(name string, paths []string) error {
+	if (name == "") == (len(paths) == 0) {
+		return logical.CodedError(400, "exactly one of plugin or mounts must be given")
+	}
+
+	wanted := make(map[string]bool, len(paths))
+	for _, path := range paths {
+		wanted[sanitizeMountPath(path)] = true
+	}
+	matches := func(path string, entry *MountEntry) bool {
+		if entry.Type != pluginBackendType {
+			return false
+		}
+		if name != "" {
+			return entry.Config.PluginName == name
+		}
+		if !wanted[path] {
+			return false
+		}
+		delete(wanted, path)
+		return true
+	}
+
+	c.mountsLock.Lock()
+	for _, entry := range c.mounts.Entries {
+		if !matches(entry.Path, entry) {
+			continue
+		}
+		view := c.mountEntryView(entry, backendBarrierPrefix)
+		backend, err := c.newLogicalBackend(entry.Type, c.mountEntrySysView(entry), view, entry.backendConfig())
+		if err == nil {
+			err = c.reloadBackend(entry.Path, entry, backend, view)
+		}
+		if err != nil {
+			c.mountsLock.Unlock()
+			return fmt.Errorf("failed to reload mount %q: %v", entry.Path, err)
+		}
+	}
+	c.mountsLock.Unlock()
+
+	c.authLock.Lock()
+	defer c.authLock.Unlock()
+	for _, entry := range c.auth.Entries {
+		path := credentialRoutePrefix + entry.Path
+		if !matches(path, entry) {
+			continue
+		}
+		view := c.mountEntryView(entry, credentialBarrierPrefix)
+		backend, err := c.newCredentialBackend(entry.Type, c.mountEntrySysView(entry), view, entry.backendConfig())
+		if err == nil {
+			err = c.reloadBackend(path, entry, backend, view)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to reload mount %q: %v", path, err)
+		}
+	}
+
+	if len(wanted) != 0 {
+		var missing []string
+		for path := range wanted {
+			missing = append(missing, path)
+		}
+		sort.Strings(missing)
+		return logical.CodedError(400, fmt.Sprintf("no plugin mounts at %s", strings.Join(missing, ", ")))
+	}
+	return nil
+}
+
+// reloadBackend replaces the backend mounted at the given path, or mounts
+// it if it isn't mounted
+func (c *Core) reloadBackend(path string, entry *MountEntry, backend logical.Backend, view *BarrierView) error {
+	if c.router.MatchingMount(path) != path {
+		if err := c.router.Mount(backend, path, entry, view); err != nil {
+			backend.Cleanup()
+			return err
+		}
+		if entry.Tainted {
+			c.router.Taint(path)
+		}
+	} else if err := c.router.Reload(path, backend); err != nil {
+		backend.Cleanup()
+		return err
+	}
+
+	if c.logger.IsInfo() {
+		c.logger.Info("core: reloaded plugin backend", "path", path, "plugin", entry.Config.PluginName)
+	}
+	return nil
+}
@@ -0,0 +1,153 @@
+package vault
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/plugin"
+)
+
+// TestPluginCatalog_helper isn't a real test: it is the plugin run by the
+// other tests, serving a generic backend generating leases
+func TestPluginCatalog_helper(t *testing.T) {
+	if os.Getenv(plugin.MagicCookieKey) == "" {
+		return
+	}
+
+	if err := plugin.Serve(LeasedPassthroughBackendFactory); err != nil {
+		fmt.Fprintf(os.Stderr, "err: %v\n", err)
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
+
+func TestCore_PluginBackend(t *testing.T) {
+	c, _, root := TestCoreUnsealed(t)
+	c.pluginCatalog.directory = filepath.Dir(os.Args[0])
+
+	contents, err := ioutil.ReadFile(os.Args[0])
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	sum := sha256.Sum256(contents)
+
+	request := func(op logical.Operation, path string, data map[string]interface{}) (*logical.Response, error) {
+		req := logical.TestRequest(t, op, path)
+		req.ClientToken = root
+		req.Data = data
+		return c.HandleRequest(req)
+	}
+	mustRequest := func(op logical.Operation, path string, data map[string]interface{}) *logical.Response {
+		resp, err := request(op, path, data)
+		if err != nil || (resp != nil && resp.IsError()) {
+			t.Fatalf("err: %v resp: %#v", err, resp)
+		}
+		return resp
+	}
+
+	// Plugins must be within the plugin directory
+	_, err = request(logical.UpdateOperation, "sys/plugins/catalog/bad", map[string]interface{}{
+		"sha256":  hex.EncodeToString(sum[:]),
+		"command": "../" + filepath.Base(os.Args[0]),
+	})
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+
+	mustRequest(logical.UpdateOperation, "sys/plugins/catalog/generic-plugin", map[string]interface{}{
+		"sha256":  hex.EncodeToString(sum[:]),
+		"command": filepath.Base(os.Args[0]),
+		"args":    "-test.run=TestPluginCatalog_helper",
+	})
+
+	resp := mustRequest(logical.ListOperation, "sys/plugins/catalog/", nil)
+	if !reflect.DeepEqual(resp.Data["keys"], []string{"generic-plugin"}) {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+	resp = mustRequest(logical.ReadOperation, "sys/plugins/catalog/generic-plugin", nil)
+	expected := map[string]interface{}{
+		"name":    "generic-plugin",
+		"command": filepath.Base(os.Args[0]),
+		"args":    []string{"-test.run=TestPluginCatalog_helper"},
+		"sha256":  hex.EncodeToString(sum[:]),
+	}
+	if !reflect.DeepEqual(resp.Data, expected) {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+
+	mustRequest(logical.UpdateOperation, "sys/mounts/plugin", map[string]interface{}{
+		"type": "plugin",
+		"config": map[string]interface{}{
+			"plugin_name": "generic-plugin",
+		},
+	})
+
+	mustRequest(logical.UpdateOperation, "plugin/foo", map[string]interface{}{
+		"foo": "bar",
+	})
+	resp = mustRequest(logical.ReadOperation, "plugin/foo", nil)
+	if resp.Data["foo"] != "bar" {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+
+	resp = mustRequest(logical.ReadOperation, "sys/mounts", nil)
+	config := resp.Data["plugin/"].(map[string]interface{})["config"].(map[string]interface{})
+	if config["plugin_name"] != "generic-plugin" {
+		t.Fatalf("bad: %#v", config)
+	}
+
+	// Reloading restarts the plugin and keeps the mount's data
+	for _, data := range []map[string]interface{}{
+		{"plugin": "generic-plugin"},
+		{"mounts": "plugin"},
+	} {
+		previous := c.router.MatchingBackend("plugin/")
+		mustRequest(logical.UpdateOperation, "sys/plugins/reload/backend", data)
+		if c.router.MatchingBackend("plugin/") == previous {
+			t.Fatal("backend was not reloaded")
+		}
+		resp = mustRequest(logical.ReadOperation, "plugin/foo", nil)
+		if resp.Data["foo"] != "bar" {
+			t.Fatalf("bad: %#v", resp.Data)
+		}
+	}
+	for _, data := range []map[string]interface{}{
+		{"mounts": "secret/"},
+		{},
+		{"plugin": "generic-plugin", "mounts": "plugin/"},
+	} {
+		if _, err := request(logical.UpdateOperation, "sys/plugins/reload/backend", data); err == nil {
+			t.Fatalf("expected an error for %#v", data)
+		}
+	}
+
+	// Binaries that don't match their checksum aren't run
+	sum[0]++
+	mustRequest(logical.UpdateOperation, "sys/plugins/catalog/tampered", map[string]interface{}{
+		"sha256":  hex.EncodeToString(sum[:]),
+		"command": filepath.Base(os.Args[0]),
+	})
+	_, err = request(logical.UpdateOperation, "sys/mounts/tampered", map[string]interface{}{
+		"type": "plugin",
+		"config": map[string]interface{}{
+			"plugin_name": "tampered",
+		},
+	})
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+
+	mustRequest(logical.DeleteOperation, "sys/mounts/plugin", nil)
+	mustRequest(logical.DeleteOperation, "sys/plugins/catalog/generic-plugin", nil)
+	resp = mustRequest(logical.ReadOperation, "sys/plugins/catalog/generic-plugin", nil)
+	if resp != nil {
+		t.Fatalf("bad: %#v", resp)
+	}
+}
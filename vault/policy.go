@@ -3,10 +3,12 @@ package vault
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/hashicorp/go-multierror"
 	"github.com/hashicorp/hcl"
 	"github.com/hashicorp/hcl/hcl/ast"
+	"github.com/hashicorp/vault/helper/duration"
 )
 
 const (
@@ -63,6 +65,29 @@ type PathCapabilities struct {
 	Capabilities       []string
 	CapabilitiesBitmap uint32 `hcl:"-"`
 	Glob               bool
+	ControlGroup       *ControlGroup `hcl:"-"`
+
+	// AllowedParameters, DeniedParameters and RequiredParameters constrain
+	// the data of create and update requests to the path
+	AllowedParameters  map[string][]interface{} `hcl:"allowed_parameters"`
+	DeniedParameters   map[string][]interface{} `hcl:"denied_parameters"`
+	RequiredParameters []string                 `hcl:"required_parameters"`
+}
+
+// ControlGroup requires the response of a request to be approved by
+// authorizers before it is released. The response is wrapped, and the
+// wrapping token can only be unwrapped once every factor is satisfied.
+type ControlGroup struct {
+	TTL     time.Duration
+	Factors []*ControlGroupFactor
+}
+
+// ControlGroupFactor is satisfied once the given number of distinct entities
+// belonging to any of the given identity groups have authorized the request
+type ControlGroupFactor struct {
+	Name       string   `json:"name"`
+	GroupNames []string `json:"group_names"`
+	Approvals  int      `json:"approvals"`
 }
 
 // Parse is used to parse the specified ACL rules into an
@@ -117,6 +142,10 @@ func parsePaths(result *Policy, list *ast.ObjectList) error {
 		valid := []string{
 			"policy",
 			"capabilities",
+			"control_group",
+			"allowed_parameters",
+			"denied_parameters",
+			"required_parameters",
 		}
 		if err := checkHCLKeys(item.Val, valid); err != nil {
 			return multierror.Prefix(err, fmt.Sprintf("path %q:", key))
@@ -128,6 +157,19 @@ func parsePaths(result *Policy, list *ast.ObjectList) error {
 			return multierror.Prefix(err, fmt.Sprintf("path %q:", key))
 		}
 
+		if o, ok := item.Val.(*ast.ObjectType); ok {
+			if cgList := o.List.Filter("control_group"); len(cgList.Items) > 0 {
+				if len(cgList.Items) > 1 {
+					return fmt.Errorf("path %q: only one control_group can be specified", key)
+				}
+				cg, err := parseControlGroup(cgList.Items[0].Val)
+				if err != nil {
+					return multierror.Prefix(err, fmt.Sprintf("path %q: control_group:", key))
+				}
+				pc.ControlGroup = cg
+			}
+		}
+
 		// Strip a leading '/' as paths in Vault start after the / in the API path
 		if len(pc.Prefix) > 0 && pc.Prefix[0] == '/' {
 			pc.Prefix = pc.Prefix[1:]
@@ -180,6 +222,83 @@ func parsePaths(result *Policy, list *ast.ObjectList) error {
 	return nil
 }
 
+func parseControlGroup(node ast.Node) (*ControlGroup, error) {
+	if err := checkHCLKeys(node, []string{"ttl", "factor"}); err != nil {
+		return nil, err
+	}
+
+	var raw struct {
+		TTL string `hcl:"ttl"`
+	}
+	if err := hcl.DecodeObject(&raw, node); err != nil {
+		return nil, err
+	}
+
+	var cg ControlGroup
+	if raw.TTL != "" {
+		ttl, err := duration.ParseDurationSecond(raw.TTL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ttl: %v", err)
+		}
+		cg.TTL = ttl
+	}
+
+	o, ok := node.(*ast.ObjectType)
+	if !ok {
+		return nil, fmt.Errorf("expected an object")
+	}
+	for _, item := range o.List.Filter("factor").Items {
+		if len(item.Keys) == 0 {
+			return nil, fmt.Errorf("factor must be named")
+		}
+		name := item.Keys[0].Token.Value().(string)
+
+		if err := checkHCLKeys(item.Val, []string{"identity"}); err != nil {
+			return nil, multierror.Prefix(err, fmt.Sprintf("factor %q:", name))
+		}
+		fo, ok := item.Val.(*ast.ObjectType)
+		if !ok {
+			return nil, fmt.Errorf("factor %q: expected an object", name)
+		}
+		identity := fo.List.Filter("identity")
+		if len(identity.Items) != 1 {
+			return nil, fmt.Errorf("factor %q: exactly one identity block must be specified", name)
+		}
+		if err := checkHCLKeys(identity.Items[0].Val, []string{"group_names", "approvals"}); err != nil {
+			return nil, multierror.Prefix(err, fmt.Sprintf("factor %q:", name))
+		}
+
+		factor := ControlGroupFactor{
+			Name:      name,
+			Approvals: 1,
+		}
+		var rawIdentity struct {
+			GroupNames []string `hcl:"group_names"`
+			Approvals  *int     `hcl:"approvals"`
+		}
+		if err := hcl.DecodeObject(&rawIdentity, identity.Items[0].Val); err != nil {
+			return nil, multierror.Prefix(err, fmt.Sprintf("factor %q:", name))
+		}
+		if len(rawIdentity.GroupNames) == 0 {
+			return nil, fmt.Errorf("factor %q: group_names must be specified", name)
+		}
+		factor.GroupNames = rawIdentity.GroupNames
+		if rawIdentity.Approvals != nil {
+			if *rawIdentity.Approvals < 1 {
+				return nil, fmt.Errorf("factor %q: approvals must be at least 1", name)
+			}
+			factor.Approvals = *rawIdentity.Approvals
+		}
+
+		cg.Factors = append(cg.Factors, &factor)
+	}
+	if len(cg.Factors) == 0 {
+		return nil, fmt.Errorf("at least one factor must be specified")
+	}
+
+	return &cg, nil
+}
+
 func checkHCLKeys(node ast.Node, valid []string) error {
 	var list *ast.ObjectList
 	switch n := node.(type) {
@@ -28,6 +28,10 @@ const (
 path "cubbyhole/response" {
     capabilities = ["create", "read"]
 }
+
+path "sys/wrapping/unwrap" {
+    capabilities = ["update"]
+}
 `
 
 	// defaultPolicy is the "default" policy
@@ -56,6 +60,10 @@ path "sys/capabilities-self" {
     capabilities = ["update"]
 }
 
+path "sys/internal/ui/mounts/*" {
+    capabilities = ["read"]
+}
+
 path "sys/renew" {
     capabilities = ["update"]
 }
@@ -63,6 +71,22 @@ path "sys/renew" {
 path "sys/renew/*" {
     capabilities = ["update"]
 }
+
+path "sys/wrapping/wrap" {
+    capabilities = ["update"]
+}
+
+path "sys/wrapping/lookup" {
+    capabilities = ["update"]
+}
+
+path "sys/wrapping/unwrap" {
+    capabilities = ["update"]
+}
+
+path "sys/wrapping/rewrap" {
+    capabilities = ["update"]
+}
 `
 )
 
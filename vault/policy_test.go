@@ -4,6 +4,7 @@ import (
 	"reflect"
 	"strings"
 	"testing"
+	"time"
 )
 
 var rawPolicy = strings.TrimSpace(`
@@ -36,6 +37,19 @@ path "/foo/bar" {
 path "foo/bar" {
 	capabilities = ["create", "sudo"]
 }
+
+# Constrain the parameters written to foobaz
+path "foo/baz" {
+	capabilities = ["update"]
+	allowed_parameters = {
+		"name" = []
+		"address" = ["10.0.0.1", "10.0.0.*"]
+	}
+	denied_parameters = {
+		"insecure_tls" = ["true"]
+	}
+	required_parameters = ["name"]
+}
 `)
 
 func TestPolicy_Parse(t *testing.T) {
@@ -49,35 +63,71 @@ func TestPolicy_Parse(t *testing.T) {
 	}
 
 	expect := []*PathCapabilities{
-		&PathCapabilities{"", "deny",
-			[]string{
+		&PathCapabilities{
+			Prefix: "",
+			Policy: "deny",
+			Capabilities: []string{
 				"deny",
-			}, DenyCapabilityInt, true},
-		&PathCapabilities{"stage/", "sudo",
-			[]string{
+			},
+			CapabilitiesBitmap: DenyCapabilityInt,
+			Glob:               true,
+		},
+		&PathCapabilities{
+			Prefix: "stage/",
+			Policy: "sudo",
+			Capabilities: []string{
 				"create",
 				"read",
 				"update",
 				"delete",
 				"list",
 				"sudo",
-			}, CreateCapabilityInt | ReadCapabilityInt | UpdateCapabilityInt |
-				DeleteCapabilityInt | ListCapabilityInt | SudoCapabilityInt, true},
-		&PathCapabilities{"prod/version", "read",
-			[]string{
+			},
+			CapabilitiesBitmap: CreateCapabilityInt | ReadCapabilityInt | UpdateCapabilityInt |
+				DeleteCapabilityInt | ListCapabilityInt | SudoCapabilityInt,
+			Glob: true,
+		},
+		&PathCapabilities{
+			Prefix: "prod/version",
+			Policy: "read",
+			Capabilities: []string{
 				"read",
 				"list",
-			}, ReadCapabilityInt | ListCapabilityInt, false},
-		&PathCapabilities{"foo/bar", "read",
-			[]string{
+			},
+			CapabilitiesBitmap: ReadCapabilityInt | ListCapabilityInt,
+		},
+		&PathCapabilities{
+			Prefix: "foo/bar",
+			Policy: "read",
+			Capabilities: []string{
 				"read",
 				"list",
-			}, ReadCapabilityInt | ListCapabilityInt, false},
-		&PathCapabilities{"foo/bar", "",
-			[]string{
+			},
+			CapabilitiesBitmap: ReadCapabilityInt | ListCapabilityInt,
+		},
+		&PathCapabilities{
+			Prefix: "foo/bar",
+			Capabilities: []string{
 				"create",
 				"sudo",
-			}, CreateCapabilityInt | SudoCapabilityInt, false},
+			},
+			CapabilitiesBitmap: CreateCapabilityInt | SudoCapabilityInt,
+		},
+		&PathCapabilities{
+			Prefix: "foo/baz",
+			Capabilities: []string{
+				"update",
+			},
+			CapabilitiesBitmap: UpdateCapabilityInt,
+			AllowedParameters: map[string][]interface{}{
+				"name":    []interface{}{},
+				"address": []interface{}{"10.0.0.1", "10.0.0.*"},
+			},
+			DeniedParameters: map[string][]interface{}{
+				"insecure_tls": []interface{}{"true"},
+			},
+			RequiredParameters: []string{"name"},
+		},
 	}
 	if !reflect.DeepEqual(p.Paths, expect) {
 		t.Errorf("expected \n\n%#v\n\n to be \n\n%#v\n\n", p.Paths, expect)
@@ -148,3 +198,63 @@ path "/" {
 		t.Errorf("bad error: %s", err)
 	}
 }
+
+func TestPolicy_ParseControlGroup(t *testing.T) {
+	p, err := Parse(strings.TrimSpace(`
+path "secret/foo" {
+	capabilities = ["read"]
+	control_group = {
+		ttl = "4h"
+		factor "managers" {
+			identity {
+				group_names = ["managers", "admins"]
+				approvals = 2
+			}
+		}
+		factor "security" {
+			identity {
+				group_names = ["security"]
+			}
+		}
+	}
+}
+`))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	expect := &ControlGroup{
+		TTL: 4 * time.Hour,
+		Factors: []*ControlGroupFactor{
+			&ControlGroupFactor{
+				Name:       "managers",
+				GroupNames: []string{"managers", "admins"},
+				Approvals:  2,
+			},
+			&ControlGroupFactor{
+				Name:       "security",
+				GroupNames: []string{"security"},
+				Approvals:  1,
+			},
+		},
+	}
+	if !reflect.DeepEqual(p.Paths[0].ControlGroup, expect) {
+		t.Fatalf("bad: %#v", p.Paths[0].ControlGroup)
+	}
+
+	_, err = Parse(strings.TrimSpace(`
+path "secret/foo" {
+	capabilities = ["read"]
+	control_group = {
+		factor "managers" {
+			identity {
+				approvals = 1
+			}
+		}
+	}
+}
+`))
+	if err == nil || !strings.Contains(err.Error(), `factor "managers": group_names must be specified`) {
+		t.Fatalf("bad error: %v", err)
+	}
+}
@@ -0,0 +1,328 @@
+package vault
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/vault/helper/jsonutil"
+	"github.com/hashicorp/vault/logical"
+)
+
+const (
+	// quotasSubPath is the sub-path used for the resource quotas
+	quotasSubPath = "quotas/"
+
+	// QuotaTypeRateLimit and QuotaTypeLeaseCount are the types of quotas
+	QuotaTypeRateLimit  = "rate-limit"
+	QuotaTypeLeaseCount = "lease-count"
+)
+
+// Quota limits the requests to a path, to a namespace and the namespaces
+// beneath it if only Namespace is set, or to all paths if both are empty.
+// The path is relative to the namespace. Rate limit quotas allow Rate
+// requests per second with bursts of up to Burst requests; lease count
+// quotas allow at most MaxLeases leases under the path or the mounts and
+// auth mounts of the namespace.
+type Quota struct {
+	Name      string `json:"name"`
+	Type      string `json:"type"`
+	Path      string `json:"path"`
+	Namespace string `json:"namespace,omitempty"`
+	Rate      int    `json:"rate,omitempty"`
+	Burst     int    `json:"burst,omitempty"`
+	MaxLeases int    `json:"max_leases,omitempty"`
+
+	// tokens and last are the state of the token bucket of rate limit
+	// quotas
+	tokens float64
+	last   time.Time
+}
+
+// allow consumes a token of a rate limit quota, returning whether the
+// request is allowed. It must be called with the quotas lock held.
+func (q *Quota) allow(now time.Time) bool {
+	burst := float64(q.Burst)
+	if burst < float64(q.Rate) {
+		burst = float64(q.Rate)
+	}
+
+	if q.last.IsZero() {
+		q.tokens = burst
+	} else {
+		q.tokens += now.Sub(q.last).Seconds() * float64(q.Rate)
+		if q.tokens > burst {
+			q.tokens = burst
+		}
+	}
+	q.last = now
+
+	if q.tokens < 1 {
+		return false
+	}
+	q.tokens--
+	return true
+}
+
+// routePrefix returns the prefix of the paths of the requests a quota with
+// a path applies to
+func (q *Quota) routePrefix() string {
+	return namespaceRoutePath(&Namespace{Path: q.Namespace}, q.Path)
+}
+
+// leasePrefixes returns the prefixes of the leases counted by a lease count
+// quota. The leases of a namespace are those of its mounts and auth mounts.
+func (q *Quota) leasePrefixes() []string {
+	if q.Path == "" && q.Namespace != "" {
+		return []string{q.Namespace, credentialRoutePrefix + q.Namespace}
+	}
+	return []string{q.routePrefix()}
+}
+
+// applies returns whether a quota applies to a request in the namespace to
+// the path the request is routed to
+func (q *Quota) applies(namespace, path string) bool {
+	if q.Path == "" {
+		return strings.HasPrefix(namespace, q.Namespace)
+	}
+	return strings.HasPrefix(path, q.routePrefix())
+}
+
+// moreSpecific returns whether a quota takes precedence over another one
+// applying to the same request: quotas with a path over namespace quotas,
+// and namespace quotas over global quotas. Between quotas of the same
+// kind, the one with the longest path or namespace does.
+func (q *Quota) moreSpecific(other *Quota) bool {
+	if (q.Path != "") != (other.Path != "") {
+		return q.Path != ""
+	}
+	qLen, otherLen := len(q.Namespace), len(other.Namespace)
+	if q.Path != "" {
+		qLen, otherLen = len(q.routePrefix()), len(other.routePrefix())
+	}
+	if qLen != otherLen {
+		return qLen > otherLen
+	}
+	return q.Name < other.Name
+}
+
+// trackLeaseCount starts counting the leases of a lease count quota
+func (m *QuotaManager) trackLeaseCount(q *Quota) error {
+	prefixes := q.leasePrefixes()
+	for i, prefix := range prefixes {
+		if err := m.expiration.trackLeaseCount(prefix); err != nil {
+			for _, tracked := range prefixes[:i] {
+				m.expiration.untrackLeaseCount(tracked)
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// untrackLeaseCount stops counting the leases of a lease count quota
+func (m *QuotaManager) untrackLeaseCount(q *Quota) {
+	for _, prefix := range q.leasePrefixes() {
+		m.expiration.untrackLeaseCount(prefix)
+	}
+}
+
+// leaseCount returns the number of leases counted by a lease count quota
+func (m *QuotaManager) leaseCount(q *Quota) int {
+	count := 0
+	for _, prefix := range q.leasePrefixes() {
+		count += m.expiration.leaseCount(prefix)
+	}
+	return count
+}
+
+// QuotaManager holds the resource quotas
+type QuotaManager struct {
+	quotas     map[string]map[string]*Quota
+	view       *BarrierView
+	expiration *ExpirationManager
+	l          sync.Mutex
+}
+
+// Get returns a copy of the quota of the given type and name, or nil if it
+// doesn't exist
+func (m *QuotaManager) Get(quotaType, name string) *Quota {
+	m.l.Lock()
+	defer m.l.Unlock()
+
+	q, ok := m.quotas[quotaType][name]
+	if !ok {
+		return nil
+	}
+	ret := *q
+	return &ret
+}
+
+// List returns the names of the quotas of the given type
+func (m *QuotaManager) List(quotaType string) []string {
+	m.l.Lock()
+	defer m.l.Unlock()
+
+	names := make([]string, 0, len(m.quotas[quotaType]))
+	for name := range m.quotas[quotaType] {
+		names = append(names, name)
+	}
+	return names
+}
+
+// Set creates or replaces a quota and persists it
+func (m *QuotaManager) Set(q *Quota) error {
+	// Start counting the leases before taking the lock, as the prefix may
+	// have to be scanned
+	if q.Type == QuotaTypeLeaseCount {
+		if err := m.trackLeaseCount(q); err != nil {
+			return err
+		}
+	}
+
+	m.l.Lock()
+	defer m.l.Unlock()
+
+	entry, err := logical.StorageEntryJSON(q.Type+"/"+q.Name, q)
+	if err == nil {
+		err = m.view.Put(entry)
+	}
+	if err != nil {
+		if q.Type == QuotaTypeLeaseCount {
+			m.untrackLeaseCount(q)
+		}
+		return fmt.Errorf("failed to persist quota: %v", err)
+	}
+
+	if old, ok := m.quotas[q.Type][q.Name]; ok && old.Type == QuotaTypeLeaseCount {
+		m.untrackLeaseCount(old)
+	}
+	m.quotas[q.Type][q.Name] = q
+	return nil
+}
+
+// Delete removes a quota
+func (m *QuotaManager) Delete(quotaType, name string) error {
+	m.l.Lock()
+	defer m.l.Unlock()
+
+	if err := m.view.Delete(quotaType + "/" + name); err != nil {
+		return fmt.Errorf("failed to delete quota: %v", err)
+	}
+
+	if old, ok := m.quotas[quotaType][name]; ok && old.Type == QuotaTypeLeaseCount {
+		m.untrackLeaseCount(old)
+	}
+	delete(m.quotas[quotaType], name)
+	return nil
+}
+
+// matching returns the quota of the given type that applies to a request
+// in the namespace to the path it is routed to: the most specific of the
+// quotas applying to it, a namespace quota applying to the requests no
+// quota with a path matches, and a global quota applying to the requests no
+// other quota matches. It must be called with the lock held.
+func (m *QuotaManager) matching(quotaType, namespace, path string) *Quota {
+	var match *Quota
+	for _, q := range m.quotas[quotaType] {
+		if !q.applies(namespace, path) {
+			continue
+		}
+		if match == nil || q.moreSpecific(match) {
+			match = q
+		}
+	}
+	return match
+}
+
+// setupQuotas loads the resource quotas
+func (c *Core) setupQuotas() error {
+	view := c.systemBarrierView.SubView(quotasSubPath)
+
+	m := &QuotaManager{
+		quotas: map[string]map[string]*Quota{
+			QuotaTypeRateLimit:  make(map[string]*Quota),
+			QuotaTypeLeaseCount: make(map[string]*Quota),
+		},
+		view:       view,
+		expiration: c.expiration,
+	}
+
+	for quotaType := range m.quotas {
+		names, err := view.List(quotaType + "/")
+		if err != nil {
+			return fmt.Errorf("failed to list quotas: %v", err)
+		}
+		for _, name := range names {
+			out, err := view.Get(quotaType + "/" + name)
+			if err != nil {
+				return fmt.Errorf("failed to read quota: %v", err)
+			}
+			if out == nil {
+				continue
+			}
+			q := new(Quota)
+			if err := jsonutil.DecodeJSON(out.Value, q); err != nil {
+				return fmt.Errorf("failed to decode quota: %v", err)
+			}
+			if q.Type == QuotaTypeLeaseCount {
+				if err := m.trackLeaseCount(q); err != nil {
+					return err
+				}
+			}
+			m.quotas[quotaType][name] = q
+		}
+	}
+
+	c.quotaManager = m
+	return nil
+}
+
+// applyQuotas checks the request against the resource quotas. Requests to
+// sys/quotas are never limited so that quotas can always be fixed. Lease
+// count quotas are checked here for logins and token creations, and for
+// the other requests when the backend returns a secret, so that they only
+// limit the requests creating leases.
+func (c *Core) applyQuotas(req *logical.Request) error {
+	if c.quotaManager == nil || strings.HasPrefix(req.Path, "sys/quotas/") {
+		return nil
+	}
+
+	m := c.quotaManager
+	m.l.Lock()
+	q := m.matching(QuotaTypeRateLimit, req.Namespace, req.Path)
+	allowed := q == nil || q.allow(time.Now())
+	m.l.Unlock()
+	if !allowed {
+		return logical.CodedError(429, fmt.Sprintf("request path %q: rate limit quota exceeded", req.Path))
+	}
+
+	if c.router.LoginPath(req.Path) || strings.HasPrefix(req.Path, "auth/token/create") {
+		return c.checkLeaseCountQuota(req)
+	}
+	return nil
+}
+
+// checkLeaseCountQuota returns an error if a lease created by the request
+// would exceed its lease count quota. Concurrent requests may still exceed
+// the quota by the number of leases being created at the time.
+func (c *Core) checkLeaseCountQuota(req *logical.Request) error {
+	if c.quotaManager == nil {
+		return nil
+	}
+
+	m := c.quotaManager
+	m.l.Lock()
+	var match Quota
+	q := m.matching(QuotaTypeLeaseCount, req.Namespace, req.Path)
+	if q != nil {
+		match = *q
+	}
+	m.l.Unlock()
+
+	if q != nil && m.leaseCount(&match) >= match.MaxLeases {
+		return logical.CodedError(429, fmt.Sprintf("request path %q: lease count quota exceeded", req.Path))
+	}
+	return nil
+}
@@ -0,0 +1,306 @@
+package vault
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/logical"
+)
+
+func TestQuota_Allow(t *testing.T) {
+	q := &Quota{
+		Type:  QuotaTypeRateLimit,
+		Rate:  1,
+		Burst: 2,
+	}
+
+	now := time.Now()
+	if !q.allow(now) || !q.allow(now) {
+		t.Fatal("expected the burst to be allowed")
+	}
+	if q.allow(now) {
+		t.Fatal("expected the request to be limited")
+	}
+	if !q.allow(now.Add(time.Second)) {
+		t.Fatal("expected a token to be added after a second")
+	}
+}
+
+func TestCore_Quotas(t *testing.T) {
+	c, _, root := TestCoreUnsealed(t)
+
+	request := func(op logical.Operation, path string, data map[string]interface{}) (*logical.Response, error) {
+		req := logical.TestRequest(t, op, path)
+		req.ClientToken = root
+		req.Data = data
+		return c.HandleRequest(req)
+	}
+	isQuotaErr := func(err error) bool {
+		coded, ok := err.(logical.HTTPCodedError)
+		return ok && coded.Code() == 429
+	}
+
+	// Quotas must apply to a mount
+	resp, err := request(logical.UpdateOperation, "sys/quotas/rate-limit/bad", map[string]interface{}{
+		"path": "nope/",
+		"rate": 1,
+	})
+	if err == nil || !resp.IsError() {
+		t.Fatalf("expected an error: %#v", resp)
+	}
+
+	resp, err = request(logical.UpdateOperation, "sys/quotas/rate-limit/secret", map[string]interface{}{
+		"path":  "secret/",
+		"rate":  1,
+		"burst": 2,
+	})
+	if err != nil {
+		t.Fatalf("err: %v %#v", err, resp)
+	}
+
+	for i := 0; i < 2; i++ {
+		if _, err := request(logical.ReadOperation, "secret/foo", nil); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+	}
+	if _, err := request(logical.ReadOperation, "secret/foo", nil); !isQuotaErr(err) {
+		t.Fatalf("expected the request to be rate limited: %v", err)
+	}
+
+	// Other paths aren't limited
+	if _, err := request(logical.ReadOperation, "sys/mounts", nil); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	resp, err = request(logical.UpdateOperation, "sys/quotas/lease-count/tokens", map[string]interface{}{
+		"path":       "auth/token/",
+		"max_leases": 1,
+	})
+	if err != nil {
+		t.Fatalf("err: %v %#v", err, resp)
+	}
+	tokenData := map[string]interface{}{
+		"ttl": "1h",
+	}
+	resp, err = request(logical.UpdateOperation, "auth/token/create", tokenData)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	token := resp.Auth.ClientToken
+	if _, err := request(logical.UpdateOperation, "auth/token/create", tokenData); !isQuotaErr(err) {
+		t.Fatalf("expected the lease count quota to be exceeded: %v", err)
+	}
+
+	// Operations that don't create leases aren't limited
+	if _, err := request(logical.ListOperation, "auth/token/accessors", nil); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Revoked leases no longer count
+	if _, err := request(logical.UpdateOperation, "auth/token/revoke/"+token, nil); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	resp, err = request(logical.UpdateOperation, "auth/token/create", tokenData)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if _, err := request(logical.UpdateOperation, "auth/token/create", tokenData); !isQuotaErr(err) {
+		t.Fatalf("expected the lease count quota to be exceeded: %v", err)
+	}
+
+	resp, err = request(logical.ListOperation, "sys/quotas/lease-count", nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !reflect.DeepEqual(resp.Data["keys"], []string{"tokens"}) {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+
+	// Quotas are persisted
+	if err := c.setupQuotas(); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	resp, err = request(logical.ReadOperation, "sys/quotas/rate-limit/secret", nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	expected := map[string]interface{}{
+		"name":      "secret",
+		"type":      QuotaTypeRateLimit,
+		"path":      "secret/",
+		"namespace": "",
+		"rate":      1,
+		"burst":     2,
+	}
+	if !reflect.DeepEqual(resp.Data, expected) {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+
+	if _, err := request(logical.DeleteOperation, "sys/quotas/lease-count/tokens", nil); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if _, err := request(logical.UpdateOperation, "auth/token/create", tokenData); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+}
+
+func TestCore_Quotas_leaseCountSecret(t *testing.T) {
+	noop := &NoopBackend{
+		Response: &logical.Response{
+			Secret: &logical.Secret{
+				LeaseOptions: logical.LeaseOptions{
+					TTL: time.Hour,
+				},
+			},
+		},
+	}
+	c, _, root := TestCoreUnsealed(t)
+	c.logicalBackends["noop"] = func(*logical.BackendConfig) (logical.Backend, error) {
+		return noop, nil
+	}
+
+	request := func(op logical.Operation, path string, data map[string]interface{}) (*logical.Response, error) {
+		req := logical.TestRequest(t, op, path)
+		req.ClientToken = root
+		req.Data = data
+		return c.HandleRequest(req)
+	}
+
+	if _, err := request(logical.UpdateOperation, "sys/mounts/foo", map[string]interface{}{
+		"type": "noop",
+	}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if _, err := request(logical.UpdateOperation, "sys/quotas/lease-count/foo", map[string]interface{}{
+		"path":       "foo/",
+		"max_leases": 1,
+	}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	if _, err := request(logical.ReadOperation, "foo/creds", nil); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	_, err := request(logical.ReadOperation, "foo/creds", nil)
+	if err == nil || !strings.Contains(err.Error(), "lease count quota exceeded") {
+		t.Fatalf("expected the lease count quota to be exceeded: %v", err)
+	}
+
+	// The secret over the quota is revoked
+	noop.Lock()
+	last := noop.Requests[len(noop.Requests)-1]
+	noop.Unlock()
+	if last.Operation != logical.RevokeOperation {
+		t.Fatalf("expected the secret to be revoked: %#v", last)
+	}
+
+	// Requests not returning secrets aren't limited
+	noop.Lock()
+	noop.Response = nil
+	noop.Unlock()
+	if _, err := request(logical.UpdateOperation, "foo/config", nil); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+}
+
+func TestCore_Quotas_namespace(t *testing.T) {
+	noop := &NoopBackend{
+		Response: &logical.Response{
+			Secret: &logical.Secret{
+				LeaseOptions: logical.LeaseOptions{
+					TTL: time.Hour,
+				},
+			},
+		},
+	}
+	c, _, root := TestCoreUnsealed(t)
+	c.logicalBackends["noop"] = func(*logical.BackendConfig) (logical.Backend, error) {
+		return noop, nil
+	}
+
+	request := func(namespace string, op logical.Operation, path string, data map[string]interface{}) (*logical.Response, error) {
+		req := logical.TestRequest(t, op, path)
+		req.Namespace = namespace
+		req.ClientToken = root
+		req.Data = data
+		return c.HandleRequest(req)
+	}
+	isQuotaErr := func(err error) bool {
+		coded, ok := err.(logical.HTTPCodedError)
+		return ok && coded.Code() == 429
+	}
+
+	for _, path := range []string{"team-a", "team-a/dev"} {
+		if _, err := request("", logical.UpdateOperation, "sys/namespaces/"+path, nil); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+	}
+	for _, path := range []string{"secret", "foo"} {
+		if _, err := request("team-a", logical.UpdateOperation, "sys/mounts/"+path, map[string]interface{}{
+			"type": map[string]string{"secret": "generic", "foo": "noop"}[path],
+		}); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+	}
+
+	// The namespace of a quota must exist, and its path is relative to it
+	resp, err := request("", logical.UpdateOperation, "sys/quotas/rate-limit/bad", map[string]interface{}{
+		"namespace": "team-b",
+		"rate":      1,
+	})
+	if err == nil || !resp.IsError() {
+		t.Fatalf("expected an error: %#v", resp)
+	}
+	if _, err := request("", logical.UpdateOperation, "sys/quotas/rate-limit/team-a-secret", map[string]interface{}{
+		"namespace": "team-a",
+		"path":      "secret/",
+		"rate":      100,
+	}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if _, err := request("", logical.UpdateOperation, "sys/quotas/rate-limit/team-a", map[string]interface{}{
+		"namespace": "team-a",
+		"rate":      1,
+		"burst":     2,
+	}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// The quota of the namespace applies to it and the namespaces beneath
+	// it, but not to the paths with their own quota
+	if _, err := request("team-a", logical.ReadOperation, "sys/mounts", nil); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if _, err := request("team-a/dev", logical.ReadOperation, "sys/mounts", nil); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if _, err := request("team-a/dev", logical.ReadOperation, "sys/mounts", nil); !isQuotaErr(err) {
+		t.Fatalf("expected the request to be rate limited: %v", err)
+	}
+	if _, err := request("team-a", logical.ReadOperation, "secret/foo", nil); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if _, err := request("", logical.ReadOperation, "sys/mounts", nil); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if _, err := request("", logical.DeleteOperation, "sys/quotas/rate-limit/team-a", nil); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// The leases of the mounts of the namespace are counted together
+	if _, err := request("", logical.UpdateOperation, "sys/quotas/lease-count/team-a", map[string]interface{}{
+		"namespace":  "team-a",
+		"max_leases": 1,
+	}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if _, err := request("team-a", logical.ReadOperation, "foo/creds", nil); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	_, err = request("", logical.ReadOperation, "team-a/foo/creds", nil)
+	if err == nil || !strings.Contains(err.Error(), "lease count quota exceeded") {
+		t.Fatalf("expected the lease count quota to be exceeded: %v", err)
+	}
+}
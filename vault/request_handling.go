@@ -7,6 +7,8 @@ import (
 
 	"github.com/armon/go-metrics"
 	"github.com/hashicorp/go-multierror"
+	"github.com/hashicorp/vault/audit"
+	"github.com/hashicorp/vault/helper/cidrutil"
 	"github.com/hashicorp/vault/helper/policyutil"
 	"github.com/hashicorp/vault/helper/strutil"
 	"github.com/hashicorp/vault/logical"
@@ -23,6 +25,11 @@ func (c *Core) HandleRequest(req *logical.Request) (resp *logical.Response, err
 		return nil, ErrStandby
 	}
 
+	// Route the request to the mounts of its namespace
+	if err := c.routeNamespace(req); err != nil {
+		return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+	}
+
 	// Allowing writing to a path ending in / makes it extremely difficult to
 	// understand user intent for the filesystem-like backends (generic,
 	// cubbyhole) -- did they want a key named foo/ or did they want to write
@@ -31,15 +38,21 @@ func (c *Core) HandleRequest(req *logical.Request) (resp *logical.Response, err
 	// backends. Basically, it's all just terrible, so don't allow it.
 	if strings.HasSuffix(req.Path, "/") &&
 		(req.Operation == logical.UpdateOperation ||
-			req.Operation == logical.CreateOperation) {
+			req.Operation == logical.CreateOperation ||
+			req.Operation == logical.PatchOperation) {
 		return logical.ErrorResponse("cannot write to a path ending in '/'"), nil
 	}
 
+	if err := c.applyQuotas(req); err != nil {
+		return nil, err
+	}
+
 	var auth *logical.Auth
+	var controlGroup *ControlGroup
 	if c.router.LoginPath(req.Path) {
 		resp, auth, err = c.handleLoginRequest(req)
 	} else {
-		resp, auth, err = c.handleRequest(req)
+		resp, auth, controlGroup, err = c.handleRequest(req)
 	}
 
 	// Ensure we don't leak internal data
@@ -63,10 +76,28 @@ func (c *Core) HandleRequest(req *logical.Request) (resp *logical.Response, err
 		if cubbyResp != nil || err != nil {
 			return cubbyResp, err
 		}
+
+		if controlGroup != nil {
+			if err := c.registerControlGroupRequest(req, auth, controlGroup, resp.WrapInfo); err != nil {
+				// The response must not be released without authorization
+				c.tokenStore.Revoke(resp.WrapInfo.Token)
+				c.logger.Error("core: failed to register control group request", "request_path", req.Path, "error", err)
+				return nil, ErrInternalError
+			}
+		}
 	}
 
 	// Create an audit trail of the response
-	if auditErr := c.auditBroker.LogResponse(auth, req, resp, err); auditErr != nil {
+	nonHMACReqDataKeys, nonHMACRespDataKeys := c.nonHMACDataKeys(req.Path)
+	logInput := &audit.LogInput{
+		Auth:                auth,
+		Request:             req,
+		Response:            resp,
+		OuterErr:            err,
+		NonHMACReqDataKeys:  nonHMACReqDataKeys,
+		NonHMACRespDataKeys: nonHMACRespDataKeys,
+	}
+	if auditErr := c.auditBroker.LogResponse(logInput, c.auditedHeaders); auditErr != nil {
 		c.logger.Error("core: failed to audit response", "request_path", req.Path, "error", auditErr)
 		return nil, ErrInternalError
 	}
@@ -74,21 +105,20 @@ func (c *Core) HandleRequest(req *logical.Request) (resp *logical.Response, err
 	// If we are wrapping, now is when we create a new response object with the
 	// wrapped information, since the original response has been audit logged
 	if wrapping {
-		wrappingResp := &logical.Response{
+		resp = &logical.Response{
 			WrapInfo: resp.WrapInfo,
+			Warnings: resp.Warnings,
 		}
-		wrappingResp.CloneWarnings(resp)
-		resp = wrappingResp
 	}
 
 	return
 }
 
-func (c *Core) handleRequest(req *logical.Request) (retResp *logical.Response, retAuth *logical.Auth, retErr error) {
+func (c *Core) handleRequest(req *logical.Request) (retResp *logical.Response, retAuth *logical.Auth, retControlGroup *ControlGroup, retErr error) {
 	defer metrics.MeasureSince([]string{"core", "handle_request"}, time.Now())
 
 	// Validate the token
-	auth, te, ctErr := c.checkToken(req)
+	auth, te, controlGroup, ctErr := c.checkToken(req)
 	// We run this logic first because we want to decrement the use count even in the case of an error
 	if te != nil {
 		// Attempt to use the token (decrement NumUses)
@@ -97,12 +127,12 @@ func (c *Core) handleRequest(req *logical.Request) (retResp *logical.Response, r
 		if err != nil {
 			c.logger.Error("core: failed to use token", "error", err)
 			retErr = multierror.Append(retErr, ErrInternalError)
-			return nil, nil, retErr
+			return nil, nil, nil, retErr
 		}
 		if te == nil {
 			// Token has been revoked by this point
 			retErr = multierror.Append(retErr, logical.ErrPermissionDenied)
-			return nil, nil, retErr
+			return nil, nil, nil, retErr
 		}
 		if te.NumUses == -1 {
 			// We defer a revocation until after logic has run, since this is a
@@ -132,41 +162,67 @@ func (c *Core) handleRequest(req *logical.Request) (retResp *logical.Response, r
 		switch ctErr {
 		case ErrInternalError, logical.ErrPermissionDenied:
 			errType = ctErr
+		case ErrControlGroupPending:
+			errType = logical.ErrPermissionDenied
 		default:
 			errType = logical.ErrInvalidRequest
 		}
 
-		if err := c.auditBroker.LogRequest(auth, req, ctErr); err != nil {
+		nonHMACReqDataKeys, _ := c.nonHMACDataKeys(req.Path)
+		logInput := &audit.LogInput{
+			Auth:               auth,
+			Request:            req,
+			OuterErr:           ctErr,
+			NonHMACReqDataKeys: nonHMACReqDataKeys,
+		}
+		if err := c.auditBroker.LogRequest(logInput, c.auditedHeaders); err != nil {
 			c.logger.Error("core: failed to audit request", "path", req.Path, "error", err)
 		}
 
 		if errType != nil {
 			retErr = multierror.Append(retErr, errType)
 		}
-		return logical.ErrorResponse(ctErr.Error()), nil, retErr
+		return logical.ErrorResponse(ctErr.Error()), nil, nil, retErr
 	}
 
 	// Attach the display name
 	req.DisplayName = auth.DisplayName
 
 	// Create an audit trail of the request
-	if err := c.auditBroker.LogRequest(auth, req, nil); err != nil {
+	nonHMACReqDataKeys, _ := c.nonHMACDataKeys(req.Path)
+	logInput := &audit.LogInput{
+		Auth:               auth,
+		Request:            req,
+		NonHMACReqDataKeys: nonHMACReqDataKeys,
+	}
+	if err := c.auditBroker.LogRequest(logInput, c.auditedHeaders); err != nil {
 		c.logger.Error("core: failed to audit request", "path", req.Path, "error", err)
 		retErr = multierror.Append(retErr, ErrInternalError)
-		return nil, auth, retErr
+		return nil, auth, nil, retErr
 	}
 
 	// Route the request
 	resp, err := c.router.Route(req)
 	if resp != nil {
-		// We don't allow backends to specify this, so ensure it's not set
-		resp.WrapInfo = nil
+		// We don't allow backends to specify this, so ensure it's not set,
+		// except when rewrapping which keeps the original TTL
+		if req.Path != "sys/wrapping/rewrap" {
+			resp.WrapInfo = nil
+		}
 
 		if req.WrapTTL != 0 {
 			resp.WrapInfo = &logical.WrapInfo{
 				TTL: req.WrapTTL,
 			}
 		}
+
+		// The response of a request subject to a control group is always
+		// wrapped, and can only be unwrapped once the request is authorized
+		if controlGroup != nil && !resp.IsError() {
+			resp.WrapInfo = &logical.WrapInfo{
+				TTL: controlGroup.wrapTTL(req.WrapTTL),
+			}
+		}
 	}
 
 	// If there is a secret, we must register it with the expiration manager.
@@ -177,7 +233,7 @@ func (c *Core) handleRequest(req *logical.Request) (retResp *logical.Response, r
 		if sysView == nil {
 			c.logger.Error("core: unable to retrieve system view from router")
 			retErr = multierror.Append(retErr, ErrInternalError)
-			return nil, auth, retErr
+			return nil, auth, nil, retErr
 		}
 
 		// Apply the default lease if none given
@@ -191,6 +247,15 @@ func (c *Core) handleRequest(req *logical.Request) (retResp *logical.Response, r
 			resp.Secret.TTL = maxTTL
 		}
 
+		// Batch tokens cannot be revoked, so leases created with them are
+		// limited to the remaining lifetime of the token
+		if te != nil && te.Type == logical.TokenTypeBatch {
+			remaining := te.expirationTime().Sub(time.Now())
+			if resp.Secret.TTL > remaining {
+				resp.Secret.TTL = remaining
+			}
+		}
+
 		// Generic mounts should return the TTL but not register
 		// for a lease as this provides a massive slowdown
 		registerLease := true
@@ -198,7 +263,7 @@ func (c *Core) handleRequest(req *logical.Request) (retResp *logical.Response, r
 		if matchingBackend == nil {
 			c.logger.Error("core: unable to retrieve generic backend from router")
 			retErr = multierror.Append(retErr, ErrInternalError)
-			return nil, auth, retErr
+			return nil, auth, nil, retErr
 		}
 		if ptbe, ok := matchingBackend.(*PassthroughBackend); ok {
 			if !ptbe.GeneratesLeases() {
@@ -208,11 +273,21 @@ func (c *Core) handleRequest(req *logical.Request) (retResp *logical.Response, r
 		}
 
 		if registerLease {
+			// The backend has already created the secret, so revoke it if
+			// the lease count quota is exceeded
+			if err := c.checkLeaseCountQuota(req); err != nil {
+				if _, revokeErr := c.router.Route(logical.RevokeRequest(req.Path, resp.Secret, resp.Data)); revokeErr != nil {
+					c.logger.Error("core: failed to revoke secret over the lease count quota", "request_path", req.Path, "error", revokeErr)
+				}
+				retErr = multierror.Append(retErr, err)
+				return nil, auth, nil, retErr
+			}
+
 			leaseID, err := c.expiration.Register(req, resp)
 			if err != nil {
 				c.logger.Error("core: failed to register lease", "request_path", req.Path, "error", err)
 				retErr = multierror.Append(retErr, ErrInternalError)
-				return nil, auth, retErr
+				return nil, auth, nil, retErr
 			}
 			resp.Secret.LeaseID = leaseID
 		}
@@ -225,7 +300,7 @@ func (c *Core) handleRequest(req *logical.Request) (retResp *logical.Response, r
 		if !strings.HasPrefix(req.Path, "auth/token/") {
 			c.logger.Error("core: unexpected Auth response for non-token backend", "request_path", req.Path)
 			retErr = multierror.Append(retErr, ErrInternalError)
-			return nil, auth, retErr
+			return nil, auth, nil, retErr
 		}
 
 		// Register with the expiration manager. We use the token's actual path
@@ -234,13 +309,16 @@ func (c *Core) handleRequest(req *logical.Request) (retResp *logical.Response, r
 		if err != nil {
 			c.logger.Error("core: failed to look up token", "error", err)
 			retErr = multierror.Append(retErr, ErrInternalError)
-			return nil, nil, retErr
+			return nil, nil, nil, retErr
 		}
 
-		if err := c.expiration.RegisterAuth(te.Path, resp.Auth); err != nil {
-			c.logger.Error("core: failed to register token lease", "request_path", req.Path, "error", err)
-			retErr = multierror.Append(retErr, ErrInternalError)
-			return nil, auth, retErr
+		// Batch tokens are not tracked by the expiration manager
+		if te.Type != logical.TokenTypeBatch {
+			if err := c.expiration.RegisterAuth(te.Path, resp.Auth); err != nil {
+				c.logger.Error("core: failed to register token lease", "request_path", req.Path, "error", err)
+				retErr = multierror.Append(retErr, ErrInternalError)
+				return nil, auth, nil, retErr
+			}
 		}
 	}
 
@@ -248,7 +326,7 @@ func (c *Core) handleRequest(req *logical.Request) (retResp *logical.Response, r
 	if err != nil {
 		retErr = multierror.Append(retErr, err)
 	}
-	return resp, auth, retErr
+	return resp, auth, controlGroup, retErr
 }
 
 // handleLoginRequest is used to handle a login request, which is an
@@ -257,7 +335,12 @@ func (c *Core) handleLoginRequest(req *logical.Request) (*logical.Response, *log
 	defer metrics.MeasureSince([]string{"core", "handle_login_request"}, time.Now())
 
 	// Create an audit trail of the request, auth is not available on login requests
-	if err := c.auditBroker.LogRequest(nil, req, nil); err != nil {
+	nonHMACReqDataKeys, _ := c.nonHMACDataKeys(req.Path)
+	logInput := &audit.LogInput{
+		Request:            req,
+		NonHMACReqDataKeys: nonHMACReqDataKeys,
+	}
+	if err := c.auditBroker.LogRequest(logInput, c.auditedHeaders); err != nil {
 		c.logger.Error("core: failed to audit request", "path", req.Path, "error", err)
 		return nil, nil, ErrInternalError
 	}
@@ -269,8 +352,21 @@ func (c *Core) handleLoginRequest(req *logical.Request) (*logical.Response, *log
 		return nil, nil, ErrInternalError
 	}
 
+	// Reject logins of users that are locked out of the mount
+	lockoutKey, lockoutConfig := c.loginLockoutKey(req)
+	if lockoutKey != "" && c.loginLockout.lockedOut(lockoutKey) {
+		c.logger.Warn("core: login attempt for locked out user", "request_path", req.Path)
+		return logical.ErrorResponse("user is locked out; try again later"), nil, logical.ErrPermissionDenied
+	}
+
 	// Route the request
 	resp, err := c.router.Route(req)
+	if lockoutKey != "" && (err == logical.ErrPermissionDenied || (resp != nil && resp.IsError())) {
+		if c.loginLockout.recordFailure(lockoutKey, lockoutConfig) {
+			c.logger.Warn("core: user locked out after failed logins", "request_path", req.Path)
+			metrics.IncrCounter([]string{"core", "login_lockout"}, 1)
+		}
+	}
 	if resp != nil {
 		// We don't allow backends to specify this, so ensure it's not set
 		resp.WrapInfo = nil
@@ -316,19 +412,80 @@ func (c *Core) handleLoginRequest(req *logical.Request) (*logical.Response, *log
 			auth.TTL = sysView.DefaultLeaseTTL()
 		}
 
-		// Limit the lease duration
-		if auth.TTL > sysView.MaxLeaseTTL() {
-			auth.TTL = sysView.MaxLeaseTTL()
+		// Limit the lease duration; an explicit max TTL set by the backend
+		// takes the place of the system/mount max
+		if auth.ExplicitMaxTTL < 0 {
+			c.logger.Error("core: negative explicit max TTL returned by login path", "request_path", req.Path)
+			return nil, nil, ErrInternalError
+		}
+		maxTTL := sysView.MaxLeaseTTL()
+		if auth.ExplicitMaxTTL > 0 {
+			maxTTL = auth.ExplicitMaxTTL
+		}
+		if auth.TTL > maxTTL {
+			auth.TTL = maxTTL
+		}
+
+		// Ensure any CIDR blocks the backend bound the token to are valid
+		if len(auth.BoundCIDRs) > 0 {
+			if _, err := cidrutil.ValidateCIDRListSlice(auth.BoundCIDRs); err != nil {
+				c.logger.Error("core: invalid bound CIDRs returned by login path", "request_path", req.Path, "error", err)
+				return nil, nil, ErrInternalError
+			}
+		}
+
+		switch auth.TokenType {
+		case "", logical.TokenTypeService:
+		case logical.TokenTypeBatch:
+			if auth.Period > 0 {
+				return logical.ErrorResponse("batch tokens cannot be periodic"), nil, logical.ErrInvalidRequest
+			}
+			// Batch tokens are not tracked by the expiration manager
+			auth.Renewable = false
+		default:
+			c.logger.Error("core: invalid token type returned by login path", "request_path", req.Path, "token_type", auth.TokenType)
+			return nil, nil, ErrInternalError
+		}
+
+		// The token belongs to the namespace of the auth mount
+		ns := c.namespaceByPath(req.Namespace)
+		if ns == nil {
+			return nil, nil, ErrInternalError
 		}
 
 		// Generate a token
 		te := TokenEntry{
-			Path:         req.Path,
-			Policies:     auth.Policies,
-			Meta:         auth.Metadata,
-			DisplayName:  auth.DisplayName,
-			CreationTime: time.Now().Unix(),
-			TTL:          auth.TTL,
+			NamespaceID:    ns.ID,
+			Path:           req.Path,
+			Policies:       auth.Policies,
+			Meta:           auth.Metadata,
+			DisplayName:    auth.DisplayName,
+			CreationTime:   time.Now().Unix(),
+			TTL:            auth.TTL,
+			ExplicitMaxTTL: auth.ExplicitMaxTTL,
+			BoundCIDRs:     auth.BoundCIDRs,
+		}
+		if auth.TokenType == logical.TokenTypeBatch {
+			te.Type = logical.TokenTypeBatch
+		}
+
+		// Map the identity returned by the backend to an entity. Identity
+		// is only available in the root namespace.
+		if auth.Alias != nil && c.identityStore != nil && ns.ID == "" {
+			mountPath := c.router.MatchingMount(req.Path)
+			entity, err := c.identityStore.CreateOrFetchEntity(mountPath, auth.Alias)
+			if err != nil {
+				c.logger.Error("core: failed to create or fetch entity", "request_path", req.Path, "error", err)
+				return nil, nil, ErrInternalError
+			}
+
+			// Sync the entity's membership in the external groups of this mount
+			if err := c.identityStore.UpdateExternalGroupMemberships(entity.ID, mountPath, auth.GroupAliases); err != nil {
+				c.logger.Error("core: failed to update external group memberships", "request_path", req.Path, "error", err)
+				return nil, nil, ErrInternalError
+			}
+			te.EntityID = entity.ID
+			auth.EntityID = entity.ID
 		}
 
 		te.Policies = policyutil.SanitizePolicies(te.Policies, true)
@@ -343,14 +500,21 @@ func (c *Core) handleLoginRequest(req *logical.Request) (*logical.Response, *log
 		auth.Accessor = te.Accessor
 		auth.Policies = te.Policies
 
-		// Register with the expiration manager
-		if err := c.expiration.RegisterAuth(te.Path, auth); err != nil {
-			c.logger.Error("core: failed to register token lease", "request_path", req.Path, "error", err)
-			return nil, auth, ErrInternalError
+		// Register with the expiration manager; batch tokens are not tracked
+		if te.Type != logical.TokenTypeBatch {
+			if err := c.expiration.RegisterAuth(te.Path, auth); err != nil {
+				c.logger.Error("core: failed to register token lease", "request_path", req.Path, "error", err)
+				return nil, auth, ErrInternalError
+			}
 		}
 
 		// Attach the display name, might be used by audit backends
 		req.DisplayName = auth.DisplayName
+
+		// A successful login resets the failed login count
+		if lockoutKey != "" {
+			c.loginLockout.reset(lockoutKey)
+		}
 	}
 
 	return resp, auth, err
@@ -380,8 +544,13 @@ func (c *Core) wrapInCubbyhole(req *logical.Request, resp *logical.Response) (*l
 	// before auditing so that resp.WrapInfo.Token can contain the HMAC'd
 	// wrapping token ID in the audit logs, so that it can be determined from
 	// the audit logs whether the token was ever actually used.
+	ns := c.namespaceByPath(req.Namespace)
+	if ns == nil {
+		return nil, ErrInternalError
+	}
 	creationTime := time.Now()
 	te := TokenEntry{
+		NamespaceID:    ns.ID,
 		Path:           req.Path,
 		Policies:       []string{"response-wrapping"},
 		CreationTime:   creationTime.Unix(),
@@ -396,6 +565,7 @@ func (c *Core) wrapInCubbyhole(req *logical.Request, resp *logical.Response) (*l
 	}
 
 	resp.WrapInfo.Token = te.ID
+	resp.WrapInfo.Accessor = te.Accessor
 	resp.WrapInfo.CreationTime = creationTime
 
 	// This will only be non-nil if this response contains a token, so in that
@@ -404,22 +574,30 @@ func (c *Core) wrapInCubbyhole(req *logical.Request, resp *logical.Response) (*l
 		resp.WrapInfo.WrappedAccessor = resp.Auth.Accessor
 	}
 
-	httpResponse := logical.SanitizeResponse(resp)
+	var marshaledResponse string
+	if req.Path == "sys/wrapping/rewrap" {
+		// The response of a rewrap is the already marshaled response of the
+		// original wrapping token, which is moved as is
+		marshaledResponse, _ = resp.Data["response"].(string)
+	} else {
+		httpResponse := logical.SanitizeResponse(resp)
 
-	// Add the unique identifier of the original request to the response
-	httpResponse.RequestID = req.ID
+		// Add the unique identifier of the original request to the response
+		httpResponse.RequestID = req.ID
 
-	// Because of the way that JSON encodes (likely just in Go) we actually get
-	// mixed-up values for ints if we simply put this object in the response
-	// and encode the whole thing; so instead we marshal it first, then store
-	// the string response. This actually ends up making it easier on the
-	// client side, too, as it becomes a straight read-string-pass-to-unmarshal
-	// operation.
+		// Because of the way that JSON encodes (likely just in Go) we actually get
+		// mixed-up values for ints if we simply put this object in the response
+		// and encode the whole thing; so instead we marshal it first, then store
+		// the string response. This actually ends up making it easier on the
+		// client side, too, as it becomes a straight read-string-pass-to-unmarshal
+		// operation.
 
-	marshaledResponse, err := json.Marshal(httpResponse)
-	if err != nil {
-		c.logger.Error("core: failed to marshal wrapped response", "error", err)
-		return nil, ErrInternalError
+		buf, err := json.Marshal(httpResponse)
+		if err != nil {
+			c.logger.Error("core: failed to marshal wrapped response", "error", err)
+			return nil, ErrInternalError
+		}
+		marshaledResponse = string(buf)
 	}
 
 	cubbyReq := &logical.Request{
@@ -427,7 +605,7 @@ func (c *Core) wrapInCubbyhole(req *logical.Request, resp *logical.Response) (*l
 		Path:        "cubbyhole/response",
 		ClientToken: te.ID,
 		Data: map[string]interface{}{
-			"response": string(marshaledResponse),
+			"response": marshaledResponse,
 		},
 	}
 
@@ -444,6 +622,30 @@ func (c *Core) wrapInCubbyhole(req *logical.Request, resp *logical.Response) (*l
 		return cubbyResp, nil
 	}
 
+	// Store the creation information separately so that it can be looked up
+	// without unwrapping the response
+	cubbyReq = &logical.Request{
+		Operation:   logical.CreateOperation,
+		Path:        "cubbyhole/wrapinfo",
+		ClientToken: te.ID,
+		Data: map[string]interface{}{
+			"creation_ttl":  int64(resp.WrapInfo.TTL.Seconds()),
+			"creation_time": creationTime.Format(time.RFC3339Nano),
+		},
+	}
+
+	cubbyResp, err = c.router.Route(cubbyReq)
+	if err != nil {
+		c.tokenStore.Revoke(te.ID)
+		c.logger.Error("core: failed to store wrapping information", "error", err)
+		return nil, ErrInternalError
+	}
+	if cubbyResp != nil && cubbyResp.IsError() {
+		c.tokenStore.Revoke(te.ID)
+		c.logger.Error("core: failed to store wrapping information", "error", cubbyResp.Data["error"])
+		return cubbyResp, nil
+	}
+
 	auth := &logical.Auth{
 		ClientToken: te.ID,
 		Policies:    []string{"response-wrapping"},
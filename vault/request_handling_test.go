@@ -136,3 +136,102 @@ func TestRequestHandling_LoginWrapping(t *testing.T) {
 		t.Fatalf("bad: %#v", resp)
 	}
 }
+
+func TestRequestHandling_LoginLockout(t *testing.T) {
+	core, _, root := TestCoreUnsealed(t)
+
+	if err := core.loadMounts(); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	core.credentialBackends["userpass"] = credUserpass.Factory
+
+	req := &logical.Request{
+		Path:        "sys/auth/userpass",
+		ClientToken: root,
+		Operation:   logical.UpdateOperation,
+		Data: map[string]interface{}{
+			"type": "userpass",
+		},
+	}
+	resp, err := core.HandleRequest(req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if resp != nil {
+		t.Fatalf("bad: %#v", resp)
+	}
+
+	req.Path = "sys/auth/userpass/tune"
+	req.Data = map[string]interface{}{
+		"lockout_threshold": 2,
+		"lockout_duration":  "1h",
+	}
+	resp, err = core.HandleRequest(req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if resp != nil {
+		t.Fatalf("bad: %#v", resp)
+	}
+
+	req.Path = "auth/userpass/users/test"
+	req.Data = map[string]interface{}{
+		"password": "foo",
+		"policies": "default",
+	}
+	resp, err = core.HandleRequest(req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if resp != nil {
+		t.Fatalf("bad: %#v", resp)
+	}
+
+	login := func(password string) (*logical.Response, error) {
+		return core.HandleRequest(&logical.Request{
+			Path:      "auth/userpass/login/test",
+			Operation: logical.UpdateOperation,
+			Data: map[string]interface{}{
+				"password": password,
+			},
+		})
+	}
+
+	// A successful login resets the failed login count
+	if resp, _ = login("bar"); resp == nil || !resp.IsError() {
+		t.Fatalf("expected error response, got %#v", resp)
+	}
+	if resp, err = login("foo"); err != nil || resp == nil || resp.Auth == nil {
+		t.Fatalf("bad: err: %v\nresp: %#v", err, resp)
+	}
+
+	// Reaching the threshold locks the user out, even with the right password
+	for i := 0; i < 2; i++ {
+		if resp, _ = login("bar"); resp == nil || !resp.IsError() {
+			t.Fatalf("%d: expected error response, got %#v", i, resp)
+		}
+	}
+	resp, err = login("foo")
+	if err != logical.ErrPermissionDenied {
+		t.Fatalf("expected permission denied, got err: %v\nresp: %#v", err, resp)
+	}
+
+	// The lockout is tracked per user
+	if _, ok := core.loginLockout.entries[core.router.MatchingMountEntry("auth/userpass/").UUID+"/test"]; !ok {
+		t.Fatalf("expected lockout entry for user")
+	}
+
+	req = &logical.Request{
+		Path:        "sys/auth/userpass/tune",
+		ClientToken: root,
+		Operation:   logical.ReadOperation,
+	}
+	resp, err = core.HandleRequest(req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if resp.Data["lockout_threshold"] != 2 || resp.Data["lockout_duration"] != 3600 {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+}
@@ -28,6 +28,11 @@ const (
 // The RollbackManager periodically initiates a logical.RollbackOperation
 // on every mounted logical backend. It ensures that only one rollback operation
 // is in-flight at any given time within a single seal/unseal phase.
+//
+// The rollback operations also invoke the periodic functions of the
+// backends, unless the periodic interval of their mount hasn't elapsed since
+// the last one, in which case the "skip_periodic" flag is set in the data of
+// the request.
 type RollbackManager struct {
 	logger log.Logger
 
@@ -43,6 +48,10 @@ type RollbackManager struct {
 	inflight     map[string]*rollbackState
 	inflightLock sync.RWMutex
 
+	// lastPeriodic is when the periodic function of each path was last
+	// invoked, guarded by inflightLock
+	lastPeriodic map[string]time.Time
+
 	doneCh       chan struct{}
 	shutdown     bool
 	shutdownCh   chan struct{}
@@ -58,13 +67,14 @@ type rollbackState struct {
 // NewRollbackManager is used to create a new rollback manager
 func NewRollbackManager(logger log.Logger, backendsFunc func() []*MountEntry, router *Router) *RollbackManager {
 	r := &RollbackManager{
-		logger:     logger,
-		backends:   backendsFunc,
-		router:     router,
-		period:     rollbackPeriod,
-		inflight:   make(map[string]*rollbackState),
-		doneCh:     make(chan struct{}),
-		shutdownCh: make(chan struct{}),
+		logger:       logger,
+		backends:     backendsFunc,
+		router:       router,
+		period:       rollbackPeriod,
+		inflight:     make(map[string]*rollbackState),
+		lastPeriodic: make(map[string]time.Time),
+		doneCh:       make(chan struct{}),
+		shutdownCh:   make(chan struct{}),
 	}
 	return r
 }
@@ -119,26 +129,32 @@ func (m *RollbackManager) triggerRollbacks() {
 		_, ok := m.inflight[path]
 		m.inflightLock.RUnlock()
 		if !ok {
-			m.startRollback(path)
+			m.startRollback(path, e.Config.PeriodicInterval)
 		}
 	}
 }
 
-// startRollback is used to start an async rollback attempt.
-// This must be called with the inflightLock held.
-func (m *RollbackManager) startRollback(path string) *rollbackState {
+// startRollback is used to start an async rollback attempt. The periodic
+// function of the backend is invoked unless the interval hasn't elapsed
+// since the last one.
+func (m *RollbackManager) startRollback(path string, periodicInterval time.Duration) *rollbackState {
 	rs := &rollbackState{}
 	rs.Add(1)
 	m.inflightAll.Add(1)
 	m.inflightLock.Lock()
 	m.inflight[path] = rs
+	now := time.Now()
+	periodic := periodicInterval <= 0 || now.Sub(m.lastPeriodic[path]) >= periodicInterval
+	if periodic {
+		m.lastPeriodic[path] = now
+	}
 	m.inflightLock.Unlock()
-	go m.attemptRollback(path, rs)
+	go m.attemptRollback(path, rs, periodic)
 	return rs
 }
 
 // attemptRollback invokes a RollbackOperation for the given path
-func (m *RollbackManager) attemptRollback(path string, rs *rollbackState) (err error) {
+func (m *RollbackManager) attemptRollback(path string, rs *rollbackState, periodic bool) (err error) {
 	defer metrics.MeasureSince([]string{"rollback", "attempt", strings.Replace(path, "/", "-", -1)}, time.Now())
 	if m.logger.IsDebug() {
 		m.logger.Debug("rollback: attempting rollback", "path", path)
@@ -158,6 +174,11 @@ func (m *RollbackManager) attemptRollback(path string, rs *rollbackState) (err e
 		Operation: logical.RollbackOperation,
 		Path:      path,
 	}
+	if !periodic {
+		req.Data = map[string]interface{}{
+			"skip_periodic": true,
+		}
+	}
 	_, err = m.router.Route(req)
 
 	// If the error is an unsupported operation, then it doesn't
@@ -171,6 +192,14 @@ func (m *RollbackManager) attemptRollback(path string, rs *rollbackState) (err e
 	return
 }
 
+// forget drops the state kept for a path once it is no longer mounted, so
+// that a backend mounted there later starts afresh
+func (m *RollbackManager) forget(path string) {
+	m.inflightLock.Lock()
+	delete(m.lastPeriodic, path)
+	m.inflightLock.Unlock()
+}
+
 // Rollback is used to trigger an immediate rollback of the path,
 // or to join an existing rollback operation if in flight.
 func (m *RollbackManager) Rollback(path string) error {
@@ -179,7 +208,7 @@ func (m *RollbackManager) Rollback(path string) error {
 	rs, ok := m.inflight[path]
 	m.inflightLock.RUnlock()
 	if !ok {
-		rs = m.startRollback(path)
+		rs = m.startRollback(path, 0)
 	}
 
 	// Wait for the attempt to finish
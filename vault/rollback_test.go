@@ -13,6 +13,12 @@ import (
 
 // mockRollback returns a mock rollback manager
 func mockRollback(t *testing.T) (*RollbackManager, *NoopBackend) {
+	return mockRollbackInterval(t, 0)
+}
+
+// mockRollbackInterval returns a mock rollback manager of a mount with the
+// periodic interval
+func mockRollbackInterval(t *testing.T, interval time.Duration) (*RollbackManager, *NoopBackend) {
 	backend := new(NoopBackend)
 	mounts := new(MountTable)
 	router := NewRouter()
@@ -20,6 +26,9 @@ func mockRollback(t *testing.T) (*RollbackManager, *NoopBackend) {
 	mounts.Entries = []*MountEntry{
 		&MountEntry{
 			Path: "foo",
+			Config: MountConfig{
+				PeriodicInterval: interval,
+			},
 		},
 	}
 	meUUID, err := uuid.GenerateUUID()
@@ -103,3 +112,25 @@ func TestRollbackManager_Join(t *testing.T) {
 	}()
 	wg.Wait()
 }
+
+func TestRollbackManager_periodicInterval(t *testing.T) {
+	m, backend := mockRollbackInterval(t, time.Hour)
+
+	m.Start()
+	time.Sleep(50 * time.Millisecond)
+	m.Stop()
+
+	backend.Lock()
+	defer backend.Unlock()
+	if len(backend.Requests) < 2 {
+		t.Fatalf("bad: %#v", backend)
+	}
+
+	// Only the first rollback invokes the periodic function
+	for i, req := range backend.Requests {
+		skip, _ := req.Data["skip_periodic"].(bool)
+		if skip != (i > 0) {
+			t.Fatalf("bad: %d: %#v", i, req.Data)
+		}
+	}
+}
@@ -104,6 +104,38 @@ func (r *Router) Remount(src, dst string) error {
 	return nil
 }
 
+// Reload replaces the backend mounted at the given prefix, keeping its
+// mount entry and storage view, and calls the Cleanup of the previous
+// backend
+func (r *Router) Reload(prefix string, backend logical.Backend) error {
+	r.l.Lock()
+	defer r.l.Unlock()
+
+	raw, ok := r.root.Get(prefix)
+	if !ok {
+		return fmt.Errorf("no mount at '%s'", prefix)
+	}
+	existing := raw.(*routeEntry)
+
+	paths := backend.SpecialPaths()
+	if paths == nil {
+		paths = new(logical.Paths)
+	}
+
+	// Requests already routed keep the previous entry
+	r.root.Insert(prefix, &routeEntry{
+		tainted:     existing.tainted,
+		backend:     backend,
+		mountEntry:  existing.mountEntry,
+		storageView: existing.storageView,
+		rootPaths:   pathsToRadix(paths.Root),
+		loginPaths:  pathsToRadix(paths.Unauthenticated),
+	})
+
+	existing.backend.Cleanup()
+	return nil
+}
+
 // Taint is used to mark a path as tainted. This means only RollbackOperation
 // RevokeOperation requests are allowed to proceed
 func (r *Router) Taint(path string) error {
@@ -237,6 +269,14 @@ func (r *Router) routeCommon(req *logical.Request, existenceCheck bool) (*logica
 	clientToken := req.ClientToken
 	switch {
 	case strings.HasPrefix(original, "auth/token/"):
+	case strings.HasPrefix(original, "sys/wrapping/"):
+		// Wrapping tokens are handled by the system backend, which needs
+		// them as is
+	case strings.HasPrefix(original, "sys/control-group/"):
+		// Authorizations are recorded for the entity of the client token
+	case strings.HasPrefix(original, "sys/internal/ui/mounts/"):
+		// Mounts are reported depending on the capabilities of the client
+		// token
 	case strings.HasPrefix(original, "cubbyhole/"):
 		// In order for the token store to revoke later, we need to have the same
 		// salted ID, so we double-salt what's going to the cubbyhole backend
@@ -251,6 +291,10 @@ func (r *Router) routeCommon(req *logical.Request, existenceCheck bool) (*logica
 	// Cache the identifier of the request
 	originalReqID := req.ID
 
+	// Headers are only kept for auditing, backends don't get them
+	headers := req.Headers
+	req.Headers = nil
+
 	// Reset the request before returning
 	defer func() {
 		req.Path = original
@@ -259,6 +303,7 @@ func (r *Router) routeCommon(req *logical.Request, existenceCheck bool) (*logica
 		req.ID = originalReqID
 		req.Storage = nil
 		req.ClientToken = clientToken
+		req.Headers = headers
 	}()
 
 	// Invoke the backend
@@ -102,16 +102,64 @@ func (d *DefaultSeal) BarrierConfig() (*SealConfig, error) {
 		return nil, err
 	}
 
+	conf, err := d.core.readBarrierConfig(d.BarrierType())
+	if err != nil || conf == nil {
+		return nil, err
+	}
+
+	d.config = conf
+	return d.config.Clone(), nil
+}
+
+func (d *DefaultSeal) SetBarrierConfig(config *SealConfig) error {
+	if err := d.checkCore(); err != nil {
+		return err
+	}
+
+	config.Type = d.BarrierType()
+	if err := d.core.writeBarrierConfig(config); err != nil {
+		return err
+	}
+
+	d.config = config.Clone()
+
+	return nil
+}
+
+func (d *DefaultSeal) RecoveryType() string {
+	return "unsupported"
+}
+
+func (d *DefaultSeal) RecoveryConfig() (*SealConfig, error) {
+	return nil, fmt.Errorf("recovery not supported")
+}
+
+func (d *DefaultSeal) SetRecoveryConfig(config *SealConfig) error {
+	return fmt.Errorf("recovery not supported")
+}
+
+func (d *DefaultSeal) VerifyRecoveryKey([]byte) error {
+	return fmt.Errorf("recovery not supported")
+}
+
+func (d *DefaultSeal) SetRecoveryKey(key []byte) error {
+	return fmt.Errorf("recovery not supported")
+}
+
+// readBarrierConfig reads the barrier seal configuration, which is stored
+// outside of the barrier, and checks that it was written by a seal of the
+// given type. It returns nil if Vault is not initialized.
+func (c *Core) readBarrierConfig(sealType string) (*SealConfig, error) {
 	// Fetch the core configuration
-	pe, err := d.core.physical.Get(barrierSealConfigPath)
+	pe, err := c.physical.Get(barrierSealConfigPath)
 	if err != nil {
-		d.core.logger.Error("core: failed to read seal configuration", "error", err)
+		c.logger.Error("core: failed to read seal configuration", "error", err)
 		return nil, fmt.Errorf("failed to check seal configuration: %v", err)
 	}
 
 	// If the seal configuration is missing, we are not initialized
 	if pe == nil {
-		d.core.logger.Info("core: seal configuration missing, not initialized")
+		c.logger.Info("core: seal configuration missing, not initialized")
 		return nil, nil
 	}
 
@@ -119,37 +167,33 @@ func (d *DefaultSeal) BarrierConfig() (*SealConfig, error) {
 
 	// Decode the barrier entry
 	if err := jsonutil.DecodeJSON(pe.Value, &conf); err != nil {
-		d.core.logger.Error("core: failed to decode seal configuration", "error", err)
+		c.logger.Error("core: failed to decode seal configuration", "error", err)
 		return nil, fmt.Errorf("failed to decode seal configuration: %v", err)
 	}
 
-	switch conf.Type {
-	// This case should not be valid for other types as only this is the default
-	case "":
-		conf.Type = d.BarrierType()
-	case d.BarrierType():
+	switch {
+	// This case is only valid for the shamir seal, as it is the default and
+	// configurations written before seal types were recorded come from it
+	case conf.Type == "" && sealType == "shamir":
+		conf.Type = sealType
+	case conf.Type == sealType:
 	default:
-		d.core.logger.Error("core: barrier seal type does not match loaded type", "barrier_seal_type", conf.Type, "loaded_seal_type", d.BarrierType())
-		return nil, fmt.Errorf("barrier seal type of %s does not match loaded type of %s", conf.Type, d.BarrierType())
+		c.logger.Error("core: barrier seal type does not match loaded type", "barrier_seal_type", conf.Type, "loaded_seal_type", sealType)
+		return nil, fmt.Errorf("barrier seal type of %s does not match loaded type of %s", conf.Type, sealType)
 	}
 
 	// Check for a valid seal configuration
 	if err := conf.Validate(); err != nil {
-		d.core.logger.Error("core: invalid seal configuration", "error", err)
+		c.logger.Error("core: invalid seal configuration", "error", err)
 		return nil, fmt.Errorf("seal validation failed: %v", err)
 	}
 
-	d.config = &conf
-	return d.config.Clone(), nil
+	return &conf, nil
 }
 
-func (d *DefaultSeal) SetBarrierConfig(config *SealConfig) error {
-	if err := d.checkCore(); err != nil {
-		return err
-	}
-
-	config.Type = d.BarrierType()
-
+// writeBarrierConfig stores the barrier seal configuration outside of the
+// barrier, so that it can be read while Vault is sealed
+func (c *Core) writeBarrierConfig(config *SealConfig) error {
 	// Encode the seal configuration
 	buf, err := json.Marshal(config)
 	if err != nil {
@@ -162,36 +206,14 @@ func (d *DefaultSeal) SetBarrierConfig(config *SealConfig) error {
 		Value: buf,
 	}
 
-	if err := d.core.physical.Put(pe); err != nil {
-		d.core.logger.Error("core: failed to write seal configuration", "error", err)
+	if err := c.physical.Put(pe); err != nil {
+		c.logger.Error("core: failed to write seal configuration", "error", err)
 		return fmt.Errorf("failed to write seal configuration: %v", err)
 	}
 
-	d.config = config.Clone()
-
 	return nil
 }
 
-func (d *DefaultSeal) RecoveryType() string {
-	return "unsupported"
-}
-
-func (d *DefaultSeal) RecoveryConfig() (*SealConfig, error) {
-	return nil, fmt.Errorf("recovery not supported")
-}
-
-func (d *DefaultSeal) SetRecoveryConfig(config *SealConfig) error {
-	return fmt.Errorf("recovery not supported")
-}
-
-func (d *DefaultSeal) VerifyRecoveryKey([]byte) error {
-	return fmt.Errorf("recovery not supported")
-}
-
-func (d *DefaultSeal) SetRecoveryKey(key []byte) error {
-	return fmt.Errorf("recovery not supported")
-}
-
 // SealConfig is used to describe the seal configuration
 type SealConfig struct {
 	// The type, for sanity checking
@@ -0,0 +1,133 @@
+// Package awskms implements a key wrapper for the auto-unseal seal that
+// protects the barrier unseal key with an AWS KMS customer master key.
+package awskms
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/kms"
+	"github.com/aws/aws-sdk-go/service/kms/kmsiface"
+	"github.com/hashicorp/vault/helper/awsutil"
+)
+
+// SealType is the seal type reported for seals using this wrapper
+const SealType = "awskms"
+
+// Wrapper encrypts and decrypts data with an AWS KMS customer master key
+type Wrapper struct {
+	keyID  string
+	client kmsiface.KMSAPI
+}
+
+// NewWrapper creates a Wrapper from the configuration of a `seal "awskms"`
+// stanza. The key ID and region can also be set through the
+// VAULT_AWSKMS_SEAL_KEY_ID and AWS_DEFAULT_REGION environment variables,
+// while credentials are sourced from the configuration, the environment,
+// AWS credential files or the instance's IAM role.
+func NewWrapper(conf map[string]string) (*Wrapper, error) {
+	keyID := os.Getenv("VAULT_AWSKMS_SEAL_KEY_ID")
+	if keyID == "" {
+		keyID = conf["kms_key_id"]
+		if keyID == "" {
+			return nil, fmt.Errorf("'kms_key_id' must be set")
+		}
+	}
+
+	region := os.Getenv("AWS_DEFAULT_REGION")
+	if region == "" {
+		region = conf["region"]
+		if region == "" {
+			region = "us-east-1"
+		}
+	}
+
+	endpoint := os.Getenv("AWS_KMS_ENDPOINT")
+	if endpoint == "" {
+		endpoint = conf["endpoint"]
+	}
+
+	credsConfig := &awsutil.CredentialsConfig{
+		AccessKey:    conf["access_key"],
+		SecretKey:    conf["secret_key"],
+		SessionToken: conf["session_token"],
+		Region:       region,
+	}
+	creds, err := credsConfig.GenerateCredentialChain()
+	if err != nil {
+		return nil, err
+	}
+
+	awsConf := &aws.Config{
+		Credentials: creds,
+		Region:      aws.String(region),
+	}
+	if endpoint != "" {
+		awsConf.Endpoint = aws.String(endpoint)
+	}
+
+	return &Wrapper{
+		keyID:  keyID,
+		client: kms.New(session.New(awsConf)),
+	}, nil
+}
+
+// Type returns the seal type of the wrapper
+func (w *Wrapper) Type() string {
+	return SealType
+}
+
+// KeyID returns the ID of the customer master key used by the wrapper
+func (w *Wrapper) KeyID() string {
+	return w.keyID
+}
+
+// Init checks that the customer master key can be used before Vault is
+// initialized with it
+func (w *Wrapper) Init() error {
+	if _, err := w.Encrypt([]byte("vault")); err != nil {
+		return fmt.Errorf("error checking KMS key %q: %v", w.keyID, err)
+	}
+	return nil
+}
+
+// Finalize is a no-op, as the wrapper holds no resources
+func (w *Wrapper) Finalize() error {
+	return nil
+}
+
+// Encrypt encrypts the given plaintext with the customer master key.
+// KMS limits the plaintext to 4 KiB.
+func (w *Wrapper) Encrypt(plaintext []byte) ([]byte, error) {
+	if plaintext == nil {
+		return nil, fmt.Errorf("given plaintext for encryption is nil")
+	}
+
+	output, err := w.client.Encrypt(&kms.EncryptInput{
+		KeyId:     aws.String(w.keyID),
+		Plaintext: plaintext,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error encrypting data: %v", err)
+	}
+
+	return output.CiphertextBlob, nil
+}
+
+// Decrypt decrypts ciphertext returned by Encrypt
+func (w *Wrapper) Decrypt(ciphertext []byte) ([]byte, error) {
+	if ciphertext == nil {
+		return nil, fmt.Errorf("given ciphertext for decryption is nil")
+	}
+
+	output, err := w.client.Decrypt(&kms.DecryptInput{
+		CiphertextBlob: ciphertext,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error decrypting data: %v", err)
+	}
+
+	return output.Plaintext, nil
+}
@@ -0,0 +1,111 @@
+package awskms
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+// testKMSServer fakes the KMS API by prefixing plaintexts with the key ID
+func testKMSServer(t *testing.T) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256") {
+			t.Errorf("request is not signed")
+		}
+
+		buf := new(bytes.Buffer)
+		buf.ReadFrom(r.Body)
+		var keyID struct{ KeyId string }
+		json.Unmarshal(buf.Bytes(), &keyID)
+
+		var resp interface{}
+		switch r.Header.Get("X-Amz-Target") {
+		case "TrentService.Encrypt":
+			var input struct{ Plaintext []byte }
+			if err := json.Unmarshal(buf.Bytes(), &input); err != nil {
+				t.Error(err)
+				return
+			}
+			resp = map[string]interface{}{
+				"CiphertextBlob": append([]byte(keyID.KeyId+":"), input.Plaintext...),
+				"KeyId":          keyID.KeyId,
+			}
+		case "TrentService.Decrypt":
+			var input struct{ CiphertextBlob []byte }
+			if err := json.Unmarshal(buf.Bytes(), &input); err != nil {
+				t.Error(err)
+				return
+			}
+			parts := bytes.SplitN(input.CiphertextBlob, []byte(":"), 2)
+			if len(parts) != 2 {
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(`{"__type":"InvalidCiphertextException","message":"bad ciphertext"}`))
+				return
+			}
+			resp = map[string]interface{}{
+				"Plaintext": parts[1],
+				"KeyId":     string(parts[0]),
+			}
+		default:
+			t.Errorf("unexpected target %q", r.Header.Get("X-Amz-Target"))
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/x-amz-json-1.1")
+		json.NewEncoder(w).Encode(resp)
+	}))
+}
+
+func TestWrapper(t *testing.T) {
+	srv := testKMSServer(t)
+	defer srv.Close()
+
+	os.Unsetenv("VAULT_AWSKMS_SEAL_KEY_ID")
+	os.Unsetenv("AWS_KMS_ENDPOINT")
+
+	if _, err := NewWrapper(map[string]string{}); err == nil {
+		t.Fatal("expected error without a key ID")
+	}
+
+	w, err := NewWrapper(map[string]string{
+		"kms_key_id": "alias/vault",
+		"region":     "us-west-2",
+		"access_key": "AKIAEXAMPLE",
+		"secret_key": "secret",
+		"endpoint":   srv.URL,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w.Type() != SealType {
+		t.Fatalf("bad type: %s", w.Type())
+	}
+	if err := w.Init(); err != nil {
+		t.Fatal(err)
+	}
+
+	ciphertext, err := w.Encrypt([]byte("barrier keys"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(ciphertext, []byte("alias/vault:barrier keys")) {
+		t.Fatalf("bad ciphertext: %q", ciphertext)
+	}
+
+	plaintext, err := w.Decrypt(ciphertext)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(plaintext, []byte("barrier keys")) {
+		t.Fatalf("bad plaintext: %q", plaintext)
+	}
+
+	_, err = w.Decrypt([]byte("garbage"))
+	if err == nil || !strings.Contains(err.Error(), "InvalidCiphertextException") {
+		t.Fatalf("expected error decrypting garbage, got: %v", err)
+	}
+}
@@ -0,0 +1,121 @@
+// Package pkcs11 implements a key wrapper for the auto-unseal seal that
+// protects the barrier unseal key with an AES key held in an HSM, accessed
+// through a PKCS#11 library. The library is loaded with cgo, so Vault must
+// be built with cgo enabled to use it.
+package pkcs11
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// SealType is the seal type reported for seals using this wrapper
+const SealType = "pkcs11"
+
+const (
+	// mechanismAESGCM and mechanismAESCBCPad are the values of the
+	// CKM_AES_GCM and CKM_AES_CBC_PAD mechanisms
+	mechanismAESGCM    uint = 0x1087
+	mechanismAESCBCPad uint = 0x1085
+)
+
+// mechanisms are the supported encryption mechanisms by name
+var mechanisms = map[string]uint{
+	"CKM_AES_GCM":     mechanismAESGCM,
+	"CKM_AES_CBC_PAD": mechanismAESCBCPad,
+}
+
+// hsmConfig is the parsed configuration of a `seal "pkcs11"` stanza
+type hsmConfig struct {
+	lib         string
+	slot        uint
+	hasSlot     bool
+	tokenLabel  string
+	pin         string
+	keyLabel    string
+	mechanism   uint
+	generateKey bool
+}
+
+// configValue returns the value of the given environment variable if set,
+// or else the given key of the configuration
+func configValue(conf map[string]string, env, key string) string {
+	if v := os.Getenv(env); v != "" {
+		return v
+	}
+	return conf[key]
+}
+
+// parseConfig parses the configuration of a `seal "pkcs11"` stanza. Every
+// parameter can also be set with a VAULT_HSM_* environment variable, which
+// takes precedence.
+func parseConfig(conf map[string]string) (*hsmConfig, error) {
+	c := &hsmConfig{
+		lib:        configValue(conf, "VAULT_HSM_LIB", "lib"),
+		tokenLabel: configValue(conf, "VAULT_HSM_TOKEN_LABEL", "token_label"),
+		pin:        configValue(conf, "VAULT_HSM_PIN", "pin"),
+		keyLabel:   configValue(conf, "VAULT_HSM_KEY_LABEL", "key_label"),
+		mechanism:  mechanismAESGCM,
+	}
+
+	if c.lib == "" {
+		return nil, fmt.Errorf("'lib' must be set")
+	}
+	if c.pin == "" {
+		return nil, fmt.Errorf("'pin' must be set")
+	}
+	if c.keyLabel == "" {
+		return nil, fmt.Errorf("'key_label' must be set")
+	}
+
+	if slot := configValue(conf, "VAULT_HSM_SLOT", "slot"); slot != "" {
+		parsed, err := strconv.ParseUint(slot, 0, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid 'slot': %v", err)
+		}
+		c.slot = uint(parsed)
+		c.hasSlot = true
+	}
+	if c.hasSlot && c.tokenLabel != "" {
+		return nil, fmt.Errorf("only one of 'slot' and 'token_label' can be set")
+	}
+	if !c.hasSlot && c.tokenLabel == "" {
+		return nil, fmt.Errorf("one of 'slot' and 'token_label' must be set")
+	}
+
+	if mechanism := configValue(conf, "VAULT_HSM_MECHANISM", "mechanism"); mechanism != "" {
+		m, err := parseMechanism(mechanism)
+		if err != nil {
+			return nil, err
+		}
+		c.mechanism = m
+	}
+
+	if generateKey := configValue(conf, "VAULT_HSM_GENERATE_KEY", "generate_key"); generateKey != "" {
+		var err error
+		c.generateKey, err = strconv.ParseBool(generateKey)
+		if err != nil {
+			return nil, fmt.Errorf("invalid 'generate_key': %v", err)
+		}
+	}
+
+	return c, nil
+}
+
+// parseMechanism parses a mechanism given either by name, e.g.
+// "CKM_AES_GCM", or by value, e.g. "0x1087"
+func parseMechanism(mechanism string) (uint, error) {
+	if m, ok := mechanisms[strings.ToUpper(mechanism)]; ok {
+		return m, nil
+	}
+	if parsed, err := strconv.ParseUint(mechanism, 0, 32); err == nil {
+		for _, m := range mechanisms {
+			if uint(parsed) == m {
+				return m, nil
+			}
+		}
+	}
+	return 0, fmt.Errorf("unsupported mechanism %q; supported mechanisms are CKM_AES_GCM and CKM_AES_CBC_PAD", mechanism)
+}
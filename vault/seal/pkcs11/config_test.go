@@ -0,0 +1,89 @@
+package pkcs11
+
+import (
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestParseConfig(t *testing.T) {
+	envs := []string{
+		"VAULT_HSM_LIB", "VAULT_HSM_SLOT", "VAULT_HSM_TOKEN_LABEL", "VAULT_HSM_PIN",
+		"VAULT_HSM_KEY_LABEL", "VAULT_HSM_MECHANISM", "VAULT_HSM_GENERATE_KEY",
+	}
+	for _, env := range envs {
+		if v := os.Getenv(env); v != "" {
+			defer os.Setenv(env, v)
+			os.Unsetenv(env)
+		}
+	}
+
+	base := func() map[string]string {
+		return map[string]string{
+			"lib":       "/usr/lib/libsofthsm2.so",
+			"slot":      "0x1",
+			"pin":       "1234",
+			"key_label": "vault",
+		}
+	}
+
+	c, err := parseConfig(base())
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := &hsmConfig{
+		lib:       "/usr/lib/libsofthsm2.so",
+		slot:      1,
+		hasSlot:   true,
+		pin:       "1234",
+		keyLabel:  "vault",
+		mechanism: mechanismAESGCM,
+	}
+	if !reflect.DeepEqual(c, expected) {
+		t.Fatalf("bad config:\n%#v\nexpected:\n%#v", c, expected)
+	}
+
+	conf := base()
+	delete(conf, "slot")
+	conf["token_label"] = "vault-token"
+	conf["mechanism"] = "ckm_aes_cbc_pad"
+	conf["generate_key"] = "true"
+	c, err = parseConfig(conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.hasSlot || c.tokenLabel != "vault-token" || c.mechanism != mechanismAESCBCPad || !c.generateKey {
+		t.Fatalf("bad config: %#v", c)
+	}
+
+	// Environment variables take precedence
+	os.Setenv("VAULT_HSM_MECHANISM", "0x1087")
+	defer os.Unsetenv("VAULT_HSM_MECHANISM")
+	c, err = parseConfig(conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.mechanism != mechanismAESGCM {
+		t.Fatalf("bad mechanism: %#x", c.mechanism)
+	}
+	os.Unsetenv("VAULT_HSM_MECHANISM")
+
+	invalid := []func(map[string]string){
+		func(m map[string]string) { delete(m, "lib") },
+		func(m map[string]string) { delete(m, "pin") },
+		func(m map[string]string) { delete(m, "key_label") },
+		func(m map[string]string) { delete(m, "slot") },
+		func(m map[string]string) { m["token_label"] = "vault-token" },
+		func(m map[string]string) { m["slot"] = "slot" },
+		func(m map[string]string) { m["mechanism"] = "CKM_RSA_PKCS" },
+		func(m map[string]string) { m["mechanism"] = "0x1" },
+		func(m map[string]string) { m["generate_key"] = "maybe" },
+	}
+	for i, f := range invalid {
+		conf := base()
+		f(conf)
+		if _, err := parseConfig(conf); err == nil {
+			t.Fatalf("expected error for invalid config %d: %#v", i, conf)
+		}
+	}
+}
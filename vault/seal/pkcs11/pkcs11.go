@@ -0,0 +1,294 @@
+// +build cgo
+
+package pkcs11
+
+import (
+	"crypto/rand"
+	"fmt"
+	"strings"
+	"sync"
+
+	p11 "github.com/miekg/pkcs11"
+)
+
+const (
+	// gcmIVSize and gcmTagBits are the IV and tag sizes used with
+	// CKM_AES_GCM
+	gcmIVSize  = 12
+	gcmTagBits = 128
+
+	// cbcIVSize is the IV size used with CKM_AES_CBC_PAD
+	cbcIVSize = 16
+
+	// generatedKeySize is the size in bytes of keys generated in the HSM
+	generatedKeySize = 32
+)
+
+// Wrapper encrypts and decrypts data with an AES key held in an HSM. It
+// keeps a single logged in session, which is serialized since PKCS#11
+// sessions can't be used concurrently.
+type Wrapper struct {
+	l         sync.Mutex
+	ctx       *p11.Ctx
+	session   p11.SessionHandle
+	key       p11.ObjectHandle
+	mechanism uint
+}
+
+// NewWrapper creates a Wrapper from the configuration of a `seal "pkcs11"`
+// stanza. It loads the PKCS#11 library, logs into the token and looks up
+// the key, generating it if it does not exist and the configuration allows.
+func NewWrapper(conf map[string]string) (*Wrapper, error) {
+	c, err := parseConfig(conf)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := p11.New(c.lib)
+	if ctx == nil {
+		return nil, fmt.Errorf("failed to load PKCS#11 library %s", c.lib)
+	}
+	if err := ctx.Initialize(); err != nil {
+		if e, ok := err.(p11.Error); !ok || e != p11.CKR_CRYPTOKI_ALREADY_INITIALIZED {
+			ctx.Destroy()
+			return nil, fmt.Errorf("failed to initialize PKCS#11 library: %v", err)
+		}
+	}
+
+	w := &Wrapper{
+		ctx:       ctx,
+		mechanism: c.mechanism,
+	}
+	if err := w.connect(c); err != nil {
+		w.Finalize()
+		return nil, err
+	}
+
+	return w, nil
+}
+
+// connect opens and logs into a session on the configured token and finds
+// or generates the key
+func (w *Wrapper) connect(c *hsmConfig) error {
+	slot, err := w.findSlot(c)
+	if err != nil {
+		return err
+	}
+
+	w.session, err = w.ctx.OpenSession(slot, p11.CKF_SERIAL_SESSION|p11.CKF_RW_SESSION)
+	if err != nil {
+		return fmt.Errorf("failed to open session on slot %d: %v", slot, err)
+	}
+
+	if err := w.ctx.Login(w.session, p11.CKU_USER, c.pin); err != nil {
+		if e, ok := err.(p11.Error); !ok || e != p11.CKR_USER_ALREADY_LOGGED_IN {
+			return fmt.Errorf("failed to log into slot %d: %v", slot, err)
+		}
+	}
+
+	key, found, err := w.findKey(c.keyLabel)
+	if err != nil {
+		return err
+	}
+	if !found {
+		if !c.generateKey {
+			return fmt.Errorf("key %q not found and 'generate_key' is not set", c.keyLabel)
+		}
+		if key, err = w.generateKey(c.keyLabel); err != nil {
+			return err
+		}
+	}
+	w.key = key
+
+	return nil
+}
+
+// findSlot returns the configured slot, looking it up by token label if
+// needed
+func (w *Wrapper) findSlot(c *hsmConfig) (uint, error) {
+	if c.hasSlot {
+		return c.slot, nil
+	}
+
+	slots, err := w.ctx.GetSlotList(true)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list slots: %v", err)
+	}
+	for _, slot := range slots {
+		info, err := w.ctx.GetTokenInfo(slot)
+		if err != nil {
+			return 0, fmt.Errorf("failed to get token info of slot %d: %v", slot, err)
+		}
+		if strings.TrimRight(info.Label, " \x00") == c.tokenLabel {
+			return slot, nil
+		}
+	}
+
+	return 0, fmt.Errorf("no token found with label %q", c.tokenLabel)
+}
+
+// findKey looks up the secret key with the given label
+func (w *Wrapper) findKey(label string) (p11.ObjectHandle, bool, error) {
+	template := []*p11.Attribute{
+		p11.NewAttribute(p11.CKA_CLASS, p11.CKO_SECRET_KEY),
+		p11.NewAttribute(p11.CKA_KEY_TYPE, p11.CKK_AES),
+		p11.NewAttribute(p11.CKA_LABEL, label),
+	}
+	if err := w.ctx.FindObjectsInit(w.session, template); err != nil {
+		return 0, false, fmt.Errorf("failed to search for key %q: %v", label, err)
+	}
+	objects, _, err := w.ctx.FindObjects(w.session, 2)
+	if finalErr := w.ctx.FindObjectsFinal(w.session); err == nil {
+		err = finalErr
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to search for key %q: %v", label, err)
+	}
+
+	switch len(objects) {
+	case 0:
+		return 0, false, nil
+	case 1:
+		return objects[0], true, nil
+	default:
+		return 0, false, fmt.Errorf("found more than one key with label %q", label)
+	}
+}
+
+// generateKey generates a persistent AES-256 key with the given label in
+// the HSM. The key can't be extracted from the HSM.
+func (w *Wrapper) generateKey(label string) (p11.ObjectHandle, error) {
+	template := []*p11.Attribute{
+		p11.NewAttribute(p11.CKA_CLASS, p11.CKO_SECRET_KEY),
+		p11.NewAttribute(p11.CKA_KEY_TYPE, p11.CKK_AES),
+		p11.NewAttribute(p11.CKA_VALUE_LEN, generatedKeySize),
+		p11.NewAttribute(p11.CKA_LABEL, label),
+		p11.NewAttribute(p11.CKA_TOKEN, true),
+		p11.NewAttribute(p11.CKA_PRIVATE, true),
+		p11.NewAttribute(p11.CKA_SENSITIVE, true),
+		p11.NewAttribute(p11.CKA_EXTRACTABLE, false),
+		p11.NewAttribute(p11.CKA_ENCRYPT, true),
+		p11.NewAttribute(p11.CKA_DECRYPT, true),
+	}
+	key, err := w.ctx.GenerateKey(w.session, []*p11.Mechanism{p11.NewMechanism(p11.CKM_AES_KEY_GEN, nil)}, template)
+	if err != nil {
+		return 0, fmt.Errorf("failed to generate key %q: %v", label, err)
+	}
+	return key, nil
+}
+
+// Type returns the seal type of the wrapper
+func (w *Wrapper) Type() string {
+	return SealType
+}
+
+// Init is a no-op, as the HSM is set up when the wrapper is created
+func (w *Wrapper) Init() error {
+	return nil
+}
+
+// Finalize logs out of the HSM and unloads the PKCS#11 library
+func (w *Wrapper) Finalize() error {
+	w.l.Lock()
+	defer w.l.Unlock()
+
+	if w.ctx == nil {
+		return nil
+	}
+	if w.session != 0 {
+		w.ctx.Logout(w.session)
+		w.ctx.CloseSession(w.session)
+	}
+	err := w.ctx.Finalize()
+	w.ctx.Destroy()
+	w.ctx = nil
+	return err
+}
+
+// Encrypt encrypts the given plaintext with the HSM key. The IV is
+// prepended to the returned ciphertext.
+func (w *Wrapper) Encrypt(plaintext []byte) ([]byte, error) {
+	if plaintext == nil {
+		return nil, fmt.Errorf("given plaintext for encryption is nil")
+	}
+
+	w.l.Lock()
+	defer w.l.Unlock()
+
+	if w.ctx == nil {
+		return nil, fmt.Errorf("HSM wrapper is finalized")
+	}
+
+	var mechanism *p11.Mechanism
+	var iv []byte
+	var gcmParams *p11.GCMParams
+	switch w.mechanism {
+	case p11.CKM_AES_GCM:
+		iv = make([]byte, gcmIVSize)
+		if _, err := rand.Read(iv); err != nil {
+			return nil, fmt.Errorf("failed to generate IV: %v", err)
+		}
+		gcmParams = p11.NewGCMParams(iv, nil, gcmTagBits)
+		defer gcmParams.Free()
+		mechanism = p11.NewMechanism(w.mechanism, gcmParams)
+	default:
+		iv = make([]byte, cbcIVSize)
+		if _, err := rand.Read(iv); err != nil {
+			return nil, fmt.Errorf("failed to generate IV: %v", err)
+		}
+		mechanism = p11.NewMechanism(w.mechanism, iv)
+	}
+
+	if err := w.ctx.EncryptInit(w.session, []*p11.Mechanism{mechanism}, w.key); err != nil {
+		return nil, fmt.Errorf("error encrypting data: %v", err)
+	}
+	ciphertext, err := w.ctx.Encrypt(w.session, plaintext)
+	if err != nil {
+		return nil, fmt.Errorf("error encrypting data: %v", err)
+	}
+
+	// Some HSMs ignore the given IV and generate their own
+	if gcmParams != nil && len(gcmParams.IV()) == gcmIVSize {
+		iv = gcmParams.IV()
+	}
+
+	return append(iv, ciphertext...), nil
+}
+
+// Decrypt decrypts ciphertext returned by Encrypt
+func (w *Wrapper) Decrypt(ciphertext []byte) ([]byte, error) {
+	w.l.Lock()
+	defer w.l.Unlock()
+
+	if w.ctx == nil {
+		return nil, fmt.Errorf("HSM wrapper is finalized")
+	}
+
+	var mechanism *p11.Mechanism
+	switch w.mechanism {
+	case p11.CKM_AES_GCM:
+		if len(ciphertext) <= gcmIVSize {
+			return nil, fmt.Errorf("given ciphertext for decryption is too short")
+		}
+		gcmParams := p11.NewGCMParams(ciphertext[:gcmIVSize], nil, gcmTagBits)
+		defer gcmParams.Free()
+		mechanism = p11.NewMechanism(w.mechanism, gcmParams)
+		ciphertext = ciphertext[gcmIVSize:]
+	default:
+		if len(ciphertext) <= cbcIVSize {
+			return nil, fmt.Errorf("given ciphertext for decryption is too short")
+		}
+		mechanism = p11.NewMechanism(w.mechanism, ciphertext[:cbcIVSize])
+		ciphertext = ciphertext[cbcIVSize:]
+	}
+
+	if err := w.ctx.DecryptInit(w.session, []*p11.Mechanism{mechanism}, w.key); err != nil {
+		return nil, fmt.Errorf("error decrypting data: %v", err)
+	}
+	plaintext, err := w.ctx.Decrypt(w.session, ciphertext)
+	if err != nil {
+		return nil, fmt.Errorf("error decrypting data: %v", err)
+	}
+
+	return plaintext, nil
+}
@@ -0,0 +1,41 @@
+// +build !cgo
+
+package pkcs11
+
+import "fmt"
+
+// errNoCgo is returned when Vault is built without cgo, which is needed to
+// load PKCS#11 libraries
+var errNoCgo = fmt.Errorf("the pkcs11 seal requires Vault to be built with cgo enabled")
+
+// Wrapper is unavailable without cgo
+type Wrapper struct{}
+
+// NewWrapper validates the configuration, but always fails since PKCS#11
+// libraries can't be loaded without cgo
+func NewWrapper(conf map[string]string) (*Wrapper, error) {
+	if _, err := parseConfig(conf); err != nil {
+		return nil, err
+	}
+	return nil, errNoCgo
+}
+
+func (w *Wrapper) Type() string {
+	return SealType
+}
+
+func (w *Wrapper) Init() error {
+	return errNoCgo
+}
+
+func (w *Wrapper) Finalize() error {
+	return nil
+}
+
+func (w *Wrapper) Encrypt(plaintext []byte) ([]byte, error) {
+	return nil, errNoCgo
+}
+
+func (w *Wrapper) Decrypt(ciphertext []byte) ([]byte, error) {
+	return nil, errNoCgo
+}
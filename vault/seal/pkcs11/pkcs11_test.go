@@ -0,0 +1,48 @@
+// +build cgo
+
+package pkcs11
+
+import (
+	"bytes"
+	"os"
+	"testing"
+)
+
+// TestWrapper runs against a real HSM, e.g. SoftHSM, configured with the
+// VAULT_HSM_* environment variables
+func TestWrapper(t *testing.T) {
+	if os.Getenv("VAULT_HSM_LIB") == "" {
+		t.Skip("VAULT_HSM_LIB not set")
+	}
+
+	w, err := NewWrapper(map[string]string{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Finalize()
+
+	if w.Type() != SealType {
+		t.Fatalf("bad type: %s", w.Type())
+	}
+
+	ciphertext, err := w.Encrypt([]byte("barrier keys"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(ciphertext, []byte("barrier keys")) {
+		t.Fatal("ciphertext contains the plaintext")
+	}
+
+	plaintext, err := w.Decrypt(ciphertext)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(plaintext, []byte("barrier keys")) {
+		t.Fatalf("bad plaintext: %q", plaintext)
+	}
+
+	ciphertext[len(ciphertext)-1] ^= 0xff
+	if plaintext, err := w.Decrypt(ciphertext); err == nil && bytes.Equal(plaintext, []byte("barrier keys")) {
+		t.Fatal("expected tampered ciphertext to fail decryption")
+	}
+}
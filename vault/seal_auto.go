@@ -0,0 +1,283 @@
+package vault
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+
+	"github.com/hashicorp/vault/helper/jsonutil"
+	"github.com/hashicorp/vault/physical"
+)
+
+const (
+	// storedBarrierKeysPath is the path used to store the barrier unseal keys
+	// of an auto seal, encrypted by its key wrapper. It is outside of the
+	// barrier, since it is needed to unseal it.
+	storedBarrierKeysPath = "core/hsm/barrier-unseal-keys"
+)
+
+// KeyWrapper encrypts and decrypts data with a key kept outside of Vault,
+// such as in a KMS or an HSM
+type KeyWrapper interface {
+	// Type is the seal type reported for seals using the wrapper
+	Type() string
+
+	// Init is called when Vault is initialized and Finalize when the server
+	// shuts down
+	Init() error
+	Finalize() error
+
+	Encrypt([]byte) ([]byte, error)
+	Decrypt([]byte) ([]byte, error)
+}
+
+// AutoSeal is a Seal that stores the barrier unseal key encrypted with a
+// KeyWrapper, so that Vault can unseal itself on start. Since operators do
+// not hold unseal keys, operations such as generating a root token or
+// rekeying are authorized with recovery keys instead.
+type AutoSeal struct {
+	wrapper        KeyWrapper
+	core           *Core
+	config         *SealConfig
+	recoveryConfig *SealConfig
+}
+
+// NewAutoSeal creates an AutoSeal using the given key wrapper
+func NewAutoSeal(wrapper KeyWrapper) *AutoSeal {
+	return &AutoSeal{
+		wrapper: wrapper,
+	}
+}
+
+func (d *AutoSeal) checkCore() error {
+	if d.core == nil {
+		return fmt.Errorf("seal does not have a core set")
+	}
+	return nil
+}
+
+func (d *AutoSeal) SetCore(core *Core) {
+	d.core = core
+}
+
+func (d *AutoSeal) Init() error {
+	return d.wrapper.Init()
+}
+
+func (d *AutoSeal) Finalize() error {
+	return d.wrapper.Finalize()
+}
+
+func (d *AutoSeal) BarrierType() string {
+	return d.wrapper.Type()
+}
+
+func (d *AutoSeal) StoredKeysSupported() bool {
+	return true
+}
+
+func (d *AutoSeal) RecoveryKeySupported() bool {
+	return true
+}
+
+// SetStoredKeys encrypts the given keys with the key wrapper and stores
+// them outside of the barrier
+func (d *AutoSeal) SetStoredKeys(keys [][]byte) error {
+	if err := d.checkCore(); err != nil {
+		return err
+	}
+	if keys == nil {
+		return fmt.Errorf("keys were nil")
+	}
+
+	buf, err := json.Marshal(keys)
+	if err != nil {
+		return fmt.Errorf("failed to encode keys for storage: %v", err)
+	}
+
+	ciphertext, err := d.wrapper.Encrypt(buf)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt keys for storage: %v", err)
+	}
+
+	pe := &physical.Entry{
+		Key:   storedBarrierKeysPath,
+		Value: ciphertext,
+	}
+	if err := d.core.physical.Put(pe); err != nil {
+		return fmt.Errorf("failed to write keys to storage: %v", err)
+	}
+
+	return nil
+}
+
+// GetStoredKeys fetches the stored keys and decrypts them with the key
+// wrapper
+func (d *AutoSeal) GetStoredKeys() ([][]byte, error) {
+	if err := d.checkCore(); err != nil {
+		return nil, err
+	}
+
+	pe, err := d.core.physical.Get(storedBarrierKeysPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch stored keys: %v", err)
+	}
+
+	// This is not strictly an error; we may not have any stored keys, for
+	// instance, if we're not initialized
+	if pe == nil {
+		return nil, nil
+	}
+
+	plaintext, err := d.wrapper.Decrypt(pe.Value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt stored keys: %v", err)
+	}
+
+	var keys [][]byte
+	if err := jsonutil.DecodeJSON(plaintext, &keys); err != nil {
+		return nil, fmt.Errorf("failed to decode stored keys: %v", err)
+	}
+
+	return keys, nil
+}
+
+func (d *AutoSeal) BarrierConfig() (*SealConfig, error) {
+	if d.config != nil {
+		return d.config.Clone(), nil
+	}
+
+	if err := d.checkCore(); err != nil {
+		return nil, err
+	}
+
+	conf, err := d.core.readBarrierConfig(d.BarrierType())
+	if err != nil || conf == nil {
+		return nil, err
+	}
+
+	d.config = conf
+	return d.config.Clone(), nil
+}
+
+func (d *AutoSeal) SetBarrierConfig(config *SealConfig) error {
+	if err := d.checkCore(); err != nil {
+		return err
+	}
+
+	config.Type = d.BarrierType()
+	if err := d.core.writeBarrierConfig(config); err != nil {
+		return err
+	}
+
+	d.config = config.Clone()
+
+	return nil
+}
+
+func (d *AutoSeal) RecoveryType() string {
+	return "shamir"
+}
+
+// RecoveryConfig returns the recovery key configuration. It is stored in
+// the barrier, so Vault must be unsealed to read it.
+func (d *AutoSeal) RecoveryConfig() (*SealConfig, error) {
+	if d.recoveryConfig != nil {
+		return d.recoveryConfig.Clone(), nil
+	}
+
+	if err := d.checkCore(); err != nil {
+		return nil, err
+	}
+
+	entry, err := d.core.barrier.Get(recoverySealConfigPath)
+	if err != nil {
+		d.core.logger.Error("core: failed to read recovery configuration", "error", err)
+		return nil, fmt.Errorf("failed to read recovery configuration: %v", err)
+	}
+	if entry == nil {
+		return nil, nil
+	}
+
+	var conf SealConfig
+	if err := jsonutil.DecodeJSON(entry.Value, &conf); err != nil {
+		d.core.logger.Error("core: failed to decode recovery configuration", "error", err)
+		return nil, fmt.Errorf("failed to decode recovery configuration: %v", err)
+	}
+
+	if conf.Type != d.RecoveryType() {
+		d.core.logger.Error("core: recovery seal type does not match loaded type", "recovery_seal_type", conf.Type, "loaded_seal_type", d.RecoveryType())
+		return nil, fmt.Errorf("recovery seal type of %s does not match loaded type of %s", conf.Type, d.RecoveryType())
+	}
+
+	d.recoveryConfig = &conf
+	return d.recoveryConfig.Clone(), nil
+}
+
+func (d *AutoSeal) SetRecoveryConfig(config *SealConfig) error {
+	if err := d.checkCore(); err != nil {
+		return err
+	}
+
+	config.Type = d.RecoveryType()
+
+	buf, err := json.Marshal(config)
+	if err != nil {
+		return fmt.Errorf("failed to encode recovery configuration: %v", err)
+	}
+
+	if err := d.core.barrier.Put(&Entry{
+		Key:   recoverySealConfigPath,
+		Value: buf,
+	}); err != nil {
+		d.core.logger.Error("core: failed to write recovery configuration", "error", err)
+		return fmt.Errorf("failed to write recovery configuration: %v", err)
+	}
+
+	d.recoveryConfig = config.Clone()
+
+	return nil
+}
+
+func (d *AutoSeal) VerifyRecoveryKey(key []byte) error {
+	if err := d.checkCore(); err != nil {
+		return err
+	}
+	if len(key) == 0 {
+		return fmt.Errorf("recovery key to verify is empty")
+	}
+
+	entry, err := d.core.barrier.Get(recoveryKeyPath)
+	if err != nil {
+		d.core.logger.Error("core: failed to read recovery key", "error", err)
+		return fmt.Errorf("failed to read recovery key: %v", err)
+	}
+	if entry == nil {
+		return fmt.Errorf("no recovery key found")
+	}
+
+	if subtle.ConstantTimeCompare(entry.Value, key) != 1 {
+		return fmt.Errorf("recovery key verification failed")
+	}
+
+	return nil
+}
+
+func (d *AutoSeal) SetRecoveryKey(key []byte) error {
+	if err := d.checkCore(); err != nil {
+		return err
+	}
+	if key == nil {
+		return fmt.Errorf("recovery key to store is nil")
+	}
+
+	if err := d.core.barrier.Put(&Entry{
+		Key:   recoveryKeyPath,
+		Value: key,
+	}); err != nil {
+		d.core.logger.Error("core: failed to write recovery key", "error", err)
+		return fmt.Errorf("failed to write recovery key: %v", err)
+	}
+
+	return nil
+}
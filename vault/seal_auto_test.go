@@ -0,0 +1,137 @@
+package vault
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/vault/helper/logformat"
+	"github.com/hashicorp/vault/physical"
+	"github.com/hashicorp/vault/shamir"
+	log "github.com/mgutz/logxi/v1"
+)
+
+// testKeyWrapper "encrypts" by XORing with a fixed key and marking the
+// result, which is enough to check data goes through the wrapper
+type testKeyWrapper struct{}
+
+func (w *testKeyWrapper) Type() string    { return "test-wrapper" }
+func (w *testKeyWrapper) Init() error     { return nil }
+func (w *testKeyWrapper) Finalize() error { return nil }
+
+func (w *testKeyWrapper) xor(in []byte) []byte {
+	out := make([]byte, len(in))
+	for i, b := range in {
+		out[i] = b ^ 0x5a
+	}
+	return out
+}
+
+func (w *testKeyWrapper) Encrypt(plaintext []byte) ([]byte, error) {
+	return append([]byte("wrapped:"), w.xor(plaintext)...), nil
+}
+
+func (w *testKeyWrapper) Decrypt(ciphertext []byte) ([]byte, error) {
+	if !bytes.HasPrefix(ciphertext, []byte("wrapped:")) {
+		return nil, fmt.Errorf("not wrapped")
+	}
+	return w.xor(bytes.TrimPrefix(ciphertext, []byte("wrapped:"))), nil
+}
+
+func TestAutoSeal(t *testing.T) {
+	logger := logformat.NewVaultLogger(log.LevelTrace)
+	inm := physical.NewInmem(logger)
+
+	core, err := NewCore(&CoreConfig{
+		Physical:     inm,
+		Seal:         NewAutoSeal(&testKeyWrapper{}),
+		DisableMlock: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := core.Initialize(&InitParams{
+		BarrierConfig: &SealConfig{
+			SecretShares:    1,
+			SecretThreshold: 1,
+			StoredShares:    1,
+		},
+		RecoveryConfig: &SealConfig{
+			SecretShares:    5,
+			SecretThreshold: 3,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.SecretShares) != 0 {
+		t.Fatalf("expected no unseal keys to be returned, got %d", len(result.SecretShares))
+	}
+	if len(result.RecoveryShares) != 5 {
+		t.Fatalf("expected 5 recovery keys, got %d", len(result.RecoveryShares))
+	}
+
+	// The stored keys must only be kept encrypted
+	pe, err := inm.Get(storedBarrierKeysPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pe == nil || !bytes.HasPrefix(pe.Value, []byte("wrapped:")) {
+		t.Fatalf("stored keys are not wrapped: %#v", pe)
+	}
+
+	if err := core.UnsealWithStoredKeys(); err != nil {
+		t.Fatal(err)
+	}
+	if sealed, _ := core.Sealed(); sealed {
+		t.Fatal("should not be sealed")
+	}
+
+	conf, err := core.SealAccess().BarrierConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if conf.Type != "test-wrapper" {
+		t.Fatalf("bad seal type: %s", conf.Type)
+	}
+
+	recoveryConf, err := core.SealAccess().RecoveryConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if recoveryConf.Type != "shamir" || recoveryConf.SecretShares != 5 || recoveryConf.SecretThreshold != 3 {
+		t.Fatalf("bad recovery config: %#v", recoveryConf)
+	}
+
+	recoveryKey, err := shamir.Combine(result.RecoveryShares[:3])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := core.seal.VerifyRecoveryKey(recoveryKey); err != nil {
+		t.Fatal(err)
+	}
+	if err := core.seal.VerifyRecoveryKey(result.RecoveryShares[0]); err == nil {
+		t.Fatal("expected a recovery key share to fail verification")
+	}
+
+	// A new core on the same storage unseals itself
+	core2, err := NewCore(&CoreConfig{
+		Physical:     inm,
+		Seal:         NewAutoSeal(&testKeyWrapper{}),
+		DisableMlock: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sealed, _ := core2.Sealed(); sealed {
+		t.Fatal("should not be sealed")
+	}
+
+	// The default seal must refuse to load the configuration
+	defSeal := &DefaultSeal{}
+	defSeal.SetCore(core2)
+	if _, err := defSeal.BarrierConfig(); err == nil {
+		t.Fatal("expected seal type mismatch error")
+	}
+}
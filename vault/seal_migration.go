@@ -0,0 +1,326 @@
+package vault
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+
+	"github.com/armon/go-metrics"
+	"github.com/hashicorp/vault/helper/jsonutil"
+	"github.com/hashicorp/vault/shamir"
+)
+
+// storedBarrierSealType returns the type of the seal Vault was initialized
+// with, or an empty string if it is not initialized
+func (c *Core) storedBarrierSealType() (string, error) {
+	pe, err := c.physical.Get(barrierSealConfigPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to check seal configuration: %v", err)
+	}
+	if pe == nil {
+		return "", nil
+	}
+
+	var conf SealConfig
+	if err := jsonutil.DecodeJSON(pe.Value, &conf); err != nil {
+		return "", fmt.Errorf("failed to decode seal configuration: %v", err)
+	}
+
+	// Configurations written before seal types were recorded come from the
+	// shamir seal
+	if conf.Type == "" {
+		return "shamir", nil
+	}
+	return conf.Type, nil
+}
+
+// SealMigrationPending returns whether Vault was started with a seal to
+// migrate to and has not been unsealed with the migrate flag yet
+func (c *Core) SealMigrationPending() bool {
+	c.stateLock.RLock()
+	defer c.stateLock.RUnlock()
+	return c.migrationSeal != nil
+}
+
+// SealMigrationConfig returns the configuration of the keys needed to
+// unseal Vault with the migrate flag
+func (c *Core) SealMigrationConfig() (*SealConfig, error) {
+	c.stateLock.Lock()
+	defer c.stateLock.Unlock()
+
+	if c.migrationSeal == nil {
+		return nil, ErrNoSealMigration
+	}
+
+	conf, err := c.migrationKeyConfig()
+	if err != nil {
+		return nil, err
+	}
+	return conf.Clone(), nil
+}
+
+// migrationKeyConfig returns the configuration of the keys needed to
+// migrate the seal: the unseal keys when migrating from Shamir, or the
+// recovery keys when migrating from an auto-unseal seal. Recovery keys are
+// configured within the barrier, so it is briefly unsealed with the stored
+// keys to read their configuration. It must be called with the state lock
+// held while Vault is sealed.
+func (c *Core) migrationKeyConfig() (*SealConfig, error) {
+	if c.migrationKeyConf != nil {
+		return c.migrationKeyConf, nil
+	}
+
+	if !c.seal.RecoveryKeySupported() {
+		conf, err := c.seal.BarrierConfig()
+		if err != nil {
+			return nil, err
+		}
+		if conf == nil {
+			return nil, ErrNotInit
+		}
+		c.migrationKeyConf = conf
+		return conf, nil
+	}
+
+	masterKey, err := c.storedMasterKey()
+	if err != nil {
+		return nil, err
+	}
+	defer memzero(masterKey)
+
+	if err := c.barrier.Unseal(masterKey); err != nil {
+		return nil, fmt.Errorf("failed to unseal barrier with stored keys: %v", err)
+	}
+	conf, err := c.seal.RecoveryConfig()
+	if sealErr := c.barrier.Seal(); sealErr != nil {
+		c.logger.Error("core: failed to seal barrier", "error", sealErr)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if conf == nil {
+		return nil, fmt.Errorf("recovery configuration missing")
+	}
+
+	c.migrationKeyConf = conf
+	return conf, nil
+}
+
+// storedMasterKey recovers the master key from the stored keys of the seal
+func (c *Core) storedMasterKey() ([]byte, error) {
+	config, err := c.seal.BarrierConfig()
+	if err != nil {
+		return nil, err
+	}
+	if config == nil {
+		return nil, ErrNotInit
+	}
+
+	keys, err := c.seal.GetStoredKeys()
+	if err != nil {
+		return nil, fmt.Errorf("fetching stored unseal keys failed: %v", err)
+	}
+	if len(keys) < config.SecretThreshold {
+		return nil, fmt.Errorf("not enough stored unseal keys to recover the master key")
+	}
+
+	if config.SecretThreshold == 1 {
+		return keys[0], nil
+	}
+	masterKey, err := shamir.Combine(keys[:config.SecretThreshold])
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute master key: %v", err)
+	}
+	return masterKey, nil
+}
+
+// UnsealMigrate is used to provide one of the key parts to unseal Vault
+// while migrating its seal. The keys are the unseal keys of the current
+// seal if it is a Shamir seal, or its recovery keys if it is an auto-unseal
+// seal. Once enough keys are provided, the master key is rotated and moved
+// to the new seal, and Vault is unsealed.
+//
+// When migrating from Shamir to an auto-unseal seal the unseal keys become
+// the recovery keys, and when migrating from an auto-unseal seal to Shamir
+// the recovery keys become the unseal keys. Recovery keys are kept when
+// migrating between auto-unseal seals.
+func (c *Core) UnsealMigrate(key []byte) (bool, error) {
+	defer metrics.MeasureSince([]string{"core", "unseal_migrate"}, time.Now())
+
+	// Verify the key length
+	min, max := c.barrier.KeyLength()
+	max += shamir.ShareOverhead
+	if len(key) < min {
+		return false, &ErrInvalidKey{fmt.Sprintf("key is shorter than minimum %d bytes", min)}
+	}
+	if len(key) > max {
+		return false, &ErrInvalidKey{fmt.Sprintf("key is longer than maximum %d bytes", max)}
+	}
+
+	c.stateLock.Lock()
+	defer c.stateLock.Unlock()
+
+	// Check if already unsealed
+	if !c.sealed {
+		return true, nil
+	}
+
+	if c.migrationSeal == nil {
+		return false, ErrNoSealMigration
+	}
+
+	config, err := c.migrationKeyConfig()
+	if err != nil {
+		return false, err
+	}
+
+	// Check if we already have this piece
+	for _, existing := range c.unlockParts {
+		if bytes.Equal(existing, key) {
+			return false, nil
+		}
+	}
+
+	// Store this key
+	c.unlockParts = append(c.unlockParts, key)
+
+	// Check if we don't have enough keys to migrate
+	if len(c.unlockParts) < config.SecretThreshold {
+		if c.logger.IsDebug() {
+			c.logger.Debug("core: cannot migrate seal, not enough keys", "keys", len(c.unlockParts), "threshold", config.SecretThreshold)
+		}
+		return false, nil
+	}
+
+	// Recover the key
+	var combinedKey []byte
+	if config.SecretThreshold == 1 {
+		combinedKey = c.unlockParts[0]
+		c.unlockParts = nil
+	} else {
+		combinedKey, err = shamir.Combine(c.unlockParts)
+		c.unlockParts = nil
+		if err != nil {
+			return false, fmt.Errorf("failed to compute key: %v", err)
+		}
+	}
+	defer memzero(combinedKey)
+
+	masterKey, err := c.migrateSeal(combinedKey, config)
+	if err != nil {
+		return false, err
+	}
+	defer memzero(masterKey)
+
+	return c.unsealInternal(masterKey)
+}
+
+// migrateSeal moves the master key from the current seal to the migration
+// seal, given the combined unseal or recovery key of the current seal. The
+// master key is rotated in the process, so that the keys of the current
+// seal can't unseal Vault anymore, and the new master key is returned. It
+// must be called with the state lock held while Vault is sealed.
+func (c *Core) migrateSeal(combinedKey []byte, keyConfig *SealConfig) ([]byte, error) {
+	fromSeal, toSeal := c.seal, c.migrationSeal
+
+	// Unseal the barrier with the current master key
+	masterKey := combinedKey
+	if fromSeal.StoredKeysSupported() {
+		var err error
+		masterKey, err = c.storedMasterKey()
+		if err != nil {
+			return nil, err
+		}
+		defer memzero(masterKey)
+	}
+	if err := c.barrier.Unseal(masterKey); err != nil {
+		return nil, err
+	}
+
+	// Ensure the barrier is re-sealed, it is unsealed again with the new
+	// master key once the migration is done
+	defer func() {
+		if err := c.barrier.Seal(); err != nil {
+			c.logger.Error("core: failed to seal barrier", "error", err)
+		}
+	}()
+
+	if fromSeal.RecoveryKeySupported() {
+		if err := fromSeal.VerifyRecoveryKey(combinedKey); err != nil {
+			return nil, &ErrInvalidKey{fmt.Sprintf("recovery key verification failed: %v", err)}
+		}
+	}
+
+	var newMasterKey []byte
+	var newConfig *SealConfig
+	if toSeal.StoredKeysSupported() {
+		var err error
+		newMasterKey, err = c.barrier.GenerateKey()
+		if err != nil {
+			return nil, fmt.Errorf("key generation failed: %v", err)
+		}
+		newConfig = &SealConfig{
+			SecretShares:    1,
+			SecretThreshold: 1,
+			StoredShares:    1,
+		}
+		if err := toSeal.SetStoredKeys([][]byte{newMasterKey}); err != nil {
+			c.logger.Error("core: failed to store keys", "error", err)
+			return nil, fmt.Errorf("failed to store keys: %v", err)
+		}
+	} else {
+		// The recovery keys become the unseal keys
+		newMasterKey = make([]byte, len(combinedKey))
+		copy(newMasterKey, combinedKey)
+		newConfig = &SealConfig{
+			SecretShares:    keyConfig.SecretShares,
+			SecretThreshold: keyConfig.SecretThreshold,
+		}
+	}
+
+	// Rekey the barrier
+	if err := c.barrier.Rekey(newMasterKey); err != nil {
+		c.logger.Error("core: failed to rekey barrier", "error", err)
+		return nil, fmt.Errorf("failed to rekey barrier: %v", err)
+	}
+	if err := toSeal.SetBarrierConfig(newConfig); err != nil {
+		c.logger.Error("core: failed to save barrier configuration", "error", err)
+		return nil, fmt.Errorf("barrier configuration saving failed: %v", err)
+	}
+
+	switch {
+	case !fromSeal.RecoveryKeySupported() && toSeal.RecoveryKeySupported():
+		// The unseal keys become the recovery keys
+		if err := toSeal.SetRecoveryConfig(&SealConfig{
+			SecretShares:    keyConfig.SecretShares,
+			SecretThreshold: keyConfig.SecretThreshold,
+		}); err != nil {
+			c.logger.Error("core: failed to save recovery configuration", "error", err)
+			return nil, fmt.Errorf("recovery configuration saving failed: %v", err)
+		}
+		if err := toSeal.SetRecoveryKey(combinedKey); err != nil {
+			return nil, err
+		}
+
+	case fromSeal.RecoveryKeySupported() && !toSeal.RecoveryKeySupported():
+		// Clean up what is left of the auto-unseal seal
+		if err := c.physical.Delete(storedBarrierKeysPath); err != nil {
+			return nil, fmt.Errorf("failed to delete stored keys: %v", err)
+		}
+		for _, path := range []string{recoverySealConfigPath, recoveryKeyPath} {
+			if err := c.barrier.Delete(path); err != nil {
+				return nil, fmt.Errorf("failed to delete recovery key data: %v", err)
+			}
+		}
+	}
+
+	if c.logger.IsInfo() {
+		c.logger.Info("core: seal migrated", "from", fromSeal.BarrierType(), "to", toSeal.BarrierType())
+	}
+
+	c.seal = toSeal
+	c.migrationSeal = nil
+	c.migrationKeyConf = nil
+
+	return newMasterKey, nil
+}
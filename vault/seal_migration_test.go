@@ -0,0 +1,233 @@
+package vault
+
+import (
+	"testing"
+
+	"github.com/hashicorp/vault/helper/logformat"
+	"github.com/hashicorp/vault/physical"
+	"github.com/hashicorp/vault/shamir"
+	log "github.com/mgutz/logxi/v1"
+)
+
+// otherKeyWrapper is a testKeyWrapper reporting another seal type, to
+// migrate between auto-unseal seals
+type otherKeyWrapper struct {
+	testKeyWrapper
+}
+
+func (w *otherKeyWrapper) Type() string { return "other-test-wrapper" }
+
+func testSealMigrationCore(t *testing.T, inm physical.Backend, seal, migrationSeal Seal) *Core {
+	core, err := NewCore(&CoreConfig{
+		Physical:      inm,
+		Seal:          seal,
+		MigrationSeal: migrationSeal,
+		DisableMlock:  true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return core
+}
+
+func testSealMigrate(t *testing.T, core *Core, keys [][]byte) {
+	if !core.SealMigrationPending() {
+		t.Fatal("expected a seal migration to be pending")
+	}
+	if _, err := core.Unseal(keys[0]); err != ErrSealMigrationPending {
+		t.Fatalf("expected migration pending error, got %v", err)
+	}
+
+	for i, key := range keys {
+		unsealed, err := core.UnsealMigrate(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if unsealed != (i == len(keys)-1) {
+			t.Fatalf("bad unseal state after %d keys: %v", i+1, unsealed)
+		}
+	}
+
+	if sealed, _ := core.Sealed(); sealed {
+		t.Fatal("should not be sealed")
+	}
+	if core.SealMigrationPending() {
+		t.Fatal("expected no seal migration to be pending")
+	}
+	if _, err := core.UnsealMigrate(keys[0]); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCore_SealMigration(t *testing.T) {
+	logger := logformat.NewVaultLogger(log.LevelTrace)
+	inm := physical.NewInmem(logger)
+
+	// Initialize with Shamir
+	core := testSealMigrationCore(t, inm, nil, nil)
+	result, err := core.Initialize(&InitParams{
+		BarrierConfig: &SealConfig{
+			SecretShares:    5,
+			SecretThreshold: 3,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	unsealKeys := result.SecretShares[:3]
+	recoveryKey, err := shamir.Combine(unsealKeys)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Configuring an auto-unseal seal requires migrating to it
+	core = testSealMigrationCore(t, inm, NewAutoSeal(&testKeyWrapper{}), nil)
+	if sealed, _ := core.Sealed(); !sealed {
+		t.Fatal("should be sealed")
+	}
+	conf, err := core.SealMigrationConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if conf.SecretShares != 5 || conf.SecretThreshold != 3 {
+		t.Fatalf("bad migration key config: %#v", conf)
+	}
+	testSealMigrate(t, core, unsealKeys)
+
+	// The unseal keys are now recovery keys
+	conf, err = core.SealAccess().BarrierConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if conf.Type != "test-wrapper" {
+		t.Fatalf("bad seal type: %s", conf.Type)
+	}
+	conf, err = core.SealAccess().RecoveryConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if conf.SecretShares != 5 || conf.SecretThreshold != 3 {
+		t.Fatalf("bad recovery config: %#v", conf)
+	}
+	if err := core.seal.VerifyRecoveryKey(recoveryKey); err != nil {
+		t.Fatal(err)
+	}
+
+	// The migrated Vault unseals itself
+	core = testSealMigrationCore(t, inm, NewAutoSeal(&testKeyWrapper{}), nil)
+	if sealed, _ := core.Sealed(); sealed {
+		t.Fatal("should not be sealed")
+	}
+	if core.SealMigrationPending() {
+		t.Fatal("expected no seal migration to be pending")
+	}
+
+	// Migrating between auto-unseal seals keeps the recovery keys
+	core = testSealMigrationCore(t, inm, NewAutoSeal(&testKeyWrapper{}), NewAutoSeal(&otherKeyWrapper{}))
+	testSealMigrate(t, core, unsealKeys)
+	conf, err = core.SealAccess().BarrierConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if conf.Type != "other-test-wrapper" {
+		t.Fatalf("bad seal type: %s", conf.Type)
+	}
+	if err := core.seal.VerifyRecoveryKey(recoveryKey); err != nil {
+		t.Fatal(err)
+	}
+
+	core = testSealMigrationCore(t, inm, NewAutoSeal(&otherKeyWrapper{}), nil)
+	if sealed, _ := core.Sealed(); sealed {
+		t.Fatal("should not be sealed")
+	}
+
+	// Migrating back to Shamir turns the recovery keys into unseal keys
+	core = testSealMigrationCore(t, inm, NewAutoSeal(&otherKeyWrapper{}), &DefaultSeal{})
+	testSealMigrate(t, core, unsealKeys)
+	conf, err = core.SealAccess().BarrierConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if conf.Type != "shamir" || conf.SecretShares != 5 || conf.SecretThreshold != 3 {
+		t.Fatalf("bad seal config: %#v", conf)
+	}
+	pe, err := inm.Get(storedBarrierKeysPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pe != nil {
+		t.Fatal("expected stored keys to be deleted")
+	}
+
+	core = testSealMigrationCore(t, inm, nil, nil)
+	for i, key := range unsealKeys {
+		unsealed, err := core.Unseal(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if unsealed != (i == len(unsealKeys)-1) {
+			t.Fatalf("bad unseal state after %d keys: %v", i+1, unsealed)
+		}
+	}
+}
+
+func TestCore_SealMigration_BadKeys(t *testing.T) {
+	logger := logformat.NewVaultLogger(log.LevelTrace)
+	inm := physical.NewInmem(logger)
+
+	core := testSealMigrationCore(t, inm, nil, nil)
+	result, err := core.Initialize(&InitParams{
+		BarrierConfig: &SealConfig{
+			SecretShares:    1,
+			SecretThreshold: 1,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Without a migration pending the migrate flag is refused
+	if _, err := core.UnsealMigrate(result.SecretShares[0]); err != ErrNoSealMigration {
+		t.Fatalf("expected no migration error, got %v", err)
+	}
+
+	// Migrating between Shamir seals makes no sense
+	if _, err := NewCore(&CoreConfig{
+		Physical:      inm,
+		MigrationSeal: &DefaultSeal{},
+		DisableMlock:  true,
+	}); err == nil {
+		t.Fatal("expected error migrating between Shamir seals")
+	}
+
+	core = testSealMigrationCore(t, inm, NewAutoSeal(&testKeyWrapper{}), nil)
+	badKey := make([]byte, len(result.SecretShares[0]))
+	if _, err := core.UnsealMigrate(badKey); err == nil {
+		t.Fatal("expected error migrating with a bad key")
+	}
+	if !core.SealMigrationPending() {
+		t.Fatal("expected the seal migration to still be pending")
+	}
+
+	// The seal is left untouched
+	if _, err := core.UnsealMigrate(result.SecretShares[0]); err != nil {
+		t.Fatal(err)
+	}
+	if sealed, _ := core.Sealed(); sealed {
+		t.Fatal("should not be sealed")
+	}
+}
+
+func TestCore_SealMigration_Uninitialized(t *testing.T) {
+	logger := logformat.NewVaultLogger(log.LevelTrace)
+	inm := physical.NewInmem(logger)
+
+	// Before initialization the seal to migrate to is used directly
+	core := testSealMigrationCore(t, inm, nil, NewAutoSeal(&testKeyWrapper{}))
+	if core.SealMigrationPending() {
+		t.Fatal("expected no seal migration to be pending")
+	}
+	if core.seal.BarrierType() != "test-wrapper" {
+		t.Fatalf("bad seal type: %s", core.seal.BarrierType())
+	}
+}
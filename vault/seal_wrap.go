@@ -0,0 +1,81 @@
+package vault
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+)
+
+// sealWrapPrefix marks the values encrypted by the seal. Values without it
+// were written before the mount was seal wrapped and are read as is.
+var sealWrapPrefix = []byte("\x00sealwrap:")
+
+// ErrSealWrapUnavailable is returned when seal wrapping a mount, or using a
+// seal-wrapped mount, while Vault is not sealed by an auto seal
+var ErrSealWrapUnavailable = errors.New("seal wrapping requires an auto seal")
+
+// sealWrapper returns the key wrapper of the seal, or nil if the seal can't
+// encrypt values
+func (c *Core) sealWrapper() KeyWrapper {
+	if seal, ok := c.seal.(*AutoSeal); ok {
+		return seal.wrapper
+	}
+	return nil
+}
+
+// sealWrapStorage encrypts the values written to the barrier with the seal
+// before they are encrypted by the barrier, so that values of seal-wrapped
+// mounts are protected by the HSM or KMS of the seal as well as by the
+// barrier keys
+type sealWrapStorage struct {
+	BarrierStorage
+	core *Core
+}
+
+func (s *sealWrapStorage) Put(entry *Entry) error {
+	wrapper := s.core.sealWrapper()
+	if wrapper == nil {
+		return ErrSealWrapUnavailable
+	}
+
+	ciphertext, err := wrapper.Encrypt(entry.Value)
+	if err != nil {
+		return fmt.Errorf("failed to seal wrap value: %v", err)
+	}
+
+	return s.BarrierStorage.Put(&Entry{
+		Key:   entry.Key,
+		Value: append(append([]byte{}, sealWrapPrefix...), ciphertext...),
+	})
+}
+
+func (s *sealWrapStorage) Get(key string) (*Entry, error) {
+	entry, err := s.BarrierStorage.Get(key)
+	if err != nil || entry == nil || !bytes.HasPrefix(entry.Value, sealWrapPrefix) {
+		return entry, err
+	}
+
+	wrapper := s.core.sealWrapper()
+	if wrapper == nil {
+		return nil, ErrSealWrapUnavailable
+	}
+
+	entry.Value, err = wrapper.Decrypt(entry.Value[len(sealWrapPrefix):])
+	if err != nil {
+		return nil, fmt.Errorf("failed to unwrap value: %v", err)
+	}
+	return entry, nil
+}
+
+// mountEntryView returns the storage view of a mount under the given
+// barrier prefix, seal wrapping its values if the mount is seal wrapped
+func (c *Core) mountEntryView(entry *MountEntry, prefix string) *BarrierView {
+	var storage BarrierStorage = c.barrier
+	if entry.SealWrap {
+		storage = &sealWrapStorage{
+			BarrierStorage: c.barrier,
+			core:           c,
+		}
+	}
+	return NewBarrierView(storage, prefix+entry.UUID+"/")
+}
@@ -0,0 +1,106 @@
+package vault
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/hashicorp/vault/helper/logformat"
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/physical"
+	log "github.com/mgutz/logxi/v1"
+)
+
+func TestCore_SealWrap(t *testing.T) {
+	core, err := NewCore(&CoreConfig{
+		Physical:     physical.NewInmem(logformat.NewVaultLogger(log.LevelTrace)),
+		Seal:         NewAutoSeal(&testKeyWrapper{}),
+		DisableMlock: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := core.Initialize(&InitParams{
+		BarrierConfig: &SealConfig{
+			SecretShares:    1,
+			SecretThreshold: 1,
+			StoredShares:    1,
+		},
+		RecoveryConfig: &SealConfig{
+			SecretShares:    1,
+			SecretThreshold: 1,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := core.UnsealWithStoredKeys(); err != nil {
+		t.Fatal(err)
+	}
+
+	request := func(op logical.Operation, path string, data map[string]interface{}) *logical.Response {
+		req := logical.TestRequest(t, op, path)
+		req.ClientToken = result.RootToken
+		req.Data = data
+		resp, err := core.HandleRequest(req)
+		if err != nil || (resp != nil && resp.IsError()) {
+			t.Fatalf("err: %v resp: %#v", err, resp)
+		}
+		return resp
+	}
+
+	request(logical.UpdateOperation, "sys/mounts/wrapped", map[string]interface{}{
+		"type":      "generic",
+		"seal_wrap": true,
+	})
+	request(logical.UpdateOperation, "wrapped/foo", map[string]interface{}{
+		"value": "bar",
+	})
+
+	// The value is encrypted by the seal within the barrier
+	entry := core.router.MatchingMountEntry("wrapped/")
+	raw, err := core.barrier.Get(backendBarrierPrefix + entry.UUID + "/foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if raw == nil || !bytes.HasPrefix(raw.Value, append(sealWrapPrefix, "wrapped:"...)) {
+		t.Fatalf("value is not seal wrapped: %#v", raw)
+	}
+
+	resp := request(logical.ReadOperation, "wrapped/foo", nil)
+	if resp.Data["value"] != "bar" {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+
+	resp = request(logical.ReadOperation, "sys/mounts", nil)
+	if resp.Data["wrapped/"].(map[string]interface{})["seal_wrap"] != true {
+		t.Fatalf("bad: %#v", resp.Data["wrapped/"])
+	}
+	if _, ok := resp.Data["secret/"].(map[string]interface{})["seal_wrap"]; ok {
+		t.Fatalf("bad: %#v", resp.Data["secret/"])
+	}
+
+	// Values of other mounts are only encrypted by the barrier
+	request(logical.UpdateOperation, "secret/foo", map[string]interface{}{
+		"value": "bar",
+	})
+	entry = core.router.MatchingMountEntry("secret/")
+	raw, err = core.barrier.Get(backendBarrierPrefix + entry.UUID + "/foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if raw == nil || bytes.HasPrefix(raw.Value, sealWrapPrefix) {
+		t.Fatalf("value is seal wrapped: %#v", raw)
+	}
+}
+
+func TestCore_SealWrap_shamir(t *testing.T) {
+	c, _, root := TestCoreUnsealed(t)
+
+	req := logical.TestRequest(t, logical.UpdateOperation, "sys/mounts/wrapped")
+	req.ClientToken = root
+	req.Data["type"] = "generic"
+	req.Data["seal_wrap"] = true
+	if _, err := c.HandleRequest(req); err == nil {
+		t.Fatal("expected an error")
+	}
+}
@@ -338,11 +338,11 @@ func (n *noopAudit) GetHash(data string) string {
 	return n.Config.Salt.GetIdentifiedHMAC(data)
 }
 
-func (n *noopAudit) LogRequest(a *logical.Auth, r *logical.Request, e error) error {
+func (n *noopAudit) LogRequest(in *audit.LogInput) error {
 	return nil
 }
 
-func (n *noopAudit) LogResponse(a *logical.Auth, r *logical.Request, re *logical.Response, err error) error {
+func (n *noopAudit) LogResponse(in *audit.LogInput) error {
 	return nil
 }
 
@@ -1,6 +1,7 @@
 package vault
 
 import (
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"regexp"
@@ -10,6 +11,7 @@ import (
 
 	"github.com/armon/go-metrics"
 	"github.com/hashicorp/go-uuid"
+	"github.com/hashicorp/vault/helper/cidrutil"
 	"github.com/hashicorp/vault/helper/duration"
 	"github.com/hashicorp/vault/helper/jsonutil"
 	"github.com/hashicorp/vault/helper/locksutil"
@@ -18,6 +20,7 @@ import (
 	"github.com/hashicorp/vault/helper/strutil"
 	"github.com/hashicorp/vault/logical"
 	"github.com/hashicorp/vault/logical/framework"
+	"github.com/hashicorp/vault/physical"
 	"github.com/mitchellh/mapstructure"
 )
 
@@ -40,6 +43,19 @@ const (
 
 	// rolesPrefix is the prefix used to store role information
 	rolesPrefix = "roles/"
+
+	// namespaceRolesPrefix is the prefix used to store the roles of the
+	// namespaces other than the root namespace, followed by the ID of the
+	// namespace
+	namespaceRolesPrefix = "namespace-roles/"
+
+	// batchTokenPrefix is the prefix of batch token IDs; the remainder
+	// of the ID is the encrypted token entry
+	batchTokenPrefix = "b."
+
+	// batchTokenEncryptionKey is the key used as additional data when
+	// encrypting batch token entries with the barrier
+	batchTokenEncryptionKey = "core/token/batch"
 )
 
 var (
@@ -59,11 +75,19 @@ type TokenStore struct {
 	view *BarrierView
 	salt *salt.Salt
 
+	barrier BarrierEncryptor
+
 	expiration *ExpirationManager
 
 	cubbyholeBackend *CubbyholeBackend
 
-	policyLookupFunc func(string) (*Policy, error)
+	// policyLookupFunc looks up the policies of a namespace
+	policyLookupFunc func(*Namespace, string) (*Policy, error)
+
+	// namespaceByPathFunc and namespaceByIDFunc look up the namespaces of
+	// requests and tokens
+	namespaceByPathFunc func(string) *Namespace
+	namespaceByIDFunc   func(string) *Namespace
 
 	tokenLocks map[string]*sync.RWMutex
 }
@@ -76,12 +100,21 @@ func NewTokenStore(c *Core, config *logical.BackendConfig) (*TokenStore, error)
 
 	// Initialize the store
 	t := &TokenStore{
-		view: view,
+		view:    view,
+		barrier: c.barrier,
 	}
 
 	if c.policyStore != nil {
-		t.policyLookupFunc = c.policyStore.GetPolicy
+		t.policyLookupFunc = func(ns *Namespace, name string) (*Policy, error) {
+			ps := c.namespacePolicyStore(ns)
+			if ps == nil {
+				return nil, nil
+			}
+			return ps.GetPolicy(name)
+		}
 	}
+	t.namespaceByPathFunc = c.namespaceByPath
+	t.namespaceByIDFunc = c.namespaceByID
 
 	// Setup the salt
 	salt, err := salt.NewSalt(view, &salt.Config{
@@ -184,6 +217,18 @@ func NewTokenStore(c *Core, config *logical.BackendConfig) (*TokenStore, error)
 						Default:     true,
 						Description: tokenRenewableHelp,
 					},
+
+					"bound_cidrs": &framework.FieldSchema{
+						Type:        framework.TypeString,
+						Default:     "",
+						Description: tokenBoundCIDRsHelp,
+					},
+
+					"token_type": &framework.FieldSchema{
+						Type:        framework.TypeString,
+						Default:     logical.TokenTypeService,
+						Description: tokenTypeHelp,
+					},
 				},
 
 				Callbacks: map[logical.Operation]framework.OperationFunc{
@@ -380,6 +425,33 @@ func NewTokenStore(c *Core, config *logical.BackendConfig) (*TokenStore, error)
 				HelpDescription: strings.TrimSpace(tokenRevokeOrphanHelp),
 			},
 
+			&framework.Path{
+				Pattern: "renew-accessor" + framework.OptionalParamRegex("urlaccessor"),
+
+				Fields: map[string]*framework.FieldSchema{
+					"urlaccessor": &framework.FieldSchema{
+						Type:        framework.TypeString,
+						Description: "Accessor of the token to renew (URL parameter)",
+					},
+					"accessor": &framework.FieldSchema{
+						Type:        framework.TypeString,
+						Description: "Accessor of the token to renew (request body)",
+					},
+					"increment": &framework.FieldSchema{
+						Type:        framework.TypeDurationSecond,
+						Default:     0,
+						Description: "The desired increment in seconds to the token expiration",
+					},
+				},
+
+				Callbacks: map[logical.Operation]framework.OperationFunc{
+					logical.UpdateOperation: t.handleUpdateRenewAccessor,
+				},
+
+				HelpSynopsis:    strings.TrimSpace(tokenRenewAccessorHelp),
+				HelpDescription: strings.TrimSpace(tokenRenewAccessorHelp),
+			},
+
 			&framework.Path{
 				Pattern: "renew-self$",
 
@@ -479,6 +551,30 @@ type TokenEntry struct {
 	// through the create endpoint; periods managed by roles or other auth
 	// backends are subject to those renewal rules.
 	Period time.Duration `json:"period" mapstructure:"period" structs:"period"`
+
+	// If set, the token can only be used from a network address within one
+	// of these CIDR blocks
+	BoundCIDRs []string `json:"bound_cidrs" mapstructure:"bound_cidrs" structs:"bound_cidrs"`
+
+	// The type of the token; either "service" or "batch". Entries written
+	// before token types existed have an empty type and are service tokens.
+	Type string `json:"type" mapstructure:"type" structs:"type"`
+
+	// If set, the ID of the identity entity the token is associated with.
+	// The policies of the entity apply in addition to the token's own.
+	EntityID string `json:"entity_id" mapstructure:"entity_id" structs:"entity_id"`
+
+	// The ID of the namespace of the token, empty for the root namespace.
+	// The token can only be used in its namespace and the namespaces
+	// beneath it, and its policies are the ones of its namespace.
+	NamespaceID string `json:"namespace_id" mapstructure:"namespace_id" structs:"namespace_id"`
+}
+
+// expirationTime returns the time at which the token expires based on its
+// creation time and TTL. This is only authoritative for batch tokens; the
+// expiration of service tokens is tracked by the expiration manager.
+func (te *TokenEntry) expirationTime() time.Time {
+	return time.Unix(te.CreationTime, 0).Add(te.TTL)
 }
 
 // tsRoleEntry contains token store role information
@@ -510,6 +606,13 @@ type tsRoleEntry struct {
 	// If set, the token entry will have an explicit maximum TTL set, rather
 	// than deferring to role/mount values
 	ExplicitMaxTTL time.Duration `json:"explicit_max_ttl" mapstructure:"explicit_max_ttl" structs:"explicit_max_ttl"`
+
+	// If set, tokens created using this role can only be used from network
+	// addresses within these CIDR blocks
+	BoundCIDRs []string `json:"bound_cidrs" mapstructure:"bound_cidrs" structs:"bound_cidrs"`
+
+	// The type of tokens created using this role
+	TokenType string `json:"token_type" mapstructure:"token_type" structs:"token_type"`
 }
 
 type accessorEntry struct {
@@ -529,6 +632,64 @@ func (ts *TokenStore) SaltID(id string) string {
 	return ts.salt.SaltID(id)
 }
 
+// requestNamespace returns the namespace of a request, or nil if it
+// doesn't exist
+func (ts *TokenStore) requestNamespace(req *logical.Request) *Namespace {
+	if ts.namespaceByPathFunc == nil {
+		if req.Namespace == "" {
+			return rootNamespace
+		}
+		return nil
+	}
+	return ts.namespaceByPathFunc(req.Namespace)
+}
+
+// entryNamespace returns the namespace of a token, or nil if it no longer
+// exists
+func (ts *TokenStore) entryNamespace(te *TokenEntry) *Namespace {
+	if ts.namespaceByIDFunc == nil {
+		if te.NamespaceID == "" {
+			return rootNamespace
+		}
+		return nil
+	}
+	return ts.namespaceByIDFunc(te.NamespaceID)
+}
+
+// checkTokenNamespace returns an error unless the token belongs to the
+// namespace of the request or a namespace beneath it, so that the tokens
+// of a namespace can only be managed from it and its parents
+func (ts *TokenStore) checkTokenNamespace(req *logical.Request, id string) error {
+	if req.Namespace == "" {
+		return nil
+	}
+	te, err := ts.Lookup(id)
+	if err != nil {
+		return err
+	}
+	if te == nil {
+		return fmt.Errorf("token not found")
+	}
+	if ns := ts.entryNamespace(te); ns == nil || !strings.HasPrefix(ns.Path, req.Namespace) {
+		return fmt.Errorf("token not found")
+	}
+	return nil
+}
+
+// namespaceRolePrefix returns the prefix the roles of a namespace are
+// stored under
+func namespaceRolePrefix(ns *Namespace) string {
+	if ns.ID == "" {
+		return rolesPrefix
+	}
+	return namespaceRolesPrefix + ns.ID + "/"
+}
+
+// clearNamespaceRoles deletes the roles of a deleted namespace
+func (ts *TokenStore) clearNamespaceRoles(namespaceID string) error {
+	return ClearView(ts.view.SubView(namespaceRolesPrefix + namespaceID + "/"))
+}
+
 // RootToken is used to generate a new token with root privileges and no parent
 func (ts *TokenStore) rootToken() (*TokenEntry, error) {
 	te := &TokenEntry{
@@ -545,6 +706,11 @@ func (ts *TokenStore) rootToken() (*TokenEntry, error) {
 
 func (ts *TokenStore) tokenStoreAccessorList(
 	req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	// The accessors of all the tokens are stored together
+	if req.Namespace != "" {
+		return logical.ErrorResponse("listing accessors is only available in the root namespace"), logical.ErrInvalidRequest
+	}
+
 	entries, err := ts.view.List(accessorPrefix)
 	if err != nil {
 		return nil, err
@@ -573,14 +739,15 @@ func (ts *TokenStore) tokenStoreAccessorList(
 }
 
 // createAccessor is used to create an identifier for the token ID.
-// A storage index, mapping the accessor to the token ID is also created.
-func (ts *TokenStore) createAccessor(entry *TokenEntry) error {
+// It returns the operation writing the storage index, mapping the accessor
+// to the token ID.
+func (ts *TokenStore) createAccessor(entry *TokenEntry) (*TxnEntry, error) {
 	defer metrics.MeasureSince([]string{"token", "createAccessor"}, time.Now())
 
 	// Create a random accessor
 	accessorUUID, err := uuid.GenerateUUID()
 	if err != nil {
-		return err
+		return nil, err
 	}
 	entry.Accessor = accessorUUID
 
@@ -593,20 +760,24 @@ func (ts *TokenStore) createAccessor(entry *TokenEntry) error {
 	}
 	aEntryBytes, err := jsonutil.EncodeJSON(aEntry)
 	if err != nil {
-		return fmt.Errorf("failed to marshal accessor index entry: %v", err)
+		return nil, fmt.Errorf("failed to marshal accessor index entry: %v", err)
 	}
 
-	le := &logical.StorageEntry{Key: path, Value: aEntryBytes}
-	if err := ts.view.Put(le); err != nil {
-		return fmt.Errorf("failed to persist accessor index entry: %v", err)
-	}
-	return nil
+	return &TxnEntry{
+		Operation: physical.PutOperation,
+		Entry:     &Entry{Key: path, Value: aEntryBytes},
+	}, nil
 }
 
 // Create is used to create a new token entry. The entry is assigned
 // a newly generated ID if not provided.
 func (ts *TokenStore) create(entry *TokenEntry) error {
 	defer metrics.MeasureSince([]string{"token", "create"}, time.Now())
+
+	if entry.Type == logical.TokenTypeBatch {
+		return ts.createBatch(entry)
+	}
+
 	// Generate an ID if necessary
 	if entry.ID == "" {
 		entryUUID, err := uuid.GenerateUUID()
@@ -618,12 +789,58 @@ func (ts *TokenStore) create(entry *TokenEntry) error {
 
 	entry.Policies = policyutil.SanitizePolicies(entry.Policies, false)
 
-	err := ts.createAccessor(entry)
+	accessorTxn, err := ts.createAccessor(entry)
 	if err != nil {
 		return err
 	}
 
-	return ts.storeCommon(entry, true)
+	return ts.storeCommon(entry, true, accessorTxn)
+}
+
+// createBatch is used to create a new batch token. Batch tokens are not
+// persisted; instead, the entry is encrypted with the barrier and the
+// ciphertext becomes the token ID.
+func (ts *TokenStore) createBatch(entry *TokenEntry) error {
+	switch {
+	case entry.ID != "":
+		return fmt.Errorf("batch tokens cannot have a custom ID")
+	case entry.NumUses != 0:
+		return fmt.Errorf("batch tokens cannot have a limited number of uses")
+	case entry.Period != 0:
+		return fmt.Errorf("batch tokens cannot be periodic")
+	case entry.TTL == 0:
+		return fmt.Errorf("batch tokens must have a TTL")
+	}
+
+	entry.Policies = policyutil.SanitizePolicies(entry.Policies, false)
+
+	// Batch tokens are not indexed, so the parent cannot reach them through
+	// a secondary index; instead the parent is checked on every lookup
+	if entry.Parent != "" {
+		parent, err := ts.Lookup(entry.Parent)
+		if err != nil {
+			return fmt.Errorf("failed to lookup parent: %v", err)
+		}
+		if parent == nil {
+			return fmt.Errorf("parent token not found")
+		}
+	}
+
+	// Batch tokens have no accessor since there is no index to map it
+	entry.Accessor = ""
+
+	enc, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to encode entry: %v", err)
+	}
+
+	ciphertext, err := ts.barrier.Encrypt(batchTokenEncryptionKey, enc)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt entry: %v", err)
+	}
+
+	entry.ID = batchTokenPrefix + base64.RawURLEncoding.EncodeToString(ciphertext)
+	return nil
 }
 
 // Store is used to store an updated token entry without writing the
@@ -634,8 +851,10 @@ func (ts *TokenStore) store(entry *TokenEntry) error {
 }
 
 // storeCommon handles the actual storage of an entry, possibly generating
-// secondary indexes
-func (ts *TokenStore) storeCommon(entry *TokenEntry, writeSecondary bool) error {
+// secondary indexes. The entry, its indexes and the given operations are
+// written in a single transaction, so that a failure can't leave some of
+// them behind.
+func (ts *TokenStore) storeCommon(entry *TokenEntry, writeSecondary bool, txns ...*TxnEntry) error {
 	saltedId := ts.SaltID(entry.ID)
 
 	// Marshal the entry
@@ -646,8 +865,9 @@ func (ts *TokenStore) storeCommon(entry *TokenEntry, writeSecondary bool) error
 
 	if writeSecondary {
 		// Write the secondary index if necessary. This is done before the
-		// primary index because we'd rather have a dangling pointer with
-		// a missing primary instead of missing the parent index and potentially
+		// primary index because, if the backend doesn't support
+		// transactions, we'd rather have a dangling pointer with a missing
+		// primary instead of missing the parent index and potentially
 		// escaping the revocation chain.
 		if entry.Parent != "" {
 			// Ensure the parent exists
@@ -660,18 +880,19 @@ func (ts *TokenStore) storeCommon(entry *TokenEntry, writeSecondary bool) error
 			}
 
 			// Create the index entry
-			path := parentPrefix + ts.SaltID(entry.Parent) + "/" + saltedId
-			le := &logical.StorageEntry{Key: path}
-			if err := ts.view.Put(le); err != nil {
-				return fmt.Errorf("failed to persist entry: %v", err)
-			}
+			txns = append(txns, &TxnEntry{
+				Operation: physical.PutOperation,
+				Entry:     &Entry{Key: parentPrefix + ts.SaltID(entry.Parent) + "/" + saltedId},
+			})
 		}
 	}
 
 	// Write the primary ID
-	path := lookupPrefix + saltedId
-	le := &logical.StorageEntry{Key: path, Value: enc}
-	if err := ts.view.Put(le); err != nil {
+	txns = append(txns, &TxnEntry{
+		Operation: physical.PutOperation,
+		Entry:     &Entry{Key: lookupPrefix + saltedId, Value: enc},
+	})
+	if err := ts.view.Transaction(txns); err != nil {
 		return fmt.Errorf("failed to persist entry: %v", err)
 	}
 	return nil
@@ -764,6 +985,12 @@ func (ts *TokenStore) Lookup(id string) (*TokenEntry, error) {
 		return nil, fmt.Errorf("cannot lookup blank token")
 	}
 
+	// Batch tokens carry their own entry and are never persisted, so
+	// there is no need to grab a lock
+	if strings.HasPrefix(id, batchTokenPrefix) {
+		return ts.lookupBatch(id)
+	}
+
 	lock := ts.getTokenLock(id)
 	lock.RLock()
 	defer lock.RUnlock()
@@ -771,6 +998,48 @@ func (ts *TokenStore) Lookup(id string) (*TokenEntry, error) {
 	return ts.lookupSalted(ts.SaltID(id))
 }
 
+// lookupBatch is used to decode a batch token. A batch token that cannot be
+// decrypted, has expired or whose parent has been revoked is treated as not
+// found.
+func (ts *TokenStore) lookupBatch(id string) (*TokenEntry, error) {
+	ciphertext, err := base64.RawURLEncoding.DecodeString(strings.TrimPrefix(id, batchTokenPrefix))
+	if err != nil {
+		return nil, nil
+	}
+
+	plaintext, err := ts.barrier.Decrypt(batchTokenEncryptionKey, ciphertext)
+	if err != nil {
+		if err == ErrBarrierSealed {
+			return nil, err
+		}
+		return nil, nil
+	}
+
+	entry := new(TokenEntry)
+	if err := jsonutil.DecodeJSON(plaintext, entry); err != nil {
+		return nil, fmt.Errorf("failed to decode entry: %v", err)
+	}
+	entry.ID = id
+
+	// Batch tokens cannot be revoked, so expiration is enforced here
+	if time.Now().After(entry.expirationTime()) {
+		return nil, nil
+	}
+
+	// If the parent has been revoked, so has this token
+	if entry.Parent != "" {
+		parent, err := ts.Lookup(entry.Parent)
+		if err != nil {
+			return nil, fmt.Errorf("failed to lookup parent: %v", err)
+		}
+		if parent == nil {
+			return nil, nil
+		}
+	}
+
+	return entry, nil
+}
+
 // lookupSlated is used to find a token given its salted ID
 func (ts *TokenStore) lookupSalted(saltedId string) (*TokenEntry, error) {
 	// Lookup token
@@ -806,6 +1075,9 @@ func (ts *TokenStore) Revoke(id string) error {
 	if id == "" {
 		return fmt.Errorf("cannot revoke blank token")
 	}
+	if strings.HasPrefix(id, batchTokenPrefix) {
+		return fmt.Errorf("batch tokens cannot be revoked")
+	}
 
 	return ts.revokeSalted(ts.SaltID(id))
 }
@@ -819,26 +1091,28 @@ func (ts *TokenStore) revokeSalted(saltedId string) error {
 		return err
 	}
 
-	// Nuke the primary key first
-	path := lookupPrefix + saltedId
-	if ts.view.Delete(path); err != nil {
-		return fmt.Errorf("failed to delete entry: %v", err)
+	// Nuke the primary key first, along with the secondary index and the
+	// accessor index if any, in a single transaction
+	txns := []*TxnEntry{
+		&TxnEntry{
+			Operation: physical.DeleteOperation,
+			Entry:     &Entry{Key: lookupPrefix + saltedId},
+		},
 	}
-
-	// Clear the secondary index if any
 	if entry != nil && entry.Parent != "" {
-		path := parentPrefix + ts.SaltID(entry.Parent) + "/" + saltedId
-		if ts.view.Delete(path); err != nil {
-			return fmt.Errorf("failed to delete entry: %v", err)
-		}
+		txns = append(txns, &TxnEntry{
+			Operation: physical.DeleteOperation,
+			Entry:     &Entry{Key: parentPrefix + ts.SaltID(entry.Parent) + "/" + saltedId},
+		})
 	}
-
-	// Clear the accessor index if any
 	if entry != nil && entry.Accessor != "" {
-		path := accessorPrefix + ts.SaltID(entry.Accessor)
-		if ts.view.Delete(path); err != nil {
-			return fmt.Errorf("failed to delete entry: %v", err)
-		}
+		txns = append(txns, &TxnEntry{
+			Operation: physical.DeleteOperation,
+			Entry:     &Entry{Key: accessorPrefix + ts.SaltID(entry.Accessor)},
+		})
+	}
+	if err := ts.view.Transaction(txns); err != nil {
+		return fmt.Errorf("failed to delete entry: %v", err)
 	}
 
 	// Revoke all secrets under this token
@@ -865,6 +1139,9 @@ func (ts *TokenStore) RevokeTree(id string) error {
 	if id == "" {
 		return fmt.Errorf("cannot revoke blank token")
 	}
+	if strings.HasPrefix(id, batchTokenPrefix) {
+		return fmt.Errorf("batch tokens cannot be revoked")
+	}
 
 	// Get the salted ID
 	saltedId := ts.SaltID(id)
@@ -905,8 +1182,12 @@ func (ts *TokenStore) revokeTreeSalted(saltedId string) error {
 // handleCreateAgainstRole handles the auth/token/create path for a role
 func (ts *TokenStore) handleCreateAgainstRole(
 	req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	ns := ts.requestNamespace(req)
+	if ns == nil {
+		return logical.ErrorResponse("namespace not found"), logical.ErrInvalidRequest
+	}
 	name := d.Get("role_name").(string)
-	roleEntry, err := ts.tokenStoreRole(name)
+	roleEntry, err := ts.tokenStoreRole(ns, name)
 	if err != nil {
 		return nil, err
 	}
@@ -1024,6 +1305,9 @@ func (ts *TokenStore) handleUpdateRevokeAccessor(req *logical.Request, data *fra
 	if err != nil {
 		return nil, err
 	}
+	if err := ts.checkTokenNamespace(req, aEntry.TokenID); err != nil {
+		return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+	}
 
 	// Revoke the token and its children
 	if err := ts.RevokeTree(aEntry.TokenID); err != nil {
@@ -1039,6 +1323,58 @@ func (ts *TokenStore) handleUpdateRevokeAccessor(req *logical.Request, data *fra
 	return nil, nil
 }
 
+// handleUpdateRenewAccessor handles the auth/token/renew-accessor path for
+// renewing the token associated with the accessor
+func (ts *TokenStore) handleUpdateRenewAccessor(req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	var urlaccessor bool
+	accessor := data.Get("accessor").(string)
+	if accessor == "" {
+		accessor = data.Get("urlaccessor").(string)
+		if accessor == "" {
+			return nil, &StatusBadRequest{Err: "missing accessor"}
+		}
+		urlaccessor = true
+	}
+
+	aEntry, err := ts.lookupByAccessor(accessor)
+	if err != nil {
+		return nil, err
+	}
+
+	// Prepare the field data required for a renew call
+	d := &framework.FieldData{
+		Raw: map[string]interface{}{
+			"token":     aEntry.TokenID,
+			"increment": data.Get("increment"),
+		},
+		Schema: map[string]*framework.FieldSchema{
+			"token": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: "Token to renew",
+			},
+			"increment": &framework.FieldSchema{
+				Type:        framework.TypeDurationSecond,
+				Description: "The desired increment in seconds to the token expiration",
+			},
+		},
+	}
+	resp, err := ts.handleRenew(req, d)
+	if err != nil || resp == nil || resp.IsError() {
+		return resp, err
+	}
+
+	// Remove the token ID from the response
+	if resp.Auth != nil {
+		resp.Auth.ClientToken = ""
+	}
+
+	if urlaccessor {
+		resp.AddWarning(`Using an accessor in the path is unsafe as the accessor can be logged in many places. Please use POST or PUT with the accessor passed in via the "accessor" parameter.`)
+	}
+
+	return resp, nil
+}
+
 // handleCreate handles the auth/token/create path for creation of new orphan
 // tokens
 func (ts *TokenStore) handleCreateOrphan(
@@ -1069,8 +1405,27 @@ func (ts *TokenStore) handleCreateCommon(
 			logical.ErrInvalidRequest
 	}
 
+	// Batch tokens cannot be revoked, so they could never revoke their
+	// children either
+	if parent.Type == logical.TokenTypeBatch {
+		return logical.ErrorResponse("batch tokens cannot create more tokens"),
+			logical.ErrInvalidRequest
+	}
+
 	// Check if the client token has sudo/root privileges for the requested path
-	isSudo := ts.System().SudoPrivilege(req.MountPoint+req.Path, req.ClientToken)
+	isSudo := ts.System().SudoPrivilege(req.Namespace+req.MountPoint+req.Path, req.ClientToken)
+
+	// The token is created in the namespace of the request. The policies of
+	// the parent are the ones of its namespace, so creating a token in
+	// another namespace requires root or sudo privileges.
+	ns := ts.requestNamespace(req)
+	if ns == nil {
+		return logical.ErrorResponse("namespace not found"), logical.ErrInvalidRequest
+	}
+	if ns.ID != parent.NamespaceID && !isSudo {
+		return logical.ErrorResponse("root or sudo privileges required to create a token in another namespace"),
+			logical.ErrInvalidRequest
+	}
 
 	// Read and parse the fields
 	var data struct {
@@ -1086,6 +1441,8 @@ func (ts *TokenStore) handleCreateCommon(
 		DisplayName     string `mapstructure:"display_name"`
 		NumUses         int    `mapstructure:"num_uses"`
 		Period          string
+		BoundCIDRs      string `mapstructure:"bound_cidrs"`
+		Type            string
 	}
 	if err := mapstructure.WeakDecode(req.Data, &data); err != nil {
 		return logical.ErrorResponse(fmt.Sprintf(
@@ -1098,9 +1455,12 @@ func (ts *TokenStore) handleCreateCommon(
 			logical.ErrInvalidRequest
 	}
 
-	// Setup the token entry
+	// Setup the token entry. Child tokens belong to the same entity as
+	// their parent.
 	te := TokenEntry{
-		Parent: req.ClientToken,
+		Parent:      req.ClientToken,
+		EntityID:    parent.EntityID,
+		NamespaceID: ns.ID,
 
 		// The mount point is always the same since we have only one token
 		// store; using req.MountPoint causes trouble in tests since they don't
@@ -1298,9 +1658,47 @@ func (ts *TokenStore) handleCreateCommon(
 		te.TTL = dur
 	}
 
+	if data.BoundCIDRs != "" {
+		boundCIDRs, err := cidrutil.ParseCIDRList(data.BoundCIDRs, ",")
+		if err != nil {
+			return logical.ErrorResponse(fmt.Sprintf("invalid bound_cidrs: %v", err)), logical.ErrInvalidRequest
+		}
+		te.BoundCIDRs = boundCIDRs
+	}
+
+	switch data.Type {
+	case "", logical.TokenTypeService:
+	case logical.TokenTypeBatch:
+		te.Type = logical.TokenTypeBatch
+	default:
+		return logical.ErrorResponse(fmt.Sprintf("invalid token type %q", data.Type)), logical.ErrInvalidRequest
+	}
+
 	// Set the lesser period/explicit max TTL if defined both in arguments and in role
+	var roleExplicitMaxTTL bool
 	if role != nil {
+		if len(role.BoundCIDRs) > 0 {
+			if len(te.BoundCIDRs) > 0 {
+				resp.AddWarning("Bound CIDRs specified both during creation call and in role; using the values from the role")
+			}
+			te.BoundCIDRs = role.BoundCIDRs
+		}
+
+		// Roles written before token types existed create service tokens
+		roleTokenType := role.TokenType
+		if roleTokenType == "" {
+			roleTokenType = logical.TokenTypeService
+		}
+		if data.Type != "" && data.Type != roleTokenType {
+			resp.AddWarning("Token type specified both during creation call and in role; using the value from the role")
+		}
+		te.Type = ""
+		if roleTokenType == logical.TokenTypeBatch {
+			te.Type = logical.TokenTypeBatch
+		}
+
 		if role.ExplicitMaxTTL != 0 {
+			roleExplicitMaxTTL = true
 			switch {
 			case te.ExplicitMaxTTL == 0:
 				te.ExplicitMaxTTL = role.ExplicitMaxTTL
@@ -1334,17 +1732,25 @@ func (ts *TokenStore) handleCreateCommon(
 			te.TTL = sysView.DefaultLeaseTTL()
 		}
 
-		// Limit the lease duration
-		if te.TTL > sysView.MaxLeaseTTL() && sysView.MaxLeaseTTL() != 0 {
-			te.TTL = sysView.MaxLeaseTTL()
+		// Limit the lease duration; an explicit max TTL set on the role takes
+		// the place of the system/mount max
+		maxTTL := sysView.MaxLeaseTTL()
+		if roleExplicitMaxTTL {
+			maxTTL = te.ExplicitMaxTTL
+		}
+		if te.TTL > maxTTL && maxTTL != 0 {
+			te.TTL = maxTTL
 		}
 	}
 
 	// Run some bounding checks if the explicit max TTL is set; we do not check
 	// period as it's defined to escape the max TTL
 	if te.ExplicitMaxTTL > 0 {
-		// Limit the lease duration, except for periodic tokens -- in that case the explicit max limits the period, which itself can escape normal max
-		if sysView.MaxLeaseTTL() != 0 && te.ExplicitMaxTTL > sysView.MaxLeaseTTL() && periodToUse == 0 {
+		// Limit the lease duration, except for periodic tokens -- in that case
+		// the explicit max limits the period, which itself can escape normal
+		// max -- and for explicit max TTLs set on a role, which are allowed to
+		// exceed the system/mount max
+		if sysView.MaxLeaseTTL() != 0 && te.ExplicitMaxTTL > sysView.MaxLeaseTTL() && periodToUse == 0 && !roleExplicitMaxTTL {
 			resp.AddWarning(fmt.Sprintf(
 				"Explicit max TTL of %d seconds is greater than system/mount allowed value; value is being capped to %d seconds",
 				int64(te.ExplicitMaxTTL.Seconds()), int64(sysView.MaxLeaseTTL().Seconds())))
@@ -1373,6 +1779,22 @@ func (ts *TokenStore) handleCreateCommon(
 		renewable = false
 	}
 
+	if te.Type == logical.TokenTypeBatch {
+		switch {
+		case te.ID != "":
+			return logical.ErrorResponse("batch tokens cannot have a custom ID"), logical.ErrInvalidRequest
+		case te.NumUses > 0:
+			return logical.ErrorResponse("batch tokens cannot have a limited number of uses"), logical.ErrInvalidRequest
+		case periodToUse > 0:
+			return logical.ErrorResponse("batch tokens cannot be periodic"), logical.ErrInvalidRequest
+		case te.TTL == 0:
+			return logical.ErrorResponse("batch tokens must have a TTL"), logical.ErrInvalidRequest
+		}
+
+		// Batch tokens are not tracked by the expiration manager
+		renewable = false
+	}
+
 	// Create the token
 	if err := ts.create(&te); err != nil {
 		return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
@@ -1389,11 +1811,13 @@ func (ts *TokenStore) handleCreateCommon(
 		},
 		ClientToken: te.ID,
 		Accessor:    te.Accessor,
+		BoundCIDRs:  te.BoundCIDRs,
+		EntityID:    te.EntityID,
 	}
 
 	if ts.policyLookupFunc != nil {
 		for _, p := range te.Policies {
-			policy, err := ts.policyLookupFunc(p)
+			policy, err := ts.policyLookupFunc(ns, p)
 			if err != nil {
 				return logical.ErrorResponse(fmt.Sprintf("could not look up policy %s", p)), nil
 			}
@@ -1433,6 +1857,10 @@ func (ts *TokenStore) handleRevokeTree(
 		urltoken = true
 	}
 
+	if err := ts.checkTokenNamespace(req, id); err != nil {
+		return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+	}
+
 	// Revoke the token and its children
 	if err := ts.RevokeTree(id); err != nil {
 		return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
@@ -1472,13 +1900,17 @@ func (ts *TokenStore) handleRevokeOrphan(
 	}
 
 	// Check if the client token has sudo/root privileges for the requested path
-	isSudo := ts.System().SudoPrivilege(req.MountPoint+req.Path, req.ClientToken)
+	isSudo := ts.System().SudoPrivilege(req.Namespace+req.MountPoint+req.Path, req.ClientToken)
 
 	if !isSudo {
 		return logical.ErrorResponse("root or sudo privileges required to revoke and orphan"),
 			logical.ErrInvalidRequest
 	}
 
+	if err := ts.checkTokenNamespace(req, id); err != nil {
+		return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+	}
+
 	// Revoke and orphan
 	if err := ts.Revoke(id); err != nil {
 		return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
@@ -1529,6 +1961,13 @@ func (ts *TokenStore) handleLookup(
 		return logical.ErrorResponse("bad token"), logical.ErrPermissionDenied
 	}
 
+	// Only the tokens of the namespace and the ones beneath it can be
+	// looked up from a namespace
+	ns := ts.entryNamespace(out)
+	if id != req.ClientToken && (ns == nil || !strings.HasPrefix(ns.Path, req.Namespace)) {
+		return logical.ErrorResponse("bad token"), logical.ErrPermissionDenied
+	}
+
 	// Generate a response. We purposely omit the parent reference otherwise
 	// you could escalate your privileges.
 	resp := &logical.Response{
@@ -1545,6 +1984,7 @@ func (ts *TokenStore) handleLookup(
 			"creation_ttl":     int64(out.TTL.Seconds()),
 			"ttl":              int64(0),
 			"explicit_max_ttl": int64(out.ExplicitMaxTTL.Seconds()),
+			"type":             logical.TokenTypeService,
 		},
 	}
 
@@ -1558,6 +1998,27 @@ func (ts *TokenStore) handleLookup(
 	if out.Period != 0 {
 		resp.Data["period"] = int64(out.Period.Seconds())
 	}
+	if len(out.BoundCIDRs) > 0 {
+		resp.Data["bound_cidrs"] = out.BoundCIDRs
+	}
+	if out.EntityID != "" {
+		resp.Data["entity_id"] = out.EntityID
+	}
+	if ns != nil && ns.Path != "" {
+		resp.Data["namespace_path"] = ns.Path
+	}
+
+	// Batch tokens are not tracked by the expiration manager, so the TTL
+	// is derived from the entry itself
+	if out.Type == logical.TokenTypeBatch {
+		resp.Data["type"] = logical.TokenTypeBatch
+		resp.Data["ttl"] = int64(out.expirationTime().Sub(time.Now().Round(time.Second)).Seconds())
+		resp.Data["renewable"] = false
+		if urltoken {
+			resp.AddWarning(`Using a token in the path is unsafe as the token can be logged in many places. Please use POST or PUT with the token passed in via the "token" parameter.`)
+		}
+		return resp, nil
+	}
 
 	// Fetch the last renewal time
 	leaseTimes, err := ts.expiration.FetchLeaseTimesByToken(out.Path, out.ID)
@@ -1619,6 +2080,15 @@ func (ts *TokenStore) handleRenew(
 	if te == nil {
 		return logical.ErrorResponse("token not found"), logical.ErrInvalidRequest
 	}
+	if id != req.ClientToken {
+		if ns := ts.entryNamespace(te); ns == nil || !strings.HasPrefix(ns.Path, req.Namespace) {
+			return logical.ErrorResponse("token not found"), logical.ErrInvalidRequest
+		}
+	}
+
+	if te.Type == logical.TokenTypeBatch {
+		return logical.ErrorResponse("batch tokens cannot be renewed"), logical.ErrInvalidRequest
+	}
 
 	// Renew the token and its children
 	resp, err := ts.expiration.RenewToken(req, te.Path, te.ID, increment)
@@ -1652,7 +2122,12 @@ func (ts *TokenStore) authRenew(
 		return nil, fmt.Errorf("no token entry found during lookup")
 	}
 
-	f := framework.LeaseExtend(req.Auth.Increment, te.ExplicitMaxTTL, ts.System())
+	// An explicit max TTL was validated when the token was created, so it is
+	// not limited by the current system/mount max
+	f := framework.LeaseExtend(req.Auth.Increment, 0, ts.System())
+	if te.ExplicitMaxTTL > 0 {
+		f = framework.LeaseExtendExplicitMax(req.Auth.Increment, te.ExplicitMaxTTL, ts.System())
+	}
 
 	// If (te/role).Period is not zero, this is a periodic token. The TTL for a
 	// periodic token is always the same (the period value). It is not subject
@@ -1684,7 +2159,11 @@ func (ts *TokenStore) authRenew(
 		return f(req, d)
 	}
 
-	role, err := ts.tokenStoreRole(te.Role)
+	ns := ts.entryNamespace(te)
+	if ns == nil {
+		return nil, fmt.Errorf("namespace of the token not found, not renewing")
+	}
+	role, err := ts.tokenStoreRole(ns, te.Role)
 	if err != nil {
 		return nil, fmt.Errorf("error looking up role %s: %s", te.Role, err)
 	}
@@ -1716,8 +2195,8 @@ func (ts *TokenStore) authRenew(
 	return f(req, d)
 }
 
-func (ts *TokenStore) tokenStoreRole(name string) (*tsRoleEntry, error) {
-	entry, err := ts.view.Get(fmt.Sprintf("%s%s", rolesPrefix, name))
+func (ts *TokenStore) tokenStoreRole(ns *Namespace, name string) (*tsRoleEntry, error) {
+	entry, err := ts.view.Get(fmt.Sprintf("%s%s", namespaceRolePrefix(ns), name))
 	if err != nil {
 		return nil, err
 	}
@@ -1735,14 +2214,19 @@ func (ts *TokenStore) tokenStoreRole(name string) (*tsRoleEntry, error) {
 
 func (ts *TokenStore) tokenStoreRoleList(
 	req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
-	entries, err := ts.view.List(rolesPrefix)
+	ns := ts.requestNamespace(req)
+	if ns == nil {
+		return logical.ErrorResponse("namespace not found"), logical.ErrInvalidRequest
+	}
+	prefix := namespaceRolePrefix(ns)
+	entries, err := ts.view.List(prefix)
 	if err != nil {
 		return nil, err
 	}
 
 	ret := make([]string, len(entries))
 	for i, entry := range entries {
-		ret[i] = strings.TrimPrefix(entry, rolesPrefix)
+		ret[i] = strings.TrimPrefix(entry, prefix)
 	}
 
 	return logical.ListResponse(ret), nil
@@ -1750,7 +2234,11 @@ func (ts *TokenStore) tokenStoreRoleList(
 
 func (ts *TokenStore) tokenStoreRoleDelete(
 	req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
-	err := ts.view.Delete(fmt.Sprintf("%s%s", rolesPrefix, data.Get("role_name").(string)))
+	ns := ts.requestNamespace(req)
+	if ns == nil {
+		return logical.ErrorResponse("namespace not found"), logical.ErrInvalidRequest
+	}
+	err := ts.view.Delete(fmt.Sprintf("%s%s", namespaceRolePrefix(ns), data.Get("role_name").(string)))
 	if err != nil {
 		return nil, err
 	}
@@ -1760,7 +2248,11 @@ func (ts *TokenStore) tokenStoreRoleDelete(
 
 func (ts *TokenStore) tokenStoreRoleRead(
 	req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
-	role, err := ts.tokenStoreRole(data.Get("role_name").(string))
+	ns := ts.requestNamespace(req)
+	if ns == nil {
+		return logical.ErrorResponse("namespace not found"), logical.ErrInvalidRequest
+	}
+	role, err := ts.tokenStoreRole(ns, data.Get("role_name").(string))
 	if err != nil {
 		return nil, err
 	}
@@ -1778,9 +2270,16 @@ func (ts *TokenStore) tokenStoreRoleRead(
 			"orphan":              role.Orphan,
 			"path_suffix":         role.PathSuffix,
 			"renewable":           role.Renewable,
+			"bound_cidrs":         role.BoundCIDRs,
+			"token_type":          role.TokenType,
 		},
 	}
 
+	// Roles written before token types existed create service tokens
+	if role.TokenType == "" {
+		resp.Data["token_type"] = logical.TokenTypeService
+	}
+
 	return resp, nil
 }
 
@@ -1789,7 +2288,11 @@ func (ts *TokenStore) tokenStoreRoleExistenceCheck(req *logical.Request, data *f
 	if name == "" {
 		return false, fmt.Errorf("role name cannot be empty")
 	}
-	role, err := ts.tokenStoreRole(name)
+	ns := ts.requestNamespace(req)
+	if ns == nil {
+		return false, fmt.Errorf("namespace not found")
+	}
+	role, err := ts.tokenStoreRole(ns, name)
 	if err != nil {
 		return false, err
 	}
@@ -1803,7 +2306,11 @@ func (ts *TokenStore) tokenStoreRoleCreateUpdate(
 	if name == "" {
 		return logical.ErrorResponse("role name cannot be empty"), nil
 	}
-	entry, err := ts.tokenStoreRole(name)
+	ns := ts.requestNamespace(req)
+	if ns == nil {
+		return logical.ErrorResponse("namespace not found"), logical.ErrInvalidRequest
+	}
+	entry, err := ts.tokenStoreRole(ns, name)
 	if err != nil {
 		return nil, err
 	}
@@ -1849,17 +2356,8 @@ func (ts *TokenStore) tokenStoreRoleCreateUpdate(
 	} else if req.Operation == logical.CreateOperation {
 		entry.ExplicitMaxTTL = time.Second * time.Duration(data.Get("explicit_max_ttl").(int))
 	}
-	if entry.ExplicitMaxTTL != 0 {
-		sysView := ts.System()
-
-		if sysView.MaxLeaseTTL() != time.Duration(0) && entry.ExplicitMaxTTL > sysView.MaxLeaseTTL() {
-			if resp == nil {
-				resp = &logical.Response{}
-			}
-			resp.AddWarning(fmt.Sprintf(
-				"Given explicit max TTL of %d is greater than system/mount allowed value of %d seconds; until this is fixed attempting to create tokens against this role will result in an error",
-				entry.ExplicitMaxTTL.Seconds(), sysView.MaxLeaseTTL().Seconds()))
-		}
+	if entry.ExplicitMaxTTL < 0 {
+		return logical.ErrorResponse("explicit_max_ttl must be positive"), logical.ErrInvalidRequest
 	}
 
 	pathSuffixInt, ok := data.GetOk("path_suffix")
@@ -1878,6 +2376,32 @@ func (ts *TokenStore) tokenStoreRoleCreateUpdate(
 		entry.PathSuffix = data.Get("path_suffix").(string)
 	}
 
+	boundCIDRsRaw, ok := data.GetOk("bound_cidrs")
+	if ok {
+		boundCIDRs, err := cidrutil.ParseCIDRList(boundCIDRsRaw.(string), ",")
+		if err != nil {
+			return logical.ErrorResponse(fmt.Sprintf("invalid bound_cidrs: %v", err)), nil
+		}
+		entry.BoundCIDRs = boundCIDRs
+	}
+
+	tokenTypeStr, ok := data.GetOk("token_type")
+	if ok {
+		entry.TokenType = tokenTypeStr.(string)
+	} else if req.Operation == logical.CreateOperation {
+		entry.TokenType = data.Get("token_type").(string)
+	}
+	switch entry.TokenType {
+	case "", logical.TokenTypeService:
+		entry.TokenType = logical.TokenTypeService
+	case logical.TokenTypeBatch:
+		if entry.Period != 0 {
+			return logical.ErrorResponse("batch tokens cannot be periodic"), nil
+		}
+	default:
+		return logical.ErrorResponse(fmt.Sprintf("invalid token_type %q", entry.TokenType)), nil
+	}
+
 	allowedPoliciesStr, ok := data.GetOk("allowed_policies")
 	if ok {
 		entry.AllowedPolicies = policyutil.SanitizePolicies(strings.Split(allowedPoliciesStr.(string), ","), false)
@@ -1900,7 +2424,7 @@ func (ts *TokenStore) tokenStoreRoleCreateUpdate(
 	}
 
 	// Store it
-	jsonEntry, err := logical.StorageEntryJSON(fmt.Sprintf("%s%s", rolesPrefix, name), entry)
+	jsonEntry, err := logical.StorageEntryJSON(fmt.Sprintf("%s%s", namespaceRolePrefix(ns), name), entry)
 	if err != nil {
 		return nil, err
 	}
@@ -1928,6 +2452,7 @@ as revocation of tokens. The tokens are renewable if associated with a lease.`
 	tokenRevokeSelfHelp      = `This endpoint will delete the token used to call it and all of its child tokens.`
 	tokenRevokeOrphanHelp    = `This endpoint will delete the token and orphan its child tokens.`
 	tokenRenewHelp           = `This endpoint will renew the given token and prevent expiration.`
+	tokenRenewAccessorHelp   = `This endpoint will renew the token associated with the accessor and prevent expiration.`
 	tokenRenewSelfHelp       = `This endpoint will renew the token used to call it and prevent expiration.`
 	tokenAllowedPoliciesHelp = `If set, tokens can be created with any subset of the policies in this
 list, rather than the normal semantics of tokens being a subset of the
@@ -1952,14 +2477,25 @@ of the 'revoke-prefix' endpoint later on.
 The given suffix must match the regular
 expression.`
 	tokenExplicitMaxTTLHelp = `If set, tokens created via this role
-carry an explicit maximum TTL. During renewal,
-the current maximum TTL values of the role
-and the mount are not checked for changes,
-and any updates to these values will have
-no effect on the token being renewed.`
+carry an explicit maximum TTL. This value takes
+the place of the system/mount maximum TTL and
+may exceed it. During renewal, the current
+maximum TTL values of the role and the mount
+are not checked for changes, and any updates
+to these values will have no effect on the
+token being renewed.`
 	tokenRenewableHelp = `Tokens created via this role will be
 renewable or not according to this value.
 Defaults to "true".`
+	tokenBoundCIDRsHelp = `Comma separated list of CIDR blocks. If set,
+tokens created via this role can only be
+used from network addresses within one of
+these blocks.`
+	tokenTypeHelp = `The type of token to create via this role;
+either "service" or "batch". Batch tokens are
+not persisted and cannot be renewed, revoked
+or used to create child tokens. Defaults to
+"service".`
 	tokenListAccessorsHelp = `List token accessors, which can then be
 be used to iterate and discover their properities
 or revoke them. Because this can be used to
@@ -7,6 +7,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/hashicorp/errwrap"
 	"github.com/hashicorp/vault/logical"
 )
 
@@ -142,8 +143,8 @@ func TestTokenStore_HandleRequest_ListAccessors(t *testing.T) {
 	if len(keys) != len(testKeys) {
 		t.Fatalf("wrong number of accessors found")
 	}
-	if len(resp.Warnings()) != 0 {
-		t.Fatalf("got warnings:\n%#v", resp.Warnings())
+	if len(resp.Warnings) != 0 {
+		t.Fatalf("got warnings:\n%#v", resp.Warnings)
 	}
 
 	// Test upgrade from old struct method of accessor storage (of token id)
@@ -177,8 +178,8 @@ func TestTokenStore_HandleRequest_ListAccessors(t *testing.T) {
 	if len(keys) != len(testKeys) {
 		t.Fatalf("wrong number of accessors found")
 	}
-	if len(resp.Warnings()) != 0 {
-		t.Fatalf("got warnings:\n%#v", resp.Warnings())
+	if len(resp.Warnings) != 0 {
+		t.Fatalf("got warnings:\n%#v", resp.Warnings)
 	}
 
 	for _, accessor := range keys2 {
@@ -1180,6 +1181,7 @@ func TestTokenStore_HandleRequest_Lookup(t *testing.T) {
 		"creation_ttl":     int64(0),
 		"ttl":              int64(0),
 		"explicit_max_ttl": int64(0),
+		"type":             "service",
 	}
 
 	if resp.Data["creation_time"].(int64) == 0 {
@@ -1218,6 +1220,7 @@ func TestTokenStore_HandleRequest_Lookup(t *testing.T) {
 		"creation_ttl":     int64(3600),
 		"ttl":              int64(3600),
 		"explicit_max_ttl": int64(0),
+		"type":             "service",
 		"renewable":        true,
 	}
 
@@ -1260,6 +1263,7 @@ func TestTokenStore_HandleRequest_Lookup(t *testing.T) {
 		"creation_ttl":     int64(3600),
 		"ttl":              int64(3600),
 		"explicit_max_ttl": int64(0),
+		"type":             "service",
 		"renewable":        true,
 	}
 
@@ -1331,6 +1335,7 @@ func TestTokenStore_HandleRequest_LookupSelf(t *testing.T) {
 		"creation_ttl":     int64(0),
 		"ttl":              int64(0),
 		"explicit_max_ttl": int64(0),
+		"type":             "service",
 	}
 
 	if resp.Data["creation_time"].(int64) == 0 {
@@ -1392,6 +1397,64 @@ func TestTokenStore_HandleRequest_Renew(t *testing.T) {
 	}
 }
 
+func TestTokenStore_HandleRequest_RenewAccessor(t *testing.T) {
+	exp := mockExpiration(t)
+	ts := exp.tokenStore
+
+	// Create new token
+	root, err := ts.rootToken()
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Create a new token
+	auth := &logical.Auth{
+		ClientToken: root.ID,
+		LeaseOptions: logical.LeaseOptions{
+			TTL:       time.Hour,
+			Renewable: true,
+		},
+	}
+	err = exp.RegisterAuth("auth/token/root", auth)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Get the original expire time to compare
+	originalExpire := auth.ExpirationTime()
+
+	beforeRenew := time.Now()
+	req := logical.TestRequest(t, logical.UpdateOperation, "renew-accessor")
+	req.Data = map[string]interface{}{
+		"accessor":  root.Accessor,
+		"increment": "3600s",
+	}
+	resp, err := ts.HandleRequest(req)
+	if err != nil {
+		t.Fatalf("err: %v %v", err, resp)
+	}
+
+	// The token ID must not be returned
+	if resp.Auth.ClientToken != "" {
+		t.Fatalf("bad: %#v", resp.Auth)
+	}
+
+	// Get the new expire time
+	newExpire := resp.Auth.ExpirationTime()
+	if newExpire.Before(originalExpire) {
+		t.Fatalf("should expire later: %s %s", newExpire, originalExpire)
+	}
+	if newExpire.Before(beforeRenew.Add(time.Hour)) {
+		t.Fatalf("should have at least an hour: %s %s", newExpire, beforeRenew)
+	}
+
+	req.Data["accessor"] = "invalid"
+	resp, err = ts.HandleRequest(req)
+	if err == nil {
+		t.Fatalf("expected an error for an invalid accessor: %#v", resp)
+	}
+}
+
 func TestTokenStore_HandleRequest_RenewSelf(t *testing.T) {
 	exp := mockExpiration(t)
 	ts := exp.tokenStore
@@ -1488,6 +1551,8 @@ func TestTokenStore_RoleCRUD(t *testing.T) {
 		"path_suffix":         "happenin",
 		"explicit_max_ttl":    int64(0),
 		"renewable":           true,
+		"bound_cidrs":         []string(nil),
+		"token_type":          "service",
 	}
 
 	if !reflect.DeepEqual(expected, resp.Data) {
@@ -1532,6 +1597,8 @@ func TestTokenStore_RoleCRUD(t *testing.T) {
 		"path_suffix":         "happenin",
 		"explicit_max_ttl":    int64(0),
 		"renewable":           false,
+		"bound_cidrs":         []string(nil),
+		"token_type":          "service",
 	}
 
 	if !reflect.DeepEqual(expected, resp.Data) {
@@ -1569,6 +1636,8 @@ func TestTokenStore_RoleCRUD(t *testing.T) {
 		"path_suffix":         "happenin",
 		"period":              int64(0),
 		"renewable":           false,
+		"bound_cidrs":         []string(nil),
+		"token_type":          "service",
 	}
 
 	if !reflect.DeepEqual(expected, resp.Data) {
@@ -1869,6 +1938,259 @@ func TestTokenStore_RolePathSuffix(t *testing.T) {
 	}
 }
 
+func TestTokenStore_RoleBoundCIDRs(t *testing.T) {
+	c, ts, _, root := TestCoreWithTokenStore(t)
+
+	req := logical.TestRequest(t, logical.UpdateOperation, "roles/test")
+	req.ClientToken = root
+	req.Data = map[string]interface{}{
+		"bound_cidrs": "127.0.0.1/32,10.0.0.0/8",
+	}
+
+	resp, err := ts.HandleRequest(req)
+	if err != nil {
+		t.Fatalf("err: %v %v", err, resp)
+	}
+	if resp != nil {
+		t.Fatalf("expected a nil response")
+	}
+
+	req.Path = "create/test"
+	resp, err = ts.HandleRequest(req)
+	if err != nil {
+		t.Fatalf("err: %v %v", err, resp)
+	}
+	if resp.Auth.ClientToken == "" {
+		t.Fatalf("bad: %#v", resp)
+	}
+
+	out, err := ts.Lookup(resp.Auth.ClientToken)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !reflect.DeepEqual(out.BoundCIDRs, []string{"10.0.0.0/8", "127.0.0.1/32"}) {
+		t.Fatalf("bad: %#v", out.BoundCIDRs)
+	}
+
+	// Requests from within the bound blocks are allowed
+	req = logical.TestRequest(t, logical.ReadOperation, "auth/token/lookup-self")
+	req.ClientToken = out.ID
+	req.Connection = &logical.Connection{RemoteAddr: "10.1.2.3"}
+	resp, err = c.HandleRequest(req)
+	if err != nil {
+		t.Fatalf("err: %v %v", err, resp)
+	}
+	if resp.Data["bound_cidrs"] == nil {
+		t.Fatalf("expected bound_cidrs in lookup response: %#v", resp.Data)
+	}
+
+	// Requests from anywhere else are denied
+	req.Connection = &logical.Connection{RemoteAddr: "192.168.1.1"}
+	resp, err = c.HandleRequest(req)
+	if err == nil || !errwrap.Contains(err, logical.ErrPermissionDenied.Error()) {
+		t.Fatalf("expected permission denied, got: %v %v", err, resp)
+	}
+
+	// As are requests without any connection information
+	req.Connection = nil
+	resp, err = c.HandleRequest(req)
+	if err == nil || !errwrap.Contains(err, logical.ErrPermissionDenied.Error()) {
+		t.Fatalf("expected permission denied, got: %v %v", err, resp)
+	}
+
+	// Invalid blocks are rejected
+	req = logical.TestRequest(t, logical.UpdateOperation, "roles/test")
+	req.ClientToken = root
+	req.Data = map[string]interface{}{
+		"bound_cidrs": "127.0.0.1",
+	}
+	resp, err = ts.HandleRequest(req)
+	if err != nil {
+		t.Fatalf("err: %v %v", err, resp)
+	}
+	if resp == nil || !resp.IsError() {
+		t.Fatalf("expected an error response")
+	}
+}
+
+func TestTokenStore_BatchTokens(t *testing.T) {
+	c, ts, _, root := TestCoreWithTokenStore(t)
+
+	// Create a separate parent so revoking it can be checked below
+	parent := "batchparent"
+	testMakeToken(t, ts, root, parent, "", []string{"root"})
+
+	req := logical.TestRequest(t, logical.UpdateOperation, "auth/token/create")
+	req.ClientToken = parent
+	req.Data = map[string]interface{}{
+		"type":     "batch",
+		"ttl":      "1h",
+		"policies": []string{"default"},
+	}
+	resp, err := c.HandleRequest(req)
+	if err != nil {
+		t.Fatalf("err: %v %v", err, resp)
+	}
+	batch := resp.Auth.ClientToken
+	if !strings.HasPrefix(batch, batchTokenPrefix) {
+		t.Fatalf("bad: %#v", resp.Auth)
+	}
+	if resp.Auth.Accessor != "" || resp.Auth.Renewable {
+		t.Fatalf("bad: %#v", resp.Auth)
+	}
+
+	// Nothing should have been persisted for the token
+	keys, err := ts.view.List(lookupPrefix)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	for _, key := range keys {
+		if key == ts.SaltID(batch) {
+			t.Fatalf("batch token was persisted")
+		}
+	}
+
+	req = logical.TestRequest(t, logical.ReadOperation, "auth/token/lookup-self")
+	req.ClientToken = batch
+	resp, err = c.HandleRequest(req)
+	if err != nil {
+		t.Fatalf("err: %v %v", err, resp)
+	}
+	if resp.Data["type"] != "batch" || resp.Data["renewable"] != false {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+	if ttl := resp.Data["ttl"].(int64); ttl <= 0 || ttl > 3600 {
+		t.Fatalf("bad: ttl: %d", ttl)
+	}
+	if resp.Data["orphan"] != false {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+
+	// Batch tokens cannot create tokens, be renewed or be revoked
+	for _, path := range []string{"auth/token/create", "auth/token/renew-self", "auth/token/revoke-self"} {
+		req = logical.TestRequest(t, logical.UpdateOperation, path)
+		req.ClientToken = batch
+		resp, err = c.HandleRequest(req)
+		if err == nil {
+			t.Fatalf("expected error for %s: %v", path, resp)
+		}
+	}
+
+	// Nor can they use the cubbyhole
+	req = logical.TestRequest(t, logical.UpdateOperation, "cubbyhole/foo")
+	req.ClientToken = batch
+	req.Data = map[string]interface{}{
+		"foo": "bar",
+	}
+	resp, err = c.HandleRequest(req)
+	if err == nil || !errwrap.Contains(err, logical.ErrPermissionDenied.Error()) {
+		t.Fatalf("expected permission denied, got: %v %v", err, resp)
+	}
+
+	// A tampered token is not found
+	out, err := ts.Lookup(batch[:len(batch)-2] + "AA")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out != nil {
+		t.Fatalf("bad: %#v", out)
+	}
+
+	// Revoking the parent invalidates the batch token
+	if err := ts.RevokeTree(parent); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	out, err = ts.Lookup(batch)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out != nil {
+		t.Fatalf("bad: %#v", out)
+	}
+
+	// Expired batch tokens are not found
+	te := &TokenEntry{
+		Path:         "auth/token/create",
+		Policies:     []string{"default"},
+		CreationTime: time.Now().Add(-2 * time.Hour).Unix(),
+		TTL:          time.Hour,
+		Type:         logical.TokenTypeBatch,
+	}
+	if err := ts.create(te); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	out, err = ts.Lookup(te.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out != nil {
+		t.Fatalf("bad: %#v", out)
+	}
+}
+
+func TestTokenStore_RoleTokenType(t *testing.T) {
+	c, ts, _, root := TestCoreWithTokenStore(t)
+
+	req := logical.TestRequest(t, logical.UpdateOperation, "roles/test")
+	req.ClientToken = root
+	req.Data = map[string]interface{}{
+		"token_type": "batch",
+		"period":     300,
+	}
+	resp, err := ts.HandleRequest(req)
+	if err != nil {
+		t.Fatalf("err: %v %v", err, resp)
+	}
+	if resp == nil || !resp.IsError() {
+		t.Fatalf("expected an error response for a periodic batch role")
+	}
+
+	req.Data = map[string]interface{}{
+		"token_type": "bogus",
+	}
+	resp, err = ts.HandleRequest(req)
+	if err != nil {
+		t.Fatalf("err: %v %v", err, resp)
+	}
+	if resp == nil || !resp.IsError() {
+		t.Fatalf("expected an error response for an invalid token type")
+	}
+
+	req.Data = map[string]interface{}{
+		"token_type": "batch",
+	}
+	resp, err = ts.HandleRequest(req)
+	if err != nil {
+		t.Fatalf("err: %v %v", err, resp)
+	}
+
+	req = logical.TestRequest(t, logical.UpdateOperation, "auth/token/create/test")
+	req.ClientToken = root
+	req.Data = map[string]interface{}{
+		"type":     "service",
+		"ttl":      "1h",
+		"policies": []string{"default"},
+	}
+	resp, err = c.HandleRequest(req)
+	if err != nil {
+		t.Fatalf("err: %v %v", err, resp)
+	}
+	if !strings.HasPrefix(resp.Auth.ClientToken, batchTokenPrefix) {
+		t.Fatalf("expected a batch token: %#v", resp.Auth)
+	}
+	if len(resp.Warnings) == 0 {
+		t.Fatalf("expected a warning about the overridden token type")
+	}
+
+	out, err := ts.Lookup(resp.Auth.ClientToken)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out == nil || out.Type != logical.TokenTypeBatch || out.Role != "test" {
+		t.Fatalf("bad: %#v", out)
+	}
+}
+
 func TestTokenStore_RolePeriod(t *testing.T) {
 	core, _, _, root := TestCoreWithTokenStore(t)
 
@@ -2033,7 +2355,8 @@ func TestTokenStore_RoleExplicitMaxTTL(t *testing.T) {
 	// Note: these requests are sent to Core since Core handles registration
 	// with the expiration manager and we need the storage to be consistent
 
-	// Make sure we can't make it larger than the system/mount max; we should get a warning on role write and an error on token creation
+	// An explicit max TTL set on a role takes the place of the system/mount
+	// max and can thus exceed it
 	req := logical.TestRequest(t, logical.UpdateOperation, "auth/token/roles/test")
 	req.ClientToken = root
 	req.Data = map[string]interface{}{
@@ -2044,17 +2367,50 @@ func TestTokenStore_RoleExplicitMaxTTL(t *testing.T) {
 	if err != nil {
 		t.Fatalf("err: %v %v", err, resp)
 	}
-	if resp == nil {
-		t.Fatalf("expected a warning")
+	if resp != nil {
+		t.Fatalf("expected a nil response")
 	}
 
-	req.Operation = logical.UpdateOperation
 	req.Path = "auth/token/create/test"
+	req.Data = map[string]interface{}{
+		"policies": []string{"default"},
+		"ttl":      "50h",
+	}
+	resp, err = core.HandleRequest(req)
+	if err != nil {
+		t.Fatalf("err: %v %v", err, resp)
+	}
+	if len(resp.Warnings) != 0 {
+		t.Fatalf("unexpected warnings: %v", resp.Warnings)
+	}
+	if resp.Auth.TTL != 50*time.Hour {
+		t.Fatalf("bad: %v", resp.Auth.TTL)
+	}
+
+	req.Path = "auth/token/lookup-self"
+	req.ClientToken = resp.Auth.ClientToken
+	req.Operation = logical.ReadOperation
+	req.Data = nil
+	resp, err = core.HandleRequest(req)
+	if err != nil {
+		t.Fatalf("err: %v %v", err, resp)
+	}
+	if resp.Data["explicit_max_ttl"].(int64) != int64((100 * time.Hour).Seconds()) {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+
+	// Without a role, the explicit max TTL is capped to the system/mount max
+	req = logical.TestRequest(t, logical.UpdateOperation, "auth/token/create")
+	req.ClientToken = root
+	req.Data = map[string]interface{}{
+		"policies":         []string{"default"},
+		"explicit_max_ttl": "100h",
+	}
 	resp, err = core.HandleRequest(req)
 	if err != nil {
-		t.Fatalf("expected an error")
+		t.Fatalf("err: %v %v", err, resp)
 	}
-	if len(resp.Warnings()) == 0 {
+	if len(resp.Warnings) == 0 {
 		t.Fatalf("expected a warning")
 	}
 